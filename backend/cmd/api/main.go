@@ -13,14 +13,18 @@ import (
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
+	"github.com/visitas/backend/internal/ai"
 	"github.com/visitas/backend/internal/config"
 	"github.com/visitas/backend/internal/handlers"
 	"github.com/visitas/backend/internal/middleware"
 	"github.com/visitas/backend/internal/repository"
 	"github.com/visitas/backend/internal/services"
 	"github.com/visitas/backend/pkg/auth"
+	"github.com/visitas/backend/pkg/blobstore"
 	"github.com/visitas/backend/pkg/encryption"
+	"github.com/visitas/backend/pkg/jobqueue"
 	"github.com/visitas/backend/pkg/logger"
+	"github.com/visitas/backend/pkg/search"
 )
 
 func main() {
@@ -89,6 +93,39 @@ func main() {
 		logger.Warn("KMS configuration not set - My Number encryption will not be available")
 	}
 
+	// Initialize medical record attachment storage; attachment data keys are wrapped by
+	// Cloud KMS when available, otherwise by the local master key
+	var attachmentBlobs blobstore.BlobStore
+	var attachmentGCS *blobstore.GCSStore
+	var attachmentEnvelope *encryption.EnvelopeEncryptor
+	if kmsEncryptor != nil {
+		attachmentEnvelope = encryption.NewEnvelopeEncryptor(kmsEncryptor)
+	} else if cfg.AttachmentMasterKey != "" {
+		wrapper, err := encryption.NewLocalKeyWrapper(cfg.AttachmentMasterKey)
+		if err != nil {
+			logger.Fatal("Invalid ATTACHMENT_MASTER_KEY", err)
+		}
+		attachmentEnvelope = encryption.NewEnvelopeEncryptor(wrapper)
+	} else {
+		logger.Warn("Neither KMS nor ATTACHMENT_MASTER_KEY is set - medical record attachments will not be available")
+	}
+	if attachmentEnvelope != nil {
+		if cfg.AttachmentStorage == "gcs" {
+			attachmentGCS, err = blobstore.NewGCSStore(ctx, cfg.AttachmentGCSBucket)
+			if err != nil {
+				logger.Fatal("Failed to initialize attachment storage", err)
+			}
+			attachmentBlobs = attachmentGCS
+		} else {
+			localStore, err := blobstore.NewLocalStore(cfg.AttachmentLocalDir)
+			if err != nil {
+				logger.Fatal("Failed to initialize attachment storage", err)
+			}
+			attachmentBlobs = localStore
+		}
+	}
+	sealedBlobs := blobstore.NewSealedStore(attachmentBlobs, attachmentEnvelope)
+
 	// Initialize repositories
 	patientRepo := repository.NewPatientRepository(spannerRepo)
 	identifierRepo := repository.NewIdentifierRepository(spannerRepo, kmsEncryptor)
@@ -105,21 +142,177 @@ func main() {
 	acpRecordRepo := repository.NewACPRecordRepository(spannerRepo)
 	medicalRecordRepo := repository.NewMedicalRecordRepository(spannerRepo)
 	medicalRecordTemplateRepo := repository.NewMedicalRecordTemplateRepository(spannerRepo)
+	staffMemberRepo := repository.NewStaffMemberRepository(spannerRepo)
+	vehicleRepo := repository.NewVehicleRepository(spannerRepo)
+	logisticsLocationRepo := repository.NewLogisticsLocationRepository(spannerRepo)
+	routeOptimizationJobRepo := repository.NewRouteOptimizationJobRepository(spannerRepo)
+	visitScheduleRecurrenceRepo := repository.NewVisitScheduleRecurrenceRepository(spannerRepo)
+	calendarFeedTokenRepo := repository.NewCalendarFeedTokenRepository(spannerRepo)
+	staffLocationRepo := repository.NewStaffLocationRepository(spannerRepo)
+	medicalRecordAttachmentRepo := repository.NewMedicalRecordAttachmentRepository(spannerRepo)
+	voiceSOAPJobRepo := repository.NewVoiceSOAPJobRepository(spannerRepo)
+	statutoryDocumentRepo := repository.NewStatutoryDocumentRepository(spannerRepo)
+	referralLetterRepo := repository.NewReferralLetterRepository(spannerRepo)
+	inboundReferralRepo := repository.NewInboundReferralRepository(spannerRepo)
 
 	// Initialize services
-	patientService := services.NewPatientService(patientRepo, assignmentRepo, auditRepo)
+	patientService := services.NewPatientService(patientRepo, assignmentRepo, auditRepo, staffMemberRepo)
 	identifierService := services.NewIdentifierService(identifierRepo, patientRepo, auditRepo)
 	medicalConditionService := services.NewMedicalConditionService(medicalConditionRepo, patientRepo)
 	allergyIntoleranceService := services.NewAllergyIntoleranceService(allergyIntoleranceRepo, patientRepo)
 	socialProfileService := services.NewSocialProfileService(socialProfileRepo, patientRepo)
 	coverageService := services.NewCoverageService(coverageRepo, patientRepo)
-	visitScheduleService := services.NewVisitScheduleService(visitScheduleRepo, patientRepo)
+	visitScheduleService := services.NewVisitScheduleService(visitScheduleRepo, patientRepo, vehicleRepo, visitScheduleRecurrenceRepo, medicalRecordRepo)
+	visitScheduleRecurrenceService := services.NewVisitScheduleRecurrenceService(visitScheduleRecurrenceRepo, visitScheduleRepo, patientRepo, staffMemberRepo, vehicleRepo)
 	clinicalObservationService := services.NewClinicalObservationService(clinicalObservationRepo, patientRepo)
-	carePlanService := services.NewCarePlanService(carePlanRepo, patientRepo)
+	carePlanScheduleService := services.NewCarePlanScheduleService(visitScheduleRecurrenceService, visitScheduleRecurrenceRepo, visitScheduleRepo)
+	carePlanService := services.NewCarePlanService(carePlanRepo, patientRepo, clinicalObservationRepo, carePlanScheduleService)
 	medicationOrderService := services.NewMedicationOrderService(medicationOrderRepo, patientRepo)
 	acpRecordService := services.NewACPRecordService(acpRecordRepo, patientRepo)
 	medicalRecordTemplateService := services.NewMedicalRecordTemplateService(medicalRecordTemplateRepo)
-	medicalRecordService := services.NewMedicalRecordService(medicalRecordRepo, patientRepo, medicalRecordTemplateRepo)
+	medicalRecordService := services.NewMedicalRecordService(medicalRecordRepo, patientRepo, medicalRecordTemplateRepo, clinicalObservationRepo)
+	medicalRecordAttachmentService := services.NewMedicalRecordAttachmentService(medicalRecordAttachmentRepo, medicalRecordRepo, patientRepo, auditRepo, sealedBlobs)
+	statutoryDocumentService := services.NewStatutoryDocumentService(
+		statutoryDocumentRepo, patientRepo, medicalConditionRepo, medicationOrderRepo, coverageRepo,
+		clinicalObservationRepo, staffMemberRepo, auditRepo, sealedBlobs, // Issued PDFs are stored like attachments
+	)
+	referralLetterService := services.NewReferralLetterService(
+		referralLetterRepo, patientRepo, medicalRecordRepo, medicalConditionRepo, medicationOrderRepo,
+		allergyIntoleranceRepo, staffMemberRepo, auditRepo, sealedBlobs,
+	)
+	inboundReferralService := services.NewInboundReferralService(
+		inboundReferralRepo, patientRepo, auditRepo, medicalConditionService, allergyIntoleranceService, medicationOrderService,
+		sealedBlobs,
+	)
+	// SOAP content search runs on an in-process index, brought up to date before each search
+	medicalRecordSearchService := services.NewMedicalRecordSearchService(medicalRecordRepo, patientRepo, assignmentRepo, auditRepo, search.NewMemoryIndex())
+	staffMemberService := services.NewStaffMemberService(staffMemberRepo)
+	vehicleService := services.NewVehicleService(vehicleRepo)
+	logisticsLocationService := services.NewLogisticsLocationService(logisticsLocationRepo)
+	staffScheduleService := services.NewStaffScheduleService(visitScheduleRepo, patientRepo, allergyIntoleranceRepo, acpRecordRepo, auditRepo)
+	calendarFeedService := services.NewCalendarFeedService(calendarFeedTokenRepo, visitScheduleRepo, patientRepo, cfg.CalendarFeedSigningKey)
+	emergencyInsertionService := services.NewEmergencyInsertionService(routeOptimizationJobRepo, visitScheduleRepo, patientRepo, staffMemberRepo)
+	staffLocationService := services.NewStaffLocationService(staffLocationRepo, staffMemberRepo, visitScheduleRepo, patientRepo, time.Duration(cfg.LocationPingRetentionHours)*time.Hour)
+	visitVerificationService := services.NewVisitVerificationService(visitScheduleRepo, patientRepo, medicalRecordRepo, float64(cfg.GeofenceRadiusMeters))
+
+	// Background worker pool for route optimization (keeps large runs off the request timeout)
+	routeJobQueue := jobqueue.New(jobqueue.Options{
+		Workers:     2,
+		Capacity:    100,
+		MaxAttempts: 3,
+		BaseBackoff: 5 * time.Second,
+		JobTimeout:  5 * time.Minute,
+	})
+	routeOptimizationService := services.NewRouteOptimizationService(
+		routeOptimizationJobRepo, visitScheduleRepo, patientRepo, logisticsLocationRepo, routeJobQueue,
+		services.NewLocalRouteOptimizer(),
+		services.NewGoogleRouteOptimizer(nil), // No Route Optimization client wired yet; engine "google" reports unavailable
+	)
+
+	// Voice-to-SOAP drafts run on their own queue; recordings are stored like attachments
+	voiceJobQueue := jobqueue.New(jobqueue.Options{
+		Workers:     2,
+		Capacity:    50,
+		MaxAttempts: 3,
+		BaseBackoff: 10 * time.Second,
+		JobTimeout:  10 * time.Minute,
+	})
+	var transcriber ai.Transcriber
+	var structurer ai.SOAPStructurer
+	switch cfg.AIProvider {
+	case "stub":
+		logger.Warn("AI_PROVIDER=stub - voice-to-SOAP drafts use the deterministic local stub, not real transcription")
+		transcriber = ai.NewStubTranscriber("")
+		structurer = ai.NewStubStructurer()
+	default:
+		logger.Warn("AI_PROVIDER not set - voice-to-SOAP drafts will not be available")
+	}
+	voiceSOAPService := services.NewVoiceSOAPService(voiceSOAPJobRepo, patientRepo, medicalRecordService, sealedBlobs, voiceJobQueue, transcriber, structurer)
+
+	// Finish care plan syncs that failed on save and keep recurring visits materialized
+	// over the rolling horizon
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(6 * time.Hour)
+		defer ticker.Stop()
+		for {
+			if err := carePlanService.ResyncSchedules(backgroundCtx); err != nil {
+				logger.Warn("Failed to resync care plan visit schedules", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			if err := visitScheduleRecurrenceService.ExtendHorizons(backgroundCtx); err != nil {
+				logger.Warn("Failed to extend recurring visit schedules", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			select {
+			case <-backgroundCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	// Fail route and voice SOAP jobs left open by an instance that stopped without recording them
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for {
+			if err := routeOptimizationService.FailStaleJobs(backgroundCtx, 30*time.Minute); err != nil {
+				logger.Warn("Failed to clean up stale route optimization jobs", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			if err := voiceSOAPService.FailStaleJobs(backgroundCtx, time.Hour); err != nil {
+				logger.Warn("Failed to clean up stale voice SOAP jobs", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			select {
+			case <-backgroundCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	// Purge location pings past the retention period
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			if err := staffLocationService.PurgeExpiredPings(backgroundCtx); err != nil {
+				logger.Warn("Failed to purge expired location pings", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			select {
+			case <-backgroundCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	// Build the medical record search index at startup and keep it close to current, so
+	// a search only has to index the last few changes
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for {
+			if err := medicalRecordSearchService.Refresh(backgroundCtx); err != nil {
+				logger.Warn("Failed to refresh medical record search index", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			select {
+			case <-backgroundCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
 
 	// Initialize middleware
 	auditMiddleware := middleware.NewAuditLoggerMiddleware(auditRepo)
@@ -131,13 +324,29 @@ func main() {
 	coverageHandler := handlers.NewCoverageHandler(coverageService)
 	medicalConditionHandler := handlers.NewMedicalConditionHandler(medicalConditionService)
 	allergyIntoleranceHandler := handlers.NewAllergyIntoleranceHandler(allergyIntoleranceService)
-	visitScheduleHandler := handlers.NewVisitScheduleHandler(visitScheduleService)
+	visitScheduleHandler := handlers.NewVisitScheduleHandler(visitScheduleService, visitScheduleRecurrenceService)
+	visitScheduleRecurrenceHandler := handlers.NewVisitScheduleRecurrenceHandler(visitScheduleRecurrenceService)
 	clinicalObservationHandler := handlers.NewClinicalObservationHandler(clinicalObservationService)
 	carePlanHandler := handlers.NewCarePlanHandler(carePlanService)
 	medicationOrderHandler := handlers.NewMedicationOrderHandler(medicationOrderService)
 	acpRecordHandler := handlers.NewACPRecordHandler(acpRecordService)
 	medicalRecordHandler := handlers.NewMedicalRecordHandler(medicalRecordService)
+	medicalRecordAttachmentHandler := handlers.NewMedicalRecordAttachmentHandler(medicalRecordAttachmentService, int64(cfg.AttachmentMaxBytes))
+	voiceSOAPHandler := handlers.NewVoiceSOAPHandler(voiceSOAPService, int64(cfg.VoiceMaxBytes))
+	statutoryDocumentHandler := handlers.NewStatutoryDocumentHandler(statutoryDocumentService)
+	referralLetterHandler := handlers.NewReferralLetterHandler(referralLetterService)
+	inboundReferralHandler := handlers.NewInboundReferralHandler(inboundReferralService, int64(cfg.AttachmentMaxBytes))
+	medicalRecordSearchHandler := handlers.NewMedicalRecordSearchHandler(medicalRecordSearchService)
 	medicalRecordTemplateHandler := handlers.NewMedicalRecordTemplateHandler(medicalRecordTemplateService)
+	staffMemberHandler := handlers.NewStaffMemberHandler(staffMemberService)
+	vehicleHandler := handlers.NewVehicleHandler(vehicleService)
+	logisticsLocationHandler := handlers.NewLogisticsLocationHandler(logisticsLocationService)
+	routeOptimizationHandler := handlers.NewRouteOptimizationHandler(routeOptimizationService)
+	staffScheduleHandler := handlers.NewStaffScheduleHandler(staffScheduleService)
+	calendarFeedHandler := handlers.NewCalendarFeedHandler(calendarFeedService)
+	emergencyInsertionHandler := handlers.NewEmergencyInsertionHandler(emergencyInsertionService)
+	staffLocationHandler := handlers.NewStaffLocationHandler(staffLocationService)
+	visitVerificationHandler := handlers.NewVisitVerificationHandler(visitVerificationService)
 
 	// Setup router
 	r := chi.NewRouter()
@@ -166,6 +375,9 @@ func main() {
 		_, _ = w.Write([]byte(`{"status":"healthy"}`))
 	})
 
+	// Calendar (ICS) feeds (public; the signed, revocable token in the URL is the credential)
+	r.Get("/calendar/{token}", calendarFeedHandler.ServeFeed)
+
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
 		// Apply authentication middleware if Firebase is configured
@@ -241,7 +453,20 @@ func main() {
 			r.Put("/{id}", visitScheduleHandler.UpdateVisitSchedule) // Update visit schedule
 			r.Delete("/{id}", visitScheduleHandler.DeleteVisitSchedule) // Delete visit schedule
 			r.Post("/{id}/assign-staff", visitScheduleHandler.AssignStaff) // Assign staff to schedule
+			r.Post("/{id}/assign-vehicle", visitScheduleHandler.AssignVehicle) // Assign vehicle to schedule
 			r.Post("/{id}/status", visitScheduleHandler.UpdateStatus) // Update schedule status
+			r.Get("/{id}/status-history", visitScheduleHandler.GetStatusHistory) // Status transition history
+			r.Post("/{id}/check-in", visitVerificationHandler.CheckIn)   // Start visit with geofence-verified device location
+			r.Post("/{id}/check-out", visitVerificationHandler.CheckOut) // Complete visit with geofence-verified device location
+			r.Get("/{id}/checks", visitVerificationHandler.GetChecks)    // Check-in/check-out records
+		})
+
+		// Recurring visit schedule routes (protected)
+		r.Route("/patients/{patient_id}/schedule-recurrences", func(r chi.Router) {
+			r.Get("/", visitScheduleRecurrenceHandler.GetRecurrences)          // List recurrence rules
+			r.Post("/", visitScheduleRecurrenceHandler.CreateRecurrence)       // Create recurrence rule and materialize visits
+			r.Get("/{id}", visitScheduleRecurrenceHandler.GetRecurrence)       // Get recurrence rule by ID
+			r.Delete("/{id}", visitScheduleRecurrenceHandler.DeleteRecurrence) // Delete rule and its upcoming visits
 		})
 
 		// Clinical observation routes (protected)
@@ -261,6 +486,10 @@ func main() {
 			r.Post("/", carePlanHandler.CreateCarePlan)    // Create care plan
 			r.Get("/active", carePlanHandler.GetActiveCarePlans) // Get active care plans
 			r.Get("/{id}", carePlanHandler.GetCarePlan)    // Get care plan by ID
+			r.Get("/{id}/goals", carePlanHandler.GetGoalStatus) // Goal progress from observations
+			r.Get("/{id}/versions", carePlanHandler.GetVersions) // Version history
+			r.Get("/{id}/versions/diff", carePlanHandler.CompareVersions) // Diff two versions
+			r.Get("/{id}/versions/{version}", carePlanHandler.GetVersion) // Snapshot of one version
 			r.Put("/{id}", carePlanHandler.UpdateCarePlan) // Update care plan
 			r.Delete("/{id}", carePlanHandler.DeleteCarePlan) // Delete care plan
 		})
@@ -294,9 +523,61 @@ func main() {
 			r.Post("/from-template", medicalRecordHandler.CreateFromTemplate) // Create from template
 			r.Get("/{id}", medicalRecordHandler.GetMedicalRecord)         // Get medical record by ID
 			r.Put("/{id}", medicalRecordHandler.UpdateMedicalRecord)      // Update medical record
+			r.Get("/{id}/revisions", medicalRecordHandler.GetRevisions)   // Revision history
+			r.Get("/{id}/revisions/diff", medicalRecordHandler.CompareRevisions) // Diff two revisions
+			r.Get("/{id}/revisions/{revision}", medicalRecordHandler.GetRevision) // One revision
+			r.Post("/{id}/revisions/{revision}/restore", medicalRecordHandler.RestoreRevision) // Restore as new revision
+			r.Post("/{id}/sign", medicalRecordHandler.SignMedicalRecord)  // Sign and lock
+			r.Get("/{id}/verify", medicalRecordHandler.VerifySignature)   // Verify signature hash
+			r.Get("/{id}/addenda", medicalRecordHandler.GetAddenda)       // List signed addenda
+			r.Post("/{id}/addenda", medicalRecordHandler.CreateAddendum)  // Add signed addendum
+			r.Post("/{id}/ai-review", medicalRecordHandler.ReviewAISuggestions) // Mark AI-suggested sections reviewed
+			r.Get("/{id}/attachments", medicalRecordAttachmentHandler.ListAttachments)   // List attachments
+			r.Post("/{id}/attachments", medicalRecordAttachmentHandler.UploadAttachment) // Upload photo or scanned document
+			r.Get("/{id}/attachments/{attachment_id}/content", medicalRecordAttachmentHandler.GetAttachmentContent)     // Download (audited)
+			r.Get("/{id}/attachments/{attachment_id}/thumbnail", medicalRecordAttachmentHandler.GetAttachmentThumbnail) // Thumbnail (audited)
+			r.Delete("/{id}/attachments/{attachment_id}", medicalRecordAttachmentHandler.DeleteAttachment)              // Delete attachment
 			r.Delete("/{id}", medicalRecordHandler.DeleteMedicalRecord)   // Delete medical record
 		})
 
+		// Voice-to-SOAP draft routes (protected)
+		r.Route("/patients/{patient_id}/voice-drafts", func(r chi.Router) {
+			r.Get("/", voiceSOAPHandler.ListJobs)         // List recent voice draft jobs
+			r.Post("/", voiceSOAPHandler.SubmitRecording) // Upload a visit recording; drafts a record in the background
+			r.Get("/{id}", voiceSOAPHandler.GetJob)       // Poll job status and draft record ID
+		})
+
+		// Statutory document routes (protected)
+		r.Route("/patients/{patient_id}/documents", func(r chi.Router) {
+			r.Get("/", statutoryDocumentHandler.ListDocuments)          // List documents (filter by type, status, valid_on)
+			r.Post("/", statutoryDocumentHandler.IssueDocument)         // Issue 訪問看護指示書 / 居宅療養管理指導書
+			r.Get("/{id}", statutoryDocumentHandler.GetDocument)        // Document with field sources
+			r.Get("/{id}/pdf", statutoryDocumentHandler.GetDocumentPDF) // Download PDF (audited)
+		})
+
+		// Referral letter routes (protected)
+		r.Route("/patients/{patient_id}/referrals", func(r chi.Router) {
+			r.Get("/", referralLetterHandler.ListLetters)                        // List referral letters (filter by status)
+			r.Post("/", referralLetterHandler.CreateLetter)                      // Draft 診療情報提供書 from the patient summary
+			r.Get("/{id}", referralLetterHandler.GetLetter)                      // Get referral letter
+			r.Put("/{id}", referralLetterHandler.UpdateLetter)                   // Edit draft
+			r.Delete("/{id}", referralLetterHandler.DeleteLetter)                // Delete draft
+			r.Post("/{id}/send", referralLetterHandler.SendLetter)               // Render, store and mark sent (physicians)
+			r.Post("/{id}/acknowledge", referralLetterHandler.AcknowledgeLetter) // Record the recipient's reply
+			r.Get("/{id}/pdf", referralLetterHandler.GetLetterPDF)               // Download PDF, or draft preview (audited)
+		})
+
+		// Inbound referral routes (protected)
+		r.Route("/patients/{patient_id}/inbound-referrals", func(r chi.Router) {
+			r.Get("/", inboundReferralHandler.ListReferrals)                          // List received referrals (filter by status)
+			r.Post("/", inboundReferralHandler.ReceiveReferral)                       // Upload a received letter with items read from it
+			r.Get("/{id}", inboundReferralHandler.GetReferral)                        // Referral with review items
+			r.Get("/{id}/document", inboundReferralHandler.GetReferralDocument)       // Download letter (audited)
+			r.Post("/{id}/items", inboundReferralHandler.AddItems)                    // Add items for review
+			r.Post("/{id}/items/{item_id}/accept", inboundReferralHandler.AcceptItem) // Map into a condition, allergy or medication order
+			r.Post("/{id}/items/{item_id}/reject", inboundReferralHandler.RejectItem) // Reject item
+		})
+
 		// Medical record copy route (protected)
 		r.Route("/medical-records/{record_id}", func(r chi.Router) {
 			r.Post("/copy", medicalRecordHandler.CopyMedicalRecord) // Copy medical record
@@ -305,6 +586,9 @@ func main() {
 		// Draft records route (protected)
 		r.Get("/medical-records/drafts", medicalRecordHandler.GetDraftRecords) // Get my draft records
 
+		// Medical record search route (protected)
+		r.Get("/medical-records/search", medicalRecordSearchHandler.SearchMedicalRecords) // Search SOAP content of my patients
+
 		// Medical record template routes (protected)
 		r.Route("/medical-record-templates", func(r chi.Router) {
 			r.Get("/", medicalRecordTemplateHandler.ListTemplates)              // List templates
@@ -315,6 +599,73 @@ func main() {
 			r.Put("/{id}", medicalRecordTemplateHandler.UpdateTemplate)         // Update template
 			r.Delete("/{id}", medicalRecordTemplateHandler.DeleteTemplate)      // Delete template
 		})
+
+		// Staff directory routes (protected)
+		r.Route("/staff", func(r chi.Router) {
+			r.Get("/", staffMemberHandler.ListStaffMembers)                    // List staff (role/specialty/availability filters)
+			r.Post("/", staffMemberHandler.CreateStaffMember)                  // Create staff member
+			r.Get("/license-expiring", staffMemberHandler.GetExpiringLicenses) // Licenses expiring within N days
+			r.Get("/{id}", staffMemberHandler.GetStaffMember)                  // Get staff member by ID
+			r.Put("/{id}", staffMemberHandler.UpdateStaffMember)               // Update staff member
+			r.Delete("/{id}", staffMemberHandler.DeleteStaffMember)            // Delete staff member (soft delete)
+		})
+
+		// Vehicle fleet routes (protected)
+		r.Route("/vehicles", func(r chi.Router) {
+			r.Get("/", vehicleHandler.ListVehicles)                 // List vehicles
+			r.Post("/", vehicleHandler.CreateVehicle)               // Create vehicle
+			r.Get("/expiry-report", vehicleHandler.GetExpiryReport) // 車検/insurance/maintenance due within N days
+			r.Get("/{id}", vehicleHandler.GetVehicle)               // Get vehicle by ID
+			r.Put("/{id}", vehicleHandler.UpdateVehicle)            // Update vehicle
+			r.Delete("/{id}", vehicleHandler.DeleteVehicle)         // Delete vehicle (soft delete)
+		})
+
+		// Logistics location routes (protected)
+		r.Route("/locations", func(r chi.Router) {
+			r.Get("/", logisticsLocationHandler.ListLocations)                        // List locations
+			r.Post("/", logisticsLocationHandler.CreateLocation)                      // Create location
+			r.Get("/nearest", logisticsLocationHandler.FindNearest)                   // Nearest active location of a type
+			r.Post("/service-area-check", logisticsLocationHandler.CheckServiceArea) // Locations whose service area covers a point
+			r.Get("/{id}", logisticsLocationHandler.GetLocation)                      // Get location by ID
+			r.Put("/{id}", logisticsLocationHandler.UpdateLocation)                   // Update location
+			r.Delete("/{id}", logisticsLocationHandler.DeleteLocation)                // Delete location (soft delete)
+		})
+
+		// Route optimization routes (protected)
+		r.Route("/routes", func(r chi.Router) {
+			r.Post("/optimize", routeOptimizationHandler.OptimizeRoute) // Queue optimization of a day's visits (local or google engine)
+			r.Post("/emergency-insertion", emergencyInsertionHandler.InsertEmergencyVisit) // Propose (and optionally apply) the cheapest insertion of an emergency visit
+		})
+
+		// Route optimization job polling (protected)
+		r.Route("/route-jobs", func(r chi.Router) {
+			r.Get("/{id}", routeOptimizationHandler.GetJob)            // Poll job status and result
+			r.Post("/{id}/cancel", routeOptimizationHandler.CancelJob) // Cancel a pending or processing job
+		})
+
+		// Signed-in staff member routes (protected)
+		r.Route("/me", func(r chi.Router) {
+			r.Get("/schedule", staffScheduleHandler.GetMySchedule)       // Day timeline across all patients (?date=YYYY-MM-DD)
+			r.Post("/location", staffLocationHandler.ReportMyLocation) // Batched GPS pings; updates position and next-visit ETA
+		})
+
+		// Live visit tracking for the office dashboard (protected)
+		r.Route("/tracking", func(r chi.Router) {
+			r.Get("/running-late", staffLocationHandler.GetRunningLate) // Visits running late (?date=YYYY-MM-DD)
+			r.Get("/eta-stream", staffLocationHandler.StreamETAs)       // Server-sent ETA updates
+		})
+
+		// Visit verification reporting (protected)
+		r.Route("/visit-verifications", func(r chi.Router) {
+			r.Get("/unverified", visitVerificationHandler.GetUnverifiedReport) // Completed visits lacking verified check-in/out (?from=&to=)
+		})
+
+		// Calendar feed URL management (protected)
+		r.Route("/calendar-feeds", func(r chi.Router) {
+			r.Get("/", calendarFeedHandler.GetFeeds)          // List my active feed URLs
+			r.Post("/", calendarFeedHandler.CreateFeed)       // Issue a staff or patient feed URL
+			r.Delete("/{id}", calendarFeedHandler.RevokeFeed) // Revoke a feed URL
+		})
 	})
 
 	// Start server
@@ -352,6 +703,24 @@ func main() {
 		logger.Fatal("Server forced to shutdown", err)
 	}
 
+	// Stop background jobs; route jobs still running are recorded as failed (interrupted)
+	stopBackground()
+	if err := routeJobQueue.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("Route job queue did not stop cleanly", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := voiceJobQueue.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("Voice SOAP job queue did not stop cleanly", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if attachmentGCS != nil {
+		attachmentGCS.Close()
+	}
+
 	// Close KMS encryptor if initialized
 	if kmsEncryptor != nil {
 		kmsEncryptor.Close()
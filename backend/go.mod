@@ -3,8 +3,10 @@ module github.com/visitas/backend
 go 1.22
 
 require (
+	cloud.google.com/go v0.112.0
 	cloud.google.com/go/kms v1.15.5
 	cloud.google.com/go/spanner v1.56.0
+	cloud.google.com/go/storage v1.36.0
 	firebase.google.com/go/v4 v4.13.0
 	github.com/go-chi/chi/v5 v5.0.11
 	github.com/go-chi/cors v1.2.1
@@ -15,13 +17,11 @@ require (
 )
 
 require (
-	cloud.google.com/go v0.112.0 // indirect
 	cloud.google.com/go/compute v1.23.3 // indirect
 	cloud.google.com/go/compute/metadata v0.2.3 // indirect
 	cloud.google.com/go/firestore v1.14.0 // indirect
 	cloud.google.com/go/iam v1.1.5 // indirect
 	cloud.google.com/go/longrunning v0.5.4 // indirect
-	cloud.google.com/go/storage v1.36.0 // indirect
 	github.com/MicahParks/keyfunc v1.9.0 // indirect
 	github.com/census-instrumentation/opencensus-proto v0.4.1 // indirect
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
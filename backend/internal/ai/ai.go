@@ -0,0 +1,62 @@
+// Package ai defines the providers behind AI-assisted documentation: speech-to-text
+// transcription of visit recordings and structuring of a transcript into SOAP sections.
+// Implementations sit behind the Transcriber and SOAPStructurer interfaces so an LLM
+// provider can be swapped in without touching the record pipeline; the stubs in this
+// package are deterministic and need no external service.
+package ai
+
+import (
+	"context"
+	"errors"
+
+	"github.com/visitas/backend/internal/models"
+)
+
+// ErrEmptyTranscript is returned when a recording contains no recognizable speech
+var ErrEmptyTranscript = errors.New("transcript is empty")
+
+// Transcript is the text recognized in a recording
+type Transcript struct {
+	Text       string
+	Language   string
+	Segments   []Segment
+	Confidence float64 // 0-1, averaged over segments
+	Model      string
+}
+
+// Segment is one utterance of a transcript
+type Segment struct {
+	Text       string
+	Confidence float64
+}
+
+// SOAPDraft is SOAP content suggested from a transcript, with what each section was based on
+type SOAPDraft struct {
+	Content    models.SOAPContent
+	Sections   []SectionSuggestion // sections that received suggested text, in SOAP order
+	Confidence float64
+	Model      string
+}
+
+// SectionSuggestion describes the suggested text of one SOAP section
+type SectionSuggestion struct {
+	Section    string   // subjective, objective, assessment, plan
+	Evidence   []string // transcript segments the text was derived from
+	Confidence float64
+}
+
+// Transcriber converts recorded speech to text
+type Transcriber interface {
+	// Transcribe recognizes the speech in audio of the given content type and language
+	Transcribe(ctx context.Context, audio []byte, contentType, language string) (*Transcript, error)
+	// Model identifies the transcription model, recorded as provenance
+	Model() string
+}
+
+// SOAPStructurer organizes a transcript into SOAP sections
+type SOAPStructurer interface {
+	// Structure suggests SOAP content for a transcript
+	Structure(ctx context.Context, transcript *Transcript) (*SOAPDraft, error)
+	// Model identifies the structuring model, recorded as provenance
+	Model() string
+}
@@ -0,0 +1,298 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/visitas/backend/internal/models"
+)
+
+// StubTranscriberModel and StubStructurerModel identify the stub providers in provenance
+const (
+	StubTranscriberModel = "visitas-stub-transcriber-v1"
+	StubStructurerModel  = "visitas-stub-structurer-v1"
+)
+
+// stubSegmentConfidence is the confidence the stub transcriber reports for every segment
+const stubSegmentConfidence = 0.9
+
+// DefaultStubScript is the transcript the stub transcriber returns when given no script
+const DefaultStubScript = "本人より腰の痛みが続いていると訴えあり。痛みは10段階で4程度。" +
+	"家族によると夜間はよく眠れている。血圧138/86、脈拍72、体温36.9度、SpO2 97%。" +
+	"下肢に軽度の浮腫あり。腰痛は横ばいで全身状態は安定していると考えられる。" +
+	"鎮痛薬は現在の処方を継続。次回訪問は2週間後を予定。"
+
+// StubTranscriber is a deterministic Transcriber for tests and local development. It does
+// not decode the audio: every non-empty recording yields the same script.
+type StubTranscriber struct {
+	script string
+}
+
+// NewStubTranscriber creates a stub transcriber returning script, or DefaultStubScript
+// when script is empty
+func NewStubTranscriber(script string) *StubTranscriber {
+	if strings.TrimSpace(script) == "" {
+		script = DefaultStubScript
+	}
+	return &StubTranscriber{script: script}
+}
+
+// Model identifies the stub transcriber
+func (t *StubTranscriber) Model() string {
+	return StubTranscriberModel
+}
+
+// Transcribe returns the configured script split into sentence segments
+func (t *StubTranscriber) Transcribe(ctx context.Context, audio []byte, contentType, language string) (*Transcript, error) {
+	if len(audio) == 0 {
+		return nil, ErrEmptyTranscript
+	}
+	if language == "" {
+		language = "ja"
+	}
+
+	var segments []Segment
+	for _, sentence := range splitSentences(t.script) {
+		segments = append(segments, Segment{Text: sentence, Confidence: stubSegmentConfidence})
+	}
+	if len(segments) == 0 {
+		return nil, ErrEmptyTranscript
+	}
+
+	return &Transcript{
+		Text:       t.script,
+		Language:   language,
+		Segments:   segments,
+		Confidence: stubSegmentConfidence,
+		Model:      StubTranscriberModel,
+	}, nil
+}
+
+// StubStructurer is a deterministic, keyword-based SOAPStructurer for tests and local
+// development. Each transcript segment is assigned to at most one section.
+type StubStructurer struct{}
+
+// NewStubStructurer creates a stub structurer
+func NewStubStructurer() *StubStructurer {
+	return &StubStructurer{}
+}
+
+// Model identifies the stub structurer
+func (s *StubStructurer) Model() string {
+	return StubStructurerModel
+}
+
+var (
+	bloodPressurePattern   = regexp.MustCompile(`血圧\s*(\d{2,3})\s*[/／]\s*(\d{2,3})`)
+	heartRatePattern       = regexp.MustCompile(`(?:脈拍|心拍数?)\s*(\d{2,3})`)
+	spo2Pattern            = regexp.MustCompile(`(?:SpO2|ＳｐＯ２|酸素飽和度)\s*(\d{2,3})`)
+	temperaturePattern     = regexp.MustCompile(`体温\s*(\d{2}(?:\.\d)?)`)
+	respiratoryRatePattern = regexp.MustCompile(`呼吸数\s*(\d{1,2})`)
+	painScorePattern       = regexp.MustCompile(`10段階で\s*(\d{1,2})|NRS\s*(\d{1,2})|(\d{1,2})\s*[/／]\s*10`)
+)
+
+// Keywords that assign a segment to a section, checked in this order after vital signs
+var (
+	planKeywords       = []string{"継続", "予定", "次回", "処方", "指導", "検討", "経過観察", "中止", "変更"}
+	assessmentKeywords = []string{"考えられ", "評価", "疑い", "安定", "悪化", "改善", "横ばい"}
+	subjectiveKeywords = []string{"訴え", "痛み", "痛い", "本人", "家族", "眠れ", "食欲", "と話"}
+	examKeywords       = []string{"所見", "浮腫", "聴診", "創", "皮膚", "腫脹", "発赤", "呼吸音"}
+)
+
+// Structure assigns transcript segments to SOAP sections by keyword and extracts vital
+// signs and the pain score
+func (s *StubStructurer) Structure(ctx context.Context, transcript *Transcript) (*SOAPDraft, error) {
+	if transcript == nil || len(transcript.Segments) == 0 {
+		return nil, ErrEmptyTranscript
+	}
+
+	evidence := map[string][]Segment{}
+	var complaints, family, exam, impressions, plans, nextVisits []string
+	vitals := &models.VitalSigns{}
+	var pain *models.PainScale
+
+	for _, segment := range transcript.Segments {
+		text := segment.Text
+		switch {
+		case extractVitalSigns(text, vitals):
+			evidence["objective"] = append(evidence["objective"], segment)
+		case containsAny(text, planKeywords):
+			evidence["plan"] = append(evidence["plan"], segment)
+			if strings.Contains(text, "次回") {
+				nextVisits = append(nextVisits, text)
+			} else {
+				plans = append(plans, text)
+			}
+		case containsAny(text, assessmentKeywords):
+			evidence["assessment"] = append(evidence["assessment"], segment)
+			impressions = append(impressions, text)
+		case containsAny(text, subjectiveKeywords):
+			evidence["subjective"] = append(evidence["subjective"], segment)
+			if score, ok := extractPainScore(text); ok {
+				pain = &models.PainScale{Score: score}
+			}
+			if strings.Contains(text, "家族") {
+				family = append(family, text)
+			} else {
+				complaints = append(complaints, text)
+			}
+		case containsAny(text, examKeywords):
+			evidence["objective"] = append(evidence["objective"], segment)
+			exam = append(exam, text)
+		}
+	}
+
+	draft := &SOAPDraft{Model: StubStructurerModel}
+	if len(complaints) > 0 || len(family) > 0 || pain != nil {
+		subjective := &models.SubjectiveSection{
+			PatientNarrative:   strings.Join(complaints, ""),
+			FamilyObservations: strings.Join(family, ""),
+			PainScale:          pain,
+		}
+		if len(complaints) > 0 {
+			subjective.ChiefComplaint = complaints[0]
+		}
+		draft.Content.Subjective = subjective
+	}
+	if *vitals != (models.VitalSigns{}) || len(exam) > 0 {
+		objective := &models.ObjectiveSection{}
+		if *vitals != (models.VitalSigns{}) {
+			objective.VitalSigns = vitals
+		}
+		if len(exam) > 0 {
+			objective.PhysicalExam = map[string]string{"general": strings.Join(exam, "")}
+		}
+		draft.Content.Objective = objective
+	}
+	if len(impressions) > 0 {
+		draft.Content.Assessment = &models.AssessmentSection{ClinicalImpression: strings.Join(impressions, "")}
+	}
+	if len(plans) > 0 || len(nextVisits) > 0 {
+		plan := &models.PlanSection{}
+		if len(plans) > 0 {
+			plan.CareInstructions = map[string]interface{}{"notes": strings.Join(plans, "")}
+		}
+		if len(nextVisits) > 0 {
+			plan.NextVisit = &models.NextVisitPlan{Purpose: strings.Join(nextVisits, "")}
+		}
+		draft.Content.Plan = plan
+	}
+
+	var total float64
+	for _, section := range []string{"subjective", "objective", "assessment", "plan"} {
+		segments := evidence[section]
+		if len(segments) == 0 {
+			continue
+		}
+		suggestion := SectionSuggestion{Section: section}
+		var sum float64
+		for _, segment := range segments {
+			suggestion.Evidence = append(suggestion.Evidence, segment.Text)
+			sum += segment.Confidence
+		}
+		suggestion.Confidence = roundConfidence(sum / float64(len(segments)))
+		draft.Sections = append(draft.Sections, suggestion)
+		total += suggestion.Confidence
+	}
+	if len(draft.Sections) == 0 {
+		return nil, fmt.Errorf("no SOAP content could be derived from the transcript")
+	}
+	draft.Confidence = roundConfidence(total / float64(len(draft.Sections)))
+
+	return draft, nil
+}
+
+// extractVitalSigns fills the vital signs mentioned in text and reports whether any were
+func extractVitalSigns(text string, vitals *models.VitalSigns) bool {
+	found := false
+	if m := bloodPressurePattern.FindStringSubmatch(text); m != nil {
+		systolic, _ := strconv.Atoi(m[1])
+		diastolic, _ := strconv.Atoi(m[2])
+		vitals.BloodPressure = &models.BloodPressure{Systolic: systolic, Diastolic: diastolic, Unit: "mmHg"}
+		found = true
+	}
+	measurements := []struct {
+		pattern *regexp.Regexp
+		target  **models.Measurement
+		unit    string
+	}{
+		{heartRatePattern, &vitals.HeartRate, "/min"},
+		{spo2Pattern, &vitals.SPO2, "%"},
+		{temperaturePattern, &vitals.Temperature, "Cel"},
+		{respiratoryRatePattern, &vitals.RespiratoryRate, "/min"},
+	}
+	for _, m := range measurements {
+		match := m.pattern.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			continue
+		}
+		*m.target = &models.Measurement{Value: value, Unit: m.unit}
+		found = true
+	}
+	return found
+}
+
+// extractPainScore finds a 0-10 pain score in text
+func extractPainScore(text string) (int, bool) {
+	m := painScorePattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0, false
+	}
+	for _, group := range m[1:] {
+		if group == "" {
+			continue
+		}
+		score, err := strconv.Atoi(group)
+		if err != nil || score > 10 {
+			return 0, false
+		}
+		return score, true
+	}
+	return 0, false
+}
+
+// splitSentences splits text at Japanese and Latin sentence ends and line breaks
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			sentences = append(sentences, s)
+		}
+		current.Reset()
+	}
+	for _, r := range text {
+		switch r {
+		case '\n', '\r':
+			flush()
+		case '。', '！', '？':
+			current.WriteRune(r)
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return sentences
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// roundConfidence keeps confidences to two decimals
+func roundConfidence(c float64) float64 {
+	return float64(int(c*100+0.5)) / 100
+}
@@ -0,0 +1,74 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStubTranscriber(t *testing.T) {
+	transcriber := NewStubTranscriber("")
+
+	transcript, err := transcriber.Transcribe(context.Background(), []byte("RIFF....WAVE"), "audio/wave", "")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultStubScript, transcript.Text)
+	assert.Equal(t, "ja", transcript.Language)
+	assert.Equal(t, StubTranscriberModel, transcript.Model)
+	require.Len(t, transcript.Segments, 8)
+	assert.Equal(t, "本人より腰の痛みが続いていると訴えあり。", transcript.Segments[0].Text)
+
+	// Same input, same output
+	again, err := transcriber.Transcribe(context.Background(), []byte("RIFF....WAVE"), "audio/wave", "")
+	require.NoError(t, err)
+	assert.Equal(t, transcript, again)
+
+	_, err = transcriber.Transcribe(context.Background(), nil, "audio/wave", "ja")
+	assert.ErrorIs(t, err, ErrEmptyTranscript)
+}
+
+func TestStubStructurer(t *testing.T) {
+	transcript, err := NewStubTranscriber("").Transcribe(context.Background(), []byte("audio"), "audio/wave", "ja")
+	require.NoError(t, err)
+
+	draft, err := NewStubStructurer().Structure(context.Background(), transcript)
+	require.NoError(t, err)
+	assert.Equal(t, StubStructurerModel, draft.Model)
+	assert.Equal(t, 0.9, draft.Confidence)
+
+	subjective := draft.Content.Subjective
+	require.NotNil(t, subjective)
+	assert.Equal(t, "本人より腰の痛みが続いていると訴えあり。", subjective.ChiefComplaint)
+	assert.Equal(t, "家族によると夜間はよく眠れている。", subjective.FamilyObservations)
+	require.NotNil(t, subjective.PainScale)
+	assert.Equal(t, 4, subjective.PainScale.Score)
+
+	vitals := draft.Content.Objective.VitalSigns
+	require.NotNil(t, vitals)
+	assert.Equal(t, 138, vitals.BloodPressure.Systolic)
+	assert.Equal(t, 86, vitals.BloodPressure.Diastolic)
+	assert.Equal(t, 72.0, vitals.HeartRate.Value)
+	assert.Equal(t, 36.9, vitals.Temperature.Value)
+	assert.Equal(t, 97.0, vitals.SPO2.Value)
+	assert.Nil(t, vitals.RespiratoryRate)
+	assert.Equal(t, "下肢に軽度の浮腫あり。", draft.Content.Objective.PhysicalExam["general"])
+
+	assert.Equal(t, "腰痛は横ばいで全身状態は安定していると考えられる。", draft.Content.Assessment.ClinicalImpression)
+	assert.Equal(t, "鎮痛薬は現在の処方を継続。", draft.Content.Plan.CareInstructions["notes"])
+	assert.Equal(t, "次回訪問は2週間後を予定。", draft.Content.Plan.NextVisit.Purpose)
+
+	require.Len(t, draft.Sections, 4)
+	assert.Equal(t, "subjective", draft.Sections[0].Section)
+	assert.Len(t, draft.Sections[0].Evidence, 3)
+	assert.Equal(t, "objective", draft.Sections[1].Section)
+	assert.Equal(t, []string{"血圧138/86、脈拍72、体温36.9度、SpO2 97%。", "下肢に軽度の浮腫あり。"}, draft.Sections[1].Evidence)
+	assert.Equal(t, "plan", draft.Sections[3].Section)
+
+	t.Run("Nothing recognizable", func(t *testing.T) {
+		transcript, err := NewStubTranscriber("こんにちは。").Transcribe(context.Background(), []byte("audio"), "audio/wave", "ja")
+		require.NoError(t, err)
+		_, err = NewStubStructurer().Structure(context.Background(), transcript)
+		assert.Error(t, err)
+	})
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -27,6 +28,27 @@ type Config struct {
 
 	// CORS
 	AllowedOrigins []string
+
+	// Calendar (ICS) feeds; feed URLs are disabled when no signing key is set
+	CalendarFeedSigningKey string
+
+	// Staff location pings older than this are rejected and purged; 0 keeps them forever
+	LocationPingRetentionHours int
+
+	// Visit check-ins/check-outs farther than this from the patient's home are recorded as unverified
+	GeofenceRadiusMeters int
+
+	// Medical record attachments; disabled unless a key is available (Cloud KMS or the local master key)
+	AttachmentStorage   string // "local" or "gcs"
+	AttachmentLocalDir  string
+	AttachmentGCSBucket string
+	AttachmentMasterKey string // base64-encoded 32-byte key, used when Cloud KMS is not configured
+	AttachmentMaxBytes  int
+
+	// Voice-to-SOAP drafts; disabled unless an AI provider is set ("stub" is the only one so far)
+	// and attachment storage is available for the recordings
+	AIProvider    string
+	VoiceMaxBytes int
 }
 
 func Load() (*Config, error) {
@@ -42,6 +64,20 @@ func Load() (*Config, error) {
 		FirebaseConfigPath: getEnv("FIREBASE_CONFIG_PATH", ""),
 		GoogleMapsAPIKey:   getEnv("GOOGLE_MAPS_API_KEY", ""),
 		AllowedOrigins:     strings.Split(getEnv("ALLOWED_ORIGINS", "http://localhost:3000"), ","),
+
+		CalendarFeedSigningKey: getEnv("CALENDAR_FEED_SIGNING_KEY", ""),
+
+		LocationPingRetentionHours: getEnvInt("LOCATION_PING_RETENTION_HOURS", 72),
+		GeofenceRadiusMeters:       getEnvInt("GEOFENCE_RADIUS_METERS", 200),
+
+		AttachmentStorage:   getEnv("ATTACHMENT_STORAGE", "local"),
+		AttachmentLocalDir:  getEnv("ATTACHMENT_LOCAL_DIR", "./data/attachments"),
+		AttachmentGCSBucket: getEnv("ATTACHMENT_GCS_BUCKET", ""),
+		AttachmentMasterKey: getEnv("ATTACHMENT_MASTER_KEY", ""),
+		AttachmentMaxBytes:  getEnvInt("ATTACHMENT_MAX_BYTES", 20<<20),
+
+		AIProvider:    getEnv("AI_PROVIDER", ""),
+		VoiceMaxBytes: getEnvInt("VOICE_MAX_BYTES", 50<<20),
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -61,6 +97,27 @@ func (c *Config) Validate() error {
 	if c.SpannerDatabase == "" {
 		return fmt.Errorf("SPANNER_DATABASE is required")
 	}
+	if c.LocationPingRetentionHours < 0 {
+		return fmt.Errorf("LOCATION_PING_RETENTION_HOURS must not be negative")
+	}
+	if c.GeofenceRadiusMeters <= 0 {
+		return fmt.Errorf("GEOFENCE_RADIUS_METERS must be positive")
+	}
+	if c.AttachmentStorage != "local" && c.AttachmentStorage != "gcs" {
+		return fmt.Errorf("ATTACHMENT_STORAGE must be local or gcs")
+	}
+	if c.AttachmentStorage == "gcs" && c.AttachmentGCSBucket == "" {
+		return fmt.Errorf("ATTACHMENT_GCS_BUCKET is required when ATTACHMENT_STORAGE is gcs")
+	}
+	if c.AttachmentMaxBytes <= 0 {
+		return fmt.Errorf("ATTACHMENT_MAX_BYTES must be positive")
+	}
+	if c.AIProvider != "" && c.AIProvider != "stub" {
+		return fmt.Errorf("AI_PROVIDER must be empty or stub")
+	}
+	if c.VoiceMaxBytes <= 0 {
+		return fmt.Errorf("VOICE_MAX_BYTES must be positive")
+	}
 	return nil
 }
 
@@ -82,3 +139,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
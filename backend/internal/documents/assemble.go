@@ -0,0 +1,445 @@
+package documents
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/models"
+)
+
+// Sources are the patient records a document is assembled from
+type Sources struct {
+	Patient      *models.Patient
+	Conditions   []*models.MedicalCondition
+	Medications  []*models.MedicationOrder
+	Allergies    []*models.AllergyIntolerance
+	Coverages    []*models.PatientCoverage // active coverages by priority
+	Observations []*models.ClinicalObservation
+	Issuer       *models.StaffMember
+	IssuedOn     civil.Date
+	Location     *time.Location // for observation dates, UTC when nil
+}
+
+// Assemble fills the fields of a form. A value entered for a field replaces the derived
+// one, or precedes it for Append fields; either way the field lists the records its value
+// came from, with manual entry as a source of its own. Unknown keys and empty required
+// fields are rejected.
+func Assemble(form *Form, src *Sources, entered map[string]string) ([]models.DocumentField, error) {
+	known := make(map[string]bool, len(form.Fields))
+	for _, spec := range form.Fields {
+		known[spec.Key] = true
+	}
+	var unknown []string
+	for key := range entered {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown fields for %s: %s", form.Title, strings.Join(unknown, ", "))
+	}
+
+	manual := models.DocumentSource{Type: models.DocumentSourceManual}
+	fields := make([]models.DocumentField, 0, len(form.Fields))
+	var missing []string
+	for _, spec := range form.Fields {
+		var derived string
+		var sources []models.DocumentSource
+		if spec.Derive != nil {
+			derived, sources = spec.Derive(src)
+		}
+
+		field := models.DocumentField{Key: spec.Key, Label: spec.Label}
+		value := strings.TrimSpace(entered[spec.Key])
+		switch {
+		case value == "":
+			field.Value, field.Sources = derived, sources
+		case spec.Append && derived != "":
+			field.Value = value + "\n" + derived
+			field.Sources = append([]models.DocumentSource{manual}, sources...)
+		default:
+			field.Value, field.Sources = value, []models.DocumentSource{manual}
+		}
+
+		if spec.Required && field.Value == "" {
+			missing = append(missing, fmt.Sprintf("%s (%s)", spec.Key, spec.Label))
+		}
+		fields = append(fields, field)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required fields for %s: %s", form.Title, strings.Join(missing, ", "))
+	}
+
+	return fields, nil
+}
+
+func sourceOf(sourceType, id string, updatedAt time.Time) models.DocumentSource {
+	source := models.DocumentSource{Type: sourceType, ID: id}
+	if !updatedAt.IsZero() {
+		source.UpdatedAt = &updatedAt
+	}
+	return source
+}
+
+func patientSource(p *models.Patient) []models.DocumentSource {
+	return []models.DocumentSource{sourceOf(models.DocumentSourcePatient, p.PatientID, p.UpdatedAt)}
+}
+
+func derivePatientName(src *Sources) (string, []models.DocumentSource) {
+	p := src.Patient
+	if p == nil {
+		return "", nil
+	}
+
+	family, given, kana := p.CurrentFamilyName, p.CurrentGivenName, ""
+	var names []models.NameRecord
+	if json.Unmarshal(p.NameHistory, &names) == nil {
+		for _, name := range names {
+			if name.ValidTo == nil {
+				family, given, kana = name.Family, name.Given, name.Kana
+			}
+		}
+	}
+
+	name := strings.TrimSpace(family + " " + given)
+	if name == "" {
+		return "", nil
+	}
+	if kana != "" {
+		name += "（" + kana + "）"
+	}
+	return name, patientSource(p)
+}
+
+func deriveBirthDate(src *Sources) (string, []models.DocumentSource) {
+	p := src.Patient
+	if p == nil || !p.BirthDate.Valid {
+		return "", nil
+	}
+	birth := civil.DateOf(p.BirthDate.Time)
+	return fmt.Sprintf("%s（%d歳）", FormatWareki(birth), ageOn(birth, src.IssuedOn)), patientSource(p)
+}
+
+func deriveAddress(src *Sources) (string, []models.DocumentSource) {
+	p := src.Patient
+	if p == nil {
+		return "", nil
+	}
+
+	var addresses []models.Address
+	json.Unmarshal(p.Addresses, &addresses)
+	var current *models.Address
+	for i := range addresses {
+		a := &addresses[i]
+		if a.ValidTo != nil {
+			continue
+		}
+		if current == nil || (a.Use == "home" && current.Use != "home") {
+			current = a
+		}
+	}
+
+	var address string
+	if current != nil {
+		address = current.Prefecture + current.City + current.Line
+		if current.Building != "" {
+			address += " " + current.Building
+		}
+		if current.PostalCode != "" {
+			address = "〒" + current.PostalCode + " " + address
+		}
+	} else {
+		address = p.CurrentPrefecture + p.CurrentCity
+	}
+	if address == "" {
+		return "", nil
+	}
+	return address, patientSource(p)
+}
+
+func derivePhone(src *Sources) (string, []models.DocumentSource) {
+	p := src.Patient
+	if p == nil {
+		return "", nil
+	}
+
+	phone := p.PrimaryPhone
+	var contacts []models.ContactPoint
+	json.Unmarshal(p.ContactPoints, &contacts)
+	bestRank := 0
+	for _, c := range contacts {
+		if c.System != "phone" || c.Value == "" {
+			continue
+		}
+		if bestRank == 0 || (c.Rank > 0 && c.Rank < bestRank) {
+			phone, bestRank = c.Value, c.Rank
+		}
+	}
+	if phone == "" {
+		return "", nil
+	}
+	return phone, patientSource(p)
+}
+
+func deriveDiagnoses(src *Sources) (string, []models.DocumentSource) {
+	var lines []string
+	var sources []models.DocumentSource
+	for _, c := range currentConditions(src.Conditions) {
+		lines = append(lines, fmt.Sprintf("(%d) %s", len(lines)+1, conditionText(c)))
+		sources = append(sources, sourceOf(models.DocumentSourceMedicalCondition, c.ConditionID, c.UpdatedAt))
+	}
+	return strings.Join(lines, "\n"), sources
+}
+
+func deriveMedications(src *Sources) (string, []models.DocumentSource) {
+	var lines []string
+	var sources []models.DocumentSource
+	for _, order := range activeOrders(src.Medications) {
+		lines = append(lines, fmt.Sprintf("%d. %s", len(lines)+1, orderText(order)))
+		sources = append(sources, sourceOf(models.DocumentSourceMedicationOrder, order.OrderID, order.UpdatedAt))
+	}
+	return strings.Join(lines, "\n"), sources
+}
+
+// currentConditions returns the active conditions that have not been refuted or
+// entered in error
+func currentConditions(conditions []*models.MedicalCondition) []*models.MedicalCondition {
+	var current []*models.MedicalCondition
+	for _, c := range conditions {
+		if !c.IsActive() ||
+			c.VerificationStatus == string(models.VerificationStatusRefuted) ||
+			c.VerificationStatus == string(models.VerificationStatusEnteredInError) {
+			continue
+		}
+		current = append(current, c)
+	}
+	return current
+}
+
+func conditionText(c *models.MedicalCondition) string {
+	if c.Code != "" {
+		return fmt.Sprintf("%s（%s）", c.DisplayName, c.Code)
+	}
+	return c.DisplayName
+}
+
+func activeOrders(orders []*models.MedicationOrder) []*models.MedicationOrder {
+	var active []*models.MedicationOrder
+	for _, order := range orders {
+		if order.Status == "active" {
+			active = append(active, order)
+		}
+	}
+	return active
+}
+
+func orderText(order *models.MedicationOrder) string {
+	text := medicationName(order.Medication)
+	if dosage := dosageText(order.DosageInstruction); dosage != "" {
+		text += " " + dosage
+	}
+	return text
+}
+
+func deriveCareLevel(src *Sources) (string, []models.DocumentSource) {
+	for _, c := range src.Coverages {
+		if c.InsuranceType != string(models.InsuranceTypeLongTermCare) || c.CareLevelCode == "" {
+			continue
+		}
+		return careLevelName(c.CareLevelCode), []models.DocumentSource{
+			sourceOf(models.DocumentSourceCoverage, c.CoverageID, c.UpdatedAt),
+		}
+	}
+	return "", nil
+}
+
+// vitalSignLabels are the Japanese names and display units of the synced vital signs
+var vitalSignLabels = map[string][2]string{
+	"bloodPressure":   {"血圧", "mmHg"},
+	"heartRate":       {"脈拍", "回/分"},
+	"spo2":            {"SpO2", "%"},
+	"temperature":     {"体温", "℃"},
+	"respiratoryRate": {"呼吸数", "回/分"},
+}
+
+// deriveRecentVitals summarizes the latest observation of each vital sign
+func deriveRecentVitals(src *Sources) (string, []models.DocumentSource) {
+	latest := map[string]*models.ClinicalObservation{}
+	for _, o := range src.Observations {
+		if o.Category != "vital_signs" {
+			continue
+		}
+		code := o.CodeValue()
+		if current, ok := latest[code]; !ok || o.EffectiveDatetime.After(current.EffectiveDatetime) {
+			latest[code] = o
+		}
+	}
+
+	var parts []string
+	var sources []models.DocumentSource
+	var measuredAt time.Time
+	for _, vs := range models.VitalSignCodes {
+		o, ok := latest[vs.Code.Code]
+		if !ok {
+			continue
+		}
+		label := vitalSignLabels[vs.Key]
+		var value string
+		if vs.Key == "bloodPressure" {
+			systolic, ok1 := o.NumericValue("systolic")
+			diastolic, ok2 := o.NumericValue("diastolic")
+			if !ok1 || !ok2 {
+				continue
+			}
+			value = formatNumber(systolic) + "/" + formatNumber(diastolic)
+		} else {
+			v, ok := o.NumericValue("")
+			if !ok {
+				continue
+			}
+			value = formatNumber(v)
+		}
+		parts = append(parts, label[0]+" "+value+label[1])
+		sources = append(sources, sourceOf(models.DocumentSourceClinicalObservation, o.ObservationID, o.UpdatedAt))
+		if o.EffectiveDatetime.After(measuredAt) {
+			measuredAt = o.EffectiveDatetime
+		}
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	loc := src.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return fmt.Sprintf("直近のバイタルサイン（%s）: %s",
+		FormatWareki(civil.DateOf(measuredAt.In(loc))), strings.Join(parts, "、")), sources
+}
+
+func deriveIssuerName(src *Sources) (string, []models.DocumentSource) {
+	s := src.Issuer
+	if s == nil {
+		return "", nil
+	}
+	name := strings.TrimSpace(s.FamilyName + " " + s.GivenName)
+	if name == "" {
+		return "", nil
+	}
+	return name, []models.DocumentSource{sourceOf(models.DocumentSourceStaffMember, s.StaffID, s.UpdatedAt)}
+}
+
+// careLevelNames maps care level codes, as 認定区分 codes or as keys, to their names
+var careLevelNames = map[string]string{
+	"01": "非該当",
+	"12": "要支援1", "13": "要支援2",
+	"21": "要介護1", "22": "要介護2", "23": "要介護3", "24": "要介護4", "25": "要介護5",
+	"support_level_1": "要支援1", "support_level_2": "要支援2",
+	"care_level_1": "要介護1", "care_level_2": "要介護2", "care_level_3": "要介護3",
+	"care_level_4": "要介護4", "care_level_5": "要介護5",
+}
+
+func careLevelName(code string) string {
+	if name, ok := careLevelNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+func medicationName(medication json.RawMessage) string {
+	var m struct {
+		Display string `json:"display"`
+		Name    string `json:"name"`
+		Code    string `json:"code"`
+	}
+	json.Unmarshal(medication, &m)
+	switch {
+	case m.Display != "":
+		return m.Display
+	case m.Name != "":
+		return m.Name
+	case m.Code != "":
+		return m.Code
+	}
+	return "（薬剤名未登録）"
+}
+
+var doseUnits = map[string]string{
+	"tablet": "錠", "capsule": "カプセル", "packet": "包", "drop": "滴", "sheet": "枚", "puff": "吸入",
+}
+
+// periodUnits gives, per FHIR period unit, how a frequency per single period and per
+// several periods is written: 1日3回, 2日に1回
+var periodUnits = map[string][2]string{
+	"h": {"1時間に", "時間"}, "d": {"1日", "日"}, "wk": {"週", "週"}, "mo": {"月", "か月"},
+}
+
+var routes = map[string]string{
+	"oral": "内服", "topical": "外用", "transdermal": "貼付", "sublingual": "舌下",
+	"inhalation": "吸入", "rectal": "坐剤", "subcutaneous": "皮下注射",
+	"intravenous": "静脈注射", "intramuscular": "筋肉注射", "ophthalmic": "点眼",
+}
+
+// dosageText renders a FHIR-style dosage instruction, e.g. 1回1錠 1日3回 内服. A free
+// text instruction is used as is.
+func dosageText(dosage json.RawMessage) string {
+	var d struct {
+		Text   string `json:"text"`
+		Timing struct {
+			Repeat struct {
+				Frequency  float64 `json:"frequency"`
+				Period     float64 `json:"period"`
+				PeriodUnit string  `json:"periodUnit"`
+			} `json:"repeat"`
+		} `json:"timing"`
+		Dose struct {
+			Value float64 `json:"value"`
+			Unit  string  `json:"unit"`
+		} `json:"dose"`
+		Route string `json:"route"`
+	}
+	if json.Unmarshal(dosage, &d) != nil {
+		return ""
+	}
+	if d.Text != "" {
+		return d.Text
+	}
+
+	var parts []string
+	if d.Dose.Value > 0 {
+		unit := d.Dose.Unit
+		if ja, ok := doseUnits[unit]; ok {
+			unit = ja
+		}
+		parts = append(parts, "1回"+formatNumber(d.Dose.Value)+unit)
+	}
+	if r := d.Timing.Repeat; r.Frequency > 0 {
+		unit, ok := periodUnits[r.PeriodUnit]
+		if !ok {
+			unit = periodUnits["d"]
+		}
+		if r.Period <= 1 {
+			parts = append(parts, fmt.Sprintf("%s%s回", unit[0], formatNumber(r.Frequency)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s%sに%s回", formatNumber(r.Period), unit[1], formatNumber(r.Frequency)))
+		}
+	}
+	if d.Route != "" {
+		route := d.Route
+		if ja, ok := routes[route]; ok {
+			route = ja
+		}
+		parts = append(parts, route)
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
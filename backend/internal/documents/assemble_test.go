@@ -0,0 +1,211 @@
+package documents
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+var (
+	testUpdatedAt = time.Date(2025, 3, 20, 1, 0, 0, 0, time.UTC)
+	testJST       = time.FixedZone("JST", 9*60*60)
+)
+
+func testSources() *Sources {
+	return &Sources{
+		Patient: &models.Patient{
+			PatientID:   "p-1",
+			BirthDate:   spanner.NullTime{Time: time.Date(1940, 4, 10, 0, 0, 0, 0, time.UTC), Valid: true},
+			NameHistory: json.RawMessage(`[{"family":"佐藤","given":"花子","kana":"サトウ ハナコ","valid_from":"1940-04-10T00:00:00Z"}]`),
+			Addresses: json.RawMessage(`[
+				{"use":"billing","prefecture":"東京都","city":"新宿区","line":"西新宿1-1","valid_from":"2020-01-01T00:00:00Z"},
+				{"use":"home","postal_code":"150-0001","prefecture":"東京都","city":"渋谷区","line":"神宮前1-2-3","building":"101号室","valid_from":"2020-01-01T00:00:00Z"}
+			]`),
+			PrimaryPhone: "03-1234-5678",
+			UpdatedAt:    testUpdatedAt,
+		},
+		Conditions: []*models.MedicalCondition{
+			{ConditionID: "c-1", ClinicalStatus: "active", VerificationStatus: "confirmed", Code: "I63.9", DisplayName: "脳梗塞後遺症", UpdatedAt: testUpdatedAt},
+			{ConditionID: "c-2", ClinicalStatus: "resolved", VerificationStatus: "confirmed", DisplayName: "肺炎"},
+			{ConditionID: "c-3", ClinicalStatus: "active", VerificationStatus: "refuted", DisplayName: "心不全"},
+			{ConditionID: "c-4", ClinicalStatus: "active", VerificationStatus: "confirmed", DisplayName: "褥瘡", UpdatedAt: testUpdatedAt},
+		},
+		Medications: []*models.MedicationOrder{
+			{
+				OrderID:           "m-1",
+				Status:            "active",
+				Medication:        json.RawMessage(`{"display":"アムロジピン錠5mg"}`),
+				DosageInstruction: json.RawMessage(`{"dose":{"value":1,"unit":"tablet"},"timing":{"repeat":{"frequency":1,"period":1,"periodUnit":"d"}},"route":"oral"}`),
+				UpdatedAt:         testUpdatedAt,
+			},
+			{OrderID: "m-2", Status: "completed", Medication: json.RawMessage(`{"display":"アモキシシリン"}`)},
+		},
+		Coverages: []*models.PatientCoverage{
+			{CoverageID: "cov-1", InsuranceType: "medical"},
+			{CoverageID: "cov-2", InsuranceType: "long_term_care", CareLevelCode: "23", UpdatedAt: testUpdatedAt},
+		},
+		Observations: []*models.ClinicalObservation{
+			{
+				ObservationID:     "o-1",
+				Category:          "vital_signs",
+				Code:              json.RawMessage(`{"system":"LOINC","code":"85354-9"}`),
+				Value:             json.RawMessage(`{"systolic":{"value":138,"unit":"mmHg"},"diastolic":{"value":86,"unit":"mmHg"}}`),
+				EffectiveDatetime: time.Date(2025, 3, 31, 16, 0, 0, 0, time.UTC),
+				UpdatedAt:         testUpdatedAt,
+			},
+			{
+				ObservationID:     "o-2",
+				Category:          "vital_signs",
+				Code:              json.RawMessage(`{"system":"LOINC","code":"8310-5"}`),
+				Value:             json.RawMessage(`{"value":37.2,"unit":"Cel"}`),
+				EffectiveDatetime: time.Date(2025, 3, 20, 1, 0, 0, 0, time.UTC),
+			},
+			{
+				ObservationID:     "o-3",
+				Category:          "vital_signs",
+				Code:              json.RawMessage(`{"system":"LOINC","code":"8310-5"}`),
+				Value:             json.RawMessage(`{"value":36.5,"unit":"Cel"}`),
+				EffectiveDatetime: time.Date(2025, 3, 30, 1, 0, 0, 0, time.UTC),
+			},
+		},
+		Issuer:   &models.StaffMember{StaffID: "s-1", FamilyName: "山田", GivenName: "太郎", Role: "doctor", UpdatedAt: testUpdatedAt},
+		IssuedOn: civil.Date{Year: 2025, Month: 4, Day: 1},
+		Location: testJST,
+	}
+}
+
+func testEntered() map[string]string {
+	return map[string]string{
+		"care_instructions":  "服薬管理、清潔保持",
+		"emergency_contact":  "長男 090-0000-0000",
+		FieldRecipient:       "みどり訪問看護ステーション",
+		FieldInstitutionName: "さくら在宅クリニック",
+	}
+}
+
+func fieldByKey(t *testing.T, fields []models.DocumentField, key string) models.DocumentField {
+	t.Helper()
+	for _, f := range fields {
+		if f.Key == key {
+			return f
+		}
+	}
+	t.Fatalf("field %s not assembled", key)
+	return models.DocumentField{}
+}
+
+func TestAssembleDerivesFieldsFromRecords(t *testing.T) {
+	fields, err := Assemble(Forms[models.DocumentTypeHomeNursingInstruction], testSources(), testEntered())
+	require.NoError(t, err)
+
+	name := fieldByKey(t, fields, "patient_name")
+	assert.Equal(t, "佐藤 花子（サトウ ハナコ）", name.Value)
+	require.Len(t, name.Sources, 1)
+	assert.Equal(t, models.DocumentSourcePatient, name.Sources[0].Type)
+	assert.Equal(t, "p-1", name.Sources[0].ID)
+	assert.Equal(t, testUpdatedAt, *name.Sources[0].UpdatedAt)
+
+	assert.Equal(t, "昭和15年4月10日（84歳）", fieldByKey(t, fields, "birth_date").Value)
+	assert.Equal(t, "〒150-0001 東京都渋谷区神宮前1-2-3 101号室", fieldByKey(t, fields, "address").Value)
+	assert.Equal(t, "03-1234-5678", fieldByKey(t, fields, "phone").Value)
+
+	diagnoses := fieldByKey(t, fields, "diagnoses")
+	assert.Equal(t, "(1) 脳梗塞後遺症（I63.9）\n(2) 褥瘡", diagnoses.Value)
+	require.Len(t, diagnoses.Sources, 2)
+	assert.Equal(t, "c-1", diagnoses.Sources[0].ID)
+	assert.Equal(t, "c-4", diagnoses.Sources[1].ID)
+
+	medications := fieldByKey(t, fields, "medications")
+	assert.Equal(t, "1. アムロジピン錠5mg 1回1錠 1日1回 内服", medications.Value)
+	require.Len(t, medications.Sources, 1)
+	assert.Equal(t, models.DocumentSourceMedicationOrder, medications.Sources[0].Type)
+
+	careLevel := fieldByKey(t, fields, "care_level")
+	assert.Equal(t, "要介護3", careLevel.Value)
+	assert.Equal(t, "cov-2", careLevel.Sources[0].ID)
+
+	vitals := fieldByKey(t, fields, "condition_status")
+	assert.Equal(t, "直近のバイタルサイン（令和7年4月1日）: 血圧 138/86mmHg、体温 36.5℃", vitals.Value)
+	require.Len(t, vitals.Sources, 2)
+	assert.Equal(t, "o-1", vitals.Sources[0].ID)
+	assert.Equal(t, "o-3", vitals.Sources[1].ID)
+	assert.Nil(t, vitals.Sources[1].UpdatedAt)
+
+	physician := fieldByKey(t, fields, FieldPhysicianName)
+	assert.Equal(t, "山田 太郎", physician.Value)
+	assert.Equal(t, models.DocumentSourceStaffMember, physician.Sources[0].Type)
+
+	recipient := fieldByKey(t, fields, FieldRecipient)
+	assert.Equal(t, "みどり訪問看護ステーション", recipient.Value)
+	assert.Equal(t, []models.DocumentSource{{Type: models.DocumentSourceManual}}, recipient.Sources)
+
+	assert.Empty(t, fieldByKey(t, fields, "wound_care").Value)
+	assert.Empty(t, fieldByKey(t, fields, "wound_care").Sources)
+}
+
+func TestAssembleEnteredValues(t *testing.T) {
+	entered := testEntered()
+	entered["diagnoses"] = "脳梗塞後遺症（右片麻痺）"
+	entered["condition_status"] = "状態は安定している"
+
+	fields, err := Assemble(Forms[models.DocumentTypeHomeNursingInstruction], testSources(), entered)
+	require.NoError(t, err)
+
+	// An entered value replaces the derived one
+	diagnoses := fieldByKey(t, fields, "diagnoses")
+	assert.Equal(t, "脳梗塞後遺症（右片麻痺）", diagnoses.Value)
+	assert.Equal(t, []models.DocumentSource{{Type: models.DocumentSourceManual}}, diagnoses.Sources)
+
+	// ...or precedes it for Append fields, keeping the derived sources
+	status := fieldByKey(t, fields, "condition_status")
+	assert.Equal(t, "状態は安定している\n直近のバイタルサイン（令和7年4月1日）: 血圧 138/86mmHg、体温 36.5℃", status.Value)
+	require.Len(t, status.Sources, 3)
+	assert.Equal(t, models.DocumentSourceManual, status.Sources[0].Type)
+	assert.Equal(t, "o-1", status.Sources[1].ID)
+}
+
+func TestAssembleRejectsUnknownAndMissingFields(t *testing.T) {
+	form := Forms[models.DocumentTypeHomeNursingInstruction]
+
+	entered := testEntered()
+	entered["favourite_colour"] = "blue"
+	_, err := Assemble(form, testSources(), entered)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown fields for 訪問看護指示書: favourite_colour")
+
+	src := testSources()
+	src.Conditions = nil
+	entered = testEntered()
+	delete(entered, "emergency_contact")
+	_, err = Assemble(form, src, entered)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "diagnoses (主たる傷病名)")
+	assert.Contains(t, err.Error(), "emergency_contact (緊急時の連絡先)")
+}
+
+func TestDosageText(t *testing.T) {
+	tests := []struct {
+		name   string
+		dosage string
+		want   string
+	}{
+		{"three times a day", `{"dose":{"value":1,"unit":"tablet"},"timing":{"repeat":{"frequency":3,"period":1,"periodUnit":"d"}},"route":"oral"}`, "1回1錠 1日3回 内服"},
+		{"twice a week", `{"dose":{"value":1,"unit":"sheet"},"timing":{"repeat":{"frequency":2,"period":1,"periodUnit":"wk"}},"route":"transdermal"}`, "1回1枚 週2回 貼付"},
+		{"every other day", `{"dose":{"value":0.5,"unit":"tablet"},"timing":{"repeat":{"frequency":1,"period":2,"periodUnit":"d"}}}`, "1回0.5錠 2日に1回"},
+		{"unknown units kept", `{"dose":{"value":2,"unit":"mL"},"route":"enteral"}`, "1回2mL enteral"},
+		{"free text", `{"text":"疼痛時 1回1錠","dose":{"value":1,"unit":"tablet"}}`, "疼痛時 1回1錠"},
+		{"invalid", `[]`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dosageText(json.RawMessage(tt.dosage)))
+		})
+	}
+}
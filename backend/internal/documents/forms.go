@@ -0,0 +1,172 @@
+// Package documents assembles and renders statutory documents such as the
+// 訪問看護指示書 and 居宅療養管理指導書. A Form lists the fields of a document in the
+// order of the official layout; Assemble fills them from patient records and the values
+// entered by the issuing physician, recording for each field the records it was produced
+// from, and Render lays the result out as a PDF.
+package documents
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/models"
+)
+
+// Keys of the fields printed in the closing block rather than the body table
+const (
+	FieldRecipient          = "recipient"
+	FieldInstitutionName    = "institution_name"
+	FieldInstitutionAddress = "institution_address"
+	FieldInstitutionPhone   = "institution_phone"
+	FieldPhysicianName      = "physician_name"
+)
+
+// Form describes a statutory document type
+type Form struct {
+	Type          string
+	Title         string
+	PeriodLabel   string // printed before the validity period
+	Addressee     string // kind of organization the document is addressed to
+	Closing       string
+	DefaultMonths int // validity period when none is requested
+	MaxMonths     int // longest validity period allowed
+	IssuerRoles   []string
+	Fields        []FieldSpec
+}
+
+// FieldSpec describes one field of a form
+type FieldSpec struct {
+	Key      string
+	Label    string
+	Group    string // heading printed above a run of fields sharing it
+	Required bool
+	// Derive produces the value from patient records; nil for fields only the physician enters
+	Derive func(*Sources) (string, []models.DocumentSource)
+	// Append keeps the derived value below an entered one instead of replacing it
+	Append bool
+}
+
+// Forms lists the supported statutory documents by type
+var Forms = map[string]*Form{
+	models.DocumentTypeHomeNursingInstruction: {
+		Type:          models.DocumentTypeHomeNursingInstruction,
+		Title:         "訪問看護指示書",
+		PeriodLabel:   "指示期間",
+		Addressee:     "訪問看護ステーション",
+		Closing:       "上記のとおり、指示いたします。",
+		DefaultMonths: 1,
+		MaxMonths:     6, // 訪問看護指示書の有効期間は最長6か月
+		IssuerRoles:   []string{"doctor"},
+		Fields: []FieldSpec{
+			{Key: "patient_name", Label: "患者氏名", Required: true, Derive: derivePatientName},
+			{Key: "birth_date", Label: "生年月日", Derive: deriveBirthDate},
+			{Key: "address", Label: "患者住所", Derive: deriveAddress},
+			{Key: "phone", Label: "電話番号", Derive: derivePhone},
+			{Key: "diagnoses", Label: "主たる傷病名", Required: true, Derive: deriveDiagnoses},
+			{Key: "condition_status", Label: "病状・治療状態", Derive: deriveRecentVitals, Append: true},
+			{Key: "medications", Label: "投与中の薬剤の用量・用法", Derive: deriveMedications},
+			{Key: "bedridden_level", Label: "寝たきり度", Group: "日常生活自立度"},
+			{Key: "dementia_level", Label: "認知症の状況", Group: "日常生活自立度"},
+			{Key: "care_level", Label: "要介護認定の状況", Derive: deriveCareLevel},
+			{Key: "pressure_ulcer", Label: "褥瘡の深さ"},
+			{Key: "medical_devices", Label: "装着・使用医療機器等"},
+			{Key: "care_instructions", Label: "療養生活指導上の留意事項", Group: "留意事項及び指示事項", Required: true},
+			{Key: "rehabilitation", Label: "リハビリテーション", Group: "留意事項及び指示事項"},
+			{Key: "wound_care", Label: "褥瘡の処置等", Group: "留意事項及び指示事項"},
+			{Key: "device_management", Label: "装着・使用医療機器等の操作援助・管理", Group: "留意事項及び指示事項"},
+			{Key: "other_instructions", Label: "その他", Group: "留意事項及び指示事項"},
+			{Key: "emergency_contact", Label: "緊急時の連絡先", Required: true},
+			{Key: "absence_response", Label: "不在時の対応"},
+			{Key: "special_notes", Label: "特記すべき留意事項"},
+			{Key: FieldRecipient, Label: "訪問看護ステーション", Required: true},
+			{Key: FieldInstitutionName, Label: "医療機関名", Required: true},
+			{Key: FieldInstitutionAddress, Label: "住所"},
+			{Key: FieldInstitutionPhone, Label: "電話"},
+			{Key: FieldPhysicianName, Label: "医師氏名", Required: true, Derive: deriveIssuerName},
+		},
+	},
+	models.DocumentTypeHomeCareGuidance: {
+		Type:          models.DocumentTypeHomeCareGuidance,
+		Title:         "居宅療養管理指導書",
+		PeriodLabel:   "指導期間",
+		Addressee:     "居宅介護支援事業所",
+		Closing:       "上記のとおり、情報提供いたします。",
+		DefaultMonths: 1,
+		MaxMonths:     6,
+		IssuerRoles:   []string{"doctor"},
+		Fields: []FieldSpec{
+			{Key: "patient_name", Label: "利用者氏名", Required: true, Derive: derivePatientName},
+			{Key: "birth_date", Label: "生年月日", Derive: deriveBirthDate},
+			{Key: "address", Label: "住所", Derive: deriveAddress},
+			{Key: "care_level", Label: "要介護度", Derive: deriveCareLevel},
+			{Key: "diagnoses", Label: "傷病名", Required: true, Derive: deriveDiagnoses},
+			{Key: "condition_status", Label: "病状・経過", Derive: deriveRecentVitals, Append: true},
+			{Key: "medications", Label: "服薬状況", Derive: deriveMedications},
+			{Key: "care_notes", Label: "介護サービスを利用する上での留意点・介護方法等", Required: true},
+			{Key: "daily_life_notes", Label: "日常生活上の留意事項"},
+			{Key: "other_notes", Label: "特記事項"},
+			{Key: FieldRecipient, Label: "居宅介護支援事業所", Required: true},
+			{Key: "care_manager", Label: "介護支援専門員"},
+			{Key: FieldInstitutionName, Label: "医療機関名", Required: true},
+			{Key: FieldInstitutionAddress, Label: "住所"},
+			{Key: FieldInstitutionPhone, Label: "電話"},
+			{Key: FieldPhysicianName, Label: "医師氏名", Required: true, Derive: deriveIssuerName},
+		},
+	},
+}
+
+// FormFor returns the form of a document type
+func FormFor(documentType string) (*Form, error) {
+	form, ok := Forms[documentType]
+	if !ok {
+		return nil, fmt.Errorf("invalid document type: %s", documentType)
+	}
+	return form, nil
+}
+
+// ValidityPeriod resolves the validity period of a document starting on from: validTo
+// when given, otherwise DefaultMonths. The period may not exceed MaxMonths.
+func (f *Form) ValidityPeriod(from civil.Date, validTo *civil.Date) (civil.Date, error) {
+	if !from.IsValid() {
+		return civil.Date{}, fmt.Errorf("valid_from is required")
+	}
+	to := periodEnd(from, f.DefaultMonths)
+	if validTo != nil {
+		to = *validTo
+	}
+	if to.Before(from) {
+		return civil.Date{}, fmt.Errorf("valid_to must not be before valid_from")
+	}
+	if limit := periodEnd(from, f.MaxMonths); to.After(limit) {
+		return civil.Date{}, fmt.Errorf("the %s of a %s may not exceed %d months (valid_to %s at the latest)", f.PeriodLabel, f.Title, f.MaxMonths, limit)
+	}
+	return to, nil
+}
+
+// IssuableBy reports whether staff with the given role may issue the form
+func (f *Form) IssuableBy(role string) bool {
+	for _, r := range f.IssuerRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// periodEnd returns the last day of a period of n months starting on from: the day before
+// the corresponding day n months later, or the end of that month when it has no
+// corresponding day (民法第143条)
+func periodEnd(from civil.Date, n int) civil.Date {
+	month := int(from.Month) - 1 + n
+	year := from.Year + month/12
+	month = month%12 + 1
+	if from.Day > daysIn(year, month) {
+		return civil.Date{Year: year, Month: time.Month(month), Day: daysIn(year, month)}
+	}
+	return civil.Date{Year: year, Month: time.Month(month), Day: from.Day}.AddDays(-1)
+}
+
+func daysIn(year, month int) int {
+	return civil.Date{Year: year, Month: time.Month(month) + 1, Day: 1}.AddDays(-1).Day
+}
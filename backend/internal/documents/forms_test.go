@@ -0,0 +1,71 @@
+package documents
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func TestValidityPeriod(t *testing.T) {
+	form := Forms[models.DocumentTypeHomeNursingInstruction]
+	date := func(y, m, d int) civil.Date { return civil.Date{Year: y, Month: time.Month(m), Day: d} }
+
+	tests := []struct {
+		name    string
+		from    civil.Date
+		validTo *civil.Date
+		want    civil.Date
+		wantErr string
+	}{
+		{name: "default one month", from: date(2025, 4, 1), want: date(2025, 4, 30)},
+		{name: "mid-month", from: date(2025, 4, 15), want: date(2025, 5, 14)},
+		{name: "no corresponding day ends at month end", from: date(2025, 1, 31), want: date(2025, 2, 28)},
+		{name: "across the year end", from: date(2025, 12, 10), want: date(2026, 1, 9)},
+		{name: "explicit six months", from: date(2025, 4, 1), validTo: ptrDate(date(2025, 9, 30)), want: date(2025, 9, 30)},
+		{name: "longer than six months", from: date(2025, 4, 1), validTo: ptrDate(date(2025, 10, 1)), wantErr: "may not exceed 6 months (valid_to 2025-09-30 at the latest)"},
+		{name: "ends before it starts", from: date(2025, 4, 1), validTo: ptrDate(date(2025, 3, 31)), wantErr: "valid_to must not be before valid_from"},
+		{name: "no start", wantErr: "valid_from is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := form.ValidityPeriod(tt.from, tt.validTo)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormFor(t *testing.T) {
+	form, err := FormFor(models.DocumentTypeHomeCareGuidance)
+	require.NoError(t, err)
+	assert.Equal(t, "居宅療養管理指導書", form.Title)
+	assert.True(t, form.IssuableBy("doctor"))
+	assert.False(t, form.IssuableBy("nurse"))
+
+	_, err = FormFor("referral_letter")
+	assert.EqualError(t, err, "invalid document type: referral_letter")
+}
+
+func TestFormatWareki(t *testing.T) {
+	assert.Equal(t, "令和7年4月1日", FormatWareki(civil.Date{Year: 2025, Month: 4, Day: 1}))
+	assert.Equal(t, "令和元年5月1日", FormatWareki(civil.Date{Year: 2019, Month: 5, Day: 1}))
+	assert.Equal(t, "平成31年4月30日", FormatWareki(civil.Date{Year: 2019, Month: 4, Day: 30}))
+	assert.Equal(t, "昭和64年1月7日", FormatWareki(civil.Date{Year: 1989, Month: 1, Day: 7}))
+	assert.Equal(t, "平成元年1月8日", FormatWareki(civil.Date{Year: 1989, Month: 1, Day: 8}))
+	assert.Equal(t, "大正15年12月24日", FormatWareki(civil.Date{Year: 1926, Month: 12, Day: 24}))
+	assert.Equal(t, "1850年1月1日", FormatWareki(civil.Date{Year: 1850, Month: 1, Day: 1}))
+}
+
+func ptrDate(d civil.Date) *civil.Date {
+	return &d
+}
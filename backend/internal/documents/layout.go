@@ -0,0 +1,95 @@
+package documents
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/visitas/backend/pkg/pdfgen"
+)
+
+// Layout of the A4 forms, in millimetres and points
+const (
+	marginX      = 15.0
+	marginTop    = 15.0
+	marginBottom = 18.0
+	contentWidth = pdfgen.PageWidth - 2*marginX
+	labelWidth   = 52.0
+	cellPadding  = 1.8
+
+	titleSize  = 18.0
+	textSize   = 10.0
+	labelSize  = 9.0
+	footerSize = 7.5
+	lineHeight = 4.6
+	minRowH    = 8.0
+)
+
+// sheet lays out a form top to bottom, as bordered label and value rows that continue
+// onto further pages as needed
+type sheet struct {
+	pdf   *pdfgen.Document
+	pages []*pdfgen.Page
+	page  *pdfgen.Page
+	y     float64
+}
+
+func newSheet(info pdfgen.Info) *sheet {
+	s := &sheet{pdf: pdfgen.New(info)}
+	s.newPage()
+	return s
+}
+
+func (s *sheet) newPage() {
+	s.page = s.pdf.AddPage()
+	s.pages = append(s.pages, s.page)
+	s.y = marginTop
+}
+
+// reserve starts a new page unless height fits on the current one
+func (s *sheet) reserve(height float64) {
+	if s.y+height > pdfgen.PageHeight-marginBottom {
+		s.newPage()
+	}
+}
+
+// heading draws a full-width row naming the group of rows below it
+func (s *sheet) heading(text string) {
+	height := minRowH * 0.75
+	s.reserve(height)
+	s.page.Rect(marginX, s.y, contentWidth, height)
+	s.page.Text(marginX+cellPadding, s.y+height-2, labelSize, text)
+	s.y += height
+}
+
+// row draws a label cell and a value cell, as tall as the longer of the two
+func (s *sheet) row(label, value string) {
+	labelLines := pdfgen.WrapText(label, labelWidth-2*cellPadding, labelSize)
+	valueLines := pdfgen.WrapText(value, contentWidth-labelWidth-2*cellPadding, textSize)
+	lines := math.Max(float64(len(labelLines)), float64(len(valueLines)))
+	height := math.Max(minRowH, lines*lineHeight+2*cellPadding)
+	s.reserve(height)
+
+	s.page.Rect(marginX, s.y, labelWidth, height)
+	s.page.Rect(marginX+labelWidth, s.y, contentWidth-labelWidth, height)
+	baseline := s.y + cellPadding + lineHeight - 1
+	for i, line := range labelLines {
+		s.page.Text(marginX+cellPadding, baseline+float64(i)*lineHeight, labelSize, line)
+	}
+	for i, line := range valueLines {
+		s.page.Text(marginX+labelWidth+cellPadding, baseline+float64(i)*lineHeight, textSize, line)
+	}
+	s.y += height
+}
+
+// finish prints the document ID, and page numbers when there are several pages, at the
+// foot of every page and returns the PDF
+func (s *sheet) finish(documentID string) ([]byte, error) {
+	for i, p := range s.pages {
+		p.Text(marginX, pdfgen.PageHeight-8, footerSize, fmt.Sprintf("文書ID %s", documentID))
+		if len(s.pages) > 1 {
+			number := fmt.Sprintf("%d/%d", i+1, len(s.pages))
+			p.Text(marginX+contentWidth-pdfgen.TextWidth(number, footerSize), pdfgen.PageHeight-8, footerSize, number)
+		}
+	}
+	return s.pdf.Bytes()
+}
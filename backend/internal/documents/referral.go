@@ -0,0 +1,371 @@
+package documents
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/pkg/pdfgen"
+)
+
+// ReferralTitle is the title printed on referral letters
+const ReferralTitle = "診療情報提供書"
+
+var genders = map[string]string{
+	"male": "男性", "female": "女性", "other": "その他", "unknown": "不明",
+}
+
+var urgencies = map[string]string{
+	"routine": "通常", "urgent": "至急", "emergent": "緊急",
+}
+
+var allergyCategories = map[string]string{
+	"food": "食物", "medication": "薬剤", "environment": "環境", "biologic": "生物製剤",
+}
+
+// SummarizeForReferral takes the patient summary printed on a referral letter from the
+// patient's records: demographics, current problems and medications, active allergies
+// and, when record is given, its SOAP note
+func SummarizeForReferral(src *Sources, record *models.MedicalRecord) (models.ReferralSummary, error) {
+	summary := models.ReferralSummary{
+		Problems:    []models.ReferralSummaryItem{},
+		Medications: []models.ReferralSummaryItem{},
+		Allergies:   []models.ReferralSummaryItem{},
+	}
+
+	summary.Patient.Name, _ = derivePatientName(src)
+	summary.Patient.BirthDate, _ = deriveBirthDate(src)
+	summary.Patient.Address, _ = deriveAddress(src)
+	summary.Patient.Phone, _ = derivePhone(src)
+	if src.Patient != nil {
+		summary.Patient.Gender = genders[src.Patient.Gender]
+	}
+
+	for _, c := range currentConditions(src.Conditions) {
+		source := sourceOf(models.DocumentSourceMedicalCondition, c.ConditionID, c.UpdatedAt)
+		summary.Problems = append(summary.Problems, models.ReferralSummaryItem{Text: conditionText(c), Source: &source})
+	}
+	for _, order := range activeOrders(src.Medications) {
+		source := sourceOf(models.DocumentSourceMedicationOrder, order.OrderID, order.UpdatedAt)
+		summary.Medications = append(summary.Medications, models.ReferralSummaryItem{Text: orderText(order), Source: &source})
+	}
+	for _, a := range src.Allergies {
+		if a.Deleted || a.ClinicalStatus != string(models.AllergyClinicalStatusActive) ||
+			a.VerificationStatus == string(models.AllergyVerificationStatusRefuted) {
+			continue
+		}
+		source := sourceOf(models.DocumentSourceAllergyIntolerance, a.AllergyID, a.UpdatedAt)
+		summary.Allergies = append(summary.Allergies, models.ReferralSummaryItem{Text: allergyText(a), Source: &source})
+	}
+
+	if record != nil {
+		soap, err := soapSummary(record)
+		if err != nil {
+			return models.ReferralSummary{}, err
+		}
+		summary.LatestSOAP = soap
+	}
+
+	return summary, nil
+}
+
+// allergyText renders an allergy as, e.g., ペニシリン（薬剤）: 蕁麻疹、呼吸困難 重篤
+func allergyText(a *models.AllergyIntolerance) string {
+	text := a.DisplayName
+	if category, ok := allergyCategories[a.Category]; ok {
+		text += "（" + category + "）"
+	}
+
+	var reactions []models.AllergyReaction
+	json.Unmarshal(a.Reactions, &reactions)
+	var manifestations []string
+	for _, r := range reactions {
+		manifestations = append(manifestations, r.Manifestation...)
+	}
+	if len(manifestations) > 0 {
+		text += ": " + strings.Join(manifestations, "、")
+	}
+	if a.Criticality == "high" {
+		text += " 重篤"
+	}
+	return text
+}
+
+// soapSummary renders each section of a record's SOAP note as text
+func soapSummary(record *models.MedicalRecord) (*models.ReferralSOAPSummary, error) {
+	var soap models.SOAPContent
+	if len(record.SOAPContent) > 0 {
+		if err := json.Unmarshal(record.SOAPContent, &soap); err != nil {
+			return nil, fmt.Errorf("invalid SOAP content in record %s: %w", record.RecordID, err)
+		}
+	}
+
+	summary := &models.ReferralSOAPSummary{
+		RecordID:       record.RecordID,
+		VisitStartedAt: record.VisitStartedAt,
+	}
+
+	if s := soap.Subjective; s != nil {
+		var lines []string
+		if s.ChiefComplaint != "" {
+			lines = append(lines, "主訴: "+s.ChiefComplaint)
+		}
+		var symptoms []string
+		for _, symptom := range s.Symptoms {
+			if symptom.Display != "" {
+				symptoms = append(symptoms, symptom.Display)
+			}
+		}
+		if len(symptoms) > 0 {
+			lines = append(lines, "症状: "+strings.Join(symptoms, "、"))
+		}
+		if s.PainScale != nil && s.PainScale.Score > 0 {
+			pain := fmt.Sprintf("疼痛: %d/10", s.PainScale.Score)
+			if s.PainScale.Location != "" {
+				pain += "（" + s.PainScale.Location + "）"
+			}
+			lines = append(lines, pain)
+		}
+		if s.PatientNarrative != "" {
+			lines = append(lines, s.PatientNarrative)
+		}
+		if s.FamilyObservations != "" {
+			lines = append(lines, "家族より: "+s.FamilyObservations)
+		}
+		summary.Subjective = strings.Join(lines, "\n")
+	}
+
+	if o := soap.Objective; o != nil {
+		var lines []string
+		if vitals := vitalSignsText(o.VitalSigns); vitals != "" {
+			lines = append(lines, vitals)
+		}
+		areas := make([]string, 0, len(o.PhysicalExam))
+		for area := range o.PhysicalExam {
+			areas = append(areas, area)
+		}
+		sort.Strings(areas)
+		for _, area := range areas {
+			lines = append(lines, area+": "+o.PhysicalExam[area])
+		}
+		tests := make([]string, 0, len(o.LaboratoryResults))
+		for test := range o.LaboratoryResults {
+			tests = append(tests, test)
+		}
+		sort.Strings(tests)
+		for _, test := range tests {
+			lines = append(lines, fmt.Sprintf("%s: %v", test, o.LaboratoryResults[test]))
+		}
+		summary.Objective = strings.Join(lines, "\n")
+	}
+
+	if a := soap.Assessment; a != nil {
+		var lines []string
+		for _, d := range a.Diagnoses {
+			if d.Code == nil || d.Code.Display == "" {
+				continue
+			}
+			line := d.Code.Display
+			if d.Status == "provisional" || d.Status == "differential" {
+				line += "（疑い）"
+			}
+			lines = append(lines, line)
+		}
+		if a.ClinicalImpression != "" {
+			lines = append(lines, a.ClinicalImpression)
+		}
+		if a.PrognosisNote != "" {
+			lines = append(lines, "予後: "+a.PrognosisNote)
+		}
+		summary.Assessment = strings.Join(lines, "\n")
+	}
+
+	if p := soap.Plan; p != nil {
+		var lines []string
+		for _, m := range p.Medications {
+			if m.DrugName == "" {
+				continue
+			}
+			line := m.DrugName
+			if m.Dosage != "" {
+				line += " " + m.Dosage
+			}
+			if action, ok := medicationActions[m.Action]; ok {
+				line += "（" + action + "）"
+			}
+			lines = append(lines, line)
+		}
+		for _, proc := range p.Procedures {
+			if proc.Code != "" {
+				lines = append(lines, "処置: "+proc.Code)
+			}
+		}
+		for _, r := range p.Referrals {
+			if r.Specialty != "" || r.Facility != "" {
+				lines = append(lines, "紹介: "+strings.TrimSpace(r.Facility+" "+r.Specialty))
+			}
+		}
+		if p.NextVisit != nil && p.NextVisit.ScheduledDate != "" {
+			lines = append(lines, "次回訪問: "+p.NextVisit.ScheduledDate)
+		}
+		summary.Plan = strings.Join(lines, "\n")
+	}
+
+	return summary, nil
+}
+
+var medicationActions = map[string]string{
+	"prescribe": "新規", "continue": "継続", "discontinue": "中止", "adjust": "変更",
+}
+
+func vitalSignsText(v *models.VitalSigns) string {
+	if v == nil {
+		return ""
+	}
+	var parts []string
+	if bp := v.BloodPressure; bp != nil && bp.Systolic > 0 {
+		label := vitalSignLabels["bloodPressure"]
+		parts = append(parts, fmt.Sprintf("%s %d/%d%s", label[0], bp.Systolic, bp.Diastolic, label[1]))
+	}
+	for _, m := range []struct {
+		key   string
+		value *models.Measurement
+	}{
+		{"heartRate", v.HeartRate},
+		{"spo2", v.SPO2},
+		{"temperature", v.Temperature},
+		{"respiratoryRate", v.RespiratoryRate},
+	} {
+		if m.value == nil || m.value.Value == 0 {
+			continue
+		}
+		label := vitalSignLabels[m.key]
+		parts = append(parts, label[0]+" "+formatNumber(m.value.Value)+label[1])
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "バイタルサイン: " + strings.Join(parts, "、")
+}
+
+// RenderReferralLetter lays a referral letter out as a 診療情報提供書 and returns the PDF,
+// dated issuedAt in loc and signed by physician. A draft is marked as such in the title.
+func RenderReferralLetter(letter *models.ReferralLetter, physician string, issuedAt time.Time, loc *time.Location, draft bool) ([]byte, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	issuedAt = issuedAt.In(loc)
+
+	title := ReferralTitle
+	if draft {
+		title += "（下書き）"
+	}
+	s := newSheet(pdfgen.Info{
+		Title:        title,
+		Author:       deref(letter.SenderInstitution),
+		Subject:      letter.ReferralID,
+		CreationDate: issuedAt,
+	})
+
+	s.page.TextCentered(pdfgen.PageWidth/2, s.y+8, titleSize, title)
+	s.y += 18
+	date := FormatWareki(civil.DateOf(issuedAt))
+	s.page.Text(marginX+contentWidth-pdfgen.TextWidth(date, textSize), s.y, textSize, date)
+
+	s.y += 6
+	s.page.Text(marginX, s.y, textSize+1, letter.RecipientInstitution)
+	if letter.RecipientDepartment != nil {
+		s.y += 6
+		s.page.Text(marginX, s.y, textSize+1, *letter.RecipientDepartment)
+	}
+	recipient := "担当医"
+	if letter.RecipientPhysician != nil && *letter.RecipientPhysician != "" {
+		recipient = *letter.RecipientPhysician
+	}
+	s.y += 6
+	s.page.Text(marginX, s.y, textSize+1, recipient+"　先生　御侍史")
+
+	senderX := marginX + 95
+	for _, line := range []string{deref(letter.SenderInstitution), deref(letter.SenderAddress), phoneLine(letter.SenderPhone)} {
+		if line != "" {
+			s.y += 6
+			s.page.Text(senderX, s.y, textSize, line)
+		}
+	}
+	s.y += 6
+	s.page.Text(senderX, s.y, textSize, "医師氏名　"+physician)
+	s.page.Text(marginX+contentWidth-6, s.y, textSize, "印")
+
+	s.y += 8
+	s.page.Text(marginX, s.y, textSize, "下記の患者さんを紹介いたします。ご高診のほどよろしくお願い申し上げます。")
+	s.y += 4
+
+	patient := letter.Summary.Patient
+	s.row("患者氏名", patient.Name)
+	s.row("生年月日・性別", strings.TrimSpace(patient.BirthDate+"　"+patient.Gender))
+	s.row("住所", patient.Address)
+	s.row("電話番号", patient.Phone)
+
+	purpose := letter.Purpose
+	if urgency, ok := urgencies[letter.Urgency]; ok && letter.Urgency != "routine" {
+		purpose = "【" + urgency + "】" + purpose
+	}
+	s.row("紹介目的", purpose)
+	s.row("傷病名", numberedItems(letter.Summary.Problems))
+
+	var course, treatment []string
+	if soap := letter.Summary.LatestSOAP; soap != nil {
+		visited := FormatWareki(civil.DateOf(soap.VisitStartedAt.In(loc)))
+		for _, section := range []string{soap.Subjective, soap.Objective} {
+			if section != "" {
+				course = append(course, section)
+			}
+		}
+		if len(course) > 0 {
+			course = append([]string{visited + " 診察時"}, course...)
+		}
+		for _, section := range []string{soap.Assessment, soap.Plan} {
+			if section != "" {
+				treatment = append(treatment, section)
+			}
+		}
+	}
+	s.row("症状経過及び検査結果", strings.Join(course, "\n"))
+	s.row("治療経過", strings.Join(treatment, "\n"))
+	s.row("現在の処方", numberedItems(letter.Summary.Medications))
+
+	allergies := numberedItems(letter.Summary.Allergies)
+	if allergies == "" {
+		allergies = "特記事項なし"
+	}
+	s.row("アレルギー・副作用歴", allergies)
+	s.row("備考", deref(letter.Remarks))
+
+	return s.finish(letter.ReferralID)
+}
+
+func numberedItems(items []models.ReferralSummaryItem) string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("%d. %s", len(lines)+1, item.Text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func phoneLine(phone *string) string {
+	if phone == nil || *phone == "" {
+		return ""
+	}
+	return "電話番号　" + *phone
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,119 @@
+package documents
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func testReferralRecord() *models.MedicalRecord {
+	return &models.MedicalRecord{
+		RecordID:       "r-1",
+		VisitStartedAt: time.Date(2025, 3, 31, 1, 0, 0, 0, time.UTC),
+		SOAPContent: json.RawMessage(`{
+			"subjective": {"chiefComplaint": "右下肢のしびれ", "painScale": {"score": 4, "location": "右大腿"}},
+			"objective": {
+				"vitalSigns": {"bloodPressure": {"systolic": 142, "diastolic": 88}, "temperature": {"value": 36.8}},
+				"physicalExam": {"神経": "右下肢筋力低下 MMT4", "皮膚": "仙骨部発赤"}
+			},
+			"assessment": {"diagnoses": [{"code": {"display": "腰部脊柱管狭窄症"}, "status": "provisional"}], "clinicalImpression": "症状進行あり"},
+			"plan": {
+				"medications": [{"action": "prescribe", "drugName": "リマプロスト", "dosage": "1日3回"}],
+				"referrals": [{"specialty": "整形外科", "facility": "中央病院", "reason": "精査", "urgency": "urgent"}]
+			}
+		}`),
+	}
+}
+
+func TestSummarizeForReferral(t *testing.T) {
+	src := testSources()
+	src.Patient.Gender = "female"
+	src.Allergies = []*models.AllergyIntolerance{
+		{
+			AllergyID: "a-1", ClinicalStatus: "active", VerificationStatus: "confirmed",
+			Category: "medication", Criticality: "high", DisplayName: "ペニシリン",
+			Reactions: json.RawMessage(`[{"manifestation":["蕁麻疹","呼吸困難"]}]`),
+			UpdatedAt: testUpdatedAt,
+		},
+		{AllergyID: "a-2", ClinicalStatus: "active", VerificationStatus: "refuted", DisplayName: "卵"},
+		{AllergyID: "a-3", ClinicalStatus: "resolved", VerificationStatus: "confirmed", DisplayName: "そば"},
+	}
+
+	summary, err := SummarizeForReferral(src, testReferralRecord())
+	require.NoError(t, err)
+
+	assert.Equal(t, "佐藤 花子（サトウ ハナコ）", summary.Patient.Name)
+	assert.Equal(t, "昭和15年4月10日（84歳）", summary.Patient.BirthDate)
+	assert.Equal(t, "女性", summary.Patient.Gender)
+	assert.Equal(t, "03-1234-5678", summary.Patient.Phone)
+
+	require.Len(t, summary.Problems, 2)
+	assert.Equal(t, "脳梗塞後遺症（I63.9）", summary.Problems[0].Text)
+	assert.Equal(t, "c-1", summary.Problems[0].Source.ID)
+	assert.Equal(t, models.DocumentSourceMedicalCondition, summary.Problems[0].Source.Type)
+
+	require.Len(t, summary.Medications, 1)
+	assert.Equal(t, "アムロジピン錠5mg 1回1錠 1日1回 内服", summary.Medications[0].Text)
+
+	require.Len(t, summary.Allergies, 1)
+	assert.Equal(t, "ペニシリン（薬剤）: 蕁麻疹、呼吸困難 重篤", summary.Allergies[0].Text)
+	assert.Equal(t, models.DocumentSourceAllergyIntolerance, summary.Allergies[0].Source.Type)
+
+	soap := summary.LatestSOAP
+	require.NotNil(t, soap)
+	assert.Equal(t, "r-1", soap.RecordID)
+	assert.Equal(t, "主訴: 右下肢のしびれ\n疼痛: 4/10（右大腿）", soap.Subjective)
+	assert.Equal(t, "バイタルサイン: 血圧 142/88mmHg、体温 36.8℃\n皮膚: 仙骨部発赤\n神経: 右下肢筋力低下 MMT4", soap.Objective)
+	assert.Equal(t, "腰部脊柱管狭窄症（疑い）\n症状進行あり", soap.Assessment)
+	assert.Equal(t, "リマプロスト 1日3回（新規）\n紹介: 中央病院 整形外科", soap.Plan)
+
+	// Without a record there is no SOAP section; an unreadable note is an error
+	summary, err = SummarizeForReferral(src, nil)
+	require.NoError(t, err)
+	assert.Nil(t, summary.LatestSOAP)
+
+	_, err = SummarizeForReferral(src, &models.MedicalRecord{RecordID: "r-2", SOAPContent: json.RawMessage(`[`)})
+	assert.Error(t, err)
+}
+
+func TestRenderReferralLetter(t *testing.T) {
+	summary, err := SummarizeForReferral(testSources(), testReferralRecord())
+	require.NoError(t, err)
+	institution := "さくら在宅クリニック"
+	letter := &models.ReferralLetter{
+		ReferralID:           "ref-1",
+		PatientID:            "p-1",
+		Status:               models.ReferralStatusDraft,
+		RecipientInstitution: "中央病院",
+		Purpose:              "腰部脊柱管狭窄症の精査加療のお願い",
+		Urgency:              "urgent",
+		Summary:              summary,
+		SenderInstitution:    &institution,
+	}
+	issuedAt := time.Date(2025, 4, 1, 0, 30, 0, 0, time.UTC)
+
+	pdf, err := RenderReferralLetter(letter, "山田 太郎", issuedAt, testJST, false)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	assert.Equal(t, 1, bytes.Count(pdf, []byte("/Type /Page ")))
+
+	again, err := RenderReferralLetter(letter, "山田 太郎", issuedAt, testJST, false)
+	require.NoError(t, err)
+	assert.Equal(t, pdf, again)
+
+	draft, err := RenderReferralLetter(letter, "山田 太郎", issuedAt, testJST, true)
+	require.NoError(t, err)
+	assert.NotEqual(t, pdf, draft)
+
+	remarks := strings.Repeat("ご家族は入院加療を希望されています。", 150)
+	letter.Remarks = &remarks
+	pdf, err = RenderReferralLetter(letter, "山田 太郎", issuedAt, testJST, false)
+	require.NoError(t, err)
+	assert.Greater(t, bytes.Count(pdf, []byte("/Type /Page ")), 1)
+}
@@ -0,0 +1,102 @@
+package documents
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/pkg/pdfgen"
+)
+
+// closingH is the height the closing block needs below the table
+const closingH = 52.0
+
+// closingFields are printed in the closing block rather than the body table
+var closingFields = map[string]bool{
+	FieldRecipient:          true,
+	FieldInstitutionName:    true,
+	FieldInstitutionAddress: true,
+	FieldInstitutionPhone:   true,
+	FieldPhysicianName:      true,
+}
+
+// Render lays an issued document out on the official form and returns the PDF. Dates
+// are printed in the Japanese calendar, the issue date in loc.
+func Render(doc *models.StatutoryDocument, loc *time.Location) ([]byte, error) {
+	form, err := FormFor(doc.DocumentType)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	issuedAt := doc.IssuedAt.In(loc)
+
+	value := func(key string) string {
+		if f := doc.Field(key); f != nil {
+			return f.Value
+		}
+		return ""
+	}
+
+	s := newSheet(pdfgen.Info{
+		Title:        form.Title,
+		Author:       value(FieldInstitutionName),
+		Subject:      doc.DocumentID,
+		CreationDate: issuedAt,
+	})
+
+	s.page.TextCentered(pdfgen.PageWidth/2, s.y+8, titleSize, form.Title)
+	s.y += 18
+	s.page.Text(marginX, s.y, textSize, fmt.Sprintf("%s　%s ～ %s",
+		form.PeriodLabel, FormatWareki(doc.ValidFrom), FormatWareki(doc.ValidTo)))
+	s.y += 4
+
+	group := ""
+	for _, spec := range form.Fields {
+		if closingFields[spec.Key] {
+			continue
+		}
+		if spec.Group != group {
+			group = spec.Group
+			if group != "" {
+				s.heading(group)
+			}
+		}
+		s.row(spec.Label, value(spec.Key))
+	}
+
+	s.reserve(closingH)
+	s.y += 8
+	s.page.Text(marginX, s.y, textSize, form.Closing)
+	s.y += 7
+	s.page.Text(marginX+5, s.y, textSize, FormatWareki(civil.DateOf(issuedAt)))
+
+	closingX := marginX + 80
+	for _, key := range []string{FieldInstitutionName, FieldInstitutionAddress, FieldInstitutionPhone} {
+		s.y += 6
+		if v := value(key); v != "" {
+			spec := form.field(key)
+			s.page.Text(closingX, s.y, textSize, spec.Label+"　"+v)
+		}
+	}
+	s.y += 6
+	s.page.Text(closingX, s.y, textSize, "医師氏名　"+value(FieldPhysicianName))
+	s.page.Text(marginX+contentWidth-6, s.y, textSize, "印")
+
+	s.y += 12
+	s.page.Text(marginX, s.y, textSize+1, form.Addressee+"　"+value(FieldRecipient)+"　殿")
+
+	return s.finish(doc.DocumentID)
+}
+
+// field returns the spec of a field of the form
+func (f *Form) field(key string) *FieldSpec {
+	for i := range f.Fields {
+		if f.Fields[i].Key == key {
+			return &f.Fields[i]
+		}
+	}
+	return &FieldSpec{Key: key}
+}
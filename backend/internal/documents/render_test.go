@@ -0,0 +1,52 @@
+package documents
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func TestRender(t *testing.T) {
+	fields, err := Assemble(Forms[models.DocumentTypeHomeNursingInstruction], testSources(), testEntered())
+	require.NoError(t, err)
+	doc := &models.StatutoryDocument{
+		DocumentID:   "d-1",
+		PatientID:    "p-1",
+		DocumentType: models.DocumentTypeHomeNursingInstruction,
+		ValidFrom:    civil.Date{Year: 2025, Month: 4, Day: 1},
+		ValidTo:      civil.Date{Year: 2025, Month: 4, Day: 30},
+		Fields:       fields,
+		IssuedAt:     time.Date(2025, 3, 31, 16, 30, 0, 0, time.UTC),
+	}
+
+	pdf, err := Render(doc, testJST)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Equal(t, 1, bytes.Count(pdf, []byte("/Type /Page ")))
+
+	// Rendering is deterministic, so the recorded hash identifies the document
+	again, err := Render(doc, testJST)
+	require.NoError(t, err)
+	assert.Equal(t, pdf, again)
+
+	// Long free text flows onto further pages
+	for i := range doc.Fields {
+		if doc.Fields[i].Key == "special_notes" {
+			doc.Fields[i].Value = strings.Repeat("夜間の急変時は主治医へ連絡すること。", 200)
+		}
+	}
+	pdf, err = Render(doc, testJST)
+	require.NoError(t, err)
+	assert.Greater(t, bytes.Count(pdf, []byte("/Type /Page ")), 1)
+
+	doc.DocumentType = "unknown"
+	_, err = Render(doc, testJST)
+	assert.Error(t, err)
+}
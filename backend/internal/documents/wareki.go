@@ -0,0 +1,46 @@
+package documents
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/civil"
+)
+
+// eras lists the Japanese eras by first day, newest first
+var eras = []struct {
+	name  string
+	start civil.Date
+}{
+	{"令和", civil.Date{Year: 2019, Month: 5, Day: 1}},
+	{"平成", civil.Date{Year: 1989, Month: 1, Day: 8}},
+	{"昭和", civil.Date{Year: 1926, Month: 12, Day: 25}},
+	{"大正", civil.Date{Year: 1912, Month: 7, Day: 30}},
+	{"明治", civil.Date{Year: 1868, Month: 10, Day: 23}},
+}
+
+// FormatWareki formats a date in the Japanese calendar used on official forms, e.g.
+// 令和7年4月1日. The first year of an era is written 元年. Dates before Meiji fall back
+// to the Gregorian calendar.
+func FormatWareki(d civil.Date) string {
+	for _, era := range eras {
+		if d.Before(era.start) {
+			continue
+		}
+		year := d.Year - era.start.Year + 1
+		yearText := fmt.Sprintf("%d", year)
+		if year == 1 {
+			yearText = "元"
+		}
+		return fmt.Sprintf("%s%s年%d月%d日", era.name, yearText, int(d.Month), d.Day)
+	}
+	return fmt.Sprintf("%d年%d月%d日", d.Year, int(d.Month), d.Day)
+}
+
+// ageOn returns the age in completed years of someone born on birth
+func ageOn(birth, on civil.Date) int {
+	age := on.Year - birth.Year
+	if on.Month < birth.Month || (on.Month == birth.Month && on.Day < birth.Day) {
+		age--
+	}
+	return age
+}
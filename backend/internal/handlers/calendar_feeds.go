@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// CalendarFeedHandler handles HTTP requests for iCalendar feeds of visit schedules
+type CalendarFeedHandler struct {
+	calendarFeedService *services.CalendarFeedService
+}
+
+// NewCalendarFeedHandler creates a new calendar feed handler
+func NewCalendarFeedHandler(calendarFeedService *services.CalendarFeedService) *CalendarFeedHandler {
+	return &CalendarFeedHandler{
+		calendarFeedService: calendarFeedService,
+	}
+}
+
+// CreateFeed handles POST /calendar-feeds
+func (h *CalendarFeedHandler) CreateFeed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CalendarFeedTokenCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.calendarFeedService.CreateFeed(ctx, &req, userID)
+	if err != nil {
+		logger.Error("Failed to create calendar feed", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not available") {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// GetFeeds handles GET /calendar-feeds
+func (h *CalendarFeedHandler) GetFeeds(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.calendarFeedService.ListFeeds(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to list calendar feeds", err)
+		http.Error(w, "Failed to retrieve calendar feeds", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// RevokeFeed handles DELETE /calendar-feeds/{id}
+func (h *CalendarFeedHandler) RevokeFeed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tokenID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.calendarFeedService.RevokeFeed(ctx, tokenID, userID); err != nil {
+		logger.Error("Failed to revoke calendar feed", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeFeed handles GET /calendar/{token}.ics
+// Public: calendar clients cannot send our auth headers, so the signed token is the credential.
+func (h *CalendarFeedHandler) ServeFeed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := strings.TrimSuffix(chi.URLParam(r, "token"), ".ics")
+
+	calendar, err := h.calendarFeedService.RenderFeed(ctx, token)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "not available") {
+			http.Error(w, "Calendar feeds are not available", http.StatusServiceUnavailable)
+			return
+		}
+		logger.Error("Failed to render calendar feed", err)
+		http.Error(w, "Failed to build calendar feed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if err := calendar.Write(w); err != nil {
+		logger.Error("Failed to write calendar feed", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -275,3 +276,156 @@ func (h *CarePlanHandler) GetActiveCarePlans(w http.ResponseWriter, r *http.Requ
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(carePlans)
 }
+
+// GetGoalStatus handles GET /patients/{patient_id}/care-plans/{id}/goals
+// Returns each goal's progress (achieved, in_progress, off_track, no_data or not_measurable)
+// computed from the patient's observations over the plan period.
+func (h *CarePlanHandler) GetGoalStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	planID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := h.carePlanService.GetGoalStatus(ctx, patientID, planID, userID)
+	if err != nil {
+		logger.Error("Failed to get care plan goal status", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid goals") {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		http.Error(w, "Failed to evaluate care plan goals", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// GetVersions handles GET /patients/{patient_id}/care-plans/{id}/versions
+// Returns the snapshot saved at each version of the plan, newest first.
+func (h *CarePlanHandler) GetVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	planID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	versions, err := h.carePlanService.ListVersions(ctx, patientID, planID, userID)
+	if err != nil {
+		logger.Error("Failed to list care plan versions", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve care plan versions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// GetVersion handles GET /patients/{patient_id}/care-plans/{id}/versions/{version}
+func (h *CarePlanHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	planID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	version, err := strconv.ParseInt(chi.URLParam(r, "version"), 10, 64)
+	if err != nil || version < 1 {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+
+	snapshot, err := h.carePlanService.GetVersion(ctx, patientID, planID, version, userID)
+	if err != nil {
+		logger.Error("Failed to get care plan version", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve care plan version", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// CompareVersions handles GET /patients/{patient_id}/care-plans/{id}/versions/diff?from=&to=
+// Returns the changes to title, description, status, intent, period, goals and activities.
+func (h *CarePlanHandler) CompareVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	planID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err != nil || from < 1 {
+		http.Error(w, "Invalid or missing from version", http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	if err != nil || to < 1 {
+		http.Error(w, "Invalid or missing to version", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.carePlanService.CompareVersions(ctx, patientID, planID, from, to, userID)
+	if err != nil {
+		logger.Error("Failed to compare care plan versions", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		http.Error(w, "Failed to compare care plan versions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
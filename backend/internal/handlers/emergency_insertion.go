@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// EmergencyInsertionHandler handles HTTP requests for inserting emergency visits into planned routes
+type EmergencyInsertionHandler struct {
+	emergencyInsertionService *services.EmergencyInsertionService
+}
+
+// NewEmergencyInsertionHandler creates a new emergency insertion handler
+func NewEmergencyInsertionHandler(emergencyInsertionService *services.EmergencyInsertionService) *EmergencyInsertionHandler {
+	return &EmergencyInsertionHandler{
+		emergencyInsertionService: emergencyInsertionService,
+	}
+}
+
+// InsertEmergencyVisit handles POST /routes/emergency-insertion
+// Without "apply" the ranked proposals are returned with 200; with "apply" the chosen
+// proposal is written and the result is returned with 201.
+func (h *EmergencyInsertionHandler) InsertEmergencyVisit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.EmergencyInsertionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.emergencyInsertionService.InsertEmergencyVisit(ctx, &req, userID)
+	if err != nil {
+		logger.Error("Failed to insert emergency visit", err)
+		if writeScheduleConflict(w, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Applied != nil {
+		w.Header().Set("Location", "/api/v1/patients/"+result.PatientID+"/schedules/"+result.Schedule.ScheduleID)
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/civil"
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// InboundReferralHandler handles HTTP requests for received referral letters
+type InboundReferralHandler struct {
+	intakeService  *services.InboundReferralService
+	maxUploadBytes int64
+}
+
+// NewInboundReferralHandler creates a new inbound referral handler
+func NewInboundReferralHandler(intakeService *services.InboundReferralService, maxUploadBytes int64) *InboundReferralHandler {
+	return &InboundReferralHandler{
+		intakeService:  intakeService,
+		maxUploadBytes: maxUploadBytes,
+	}
+}
+
+// ReceiveReferral handles POST /patients/{patient_id}/inbound-referrals. The letter is
+// the "file" part; items read from it may be given as a JSON array in the "items" field.
+func (h *InboundReferralHandler) ReceiveReferral(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Leave room for the multipart framing and the other form fields
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadBytes+1<<20)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("File exceeds the maximum size of %d bytes", h.maxUploadBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxUploadBytes {
+		http.Error(w, fmt.Sprintf("File exceeds the maximum size of %d bytes", h.maxUploadBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		logger.Error("Failed to read uploaded file", err)
+		http.Error(w, "Failed to read file", http.StatusBadRequest)
+		return
+	}
+
+	upload := &models.InboundReferralUpload{
+		ReferringInstitution: r.FormValue("referring_institution"),
+		FileName:             header.Filename,
+		Data:                 data,
+	}
+	if physician := strings.TrimSpace(r.FormValue("referring_physician")); physician != "" {
+		upload.ReferringPhysician = &physician
+	}
+	if purpose := strings.TrimSpace(r.FormValue("purpose")); purpose != "" {
+		upload.Purpose = &purpose
+	}
+	if dateStr := r.FormValue("referral_date"); dateStr != "" {
+		d, err := civil.ParseDate(dateStr)
+		if err != nil {
+			http.Error(w, "Invalid referral_date format (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		upload.ReferralDate = &d
+	}
+	if items := r.FormValue("items"); items != "" {
+		if err := json.Unmarshal([]byte(items), &upload.Items); err != nil {
+			http.Error(w, "Invalid items (use a JSON array of {kind, text})", http.StatusBadRequest)
+			return
+		}
+	}
+
+	intake, err := h.intakeService.ReceiveReferral(ctx, patientID, upload, userID)
+	if err != nil {
+		logger.Error("Failed to receive referral", err)
+		writeReferralError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(intake)
+}
+
+// ListReferrals handles GET /patients/{patient_id}/inbound-referrals
+func (h *InboundReferralHandler) ListReferrals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &models.InboundReferralFilter{}
+	if status := query.Get("status"); status != "" {
+		if status != models.InboundReferralStatusPendingReview && status != models.InboundReferralStatusReviewed {
+			http.Error(w, "Invalid status (use pending_review or reviewed)", http.StatusBadRequest)
+			return
+		}
+		filter.Status = &status
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 200 {
+			http.Error(w, "Invalid limit (1-200)", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	intakes, err := h.intakeService.ListReferrals(ctx, patientID, filter, userID)
+	if err != nil {
+		logger.Error("Failed to list inbound referrals", err)
+		writeReferralError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"inbound_referrals": intakes,
+		"total":             len(intakes),
+	})
+}
+
+// GetReferral handles GET /patients/{patient_id}/inbound-referrals/{id}
+func (h *InboundReferralHandler) GetReferral(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	intakeID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	intake, err := h.intakeService.GetReferral(ctx, patientID, intakeID, userID)
+	if err != nil {
+		logger.Error("Failed to get inbound referral", err)
+		writeReferralError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(intake)
+}
+
+// GetReferralDocument handles GET /patients/{patient_id}/inbound-referrals/{id}/document
+func (h *InboundReferralHandler) GetReferralDocument(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	intakeID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	content, err := h.intakeService.DownloadDocument(ctx, patientID, intakeID,
+		userID, middleware.ClientIP(r), r.UserAgent())
+	if err != nil {
+		logger.Error("Failed to download referral document", err)
+		writeReferralError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", content.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content.Data)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": content.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(content.Data)
+}
+
+// AddItems handles POST /patients/{patient_id}/inbound-referrals/{id}/items
+func (h *InboundReferralHandler) AddItems(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	intakeID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Items []models.ReferralIntakeItemInput `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	intake, err := h.intakeService.AddItems(ctx, patientID, intakeID, req.Items, userID)
+	if err != nil {
+		logger.Error("Failed to add referral items", err)
+		writeReferralError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(intake)
+}
+
+// AcceptItem handles POST /patients/{patient_id}/inbound-referrals/{id}/items/{item_id}/accept
+func (h *InboundReferralHandler) AcceptItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	intakeID := chi.URLParam(r, "id")
+	itemID := chi.URLParam(r, "item_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReferralItemAcceptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	intake, err := h.intakeService.AcceptItem(ctx, patientID, intakeID, itemID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to accept referral item", err)
+		writeReferralError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(intake)
+}
+
+// RejectItem handles POST /patients/{patient_id}/inbound-referrals/{id}/items/{item_id}/reject
+func (h *InboundReferralHandler) RejectItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	intakeID := chi.URLParam(r, "id")
+	itemID := chi.URLParam(r, "item_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReferralItemRejectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	intake, err := h.intakeService.RejectItem(ctx, patientID, intakeID, itemID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to reject referral item", err)
+		writeReferralError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(intake)
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/geo"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// LogisticsLocationHandler handles HTTP requests for logistics locations
+type LogisticsLocationHandler struct {
+	locationService *services.LogisticsLocationService
+}
+
+// NewLogisticsLocationHandler creates a new logistics location handler
+func NewLogisticsLocationHandler(locationService *services.LogisticsLocationService) *LogisticsLocationHandler {
+	return &LogisticsLocationHandler{
+		locationService: locationService,
+	}
+}
+
+// CreateLocation handles POST /locations
+func (h *LogisticsLocationHandler) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.LogisticsLocationCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	location, err := h.locationService.CreateLocation(ctx, &req, userID)
+	if err != nil {
+		logger.Error("Failed to create logistics location", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(location)
+}
+
+// GetLocation handles GET /locations/{id}
+func (h *LogisticsLocationHandler) GetLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	locationID := chi.URLParam(r, "id")
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	location, err := h.locationService.GetLocation(ctx, locationID)
+	if err != nil {
+		logger.Error("Failed to get logistics location", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(location)
+}
+
+// ListLocations handles GET /locations
+func (h *LogisticsLocationHandler) ListLocations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filter := &models.LogisticsLocationFilter{}
+
+	if orgID := r.URL.Query().Get("organization_id"); orgID != "" {
+		filter.OrganizationID = &orgID
+	}
+	if locationType := r.URL.Query().Get("location_type"); locationType != "" {
+		filter.LocationType = &locationType
+	}
+	if status := r.URL.Query().Get("location_status"); status != "" {
+		filter.LocationStatus = &status
+	}
+	if startStr := r.URL.Query().Get("is_route_start_point"); startStr != "" {
+		start, err := strconv.ParseBool(startStr)
+		if err != nil {
+			http.Error(w, "Invalid is_route_start_point", http.StatusBadRequest)
+			return
+		}
+		filter.IsRouteStartPoint = &start
+	}
+	if endStr := r.URL.Query().Get("is_route_end_point"); endStr != "" {
+		end, err := strconv.ParseBool(endStr)
+		if err != nil {
+			http.Error(w, "Invalid is_route_end_point", http.StatusBadRequest)
+			return
+		}
+		filter.IsRouteEndPoint = &end
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = offset
+	}
+
+	locations, err := h.locationService.ListLocations(ctx, filter)
+	if err != nil {
+		logger.Error("Failed to list logistics locations", err)
+		http.Error(w, "Failed to retrieve locations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(locations)
+}
+
+// UpdateLocation handles PUT /locations/{id}
+func (h *LogisticsLocationHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	locationID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.LogisticsLocationUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	location, err := h.locationService.UpdateLocation(ctx, locationID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to update logistics location", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(location)
+}
+
+// DeleteLocation handles DELETE /locations/{id}
+func (h *LogisticsLocationHandler) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	locationID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.locationService.DeleteLocation(ctx, locationID, userID); err != nil {
+		logger.Error("Failed to delete logistics location", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete location", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FindNearest handles GET /locations/nearest?type=clinic&latitude=..&longitude=..
+func (h *LogisticsLocationHandler) FindNearest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	locationType := r.URL.Query().Get("type")
+	if locationType == "" {
+		http.Error(w, "type is required", http.StatusBadRequest)
+		return
+	}
+
+	lat, err := strconv.ParseFloat(r.URL.Query().Get("latitude"), 64)
+	if err != nil {
+		http.Error(w, "Invalid latitude", http.StatusBadRequest)
+		return
+	}
+	lng, err := strconv.ParseFloat(r.URL.Query().Get("longitude"), 64)
+	if err != nil {
+		http.Error(w, "Invalid longitude", http.StatusBadRequest)
+		return
+	}
+
+	var orgID *string
+	if org := r.URL.Query().Get("organization_id"); org != "" {
+		orgID = &org
+	}
+
+	result, err := h.locationService.FindNearest(ctx, locationType, geo.Point{Latitude: lat, Longitude: lng}, orgID)
+	if err != nil {
+		logger.Error("Failed to find nearest logistics location", err)
+		if strings.Contains(err.Error(), "no active") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// CheckServiceArea handles POST /locations/service-area-check
+func (h *LogisticsLocationHandler) CheckServiceArea(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ServiceAreaCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.locationService.CheckServiceArea(ctx, &req)
+	if err != nil {
+		logger.Error("Failed to check service area", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
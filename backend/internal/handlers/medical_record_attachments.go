@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// multipartMemory is how much of an upload is buffered in memory before spilling to disk
+const multipartMemory = 8 << 20
+
+// MedicalRecordAttachmentHandler handles HTTP requests for medical record attachments
+type MedicalRecordAttachmentHandler struct {
+	attachmentService *services.MedicalRecordAttachmentService
+	maxUploadBytes    int64
+}
+
+// NewMedicalRecordAttachmentHandler creates a new medical record attachment handler
+func NewMedicalRecordAttachmentHandler(attachmentService *services.MedicalRecordAttachmentService, maxUploadBytes int64) *MedicalRecordAttachmentHandler {
+	return &MedicalRecordAttachmentHandler{
+		attachmentService: attachmentService,
+		maxUploadBytes:    maxUploadBytes,
+	}
+}
+
+// UploadAttachment handles POST /patients/{patient_id}/medical-records/{id}/attachments
+func (h *MedicalRecordAttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Leave room for the multipart framing and the other form fields
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadBytes+1<<20)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("File exceeds the maximum size of %d bytes", h.maxUploadBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxUploadBytes {
+		http.Error(w, fmt.Sprintf("File exceeds the maximum size of %d bytes", h.maxUploadBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		logger.Error("Failed to read uploaded file", err)
+		http.Error(w, "Failed to read file", http.StatusBadRequest)
+		return
+	}
+
+	upload := &models.MedicalRecordAttachmentUpload{
+		Category: r.FormValue("category"),
+		FileName: header.Filename,
+		Data:     data,
+	}
+	if caption := strings.TrimSpace(r.FormValue("caption")); caption != "" {
+		upload.Caption = &caption
+	}
+
+	attachment, err := h.attachmentService.UploadAttachment(ctx, patientID, recordID, upload, userID)
+	if err != nil {
+		logger.Error("Failed to upload attachment", err)
+		writeAttachmentError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// ListAttachments handles GET /patients/{patient_id}/medical-records/{id}/attachments
+func (h *MedicalRecordAttachmentHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	attachments, err := h.attachmentService.ListAttachments(ctx, patientID, recordID, userID)
+	if err != nil {
+		logger.Error("Failed to list attachments", err)
+		writeAttachmentError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attachments": attachments,
+		"total":       len(attachments),
+	})
+}
+
+// GetAttachmentContent handles GET /patients/{patient_id}/medical-records/{id}/attachments/{attachment_id}/content
+func (h *MedicalRecordAttachmentHandler) GetAttachmentContent(w http.ResponseWriter, r *http.Request) {
+	h.download(w, r, false)
+}
+
+// GetAttachmentThumbnail handles GET /patients/{patient_id}/medical-records/{id}/attachments/{attachment_id}/thumbnail
+func (h *MedicalRecordAttachmentHandler) GetAttachmentThumbnail(w http.ResponseWriter, r *http.Request) {
+	h.download(w, r, true)
+}
+
+func (h *MedicalRecordAttachmentHandler) download(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+	attachmentID := chi.URLParam(r, "attachment_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	content, err := h.attachmentService.DownloadAttachment(ctx, patientID, recordID, attachmentID, thumbnail,
+		userID, middleware.ClientIP(r), r.UserAgent())
+	if err != nil {
+		logger.Error("Failed to download attachment", err)
+		writeAttachmentError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	disposition := "attachment"
+	if thumbnail || strings.HasPrefix(content.ContentType, "image/") {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", content.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content.Data)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": content.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(content.Data)
+}
+
+// DeleteAttachment handles DELETE /patients/{patient_id}/medical-records/{id}/attachments/{attachment_id}
+func (h *MedicalRecordAttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+	attachmentID := chi.URLParam(r, "attachment_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(ctx, patientID, recordID, attachmentID, userID); err != nil {
+		logger.Error("Failed to delete attachment", err)
+		writeAttachmentError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAttachmentError maps an attachment service error to a response, falling back to
+// the given status
+func writeAttachmentError(w http.ResponseWriter, err error, fallback int) {
+	switch {
+	case strings.Contains(err.Error(), "access denied"):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrAttachmentsUnavailable):
+		http.Error(w, "Attachments are not available", http.StatusServiceUnavailable)
+	case errors.Is(err, repository.ErrMedicalRecordLocked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), fallback)
+	}
+}
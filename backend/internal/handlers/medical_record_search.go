@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// MedicalRecordSearchHandler handles HTTP requests for searching medical records
+type MedicalRecordSearchHandler struct {
+	searchService *services.MedicalRecordSearchService
+}
+
+// NewMedicalRecordSearchHandler creates a new medical record search handler
+func NewMedicalRecordSearchHandler(searchService *services.MedicalRecordSearchService) *MedicalRecordSearchHandler {
+	return &MedicalRecordSearchHandler{
+		searchService: searchService,
+	}
+}
+
+// SearchMedicalRecords handles GET /medical-records/search. q is matched against the
+// SOAP content; diagnosis_code (comma-separated, prefixes allowed) and medication filter
+// on the assessment's diagnoses and the plan's medications.
+func (h *MedicalRecordSearchHandler) SearchMedicalRecords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	req := &models.MedicalRecordSearchRequest{
+		Query: query.Get("q"),
+		Sort:  query.Get("sort"),
+	}
+	if patientID := query.Get("patient_id"); patientID != "" {
+		req.PatientID = &patientID
+	}
+	if codes := query.Get("diagnosis_code"); codes != "" {
+		req.DiagnosisCodes = strings.Split(codes, ",")
+	}
+	if medication := query.Get("medication"); medication != "" {
+		req.Medication = &medication
+	}
+	if status := query.Get("status"); status != "" {
+		req.Status = &status
+	}
+	if dateFrom := query.Get("date_from"); dateFrom != "" {
+		t, err := time.Parse("2006-01-02", dateFrom)
+		if err != nil {
+			http.Error(w, "Invalid date_from format (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		req.VisitDateFrom = &t
+	}
+	if dateTo := query.Get("date_to"); dateTo != "" {
+		t, err := time.Parse("2006-01-02", dateTo)
+		if err != nil {
+			http.Error(w, "Invalid date_to format (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		req.VisitDateTo = &t
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 200 {
+			http.Error(w, "Invalid limit (1-200)", http.StatusBadRequest)
+			return
+		}
+		req.Limit = limit
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		req.Offset = offset
+	}
+
+	result, err := h.searchService.Search(ctx, req, userID, middleware.ClientIP(r), r.UserAgent())
+	if err != nil {
+		logger.Error("Failed to search medical records", err)
+		switch {
+		case strings.Contains(err.Error(), "access denied"):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case strings.HasPrefix(err.Error(), "failed to"):
+			http.Error(w, "Failed to search medical records", http.StatusInternalServerError)
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
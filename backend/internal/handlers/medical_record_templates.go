@@ -48,6 +48,9 @@ func (h *MedicalRecordTemplateHandler) CreateTemplate(w http.ResponseWriter, r *
 	template, err := h.templateService.CreateTemplate(ctx, &req, userID)
 	if err != nil {
 		logger.Error("Failed to create template", err)
+		if writeSOAPValidationError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -156,6 +159,9 @@ func (h *MedicalRecordTemplateHandler) UpdateTemplate(w http.ResponseWriter, r *
 	template, err := h.templateService.UpdateTemplate(ctx, templateID, &req, userID)
 	if err != nil {
 		logger.Error("Failed to update template", err)
+		if writeSOAPValidationError(w, err) {
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/visitas/backend/internal/middleware"
 	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
 	"github.com/visitas/backend/internal/services"
 	"github.com/visitas/backend/pkg/logger"
 )
@@ -26,6 +28,23 @@ func NewMedicalRecordHandler(medicalRecordService *services.MedicalRecordService
 	}
 }
 
+// writeSOAPValidationError responds 422 with the invalid field paths when err is a
+// SOAP content validation failure, and reports whether it did
+func writeSOAPValidationError(w http.ResponseWriter, err error) bool {
+	var validationErr *models.SOAPValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(models.SOAPValidationResponse{
+		Error:  validationErr.Error(),
+		Fields: validationErr.Fields,
+	})
+	return true
+}
+
 // CreateMedicalRecord handles POST /patients/{patient_id}/medical-records
 func (h *MedicalRecordHandler) CreateMedicalRecord(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -50,6 +69,9 @@ func (h *MedicalRecordHandler) CreateMedicalRecord(w http.ResponseWriter, r *htt
 	record, err := h.medicalRecordService.CreateRecord(ctx, patientID, &req, userID)
 	if err != nil {
 		logger.Error("Failed to create medical record", err)
+		if writeSOAPValidationError(w, err) {
+			return
+		}
 		if strings.Contains(err.Error(), "access denied") {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
@@ -195,10 +217,17 @@ func (h *MedicalRecordHandler) UpdateMedicalRecord(w http.ResponseWriter, r *htt
 	record, err := h.medicalRecordService.UpdateRecord(ctx, patientID, recordID, &req, userID)
 	if err != nil {
 		logger.Error("Failed to update medical record", err)
+		if writeSOAPValidationError(w, err) {
+			return
+		}
 		if strings.Contains(err.Error(), "access denied") {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
+		if errors.Is(err, repository.ErrMedicalRecordLocked) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		if strings.Contains(err.Error(), "CONFLICT") {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusConflict)
@@ -241,6 +270,10 @@ func (h *MedicalRecordHandler) DeleteMedicalRecord(w http.ResponseWriter, r *htt
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
+		if errors.Is(err, repository.ErrMedicalRecordLocked) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
@@ -289,6 +322,9 @@ func (h *MedicalRecordHandler) CopyMedicalRecord(w http.ResponseWriter, r *http.
 	record, err := h.medicalRecordService.CopyRecord(ctx, sourcePatientID, recordID, targetPatientID, &req, userID)
 	if err != nil {
 		logger.Error("Failed to copy medical record", err)
+		if writeSOAPValidationError(w, err) {
+			return
+		}
 		if strings.Contains(err.Error(), "access denied") {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
@@ -330,6 +366,9 @@ func (h *MedicalRecordHandler) CreateFromTemplate(w http.ResponseWriter, r *http
 	record, err := h.medicalRecordService.CreateFromTemplate(ctx, patientID, &req, userID)
 	if err != nil {
 		logger.Error("Failed to create medical record from template", err)
+		if writeSOAPValidationError(w, err) {
+			return
+		}
 		if strings.Contains(err.Error(), "access denied") {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
@@ -405,3 +444,385 @@ func (h *MedicalRecordHandler) GetDraftRecords(w http.ResponseWriter, r *http.Re
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(records)
 }
+
+// GetRevisions handles GET /patients/{patient_id}/medical-records/{id}/revisions
+// Returns every revision of the record (who, when, why), newest first.
+func (h *MedicalRecordHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	// Extract user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revisions, err := h.medicalRecordService.ListRevisions(ctx, patientID, recordID, userID)
+	if err != nil {
+		logger.Error("Failed to list medical record revisions", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve medical record revisions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// GetRevision handles GET /patients/{patient_id}/medical-records/{id}/revisions/{revision}
+func (h *MedicalRecordHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	// Extract user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revision, err := strconv.ParseInt(chi.URLParam(r, "revision"), 10, 64)
+	if err != nil || revision < 1 {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	rev, err := h.medicalRecordService.GetRevision(ctx, patientID, recordID, revision, userID)
+	if err != nil {
+		logger.Error("Failed to get medical record revision", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve medical record revision", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rev)
+}
+
+// CompareRevisions handles GET /patients/{patient_id}/medical-records/{id}/revisions/diff?from=&to=
+// Returns the changed record fields and SOAP content paths between the two revisions.
+func (h *MedicalRecordHandler) CompareRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	// Extract user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err != nil || from < 1 {
+		http.Error(w, "Invalid or missing from revision", http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	if err != nil || to < 1 {
+		http.Error(w, "Invalid or missing to revision", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.medicalRecordService.CompareRevisions(ctx, patientID, recordID, from, to, userID)
+	if err != nil {
+		logger.Error("Failed to compare medical record revisions", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to compare medical record revisions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// RestoreRevision handles POST /patients/{patient_id}/medical-records/{id}/revisions/{revision}/restore
+// The old content is saved as a new revision; the history is never rewritten.
+func (h *MedicalRecordHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	// Extract user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revision, err := strconv.ParseInt(chi.URLParam(r, "revision"), 10, 64)
+	if err != nil || revision < 1 {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional
+	var req models.RestoreRevisionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Error("Failed to decode request body", err)
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	record, err := h.medicalRecordService.RestoreRevision(ctx, patientID, recordID, revision, &req, userID)
+	if err != nil {
+		logger.Error("Failed to restore medical record revision", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, repository.ErrMedicalRecordLocked) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "CONFLICT") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":   "CONFLICT",
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to restore medical record revision", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// SignMedicalRecord handles POST /patients/{patient_id}/medical-records/{id}/sign
+// Finalizes the record; it can only be amended by signed addenda afterwards.
+func (h *MedicalRecordHandler) SignMedicalRecord(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	// Extract user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// The body is optional
+	var req models.MedicalRecordSignRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Error("Failed to decode request body", err)
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	record, err := h.medicalRecordService.SignRecord(ctx, patientID, recordID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to sign medical record", err)
+		if writeSOAPValidationError(w, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "CONFLICT") || strings.Contains(err.Error(), "already signed") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "cannot sign") {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		http.Error(w, "Failed to sign medical record", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// ReviewAISuggestions handles POST /patients/{patient_id}/medical-records/{id}/ai-review
+// Marks AI-suggested sections as reviewed by the signed-in physician; all of them when
+// no sections are given.
+func (h *MedicalRecordHandler) ReviewAISuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	// Extract user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.MedicalRecordAIReviewRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Error("Failed to decode request body", err)
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	record, err := h.medicalRecordService.ReviewAISuggestions(ctx, patientID, recordID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to review AI suggestions", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, repository.ErrMedicalRecordLocked) || strings.Contains(err.Error(), "CONFLICT") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// VerifySignature handles GET /patients/{patient_id}/medical-records/{id}/verify
+// Recomputes the canonical hash and reports whether it matches the stored signature.
+func (h *MedicalRecordHandler) VerifySignature(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	// Extract user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	verification, err := h.medicalRecordService.VerifySignature(ctx, patientID, recordID, userID)
+	if err != nil {
+		logger.Error("Failed to verify medical record signature", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to verify medical record signature", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verification)
+}
+
+// CreateAddendum handles POST /patients/{patient_id}/medical-records/{id}/addenda
+func (h *MedicalRecordHandler) CreateAddendum(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	// Extract user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.MedicalRecordAddendumRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	addendum, err := h.medicalRecordService.AddAddendum(ctx, patientID, recordID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to create addendum", err)
+		if writeSOAPValidationError(w, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(addendum)
+}
+
+// GetAddenda handles GET /patients/{patient_id}/medical-records/{id}/addenda
+func (h *MedicalRecordHandler) GetAddenda(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recordID := chi.URLParam(r, "id")
+
+	// Extract user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	addenda, err := h.medicalRecordService.ListAddenda(ctx, patientID, recordID, userID)
+	if err != nil {
+		logger.Error("Failed to list addenda", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Failed to retrieve addenda", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(addenda)
+}
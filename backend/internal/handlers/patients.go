@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/visitas/backend/internal/middleware"
@@ -251,6 +252,14 @@ func (h *PatientHandler) AssignPatientToStaff(w http.ResponseWriter, r *http.Req
 	)
 	if err != nil {
 		logger.ErrorContext(r.Context(), "Failed to assign patient to staff", err)
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "staff member is not active") {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "Failed to assign patient to staff")
 		return
 	}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/documents"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// ReferralLetterHandler handles HTTP requests for outbound referral letters
+type ReferralLetterHandler struct {
+	letterService *services.ReferralLetterService
+}
+
+// NewReferralLetterHandler creates a new referral letter handler
+func NewReferralLetterHandler(letterService *services.ReferralLetterService) *ReferralLetterHandler {
+	return &ReferralLetterHandler{
+		letterService: letterService,
+	}
+}
+
+// CreateLetter handles POST /patients/{patient_id}/referrals
+func (h *ReferralLetterHandler) CreateLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReferralLetterCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	letter, err := h.letterService.CreateLetter(ctx, patientID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to create referral letter", err)
+		writeReferralError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(letter)
+}
+
+// ListLetters handles GET /patients/{patient_id}/referrals
+func (h *ReferralLetterHandler) ListLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &models.ReferralLetterFilter{}
+	if status := query.Get("status"); status != "" {
+		if status != models.ReferralStatusDraft && status != models.ReferralStatusSent && status != models.ReferralStatusAcknowledged {
+			http.Error(w, "Invalid status (use draft, sent or acknowledged)", http.StatusBadRequest)
+			return
+		}
+		filter.Status = &status
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 200 {
+			http.Error(w, "Invalid limit (1-200)", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	letters, err := h.letterService.ListLetters(ctx, patientID, filter, userID)
+	if err != nil {
+		logger.Error("Failed to list referral letters", err)
+		writeReferralError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"referrals": letters,
+		"total":     len(letters),
+	})
+}
+
+// GetLetter handles GET /patients/{patient_id}/referrals/{id}
+func (h *ReferralLetterHandler) GetLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	referralID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	letter, err := h.letterService.GetLetter(ctx, patientID, referralID, userID)
+	if err != nil {
+		logger.Error("Failed to get referral letter", err)
+		writeReferralError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letter)
+}
+
+// UpdateLetter handles PUT /patients/{patient_id}/referrals/{id}
+func (h *ReferralLetterHandler) UpdateLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	referralID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReferralLetterUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	letter, err := h.letterService.UpdateDraft(ctx, patientID, referralID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to update referral letter", err)
+		writeReferralError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letter)
+}
+
+// DeleteLetter handles DELETE /patients/{patient_id}/referrals/{id}
+func (h *ReferralLetterHandler) DeleteLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	referralID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.letterService.DeleteDraft(ctx, patientID, referralID, userID); err != nil {
+		logger.Error("Failed to delete referral letter", err)
+		writeReferralError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SendLetter handles POST /patients/{patient_id}/referrals/{id}/send
+func (h *ReferralLetterHandler) SendLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	referralID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReferralSendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	letter, err := h.letterService.SendLetter(ctx, patientID, referralID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to send referral letter", err)
+		writeReferralError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letter)
+}
+
+// AcknowledgeLetter handles POST /patients/{patient_id}/referrals/{id}/acknowledge
+func (h *ReferralLetterHandler) AcknowledgeLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	referralID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReferralAcknowledgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	letter, err := h.letterService.AcknowledgeLetter(ctx, patientID, referralID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to acknowledge referral letter", err)
+		writeReferralError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letter)
+}
+
+// GetLetterPDF handles GET /patients/{patient_id}/referrals/{id}/pdf
+func (h *ReferralLetterHandler) GetLetterPDF(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	referralID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	letter, pdf, err := h.letterService.DownloadPDF(ctx, patientID, referralID,
+		userID, middleware.ClientIP(r), r.UserAgent())
+	if err != nil {
+		logger.Error("Failed to download referral letter", err)
+		writeReferralError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s_%s.pdf", documents.ReferralTitle, letter.RecipientInstitution)
+	if letter.Status == models.ReferralStatusDraft {
+		fileName = fmt.Sprintf("%s_%s_下書き.pdf", documents.ReferralTitle, letter.RecipientInstitution)
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": fileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(pdf)
+}
+
+// writeReferralError maps a referral letter or inbound referral service error to a
+// response, falling back to the given status for validation errors
+func writeReferralError(w http.ResponseWriter, err error, fallback int) {
+	switch {
+	case strings.Contains(err.Error(), "access denied"):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrReferralsUnavailable):
+		http.Error(w, "Referral documents are not available", http.StatusServiceUnavailable)
+	case errors.Is(err, services.ErrInboundReferralsUnavailable):
+		http.Error(w, "Inbound referrals are not available", http.StatusServiceUnavailable)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.HasPrefix(err.Error(), "CONFLICT"):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case strings.HasPrefix(err.Error(), "failed to"):
+		http.Error(w, "Failed to process referral", http.StatusInternalServerError)
+	default:
+		http.Error(w, err.Error(), fallback)
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// etaStreamKeepAlive is how often an idle ETA stream sends a comment so proxies keep it open
+const etaStreamKeepAlive = 20 * time.Second
+
+// StaffLocationHandler handles HTTP requests for staff location reports and live visit ETAs
+type StaffLocationHandler struct {
+	staffLocationService *services.StaffLocationService
+}
+
+// NewStaffLocationHandler creates a new staff location handler
+func NewStaffLocationHandler(staffLocationService *services.StaffLocationService) *StaffLocationHandler {
+	return &StaffLocationHandler{
+		staffLocationService: staffLocationService,
+	}
+}
+
+// ReportMyLocation handles POST /me/location
+// Accepts a batch of the caller's GPS fixes and returns the ETA of their next visit.
+func (h *StaffLocationHandler) ReportMyLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.StaffLocationBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.staffLocationService.ReportLocation(ctx, userID, &req)
+	if err != nil {
+		var rateErr *services.LocationRateLimitError
+		if errors.As(err, &rateErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateErr.RetryAfter.Seconds()))))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		logger.Error("Failed to report location", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "failed to") {
+			http.Error(w, "Failed to store location", http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetRunningLate handles GET /tracking/running-late?date=YYYY-MM-DD
+// Returns the visits of the day that have not started and are running late; date defaults to today.
+func (h *StaffLocationHandler) GetRunningLate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var date *civil.Date
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		d, err := civil.ParseDate(dateStr)
+		if err != nil {
+			http.Error(w, "Invalid date format (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		date = &d
+	}
+
+	schedules, err := h.staffLocationService.GetRunningLate(ctx, userID, date)
+	if err != nil {
+		logger.Error("Failed to get running late visits", err)
+		http.Error(w, "Failed to retrieve running late visits", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// StreamETAs handles GET /tracking/eta-stream
+// Server-sent events: one "eta" event per ETA update for patients the caller can access.
+// The stream ends with the request timeout; EventSource clients reconnect automatically.
+func (h *StaffLocationHandler) StreamETAs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	// The server's write timeout is meant for ordinary responses, not a long-lived stream
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	updates := h.staffLocationService.SubscribeETAs(ctx, userID)
+	keepAlive := time.NewTicker(etaStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case eta, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(eta)
+			if err != nil {
+				logger.Error("Failed to encode ETA update", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: eta\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
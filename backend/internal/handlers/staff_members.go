@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// StaffMemberHandler handles HTTP requests for the staff directory
+type StaffMemberHandler struct {
+	staffMemberService *services.StaffMemberService
+}
+
+// NewStaffMemberHandler creates a new staff member handler
+func NewStaffMemberHandler(staffMemberService *services.StaffMemberService) *StaffMemberHandler {
+	return &StaffMemberHandler{
+		staffMemberService: staffMemberService,
+	}
+}
+
+// CreateStaffMember handles POST /staff
+func (h *StaffMemberHandler) CreateStaffMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.StaffMemberCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	staff, err := h.staffMemberService.CreateStaffMember(ctx, &req, userID)
+	if err != nil {
+		logger.Error("Failed to create staff member", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(staff)
+}
+
+// GetStaffMember handles GET /staff/{id}
+func (h *StaffMemberHandler) GetStaffMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	staffID := chi.URLParam(r, "id")
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	staff, err := h.staffMemberService.GetStaffMember(ctx, staffID)
+	if err != nil {
+		logger.Error("Failed to get staff member", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(staff)
+}
+
+// ListStaffMembers handles GET /staff
+func (h *StaffMemberHandler) ListStaffMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filter := &models.StaffMemberFilter{}
+
+	if orgID := r.URL.Query().Get("organization_id"); orgID != "" {
+		filter.OrganizationID = &orgID
+	}
+	if role := r.URL.Query().Get("role"); role != "" {
+		filter.Role = &role
+	}
+	if specialty := r.URL.Query().Get("specialty"); specialty != "" {
+		filter.Specialty = &specialty
+	}
+	if availability := r.URL.Query().Get("availability_status"); availability != "" {
+		filter.AvailabilityStatus = &availability
+	}
+	if accountStatus := r.URL.Query().Get("account_status"); accountStatus != "" {
+		filter.AccountStatus = &accountStatus
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = offset
+	}
+
+	staff, err := h.staffMemberService.ListStaffMembers(ctx, filter)
+	if err != nil {
+		logger.Error("Failed to list staff members", err)
+		http.Error(w, "Failed to retrieve staff members", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(staff)
+}
+
+// UpdateStaffMember handles PUT /staff/{id}
+func (h *StaffMemberHandler) UpdateStaffMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	staffID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.StaffMemberUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	staff, err := h.staffMemberService.UpdateStaffMember(ctx, staffID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to update staff member", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(staff)
+}
+
+// DeleteStaffMember handles DELETE /staff/{id}
+func (h *StaffMemberHandler) DeleteStaffMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	staffID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.staffMemberService.DeleteStaffMember(ctx, staffID, userID); err != nil {
+		logger.Error("Failed to delete staff member", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete staff member", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetExpiringLicenses handles GET /staff/license-expiring?days=N
+func (h *StaffMemberHandler) GetExpiringLicenses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	days := 30 // Default: licenses expiring within 30 days
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil {
+			http.Error(w, "Invalid days parameter", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	staff, err := h.staffMemberService.GetExpiringLicenses(ctx, days)
+	if err != nil {
+		logger.Error("Failed to get expiring licenses", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(staff)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// StaffScheduleHandler handles HTTP requests for the signed-in staff member's schedule
+type StaffScheduleHandler struct {
+	staffScheduleService *services.StaffScheduleService
+}
+
+// NewStaffScheduleHandler creates a new staff schedule handler
+func NewStaffScheduleHandler(staffScheduleService *services.StaffScheduleService) *StaffScheduleHandler {
+	return &StaffScheduleHandler{
+		staffScheduleService: staffScheduleService,
+	}
+}
+
+// GetMySchedule handles GET /me/schedule?date=YYYY-MM-DD
+// Returns the caller's visits for the day across all patients; date defaults to today.
+func (h *StaffScheduleHandler) GetMySchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var date *civil.Date
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		d, err := civil.ParseDate(dateStr)
+		if err != nil {
+			http.Error(w, "Invalid date format (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		date = &d
+	}
+
+	schedule, err := h.staffScheduleService.GetDaySchedule(ctx, userID, date)
+	if err != nil {
+		logger.Error("Failed to get staff schedule", err)
+		http.Error(w, "Failed to retrieve schedule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/civil"
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/documents"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// StatutoryDocumentHandler handles HTTP requests for statutory documents
+type StatutoryDocumentHandler struct {
+	documentService *services.StatutoryDocumentService
+}
+
+// NewStatutoryDocumentHandler creates a new statutory document handler
+func NewStatutoryDocumentHandler(documentService *services.StatutoryDocumentService) *StatutoryDocumentHandler {
+	return &StatutoryDocumentHandler{
+		documentService: documentService,
+	}
+}
+
+// IssueDocument handles POST /patients/{patient_id}/documents
+func (h *StatutoryDocumentHandler) IssueDocument(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.StatutoryDocumentCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := h.documentService.IssueDocument(ctx, patientID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to issue statutory document", err)
+		writeDocumentError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(doc)
+}
+
+// ListDocuments handles GET /patients/{patient_id}/documents
+func (h *StatutoryDocumentHandler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &models.StatutoryDocumentFilter{}
+	if documentType := query.Get("type"); documentType != "" {
+		filter.DocumentType = &documentType
+	}
+	if status := query.Get("status"); status != "" {
+		if status != "issued" && status != "superseded" {
+			http.Error(w, "Invalid status (use issued or superseded)", http.StatusBadRequest)
+			return
+		}
+		filter.Status = &status
+	}
+	if validOnStr := query.Get("valid_on"); validOnStr != "" {
+		d, err := civil.ParseDate(validOnStr)
+		if err != nil {
+			http.Error(w, "Invalid valid_on date format (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		filter.ValidOn = &d
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 200 {
+			http.Error(w, "Invalid limit (1-200)", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	docs, err := h.documentService.ListDocuments(ctx, patientID, filter, userID)
+	if err != nil {
+		logger.Error("Failed to list statutory documents", err)
+		writeDocumentError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"documents": docs,
+		"total":     len(docs),
+	})
+}
+
+// GetDocument handles GET /patients/{patient_id}/documents/{id}
+func (h *StatutoryDocumentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	documentID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	doc, err := h.documentService.GetDocument(ctx, patientID, documentID, userID)
+	if err != nil {
+		logger.Error("Failed to get statutory document", err)
+		writeDocumentError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
+
+// GetDocumentPDF handles GET /patients/{patient_id}/documents/{id}/pdf
+func (h *StatutoryDocumentHandler) GetDocumentPDF(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	documentID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	doc, pdf, err := h.documentService.DownloadPDF(ctx, patientID, documentID,
+		userID, middleware.ClientIP(r), r.UserAgent())
+	if err != nil {
+		logger.Error("Failed to download statutory document", err)
+		writeDocumentError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	fileName := doc.DocumentType + ".pdf"
+	if form, err := documents.FormFor(doc.DocumentType); err == nil {
+		fileName = fmt.Sprintf("%s_%s.pdf", form.Title, doc.ValidFrom.String())
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": fileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(pdf)
+}
+
+// writeDocumentError maps a statutory document service error to a response, falling
+// back to the given status for validation errors
+func writeDocumentError(w http.ResponseWriter, err error, fallback int) {
+	switch {
+	case strings.Contains(err.Error(), "access denied"):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrDocumentsUnavailable):
+		http.Error(w, "Statutory documents are not available", http.StatusServiceUnavailable)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.HasPrefix(err.Error(), "failed to"):
+		http.Error(w, "Failed to process statutory document", http.StatusInternalServerError)
+	default:
+		http.Error(w, err.Error(), fallback)
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// VehicleHandler handles HTTP requests for the vehicle fleet
+type VehicleHandler struct {
+	vehicleService *services.VehicleService
+}
+
+// NewVehicleHandler creates a new vehicle handler
+func NewVehicleHandler(vehicleService *services.VehicleService) *VehicleHandler {
+	return &VehicleHandler{
+		vehicleService: vehicleService,
+	}
+}
+
+// CreateVehicle handles POST /vehicles
+func (h *VehicleHandler) CreateVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.VehicleCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	vehicle, err := h.vehicleService.CreateVehicle(ctx, &req, userID)
+	if err != nil {
+		logger.Error("Failed to create vehicle", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(vehicle)
+}
+
+// GetVehicle handles GET /vehicles/{id}
+func (h *VehicleHandler) GetVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vehicleID := chi.URLParam(r, "id")
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vehicle, err := h.vehicleService.GetVehicle(ctx, vehicleID)
+	if err != nil {
+		logger.Error("Failed to get vehicle", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicle)
+}
+
+// ListVehicles handles GET /vehicles
+func (h *VehicleHandler) ListVehicles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filter := &models.VehicleFilter{}
+
+	if orgID := r.URL.Query().Get("organization_id"); orgID != "" {
+		filter.OrganizationID = &orgID
+	}
+	if vehicleType := r.URL.Query().Get("vehicle_type"); vehicleType != "" {
+		filter.VehicleType = &vehicleType
+	}
+	if status := r.URL.Query().Get("vehicle_status"); status != "" {
+		filter.VehicleStatus = &status
+	}
+	if assignedTo := r.URL.Query().Get("currently_assigned_to"); assignedTo != "" {
+		filter.CurrentlyAssignedTo = &assignedTo
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = offset
+	}
+
+	vehicles, err := h.vehicleService.ListVehicles(ctx, filter)
+	if err != nil {
+		logger.Error("Failed to list vehicles", err)
+		http.Error(w, "Failed to retrieve vehicles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicles)
+}
+
+// UpdateVehicle handles PUT /vehicles/{id}
+func (h *VehicleHandler) UpdateVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vehicleID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.VehicleUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	vehicle, err := h.vehicleService.UpdateVehicle(ctx, vehicleID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to update vehicle", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicle)
+}
+
+// DeleteVehicle handles DELETE /vehicles/{id}
+func (h *VehicleHandler) DeleteVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vehicleID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.vehicleService.DeleteVehicle(ctx, vehicleID, userID); err != nil {
+		logger.Error("Failed to delete vehicle", err)
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetExpiryReport handles GET /vehicles/expiry-report?days=N
+func (h *VehicleHandler) GetExpiryReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, ok := middleware.GetUserIDFromContext(ctx); !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	days := 30 // Default: deadlines within 30 days
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil {
+			http.Error(w, "Invalid days parameter", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	report, err := h.vehicleService.GetExpiryReport(ctx, days)
+	if err != nil {
+		logger.Error("Failed to build vehicle expiry report", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// VisitScheduleRecurrenceHandler handles HTTP requests for recurring visit rules
+type VisitScheduleRecurrenceHandler struct {
+	recurrenceService *services.VisitScheduleRecurrenceService
+}
+
+// NewVisitScheduleRecurrenceHandler creates a new visit schedule recurrence handler
+func NewVisitScheduleRecurrenceHandler(recurrenceService *services.VisitScheduleRecurrenceService) *VisitScheduleRecurrenceHandler {
+	return &VisitScheduleRecurrenceHandler{
+		recurrenceService: recurrenceService,
+	}
+}
+
+// CreateRecurrence handles POST /patients/{patient_id}/schedule-recurrences
+func (h *VisitScheduleRecurrenceHandler) CreateRecurrence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.VisitScheduleRecurrenceCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	recurrence, err := h.recurrenceService.CreateRecurrence(ctx, patientID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to create visit schedule recurrence", err)
+		if writeScheduleConflict(w, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(recurrence)
+}
+
+// GetRecurrences handles GET /patients/{patient_id}/schedule-recurrences
+func (h *VisitScheduleRecurrenceHandler) GetRecurrences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	recurrences, err := h.recurrenceService.ListRecurrences(ctx, patientID, userID)
+	if err != nil {
+		logger.Error("Failed to list visit schedule recurrences", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Failed to retrieve visit schedule recurrences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recurrences)
+}
+
+// GetRecurrence handles GET /patients/{patient_id}/schedule-recurrences/{id}
+func (h *VisitScheduleRecurrenceHandler) GetRecurrence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recurrenceID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	recurrence, err := h.recurrenceService.GetRecurrence(ctx, patientID, recurrenceID, userID)
+	if err != nil {
+		logger.Error("Failed to get visit schedule recurrence", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve visit schedule recurrence", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recurrence)
+}
+
+// DeleteRecurrence handles DELETE /patients/{patient_id}/schedule-recurrences/{id}
+// Upcoming visits that have not started are removed with the rule.
+func (h *VisitScheduleRecurrenceHandler) DeleteRecurrence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	recurrenceID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.recurrenceService.DeleteRecurrence(ctx, patientID, recurrenceID, userID); err != nil {
+		logger.Error("Failed to delete visit schedule recurrence", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
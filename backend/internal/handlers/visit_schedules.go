@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -16,15 +18,46 @@ import (
 // VisitScheduleHandler handles HTTP requests for visit schedules
 type VisitScheduleHandler struct {
 	visitScheduleService *services.VisitScheduleService
+	recurrenceService    *services.VisitScheduleRecurrenceService
 }
 
 // NewVisitScheduleHandler creates a new visit schedule handler
-func NewVisitScheduleHandler(visitScheduleService *services.VisitScheduleService) *VisitScheduleHandler {
+func NewVisitScheduleHandler(visitScheduleService *services.VisitScheduleService, recurrenceService *services.VisitScheduleRecurrenceService) *VisitScheduleHandler {
 	return &VisitScheduleHandler{
 		visitScheduleService: visitScheduleService,
+		recurrenceService:    recurrenceService,
 	}
 }
 
+// writeScheduleConflict responds 409 with the conflicting visits when err is a
+// staff scheduling conflict, and reports whether it did
+func writeScheduleConflict(w http.ResponseWriter, err error) bool {
+	var conflictErr *services.ScheduleConflictError
+	if !errors.As(err, &conflictErr) {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(models.ScheduleConflictResponse{
+		Error:                  conflictErr.Error(),
+		StaffID:                conflictErr.StaffID,
+		ConflictingScheduleIDs: conflictErr.ScheduleIDs(),
+		Conflicts:              conflictErr.Conflicts,
+	})
+	return true
+}
+
+// parseEditScope reads the ?scope= parameter for edits to recurring visits:
+// "this" (default) edits one occurrence, "following" edits it and all later ones
+func parseEditScope(r *http.Request) (string, bool) {
+	scope := r.URL.Query().Get("scope")
+	if scope == "" {
+		return "this", true
+	}
+	return scope, scope == "this" || scope == "following"
+}
+
 // CreateVisitSchedule handles POST /patients/{patient_id}/schedules
 func (h *VisitScheduleHandler) CreateVisitSchedule(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -47,6 +80,9 @@ func (h *VisitScheduleHandler) CreateVisitSchedule(w http.ResponseWriter, r *htt
 	schedule, err := h.visitScheduleService.CreateVisitSchedule(ctx, patientID, &req, userID)
 	if err != nil {
 		logger.Error("Failed to create visit schedule", err)
+		if writeScheduleConflict(w, err) {
+			return
+		}
 		if err.Error() == "patient not found" {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
@@ -194,7 +230,7 @@ func (h *VisitScheduleHandler) GetVisitSchedules(w http.ResponseWriter, r *http.
 	json.NewEncoder(w).Encode(schedules)
 }
 
-// UpdateVisitSchedule handles PUT /patients/{patient_id}/schedules/{id}
+// UpdateVisitSchedule handles PUT /patients/{patient_id}/schedules/{id}?scope=this|following
 func (h *VisitScheduleHandler) UpdateVisitSchedule(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	patientID := chi.URLParam(r, "patient_id")
@@ -207,6 +243,12 @@ func (h *VisitScheduleHandler) UpdateVisitSchedule(w http.ResponseWriter, r *htt
 		return
 	}
 
+	scope, ok := parseEditScope(r)
+	if !ok {
+		http.Error(w, "Invalid scope (expected this or following)", http.StatusBadRequest)
+		return
+	}
+
 	var req models.VisitScheduleUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logger.Error("Failed to decode request body", err)
@@ -214,17 +256,30 @@ func (h *VisitScheduleHandler) UpdateVisitSchedule(w http.ResponseWriter, r *htt
 		return
 	}
 
-	schedule, err := h.visitScheduleService.UpdateVisitSchedule(ctx, patientID, scheduleID, &req, userID)
+	var schedule *models.VisitSchedule
+	var err error
+	if scope == "following" {
+		schedule, err = h.recurrenceService.UpdateFollowing(ctx, patientID, scheduleID, &req, userID)
+	} else {
+		schedule, err = h.visitScheduleService.UpdateVisitSchedule(ctx, patientID, scheduleID, &req, userID)
+	}
 	if err != nil {
 		logger.Error("Failed to update visit schedule", err)
-		if err.Error() == "visit schedule not found" {
+		if writeScheduleConflict(w, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
-		if err.Error() == "access denied: you do not have permission to update schedules for this patient" {
+		if strings.Contains(err.Error(), "access denied") {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
+		if strings.Contains(err.Error(), "invalid status transition") || strings.Contains(err.Error(), "cannot be edited") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -233,7 +288,7 @@ func (h *VisitScheduleHandler) UpdateVisitSchedule(w http.ResponseWriter, r *htt
 	json.NewEncoder(w).Encode(schedule)
 }
 
-// DeleteVisitSchedule handles DELETE /patients/{patient_id}/schedules/{id}
+// DeleteVisitSchedule handles DELETE /patients/{patient_id}/schedules/{id}?scope=this|following
 func (h *VisitScheduleHandler) DeleteVisitSchedule(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	patientID := chi.URLParam(r, "patient_id")
@@ -246,13 +301,28 @@ func (h *VisitScheduleHandler) DeleteVisitSchedule(w http.ResponseWriter, r *htt
 		return
 	}
 
-	err := h.visitScheduleService.DeleteVisitSchedule(ctx, patientID, scheduleID, userID)
+	scope, ok := parseEditScope(r)
+	if !ok {
+		http.Error(w, "Invalid scope (expected this or following)", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if scope == "following" {
+		err = h.recurrenceService.DeleteFollowing(ctx, patientID, scheduleID, userID)
+	} else {
+		err = h.visitScheduleService.DeleteVisitSchedule(ctx, patientID, scheduleID, userID)
+	}
 	if err != nil {
 		logger.Error("Failed to delete visit schedule", err)
-		if err.Error() == "access denied: you do not have permission to delete schedules for this patient" {
+		if strings.Contains(err.Error(), "access denied") {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
+		if strings.Contains(err.Error(), "not part of a recurrence") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
@@ -316,7 +386,8 @@ func (h *VisitScheduleHandler) AssignStaff(w http.ResponseWriter, r *http.Reques
 	}
 
 	var req struct {
-		StaffID string `json:"staff_id"`
+		StaffID           string `json:"staff_id"`
+		OverrideConflicts bool   `json:"override_conflicts"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logger.Error("Failed to decode request body", err)
@@ -329,13 +400,24 @@ func (h *VisitScheduleHandler) AssignStaff(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	schedule, err := h.visitScheduleService.AssignStaff(ctx, patientID, scheduleID, req.StaffID, userID)
+	schedule, err := h.visitScheduleService.AssignStaff(ctx, patientID, scheduleID, req.StaffID, req.OverrideConflicts, userID)
 	if err != nil {
 		logger.Error("Failed to assign staff", err)
-		if err.Error() == "access denied: you do not have permission to assign staff for this patient's schedules" {
+		if writeScheduleConflict(w, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "access denied") {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid status transition") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -344,8 +426,8 @@ func (h *VisitScheduleHandler) AssignStaff(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(schedule)
 }
 
-// UpdateStatus handles POST /patients/{patient_id}/schedules/{id}/status
-func (h *VisitScheduleHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+// AssignVehicle handles POST /patients/{patient_id}/schedules/{id}/assign-vehicle
+func (h *VisitScheduleHandler) AssignVehicle(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	patientID := chi.URLParam(r, "patient_id")
 	scheduleID := chi.URLParam(r, "id")
@@ -358,8 +440,52 @@ func (h *VisitScheduleHandler) UpdateStatus(w http.ResponseWriter, r *http.Reque
 	}
 
 	var req struct {
-		Status string `json:"status"`
+		VehicleID string `json:"vehicle_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.VehicleID == "" {
+		http.Error(w, "vehicle_id is required", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.visitScheduleService.AssignVehicle(ctx, patientID, scheduleID, req.VehicleID, userID)
+	if err != nil {
+		logger.Error("Failed to assign vehicle", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// UpdateStatus handles POST /patients/{patient_id}/schedules/{id}/status
+func (h *VisitScheduleHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	scheduleID := chi.URLParam(r, "id")
+
+	// Get user ID from context
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.VisitScheduleStatusChangeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logger.Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -371,13 +497,21 @@ func (h *VisitScheduleHandler) UpdateStatus(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	schedule, err := h.visitScheduleService.UpdateStatus(ctx, patientID, scheduleID, req.Status, userID)
+	schedule, err := h.visitScheduleService.UpdateStatus(ctx, patientID, scheduleID, &req, userID)
 	if err != nil {
 		logger.Error("Failed to update status", err)
-		if err.Error() == "access denied: you do not have permission to update status for this patient's schedules" {
+		if strings.Contains(err.Error(), "access denied") {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid status transition") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -385,3 +519,34 @@ func (h *VisitScheduleHandler) UpdateStatus(w http.ResponseWriter, r *http.Reque
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(schedule)
 }
+
+// GetStatusHistory handles GET /patients/{patient_id}/schedules/{id}/status-history
+func (h *VisitScheduleHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	scheduleID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	events, err := h.visitScheduleService.GetStatusHistory(ctx, patientID, scheduleID, userID)
+	if err != nil {
+		logger.Error("Failed to get status history", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve status history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/civil"
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// VisitVerificationHandler handles HTTP requests for geofence-verified visit check-ins and check-outs
+type VisitVerificationHandler struct {
+	visitVerificationService *services.VisitVerificationService
+}
+
+// NewVisitVerificationHandler creates a new visit verification handler
+func NewVisitVerificationHandler(visitVerificationService *services.VisitVerificationService) *VisitVerificationHandler {
+	return &VisitVerificationHandler{
+		visitVerificationService: visitVerificationService,
+	}
+}
+
+// CheckIn handles POST /patients/{patient_id}/schedules/{id}/check-in
+func (h *VisitVerificationHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	h.check(w, r, h.visitVerificationService.CheckIn)
+}
+
+// CheckOut handles POST /patients/{patient_id}/schedules/{id}/check-out
+func (h *VisitVerificationHandler) CheckOut(w http.ResponseWriter, r *http.Request) {
+	h.check(w, r, h.visitVerificationService.CheckOut)
+}
+
+type visitCheckFunc func(ctx context.Context, patientID, scheduleID string, req *models.VisitCheckRequest, staffID string) (*models.VisitCheckResult, error)
+
+func (h *VisitVerificationHandler) check(w http.ResponseWriter, r *http.Request, checkFn visitCheckFunc) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	scheduleID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.VisitCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := checkFn(ctx, patientID, scheduleID, &req, userID)
+	if err != nil {
+		logger.Error("Failed to record visit check", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid status transition") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if strings.Contains(err.Error(), "failed to") {
+			http.Error(w, "Failed to record visit check", http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetChecks handles GET /patients/{patient_id}/schedules/{id}/checks
+func (h *VisitVerificationHandler) GetChecks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	scheduleID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	checks, err := h.visitVerificationService.GetChecks(ctx, patientID, scheduleID, userID)
+	if err != nil {
+		logger.Error("Failed to get visit checks", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve visit checks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(checks)
+}
+
+// GetUnverifiedReport handles GET /visit-verifications/unverified?from=YYYY-MM-DD&to=YYYY-MM-DD
+// from defaults to the first of the current month and to defaults to today.
+func (h *VisitVerificationHandler) GetUnverifiedReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var from, to *civil.Date
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		d, err := civil.ParseDate(fromStr)
+		if err != nil {
+			http.Error(w, "Invalid from date format (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		from = &d
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		d, err := civil.ParseDate(toStr)
+		if err != nil {
+			http.Error(w, "Invalid to date format (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		to = &d
+	}
+
+	report, err := h.visitVerificationService.GetUnverifiedReport(ctx, userID, from, to)
+	if err != nil {
+		logger.Error("Failed to get unverified visit report", err)
+		if strings.Contains(err.Error(), "failed to") {
+			http.Error(w, "Failed to retrieve unverified visits", http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// VoiceSOAPHandler handles HTTP requests for voice-to-SOAP drafts
+type VoiceSOAPHandler struct {
+	voiceSOAPService *services.VoiceSOAPService
+	maxUploadBytes   int64
+}
+
+// NewVoiceSOAPHandler creates a new voice-to-SOAP handler
+func NewVoiceSOAPHandler(voiceSOAPService *services.VoiceSOAPService, maxUploadBytes int64) *VoiceSOAPHandler {
+	return &VoiceSOAPHandler{
+		voiceSOAPService: voiceSOAPService,
+		maxUploadBytes:   maxUploadBytes,
+	}
+}
+
+// SubmitRecording handles POST /patients/{patient_id}/voice-drafts
+// The recording is processed in the background; the pending job is returned with 202
+// and polled via GET /patients/{patient_id}/voice-drafts/{id} until it names the draft record.
+func (h *VoiceSOAPHandler) SubmitRecording(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Leave room for the multipart framing and the other form fields
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadBytes+1<<20)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("Recording exceeds the maximum size of %d bytes", h.maxUploadBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("audio")
+	if err != nil {
+		http.Error(w, "audio is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxUploadBytes {
+		http.Error(w, fmt.Sprintf("Recording exceeds the maximum size of %d bytes", h.maxUploadBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	audio, err := io.ReadAll(file)
+	if err != nil {
+		logger.Error("Failed to read uploaded recording", err)
+		http.Error(w, "Failed to read recording", http.StatusBadRequest)
+		return
+	}
+
+	visitStartedAt, err := time.Parse(time.RFC3339, r.FormValue("visit_started_at"))
+	if err != nil {
+		http.Error(w, "visit_started_at must be an RFC 3339 timestamp", http.StatusBadRequest)
+		return
+	}
+
+	req := &models.VoiceSOAPRequest{
+		VisitStartedAt: visitStartedAt,
+		VisitType:      r.FormValue("visit_type"),
+		Language:       r.FormValue("language"),
+		Audio:          audio,
+	}
+	if scheduleID := strings.TrimSpace(r.FormValue("schedule_id")); scheduleID != "" {
+		req.ScheduleID = &scheduleID
+	}
+
+	job, err := h.voiceSOAPService.SubmitRecording(ctx, patientID, req, userID)
+	if err != nil {
+		logger.Error("Failed to submit recording", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not available") {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/patients/%s/voice-drafts/%s", patientID, job.JobID))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// ListJobs handles GET /patients/{patient_id}/voice-drafts
+func (h *VoiceSOAPHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	jobs, err := h.voiceSOAPService.ListJobs(ctx, patientID, userID, limit)
+	if err != nil {
+		logger.Error("Failed to list voice SOAP jobs", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs":  jobs,
+		"total": len(jobs),
+	})
+}
+
+// GetJob handles GET /patients/{patient_id}/voice-drafts/{id}
+func (h *VoiceSOAPHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	patientID := chi.URLParam(r, "patient_id")
+	jobID := chi.URLParam(r, "id")
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	job, err := h.voiceSOAPService.GetJob(ctx, patientID, jobID, userID)
+	if err != nil {
+		logger.Error("Failed to get voice SOAP job", err)
+		if strings.Contains(err.Error(), "access denied") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
@@ -170,6 +170,12 @@ func extractAccessedFields(r *http.Request) []string {
 	return []string{}
 }
 
+// ClientIP extracts the client IP address from the request, for handlers that write
+// their own audit entries
+func ClientIP(r *http.Request) string {
+	return getClientIP(r)
+}
+
 // getClientIP extracts the client IP address from the request
 func getClientIP(r *http.Request) string {
 	// Check X-Forwarded-For header first (for requests behind a proxy)
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"cloud.google.com/go/spanner"
@@ -37,6 +38,21 @@ type ACPRecord struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// HasDNAR reports whether the record's directives include a Do-Not-Attempt-Resuscitation order.
+// Directives that cannot be read return an error rather than "no DNAR".
+func (a *ACPRecord) HasDNAR() (bool, error) {
+	var directives struct {
+		DNAR bool `json:"dnar"`
+	}
+	if len(a.Directives) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(a.Directives, &directives); err != nil {
+		return false, fmt.Errorf("failed to parse ACP directives: %w", err)
+	}
+	return directives.DNAR, nil
+}
+
 // ACPRecordCreateRequest represents the request body for creating an ACP record
 type ACPRecordCreateRequest struct {
 	RecordedDate      time.Time       `json:"recorded_date" validate:"required"`
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+// CalendarFeedToken is a revocable subscription to an ICS feed of visit schedules
+type CalendarFeedToken struct {
+	TokenID   string             `json:"token_id"`
+	FeedType  string             `json:"feed_type"`  // "staff" | "patient"
+	SubjectID string             `json:"subject_id"` // staff ID or patient ID
+	Label     spanner.NullString `json:"label,omitempty"`
+
+	CreatedBy      string           `json:"created_by"`
+	CreatedAt      time.Time        `json:"created_at"`
+	LastAccessedAt spanner.NullTime `json:"last_accessed_at,omitempty"`
+
+	Revoked   bool               `json:"revoked"`
+	RevokedAt spanner.NullTime   `json:"revoked_at,omitempty"`
+	RevokedBy spanner.NullString `json:"revoked_by,omitempty"`
+
+	// Signed subscription URL path; filled in by the service, not stored
+	FeedPath string `json:"feed_path,omitempty"`
+}
+
+// CalendarFeedTokenCreateRequest represents the request body for creating a feed URL.
+// Staff feeds are always for the caller; patient feeds require access to the patient.
+type CalendarFeedTokenCreateRequest struct {
+	FeedType  string  `json:"feed_type" validate:"required,oneof=staff patient"`
+	PatientID *string `json:"patient_id,omitempty"` // required for patient feeds
+	Label     *string `json:"label,omitempty"`
+}
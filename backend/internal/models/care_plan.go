@@ -2,8 +2,10 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"cloud.google.com/go/civil"
 	"cloud.google.com/go/spanner"
 )
 
@@ -22,7 +24,7 @@ type CarePlan struct {
 	PeriodStart time.Time    `json:"period_start"`
 	PeriodEnd   spanner.NullTime `json:"period_end,omitempty"`
 
-	// JSONB fields - Goals (SMART format) and Activity plans
+	// JSONB fields - Goals ([]CarePlanGoal) and Activity plans ([]CarePlanActivity)
 	Goals      json.RawMessage `json:"goals,omitempty"`
 	Activities json.RawMessage `json:"activities,omitempty"`
 
@@ -65,7 +67,8 @@ type CarePlanUpdateRequest struct {
 	Goals      json.RawMessage `json:"goals,omitempty"`
 	Activities json.RawMessage `json:"activities,omitempty"`
 
-	ExpectedVersion *int64 `json:"expected_version,omitempty"` // Optimistic locking
+	ExpectedVersion *int64  `json:"expected_version,omitempty"` // Optimistic locking
+	ChangeReason    *string `json:"change_reason,omitempty"`    // Recorded on the new version's snapshot
 }
 
 // CarePlanFilter represents filter options for listing care plans
@@ -79,3 +82,255 @@ type CarePlanFilter struct {
 	Limit           int
 	Offset          int
 }
+
+// CarePlanActivity is one planned activity of a care plan (FHIR CarePlan.activity.detail).
+// Activities with a frequency generate recurring visit schedules while the plan is active;
+// the schedules reference the plan by care_plan_ref and the activity by activity_ref.
+type CarePlanActivity struct {
+	ActivityID  string  `json:"activity_id"` // assigned on save when empty
+	Kind        string  `json:"kind"`        // "home_medical_visit" | "home_nursing_visit" | "rehabilitation" | "medication_management" | "other"
+	Description *string `json:"description,omitempty"`
+
+	AssignedRole    string  `json:"assigned_role"` // doctor, nurse, therapist, pharmacist, care_manager
+	AssignedStaffID *string `json:"assigned_staff_id,omitempty"`
+	DurationMinutes int64   `json:"duration_minutes"`
+
+	// Frequency is the visit pattern; nil for activities that are not scheduled (e.g. as needed)
+	Frequency *CarePlanActivityFrequency `json:"frequency,omitempty"`
+}
+
+// CarePlanActivityFrequency is a visit pattern in the vocabulary of visit schedule recurrences
+type CarePlanActivityFrequency struct {
+	Pattern       string   `json:"pattern"`                    // "weekly" | "biweekly" | "monthly_nth_weekday"
+	ByWeekday     []string `json:"by_weekday"`                 // RRULE BYDAY codes: MO, TU, WE, TH, FR, SA, SU
+	ByWeekOfMonth []int64  `json:"by_week_of_month,omitempty"` // monthly_nth_weekday only: 1-5, -1 = last
+	StartTime     *string  `json:"start_time,omitempty"`       // HH:MM
+	EndTime       *string  `json:"end_time,omitempty"`         // HH:MM
+}
+
+// CarePlanActivityKinds lists the valid activity kinds
+var CarePlanActivityKinds = map[string]bool{
+	"home_medical_visit":    true,
+	"home_nursing_visit":    true,
+	"rehabilitation":        true,
+	"medication_management": true,
+	"other":                 true,
+}
+
+// CarePlanActivityRoles lists the staff roles an activity can be assigned to
+var CarePlanActivityRoles = map[string]bool{
+	"doctor":       true,
+	"nurse":        true,
+	"therapist":    true,
+	"pharmacist":   true,
+	"care_manager": true,
+}
+
+// ParseCarePlanActivities decodes a plan's activities; empty JSON means no activities
+func ParseCarePlanActivities(raw json.RawMessage) ([]CarePlanActivity, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var activities []CarePlanActivity
+	if err := json.Unmarshal(raw, &activities); err != nil {
+		return nil, fmt.Errorf("invalid activities: expected an array of activities: %w", err)
+	}
+	return activities, nil
+}
+
+// Validate checks an activity's kind, role and duration. The frequency pattern itself is
+// checked when its recurrence rule is built.
+func (a *CarePlanActivity) Validate() error {
+	if len(a.ActivityID) > 36 {
+		return fmt.Errorf("activity_id must be at most 36 characters")
+	}
+	if !CarePlanActivityKinds[a.Kind] {
+		return fmt.Errorf("invalid activity kind: %s", a.Kind)
+	}
+	if !CarePlanActivityRoles[a.AssignedRole] {
+		return fmt.Errorf("invalid activity assigned_role: %s", a.AssignedRole)
+	}
+	if a.DurationMinutes < 5 || a.DurationMinutes > 480 {
+		return fmt.Errorf("invalid activity duration: must be between 5 and 480 minutes")
+	}
+	return nil
+}
+
+// CarePlanGoal is a goal of a care plan (FHIR Goal). Goals with a target are measurable and
+// their progress is computed from the patient's clinical observations.
+type CarePlanGoal struct {
+	GoalID      string              `json:"goal_id"` // assigned on save when empty
+	Description string              `json:"description"`
+	Target      *CarePlanGoalTarget `json:"target,omitempty"`   // nil for goals reviewed by hand
+	DueDate     *civil.Date         `json:"due_date,omitempty"` // defaults to the end of the plan
+}
+
+// CarePlanGoalTarget ties a goal to an observation and the value it should reach,
+// e.g. systolic blood pressure < 140 or Barthel index >= 60
+type CarePlanGoalTarget struct {
+	Category   string  `json:"category"`            // observation category: vital_signs, adl_assessment, cognitive_assessment, pain_scale
+	Code       string  `json:"code,omitempty"`      // observation code (e.g. LOINC 8480-6); empty matches the whole category
+	Component  string  `json:"component,omitempty"` // value field to read: "systolic", "diastolic", "total_score"; defaults to "value"
+	Comparator string  `json:"comparator"`          // "<" | "<=" | ">" | ">=" | "="
+	Value      float64 `json:"value"`
+	Unit       string  `json:"unit,omitempty"`
+}
+
+// CarePlanGoalComparators lists the valid target comparators
+var CarePlanGoalComparators = map[string]bool{"<": true, "<=": true, ">": true, ">=": true, "=": true}
+
+// ObservationCategories lists the valid clinical observation categories
+var ObservationCategories = map[string]bool{
+	"vital_signs":          true,
+	"adl_assessment":       true,
+	"cognitive_assessment": true,
+	"pain_scale":           true,
+}
+
+// ParseCarePlanGoals decodes a plan's goals; empty JSON means no goals
+func ParseCarePlanGoals(raw json.RawMessage) ([]CarePlanGoal, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var goals []CarePlanGoal
+	if err := json.Unmarshal(raw, &goals); err != nil {
+		return nil, fmt.Errorf("invalid goals: expected an array of goals: %w", err)
+	}
+	return goals, nil
+}
+
+// Validate checks a goal's description and target
+func (g *CarePlanGoal) Validate() error {
+	if len(g.GoalID) > 36 {
+		return fmt.Errorf("goal_id must be at most 36 characters")
+	}
+	if g.Description == "" {
+		return fmt.Errorf("goal description is required")
+	}
+	if g.Target == nil {
+		return nil
+	}
+	if !ObservationCategories[g.Target.Category] {
+		return fmt.Errorf("invalid goal target category: %s", g.Target.Category)
+	}
+	if !CarePlanGoalComparators[g.Target.Comparator] {
+		return fmt.Errorf("invalid goal target comparator: %s", g.Target.Comparator)
+	}
+	return nil
+}
+
+// Met reports whether a measured value reaches the target
+func (t *CarePlanGoalTarget) Met(value float64) bool {
+	switch t.Comparator {
+	case "<":
+		return value < t.Value
+	case "<=":
+		return value <= t.Value
+	case ">":
+		return value > t.Value
+	case ">=":
+		return value >= t.Value
+	case "=":
+		return value == t.Value
+	default:
+		return false
+	}
+}
+
+// Matches reports whether an observation measures the target
+func (t *CarePlanGoalTarget) Matches(observation *ClinicalObservation) bool {
+	if observation.Category != t.Category {
+		return false
+	}
+	return t.Code == "" || observation.CodeValue() == t.Code
+}
+
+// CarePlanGoalProgress is the computed state of one goal
+type CarePlanGoalProgress struct {
+	GoalID      string              `json:"goal_id"`
+	Description string              `json:"description"`
+	Target      *CarePlanGoalTarget `json:"target,omitempty"`
+	DueDate     *civil.Date         `json:"due_date,omitempty"`
+
+	// Status is "achieved" | "in_progress" | "off_track" | "no_data" | "not_measurable"
+	Status          string            `json:"status"`
+	Baseline        *GoalMeasurement  `json:"baseline,omitempty"` // first measurement in the plan period
+	Latest          *GoalMeasurement  `json:"latest,omitempty"`
+	Measurements    int               `json:"measurements"`
+	ProgressPercent *float64          `json:"progress_percent,omitempty"` // share of the way from baseline to target
+	History         []GoalMeasurement `json:"history,omitempty"`
+}
+
+// GoalMeasurement is one observation value read for a goal
+type GoalMeasurement struct {
+	ObservationID     string    `json:"observation_id"`
+	EffectiveDatetime time.Time `json:"effective_datetime"`
+	Value             float64   `json:"value"`
+}
+
+// CarePlanGoalStatus is the goal-by-goal progress of a care plan
+type CarePlanGoalStatus struct {
+	PlanID      string                 `json:"plan_id"`
+	PatientID   string                 `json:"patient_id"`
+	EvaluatedAt time.Time              `json:"evaluated_at"`
+	Goals       []CarePlanGoalProgress `json:"goals"`
+}
+
+// CarePlanVersion is the immutable snapshot of a care plan as saved at one version
+type CarePlanVersion struct {
+	PlanID    string `json:"plan_id"`
+	Version   int64  `json:"version"`
+	PatientID string `json:"patient_id"`
+
+	Status string `json:"status"`
+	Intent string `json:"intent"`
+
+	Title       string             `json:"title"`
+	Description spanner.NullString `json:"description,omitempty"`
+
+	PeriodStart time.Time        `json:"period_start"`
+	PeriodEnd   spanner.NullTime `json:"period_end,omitempty"`
+
+	Goals      json.RawMessage `json:"goals,omitempty"`
+	Activities json.RawMessage `json:"activities,omitempty"`
+
+	ChangeReason spanner.NullString `json:"change_reason,omitempty"`
+	RecordedBy   string             `json:"recorded_by"`
+	RecordedAt   time.Time          `json:"recorded_at"`
+}
+
+// CarePlanDiff is what changed in a care plan between two of its versions.
+// Goals and activities are matched by goal_id and activity_id.
+type CarePlanDiff struct {
+	PlanID      string `json:"plan_id"`
+	FromVersion int64  `json:"from_version"`
+	ToVersion   int64  `json:"to_version"`
+
+	// Changes to title, description, status, intent, period_start and period_end
+	Fields     []CarePlanFieldChange `json:"fields"`
+	Goals      CarePlanItemsDiff     `json:"goals"`
+	Activities CarePlanItemsDiff     `json:"activities"`
+}
+
+// CarePlanFieldChange is one field whose value differs between two versions.
+// From or To is null when the field is absent in that version.
+type CarePlanFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// CarePlanItemsDiff lists the goals or activities added, removed and changed between two versions
+type CarePlanItemsDiff struct {
+	Added   []map[string]interface{} `json:"added"`
+	Removed []map[string]interface{} `json:"removed"`
+	Changed []CarePlanItemChange     `json:"changed"`
+}
+
+// CarePlanItemChange is a goal or activity present in both versions with different fields
+type CarePlanItemChange struct {
+	ID     string                `json:"id"`
+	Fields []CarePlanFieldChange `json:"fields"`
+}
@@ -70,6 +70,45 @@ type ClinicalObservationFilter struct {
 	Offset                int
 }
 
+// CodeValue returns the observation's code (e.g. the LOINC code), or "" when it has none
+func (o *ClinicalObservation) CodeValue() string {
+	var code ObservationCode
+	if len(o.Code) == 0 || json.Unmarshal(o.Code, &code) != nil {
+		return ""
+	}
+	return code.Code
+}
+
+// NumericValue reads a number from the observation value: the named component (such as
+// "systolic" of a blood pressure or "total_score" of an ADL score), or "value" when component
+// is empty. A component may be a bare number or a quantity with a "value".
+func (o *ClinicalObservation) NumericValue(component string) (float64, bool) {
+	if component == "" {
+		component = "value"
+	}
+
+	var fields map[string]json.RawMessage
+	if len(o.Value) == 0 || json.Unmarshal(o.Value, &fields) != nil {
+		return 0, false
+	}
+	raw, ok := fields[component]
+	if !ok {
+		return 0, false
+	}
+
+	var number float64
+	if json.Unmarshal(raw, &number) == nil {
+		return number, true
+	}
+	var quantity struct {
+		Value *float64 `json:"value"`
+	}
+	if json.Unmarshal(raw, &quantity) == nil && quantity.Value != nil {
+		return *quantity.Value, true
+	}
+	return 0, false
+}
+
 // Common code structures for LOINC/SNOMED CT compliance
 
 // ObservationCode represents a standardized code for clinical observations
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClinicalObservationNumericValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		component string
+		expected  float64
+		ok        bool
+	}{
+		{"Quantity", `{"value": 36.8, "unit": "Cel"}`, "", 36.8, true},
+		{"Blood pressure component", `{"systolic": {"value": 138, "unit": "mmHg"}, "diastolic": {"value": 82, "unit": "mmHg"}}`, "systolic", 138, true},
+		{"ADL total score", `{"total_score": 65, "method": "Barthel"}`, "total_score", 65, true},
+		{"Missing component", `{"value": 36.8}`, "systolic", 0, false},
+		{"Coded value", `{"value": {"code": "LA6576-8"}}`, "", 0, false},
+		{"Not an object", `120`, "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observation := &ClinicalObservation{Value: json.RawMessage(tt.value)}
+
+			value, ok := observation.NumericValue(tt.component)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestClinicalObservationCodeValue(t *testing.T) {
+	assert.Equal(t, "8480-6", (&ClinicalObservation{Code: json.RawMessage(`{"system": "LOINC", "code": "8480-6"}`)}).CodeValue())
+	assert.Equal(t, "", (&ClinicalObservation{}).CodeValue())
+}
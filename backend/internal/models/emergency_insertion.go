@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// EmergencyInsertionRequest represents the request body for POST /routes/emergency-insertion
+type EmergencyInsertionRequest struct {
+	PatientID                string `json:"patient_id" validate:"required"`
+	Urgency                  string `json:"urgency" validate:"required,oneof=critical urgent same_day"`
+	EstimatedDurationMinutes int64  `json:"estimated_duration_minutes" validate:"omitempty,min=5,max=480"` // defaults to 30
+
+	// Candidate staff. When empty, every active on-duty staff member of Role is evaluated;
+	// without a Role, the on-duty clinical staff (doctors, nurses, therapists, pharmacists).
+	StaffIDs []string `json:"staff_ids,omitempty"`
+	Role     *string  `json:"role,omitempty"`
+
+	// Apply creates the emergency visit and resequences the chosen route.
+	// The cheapest proposal is applied unless StaffID picks another candidate's.
+	Apply             bool    `json:"apply"`
+	StaffID           *string `json:"staff_id,omitempty"`
+	OverrideConflicts bool    `json:"override_conflicts,omitempty"` // apply even if the new route clashes with other visits
+}
+
+// EmergencyVisitDelay is the knock-on effect of an insertion on one already-planned visit
+type EmergencyVisitDelay struct {
+	ScheduleID    string    `json:"schedule_id"`
+	PatientID     string    `json:"patient_id"`
+	OriginalStart time.Time `json:"original_start"`
+	NewStart      time.Time `json:"new_start"`
+	DelaySeconds  int64     `json:"delay_seconds"`
+	Late          bool      `json:"late"` // now starts after its time window closes
+}
+
+// EmergencyInsertionProposal is the cheapest place to fit the emergency visit into one staff member's remaining route
+type EmergencyInsertionProposal struct {
+	StaffID    string  `json:"staff_id"`
+	StaffName  string  `json:"staff_name"`
+	RouteJobID *string `json:"route_job_id,omitempty"` // latest applied route the proposal builds on
+
+	Origin    string    `json:"origin"`   // "in_progress_visit" | "current_location" | "route_start"
+	Position  int       `json:"position"` // 1-based sequence of the emergency visit in the remaining route
+	Arrival   time.Time `json:"arrival_time"`
+	StartTime time.Time `json:"start_time"`
+
+	MeetsDeadline      bool                  `json:"meets_deadline"`
+	AddedTravelSeconds int64                 `json:"added_travel_seconds"`
+	TotalDelaySeconds  int64                 `json:"total_delay_seconds"`
+	Delays             []EmergencyVisitDelay `json:"delays"`
+	Cost               float64               `json:"cost"`
+
+	// Resequenced remaining route, used when the proposal is applied
+	Route OptimizedRoute `json:"-"`
+}
+
+// EmergencyInsertionResult lists the proposals, cheapest first, and what was applied
+type EmergencyInsertionResult struct {
+	PatientID       string                        `json:"patient_id"`
+	Urgency         string                        `json:"urgency"`
+	Deadline        time.Time                     `json:"deadline"`
+	Proposals       []*EmergencyInsertionProposal `json:"proposals"`
+	SkippedStaffIDs []string                      `json:"skipped_staff_ids,omitempty"` // no known position to route from
+
+	Applied  *EmergencyInsertionProposal `json:"applied,omitempty"`
+	Schedule *VisitSchedule              `json:"schedule,omitempty"` // the emergency visit created when applied
+	JobID    *string                     `json:"job_id,omitempty"`   // emergency_insertion route job recorded when applied
+}
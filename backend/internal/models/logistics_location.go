@@ -0,0 +1,263 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/visitas/backend/pkg/geo"
+)
+
+// LogisticsLocation represents a base used for visits and routing
+// (clinic, office, pharmacy, route start/end point, etc.)
+type LogisticsLocation struct {
+	LocationID     string  `json:"location_id"`
+	OrganizationID *string `json:"organization_id,omitempty"`
+
+	LocationType string  `json:"location_type"` // clinic, office, pharmacy, hospital, care_facility, warehouse
+	LocationName string  `json:"location_name"`
+	LocationCode *string `json:"location_code,omitempty"`
+
+	// Address
+	PostalCode   *string `json:"postal_code,omitempty"`
+	Prefecture   string  `json:"prefecture"`
+	City         string  `json:"city"`
+	AddressLine  string  `json:"address_line"`
+	BuildingName *string `json:"building_name,omitempty"`
+
+	// Geolocation
+	Latitude              float64    `json:"latitude"`
+	Longitude             float64    `json:"longitude"`
+	GeolocationVerified   bool       `json:"geolocation_verified"`
+	GeolocationVerifiedAt *time.Time `json:"geolocation_verified_at,omitempty"`
+	GooglePlaceID         *string    `json:"google_place_id,omitempty"`
+	GoogleMapsURL         *string    `json:"google_maps_url,omitempty"`
+
+	// Contact
+	Phone      *string `json:"phone,omitempty"`
+	Fax        *string `json:"fax,omitempty"`
+	Email      *string `json:"email,omitempty"`
+	WebsiteURL *string `json:"website_url,omitempty"`
+
+	OperatingHours json.RawMessage `json:"operating_hours,omitempty"` // JSONB
+
+	// Capacity
+	StaffCapacity              *int64 `json:"staff_capacity,omitempty"`
+	VehicleCapacity            *int64 `json:"vehicle_capacity,omitempty"`
+	ParkingSpots               *int64 `json:"parking_spots,omitempty"`
+	HasMedicalEquipmentStorage bool   `json:"has_medical_equipment_storage"`
+
+	// Route optimization
+	IsRouteStartPoint    bool    `json:"is_route_start_point"`
+	IsRouteEndPoint      bool    `json:"is_route_end_point"`
+	DefaultDepartureTime *string `json:"default_departure_time,omitempty"` // HH:MM
+	DefaultReturnTime    *string `json:"default_return_time,omitempty"`    // HH:MM
+
+	// Service area
+	ServiceArea        json.RawMessage `json:"service_area,omitempty"` // JSONB - see ServiceArea
+	MaxServiceRadiusKm *float64        `json:"max_service_radius_km,omitempty"`
+
+	Facilities       json.RawMessage `json:"facilities,omitempty"` // JSONB array
+	ParentLocationID *string         `json:"parent_location_id,omitempty"`
+	LocationStatus   string          `json:"location_status"` // active, inactive, temporary_closed, relocated
+
+	AccessInstructions *string `json:"access_instructions,omitempty"`
+	SpecialNotes       *string `json:"special_notes,omitempty"`
+
+	IsBillingLocation bool    `json:"is_billing_location"`
+	BillingCode       *string `json:"billing_code,omitempty"`
+
+	// Audit
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy *string    `json:"created_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UpdatedBy *string    `json:"updated_by,omitempty"`
+	Deleted   bool       `json:"deleted"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ServiceArea is the decoded form of logistics_locations.service_area.
+// Radius areas are centred on the location; polygon coordinates follow
+// GeoJSON order: [[longitude, latitude], ...].
+type ServiceArea struct {
+	Type        string      `json:"type"` // radius, polygon
+	RadiusKm    *float64    `json:"radius_km,omitempty"`
+	Coordinates [][]float64 `json:"coordinates,omitempty"`
+}
+
+// Point returns the location's coordinates
+func (l *LogisticsLocation) Point() geo.Point {
+	return geo.Point{Latitude: l.Latitude, Longitude: l.Longitude}
+}
+
+// ParseServiceArea decodes the service_area JSONB column.
+// When no service_area is stored but max_service_radius_km is set, a radius area is returned.
+func (l *LogisticsLocation) ParseServiceArea() (*ServiceArea, error) {
+	if len(l.ServiceArea) == 0 || string(l.ServiceArea) == "null" {
+		if l.MaxServiceRadiusKm != nil {
+			return &ServiceArea{Type: "radius", RadiusKm: l.MaxServiceRadiusKm}, nil
+		}
+		return nil, nil
+	}
+
+	var area ServiceArea
+	if err := json.Unmarshal(l.ServiceArea, &area); err != nil {
+		return nil, fmt.Errorf("invalid service_area: %w", err)
+	}
+
+	switch area.Type {
+	case "radius":
+		if area.RadiusKm == nil {
+			area.RadiusKm = l.MaxServiceRadiusKm
+		}
+		if area.RadiusKm == nil || *area.RadiusKm <= 0 {
+			return nil, fmt.Errorf("invalid service_area: radius_km must be positive")
+		}
+	case "polygon":
+		if len(area.Coordinates) < 3 {
+			return nil, fmt.Errorf("invalid service_area: polygon needs at least 3 coordinates")
+		}
+		for _, c := range area.Coordinates {
+			if len(c) != 2 {
+				return nil, fmt.Errorf("invalid service_area: coordinates must be [longitude, latitude] pairs")
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid service_area: unknown type %q", area.Type)
+	}
+
+	return &area, nil
+}
+
+// CoversPoint reports whether p falls inside the location's service area.
+// Locations without any service area definition cover nothing.
+func (l *LogisticsLocation) CoversPoint(p geo.Point) (bool, error) {
+	area, err := l.ParseServiceArea()
+	if err != nil || area == nil {
+		return false, err
+	}
+
+	if area.Type == "radius" {
+		return geo.WithinRadius(l.Point(), p, *area.RadiusKm), nil
+	}
+
+	polygon := make([]geo.Point, len(area.Coordinates))
+	for i, c := range area.Coordinates {
+		polygon[i] = geo.Point{Latitude: c[1], Longitude: c[0]}
+	}
+	return geo.InPolygon(p, polygon), nil
+}
+
+// LogisticsLocationCreateRequest represents the request body for creating a location
+type LogisticsLocationCreateRequest struct {
+	OrganizationID             *string         `json:"organization_id,omitempty"`
+	LocationType               string          `json:"location_type" validate:"required"`
+	LocationName               string          `json:"location_name" validate:"required"`
+	LocationCode               *string         `json:"location_code,omitempty"`
+	PostalCode                 *string         `json:"postal_code,omitempty"`
+	Prefecture                 string          `json:"prefecture" validate:"required"`
+	City                       string          `json:"city" validate:"required"`
+	AddressLine                string          `json:"address_line" validate:"required"`
+	BuildingName               *string         `json:"building_name,omitempty"`
+	Latitude                   float64         `json:"latitude" validate:"required"`
+	Longitude                  float64         `json:"longitude" validate:"required"`
+	GeolocationVerified        bool            `json:"geolocation_verified"`
+	GooglePlaceID              *string         `json:"google_place_id,omitempty"`
+	GoogleMapsURL              *string         `json:"google_maps_url,omitempty"`
+	Phone                      *string         `json:"phone,omitempty"`
+	Fax                        *string         `json:"fax,omitempty"`
+	Email                      *string         `json:"email,omitempty"`
+	WebsiteURL                 *string         `json:"website_url,omitempty"`
+	OperatingHours             json.RawMessage `json:"operating_hours,omitempty"`
+	StaffCapacity              *int64          `json:"staff_capacity,omitempty"`
+	VehicleCapacity            *int64          `json:"vehicle_capacity,omitempty"`
+	ParkingSpots               *int64          `json:"parking_spots,omitempty"`
+	HasMedicalEquipmentStorage bool            `json:"has_medical_equipment_storage"`
+	IsRouteStartPoint          bool            `json:"is_route_start_point"`
+	IsRouteEndPoint            bool            `json:"is_route_end_point"`
+	DefaultDepartureTime       *string         `json:"default_departure_time,omitempty"`
+	DefaultReturnTime          *string         `json:"default_return_time,omitempty"`
+	ServiceArea                json.RawMessage `json:"service_area,omitempty"`
+	MaxServiceRadiusKm         *float64        `json:"max_service_radius_km,omitempty"`
+	Facilities                 json.RawMessage `json:"facilities,omitempty"`
+	ParentLocationID           *string         `json:"parent_location_id,omitempty"`
+	LocationStatus             string          `json:"location_status,omitempty"`
+	AccessInstructions         *string         `json:"access_instructions,omitempty"`
+	SpecialNotes               *string         `json:"special_notes,omitempty"`
+	IsBillingLocation          bool            `json:"is_billing_location"`
+	BillingCode                *string         `json:"billing_code,omitempty"`
+}
+
+// LogisticsLocationUpdateRequest represents the request body for updating a location
+type LogisticsLocationUpdateRequest struct {
+	LocationType               *string         `json:"location_type,omitempty"`
+	LocationName               *string         `json:"location_name,omitempty"`
+	LocationCode               *string         `json:"location_code,omitempty"`
+	PostalCode                 *string         `json:"postal_code,omitempty"`
+	Prefecture                 *string         `json:"prefecture,omitempty"`
+	City                       *string         `json:"city,omitempty"`
+	AddressLine                *string         `json:"address_line,omitempty"`
+	BuildingName               *string         `json:"building_name,omitempty"`
+	Latitude                   *float64        `json:"latitude,omitempty"`
+	Longitude                  *float64        `json:"longitude,omitempty"`
+	GeolocationVerified        *bool           `json:"geolocation_verified,omitempty"`
+	GooglePlaceID              *string         `json:"google_place_id,omitempty"`
+	GoogleMapsURL              *string         `json:"google_maps_url,omitempty"`
+	Phone                      *string         `json:"phone,omitempty"`
+	Fax                        *string         `json:"fax,omitempty"`
+	Email                      *string         `json:"email,omitempty"`
+	WebsiteURL                 *string         `json:"website_url,omitempty"`
+	OperatingHours             json.RawMessage `json:"operating_hours,omitempty"`
+	StaffCapacity              *int64          `json:"staff_capacity,omitempty"`
+	VehicleCapacity            *int64          `json:"vehicle_capacity,omitempty"`
+	ParkingSpots               *int64          `json:"parking_spots,omitempty"`
+	HasMedicalEquipmentStorage *bool           `json:"has_medical_equipment_storage,omitempty"`
+	IsRouteStartPoint          *bool           `json:"is_route_start_point,omitempty"`
+	IsRouteEndPoint            *bool           `json:"is_route_end_point,omitempty"`
+	DefaultDepartureTime       *string         `json:"default_departure_time,omitempty"`
+	DefaultReturnTime          *string         `json:"default_return_time,omitempty"`
+	ServiceArea                json.RawMessage `json:"service_area,omitempty"`
+	MaxServiceRadiusKm         *float64        `json:"max_service_radius_km,omitempty"`
+	Facilities                 json.RawMessage `json:"facilities,omitempty"`
+	ParentLocationID           *string         `json:"parent_location_id,omitempty"`
+	LocationStatus             *string         `json:"location_status,omitempty"`
+	AccessInstructions         *string         `json:"access_instructions,omitempty"`
+	SpecialNotes               *string         `json:"special_notes,omitempty"`
+	IsBillingLocation          *bool           `json:"is_billing_location,omitempty"`
+	BillingCode                *string         `json:"billing_code,omitempty"`
+}
+
+// LogisticsLocationFilter represents filter options for listing locations
+type LogisticsLocationFilter struct {
+	OrganizationID    *string
+	LocationType      *string
+	LocationStatus    *string
+	IsRouteStartPoint *bool
+	IsRouteEndPoint   *bool
+	Limit             int
+	Offset            int
+}
+
+// NearestLocationResult is a location together with its distance from the query point
+type NearestLocationResult struct {
+	Location   *LogisticsLocation `json:"location"`
+	DistanceKm float64            `json:"distance_km"`
+}
+
+// ServiceAreaCheckRequest asks which locations serve a point. Either the
+// coordinates or an address with a geolocation must be supplied.
+type ServiceAreaCheckRequest struct {
+	Latitude       *float64 `json:"latitude,omitempty"`
+	Longitude      *float64 `json:"longitude,omitempty"`
+	Address        *Address `json:"address,omitempty"`
+	LocationType   *string  `json:"location_type,omitempty"`
+	OrganizationID *string  `json:"organization_id,omitempty"`
+}
+
+// ServiceAreaCheckResult reports the locations whose service area covers a point
+type ServiceAreaCheckResult struct {
+	Latitude          float64                  `json:"latitude"`
+	Longitude         float64                  `json:"longitude"`
+	Covered           bool                     `json:"covered"`
+	CoveringLocations []*NearestLocationResult `json:"covering_locations"`
+}
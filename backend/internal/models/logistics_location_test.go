@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/visitas/backend/pkg/geo"
+)
+
+func TestLogisticsLocation_CoversPoint(t *testing.T) {
+	// Clinic near Tokyo Station
+	base := geo.Point{Latitude: 35.6812, Longitude: 139.7671}
+	nearby := geo.Point{Latitude: 35.6896, Longitude: 139.7006}  // Shinjuku, ~6km
+	farAway := geo.Point{Latitude: 35.4437, Longitude: 139.6380} // Yokohama, ~29km
+	radius := 10.0
+
+	tests := []struct {
+		name        string
+		serviceArea string
+		maxRadius   *float64
+		point       geo.Point
+		expected    bool
+		expectErr   bool
+	}{
+		{
+			name:        "Radius area covers nearby point",
+			serviceArea: `{"type":"radius","radius_km":10}`,
+			point:       nearby,
+			expected:    true,
+		},
+		{
+			name:        "Radius area excludes distant point",
+			serviceArea: `{"type":"radius","radius_km":10}`,
+			point:       farAway,
+			expected:    false,
+		},
+		{
+			name:      "Falls back to max_service_radius_km",
+			maxRadius: &radius,
+			point:     nearby,
+			expected:  true,
+		},
+		{
+			name:        "Polygon area in GeoJSON order",
+			serviceArea: `{"type":"polygon","coordinates":[[139.6,35.6],[139.9,35.6],[139.9,35.8],[139.6,35.8]]}`,
+			point:       nearby,
+			expected:    true,
+		},
+		{
+			name:        "Polygon area excludes outside point",
+			serviceArea: `{"type":"polygon","coordinates":[[139.6,35.6],[139.9,35.6],[139.9,35.8],[139.6,35.8]]}`,
+			point:       farAway,
+			expected:    false,
+		},
+		{
+			name:     "No service area covers nothing",
+			point:    base,
+			expected: false,
+		},
+		{
+			name:        "Unknown area type",
+			serviceArea: `{"type":"circle"}`,
+			point:       nearby,
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &LogisticsLocation{
+				Latitude:           base.Latitude,
+				Longitude:          base.Longitude,
+				MaxServiceRadiusKm: tt.maxRadius,
+			}
+			if tt.serviceArea != "" {
+				l.ServiceArea = json.RawMessage(tt.serviceArea)
+			}
+
+			covered, err := l.CoversPoint(tt.point)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, covered)
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -43,6 +46,13 @@ type MedicalRecord struct {
 	Deleted   bool       `json:"deleted"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 	DeletedBy *string    `json:"deleted_by,omitempty"`
+
+	// Signing: a signed record is locked; corrections are signed addenda
+	SignedAt        *time.Time `json:"signed_at,omitempty"`
+	SignedBy        *string    `json:"signed_by,omitempty"`
+	SignatureHash   *string    `json:"signature_hash,omitempty"` // hex SHA-256 of CanonicalJSON
+	AmendsRecordID  *string    `json:"amends_record_id,omitempty"`
+	AmendmentReason *string    `json:"amendment_reason,omitempty"`
 }
 
 // MedicalRecordCreateRequest represents the request body for creating a medical record
@@ -70,6 +80,7 @@ type MedicalRecordUpdateRequest struct {
 	TemplateID      *string         `json:"template_id,omitempty"`
 	AudioFileURL    *string         `json:"audio_file_url,omitempty"`
 	ExpectedVersion *int64          `json:"expected_version,omitempty"` // Optimistic locking
+	ChangeReason    *string         `json:"change_reason,omitempty"`    // Recorded on the revision
 }
 
 // MedicalRecordFilter represents filter options for listing medical records
@@ -88,6 +99,133 @@ type MedicalRecordFilter struct {
 	Offset        int
 }
 
+// MedicalRecordSignRequest represents the request body for signing a medical record
+type MedicalRecordSignRequest struct {
+	ExpectedVersion *int64 `json:"expected_version,omitempty"` // Optimistic locking
+}
+
+// MedicalRecordAddendumRequest represents the request body for adding a signed addendum
+type MedicalRecordAddendumRequest struct {
+	SOAPContent json.RawMessage `json:"soap_content" validate:"required"`
+	Reason      string          `json:"reason" validate:"required"`
+}
+
+// SignatureVerification is the result of recomputing a signed record's hash
+type SignatureVerification struct {
+	RecordID     string     `json:"record_id"`
+	Signed       bool       `json:"signed"`
+	SignedBy     *string    `json:"signed_by,omitempty"`
+	SignedAt     *time.Time `json:"signed_at,omitempty"`
+	StoredHash   *string    `json:"stored_hash,omitempty"`
+	ComputedHash string     `json:"computed_hash,omitempty"`
+	Valid        bool       `json:"valid"`
+}
+
+// CanonicalJSON is the byte-stable form of a record that its signature covers: the clinical
+// fields, SOAP content, version and signer, with object keys sorted, timestamps in UTC and
+// numbers in their shortest form, so the JSONB column's own formatting does not matter.
+// Audit timestamps and generated columns are excluded.
+func (r *MedicalRecord) CanonicalJSON() ([]byte, error) {
+	var soap interface{}
+	if len(r.SOAPContent) > 0 {
+		if err := json.Unmarshal(r.SOAPContent, &soap); err != nil {
+			return nil, fmt.Errorf("invalid SOAP content: %w", err)
+		}
+	}
+
+	timestamp := func(t *time.Time) interface{} {
+		if t == nil {
+			return nil
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	text := func(s *string) interface{} {
+		if s == nil {
+			return nil
+		}
+		return *s
+	}
+
+	// encoding/json writes map keys in sorted order
+	return json.Marshal(map[string]interface{}{
+		"record_id":        r.RecordID,
+		"patient_id":       r.PatientID,
+		"visit_started_at": timestamp(&r.VisitStartedAt),
+		"visit_ended_at":   timestamp(r.VisitEndedAt),
+		"visit_type":       r.VisitType,
+		"performed_by":     r.PerformedBy,
+		"status":           r.Status,
+		"schedule_id":      text(r.ScheduleID),
+		"soap_content":     soap,
+		"template_id":      text(r.TemplateID),
+		"source_record_id": text(r.SourceRecordID),
+		"source_type":      r.SourceType,
+		"audio_file_url":   text(r.AudioFileURL),
+		"amends_record_id": text(r.AmendsRecordID),
+		"amendment_reason": text(r.AmendmentReason),
+		"version":          r.Version,
+		"signed_by":        text(r.SignedBy),
+		"signed_at":        timestamp(r.SignedAt),
+	})
+}
+
+// ComputeSignatureHash returns the hex SHA-256 of the record's CanonicalJSON
+func (r *MedicalRecord) ComputeSignatureHash() (string, error) {
+	canonical, err := r.CanonicalJSON()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// MedicalRecordRevision is one append-only revision of a medical record: the record as saved
+// at one version, with who saved it, when and why (真正性)
+type MedicalRecordRevision struct {
+	RecordID  string `json:"record_id"`
+	Revision  int64  `json:"revision"` // the record version this revision produced
+	PatientID string `json:"patient_id"`
+
+	VisitEndedAt *time.Time      `json:"visit_ended_at,omitempty"`
+	VisitType    string          `json:"visit_type"`
+	Status       string          `json:"status"`
+	ScheduleID   *string         `json:"schedule_id,omitempty"`
+	SOAPContent  json.RawMessage `json:"soap_content,omitempty"`
+	TemplateID   *string         `json:"template_id,omitempty"`
+	AudioFileURL *string         `json:"audio_file_url,omitempty"`
+
+	ChangeType           string    `json:"change_type"` // create, update, restore, sign
+	ChangeReason         *string   `json:"change_reason,omitempty"`
+	RestoredFromRevision *int64    `json:"restored_from_revision,omitempty"`
+	RevisedBy            string    `json:"revised_by"`
+	RevisedAt            time.Time `json:"revised_at"`
+}
+
+// RestoreRevisionRequest represents the request body for restoring a revision
+type RestoreRevisionRequest struct {
+	Reason          *string `json:"reason,omitempty"`
+	ExpectedVersion *int64  `json:"expected_version,omitempty"` // Optimistic locking
+}
+
+// MedicalRecordRevisionDiff is what changed in a medical record between two revisions
+type MedicalRecordRevisionDiff struct {
+	RecordID     string `json:"record_id"`
+	FromRevision int64  `json:"from_revision"`
+	ToRevision   int64  `json:"to_revision"`
+
+	// Changes to the record fields and to every leaf of soap_content, by JSON path
+	// (e.g. "soap_content.objective.vitalSigns.heartRate.value")
+	Changes []JSONChange `json:"changes"`
+}
+
+// JSONChange is one value added, removed or changed at a JSON path
+type JSONChange struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"` // added, removed, changed
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
 // MedicalRecordTemplate represents a reusable SOAP note template
 type MedicalRecordTemplate struct {
 	TemplateID          string          `json:"template_id"`
@@ -95,6 +233,7 @@ type MedicalRecordTemplate struct {
 	TemplateDescription *string         `json:"template_description,omitempty"`
 	Specialty           *string         `json:"specialty,omitempty"` // general, internal_medicine, neurology, palliative_care
 	SOAPTemplate        json.RawMessage `json:"soap_template"`
+	RequiredSections    []string        `json:"required_sections,omitempty"` // SOAP paths that must be filled before completion
 	IsSystemTemplate    bool            `json:"is_system_template"`
 	UsageCount          int64           `json:"usage_count"`
 	CreatedAt           time.Time       `json:"created_at"`
@@ -111,6 +250,7 @@ type MedicalRecordTemplateCreateRequest struct {
 	TemplateDescription *string         `json:"template_description,omitempty"`
 	Specialty           *string         `json:"specialty,omitempty" validate:"omitempty,oneof=general internal_medicine neurology palliative_care"`
 	SOAPTemplate        json.RawMessage `json:"soap_template" validate:"required"`
+	RequiredSections    []string        `json:"required_sections,omitempty"`
 	IsSystemTemplate    bool            `json:"is_system_template"`
 }
 
@@ -120,6 +260,7 @@ type MedicalRecordTemplateUpdateRequest struct {
 	TemplateDescription *string         `json:"template_description,omitempty"`
 	Specialty           *string         `json:"specialty,omitempty" validate:"omitempty,oneof=general internal_medicine neurology palliative_care"`
 	SOAPTemplate        json.RawMessage `json:"soap_template,omitempty"`
+	RequiredSections    []string        `json:"required_sections,omitempty"` // An empty list clears the requirement
 }
 
 // MedicalRecordTemplateFilter represents filter options for listing templates
@@ -274,6 +415,23 @@ type AIAssistance struct {
 	Model         string  `json:"model,omitempty"`
 	Confidence    float64 `json:"confidence,omitempty"`
 	TranscriptURL string  `json:"transcriptUrl,omitempty"`
+
+	// Voice-to-SOAP drafts: the job and transcriber that produced the draft, and the
+	// provenance of each AI-suggested section, kept until a physician has reviewed it
+	JobID            string              `json:"jobId,omitempty"`
+	TranscriberModel string              `json:"transcriberModel,omitempty"`
+	Sections         []SectionProvenance `json:"sections,omitempty"`
+}
+
+// SectionProvenance records where the AI-suggested text of a SOAP section came from
+type SectionProvenance struct {
+	Section    string     `json:"section"` // subjective, objective, assessment, plan
+	Source     string     `json:"source"`  // ai_generated
+	Model      string     `json:"model,omitempty"`
+	Confidence float64    `json:"confidence,omitempty"`
+	Evidence   []string   `json:"evidence,omitempty"` // transcript sentences the text was derived from
+	ReviewedBy *string    `json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
 }
 
 // LinkedEntities represents links to other domain entities
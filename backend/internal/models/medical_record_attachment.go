@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+// MedicalRecordAttachment is a photo or scanned document attached to a medical record.
+// The file itself is encrypted in blob storage; this is its metadata.
+type MedicalRecordAttachment struct {
+	AttachmentID string  `json:"attachment_id"`
+	RecordID     string  `json:"record_id"`
+	PatientID    string  `json:"patient_id"`
+	Category     string  `json:"category"` // wound_photo, skin_photo, scanned_document, other
+	FileName     string  `json:"file_name"`
+	ContentType  string  `json:"content_type"`
+	SizeBytes    int64   `json:"size_bytes"`
+	SHA256       string  `json:"sha256"` // of the stored, metadata-stripped content
+	Width        *int64  `json:"width,omitempty"`
+	Height       *int64  `json:"height,omitempty"`
+	Caption      *string `json:"caption,omitempty"`
+	HasThumbnail bool    `json:"has_thumbnail"`
+
+	// Storage location and wrapped data encryption keys; never returned to clients
+	BlobKey             string  `json:"-"`
+	WrappedKey          string  `json:"-"`
+	ThumbnailBlobKey    *string `json:"-"`
+	ThumbnailWrappedKey *string `json:"-"`
+
+	UploadedAt time.Time  `json:"uploaded_at"`
+	UploadedBy string     `json:"uploaded_by"`
+	Deleted    bool       `json:"deleted"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy  *string    `json:"deleted_by,omitempty"`
+}
+
+// MedicalRecordAttachmentUpload is a file received for attachment to a record
+type MedicalRecordAttachmentUpload struct {
+	Category string
+	FileName string
+	Caption  *string
+	Data     []byte
+}
+
+// AttachmentContent is the decrypted content of an attachment or its thumbnail
+type AttachmentContent struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// AttachmentCategories lists the valid attachment categories
+var AttachmentCategories = map[string]bool{
+	"wound_photo":      true,
+	"skin_photo":       true,
+	"scanned_document": true,
+	"other":            true,
+}
+
+// AttachmentContentTypes lists the accepted attachment content types, detected from the
+// file content rather than trusted from the upload
+var AttachmentContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
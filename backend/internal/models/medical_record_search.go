@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Medical record search orders
+const (
+	MedicalRecordSearchSortRecent    = "recent"
+	MedicalRecordSearchSortRelevance = "relevance"
+)
+
+// MedicalRecordSearchRequest represents a search across the SOAP content of the records
+// of the patients the caller is assigned to
+type MedicalRecordSearchRequest struct {
+	Query          string   // Words that must all appear, e.g. "褥瘡 発赤"
+	PatientID      *string  // Restricts the search to one patient
+	DiagnosisCodes []string // Assessment diagnosis codes or code prefixes, e.g. "L89"
+	Medication     *string  // Part of a drug name in the plan's medications
+	Status         *string
+	VisitDateFrom  *time.Time
+	VisitDateTo    *time.Time // Inclusive
+	Sort           string     // recent (default) or relevance
+	Limit          int
+	Offset         int
+}
+
+// MedicalRecordSearchResult represents a page of search hits
+type MedicalRecordSearchResult struct {
+	Hits  []MedicalRecordSearchHit `json:"hits"`
+	Total int                      `json:"total"`
+}
+
+// MedicalRecordSearchHit represents a record matching a search
+type MedicalRecordSearchHit struct {
+	RecordID       string                       `json:"record_id"`
+	PatientID      string                       `json:"patient_id"`
+	VisitStartedAt time.Time                    `json:"visit_started_at"`
+	VisitType      string                       `json:"visit_type"`
+	Status         string                       `json:"status"`
+	DiagnosisCodes []string                     `json:"diagnosis_codes,omitempty"`
+	Score          float64                      `json:"score"`
+	Snippets       []MedicalRecordSearchSnippet `json:"snippets"`
+}
+
+// MedicalRecordSearchSnippet represents an excerpt of a SOAP section around a match
+type MedicalRecordSearchSnippet struct {
+	Section    string      `json:"section"` // subjective, objective, assessment, plan or medication
+	Text       string      `json:"text"`
+	Highlights []TextRange `json:"highlights"` // Matches within text
+}
+
+// TextRange represents a half-open range of character (not byte) offsets
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
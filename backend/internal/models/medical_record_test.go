@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMedicalRecordSignatureHash(t *testing.T) {
+	signedAt := time.Date(2025, 4, 10, 1, 30, 0, 123456000, time.UTC)
+	signedBy := "doctor-1"
+	record := func(soap string) *MedicalRecord {
+		return &MedicalRecord{
+			RecordID:       "record-1",
+			PatientID:      "patient-1",
+			VisitStartedAt: time.Date(2025, 4, 10, 10, 0, 0, 0, time.FixedZone("JST", 9*60*60)),
+			VisitType:      "regular",
+			PerformedBy:    signedBy,
+			Status:         "completed",
+			SOAPContent:    json.RawMessage(soap),
+			SourceType:     "manual",
+			Version:        3,
+			SignedAt:       &signedAt,
+			SignedBy:       &signedBy,
+		}
+	}
+
+	original, err := record(`{"subjective": {"chiefComplaint": "倦怠感"}, "objective": {"vitalSigns": {"spo2": {"value": 96.0}}}}`).ComputeSignatureHash()
+	require.NoError(t, err)
+	assert.Len(t, original, 64)
+
+	t.Run("Stable across JSONB formatting", func(t *testing.T) {
+		// Key order, whitespace and number spelling as the database may return them
+		reformatted, err := record(`{"objective":{"vitalSigns":{"spo2":{"value":96}}},"subjective":{"chiefComplaint":"倦怠感"}}`).ComputeSignatureHash()
+		require.NoError(t, err)
+		assert.Equal(t, original, reformatted)
+	})
+
+	t.Run("Stable across time zones", func(t *testing.T) {
+		r := record(`{"subjective": {"chiefComplaint": "倦怠感"}, "objective": {"vitalSigns": {"spo2": {"value": 96.0}}}}`)
+		r.VisitStartedAt = r.VisitStartedAt.UTC()
+		hash, err := r.ComputeSignatureHash()
+		require.NoError(t, err)
+		assert.Equal(t, original, hash)
+	})
+
+	t.Run("Changes with the content", func(t *testing.T) {
+		edited, err := record(`{"subjective": {"chiefComplaint": "倦怠感"}, "objective": {"vitalSigns": {"spo2": {"value": 95}}}}`).ComputeSignatureHash()
+		require.NoError(t, err)
+		assert.NotEqual(t, original, edited)
+	})
+
+	t.Run("Changes with the signer", func(t *testing.T) {
+		r := record(`{"subjective": {"chiefComplaint": "倦怠感"}, "objective": {"vitalSigns": {"spo2": {"value": 96.0}}}}`)
+		other := "doctor-2"
+		r.SignedBy = &other
+		hash, err := r.ComputeSignatureHash()
+		require.NoError(t, err)
+		assert.NotEqual(t, original, hash)
+	})
+
+	t.Run("Invalid SOAP content", func(t *testing.T) {
+		_, err := record(`{"subjective":`).ComputeSignatureHash()
+		assert.Error(t, err)
+	})
+}
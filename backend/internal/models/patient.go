@@ -194,3 +194,50 @@ func (p *Patient) GetAddresses() ([]Address, error) {
 	err := json.Unmarshal(p.Addresses, &addresses)
 	return addresses, err
 }
+
+// VisitAddress returns the patient's current home address, falling back to any
+// current address. Returns nil when the patient has no current address.
+func (p *Patient) VisitAddress() (*Address, error) {
+	addresses, err := p.GetAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	var fallback *Address
+	for i := range addresses {
+		if addresses[i].ValidTo != nil {
+			continue
+		}
+		if addresses[i].Use == "home" {
+			return &addresses[i], nil
+		}
+		if fallback == nil {
+			fallback = &addresses[i]
+		}
+	}
+	return fallback, nil
+}
+
+// VisitGeolocation returns the coordinates of the patient's current home address,
+// falling back to any current address with a geolocation. Returns nil when none is known.
+func (p *Patient) VisitGeolocation() (*Geolocation, error) {
+	addresses, err := p.GetAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	var fallback *Geolocation
+	for i := range addresses {
+		addr := addresses[i]
+		if addr.ValidTo != nil || addr.Geolocation == nil {
+			continue
+		}
+		if addr.Use == "home" {
+			return addr.Geolocation, nil
+		}
+		if fallback == nil {
+			fallback = addr.Geolocation
+		}
+	}
+	return fallback, nil
+}
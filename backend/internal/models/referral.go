@@ -0,0 +1,303 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// Referral letter statuses
+const (
+	ReferralStatusDraft        = "draft"
+	ReferralStatusSent         = "sent"
+	ReferralStatusAcknowledged = "acknowledged"
+)
+
+// ReferralSendMethods lists how a referral letter can be sent
+var ReferralSendMethods = map[string]bool{
+	"fax":        true,
+	"mail":       true,
+	"hand":       true, // handed to the patient or family
+	"electronic": true,
+}
+
+// ReferralLetter is an outbound 診療情報提供書. Its summary is a snapshot of the patient's
+// problems, medications, allergies and latest SOAP note, editable while the letter is a
+// draft. Sending renders the PDF, stores it encrypted in blob storage and locks the letter.
+type ReferralLetter struct {
+	ReferralID string `json:"referral_id"`
+	PatientID  string `json:"patient_id"`
+	Status     string `json:"status"` // draft, sent, acknowledged
+
+	RecipientInstitution string  `json:"recipient_institution"`
+	RecipientDepartment  *string `json:"recipient_department,omitempty"`
+	RecipientPhysician   *string `json:"recipient_physician,omitempty"`
+	Purpose              string  `json:"purpose"` // 紹介目的
+	Urgency              string  `json:"urgency"` // routine, urgent, emergent
+	SourceRecordID       *string `json:"source_record_id,omitempty"`
+
+	Summary ReferralSummary `json:"summary"`
+	Remarks *string         `json:"remarks,omitempty"` // 備考
+
+	SenderInstitution *string `json:"sender_institution,omitempty"`
+	SenderAddress     *string `json:"sender_address,omitempty"`
+	SenderPhone       *string `json:"sender_phone,omitempty"`
+
+	PDFSHA256     *string `json:"pdf_sha256,omitempty"`
+	PDFSizeBytes  *int64  `json:"pdf_size_bytes,omitempty"`
+	PDFBlobKey    *string `json:"-"`
+	PDFWrappedKey *string `json:"-"`
+
+	SentAt              *time.Time `json:"sent_at,omitempty"`
+	SentBy              *string    `json:"sent_by,omitempty"`
+	SentMethod          *string    `json:"sent_method,omitempty"`
+	AcknowledgedAt      *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy      *string    `json:"acknowledged_by,omitempty"` // staff who recorded the reply
+	AcknowledgementNote *string    `json:"acknowledgement_note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by"`
+}
+
+// ReferralSummary is the patient summary printed on a referral letter
+type ReferralSummary struct {
+	Patient     ReferralPatient       `json:"patient"`
+	Problems    []ReferralSummaryItem `json:"problems"`
+	Medications []ReferralSummaryItem `json:"medications"`
+	Allergies   []ReferralSummaryItem `json:"allergies"`
+	LatestSOAP  *ReferralSOAPSummary  `json:"latest_soap,omitempty"`
+}
+
+// ReferralPatient holds the patient demographics printed on a referral letter
+type ReferralPatient struct {
+	Name      string `json:"name"`
+	BirthDate string `json:"birth_date,omitempty"` // in the Japanese calendar, with age
+	Gender    string `json:"gender,omitempty"`
+	Address   string `json:"address,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+}
+
+// ReferralSummaryItem is one problem, medication or allergy line, with the record it
+// was taken from. Lines added while editing a draft have no source.
+type ReferralSummaryItem struct {
+	Text   string          `json:"text"`
+	Source *DocumentSource `json:"source,omitempty"`
+}
+
+// ReferralSOAPSummary is the latest SOAP note of the patient, as text per section
+type ReferralSOAPSummary struct {
+	RecordID       string    `json:"record_id"`
+	VisitStartedAt time.Time `json:"visit_started_at"`
+	Subjective     string    `json:"subjective,omitempty"`
+	Objective      string    `json:"objective,omitempty"`
+	Assessment     string    `json:"assessment,omitempty"`
+	Plan           string    `json:"plan,omitempty"`
+}
+
+// ReferralLetterCreateRequest drafts a referral letter. Recipient, purpose and urgency can
+// be taken from a referral planned in the source record's SOAP plan.
+type ReferralLetterCreateRequest struct {
+	RecipientInstitution string  `json:"recipient_institution"`
+	RecipientDepartment  *string `json:"recipient_department,omitempty"`
+	RecipientPhysician   *string `json:"recipient_physician,omitempty"`
+	Purpose              string  `json:"purpose"`
+	Urgency              string  `json:"urgency,omitempty"`          // defaults to routine
+	SourceRecordID       *string `json:"source_record_id,omitempty"` // defaults to the latest completed record
+	PlanReferralIndex    *int    `json:"plan_referral_index,omitempty"`
+	Remarks              *string `json:"remarks,omitempty"`
+	SenderInstitution    *string `json:"sender_institution,omitempty"`
+	SenderAddress        *string `json:"sender_address,omitempty"`
+	SenderPhone          *string `json:"sender_phone,omitempty"`
+}
+
+// ReferralLetterUpdateRequest edits a draft referral letter
+type ReferralLetterUpdateRequest struct {
+	RecipientInstitution *string          `json:"recipient_institution,omitempty"`
+	RecipientDepartment  *string          `json:"recipient_department,omitempty"`
+	RecipientPhysician   *string          `json:"recipient_physician,omitempty"`
+	Purpose              *string          `json:"purpose,omitempty"`
+	Urgency              *string          `json:"urgency,omitempty"`
+	Summary              *ReferralSummary `json:"summary,omitempty"`
+	Remarks              *string          `json:"remarks,omitempty"`
+	SenderInstitution    *string          `json:"sender_institution,omitempty"`
+	SenderAddress        *string          `json:"sender_address,omitempty"`
+	SenderPhone          *string          `json:"sender_phone,omitempty"`
+}
+
+// ReferralSendRequest records how a referral letter was sent
+type ReferralSendRequest struct {
+	Method string `json:"method"` // fax, mail, hand, electronic
+}
+
+// ReferralAcknowledgeRequest records the recipient's reply (返書) to a referral letter
+type ReferralAcknowledgeRequest struct {
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"` // defaults to now
+	Note           *string    `json:"note,omitempty"`
+}
+
+// ReferralLetterFilter represents filter options for listing referral letters
+type ReferralLetterFilter struct {
+	Status *string
+	Limit  int
+}
+
+// Inbound referral statuses
+const (
+	InboundReferralStatusPendingReview = "pending_review"
+	InboundReferralStatusReviewed      = "reviewed"
+)
+
+// Kinds of items extracted from an inbound referral
+const (
+	ReferralItemKindCondition  = "condition"
+	ReferralItemKindAllergy    = "allergy"
+	ReferralItemKindMedication = "medication"
+)
+
+// Review statuses of an extracted item
+const (
+	ReferralItemStatusPending  = "pending"
+	ReferralItemStatusAccepted = "accepted"
+	ReferralItemStatusRejected = "rejected"
+)
+
+// InboundReferral is a referral letter received from another provider. The document is
+// encrypted in blob storage; the items extracted from it wait for review, and an
+// accepted item is mapped into a condition, allergy or medication order.
+type InboundReferral struct {
+	IntakeID  string `json:"intake_id"`
+	PatientID string `json:"patient_id"`
+	Status    string `json:"status"` // pending_review, reviewed
+
+	ReferringInstitution string      `json:"referring_institution"`
+	ReferringPhysician   *string     `json:"referring_physician,omitempty"`
+	ReferralDate         *civil.Date `json:"referral_date,omitempty"`
+	Purpose              *string     `json:"purpose,omitempty"`
+
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	SHA256      string `json:"sha256"`
+	BlobKey     string `json:"-"`
+	WrappedKey  string `json:"-"`
+
+	Items []ReferralIntakeItem `json:"items"`
+
+	ReceivedAt time.Time  `json:"received_at"`
+	ReceivedBy string     `json:"received_by"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"` // when the last pending item was resolved
+	ReviewedBy *string    `json:"reviewed_by,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ReferralIntakeItem is a problem, allergy or medication extracted from an inbound referral
+type ReferralIntakeItem struct {
+	ItemID     string     `json:"item_id"`
+	Kind       string     `json:"kind"` // condition, allergy, medication
+	Text       string     `json:"text"` // as written in the letter
+	Status     string     `json:"status"`
+	ResourceID *string    `json:"resource_id,omitempty"` // the condition, allergy or order created on acceptance
+	ReviewedBy *string    `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	Note       *string    `json:"note,omitempty"`
+}
+
+// Item returns the extracted item with the given ID, or nil
+func (r *InboundReferral) Item(itemID string) *ReferralIntakeItem {
+	for i := range r.Items {
+		if r.Items[i].ItemID == itemID {
+			return &r.Items[i]
+		}
+	}
+	return nil
+}
+
+// PendingItems counts the extracted items still waiting for review
+func (r *InboundReferral) PendingItems() int {
+	pending := 0
+	for _, item := range r.Items {
+		if item.Status == ReferralItemStatusPending {
+			pending++
+		}
+	}
+	return pending
+}
+
+// PendingItem returns the extracted item with the given ID if it still waits for review
+func (r *InboundReferral) PendingItem(itemID string) (*ReferralIntakeItem, error) {
+	item := r.Item(itemID)
+	if item == nil {
+		return nil, fmt.Errorf("referral item not found")
+	}
+	if item.Status != ReferralItemStatusPending {
+		return nil, fmt.Errorf("CONFLICT: referral item has already been %s", item.Status)
+	}
+	return item, nil
+}
+
+// ResolveItem records the review of a pending item
+func (r *InboundReferral) ResolveItem(itemID, status string, resourceID, note *string, reviewedBy string, now time.Time) error {
+	item, err := r.PendingItem(itemID)
+	if err != nil {
+		return err
+	}
+	item.Status = status
+	item.ResourceID = resourceID
+	item.Note = note
+	item.ReviewedBy = &reviewedBy
+	item.ReviewedAt = &now
+	return nil
+}
+
+// UpdateReviewStatus recomputes the review status from the items. A referral with
+// pending items waits for review; otherwise it counts as reviewed by whoever resolved
+// its last item.
+func (r *InboundReferral) UpdateReviewStatus(actor string, now time.Time) {
+	if r.PendingItems() > 0 {
+		r.Status = InboundReferralStatusPendingReview
+		r.ReviewedAt, r.ReviewedBy = nil, nil
+	} else if r.Status != InboundReferralStatusReviewed {
+		r.Status = InboundReferralStatusReviewed
+		r.ReviewedAt = &now
+		r.ReviewedBy = &actor
+	}
+}
+
+// ReferralIntakeItemInput is an item extracted from an inbound referral for review
+type ReferralIntakeItemInput struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+// InboundReferralUpload is a received referral document with its details
+type InboundReferralUpload struct {
+	ReferringInstitution string
+	ReferringPhysician   *string
+	ReferralDate         *civil.Date
+	Purpose              *string
+	FileName             string
+	Data                 []byte
+	Items                []ReferralIntakeItemInput
+}
+
+// ReferralItemAcceptRequest maps an extracted item into the patient's records. Exactly the
+// request matching the item's kind is given; its patient is the referral's patient.
+type ReferralItemAcceptRequest struct {
+	Condition       *MedicalConditionCreateRequest   `json:"condition,omitempty"`
+	Allergy         *AllergyIntoleranceCreateRequest `json:"allergy,omitempty"`
+	MedicationOrder *MedicationOrderCreateRequest    `json:"medication_order,omitempty"`
+}
+
+// ReferralItemRejectRequest rejects an extracted item
+type ReferralItemRejectRequest struct {
+	Note *string `json:"note,omitempty"`
+}
+
+// InboundReferralFilter represents filter options for listing inbound referrals
+type InboundReferralFilter struct {
+	Status *string
+	Limit  int
+}
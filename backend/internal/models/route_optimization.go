@@ -0,0 +1,156 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/pkg/geo"
+)
+
+// RouteOptimizationJob represents one optimization run stored in route_optimization_jobs
+type RouteOptimizationJob struct {
+	JobID           string     `json:"job_id"`
+	OrganizationID  *string    `json:"organization_id,omitempty"`
+	JobType         string     `json:"job_type"`         // daily_route, weekly_route, emergency_insertion, manual_optimization
+	JobStatus       string     `json:"job_status"`       // pending, processing, completed, failed, cancelled
+	OptimizerEngine string     `json:"optimizer_engine"` // local, google
+	TargetDate      civil.Date `json:"target_date"`
+	TargetStartTime *time.Time `json:"target_start_time,omitempty"`
+	TargetEndTime   *time.Time `json:"target_end_time,omitempty"`
+
+	StaffID   *string `json:"staff_id,omitempty"`
+	VehicleID *string `json:"vehicle_id,omitempty"`
+
+	StartLocationID *string  `json:"start_location_id,omitempty"`
+	EndLocationID   *string  `json:"end_location_id,omitempty"`
+	StartLatitude   *float64 `json:"start_latitude,omitempty"`
+	StartLongitude  *float64 `json:"start_longitude,omitempty"`
+	EndLatitude     *float64 `json:"end_latitude,omitempty"`
+	EndLongitude    *float64 `json:"end_longitude,omitempty"`
+
+	OptimizationParams  json.RawMessage `json:"optimization_params"` // JSONB
+	IncludedScheduleIDs []string        `json:"included_schedule_ids"`
+	TotalVisitsCount    int64           `json:"total_visits_count"`
+
+	// Google Route Optimization API exchange (only for the google engine)
+	GoogleAPIRequestPayload    json.RawMessage `json:"google_api_request_payload,omitempty"`
+	GoogleAPIRequestTime       *time.Time      `json:"google_api_request_time,omitempty"`
+	GoogleAPIResponsePayload   json.RawMessage `json:"google_api_response_payload,omitempty"`
+	GoogleAPIResponseTime      *time.Time      `json:"google_api_response_time,omitempty"`
+	GoogleAPIComputationTimeMs *int64          `json:"google_api_computation_time_ms,omitempty"`
+
+	OptimizedRoute       json.RawMessage `json:"optimized_route,omitempty"` // JSONB - RouteOptimizationResult
+	TotalDistanceMeters  *int64          `json:"total_distance_meters,omitempty"`
+	TotalDurationSeconds *int64          `json:"total_duration_seconds,omitempty"`
+
+	AppliedToSchedules bool       `json:"applied_to_schedules"`
+	AppliedAt          *time.Time `json:"applied_at,omitempty"`
+	AppliedBy          *string    `json:"applied_by,omitempty"`
+
+	ErrorCode    *string `json:"error_code,omitempty"`
+	ErrorMessage *string `json:"error_message,omitempty"`
+	RetryCount   int64   `json:"retry_count"`
+
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy *string   `json:"created_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy *string   `json:"updated_by,omitempty"`
+}
+
+// RouteOptimizeRequest represents the request body for POST /routes/optimize
+type RouteOptimizeRequest struct {
+	TargetDate     string  `json:"target_date" validate:"required"` // YYYY-MM-DD
+	OrganizationID *string `json:"organization_id,omitempty"`
+	StaffID        *string `json:"staff_id,omitempty"`
+	VehicleID      *string `json:"vehicle_id,omitempty"`
+
+	// Visits to route. When empty, the staff member's open schedules on target_date are used.
+	ScheduleIDs []string `json:"schedule_ids,omitempty"`
+
+	// Start/end either from a logistics location or explicit coordinates.
+	// The end defaults to the start (return to base).
+	StartLocationID *string  `json:"start_location_id,omitempty"`
+	EndLocationID   *string  `json:"end_location_id,omitempty"`
+	StartLatitude   *float64 `json:"start_latitude,omitempty"`
+	StartLongitude  *float64 `json:"start_longitude,omitempty"`
+	EndLatitude     *float64 `json:"end_latitude,omitempty"`
+	EndLongitude    *float64 `json:"end_longitude,omitempty"`
+
+	DepartureTime *string `json:"departure_time,omitempty"` // HH:MM, defaults to the start location's default_departure_time or 09:00
+	Objective     string  `json:"objective,omitempty"`      // minimize_travel_time (default), minimize_distance
+	Engine        string  `json:"engine,omitempty"`         // local (default), google
+	JobType       string  `json:"job_type,omitempty"`       // defaults to manual_optimization
+
+	// Apply writes the optimized sequence back to visit_schedules
+	Apply             bool `json:"apply"`
+	OverrideConflicts bool `json:"override_conflicts,omitempty"` // apply despite staff double-booking or travel conflicts
+}
+
+// RouteStop is one visit handed to a RouteOptimizer
+type RouteStop struct {
+	ScheduleID      string     `json:"schedule_id"`
+	PatientID       string     `json:"patient_id"`
+	Location        geo.Point  `json:"location"`
+	WindowStart     *time.Time `json:"window_start,omitempty"`
+	WindowEnd       *time.Time `json:"window_end,omitempty"`
+	DurationMinutes int64      `json:"duration_minutes"`
+	Priority        int64      `json:"priority"` // 1-10, higher is more important
+}
+
+// RouteOptimizationProblem is the engine-neutral input of a RouteOptimizer
+type RouteOptimizationProblem struct {
+	Start         geo.Point   `json:"start"`
+	End           geo.Point   `json:"end"`
+	DepartureTime time.Time   `json:"departure_time"`
+	Objective     string      `json:"objective"`
+	Stops         []RouteStop `json:"stops"`
+}
+
+// OptimizedVisit is one visit in an optimized route
+type OptimizedVisit struct {
+	ScheduleID            string    `json:"schedule_id"`
+	Sequence              int       `json:"sequence"`
+	ArrivalTime           time.Time `json:"arrival_time"`
+	StartTime             time.Time `json:"start_time"`
+	DepartureTime         time.Time `json:"departure_time"`
+	TravelDistanceMeters  int64     `json:"travel_distance_meters"`
+	TravelDurationSeconds int64     `json:"travel_duration_seconds"`
+	WaitSeconds           int64     `json:"wait_seconds"`
+	Late                  bool      `json:"late"` // service starts after the time window closes
+}
+
+// OptimizedRoute is the visit sequence for one staff member/vehicle
+type OptimizedRoute struct {
+	VehicleIndex         int              `json:"vehicle_index"`
+	Visits               []OptimizedVisit `json:"visits"`
+	ReturnTime           time.Time        `json:"return_time"`
+	TotalDistanceMeters  int64            `json:"total_distance_meters"`
+	TotalDurationSeconds int64            `json:"total_duration_seconds"`
+}
+
+// RouteOptimizationResult is stored in route_optimization_jobs.optimized_route
+type RouteOptimizationResult struct {
+	Engine             string           `json:"engine"`
+	Routes             []OptimizedRoute `json:"routes"`
+	SkippedScheduleIDs []string         `json:"skipped_schedule_ids,omitempty"` // visits without coordinates or not served
+
+	// Raw engine exchange, persisted on the job row rather than in optimized_route
+	RequestPayload  json.RawMessage `json:"-"`
+	ResponsePayload json.RawMessage `json:"-"`
+}
+
+// ScheduleOptimizationResult is written to visit_schedules.optimization_result
+// when an optimized route is applied
+type ScheduleOptimizationResult struct {
+	JobID         string    `json:"job_id"`
+	Engine        string    `json:"engine"`
+	Sequence      int       `json:"sequence"`
+	ArrivalTime   time.Time `json:"arrival_time"`
+	StartTime     time.Time `json:"start_time"`
+	DepartureTime time.Time `json:"departure_time"`
+	Late          bool      `json:"late"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ScheduleConflict describes an existing visit that clashes with a proposed staff assignment
+type ScheduleConflict struct {
+	ScheduleID   string     `json:"schedule_id"`
+	PatientID    string     `json:"patient_id"`
+	ConflictType string     `json:"conflict_type"`         // "overlap" | "travel_time" | "day_capacity"
+	VisitStart   *time.Time `json:"visit_start,omitempty"` // omitted for visits without a planned start
+	VisitEnd     *time.Time `json:"visit_end,omitempty"`
+
+	// travel_time conflicts only
+	RequiredTravelMinutes  int64 `json:"required_travel_minutes,omitempty"`
+	AvailableTravelMinutes int64 `json:"available_travel_minutes,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// ScheduleConflictResponse is the 409 body returned when an assignment conflicts.
+// Repeat the request with override_conflicts=true to save it anyway.
+type ScheduleConflictResponse struct {
+	Error                  string             `json:"error"`
+	StaffID                string             `json:"staff_id"`
+	ConflictingScheduleIDs []string           `json:"conflicting_schedule_ids"`
+	Conflicts              []ScheduleConflict `json:"conflicts"`
+}
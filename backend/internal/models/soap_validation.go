@@ -0,0 +1,269 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SOAPFieldError describes one invalid field in SOAP content, addressed by its JSON path
+// (e.g. "soap_content.objective.vitalSigns.spo2.value")
+type SOAPFieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// SOAPValidationError is returned when SOAP content does not match the typed schema or a
+// record is completed while template-required sections are still empty
+type SOAPValidationError struct {
+	Fields []SOAPFieldError
+}
+
+func (e *SOAPValidationError) Error() string {
+	if len(e.Fields) == 1 {
+		return fmt.Sprintf("invalid soap_content: %s %s", e.Fields[0].Path, e.Fields[0].Message)
+	}
+	return fmt.Sprintf("invalid soap_content: %d field errors", len(e.Fields))
+}
+
+// SOAPValidationResponse is the 422 response body for invalid SOAP content
+type SOAPValidationResponse struct {
+	Error  string           `json:"error"`
+	Fields []SOAPFieldError `json:"fields"`
+}
+
+// soapPathPrefix roots field paths at the request field that carries the content
+const soapPathPrefix = "soap_content"
+
+// Accepted enum values in the typed SOAP schema
+var (
+	validSOAPSections         = map[string]bool{"subjective": true, "objective": true, "assessment": true, "plan": true}
+	validSymptomSeverities    = map[string]bool{"mild": true, "moderate": true, "severe": true}
+	validDiagnosisStatuses    = map[string]bool{"provisional": true, "confirmed": true, "differential": true}
+	validDiagnosisCodeSystems = map[string]bool{"ICD-10": true, "SNOMED CT": true, "SNOMED-CT": true, "MEDIS": true}
+	validMedicationActions    = map[string]bool{"prescribe": true, "continue": true, "discontinue": true, "adjust": true}
+	validReferralUrgencies    = map[string]bool{"routine": true, "urgent": true, "emergent": true}
+)
+
+// Physiologically plausible ranges for vital signs. Values outside them are almost
+// always unit mix-ups or typos rather than real measurements.
+var vitalSignRanges = map[string][2]float64{
+	"heartRate":       {20, 300}, // bpm
+	"spo2":            {50, 100}, // %
+	"temperature":     {30, 45},  // °C
+	"respiratoryRate": {4, 80},   // /min
+	"systolic":        {40, 300}, // mmHg
+	"diastolic":       {20, 200}, // mmHg
+}
+
+// ValidateSOAPContent checks SOAP content against the typed schema and returns a
+// *SOAPValidationError listing every invalid field. Empty content is valid.
+func ValidateSOAPContent(raw json.RawMessage) error {
+	if isEmptySOAP(raw) {
+		return nil
+	}
+
+	v := &soapValidator{}
+
+	var content SOAPContent
+	if err := json.Unmarshal(raw, &content); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			v.add(typeErr.Field, fmt.Sprintf("must be %s, got %s", jsonTypeName(typeErr.Type.Kind().String()), typeErr.Value))
+		} else {
+			v.add("", "must be a JSON object")
+		}
+		return v.err()
+	}
+
+	if s := content.Subjective; s != nil {
+		for i, symptom := range s.Symptoms {
+			if symptom.Severity != "" && !validSymptomSeverities[symptom.Severity] {
+				v.add(fmt.Sprintf("subjective.symptoms[%d].severity", i), "must be one of mild, moderate, severe")
+			}
+		}
+		if s.PainScale != nil && (s.PainScale.Score < 0 || s.PainScale.Score > 10) {
+			v.add("subjective.painScale.score", "must be between 0 and 10")
+		}
+	}
+
+	if o := content.Objective; o != nil && o.VitalSigns != nil {
+		vs := o.VitalSigns
+		if bp := vs.BloodPressure; bp != nil {
+			v.checkRange("objective.vitalSigns.bloodPressure.systolic", "systolic", float64(bp.Systolic))
+			v.checkRange("objective.vitalSigns.bloodPressure.diastolic", "diastolic", float64(bp.Diastolic))
+			if bp.Systolic != 0 && bp.Diastolic != 0 && bp.Diastolic >= bp.Systolic {
+				v.add("objective.vitalSigns.bloodPressure.diastolic", "must be lower than systolic")
+			}
+		}
+		for name, m := range map[string]*Measurement{
+			"heartRate":       vs.HeartRate,
+			"spo2":            vs.SPO2,
+			"temperature":     vs.Temperature,
+			"respiratoryRate": vs.RespiratoryRate,
+		} {
+			if m != nil {
+				v.checkRange("objective.vitalSigns."+name+".value", name, m.Value)
+			}
+		}
+	}
+
+	if a := content.Assessment; a != nil {
+		for i, d := range a.Diagnoses {
+			path := fmt.Sprintf("assessment.diagnoses[%d]", i)
+			if d.Status != "" && !validDiagnosisStatuses[d.Status] {
+				v.add(path+".status", "must be one of provisional, confirmed, differential")
+			}
+			if d.Code == nil {
+				continue
+			}
+			if d.Code.System == "" && d.Code.Code != "" {
+				v.add(path+".code.system", "is required when a code is given")
+			} else if d.Code.System != "" && !validDiagnosisCodeSystems[d.Code.System] {
+				v.add(path+".code.system", "must be one of ICD-10, SNOMED CT, SNOMED-CT, MEDIS")
+			}
+			if d.Code.Code == "" && d.Code.System != "" {
+				v.add(path+".code.code", "is required when a code system is given")
+			}
+		}
+	}
+
+	if p := content.Plan; p != nil {
+		for i, m := range p.Medications {
+			if m.Action != "" && !validMedicationActions[m.Action] {
+				v.add(fmt.Sprintf("plan.medications[%d].action", i), "must be one of prescribe, continue, discontinue, adjust")
+			}
+		}
+		if p.NextVisit != nil && p.NextVisit.ScheduledDate != "" {
+			if _, err := time.Parse("2006-01-02", p.NextVisit.ScheduledDate); err != nil {
+				v.add("plan.nextVisit.scheduledDate", "must be a date in YYYY-MM-DD format")
+			}
+		}
+		for i, r := range p.Referrals {
+			if r.Urgency != "" && !validReferralUrgencies[r.Urgency] {
+				v.add(fmt.Sprintf("plan.referrals[%d].urgency", i), "must be one of routine, urgent, emergent")
+			}
+		}
+	}
+
+	return v.err()
+}
+
+// ValidateRequiredSections checks that each template-declared section is a dot-separated
+// path into one of the four SOAP sections, such as "assessment.diagnoses"
+func ValidateRequiredSections(sections []string) error {
+	for _, section := range sections {
+		parts := strings.Split(section, ".")
+		if !validSOAPSections[parts[0]] {
+			return fmt.Errorf("invalid required section %q: must start with subjective, objective, assessment or plan", section)
+		}
+		for _, part := range parts[1:] {
+			if part == "" {
+				return fmt.Errorf("invalid required section %q", section)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckRequiredSections returns a *SOAPValidationError naming each required section that
+// is missing or empty in the content, or nil when all of them are filled
+func CheckRequiredSections(raw json.RawMessage, sections []string) error {
+	if len(sections) == 0 {
+		return nil
+	}
+
+	var content interface{}
+	if !isEmptySOAP(raw) {
+		if err := json.Unmarshal(raw, &content); err != nil {
+			return fmt.Errorf("failed to parse SOAP content: %w", err)
+		}
+	}
+
+	v := &soapValidator{}
+	for _, section := range sections {
+		value := content
+		for _, key := range strings.Split(section, ".") {
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = obj[key]
+		}
+		if isEmptySOAPValue(value) {
+			v.add(section, "is required by the template before the record can be completed")
+		}
+	}
+	return v.err()
+}
+
+// soapValidator collects field errors with paths relative to the SOAP root
+type soapValidator struct {
+	fields []SOAPFieldError
+}
+
+func (v *soapValidator) add(path, message string) {
+	full := soapPathPrefix
+	if path != "" {
+		full += "." + path
+	}
+	v.fields = append(v.fields, SOAPFieldError{Path: full, Message: message})
+}
+
+// checkRange flags a measured value outside its plausible range. Zero is how an omitted
+// value decodes, so it is not checked.
+func (v *soapValidator) checkRange(path, name string, value float64) {
+	bounds := vitalSignRanges[name]
+	if value != 0 && (value < bounds[0] || value > bounds[1]) {
+		v.add(path, fmt.Sprintf("must be between %g and %g", bounds[0], bounds[1]))
+	}
+}
+
+func (v *soapValidator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	// Map iteration above is unordered; keep responses stable
+	sort.SliceStable(v.fields, func(i, j int) bool { return v.fields[i].Path < v.fields[j].Path })
+	return &SOAPValidationError{Fields: v.fields}
+}
+
+func isEmptySOAP(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
+// isEmptySOAPValue treats null, blank strings and empty objects or arrays as unfilled
+func isEmptySOAPValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// jsonTypeName names a Go kind the way a JSON client would understand it
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "string":
+		return "a string"
+	case "bool":
+		return "a boolean"
+	case "slice", "array":
+		return "an array"
+	case "struct", "map", "ptr":
+		return "an object"
+	}
+	return "a number"
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSOAPContent(t *testing.T) {
+	fieldErrors := func(t *testing.T, soap string) []SOAPFieldError {
+		err := ValidateSOAPContent(json.RawMessage(soap))
+		if err == nil {
+			return nil
+		}
+		var validationErr *SOAPValidationError
+		require.ErrorAs(t, err, &validationErr)
+		return validationErr.Fields
+	}
+
+	t.Run("Valid content", func(t *testing.T) {
+		assert.Empty(t, fieldErrors(t, `{
+			"subjective": {"symptoms": [{"display": "倦怠感", "severity": "moderate"}], "painScale": {"score": 0}},
+			"objective": {"vitalSigns": {
+				"bloodPressure": {"systolic": 132, "diastolic": 84},
+				"heartRate": {"value": 72}, "spo2": {"value": 96}, "temperature": {"value": 36.8}
+			}},
+			"assessment": {"diagnoses": [{"code": {"system": "ICD-10", "code": "I10"}, "status": "confirmed"}]},
+			"plan": {
+				"medications": [{"action": "continue", "drugName": "アムロジピン"}],
+				"nextVisit": {"scheduledDate": "2025-04-24"},
+				"referrals": [{"specialty": "cardiology", "urgency": "routine"}]
+			}
+		}`))
+	})
+
+	t.Run("Empty content", func(t *testing.T) {
+		assert.NoError(t, ValidateSOAPContent(nil))
+		assert.NoError(t, ValidateSOAPContent(json.RawMessage(`null`)))
+	})
+
+	t.Run("Reports every invalid field by path", func(t *testing.T) {
+		fields := fieldErrors(t, `{
+			"subjective": {"symptoms": [{"severity": "mild"}, {"severity": "unbearable"}], "painScale": {"score": 12}},
+			"objective": {"vitalSigns": {
+				"bloodPressure": {"systolic": 80, "diastolic": 90},
+				"spo2": {"value": 150}, "temperature": {"value": 98.6}
+			}},
+			"assessment": {"diagnoses": [{"code": {"system": "ICD-9", "code": "401.9"}, "status": "suspected"}, {"code": {"code": "I10"}}]},
+			"plan": {
+				"medications": [{"action": "stop"}],
+				"nextVisit": {"scheduledDate": "2025/04/24"},
+				"referrals": [{"urgency": "asap"}]
+			}
+		}`)
+
+		paths := make([]string, len(fields))
+		for i, f := range fields {
+			paths[i] = f.Path
+		}
+		assert.Equal(t, []string{
+			"soap_content.assessment.diagnoses[0].code.system",
+			"soap_content.assessment.diagnoses[0].status",
+			"soap_content.assessment.diagnoses[1].code.system",
+			"soap_content.objective.vitalSigns.bloodPressure.diastolic",
+			"soap_content.objective.vitalSigns.spo2.value",
+			"soap_content.objective.vitalSigns.temperature.value",
+			"soap_content.plan.medications[0].action",
+			"soap_content.plan.nextVisit.scheduledDate",
+			"soap_content.plan.referrals[0].urgency",
+			"soap_content.subjective.painScale.score",
+			"soap_content.subjective.symptoms[1].severity",
+		}, paths)
+	})
+
+	t.Run("Wrong JSON type", func(t *testing.T) {
+		fields := fieldErrors(t, `{"subjective": {"painScale": {"score": "high"}}}`)
+		require.Len(t, fields, 1)
+		assert.Equal(t, "soap_content.subjective.painScale.score", fields[0].Path)
+		assert.Contains(t, fields[0].Message, "must be a number")
+	})
+
+	t.Run("Not an object", func(t *testing.T) {
+		fields := fieldErrors(t, `["subjective"]`)
+		require.Len(t, fields, 1)
+		assert.Equal(t, "soap_content", fields[0].Path)
+	})
+}
+
+func TestRequiredSections(t *testing.T) {
+	t.Run("Section paths", func(t *testing.T) {
+		assert.NoError(t, ValidateRequiredSections([]string{"assessment.diagnoses", "plan"}))
+		assert.Error(t, ValidateRequiredSections([]string{"diagnoses"}))
+		assert.Error(t, ValidateRequiredSections([]string{"plan..nextVisit"}))
+	})
+
+	sections := []string{"subjective.chiefComplaint", "assessment.diagnoses", "plan.nextVisit"}
+
+	t.Run("All filled", func(t *testing.T) {
+		err := CheckRequiredSections(json.RawMessage(`{
+			"subjective": {"chiefComplaint": "息切れ"},
+			"assessment": {"diagnoses": [{"code": {"system": "ICD-10", "code": "I50"}}]},
+			"plan": {"nextVisit": {"scheduledDate": "2025-04-24"}}
+		}`), sections)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Missing and empty sections", func(t *testing.T) {
+		err := CheckRequiredSections(json.RawMessage(`{
+			"subjective": {"chiefComplaint": "  "},
+			"assessment": {"diagnoses": []}
+		}`), sections)
+
+		var validationErr *SOAPValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Len(t, validationErr.Fields, 3)
+		assert.Equal(t, "soap_content.assessment.diagnoses", validationErr.Fields[0].Path)
+		assert.Equal(t, "soap_content.plan.nextVisit", validationErr.Fields[1].Path)
+		assert.Equal(t, "soap_content.subjective.chiefComplaint", validationErr.Fields[2].Path)
+	})
+
+	t.Run("No content", func(t *testing.T) {
+		err := CheckRequiredSections(nil, sections)
+		var validationErr *SOAPValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Len(t, validationErr.Fields, 3)
+	})
+
+	t.Run("No requirements", func(t *testing.T) {
+		assert.NoError(t, CheckRequiredSections(nil, nil))
+	})
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// StaffLocationPing is one GPS fix reported by a staff member's device
+type StaffLocationPing struct {
+	PingID         string    `json:"ping_id"`
+	StaffID        string    `json:"staff_id"`
+	VehicleID      *string   `json:"vehicle_id,omitempty"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	AccuracyMeters *float64  `json:"accuracy_meters,omitempty"`
+	SpeedKmh       *float64  `json:"speed_kmh,omitempty"`
+	HeadingDegrees *float64  `json:"heading_degrees,omitempty"`
+	RecordedAt     time.Time `json:"recorded_at"`
+	ReceivedAt     time.Time `json:"received_at"`
+}
+
+// StaffLocationPingInput is one fix in a location batch
+type StaffLocationPingInput struct {
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	AccuracyMeters *float64  `json:"accuracy_meters,omitempty"`
+	SpeedKmh       *float64  `json:"speed_kmh,omitempty"`
+	HeadingDegrees *float64  `json:"heading_degrees,omitempty"`
+	RecordedAt     time.Time `json:"recorded_at" validate:"required"`
+}
+
+// StaffLocationBatchRequest represents the request body for reporting the caller's location.
+// Devices buffer fixes while offline and send them together; the newest fix becomes the
+// staff member's (and vehicle's) current position.
+type StaffLocationBatchRequest struct {
+	VehicleID *string                  `json:"vehicle_id,omitempty"` // defaults to the staff member's assigned vehicle; otherwise one booked for their visits today
+	Pings     []StaffLocationPingInput `json:"pings" validate:"required,min=1,max=100"`
+}
+
+// StaffLocationBatchResult reports what was stored from a batch and the resulting ETA
+type StaffLocationBatchResult struct {
+	Accepted  int       `json:"accepted"`
+	Rejected  int       `json:"rejected"` // fixes outside the coordinate range, in the future or past retention
+	NextVisit *VisitETA `json:"next_visit,omitempty"`
+}
+
+// VisitETA is the live arrival estimate for a staff member's next visit
+type VisitETA struct {
+	ScheduleID         string     `json:"schedule_id"`
+	PatientID          string     `json:"patient_id"`
+	StaffID            string     `json:"staff_id"`
+	EstimatedArrivalAt time.Time  `json:"estimated_arrival_at"`
+	DueBy              *time.Time `json:"due_by,omitempty"` // latest on-time arrival, when the visit has a time window
+	RunningLate        bool       `json:"running_late"`
+	LateByMinutes      int64      `json:"late_by_minutes,omitempty"`
+	DistanceKm         float64    `json:"distance_km"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// StaffMember represents a healthcare professional in the staff directory
+type StaffMember struct {
+	StaffID        string  `json:"staff_id"`
+	OrganizationID *string `json:"organization_id,omitempty"`
+
+	// Name
+	FamilyName     string  `json:"family_name"`
+	GivenName      string  `json:"given_name"`
+	FamilyNameKana *string `json:"family_name_kana,omitempty"`
+	GivenNameKana  *string `json:"given_name_kana,omitempty"`
+
+	// Contact
+	Email                 string  `json:"email"`
+	Phone                 *string `json:"phone,omitempty"`
+	EmergencyContactPhone *string `json:"emergency_contact_phone,omitempty"`
+
+	// Role and license
+	Role              string      `json:"role"` // doctor, nurse, care_manager, therapist, pharmacist, driver, admin
+	QualificationType *string     `json:"qualification_type,omitempty"`
+	LicenseNumber     *string     `json:"license_number,omitempty"`
+	LicenseIssuedDate *civil.Date `json:"license_issued_date,omitempty"`
+	LicenseExpiryDate *civil.Date `json:"license_expiry_date,omitempty"`
+
+	// Skills (JSONB)
+	Specialties    json.RawMessage `json:"specialties,omitempty"`
+	Certifications json.RawMessage `json:"certifications,omitempty"`
+
+	// Availability
+	WorkSchedule       json.RawMessage `json:"work_schedule,omitempty"`
+	AvailabilityStatus string          `json:"availability_status"` // available, on_visit, off_duty, on_leave
+
+	// Location
+	BaseLocationID     *string    `json:"base_location_id,omitempty"`
+	CurrentLatitude    *float64   `json:"current_latitude,omitempty"`
+	CurrentLongitude   *float64   `json:"current_longitude,omitempty"`
+	LastLocationUpdate *time.Time `json:"last_location_update,omitempty"`
+	AssignedVehicleID  *string    `json:"assigned_vehicle_id,omitempty"`
+
+	// Permissions
+	CanPrescribe       bool   `json:"can_prescribe"`
+	CanViewAllPatients bool   `json:"can_view_all_patients"`
+	AccessLevel        string `json:"access_level"` // standard, supervisor, admin
+
+	// Account
+	AccountStatus       string     `json:"account_status"` // active, suspended, inactive
+	OnboardingCompleted bool       `json:"onboarding_completed"`
+	LastLoginAt         *time.Time `json:"last_login_at,omitempty"`
+
+	Bio           *string `json:"bio,omitempty"`
+	InternalNotes *string `json:"internal_notes,omitempty"`
+
+	// Audit
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy *string    `json:"created_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UpdatedBy *string    `json:"updated_by,omitempty"`
+	Deleted   bool       `json:"deleted"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// IsActive reports whether the staff member can currently take assignments
+func (s *StaffMember) IsActive() bool {
+	return !s.Deleted && s.AccountStatus == "active"
+}
+
+// LicenseExpiresWithin reports whether the license expires on or before today+days
+func (s *StaffMember) LicenseExpiresWithin(today civil.Date, days int) bool {
+	if s.LicenseExpiryDate == nil {
+		return false
+	}
+	return !s.LicenseExpiryDate.After(today.AddDays(days))
+}
+
+// StaffMemberCreateRequest represents the request body for creating a staff member
+type StaffMemberCreateRequest struct {
+	StaffID               *string         `json:"staff_id,omitempty"` // Firebase UID; generated when omitted
+	OrganizationID        *string         `json:"organization_id,omitempty"`
+	FamilyName            string          `json:"family_name" validate:"required"`
+	GivenName             string          `json:"given_name" validate:"required"`
+	FamilyNameKana        *string         `json:"family_name_kana,omitempty"`
+	GivenNameKana         *string         `json:"given_name_kana,omitempty"`
+	Email                 string          `json:"email" validate:"required,email"`
+	Phone                 *string         `json:"phone,omitempty"`
+	EmergencyContactPhone *string         `json:"emergency_contact_phone,omitempty"`
+	Role                  string          `json:"role" validate:"required"`
+	QualificationType     *string         `json:"qualification_type,omitempty"`
+	LicenseNumber         *string         `json:"license_number,omitempty"`
+	LicenseIssuedDate     *civil.Date     `json:"license_issued_date,omitempty"`
+	LicenseExpiryDate     *civil.Date     `json:"license_expiry_date,omitempty"`
+	Specialties           json.RawMessage `json:"specialties,omitempty"`
+	Certifications        json.RawMessage `json:"certifications,omitempty"`
+	WorkSchedule          json.RawMessage `json:"work_schedule,omitempty"`
+	AvailabilityStatus    string          `json:"availability_status,omitempty"`
+	BaseLocationID        *string         `json:"base_location_id,omitempty"`
+	AssignedVehicleID     *string         `json:"assigned_vehicle_id,omitempty"`
+	CanPrescribe          bool            `json:"can_prescribe"`
+	CanViewAllPatients    bool            `json:"can_view_all_patients"`
+	AccessLevel           string          `json:"access_level,omitempty"`
+	AccountStatus         string          `json:"account_status,omitempty"`
+	Bio                   *string         `json:"bio,omitempty"`
+	InternalNotes         *string         `json:"internal_notes,omitempty"`
+}
+
+// StaffMemberUpdateRequest represents the request body for updating a staff member
+type StaffMemberUpdateRequest struct {
+	FamilyName            *string         `json:"family_name,omitempty"`
+	GivenName             *string         `json:"given_name,omitempty"`
+	FamilyNameKana        *string         `json:"family_name_kana,omitempty"`
+	GivenNameKana         *string         `json:"given_name_kana,omitempty"`
+	Email                 *string         `json:"email,omitempty"`
+	Phone                 *string         `json:"phone,omitempty"`
+	EmergencyContactPhone *string         `json:"emergency_contact_phone,omitempty"`
+	Role                  *string         `json:"role,omitempty"`
+	QualificationType     *string         `json:"qualification_type,omitempty"`
+	LicenseNumber         *string         `json:"license_number,omitempty"`
+	LicenseIssuedDate     *civil.Date     `json:"license_issued_date,omitempty"`
+	LicenseExpiryDate     *civil.Date     `json:"license_expiry_date,omitempty"`
+	Specialties           json.RawMessage `json:"specialties,omitempty"`
+	Certifications        json.RawMessage `json:"certifications,omitempty"`
+	WorkSchedule          json.RawMessage `json:"work_schedule,omitempty"`
+	AvailabilityStatus    *string         `json:"availability_status,omitempty"`
+	BaseLocationID        *string         `json:"base_location_id,omitempty"`
+	AssignedVehicleID     *string         `json:"assigned_vehicle_id,omitempty"`
+	CanPrescribe          *bool           `json:"can_prescribe,omitempty"`
+	CanViewAllPatients    *bool           `json:"can_view_all_patients,omitempty"`
+	AccessLevel           *string         `json:"access_level,omitempty"`
+	AccountStatus         *string         `json:"account_status,omitempty"`
+	OnboardingCompleted   *bool           `json:"onboarding_completed,omitempty"`
+	Bio                   *string         `json:"bio,omitempty"`
+	InternalNotes         *string         `json:"internal_notes,omitempty"`
+}
+
+// StaffMemberFilter represents filter options for listing staff members
+type StaffMemberFilter struct {
+	OrganizationID     *string
+	Role               *string
+	Specialty          *string
+	AvailabilityStatus *string
+	AccountStatus      *string
+	Limit              int
+	Offset             int
+}
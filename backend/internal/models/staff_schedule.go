@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+
+	"cloud.google.com/go/civil"
+)
+
+// StaffDaySchedule is a staff member's visit timeline for one day across all patients
+type StaffDaySchedule struct {
+	StaffID string                `json:"staff_id"`
+	Date    civil.Date            `json:"date"`
+	Visits  []*StaffScheduleVisit `json:"visits"`
+}
+
+// StaffScheduleVisit is one visit on the timeline with the patient details needed on the road
+type StaffScheduleVisit struct {
+	*VisitSchedule
+
+	Sequence *int                 `json:"sequence,omitempty"` // position in the optimized route, when one has been applied
+	Patient  StaffSchedulePatient `json:"patient"`
+
+	// Allergies and DNAR are null when unknown (see UnavailableDetails); an empty list and
+	// false mean the patient has none
+	Allergies []StaffScheduleAllergy `json:"active_allergies"`
+	DNAR      *bool                  `json:"dnar"` // from the patient's active ACP record
+
+	// UnavailableDetails lists the details that could not be loaded or may not be shown:
+	// "patient", "allergies", "dnar"
+	UnavailableDetails []string `json:"unavailable_details,omitempty"`
+}
+
+// StaffSchedulePatient carries the patient's name and visit address
+type StaffSchedulePatient struct {
+	PatientID          string       `json:"patient_id"`
+	FamilyName         string       `json:"family_name"`
+	GivenName          string       `json:"given_name"`
+	PrimaryPhone       string       `json:"primary_phone,omitempty"`
+	Address            *Address     `json:"address,omitempty"`
+	Geolocation        *Geolocation `json:"geolocation,omitempty"`
+	AccessInstructions string       `json:"access_instructions,omitempty"`
+}
+
+// StaffScheduleAllergy is the summary of an active allergy shown on the timeline
+type StaffScheduleAllergy struct {
+	AllergyID   string `json:"allergy_id"`
+	DisplayName string `json:"display_name"`
+	Category    string `json:"category"`
+	Criticality string `json:"criticality"`
+	MaxSeverity string `json:"max_severity,omitempty"`
+}
+
+// OptimizedSequence returns the route position written by route optimization, if any
+func (v *VisitSchedule) OptimizedSequence() *int {
+	if len(v.OptimizationResult) == 0 {
+		return nil
+	}
+	var result ScheduleOptimizationResult
+	if err := json.Unmarshal(v.OptimizationResult, &result); err != nil || result.JobID == "" {
+		return nil
+	}
+	return &result.Sequence
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// Statutory document types
+const (
+	DocumentTypeHomeNursingInstruction = "home_nursing_instruction" // 訪問看護指示書
+	DocumentTypeHomeCareGuidance       = "home_care_guidance"       // 居宅療養管理指導書
+)
+
+// Sources a statutory document field can be derived from
+const (
+	DocumentSourcePatient             = "patient"
+	DocumentSourceMedicalCondition    = "medical_condition"
+	DocumentSourceMedicationOrder     = "medication_order"
+	DocumentSourceCoverage            = "coverage"
+	DocumentSourceClinicalObservation = "clinical_observation"
+	DocumentSourceStaffMember         = "staff_member"
+	DocumentSourceAllergyIntolerance  = "allergy_intolerance"
+	DocumentSourceMedicalRecord       = "medical_record"
+	DocumentSourceManual              = "manual" // entered by the issuing physician
+)
+
+// StatutoryDocument is an issued statutory form, such as a 訪問看護指示書. The field
+// values are a snapshot taken at issue time, each with the records it was produced from;
+// the rendered PDF is encrypted in blob storage.
+type StatutoryDocument struct {
+	DocumentID   string     `json:"document_id"`
+	PatientID    string     `json:"patient_id"`
+	DocumentType string     `json:"document_type"`
+	Status       string     `json:"status"` // issued, superseded
+	ValidFrom    civil.Date `json:"valid_from"`
+	ValidTo      civil.Date `json:"valid_to"` // inclusive; 指示期間 for instructions
+
+	Fields []DocumentField `json:"fields"`
+
+	PDFSHA256     string `json:"pdf_sha256"`
+	PDFSizeBytes  int64  `json:"pdf_size_bytes"`
+	PDFBlobKey    string `json:"-"`
+	PDFWrappedKey string `json:"-"`
+
+	IssuedAt     time.Time  `json:"issued_at"`
+	IssuedBy     string     `json:"issued_by"`
+	SupersededBy *string    `json:"superseded_by,omitempty"`
+	SupersededAt *time.Time `json:"superseded_at,omitempty"`
+}
+
+// DocumentField is one filled-in field of a statutory document
+type DocumentField struct {
+	Key     string           `json:"key"`
+	Label   string           `json:"label"`
+	Value   string           `json:"value"`
+	Sources []DocumentSource `json:"sources,omitempty"`
+}
+
+// DocumentSource identifies a record a field value was produced from, at the version used
+type DocumentSource struct {
+	Type      string     `json:"type"`
+	ID        string     `json:"id,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// StatutoryDocumentCreateRequest issues a statutory document for a patient. Fields holds
+// the values entered by the physician, by field key; a value given for a field that is
+// otherwise derived from patient records replaces the derived value.
+type StatutoryDocumentCreateRequest struct {
+	DocumentType string            `json:"document_type"`
+	ValidFrom    civil.Date        `json:"valid_from"`
+	ValidTo      *civil.Date       `json:"valid_to,omitempty"` // defaults per document type
+	Fields       map[string]string `json:"fields,omitempty"`
+}
+
+// StatutoryDocumentFilter represents filter options for listing statutory documents
+type StatutoryDocumentFilter struct {
+	DocumentType *string
+	ValidOn      *civil.Date // documents whose validity period includes this date
+	Status       *string
+	Limit        int
+}
+
+// Field returns the field with the given key, or nil
+func (d *StatutoryDocument) Field(key string) *DocumentField {
+	for i := range d.Fields {
+		if d.Fields[i].Key == key {
+			return &d.Fields[i]
+		}
+	}
+	return nil
+}
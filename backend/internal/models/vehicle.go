@@ -0,0 +1,188 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// Vehicle represents a vehicle in the visit fleet
+type Vehicle struct {
+	VehicleID      string  `json:"vehicle_id"`
+	OrganizationID *string `json:"organization_id,omitempty"`
+
+	// Vehicle details
+	VehicleType string  `json:"vehicle_type"` // car, kei_car, van, wheelchair_van, motorcycle, bicycle
+	Make        *string `json:"make,omitempty"`
+	Model       *string `json:"model,omitempty"`
+	Year        *int64  `json:"year,omitempty"`
+	Color       *string `json:"color,omitempty"`
+
+	// Registration (車検)
+	LicensePlate           string      `json:"license_plate"`
+	RegistrationNumber     *string     `json:"registration_number,omitempty"`
+	RegistrationExpiryDate *civil.Date `json:"registration_expiry_date,omitempty"`
+
+	// Insurance
+	InsuranceCompany      *string     `json:"insurance_company,omitempty"`
+	InsurancePolicyNumber *string     `json:"insurance_policy_number,omitempty"`
+	InsuranceExpiryDate   *civil.Date `json:"insurance_expiry_date,omitempty"`
+
+	// Maintenance
+	LastMaintenanceDate *civil.Date `json:"last_maintenance_date,omitempty"`
+	NextMaintenanceDate *civil.Date `json:"next_maintenance_date,omitempty"`
+	OdometerReading     *int64      `json:"odometer_reading,omitempty"`
+	FuelType            *string     `json:"fuel_type,omitempty"` // gasoline, diesel, hybrid, electric
+
+	// Equipment and capacity
+	MedicalEquipment  json.RawMessage `json:"medical_equipment,omitempty"` // JSONB
+	PassengerCapacity *int64          `json:"passenger_capacity,omitempty"`
+	CargoCapacityKg   *float64        `json:"cargo_capacity_kg,omitempty"`
+
+	// GPS tracking
+	GPSDeviceID        *string    `json:"gps_device_id,omitempty"`
+	CurrentLatitude    *float64   `json:"current_latitude,omitempty"`
+	CurrentLongitude   *float64   `json:"current_longitude,omitempty"`
+	LastLocationUpdate *time.Time `json:"last_location_update,omitempty"`
+
+	// Status
+	VehicleStatus       string      `json:"vehicle_status"` // available, in_use, maintenance, out_of_service, retired
+	CurrentlyAssignedTo *string     `json:"currently_assigned_to,omitempty"`
+	AssignmentStartDate *civil.Date `json:"assignment_start_date,omitempty"`
+
+	Notes *string `json:"notes,omitempty"`
+
+	// Audit
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy *string    `json:"created_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UpdatedBy *string    `json:"updated_by,omitempty"`
+	Deleted   bool       `json:"deleted"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// IsBookable reports whether the vehicle can be booked for a visit on the given date.
+// Vehicles under maintenance or out of service are never bookable, and a lapsed
+// 車検 or insurance on the visit date makes the vehicle unusable on public roads.
+func (v *Vehicle) IsBookable(on civil.Date) bool {
+	if v.Deleted {
+		return false
+	}
+	if v.VehicleStatus != "available" && v.VehicleStatus != "in_use" {
+		return false
+	}
+	if v.RegistrationExpiryDate != nil && v.RegistrationExpiryDate.Before(on) {
+		return false
+	}
+	if v.InsuranceExpiryDate != nil && v.InsuranceExpiryDate.Before(on) {
+		return false
+	}
+	return true
+}
+
+// VehicleCreateRequest represents the request body for creating a vehicle
+type VehicleCreateRequest struct {
+	OrganizationID         *string         `json:"organization_id,omitempty"`
+	VehicleType            string          `json:"vehicle_type" validate:"required"`
+	Make                   *string         `json:"make,omitempty"`
+	Model                  *string         `json:"model,omitempty"`
+	Year                   *int64          `json:"year,omitempty"`
+	Color                  *string         `json:"color,omitempty"`
+	LicensePlate           string          `json:"license_plate" validate:"required"`
+	RegistrationNumber     *string         `json:"registration_number,omitempty"`
+	RegistrationExpiryDate *civil.Date     `json:"registration_expiry_date,omitempty"`
+	InsuranceCompany       *string         `json:"insurance_company,omitempty"`
+	InsurancePolicyNumber  *string         `json:"insurance_policy_number,omitempty"`
+	InsuranceExpiryDate    *civil.Date     `json:"insurance_expiry_date,omitempty"`
+	LastMaintenanceDate    *civil.Date     `json:"last_maintenance_date,omitempty"`
+	NextMaintenanceDate    *civil.Date     `json:"next_maintenance_date,omitempty"`
+	OdometerReading        *int64          `json:"odometer_reading,omitempty"`
+	FuelType               *string         `json:"fuel_type,omitempty"`
+	MedicalEquipment       json.RawMessage `json:"medical_equipment,omitempty"`
+	PassengerCapacity      *int64          `json:"passenger_capacity,omitempty"`
+	CargoCapacityKg        *float64        `json:"cargo_capacity_kg,omitempty"`
+	GPSDeviceID            *string         `json:"gps_device_id,omitempty"`
+	VehicleStatus          string          `json:"vehicle_status,omitempty"`
+	Notes                  *string         `json:"notes,omitempty"`
+}
+
+// VehicleUpdateRequest represents the request body for updating a vehicle
+type VehicleUpdateRequest struct {
+	VehicleType            *string         `json:"vehicle_type,omitempty"`
+	Make                   *string         `json:"make,omitempty"`
+	Model                  *string         `json:"model,omitempty"`
+	Year                   *int64          `json:"year,omitempty"`
+	Color                  *string         `json:"color,omitempty"`
+	LicensePlate           *string         `json:"license_plate,omitempty"`
+	RegistrationNumber     *string         `json:"registration_number,omitempty"`
+	RegistrationExpiryDate *civil.Date     `json:"registration_expiry_date,omitempty"`
+	InsuranceCompany       *string         `json:"insurance_company,omitempty"`
+	InsurancePolicyNumber  *string         `json:"insurance_policy_number,omitempty"`
+	InsuranceExpiryDate    *civil.Date     `json:"insurance_expiry_date,omitempty"`
+	LastMaintenanceDate    *civil.Date     `json:"last_maintenance_date,omitempty"`
+	NextMaintenanceDate    *civil.Date     `json:"next_maintenance_date,omitempty"`
+	OdometerReading        *int64          `json:"odometer_reading,omitempty"`
+	FuelType               *string         `json:"fuel_type,omitempty"`
+	MedicalEquipment       json.RawMessage `json:"medical_equipment,omitempty"`
+	PassengerCapacity      *int64          `json:"passenger_capacity,omitempty"`
+	CargoCapacityKg        *float64        `json:"cargo_capacity_kg,omitempty"`
+	GPSDeviceID            *string         `json:"gps_device_id,omitempty"`
+	VehicleStatus          *string         `json:"vehicle_status,omitempty"`
+	CurrentlyAssignedTo    *string         `json:"currently_assigned_to,omitempty"`
+	AssignmentStartDate    *civil.Date     `json:"assignment_start_date,omitempty"`
+	Notes                  *string         `json:"notes,omitempty"`
+}
+
+// VehicleFilter represents filter options for listing vehicles
+type VehicleFilter struct {
+	OrganizationID      *string
+	VehicleType         *string
+	VehicleStatus       *string
+	CurrentlyAssignedTo *string
+	Limit               int
+	Offset              int
+}
+
+// VehicleExpiryItem describes one upcoming or overdue deadline for a vehicle
+type VehicleExpiryItem struct {
+	Kind          string     `json:"kind"` // registration, insurance, maintenance
+	DueDate       civil.Date `json:"due_date"`
+	DaysRemaining int        `json:"days_remaining"` // negative when overdue
+	Overdue       bool       `json:"overdue"`
+}
+
+// VehicleExpiryReport lists vehicles with deadlines inside the reporting window
+type VehicleExpiryReport struct {
+	VehicleID     string              `json:"vehicle_id"`
+	LicensePlate  string              `json:"license_plate"`
+	VehicleType   string              `json:"vehicle_type"`
+	VehicleStatus string              `json:"vehicle_status"`
+	Items         []VehicleExpiryItem `json:"items"`
+}
+
+// ExpiryItems returns the registration, insurance and maintenance deadlines that
+// fall on or before today+days, including ones already past.
+func (v *Vehicle) ExpiryItems(today civil.Date, days int) []VehicleExpiryItem {
+	limit := today.AddDays(days)
+	var items []VehicleExpiryItem
+
+	check := func(kind string, due *civil.Date) {
+		if due == nil || due.After(limit) {
+			return
+		}
+		remaining := due.DaysSince(today)
+		items = append(items, VehicleExpiryItem{
+			Kind:          kind,
+			DueDate:       *due,
+			DaysRemaining: remaining,
+			Overdue:       remaining < 0,
+		})
+	}
+
+	check("registration", v.RegistrationExpiryDate)
+	check("insurance", v.InsuranceExpiryDate)
+	check("maintenance", v.NextMaintenanceDate)
+
+	return items
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"testing"
+
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicle_IsBookable(t *testing.T) {
+	visitDate := civil.Date{Year: 2025, Month: 4, Day: 10}
+	dayBefore := visitDate.AddDays(-1)
+	dayAfter := visitDate.AddDays(1)
+
+	tests := []struct {
+		name               string
+		status             string
+		deleted            bool
+		registrationExpiry *civil.Date
+		insuranceExpiry    *civil.Date
+		expected           bool
+	}{
+		{
+			name:     "Available vehicle without expiry dates",
+			status:   "available",
+			expected: true,
+		},
+		{
+			name:     "In-use vehicle can still be booked",
+			status:   "in_use",
+			expected: true,
+		},
+		{
+			name:     "Vehicle under maintenance",
+			status:   "maintenance",
+			expected: false,
+		},
+		{
+			name:     "Deleted vehicle",
+			status:   "available",
+			deleted:  true,
+			expected: false,
+		},
+		{
+			name:               "Registration expires on the visit date",
+			status:             "available",
+			registrationExpiry: &visitDate,
+			expected:           true,
+		},
+		{
+			name:               "Registration lapsed before the visit date",
+			status:             "available",
+			registrationExpiry: &dayBefore,
+			expected:           false,
+		},
+		{
+			name:            "Insurance lapsed before the visit date",
+			status:          "available",
+			insuranceExpiry: &dayBefore,
+			expected:        false,
+		},
+		{
+			name:               "Both valid after the visit date",
+			status:             "available",
+			registrationExpiry: &dayAfter,
+			insuranceExpiry:    &dayAfter,
+			expected:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Vehicle{
+				VehicleStatus:          tt.status,
+				Deleted:                tt.deleted,
+				RegistrationExpiryDate: tt.registrationExpiry,
+				InsuranceExpiryDate:    tt.insuranceExpiry,
+			}
+			assert.Equal(t, tt.expected, v.IsBookable(visitDate))
+		})
+	}
+}
+
+func TestVehicle_ExpiryItems(t *testing.T) {
+	today := civil.Date{Year: 2025, Month: 4, Day: 1}
+	overdue := today.AddDays(-3)
+	soon := today.AddDays(10)
+	later := today.AddDays(90)
+
+	v := &Vehicle{
+		RegistrationExpiryDate: &soon,
+		InsuranceExpiryDate:    &later,
+		NextMaintenanceDate:    &overdue,
+	}
+
+	items := v.ExpiryItems(today, 30)
+
+	assert.Len(t, items, 2)
+	assert.Equal(t, "registration", items[0].Kind)
+	assert.Equal(t, 10, items[0].DaysRemaining)
+	assert.False(t, items[0].Overdue)
+	assert.Equal(t, "maintenance", items[1].Kind)
+	assert.Equal(t, -3, items[1].DaysRemaining)
+	assert.True(t, items[1].Overdue)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// VisitCheck records the device location at a visit check-in or check-out and
+// whether it fell within the geofence around the patient's home
+type VisitCheck struct {
+	CheckID    string `json:"check_id"`
+	ScheduleID string `json:"schedule_id"`
+	PatientID  string `json:"patient_id"`
+	StaffID    string `json:"staff_id"`
+	CheckType  string `json:"check_type"` // "check_in" | "check_out"
+
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	AccuracyMeters *float64  `json:"accuracy_meters,omitempty"`
+	RecordedAt     time.Time `json:"recorded_at"`
+
+	PatientLatitude  *float64 `json:"patient_latitude,omitempty"`
+	PatientLongitude *float64 `json:"patient_longitude,omitempty"`
+	DistanceMeters   *float64 `json:"distance_meters,omitempty"`
+	RadiusMeters     float64  `json:"radius_meters"`
+	Verified         bool     `json:"verified"`
+	FailureReason    *string  `json:"failure_reason,omitempty"` // "no_patient_geolocation" | "outside_geofence" | "low_accuracy"
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// VisitCheckRequest represents the request body for checking in to or out of a visit
+type VisitCheckRequest struct {
+	Latitude       float64    `json:"latitude" validate:"required"`
+	Longitude      float64    `json:"longitude" validate:"required"`
+	AccuracyMeters *float64   `json:"accuracy_meters,omitempty"`
+	RecordedAt     *time.Time `json:"recorded_at,omitempty"` // device time of the fix when sent later; defaults to now
+}
+
+// VisitCheckResult is the visit after a check-in or check-out together with the check made
+type VisitCheckResult struct {
+	Schedule *VisitSchedule `json:"schedule"`
+	Check    *VisitCheck    `json:"check"`
+}
+
+// UnverifiedVisit is a completed visit whose check-in or check-out could not be verified
+type UnverifiedVisit struct {
+	Schedule *VisitSchedule `json:"schedule"`
+	CheckIn  *VisitCheck    `json:"check_in,omitempty"`
+	CheckOut *VisitCheck    `json:"check_out,omitempty"`
+	Reasons  []string       `json:"reasons"` // e.g. "no_check_in", "check_in_outside_geofence"
+}
+
+// UnverifiedVisitReport lists completed visits in a date range lacking geofence verification
+type UnverifiedVisitReport struct {
+	From   civil.Date         `json:"from"`
+	To     civil.Date         `json:"to"`
+	Visits []*UnverifiedVisit `json:"visits"`
+}
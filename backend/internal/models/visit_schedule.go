@@ -27,6 +27,22 @@ type VisitSchedule struct {
 	// Status
 	Status string `json:"status"` // "draft" | "optimized" | "assigned" | "in_progress" | "completed" | "cancelled"
 
+	// Actual visit times and cancellation, set by status transitions
+	ActualArrivalAt    spanner.NullTime   `json:"actual_arrival_at,omitempty"`
+	ActualDepartureAt  spanner.NullTime   `json:"actual_departure_at,omitempty"`
+	CancelledAt        spanner.NullTime   `json:"cancelled_at,omitempty"`
+	CancelledBy        spanner.NullString `json:"cancelled_by,omitempty"`
+	CancellationReason spanner.NullString `json:"cancellation_reason,omitempty"`
+
+	// Live ETA for the next visit on the assigned staff member's route, from location pings
+	EstimatedArrivalAt spanner.NullTime `json:"estimated_arrival_at,omitempty"`
+	RunningLate        bool             `json:"running_late"`
+	ETAUpdatedAt       spanner.NullTime `json:"eta_updated_at,omitempty"`
+
+	// Geofence verification of check-in/check-out; null until that check has been made
+	CheckInVerified  spanner.NullBool `json:"check_in_verified,omitempty"`
+	CheckOutVerified spanner.NullBool `json:"check_out_verified,omitempty"`
+
 	// Route Optimization integration
 	PriorityScore      int64           `json:"priority_score"`
 	Constraints        json.RawMessage `json:"constraints,omitempty"`         // JSONB - Google Maps API Shipment.VisitRequest equivalent
@@ -36,6 +52,11 @@ type VisitSchedule struct {
 	CarePlanRef  spanner.NullString `json:"care_plan_ref,omitempty"`
 	ActivityRef  spanner.NullString `json:"activity_ref,omitempty"`
 
+	// Recurrence (set on rows materialized from a VisitScheduleRecurrence)
+	RecurrenceID          spanner.NullString `json:"recurrence_id,omitempty"`
+	OccurrenceDate        spanner.NullDate   `json:"occurrence_date,omitempty"`         // original slot date, kept when the visit is moved
+	IsRecurrenceException bool               `json:"is_recurrence_exception,omitempty"` // edited individually; left alone by series edits
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -49,11 +70,12 @@ type VisitScheduleCreateRequest struct {
 	EstimatedDurationMinutes int64           `json:"estimated_duration_minutes" validate:"required,min=5,max=480"`
 	AssignedStaffID          *string         `json:"assigned_staff_id,omitempty"`
 	AssignedVehicleID        *string         `json:"assigned_vehicle_id,omitempty"`
-	Status                   string          `json:"status" validate:"required,oneof=draft optimized assigned in_progress completed cancelled"`
+	Status                   string          `json:"status" validate:"required,oneof=draft assigned"`
 	PriorityScore            int64           `json:"priority_score" validate:"min=1,max=10"`
 	Constraints              json.RawMessage `json:"constraints,omitempty"`
 	CarePlanRef              *string         `json:"care_plan_ref,omitempty"`
 	ActivityRef              *string         `json:"activity_ref,omitempty"`
+	OverrideConflicts        bool            `json:"override_conflicts,omitempty"` // save despite staff double-booking or travel conflicts
 }
 
 // VisitScheduleUpdateRequest represents the request body for updating a visit schedule
@@ -71,6 +93,27 @@ type VisitScheduleUpdateRequest struct {
 	OptimizationResult       json.RawMessage `json:"optimization_result,omitempty"`
 	CarePlanRef              *string         `json:"care_plan_ref,omitempty"`
 	ActivityRef              *string         `json:"activity_ref,omitempty"`
+	OverrideConflicts        bool            `json:"override_conflicts,omitempty"` // save despite staff double-booking or travel conflicts
+}
+
+// VisitScheduleStatusChangeRequest represents the request body for changing a visit's status
+type VisitScheduleStatusChangeRequest struct {
+	Status     string     `json:"status" validate:"required,oneof=draft optimized assigned in_progress completed cancelled"`
+	Reason     *string    `json:"reason,omitempty"`      // required when cancelling
+	OccurredAt *time.Time `json:"occurred_at,omitempty"` // actual arrival/departure time when recorded later; defaults to now
+}
+
+// VisitScheduleStatusEvent records one status transition of a visit schedule
+type VisitScheduleStatusEvent struct {
+	EventID    string    `json:"event_id"`
+	ScheduleID string    `json:"schedule_id"`
+	PatientID  string    `json:"patient_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     *string   `json:"reason,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+	ChangedBy  string    `json:"changed_by"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // VisitScheduleFilter represents filter options for listing visit schedules
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+)
+
+// VisitScheduleRecurrence is an RRULE-style rule that materializes visit_schedules rows
+// (e.g. every 2nd and 4th Tuesday for 月2回訪問診療)
+type VisitScheduleRecurrence struct {
+	RecurrenceID string `json:"recurrence_id"`
+	PatientID    string `json:"patient_id"`
+
+	// Pattern
+	Frequency      string       `json:"frequency"`                  // "weekly" | "biweekly" | "monthly_nth_weekday"
+	ByWeekday      []string     `json:"by_weekday"`                 // RRULE BYDAY codes: MO, TU, WE, TH, FR, SA, SU
+	ByWeekOfMonth  []int64      `json:"by_week_of_month,omitempty"` // monthly_nth_weekday only: 1-5, -1 = last
+	StartDate      civil.Date   `json:"start_date"`
+	EndDate        *civil.Date  `json:"end_date,omitempty"`
+	ExceptionDates []civil.Date `json:"exception_dates,omitempty"`
+
+	// Template applied to each occurrence
+	VisitType                string          `json:"visit_type"`
+	StartTime                *string         `json:"start_time,omitempty"` // HH:MM
+	EndTime                  *string         `json:"end_time,omitempty"`   // HH:MM
+	EstimatedDurationMinutes int64           `json:"estimated_duration_minutes"`
+	AssignedStaffID          *string         `json:"assigned_staff_id,omitempty"`
+	AssignedVehicleID        *string         `json:"assigned_vehicle_id,omitempty"`
+	PriorityScore            int64           `json:"priority_score"`
+	Constraints              json.RawMessage `json:"constraints,omitempty"`
+	CarePlanRef              *string         `json:"care_plan_ref,omitempty"`
+	ActivityRef              *string         `json:"activity_ref,omitempty"`
+
+	// Rolling materialization
+	MaterializedUntil *civil.Date `json:"materialized_until,omitempty"`
+	Status            string      `json:"status"` // "active" | "ended"
+
+	// Audit
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy *string    `json:"created_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UpdatedBy *string    `json:"updated_by,omitempty"`
+	Deleted   bool       `json:"deleted"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// RecurrenceWeekdays maps RRULE BYDAY codes to weekdays
+var RecurrenceWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Occurrences returns the rule's visit dates between from and to (inclusive),
+// honouring the start/end dates and exception dates
+func (r *VisitScheduleRecurrence) Occurrences(from, to civil.Date) []civil.Date {
+	if from.Before(r.StartDate) {
+		from = r.StartDate
+	}
+	if r.EndDate != nil && r.EndDate.Before(to) {
+		to = *r.EndDate
+	}
+
+	weekdays := make(map[time.Weekday]bool, len(r.ByWeekday))
+	for _, code := range r.ByWeekday {
+		if wd, ok := RecurrenceWeekdays[code]; ok {
+			weekdays[wd] = true
+		}
+	}
+	excluded := make(map[civil.Date]bool, len(r.ExceptionDates))
+	for _, d := range r.ExceptionDates {
+		excluded[d] = true
+	}
+
+	var dates []civil.Date
+	for d := from; !d.After(to); d = d.AddDays(1) {
+		if !weekdays[weekdayOf(d)] || excluded[d] {
+			continue
+		}
+		if r.matches(d) {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// matches applies the frequency-specific part of the rule to a date that is
+// already on one of the rule's weekdays
+func (r *VisitScheduleRecurrence) matches(d civil.Date) bool {
+	switch r.Frequency {
+	case "weekly":
+		return true
+	case "biweekly":
+		// Weeks are counted from the Monday of the start date's week
+		anchor := r.StartDate.AddDays(-((int(weekdayOf(r.StartDate)) + 6) % 7))
+		return (d.DaysSince(anchor)/7)%2 == 0
+	case "monthly_nth_weekday":
+		nth := int64((d.Day-1)/7 + 1)
+		last := d.AddDays(7).Month != d.Month
+		for _, w := range r.ByWeekOfMonth {
+			if w == nth || (w == -1 && last) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// RRule renders the pattern as an iCalendar RRULE value
+func (r *VisitScheduleRecurrence) RRule() string {
+	days := append([]string(nil), r.ByWeekday...)
+	sort.Slice(days, func(i, j int) bool {
+		return (RecurrenceWeekdays[days[i]]+6)%7 < (RecurrenceWeekdays[days[j]]+6)%7
+	})
+
+	parts := []string{}
+	switch r.Frequency {
+	case "weekly":
+		parts = append(parts, "FREQ=WEEKLY", "BYDAY="+strings.Join(days, ","))
+	case "biweekly":
+		parts = append(parts, "FREQ=WEEKLY", "INTERVAL=2", "BYDAY="+strings.Join(days, ","))
+	case "monthly_nth_weekday":
+		var byDay []string
+		for _, w := range r.ByWeekOfMonth {
+			for _, day := range days {
+				byDay = append(byDay, fmt.Sprintf("%d%s", w, day))
+			}
+		}
+		parts = append(parts, "FREQ=MONTHLY", "BYDAY="+strings.Join(byDay, ","))
+	}
+	if r.EndDate != nil {
+		parts = append(parts, fmt.Sprintf("UNTIL=%04d%02d%02d", r.EndDate.Year, r.EndDate.Month, r.EndDate.Day))
+	}
+	return strings.Join(parts, ";")
+}
+
+// NewOccurrence builds the visit schedule for one occurrence date; wall-clock
+// start/end times are interpreted in loc. ScheduleID and timestamps are left
+// for the repository to fill.
+func (r *VisitScheduleRecurrence) NewOccurrence(date civil.Date, loc *time.Location) (*VisitSchedule, error) {
+	schedule := &VisitSchedule{
+		PatientID:                r.PatientID,
+		VisitDate:                date,
+		VisitType:                r.VisitType,
+		EstimatedDurationMinutes: r.EstimatedDurationMinutes,
+		Status:                   "draft",
+		PriorityScore:            r.PriorityScore,
+		Constraints:              r.Constraints,
+		RecurrenceID:             spanner.NullString{StringVal: r.RecurrenceID, Valid: true},
+		OccurrenceDate:           spanner.NullDate{Date: date, Valid: true},
+	}
+
+	if r.StartTime != nil {
+		start, err := wallClock(date, *r.StartTime, loc)
+		if err != nil {
+			return nil, err
+		}
+		schedule.TimeWindowStart = spanner.NullTime{Time: start, Valid: true}
+	}
+	if r.EndTime != nil {
+		end, err := wallClock(date, *r.EndTime, loc)
+		if err != nil {
+			return nil, err
+		}
+		schedule.TimeWindowEnd = spanner.NullTime{Time: end, Valid: true}
+	}
+	if r.AssignedStaffID != nil {
+		schedule.AssignedStaffID = spanner.NullString{StringVal: *r.AssignedStaffID, Valid: true}
+		schedule.Status = "assigned"
+	}
+	if r.AssignedVehicleID != nil {
+		schedule.AssignedVehicleID = spanner.NullString{StringVal: *r.AssignedVehicleID, Valid: true}
+	}
+	if r.CarePlanRef != nil {
+		schedule.CarePlanRef = spanner.NullString{StringVal: *r.CarePlanRef, Valid: true}
+	}
+	if r.ActivityRef != nil {
+		schedule.ActivityRef = spanner.NullString{StringVal: *r.ActivityRef, Valid: true}
+	}
+
+	return schedule, nil
+}
+
+func weekdayOf(d civil.Date) time.Weekday {
+	return d.In(time.UTC).Weekday()
+}
+
+func wallClock(date civil.Date, hhmm string, loc *time.Location) (time.Time, error) {
+	clock, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (expected HH:MM)", hhmm)
+	}
+	return time.Date(date.Year, date.Month, date.Day, clock.Hour(), clock.Minute(), 0, 0, loc), nil
+}
+
+// VisitScheduleRecurrenceCreateRequest represents the request body for creating a recurrence rule
+type VisitScheduleRecurrenceCreateRequest struct {
+	Frequency                string          `json:"frequency" validate:"required,oneof=weekly biweekly monthly_nth_weekday"`
+	ByWeekday                []string        `json:"by_weekday" validate:"required"`
+	ByWeekOfMonth            []int64         `json:"by_week_of_month,omitempty"`
+	StartDate                civil.Date      `json:"start_date" validate:"required"`
+	EndDate                  *civil.Date     `json:"end_date,omitempty"`
+	ExceptionDates           []civil.Date    `json:"exception_dates,omitempty"`
+	VisitType                string          `json:"visit_type" validate:"required,oneof=regular emergency initial_assessment terminal_care"`
+	StartTime                *string         `json:"start_time,omitempty"`
+	EndTime                  *string         `json:"end_time,omitempty"`
+	EstimatedDurationMinutes int64           `json:"estimated_duration_minutes" validate:"required,min=5,max=480"`
+	AssignedStaffID          *string         `json:"assigned_staff_id,omitempty"`
+	AssignedVehicleID        *string         `json:"assigned_vehicle_id,omitempty"`
+	PriorityScore            int64           `json:"priority_score" validate:"min=1,max=10"`
+	Constraints              json.RawMessage `json:"constraints,omitempty"`
+	CarePlanRef              *string         `json:"care_plan_ref,omitempty"`
+	ActivityRef              *string         `json:"activity_ref,omitempty"`
+	OverrideConflicts        bool            `json:"override_conflicts,omitempty"` // save despite staff double-booking or travel conflicts
+}
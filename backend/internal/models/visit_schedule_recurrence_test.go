@@ -0,0 +1,127 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(y int, m time.Month, d int) civil.Date {
+	return civil.Date{Year: y, Month: m, Day: d}
+}
+
+func TestVisitScheduleRecurrence_Occurrences(t *testing.T) {
+	endDate := date(2025, 4, 20)
+
+	tests := []struct {
+		name       string
+		recurrence VisitScheduleRecurrence
+		from, to   civil.Date
+		expected   []civil.Date
+	}{
+		{
+			name: "Weekly honours end date and exception dates",
+			recurrence: VisitScheduleRecurrence{
+				Frequency:      "weekly",
+				ByWeekday:      []string{"WE"},
+				StartDate:      date(2025, 4, 1),
+				EndDate:        &endDate,
+				ExceptionDates: []civil.Date{date(2025, 4, 9)},
+			},
+			from:     date(2025, 4, 1),
+			to:       date(2025, 4, 30),
+			expected: []civil.Date{date(2025, 4, 2), date(2025, 4, 16)},
+		},
+		{
+			name: "Biweekly counts weeks from the start date's week",
+			recurrence: VisitScheduleRecurrence{
+				Frequency: "biweekly",
+				ByWeekday: []string{"MO", "TH"},
+				StartDate: date(2025, 4, 2), // Wednesday
+			},
+			from:     date(2025, 3, 1),
+			to:       date(2025, 5, 1),
+			expected: []civil.Date{date(2025, 4, 3), date(2025, 4, 14), date(2025, 4, 17), date(2025, 4, 28), date(2025, 5, 1)},
+		},
+		{
+			name: "Second and fourth Tuesday",
+			recurrence: VisitScheduleRecurrence{
+				Frequency:     "monthly_nth_weekday",
+				ByWeekday:     []string{"TU"},
+				ByWeekOfMonth: []int64{2, 4},
+				StartDate:     date(2025, 4, 1),
+			},
+			from:     date(2025, 4, 1),
+			to:       date(2025, 5, 31),
+			expected: []civil.Date{date(2025, 4, 8), date(2025, 4, 22), date(2025, 5, 13), date(2025, 5, 27)},
+		},
+		{
+			name: "Last Friday of the month",
+			recurrence: VisitScheduleRecurrence{
+				Frequency:     "monthly_nth_weekday",
+				ByWeekday:     []string{"FR"},
+				ByWeekOfMonth: []int64{-1},
+				StartDate:     date(2025, 4, 1),
+			},
+			from:     date(2025, 4, 1),
+			to:       date(2025, 5, 31),
+			expected: []civil.Date{date(2025, 4, 25), date(2025, 5, 30)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.recurrence.Occurrences(tt.from, tt.to))
+		})
+	}
+}
+
+func TestVisitScheduleRecurrence_RRule(t *testing.T) {
+	endDate := date(2025, 12, 31)
+
+	monthly := VisitScheduleRecurrence{
+		Frequency:     "monthly_nth_weekday",
+		ByWeekday:     []string{"TU"},
+		ByWeekOfMonth: []int64{2, 4},
+		EndDate:       &endDate,
+	}
+	assert.Equal(t, "FREQ=MONTHLY;BYDAY=2TU,4TU;UNTIL=20251231", monthly.RRule())
+
+	biweekly := VisitScheduleRecurrence{Frequency: "biweekly", ByWeekday: []string{"TH", "MO"}}
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", biweekly.RRule())
+}
+
+func TestVisitScheduleRecurrence_NewOccurrence(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	staffID := "staff-1"
+	start, end := "09:30", "10:15"
+
+	recurrence := VisitScheduleRecurrence{
+		RecurrenceID:             "rec-1",
+		PatientID:                "patient-1",
+		VisitType:                "regular",
+		StartTime:                &start,
+		EndTime:                  &end,
+		EstimatedDurationMinutes: 45,
+		AssignedStaffID:          &staffID,
+		PriorityScore:            5,
+	}
+
+	schedule, err := recurrence.NewOccurrence(date(2025, 4, 8), jst)
+	require.NoError(t, err)
+
+	assert.Equal(t, date(2025, 4, 8), schedule.VisitDate)
+	assert.Equal(t, "rec-1", schedule.RecurrenceID.StringVal)
+	assert.Equal(t, date(2025, 4, 8), schedule.OccurrenceDate.Date)
+	assert.Equal(t, "assigned", schedule.Status)
+	assert.Equal(t, time.Date(2025, 4, 8, 9, 30, 0, 0, jst), schedule.TimeWindowStart.Time)
+	assert.Equal(t, time.Date(2025, 4, 8, 10, 15, 0, 0, jst), schedule.TimeWindowEnd.Time)
+
+	bad := "9.30"
+	recurrence.StartTime = &bad
+	_, err = recurrence.NewOccurrence(date(2025, 4, 8), jst)
+	assert.Error(t, err)
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// VitalSignCode maps a vital sign of the SOAP objective section to the LOINC code it is
+// stored under in clinical_observations
+type VitalSignCode struct {
+	Key  string          // key under objective.vitalSigns
+	Code ObservationCode // LOINC code of the observation
+	Unit string          // unit used when the SOAP entry has none
+}
+
+// VitalSignCodes lists the vital signs kept in sync between medical records and clinical
+// observations. Blood pressure is a panel whose value holds systolic and diastolic quantities.
+var VitalSignCodes = []VitalSignCode{
+	{Key: "bloodPressure", Code: ObservationCode{System: "LOINC", Code: "85354-9", Display: "Blood pressure panel"}, Unit: "mmHg"},
+	{Key: "heartRate", Code: ObservationCode{System: "LOINC", Code: "8867-4", Display: "Heart rate"}, Unit: "/min"},
+	{Key: "spo2", Code: ObservationCode{System: "LOINC", Code: "59408-5", Display: "Oxygen saturation in Arterial blood by Pulse oximetry"}, Unit: "%"},
+	{Key: "temperature", Code: ObservationCode{System: "LOINC", Code: "8310-5", Display: "Body temperature"}, Unit: "Cel"},
+	{Key: "respiratoryRate", Code: ObservationCode{System: "LOINC", Code: "9279-1", Display: "Respiratory rate"}, Unit: "/min"},
+}
+
+// VitalSignCodeFor returns the vital sign mapped to a LOINC code
+func VitalSignCodeFor(code string) (VitalSignCode, bool) {
+	for _, vs := range VitalSignCodes {
+		if vs.Code.Code == code {
+			return vs, true
+		}
+	}
+	return VitalSignCode{}, false
+}
+
+// VitalSignObservation is the observation code and value derived from one SOAP vital sign
+type VitalSignObservation struct {
+	Code  ObservationCode
+	Value json.RawMessage
+}
+
+// VitalSignObservations derives one observation per vital sign recorded in the SOAP
+// objective section, in VitalSignCodes order. Empty content has none.
+func VitalSignObservations(soapContent json.RawMessage) ([]VitalSignObservation, error) {
+	if isEmptySOAP(soapContent) {
+		return nil, nil
+	}
+
+	var content SOAPContent
+	if err := json.Unmarshal(soapContent, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse SOAP content: %w", err)
+	}
+	if content.Objective == nil || content.Objective.VitalSigns == nil {
+		return nil, nil
+	}
+	vitals := content.Objective.VitalSigns
+
+	measurements := map[string]*Measurement{
+		"heartRate":       vitals.HeartRate,
+		"spo2":            vitals.SPO2,
+		"temperature":     vitals.Temperature,
+		"respiratoryRate": vitals.RespiratoryRate,
+	}
+
+	var observations []VitalSignObservation
+	for _, vs := range VitalSignCodes {
+		var value interface{}
+		if vs.Key == "bloodPressure" {
+			bp := vitals.BloodPressure
+			if bp == nil || (bp.Systolic == 0 && bp.Diastolic == 0) {
+				continue
+			}
+			value = BloodPressureValue{
+				Systolic:  QuantityValue{Value: float64(bp.Systolic), Unit: vs.Unit},
+				Diastolic: QuantityValue{Value: float64(bp.Diastolic), Unit: vs.Unit},
+			}
+		} else {
+			m := measurements[vs.Key]
+			if m == nil || m.Value == 0 {
+				continue
+			}
+			unit := m.Unit
+			if unit == "" {
+				unit = vs.Unit
+			}
+			value = QuantityValue{Value: m.Value, Unit: unit}
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s observation: %w", vs.Key, err)
+		}
+		observations = append(observations, VitalSignObservation{Code: vs.Code, Value: data})
+	}
+	return observations, nil
+}
+
+// HydrateVitalSigns overlays the vital signs of a record's linked observations onto its SOAP
+// content, so the record shows the observations as the source of truth, and lists their IDs
+// in objective.linkedObservations. For each code the latest observation wins.
+func HydrateVitalSigns(soapContent json.RawMessage, observations []*ClinicalObservation) (json.RawMessage, error) {
+	if len(observations) == 0 {
+		return soapContent, nil
+	}
+
+	content := map[string]interface{}{}
+	if !isEmptySOAP(soapContent) {
+		if err := json.Unmarshal(soapContent, &content); err != nil {
+			return nil, fmt.Errorf("failed to parse SOAP content: %w", err)
+		}
+	}
+	objective, _ := content["objective"].(map[string]interface{})
+	if objective == nil {
+		objective = map[string]interface{}{}
+	}
+	vitals, _ := objective["vitalSigns"].(map[string]interface{})
+	if vitals == nil {
+		vitals = map[string]interface{}{}
+	}
+
+	latest := make(map[string]*ClinicalObservation)
+	var linked []string
+	for _, o := range observations {
+		linked = append(linked, o.ObservationID)
+		code := o.CodeValue()
+		if _, ok := VitalSignCodeFor(code); !ok {
+			continue
+		}
+		if current, ok := latest[code]; !ok || o.EffectiveDatetime.After(current.EffectiveDatetime) {
+			latest[code] = o
+		}
+	}
+
+	for code, o := range latest {
+		vs, _ := VitalSignCodeFor(code)
+		if vs.Key == "bloodPressure" {
+			systolic, okS := o.NumericValue("systolic")
+			diastolic, okD := o.NumericValue("diastolic")
+			if !okS || !okD {
+				continue
+			}
+			vitals[vs.Key] = map[string]interface{}{"systolic": systolic, "diastolic": diastolic, "unit": vs.Unit}
+			continue
+		}
+		value, ok := o.NumericValue("")
+		if !ok {
+			continue
+		}
+		var quantity QuantityValue
+		unit := vs.Unit
+		if json.Unmarshal(o.Value, &quantity) == nil && quantity.Unit != "" {
+			unit = quantity.Unit
+		}
+		vitals[vs.Key] = map[string]interface{}{"value": value, "unit": unit}
+	}
+
+	sort.Strings(linked)
+	if len(vitals) > 0 {
+		objective["vitalSigns"] = vitals
+	}
+	objective["linkedObservations"] = linked
+	content["objective"] = objective
+
+	return json.Marshal(content)
+}
+
+// TouchedVitalSigns reports which vital signs a SOAP update writes, by key under
+// objective.vitalSigns. Nulling the objective section or vitalSigns touches all of them.
+func TouchedVitalSigns(soapUpdate json.RawMessage) (map[string]bool, error) {
+	touched := make(map[string]bool)
+	if isEmptySOAP(soapUpdate) {
+		return touched, nil
+	}
+
+	var content map[string]json.RawMessage
+	if err := json.Unmarshal(soapUpdate, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse SOAP content: %w", err)
+	}
+	objectiveRaw, ok := content["objective"]
+	if !ok {
+		return touched, nil
+	}
+
+	var objective map[string]json.RawMessage
+	if err := json.Unmarshal(objectiveRaw, &objective); err != nil {
+		return nil, fmt.Errorf("failed to parse SOAP objective section: %w", err)
+	}
+
+	var vitals map[string]json.RawMessage
+	if objective != nil {
+		vitalsRaw, ok := objective["vitalSigns"]
+		if !ok {
+			return touched, nil
+		}
+		if err := json.Unmarshal(vitalsRaw, &vitals); err != nil {
+			return nil, fmt.Errorf("failed to parse SOAP vital signs: %w", err)
+		}
+	}
+	for _, vs := range VitalSignCodes {
+		if _, ok := vitals[vs.Key]; ok || vitals == nil {
+			touched[vs.Key] = true
+		}
+	}
+	return touched, nil
+}
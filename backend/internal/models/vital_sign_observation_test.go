@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVitalSignObservations(t *testing.T) {
+	observations, err := VitalSignObservations(json.RawMessage(`{
+		"objective": {"vitalSigns": {
+			"bloodPressure": {"systolic": 138, "diastolic": 86, "unit": "mmHg"},
+			"heartRate": {"value": 72, "unit": "bpm"},
+			"temperature": {"value": 36.9}
+		}}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, observations, 3)
+
+	assert.Equal(t, "85354-9", observations[0].Code.Code)
+	assert.JSONEq(t, `{"systolic": {"value": 138, "unit": "mmHg"}, "diastolic": {"value": 86, "unit": "mmHg"}}`, string(observations[0].Value))
+	assert.Equal(t, "8867-4", observations[1].Code.Code)
+	assert.JSONEq(t, `{"value": 72, "unit": "bpm"}`, string(observations[1].Value))
+	assert.Equal(t, "8310-5", observations[2].Code.Code)
+	assert.JSONEq(t, `{"value": 36.9, "unit": "Cel"}`, string(observations[2].Value))
+
+	t.Run("No vital signs", func(t *testing.T) {
+		observations, err := VitalSignObservations(json.RawMessage(`{"subjective": {"chiefComplaint": "倦怠感"}}`))
+		require.NoError(t, err)
+		assert.Empty(t, observations)
+	})
+}
+
+func TestHydrateVitalSigns(t *testing.T) {
+	visit := time.Date(2025, 4, 10, 1, 0, 0, 0, time.UTC)
+	observation := func(id, code, value string, at time.Time) *ClinicalObservation {
+		return &ClinicalObservation{
+			ObservationID:     id,
+			Category:          "vital_signs",
+			Code:              json.RawMessage(`{"system": "LOINC", "code": "` + code + `"}`),
+			Value:             json.RawMessage(value),
+			EffectiveDatetime: at,
+		}
+	}
+
+	hydrated, err := HydrateVitalSigns(json.RawMessage(`{
+		"subjective": {"chiefComplaint": "倦怠感"},
+		"objective": {"vitalSigns": {"heartRate": {"value": 72, "unit": "bpm"}, "spo2": {"value": 97}}}
+	}`), []*ClinicalObservation{
+		observation("obs-2", "8867-4", `{"value": 76, "unit": "bpm"}`, visit),
+		observation("obs-3", "8867-4", `{"value": 80, "unit": "bpm"}`, visit.Add(-time.Hour)),
+		observation("obs-1", "85354-9", `{"systolic": {"value": 128, "unit": "mmHg"}, "diastolic": {"value": 78, "unit": "mmHg"}}`, visit),
+	})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"subjective": {"chiefComplaint": "倦怠感"},
+		"objective": {
+			"vitalSigns": {
+				"bloodPressure": {"systolic": 128, "diastolic": 78, "unit": "mmHg"},
+				"heartRate": {"value": 76, "unit": "bpm"},
+				"spo2": {"value": 97}
+			},
+			"linkedObservations": ["obs-1", "obs-2", "obs-3"]
+		}
+	}`, string(hydrated))
+
+	t.Run("No linked observations", func(t *testing.T) {
+		soap := json.RawMessage(`{"objective": {"vitalSigns": {"spo2": {"value": 97}}}}`)
+		hydrated, err := HydrateVitalSigns(soap, nil)
+		require.NoError(t, err)
+		assert.Equal(t, soap, hydrated)
+	})
+}
+
+func TestTouchedVitalSigns(t *testing.T) {
+	touched, err := TouchedVitalSigns(json.RawMessage(`{"objective": {"vitalSigns": {"heartRate": {"value": 80}, "spo2": null}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"heartRate": true, "spo2": true}, touched)
+
+	touched, err = TouchedVitalSigns(json.RawMessage(`{"plan": {"nextVisit": {"purpose": "血圧再測定"}}}`))
+	require.NoError(t, err)
+	assert.Empty(t, touched)
+
+	touched, err = TouchedVitalSigns(json.RawMessage(`{"objective": {"vitalSigns": null}}`))
+	require.NoError(t, err)
+	assert.Len(t, touched, len(VitalSignCodes))
+
+	touched, err = TouchedVitalSigns(json.RawMessage(`{"objective": null}`))
+	require.NoError(t, err)
+	assert.Len(t, touched, len(VitalSignCodes))
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// VoiceSOAPJob is a background run that turns a visit recording into a draft SOAP record
+type VoiceSOAPJob struct {
+	JobID          string    `json:"job_id"`
+	PatientID      string    `json:"patient_id"`
+	VisitStartedAt time.Time `json:"visit_started_at"`
+	VisitType      string    `json:"visit_type"`
+	ScheduleID     *string   `json:"schedule_id,omitempty"`
+	Language       string    `json:"language"`
+	JobStatus      string    `json:"job_status"` // pending, processing, completed, failed
+	Attempts       int64     `json:"attempts"`
+
+	AudioContentType string `json:"audio_content_type"`
+	AudioSizeBytes   int64  `json:"audio_size_bytes"`
+	AudioBlobKey     string `json:"-"`
+	AudioWrappedKey  string `json:"-"`
+
+	Transcript       *string `json:"transcript,omitempty"`
+	TranscriberModel *string `json:"transcriber_model,omitempty"`
+	StructurerModel  *string `json:"structurer_model,omitempty"`
+	RecordID         *string `json:"record_id,omitempty"` // draft record, once completed
+	ErrorMessage     *string `json:"error_message,omitempty"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	CreatedBy   string     `json:"created_by"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// VoiceSOAPRequest is an uploaded visit recording to draft a record from
+type VoiceSOAPRequest struct {
+	VisitStartedAt time.Time
+	VisitType      string
+	ScheduleID     *string
+	Language       string // BCP 47 language of the recording, default "ja"
+	Audio          []byte
+}
+
+// MedicalRecordAIReviewRequest marks AI-suggested sections of a record as reviewed.
+// An empty list reviews every section still pending.
+type MedicalRecordAIReviewRequest struct {
+	Sections []string `json:"sections,omitempty"`
+}
+
+// VoiceAudioContentTypes lists the accepted recording formats, as detected from the content
+var VoiceAudioContentTypes = map[string]bool{
+	"audio/wave":      true,
+	"audio/mpeg":      true,
+	"audio/aiff":      true,
+	"application/ogg": true,
+	"video/webm":      true, // MediaRecorder audio/webm
+	"video/mp4":       true, // m4a
+}
+
+// HasAIAssistance reports whether a record's content was produced with AI assistance
+func HasAIAssistance(sourceType string, soapContent json.RawMessage) bool {
+	if sourceType == "voice_to_text" || sourceType == "ai_generated" {
+		return true
+	}
+	assistance, err := aiAssistanceOf(soapContent)
+	if err != nil || assistance == nil {
+		return false
+	}
+	return assistance.Enabled || assistance.AIGenerated || len(assistance.Sections) > 0
+}
+
+// PendingAIReview lists the sections whose AI-suggested text has not been reviewed yet
+func PendingAIReview(soapContent json.RawMessage) ([]string, error) {
+	assistance, err := aiAssistanceOf(soapContent)
+	if err != nil || assistance == nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, section := range assistance.Sections {
+		if section.ReviewedAt == nil {
+			pending = append(pending, section.Section)
+		}
+	}
+	return pending, nil
+}
+
+// AIReviewPendingError reports sections that must be reviewed before a record can be signed
+func AIReviewPendingError(sections []string) error {
+	v := &soapValidator{}
+	for _, section := range sections {
+		v.add(section, "contains AI-suggested text that has not been reviewed by a physician")
+	}
+	return v.err()
+}
+
+// ReviewAISections stamps the reviewer on the given pending AI-suggested sections, or on
+// all pending sections when none are given, and returns the updated content and the
+// sections it marked
+func ReviewAISections(soapContent json.RawMessage, sections []string, reviewedBy string, reviewedAt time.Time) (json.RawMessage, []string, error) {
+	content := map[string]json.RawMessage{}
+	if !isEmptySOAP(soapContent) {
+		if err := json.Unmarshal(soapContent, &content); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse SOAP content: %w", err)
+		}
+	}
+	metadata := map[string]json.RawMessage{}
+	if raw, ok := content["_metadata"]; ok && !isEmptySOAP(raw) {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse SOAP metadata: %w", err)
+		}
+	}
+	var assistance AIAssistance
+	if raw, ok := metadata["aiAssistance"]; ok && !isEmptySOAP(raw) {
+		if err := json.Unmarshal(raw, &assistance); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse AI assistance metadata: %w", err)
+		}
+	}
+
+	requested := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		requested[section] = true
+	}
+
+	var reviewed []string
+	for i := range assistance.Sections {
+		section := &assistance.Sections[i]
+		if len(requested) > 0 && !requested[section.Section] {
+			continue
+		}
+		delete(requested, section.Section)
+		if section.ReviewedAt != nil {
+			continue
+		}
+		reviewer, at := reviewedBy, reviewedAt
+		section.ReviewedBy, section.ReviewedAt = &reviewer, &at
+		reviewed = append(reviewed, section.Section)
+	}
+	for _, section := range sections {
+		if requested[section] {
+			return nil, nil, fmt.Errorf("section %s has no AI-suggested text to review", section)
+		}
+	}
+	if len(reviewed) == 0 {
+		return soapContent, nil, nil
+	}
+
+	var err error
+	if metadata["aiAssistance"], err = json.Marshal(assistance); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode AI assistance metadata: %w", err)
+	}
+	if content["_metadata"], err = json.Marshal(metadata); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode SOAP metadata: %w", err)
+	}
+	updated, err := json.Marshal(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode SOAP content: %w", err)
+	}
+	return updated, reviewed, nil
+}
+
+// ValidateAIAssistancePatch rejects updates that would rewrite or drop the AI provenance
+// of a record, which only the voice-to-SOAP pipeline and the review endpoint may change
+func ValidateAIAssistancePatch(existing, soapUpdate json.RawMessage) error {
+	if isEmptySOAP(soapUpdate) {
+		return nil
+	}
+	assistance, err := aiAssistanceOf(existing)
+	if err != nil || assistance == nil || len(assistance.Sections) == 0 {
+		return nil
+	}
+
+	var content map[string]json.RawMessage
+	if err := json.Unmarshal(soapUpdate, &content); err != nil {
+		return nil // reported by ValidateSOAPContent
+	}
+	raw, ok := content["_metadata"]
+	if !ok {
+		return nil
+	}
+
+	v := &soapValidator{}
+	var metadata map[string]json.RawMessage
+	if isEmptySOAP(raw) {
+		v.add("_metadata.aiAssistance", "is managed by the voice-to-SOAP pipeline and cannot be removed")
+	} else if err := json.Unmarshal(raw, &metadata); err == nil {
+		if _, ok := metadata["aiAssistance"]; ok {
+			v.add("_metadata.aiAssistance", "is managed by the voice-to-SOAP pipeline and cannot be edited")
+		}
+	}
+	return v.err()
+}
+
+// aiAssistanceOf extracts _metadata.aiAssistance from SOAP content, nil when absent
+func aiAssistanceOf(soapContent json.RawMessage) (*AIAssistance, error) {
+	if isEmptySOAP(soapContent) {
+		return nil, nil
+	}
+
+	var content struct {
+		Metadata *struct {
+			AIAssistance *AIAssistance `json:"aiAssistance"`
+		} `json:"_metadata"`
+	}
+	if err := json.Unmarshal(soapContent, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse SOAP content: %w", err)
+	}
+	if content.Metadata == nil {
+		return nil, nil
+	}
+	return content.Metadata.AIAssistance, nil
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const aiDraftSOAP = `{
+	"subjective": {"chiefComplaint": "腰の痛みが続いている"},
+	"plan": {"nextVisit": {"purpose": "次回訪問は2週間後"}},
+	"_metadata": {"sourceType": "voice_to_text", "aiAssistance": {
+		"aiGenerated": true,
+		"jobId": "job-1",
+		"sections": [
+			{"section": "subjective", "source": "voice_to_text", "model": "stub", "confidence": 0.9},
+			{"section": "plan", "source": "voice_to_text", "model": "stub", "confidence": 0.9}
+		]
+	}}
+}`
+
+func TestHasAIAssistance(t *testing.T) {
+	assert.True(t, HasAIAssistance("voice_to_text", nil))
+	assert.True(t, HasAIAssistance("manual", json.RawMessage(aiDraftSOAP)))
+	assert.True(t, HasAIAssistance("manual", json.RawMessage(`{"_metadata": {"aiAssistance": {"enabled": true}}}`)))
+	assert.False(t, HasAIAssistance("manual", json.RawMessage(`{"subjective": {"chiefComplaint": "発熱"}}`)))
+	assert.False(t, HasAIAssistance("manual", nil))
+}
+
+func TestReviewAISections(t *testing.T) {
+	at := time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC)
+
+	pending, err := PendingAIReview(json.RawMessage(aiDraftSOAP))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"subjective", "plan"}, pending)
+
+	t.Run("Named section", func(t *testing.T) {
+		updated, reviewed, err := ReviewAISections(json.RawMessage(aiDraftSOAP), []string{"plan"}, "doctor-1", at)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plan"}, reviewed)
+
+		pending, err := PendingAIReview(updated)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"subjective"}, pending)
+
+		var content SOAPContent
+		require.NoError(t, json.Unmarshal(updated, &content))
+		assert.Equal(t, "腰の痛みが続いている", content.Subjective.ChiefComplaint)
+		assert.Equal(t, "voice_to_text", content.Metadata.SourceType)
+		plan := content.Metadata.AIAssistance.Sections[1]
+		require.NotNil(t, plan.ReviewedBy)
+		assert.Equal(t, "doctor-1", *plan.ReviewedBy)
+		assert.True(t, plan.ReviewedAt.Equal(at))
+	})
+
+	t.Run("All pending sections", func(t *testing.T) {
+		updated, reviewed, err := ReviewAISections(json.RawMessage(aiDraftSOAP), nil, "doctor-1", at)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"subjective", "plan"}, reviewed)
+
+		pending, err := PendingAIReview(updated)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		// Reviewing again changes nothing
+		again, reviewed, err := ReviewAISections(updated, nil, "doctor-2", at.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, reviewed)
+		assert.JSONEq(t, string(updated), string(again))
+	})
+
+	t.Run("Section without suggestion", func(t *testing.T) {
+		_, _, err := ReviewAISections(json.RawMessage(aiDraftSOAP), []string{"assessment"}, "doctor-1", at)
+		assert.EqualError(t, err, "section assessment has no AI-suggested text to review")
+	})
+}
+
+func TestAIReviewPendingError(t *testing.T) {
+	err := AIReviewPendingError([]string{"subjective", "plan"})
+	var validationErr *SOAPValidationError
+	require.ErrorAs(t, err, &validationErr)
+	var paths []string
+	for _, field := range validationErr.Fields {
+		paths = append(paths, field.Path)
+	}
+	assert.ElementsMatch(t, []string{"soap_content.subjective", "soap_content.plan"}, paths)
+
+	assert.NoError(t, AIReviewPendingError(nil))
+}
+
+func TestValidateAIAssistancePatch(t *testing.T) {
+	existing := json.RawMessage(aiDraftSOAP)
+
+	assert.NoError(t, ValidateAIAssistancePatch(existing, json.RawMessage(`{"subjective": {"chiefComplaint": "腰痛"}}`)))
+	assert.NoError(t, ValidateAIAssistancePatch(existing, json.RawMessage(`{"_metadata": {"draftMode": false}}`)))
+	assert.NoError(t, ValidateAIAssistancePatch(existing, nil))
+
+	err := ValidateAIAssistancePatch(existing, json.RawMessage(`{"_metadata": {"aiAssistance": {"sections": []}}}`))
+	var validationErr *SOAPValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "soap_content._metadata.aiAssistance", validationErr.Fields[0].Path)
+
+	assert.Error(t, ValidateAIAssistancePatch(existing, json.RawMessage(`{"_metadata": null}`)))
+
+	// Records without AI-suggested sections keep accepting metadata edits
+	manual := json.RawMessage(`{"_metadata": {"aiAssistance": {"enabled": true}}}`)
+	assert.NoError(t, ValidateAIAssistancePatch(manual, json.RawMessage(`{"_metadata": {"aiAssistance": {"enabled": false}}}`)))
+}
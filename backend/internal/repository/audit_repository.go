@@ -20,6 +20,7 @@ const (
 	AuditActionUpdate AuditAction = "update"
 	AuditActionDelete AuditAction = "delete"
 	AuditActionDecrypt AuditAction = "decrypt"
+	AuditActionDownload AuditAction = "download"
 )
 
 // AuditLog represents a patient access audit log entry
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// CalendarFeedTokenRepository handles calendar feed token data operations
+type CalendarFeedTokenRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewCalendarFeedTokenRepository creates a new calendar feed token repository
+func NewCalendarFeedTokenRepository(spannerRepo *SpannerRepository) *CalendarFeedTokenRepository {
+	return &CalendarFeedTokenRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const calendarFeedTokenColumns = `token_id, feed_type, subject_id, label,
+			created_by, created_at, last_accessed_at,
+			revoked, revoked_at, revoked_by`
+
+// Create creates a new feed token
+func (r *CalendarFeedTokenRepository) Create(ctx context.Context, feedType, subjectID string, label *string, createdBy string) (*models.CalendarFeedToken, error) {
+	token := &models.CalendarFeedToken{
+		TokenID:   uuid.New().String(),
+		FeedType:  feedType,
+		SubjectID: subjectID,
+		Label:     nullString(label),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+
+	mutation := spanner.Insert("calendar_feed_tokens",
+		[]string{"token_id", "feed_type", "subject_id", "label", "created_by", "created_at", "revoked"},
+		[]interface{}{token.TokenID, token.FeedType, token.SubjectID, token.Label, token.CreatedBy, token.CreatedAt, false},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar feed token: %w", err)
+	}
+
+	return token, nil
+}
+
+// GetByID retrieves a feed token by ID, including revoked tokens
+func (r *CalendarFeedTokenRepository) GetByID(ctx context.Context, tokenID string) (*models.CalendarFeedToken, error) {
+	stmt := NewStatement(`SELECT `+calendarFeedTokenColumns+`
+		FROM calendar_feed_tokens
+		WHERE token_id = @token_id`,
+		map[string]interface{}{
+			"token_id": tokenID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("calendar feed token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query calendar feed token: %w", err)
+	}
+
+	return scanCalendarFeedToken(row)
+}
+
+// ListByCreator retrieves the active feed tokens a user has created
+func (r *CalendarFeedTokenRepository) ListByCreator(ctx context.Context, createdBy string) ([]*models.CalendarFeedToken, error) {
+	stmt := NewStatement(`SELECT `+calendarFeedTokenColumns+`
+		FROM calendar_feed_tokens
+		WHERE created_by = @created_by AND revoked = false
+		ORDER BY created_at DESC`,
+		map[string]interface{}{
+			"created_by": createdBy,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var tokens []*models.CalendarFeedToken
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate calendar feed tokens: %w", err)
+		}
+
+		token, err := scanCalendarFeedToken(row)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// Revoke permanently disables a feed token
+func (r *CalendarFeedTokenRepository) Revoke(ctx context.Context, tokenID, revokedBy string) error {
+	mutation := spanner.Update("calendar_feed_tokens",
+		[]string{"token_id", "revoked", "revoked_at", "revoked_by"},
+		[]interface{}{tokenID, true, time.Now(), revokedBy},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to revoke calendar feed token: %w", err)
+	}
+
+	return nil
+}
+
+// TouchAccessed records when a feed was last fetched
+func (r *CalendarFeedTokenRepository) TouchAccessed(ctx context.Context, tokenID string) error {
+	mutation := spanner.Update("calendar_feed_tokens",
+		[]string{"token_id", "last_accessed_at"},
+		[]interface{}{tokenID, time.Now()},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to update calendar feed token: %w", err)
+	}
+
+	return nil
+}
+
+func scanCalendarFeedToken(row *spanner.Row) (*models.CalendarFeedToken, error) {
+	var token models.CalendarFeedToken
+	err := row.Columns(
+		&token.TokenID, &token.FeedType, &token.SubjectID, &token.Label,
+		&token.CreatedBy, &token.CreatedAt, &token.LastAccessedAt,
+		&token.Revoked, &token.RevokedAt, &token.RevokedBy,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan calendar feed token: %w", err)
+	}
+
+	return &token, nil
+}
@@ -76,7 +76,10 @@ func (r *CarePlanRepository) Create(ctx context.Context, patientID string, req *
 		},
 	)
 
-	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	// Version 1 is snapshotted in the same commit
+	snapshot := carePlanVersionInsert(carePlan, req.CreatedBy, nil, now)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation, snapshot})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create care plan: %w", err)
 	}
@@ -199,171 +202,124 @@ func (r *CarePlanRepository) List(ctx context.Context, filter *models.CarePlanFi
 	return carePlans, nil
 }
 
-// Update updates a care plan
-func (r *CarePlanRepository) Update(ctx context.Context, patientID, planID string, req *models.CarePlanUpdateRequest) (*models.CarePlan, error) {
-	// First, get the existing care plan
-	existing, err := r.GetByID(ctx, patientID, planID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Build update map
-	updates := make(map[string]interface{})
-
-	if req.Status != nil {
-		updates["status"] = *req.Status
-		existing.Status = *req.Status
-	}
-
-	if req.Intent != nil {
-		updates["intent"] = *req.Intent
-		existing.Intent = *req.Intent
-	}
-
-	if req.Title != nil {
-		updates["title"] = *req.Title
-		existing.Title = *req.Title
-	}
-
-	if req.Description != nil {
-		updates["description"] = spanner.NullString{StringVal: *req.Description, Valid: true}
-		existing.Description = spanner.NullString{StringVal: *req.Description, Valid: true}
-	}
-
-	if req.PeriodStart != nil {
-		updates["period_start"] = *req.PeriodStart
-		existing.PeriodStart = *req.PeriodStart
-	}
-
-	if req.PeriodEnd != nil {
-		updates["period_end"] = spanner.NullTime{Time: *req.PeriodEnd, Valid: true}
-		existing.PeriodEnd = spanner.NullTime{Time: *req.PeriodEnd, Valid: true}
-	}
-
-	if len(req.Goals) > 0 {
-		updates["goals"] = spanner.NullString{StringVal: string(req.Goals), Valid: true}
-		existing.Goals = req.Goals
-	}
-
-	if len(req.Activities) > 0 {
-		updates["activities"] = spanner.NullString{StringVal: string(req.Activities), Valid: true}
-		existing.Activities = req.Activities
-	}
-
-	if len(updates) == 0 {
-		return existing, nil
-	}
-
-	updates["updated_at"] = time.Now()
-	existing.UpdatedAt = updates["updated_at"].(time.Time)
-
-	// Increment version for optimistic locking
-	updates["version"] = existing.Version + 1
-	existing.Version++
-
-	// Build column list and values
-	columns := []string{"patient_id", "plan_id"}
-	values := []interface{}{patientID, planID}
-
-	for col, val := range updates {
-		columns = append(columns, col)
-		values = append(values, val)
-	}
+// Update updates a care plan and records the new version's snapshot
+func (r *CarePlanRepository) Update(ctx context.Context, patientID, planID string, req *models.CarePlanUpdateRequest, updatedBy string) (*models.CarePlan, error) {
+	return r.update(ctx, patientID, planID, nil, req, updatedBy)
+}
 
-	mutation := spanner.Update("care_plans", columns, values)
+// UpdateWithVersion updates a care plan with optimistic locking and records the new version's snapshot
+func (r *CarePlanRepository) UpdateWithVersion(ctx context.Context, patientID, planID string, expectedVersion int64, req *models.CarePlanUpdateRequest, updatedBy string) (*models.CarePlan, error) {
+	return r.update(ctx, patientID, planID, &expectedVersion, req, updatedBy)
+}
 
-	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
-	if err != nil {
-		return nil, fmt.Errorf("failed to update care plan: %w", err)
-	}
+// update applies req to the plan and writes the row and its new snapshot in one transaction,
+// so a version number always has exactly one snapshot. Plans saved before snapshots were
+// kept get their current version snapshotted first, so the first diff has a baseline.
+func (r *CarePlanRepository) update(ctx context.Context, patientID, planID string, expectedVersion *int64, req *models.CarePlanUpdateRequest, updatedBy string) (*models.CarePlan, error) {
+	var updated *models.CarePlan
 
-	return existing, nil
-}
+	_, err := r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		existing, err := readCarePlan(ctx, txn, patientID, planID)
+		if err != nil {
+			return err
+		}
 
-// UpdateWithVersion updates a care plan with optimistic locking
-func (r *CarePlanRepository) UpdateWithVersion(ctx context.Context, patientID, planID string, expectedVersion int64, req *models.CarePlanUpdateRequest) (*models.CarePlan, error) {
-	// First, get the existing care plan
-	existing, err := r.GetByID(ctx, patientID, planID)
-	if err != nil {
-		return nil, err
-	}
+		// Check version for optimistic locking
+		if expectedVersion != nil && existing.Version != *expectedVersion {
+			return fmt.Errorf("CONFLICT: Care plan was modified by another user. Expected version %d but found %d", *expectedVersion, existing.Version)
+		}
 
-	// Check version for optimistic locking
-	if existing.Version != expectedVersion {
-		return nil, fmt.Errorf("CONFLICT: Care plan was modified by another user. Expected version %d but found %d", expectedVersion, existing.Version)
-	}
+		hasBaseline, err := carePlanVersionExists(ctx, txn, planID, existing.Version)
+		if err != nil {
+			return err
+		}
+		var mutations []*spanner.Mutation
+		if !hasBaseline {
+			mutations = append(mutations, carePlanVersionInsert(existing, existing.CreatedBy, nil, existing.UpdatedAt))
+		}
 
-	// Build update map
-	updates := make(map[string]interface{})
+		// Build update map
+		updates := make(map[string]interface{})
 
-	if req.Status != nil {
-		updates["status"] = *req.Status
-		existing.Status = *req.Status
-	}
+		if req.Status != nil {
+			updates["status"] = *req.Status
+			existing.Status = *req.Status
+		}
 
-	if req.Intent != nil {
-		updates["intent"] = *req.Intent
-		existing.Intent = *req.Intent
-	}
+		if req.Intent != nil {
+			updates["intent"] = *req.Intent
+			existing.Intent = *req.Intent
+		}
 
-	if req.Title != nil {
-		updates["title"] = *req.Title
-		existing.Title = *req.Title
-	}
+		if req.Title != nil {
+			updates["title"] = *req.Title
+			existing.Title = *req.Title
+		}
 
-	if req.Description != nil {
-		updates["description"] = spanner.NullString{StringVal: *req.Description, Valid: true}
-		existing.Description = spanner.NullString{StringVal: *req.Description, Valid: true}
-	}
+		if req.Description != nil {
+			updates["description"] = spanner.NullString{StringVal: *req.Description, Valid: true}
+			existing.Description = spanner.NullString{StringVal: *req.Description, Valid: true}
+		}
 
-	if req.PeriodStart != nil {
-		updates["period_start"] = *req.PeriodStart
-		existing.PeriodStart = *req.PeriodStart
-	}
+		if req.PeriodStart != nil {
+			updates["period_start"] = *req.PeriodStart
+			existing.PeriodStart = *req.PeriodStart
+		}
 
-	if req.PeriodEnd != nil {
-		updates["period_end"] = spanner.NullTime{Time: *req.PeriodEnd, Valid: true}
-		existing.PeriodEnd = spanner.NullTime{Time: *req.PeriodEnd, Valid: true}
-	}
+		if req.PeriodEnd != nil {
+			updates["period_end"] = spanner.NullTime{Time: *req.PeriodEnd, Valid: true}
+			existing.PeriodEnd = spanner.NullTime{Time: *req.PeriodEnd, Valid: true}
+		}
 
-	if len(req.Goals) > 0 {
-		updates["goals"] = spanner.NullString{StringVal: string(req.Goals), Valid: true}
-		existing.Goals = req.Goals
-	}
+		if len(req.Goals) > 0 {
+			updates["goals"] = spanner.NullString{StringVal: string(req.Goals), Valid: true}
+			existing.Goals = req.Goals
+		}
 
-	if len(req.Activities) > 0 {
-		updates["activities"] = spanner.NullString{StringVal: string(req.Activities), Valid: true}
-		existing.Activities = req.Activities
-	}
+		if len(req.Activities) > 0 {
+			updates["activities"] = spanner.NullString{StringVal: string(req.Activities), Valid: true}
+			existing.Activities = req.Activities
+		}
 
-	if len(updates) == 0 {
-		return existing, nil
-	}
+		if len(updates) == 0 {
+			updated = existing
+			return txn.BufferWrite(mutations)
+		}
 
-	updates["updated_at"] = time.Now()
-	existing.UpdatedAt = updates["updated_at"].(time.Time)
+		now := time.Now()
+		updates["updated_at"] = now
+		updates["updated_by"] = updatedBy
+		existing.UpdatedAt = now
 
-	// Increment version
-	updates["version"] = existing.Version + 1
-	existing.Version++
+		// Increment version for optimistic locking
+		updates["version"] = existing.Version + 1
+		existing.Version++
 
-	// Build column list and values
-	columns := []string{"patient_id", "plan_id"}
-	values := []interface{}{patientID, planID}
+		// Build column list and values
+		columns := []string{"patient_id", "plan_id"}
+		values := []interface{}{patientID, planID}
 
-	for col, val := range updates {
-		columns = append(columns, col)
-		values = append(values, val)
-	}
+		for col, val := range updates {
+			columns = append(columns, col)
+			values = append(values, val)
+		}
 
-	mutation := spanner.Update("care_plans", columns, values)
+		mutations = append(mutations,
+			spanner.Update("care_plans", columns, values),
+			carePlanVersionInsert(existing, updatedBy, req.ChangeReason, now),
+		)
 
-	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+		updated = existing
+		return txn.BufferWrite(mutations)
+	})
 	if err != nil {
+		if err.Error() == "care plan not found" || strings.HasPrefix(err.Error(), "CONFLICT") {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to update care plan: %w", err)
 	}
 
-	return existing, nil
+	return updated, nil
 }
 
 // Delete deletes a care plan
@@ -415,6 +371,222 @@ func (r *CarePlanRepository) GetActiveCarePlans(ctx context.Context, patientID s
 	return carePlans, nil
 }
 
+// ListAllActive retrieves the active care plans of every patient
+func (r *CarePlanRepository) ListAllActive(ctx context.Context) ([]*models.CarePlan, error) {
+	stmt := NewStatement(`SELECT
+			plan_id, patient_id, status, intent,
+			title, description, period_start, period_end,
+			goals::text, activities::text,
+			version, created_by, created_at, updated_at
+		FROM care_plans
+		WHERE status = 'active'`,
+		map[string]interface{}{})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var carePlans []*models.CarePlan
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate active care plans: %w", err)
+		}
+
+		carePlan, err := scanCarePlan(row)
+		if err != nil {
+			return nil, err
+		}
+		carePlans = append(carePlans, carePlan)
+	}
+
+	return carePlans, nil
+}
+
+// ListVersions retrieves the snapshots of a care plan, newest first.
+// Snapshots outlive the plan, so the history of a deleted plan can still be read.
+func (r *CarePlanRepository) ListVersions(ctx context.Context, patientID, planID string) ([]*models.CarePlanVersion, error) {
+	stmt := NewStatement(`SELECT
+			plan_id, version, patient_id, status, intent,
+			title, description, period_start, period_end,
+			goals::text, activities::text,
+			change_reason, recorded_by, recorded_at
+		FROM care_plan_versions
+		WHERE patient_id = @patient_id AND plan_id = @plan_id
+		ORDER BY version DESC`,
+		map[string]interface{}{
+			"patient_id": patientID,
+			"plan_id":    planID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var versions []*models.CarePlanVersion
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate care plan versions: %w", err)
+		}
+
+		version, err := scanCarePlanVersion(row)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+// GetVersion retrieves one snapshot of a care plan
+func (r *CarePlanRepository) GetVersion(ctx context.Context, patientID, planID string, version int64) (*models.CarePlanVersion, error) {
+	stmt := NewStatement(`SELECT
+			plan_id, version, patient_id, status, intent,
+			title, description, period_start, period_end,
+			goals::text, activities::text,
+			change_reason, recorded_by, recorded_at
+		FROM care_plan_versions
+		WHERE patient_id = @patient_id AND plan_id = @plan_id AND version = @version`,
+		map[string]interface{}{
+			"patient_id": patientID,
+			"plan_id":    planID,
+			"version":    version,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("care plan version not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query care plan version: %w", err)
+	}
+
+	return scanCarePlanVersion(row)
+}
+
+// readCarePlan reads a care plan inside a read-write transaction
+func readCarePlan(ctx context.Context, txn *spanner.ReadWriteTransaction, patientID, planID string) (*models.CarePlan, error) {
+	stmt := NewStatement(`SELECT
+			plan_id, patient_id, status, intent,
+			title, description, period_start, period_end,
+			goals::text, activities::text,
+			version, created_by, created_at, updated_at
+		FROM care_plans
+		WHERE patient_id = @patient_id AND plan_id = @plan_id`,
+		map[string]interface{}{
+			"patient_id": patientID,
+			"plan_id":    planID,
+		})
+
+	iter := txn.Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("care plan not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCarePlan(row)
+}
+
+// carePlanVersionExists reports whether a snapshot has been recorded for a plan version
+func carePlanVersionExists(ctx context.Context, txn *spanner.ReadWriteTransaction, planID string, version int64) (bool, error) {
+	stmt := NewStatement(`SELECT version FROM care_plan_versions WHERE plan_id = @plan_id AND version = @version`,
+		map[string]interface{}{
+			"plan_id": planID,
+			"version": version,
+		})
+
+	iter := txn.Query(ctx, stmt)
+	defer iter.Stop()
+
+	_, err := iter.Next()
+	if err == iterator.Done {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// carePlanVersionInsert builds the snapshot row of a care plan at its current version
+func carePlanVersionInsert(plan *models.CarePlan, recordedBy string, changeReason *string, recordedAt time.Time) *spanner.Mutation {
+	var goalsStr, activitiesStr, reason spanner.NullString
+	if len(plan.Goals) > 0 {
+		goalsStr = spanner.NullString{StringVal: string(plan.Goals), Valid: true}
+	}
+	if len(plan.Activities) > 0 {
+		activitiesStr = spanner.NullString{StringVal: string(plan.Activities), Valid: true}
+	}
+	if changeReason != nil {
+		reason = spanner.NullString{StringVal: *changeReason, Valid: true}
+	}
+
+	return spanner.Insert("care_plan_versions",
+		[]string{
+			"plan_id", "version", "patient_id", "status", "intent",
+			"title", "description", "period_start", "period_end",
+			"goals", "activities",
+			"change_reason", "recorded_by", "recorded_at",
+		},
+		[]interface{}{
+			plan.PlanID, plan.Version, plan.PatientID, plan.Status, plan.Intent,
+			plan.Title, plan.Description, plan.PeriodStart, plan.PeriodEnd,
+			goalsStr, activitiesStr,
+			reason, recordedBy, recordedAt,
+		},
+	)
+}
+
+// scanCarePlanVersion scans a Spanner row into a CarePlanVersion model
+func scanCarePlanVersion(row *spanner.Row) (*models.CarePlanVersion, error) {
+	var version models.CarePlanVersion
+	var goalsStr, activitiesStr, recordedBy spanner.NullString
+
+	err := row.Columns(
+		&version.PlanID,
+		&version.Version,
+		&version.PatientID,
+		&version.Status,
+		&version.Intent,
+		&version.Title,
+		&version.Description,
+		&version.PeriodStart,
+		&version.PeriodEnd,
+		&goalsStr,
+		&activitiesStr,
+		&version.ChangeReason,
+		&recordedBy,
+		&version.RecordedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan care plan version: %w", err)
+	}
+
+	if goalsStr.Valid {
+		version.Goals = json.RawMessage(goalsStr.StringVal)
+	}
+	if activitiesStr.Valid {
+		version.Activities = json.RawMessage(activitiesStr.StringVal)
+	}
+	version.RecordedBy = recordedBy.StringVal
+
+	return &version, nil
+}
+
 // scanCarePlan scans a Spanner row into a CarePlan model
 func scanCarePlan(row *spanner.Row) (*models.CarePlan, error) {
 	var carePlan models.CarePlan
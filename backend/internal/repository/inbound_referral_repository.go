@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// InboundReferralRepository handles received referral letters and their extracted items
+type InboundReferralRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewInboundReferralRepository creates a new inbound referral repository
+func NewInboundReferralRepository(spannerRepo *SpannerRepository) *InboundReferralRepository {
+	return &InboundReferralRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const inboundReferralColumns = `intake_id, patient_id, intake_status,
+			referring_institution, referring_physician, referral_date, purpose,
+			file_name, content_type, size_bytes, sha256, blob_key, wrapped_key,
+			items::text,
+			received_at, received_by, reviewed_at, reviewed_by, updated_at`
+
+// Create records a referral whose document has already been stored. The caller assigns
+// the intake ID, since it is part of the blob key and the encryption AAD.
+func (r *InboundReferralRepository) Create(ctx context.Context, intake *models.InboundReferral) error {
+	items, err := json.Marshal(intake.Items)
+	if err != nil {
+		return fmt.Errorf("failed to encode referral items: %w", err)
+	}
+
+	mutation := spanner.Insert("inbound_referrals",
+		[]string{
+			"intake_id", "patient_id", "intake_status",
+			"referring_institution", "referring_physician", "referral_date", "purpose",
+			"file_name", "content_type", "size_bytes", "sha256", "blob_key", "wrapped_key",
+			"items",
+			"received_at", "received_by", "reviewed_at", "reviewed_by", "updated_at",
+		},
+		[]interface{}{
+			intake.IntakeID, intake.PatientID, intake.Status,
+			intake.ReferringInstitution, nullString(intake.ReferringPhysician), nullDate(intake.ReferralDate), nullString(intake.Purpose),
+			intake.FileName, intake.ContentType, intake.SizeBytes, intake.SHA256, intake.BlobKey, intake.WrappedKey,
+			spanner.NullString{StringVal: string(items), Valid: true},
+			intake.ReceivedAt, intake.ReceivedBy, nullTime(intake.ReviewedAt), nullString(intake.ReviewedBy), intake.UpdatedAt,
+		},
+	)
+
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to create inbound referral: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a patient's inbound referral by ID
+func (r *InboundReferralRepository) GetByID(ctx context.Context, patientID, intakeID string) (*models.InboundReferral, error) {
+	stmt := NewStatement(`SELECT `+inboundReferralColumns+`
+		FROM inbound_referrals
+		WHERE intake_id = @intake_id AND patient_id = @patient_id`,
+		map[string]interface{}{
+			"intake_id":  intakeID,
+			"patient_id": patientID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("inbound referral not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inbound referral: %w", err)
+	}
+
+	return scanInboundReferral(row)
+}
+
+// List lists a patient's inbound referrals, most recently received first
+func (r *InboundReferralRepository) List(ctx context.Context, patientID string, filter *models.InboundReferralFilter) ([]*models.InboundReferral, error) {
+	conditions := []string{"patient_id = @patient_id"}
+	params := map[string]interface{}{
+		"patient_id": patientID,
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, "intake_status = @intake_status")
+		params["intake_status"] = *filter.Status
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	params["limit"] = int64(limit)
+
+	stmt := NewStatement(`SELECT `+inboundReferralColumns+`
+		FROM inbound_referrals
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY received_at DESC
+		LIMIT @limit`, params)
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	intakes := []*models.InboundReferral{}
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate inbound referrals: %w", err)
+		}
+
+		intake, err := scanInboundReferral(row)
+		if err != nil {
+			return nil, err
+		}
+		intakes = append(intakes, intake)
+	}
+
+	return intakes, nil
+}
+
+// AddItems appends extracted items to a referral and puts it back up for review
+func (r *InboundReferralRepository) AddItems(ctx context.Context, patientID, intakeID string, items []models.ReferralIntakeItem, addedBy string) (*models.InboundReferral, error) {
+	return r.updateItems(ctx, patientID, intakeID, addedBy, func(intake *models.InboundReferral, _ time.Time) error {
+		intake.Items = append(intake.Items, items...)
+		return nil
+	})
+}
+
+// ResolveItem records the review of a pending item. The referral is marked reviewed
+// when no pending items remain.
+func (r *InboundReferralRepository) ResolveItem(ctx context.Context, patientID, intakeID, itemID, status string, resourceID, note *string, reviewedBy string) (*models.InboundReferral, error) {
+	return r.updateItems(ctx, patientID, intakeID, reviewedBy, func(intake *models.InboundReferral, now time.Time) error {
+		return intake.ResolveItem(itemID, status, resourceID, note, reviewedBy, now)
+	})
+}
+
+// updateItems applies fn to a referral's items in a transaction and recomputes its review
+// status from them
+func (r *InboundReferralRepository) updateItems(ctx context.Context, patientID, intakeID, actor string, fn func(*models.InboundReferral, time.Time) error) (*models.InboundReferral, error) {
+	var updated *models.InboundReferral
+
+	_, err := r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		intake, err := readInboundReferral(ctx, txn, patientID, intakeID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := fn(intake, now); err != nil {
+			return err
+		}
+
+		intake.UpdatedAt = now
+		intake.UpdateReviewStatus(actor, now)
+
+		items, err := json.Marshal(intake.Items)
+		if err != nil {
+			return fmt.Errorf("failed to encode referral items: %w", err)
+		}
+		updated = intake
+		return txn.BufferWrite([]*spanner.Mutation{
+			spanner.Update("inbound_referrals",
+				[]string{"intake_id", "intake_status", "items", "reviewed_at", "reviewed_by", "updated_at"},
+				[]interface{}{
+					intakeID, intake.Status, spanner.NullString{StringVal: string(items), Valid: true},
+					nullTime(intake.ReviewedAt), nullString(intake.ReviewedBy), intake.UpdatedAt,
+				}),
+		})
+	})
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") || strings.HasPrefix(err.Error(), "CONFLICT") {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update inbound referral: %w", err)
+	}
+
+	return updated, nil
+}
+
+func readInboundReferral(ctx context.Context, txn *spanner.ReadWriteTransaction, patientID, intakeID string) (*models.InboundReferral, error) {
+	stmt := NewStatement(`SELECT `+inboundReferralColumns+`
+		FROM inbound_referrals
+		WHERE intake_id = @intake_id AND patient_id = @patient_id`,
+		map[string]interface{}{
+			"intake_id":  intakeID,
+			"patient_id": patientID,
+		})
+
+	iter := txn.Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("inbound referral not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInboundReferral(row)
+}
+
+// scanInboundReferral scans a Spanner row into an InboundReferral model
+func scanInboundReferral(row *spanner.Row) (*models.InboundReferral, error) {
+	var intake models.InboundReferral
+	var referringPhysician, purpose, items, reviewedBy spanner.NullString
+	var referralDate spanner.NullDate
+	var reviewedAt spanner.NullTime
+
+	err := row.Columns(
+		&intake.IntakeID,
+		&intake.PatientID,
+		&intake.Status,
+		&intake.ReferringInstitution,
+		&referringPhysician,
+		&referralDate,
+		&purpose,
+		&intake.FileName,
+		&intake.ContentType,
+		&intake.SizeBytes,
+		&intake.SHA256,
+		&intake.BlobKey,
+		&intake.WrappedKey,
+		&items,
+		&intake.ReceivedAt,
+		&intake.ReceivedBy,
+		&reviewedAt,
+		&reviewedBy,
+		&intake.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan inbound referral: %w", err)
+	}
+
+	intake.Items = []models.ReferralIntakeItem{}
+	if items.Valid {
+		if err := json.Unmarshal([]byte(items.StringVal), &intake.Items); err != nil {
+			return nil, fmt.Errorf("failed to parse referral items: %w", err)
+		}
+	}
+	intake.ReferringPhysician = stringPtrFromNull(referringPhysician)
+	intake.ReferralDate = datePtrFromNull(referralDate)
+	intake.Purpose = stringPtrFromNull(purpose)
+	intake.ReviewedBy = stringPtrFromNull(reviewedBy)
+	if reviewedAt.Valid {
+		t := reviewedAt.Time
+		intake.ReviewedAt = &t
+	}
+
+	return &intake, nil
+}
@@ -57,10 +57,12 @@ type CarePlanRepositoryInterface interface {
 	Create(ctx context.Context, patientID string, req *models.CarePlanCreateRequest) (*models.CarePlan, error)
 	GetByID(ctx context.Context, patientID, planID string) (*models.CarePlan, error)
 	List(ctx context.Context, filter *models.CarePlanFilter) ([]*models.CarePlan, error)
-	Update(ctx context.Context, patientID, planID string, req *models.CarePlanUpdateRequest) (*models.CarePlan, error)
-	UpdateWithVersion(ctx context.Context, patientID, planID string, expectedVersion int64, req *models.CarePlanUpdateRequest) (*models.CarePlan, error)
+	Update(ctx context.Context, patientID, planID string, req *models.CarePlanUpdateRequest, updatedBy string) (*models.CarePlan, error)
+	UpdateWithVersion(ctx context.Context, patientID, planID string, expectedVersion int64, req *models.CarePlanUpdateRequest, updatedBy string) (*models.CarePlan, error)
 	Delete(ctx context.Context, patientID, planID string) error
 	GetActiveCarePlans(ctx context.Context, patientID string) ([]*models.CarePlan, error)
+	ListVersions(ctx context.Context, patientID, planID string) ([]*models.CarePlanVersion, error)
+	GetVersion(ctx context.Context, patientID, planID string, version int64) (*models.CarePlanVersion, error)
 }
 
 // VisitScheduleRepositoryInterface defines the interface for visit schedule repository operations
@@ -91,6 +93,8 @@ type MedicalRecordRepositoryInterface interface {
 	List(ctx context.Context, filter *models.MedicalRecordFilter) ([]*models.MedicalRecord, error)
 	Update(ctx context.Context, patientID, recordID string, req *models.MedicalRecordUpdateRequest) (*models.MedicalRecord, error)
 	Delete(ctx context.Context, patientID, recordID string) error
+	ListRevisions(ctx context.Context, patientID, recordID string) ([]*models.MedicalRecordRevision, error)
+	GetRevision(ctx context.Context, patientID, recordID string, revision int64) (*models.MedicalRecordRevision, error)
 }
 
 // MedicalRecordTemplateRepositoryInterface defines the interface for medical record template repository operations
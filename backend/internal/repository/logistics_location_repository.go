@@ -0,0 +1,572 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// LogisticsLocationRepository handles logistics location data operations
+type LogisticsLocationRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewLogisticsLocationRepository creates a new logistics location repository
+func NewLogisticsLocationRepository(spannerRepo *SpannerRepository) *LogisticsLocationRepository {
+	return &LogisticsLocationRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const logisticsLocationColumns = `location_id, organization_id,
+			location_type, location_name, location_code,
+			postal_code, prefecture, city, address_line, building_name,
+			latitude, longitude, geolocation_verified, geolocation_verified_at,
+			google_place_id, google_maps_url,
+			phone, fax, email, website_url,
+			operating_hours::text,
+			staff_capacity, vehicle_capacity, parking_spots, has_medical_equipment_storage,
+			is_route_start_point, is_route_end_point, default_departure_time, default_return_time,
+			service_area::text, max_service_radius_km,
+			facilities::text, parent_location_id, location_status,
+			access_instructions, special_notes,
+			is_billing_location, billing_code,
+			created_at, created_by, updated_at, updated_by, deleted, deleted_at`
+
+// Create creates a new logistics location
+func (r *LogisticsLocationRepository) Create(ctx context.Context, req *models.LogisticsLocationCreateRequest, createdBy string) (*models.LogisticsLocation, error) {
+	locationID := uuid.New().String()
+	now := time.Now()
+
+	location := &models.LogisticsLocation{
+		LocationID:                 locationID,
+		OrganizationID:             req.OrganizationID,
+		LocationType:               req.LocationType,
+		LocationName:               req.LocationName,
+		LocationCode:               req.LocationCode,
+		PostalCode:                 req.PostalCode,
+		Prefecture:                 req.Prefecture,
+		City:                       req.City,
+		AddressLine:                req.AddressLine,
+		BuildingName:               req.BuildingName,
+		Latitude:                   req.Latitude,
+		Longitude:                  req.Longitude,
+		GeolocationVerified:        req.GeolocationVerified,
+		GooglePlaceID:              req.GooglePlaceID,
+		GoogleMapsURL:              req.GoogleMapsURL,
+		Phone:                      req.Phone,
+		Fax:                        req.Fax,
+		Email:                      req.Email,
+		WebsiteURL:                 req.WebsiteURL,
+		OperatingHours:             req.OperatingHours,
+		StaffCapacity:              req.StaffCapacity,
+		VehicleCapacity:            req.VehicleCapacity,
+		ParkingSpots:               req.ParkingSpots,
+		HasMedicalEquipmentStorage: req.HasMedicalEquipmentStorage,
+		IsRouteStartPoint:          req.IsRouteStartPoint,
+		IsRouteEndPoint:            req.IsRouteEndPoint,
+		DefaultDepartureTime:       req.DefaultDepartureTime,
+		DefaultReturnTime:          req.DefaultReturnTime,
+		ServiceArea:                req.ServiceArea,
+		MaxServiceRadiusKm:         req.MaxServiceRadiusKm,
+		Facilities:                 req.Facilities,
+		ParentLocationID:           req.ParentLocationID,
+		LocationStatus:             req.LocationStatus,
+		AccessInstructions:         req.AccessInstructions,
+		SpecialNotes:               req.SpecialNotes,
+		IsBillingLocation:          req.IsBillingLocation,
+		BillingCode:                req.BillingCode,
+		CreatedAt:                  now,
+		CreatedBy:                  &createdBy,
+		UpdatedAt:                  now,
+	}
+
+	var verifiedAt spanner.NullTime
+	if req.GeolocationVerified {
+		verifiedAt = spanner.NullTime{Time: now, Valid: true}
+		location.GeolocationVerifiedAt = &now
+	}
+
+	mutation := spanner.Insert("logistics_locations",
+		[]string{
+			"location_id", "organization_id",
+			"location_type", "location_name", "location_code",
+			"postal_code", "prefecture", "city", "address_line", "building_name",
+			"latitude", "longitude", "geolocation_verified", "geolocation_verified_at",
+			"google_place_id", "google_maps_url",
+			"phone", "fax", "email", "website_url",
+			"operating_hours",
+			"staff_capacity", "vehicle_capacity", "parking_spots", "has_medical_equipment_storage",
+			"is_route_start_point", "is_route_end_point", "default_departure_time", "default_return_time",
+			"service_area", "max_service_radius_km",
+			"facilities", "parent_location_id", "location_status",
+			"access_instructions", "special_notes",
+			"is_billing_location", "billing_code",
+			"created_at", "created_by", "updated_at", "deleted",
+		},
+		[]interface{}{
+			locationID, nullString(req.OrganizationID),
+			req.LocationType, req.LocationName, nullString(req.LocationCode),
+			nullString(req.PostalCode), req.Prefecture, req.City, req.AddressLine, nullString(req.BuildingName),
+			req.Latitude, req.Longitude, req.GeolocationVerified, verifiedAt,
+			nullString(req.GooglePlaceID), nullString(req.GoogleMapsURL),
+			nullString(req.Phone), nullString(req.Fax), nullString(req.Email), nullString(req.WebsiteURL),
+			nullJSON(req.OperatingHours),
+			nullInt64(req.StaffCapacity), nullInt64(req.VehicleCapacity), nullInt64(req.ParkingSpots), req.HasMedicalEquipmentStorage,
+			req.IsRouteStartPoint, req.IsRouteEndPoint, nullString(req.DefaultDepartureTime), nullString(req.DefaultReturnTime),
+			nullJSON(req.ServiceArea), nullFloat64(req.MaxServiceRadiusKm),
+			nullJSON(req.Facilities), nullString(req.ParentLocationID), req.LocationStatus,
+			nullString(req.AccessInstructions), nullString(req.SpecialNotes),
+			req.IsBillingLocation, nullString(req.BillingCode),
+			now, createdBy, now, false,
+		},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logistics location: %w", err)
+	}
+
+	return location, nil
+}
+
+// GetByID retrieves a logistics location by ID
+func (r *LogisticsLocationRepository) GetByID(ctx context.Context, locationID string) (*models.LogisticsLocation, error) {
+	stmt := NewStatement(`SELECT `+logisticsLocationColumns+`
+		FROM logistics_locations
+		WHERE location_id = @location_id AND deleted = false`,
+		map[string]interface{}{
+			"location_id": locationID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("logistics location not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logistics location: %w", err)
+	}
+
+	return scanLogisticsLocation(row)
+}
+
+// List retrieves logistics locations with filters
+func (r *LogisticsLocationRepository) List(ctx context.Context, filter *models.LogisticsLocationFilter) ([]*models.LogisticsLocation, error) {
+	conditions := []string{"deleted = false"}
+	params := make(map[string]interface{})
+
+	if filter.OrganizationID != nil {
+		conditions = append(conditions, "organization_id = @organization_id")
+		params["organization_id"] = *filter.OrganizationID
+	}
+
+	if filter.LocationType != nil {
+		conditions = append(conditions, "location_type = @location_type")
+		params["location_type"] = *filter.LocationType
+	}
+
+	if filter.LocationStatus != nil {
+		conditions = append(conditions, "location_status = @location_status")
+		params["location_status"] = *filter.LocationStatus
+	}
+
+	if filter.IsRouteStartPoint != nil {
+		conditions = append(conditions, "is_route_start_point = @is_route_start_point")
+		params["is_route_start_point"] = *filter.IsRouteStartPoint
+	}
+
+	if filter.IsRouteEndPoint != nil {
+		conditions = append(conditions, "is_route_end_point = @is_route_end_point")
+		params["is_route_end_point"] = *filter.IsRouteEndPoint
+	}
+
+	limit := 100
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	offset := 0
+	if filter.Offset > 0 {
+		offset = filter.Offset
+	}
+
+	params["limit"] = limit
+	params["offset"] = offset
+
+	stmt := NewStatement(fmt.Sprintf(`SELECT `+logisticsLocationColumns+`
+		FROM logistics_locations
+		WHERE %s
+		ORDER BY location_name ASC
+		LIMIT @limit OFFSET @offset`, strings.Join(conditions, " AND ")),
+		params)
+
+	return r.queryLogisticsLocations(ctx, stmt)
+}
+
+// ListActive retrieves all active locations, optionally restricted by type and organization.
+// Used for nearest-location and service-area lookups, which are evaluated in Go.
+func (r *LogisticsLocationRepository) ListActive(ctx context.Context, locationType, organizationID *string) ([]*models.LogisticsLocation, error) {
+	conditions := []string{"deleted = false", "location_status = 'active'"}
+	params := make(map[string]interface{})
+
+	if locationType != nil {
+		conditions = append(conditions, "location_type = @location_type")
+		params["location_type"] = *locationType
+	}
+
+	if organizationID != nil {
+		conditions = append(conditions, "organization_id = @organization_id")
+		params["organization_id"] = *organizationID
+	}
+
+	stmt := NewStatement(fmt.Sprintf(`SELECT `+logisticsLocationColumns+`
+		FROM logistics_locations
+		WHERE %s`, strings.Join(conditions, " AND ")),
+		params)
+
+	return r.queryLogisticsLocations(ctx, stmt)
+}
+
+// Update updates a logistics location
+func (r *LogisticsLocationRepository) Update(ctx context.Context, locationID string, req *models.LogisticsLocationUpdateRequest, updatedBy string) (*models.LogisticsLocation, error) {
+	existing, err := r.GetByID(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := make(map[string]interface{})
+
+	if req.LocationType != nil {
+		updates["location_type"] = *req.LocationType
+		existing.LocationType = *req.LocationType
+	}
+	if req.LocationName != nil {
+		updates["location_name"] = *req.LocationName
+		existing.LocationName = *req.LocationName
+	}
+	if req.LocationCode != nil {
+		updates["location_code"] = nullString(req.LocationCode)
+		existing.LocationCode = req.LocationCode
+	}
+	if req.PostalCode != nil {
+		updates["postal_code"] = nullString(req.PostalCode)
+		existing.PostalCode = req.PostalCode
+	}
+	if req.Prefecture != nil {
+		updates["prefecture"] = *req.Prefecture
+		existing.Prefecture = *req.Prefecture
+	}
+	if req.City != nil {
+		updates["city"] = *req.City
+		existing.City = *req.City
+	}
+	if req.AddressLine != nil {
+		updates["address_line"] = *req.AddressLine
+		existing.AddressLine = *req.AddressLine
+	}
+	if req.BuildingName != nil {
+		updates["building_name"] = nullString(req.BuildingName)
+		existing.BuildingName = req.BuildingName
+	}
+	if req.Latitude != nil {
+		updates["latitude"] = *req.Latitude
+		existing.Latitude = *req.Latitude
+	}
+	if req.Longitude != nil {
+		updates["longitude"] = *req.Longitude
+		existing.Longitude = *req.Longitude
+	}
+	if req.GeolocationVerified != nil {
+		updates["geolocation_verified"] = *req.GeolocationVerified
+		existing.GeolocationVerified = *req.GeolocationVerified
+		if *req.GeolocationVerified {
+			updates["geolocation_verified_at"] = spanner.NullTime{Time: now, Valid: true}
+			existing.GeolocationVerifiedAt = &now
+		}
+	}
+	if req.GooglePlaceID != nil {
+		updates["google_place_id"] = nullString(req.GooglePlaceID)
+		existing.GooglePlaceID = req.GooglePlaceID
+	}
+	if req.GoogleMapsURL != nil {
+		updates["google_maps_url"] = nullString(req.GoogleMapsURL)
+		existing.GoogleMapsURL = req.GoogleMapsURL
+	}
+	if req.Phone != nil {
+		updates["phone"] = nullString(req.Phone)
+		existing.Phone = req.Phone
+	}
+	if req.Fax != nil {
+		updates["fax"] = nullString(req.Fax)
+		existing.Fax = req.Fax
+	}
+	if req.Email != nil {
+		updates["email"] = nullString(req.Email)
+		existing.Email = req.Email
+	}
+	if req.WebsiteURL != nil {
+		updates["website_url"] = nullString(req.WebsiteURL)
+		existing.WebsiteURL = req.WebsiteURL
+	}
+	if len(req.OperatingHours) > 0 {
+		updates["operating_hours"] = nullJSON(req.OperatingHours)
+		existing.OperatingHours = req.OperatingHours
+	}
+	if req.StaffCapacity != nil {
+		updates["staff_capacity"] = nullInt64(req.StaffCapacity)
+		existing.StaffCapacity = req.StaffCapacity
+	}
+	if req.VehicleCapacity != nil {
+		updates["vehicle_capacity"] = nullInt64(req.VehicleCapacity)
+		existing.VehicleCapacity = req.VehicleCapacity
+	}
+	if req.ParkingSpots != nil {
+		updates["parking_spots"] = nullInt64(req.ParkingSpots)
+		existing.ParkingSpots = req.ParkingSpots
+	}
+	if req.HasMedicalEquipmentStorage != nil {
+		updates["has_medical_equipment_storage"] = *req.HasMedicalEquipmentStorage
+		existing.HasMedicalEquipmentStorage = *req.HasMedicalEquipmentStorage
+	}
+	if req.IsRouteStartPoint != nil {
+		updates["is_route_start_point"] = *req.IsRouteStartPoint
+		existing.IsRouteStartPoint = *req.IsRouteStartPoint
+	}
+	if req.IsRouteEndPoint != nil {
+		updates["is_route_end_point"] = *req.IsRouteEndPoint
+		existing.IsRouteEndPoint = *req.IsRouteEndPoint
+	}
+	if req.DefaultDepartureTime != nil {
+		updates["default_departure_time"] = nullString(req.DefaultDepartureTime)
+		existing.DefaultDepartureTime = req.DefaultDepartureTime
+	}
+	if req.DefaultReturnTime != nil {
+		updates["default_return_time"] = nullString(req.DefaultReturnTime)
+		existing.DefaultReturnTime = req.DefaultReturnTime
+	}
+	if len(req.ServiceArea) > 0 {
+		updates["service_area"] = nullJSON(req.ServiceArea)
+		existing.ServiceArea = req.ServiceArea
+	}
+	if req.MaxServiceRadiusKm != nil {
+		updates["max_service_radius_km"] = nullFloat64(req.MaxServiceRadiusKm)
+		existing.MaxServiceRadiusKm = req.MaxServiceRadiusKm
+	}
+	if len(req.Facilities) > 0 {
+		updates["facilities"] = nullJSON(req.Facilities)
+		existing.Facilities = req.Facilities
+	}
+	if req.ParentLocationID != nil {
+		updates["parent_location_id"] = nullString(req.ParentLocationID)
+		existing.ParentLocationID = req.ParentLocationID
+	}
+	if req.LocationStatus != nil {
+		updates["location_status"] = *req.LocationStatus
+		existing.LocationStatus = *req.LocationStatus
+	}
+	if req.AccessInstructions != nil {
+		updates["access_instructions"] = nullString(req.AccessInstructions)
+		existing.AccessInstructions = req.AccessInstructions
+	}
+	if req.SpecialNotes != nil {
+		updates["special_notes"] = nullString(req.SpecialNotes)
+		existing.SpecialNotes = req.SpecialNotes
+	}
+	if req.IsBillingLocation != nil {
+		updates["is_billing_location"] = *req.IsBillingLocation
+		existing.IsBillingLocation = *req.IsBillingLocation
+	}
+	if req.BillingCode != nil {
+		updates["billing_code"] = nullString(req.BillingCode)
+		existing.BillingCode = req.BillingCode
+	}
+
+	if len(updates) == 0 {
+		return existing, nil
+	}
+
+	updates["updated_at"] = now
+	updates["updated_by"] = spanner.NullString{StringVal: updatedBy, Valid: true}
+	existing.UpdatedAt = now
+	existing.UpdatedBy = &updatedBy
+
+	columns := []string{"location_id"}
+	values := []interface{}{locationID}
+
+	for col, val := range updates {
+		columns = append(columns, col)
+		values = append(values, val)
+	}
+
+	mutation := spanner.Update("logistics_locations", columns, values)
+
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update logistics location: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Delete soft-deletes a logistics location
+func (r *LogisticsLocationRepository) Delete(ctx context.Context, locationID, deletedBy string) error {
+	if _, err := r.GetByID(ctx, locationID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	mutation := spanner.Update("logistics_locations",
+		[]string{"location_id", "deleted", "deleted_at", "updated_at", "updated_by"},
+		[]interface{}{locationID, true, now, now, deletedBy},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to delete logistics location: %w", err)
+	}
+
+	return nil
+}
+
+// queryLogisticsLocations runs a location query and scans all rows
+func (r *LogisticsLocationRepository) queryLogisticsLocations(ctx context.Context, stmt spanner.Statement) ([]*models.LogisticsLocation, error) {
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var locations []*models.LogisticsLocation
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate logistics locations: %w", err)
+		}
+
+		location, err := scanLogisticsLocation(row)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, nil
+}
+
+// scanLogisticsLocation scans a Spanner row into a LogisticsLocation model
+func scanLogisticsLocation(row *spanner.Row) (*models.LogisticsLocation, error) {
+	var l models.LogisticsLocation
+	var organizationID, locationCode, postalCode, buildingName spanner.NullString
+	var googlePlaceID, googleMapsURL, phone, fax, email, websiteURL spanner.NullString
+	var operatingHours, serviceArea, facilities spanner.NullString
+	var departureTime, returnTime, parentLocationID spanner.NullString
+	var accessInstructions, specialNotes, billingCode, createdBy, updatedBy spanner.NullString
+	var staffCapacity, vehicleCapacity, parkingSpots spanner.NullInt64
+	var maxServiceRadius spanner.NullFloat64
+	var verifiedAt, deletedAt spanner.NullTime
+
+	err := row.Columns(
+		&l.LocationID,
+		&organizationID,
+		&l.LocationType,
+		&l.LocationName,
+		&locationCode,
+		&postalCode,
+		&l.Prefecture,
+		&l.City,
+		&l.AddressLine,
+		&buildingName,
+		&l.Latitude,
+		&l.Longitude,
+		&l.GeolocationVerified,
+		&verifiedAt,
+		&googlePlaceID,
+		&googleMapsURL,
+		&phone,
+		&fax,
+		&email,
+		&websiteURL,
+		&operatingHours,
+		&staffCapacity,
+		&vehicleCapacity,
+		&parkingSpots,
+		&l.HasMedicalEquipmentStorage,
+		&l.IsRouteStartPoint,
+		&l.IsRouteEndPoint,
+		&departureTime,
+		&returnTime,
+		&serviceArea,
+		&maxServiceRadius,
+		&facilities,
+		&parentLocationID,
+		&l.LocationStatus,
+		&accessInstructions,
+		&specialNotes,
+		&l.IsBillingLocation,
+		&billingCode,
+		&l.CreatedAt,
+		&createdBy,
+		&l.UpdatedAt,
+		&updatedBy,
+		&l.Deleted,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan logistics location: %w", err)
+	}
+
+	l.OrganizationID = stringPtrFromNull(organizationID)
+	l.LocationCode = stringPtrFromNull(locationCode)
+	l.PostalCode = stringPtrFromNull(postalCode)
+	l.BuildingName = stringPtrFromNull(buildingName)
+	l.GooglePlaceID = stringPtrFromNull(googlePlaceID)
+	l.GoogleMapsURL = stringPtrFromNull(googleMapsURL)
+	l.Phone = stringPtrFromNull(phone)
+	l.Fax = stringPtrFromNull(fax)
+	l.Email = stringPtrFromNull(email)
+	l.WebsiteURL = stringPtrFromNull(websiteURL)
+	l.DefaultDepartureTime = stringPtrFromNull(departureTime)
+	l.DefaultReturnTime = stringPtrFromNull(returnTime)
+	l.ParentLocationID = stringPtrFromNull(parentLocationID)
+	l.AccessInstructions = stringPtrFromNull(accessInstructions)
+	l.SpecialNotes = stringPtrFromNull(specialNotes)
+	l.BillingCode = stringPtrFromNull(billingCode)
+	l.CreatedBy = stringPtrFromNull(createdBy)
+	l.UpdatedBy = stringPtrFromNull(updatedBy)
+
+	l.StaffCapacity = int64PtrFromNull(staffCapacity)
+	l.VehicleCapacity = int64PtrFromNull(vehicleCapacity)
+	l.ParkingSpots = int64PtrFromNull(parkingSpots)
+
+	if operatingHours.Valid {
+		l.OperatingHours = json.RawMessage(operatingHours.StringVal)
+	}
+	if serviceArea.Valid {
+		l.ServiceArea = json.RawMessage(serviceArea.StringVal)
+	}
+	if facilities.Valid {
+		l.Facilities = json.RawMessage(facilities.StringVal)
+	}
+	if maxServiceRadius.Valid {
+		l.MaxServiceRadiusKm = &maxServiceRadius.Float64
+	}
+	if verifiedAt.Valid {
+		l.GeolocationVerifiedAt = &verifiedAt.Time
+	}
+	if deletedAt.Valid {
+		l.DeletedAt = &deletedAt.Time
+	}
+
+	return &l, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// MedicalRecordAttachmentRepository handles medical record attachment metadata
+type MedicalRecordAttachmentRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewMedicalRecordAttachmentRepository creates a new medical record attachment repository
+func NewMedicalRecordAttachmentRepository(spannerRepo *SpannerRepository) *MedicalRecordAttachmentRepository {
+	return &MedicalRecordAttachmentRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const medicalRecordAttachmentColumns = `attachment_id, record_id, patient_id,
+			category, file_name, content_type, size_bytes, sha256, width, height, caption,
+			blob_key, wrapped_key, thumbnail_blob_key, thumbnail_wrapped_key,
+			uploaded_at, uploaded_by, deleted, deleted_at, deleted_by`
+
+// Create records an attachment whose content has already been stored. The caller assigns
+// the attachment ID, since it is part of the blob key and the encryption AAD.
+func (r *MedicalRecordAttachmentRepository) Create(ctx context.Context, attachment *models.MedicalRecordAttachment) error {
+	mutation := spanner.Insert("medical_record_attachments",
+		[]string{
+			"attachment_id", "record_id", "patient_id",
+			"category", "file_name", "content_type", "size_bytes", "sha256", "width", "height", "caption",
+			"blob_key", "wrapped_key", "thumbnail_blob_key", "thumbnail_wrapped_key",
+			"uploaded_at", "uploaded_by", "deleted",
+		},
+		[]interface{}{
+			attachment.AttachmentID, attachment.RecordID, attachment.PatientID,
+			attachment.Category, attachment.FileName, attachment.ContentType, attachment.SizeBytes, attachment.SHA256,
+			nullInt64(attachment.Width), nullInt64(attachment.Height), nullString(attachment.Caption),
+			attachment.BlobKey, attachment.WrappedKey, nullString(attachment.ThumbnailBlobKey), nullString(attachment.ThumbnailWrappedKey),
+			attachment.UploadedAt, attachment.UploadedBy, false,
+		},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an attachment of a record
+func (r *MedicalRecordAttachmentRepository) GetByID(ctx context.Context, patientID, recordID, attachmentID string) (*models.MedicalRecordAttachment, error) {
+	stmt := NewStatement(`SELECT `+medicalRecordAttachmentColumns+`
+		FROM medical_record_attachments
+		WHERE patient_id = @patient_id AND record_id = @record_id AND attachment_id = @attachment_id AND deleted = false`,
+		map[string]interface{}{
+			"patient_id":    patientID,
+			"record_id":     recordID,
+			"attachment_id": attachmentID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachment: %w", err)
+	}
+
+	return scanMedicalRecordAttachment(row)
+}
+
+// ListByRecord lists the attachments of a record, oldest first
+func (r *MedicalRecordAttachmentRepository) ListByRecord(ctx context.Context, patientID, recordID string) ([]*models.MedicalRecordAttachment, error) {
+	stmt := NewStatement(`SELECT `+medicalRecordAttachmentColumns+`
+		FROM medical_record_attachments
+		WHERE patient_id = @patient_id AND record_id = @record_id AND deleted = false
+		ORDER BY uploaded_at ASC`,
+		map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	attachments := []*models.MedicalRecordAttachment{}
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate attachments: %w", err)
+		}
+
+		attachment, err := scanMedicalRecordAttachment(row)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// Delete soft-deletes an attachment. The metadata row is kept for the audit trail.
+func (r *MedicalRecordAttachmentRepository) Delete(ctx context.Context, attachmentID, deletedBy string) error {
+	now := time.Now()
+	mutation := spanner.Update("medical_record_attachments",
+		[]string{"attachment_id", "deleted", "deleted_at", "deleted_by"},
+		[]interface{}{attachmentID, true, now, deletedBy},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return nil
+}
+
+// scanMedicalRecordAttachment scans a Spanner row into a MedicalRecordAttachment model
+func scanMedicalRecordAttachment(row *spanner.Row) (*models.MedicalRecordAttachment, error) {
+	var attachment models.MedicalRecordAttachment
+	var width, height spanner.NullInt64
+	var caption, thumbnailBlobKey, thumbnailWrappedKey, deletedBy spanner.NullString
+	var deletedAt spanner.NullTime
+
+	err := row.Columns(
+		&attachment.AttachmentID,
+		&attachment.RecordID,
+		&attachment.PatientID,
+		&attachment.Category,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.SizeBytes,
+		&attachment.SHA256,
+		&width,
+		&height,
+		&caption,
+		&attachment.BlobKey,
+		&attachment.WrappedKey,
+		&thumbnailBlobKey,
+		&thumbnailWrappedKey,
+		&attachment.UploadedAt,
+		&attachment.UploadedBy,
+		&attachment.Deleted,
+		&deletedAt,
+		&deletedBy,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan attachment: %w", err)
+	}
+
+	attachment.Width = int64PtrFromNull(width)
+	attachment.Height = int64PtrFromNull(height)
+	attachment.Caption = stringPtrFromNull(caption)
+	attachment.ThumbnailBlobKey = stringPtrFromNull(thumbnailBlobKey)
+	attachment.ThumbnailWrappedKey = stringPtrFromNull(thumbnailWrappedKey)
+	attachment.HasThumbnail = attachment.ThumbnailBlobKey != nil
+	attachment.DeletedBy = stringPtrFromNull(deletedBy)
+	if deletedAt.Valid {
+		attachment.DeletedAt = &deletedAt.Time
+	}
+
+	return &attachment, nil
+}
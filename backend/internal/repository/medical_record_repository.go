@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -13,6 +14,9 @@ import (
 	"google.golang.org/api/iterator"
 )
 
+// ErrMedicalRecordLocked is returned when a signed record would be changed
+var ErrMedicalRecordLocked = errors.New("medical record is signed and locked; add a signed addendum to amend it")
+
 // MedicalRecordRepository handles medical record data operations
 type MedicalRecordRepository struct {
 	spannerRepo *SpannerRepository
@@ -31,72 +35,33 @@ func (r *MedicalRecordRepository) Create(ctx context.Context, patientID string,
 	now := time.Now()
 
 	record := &models.MedicalRecord{
-		RecordID:       recordID,
-		PatientID:      patientID,
-		VisitStartedAt: req.VisitStartedAt,
-		VisitEndedAt:   req.VisitEndedAt,
-		VisitType:      req.VisitType,
-		PerformedBy:    req.PerformedBy,
-		Status:         req.Status,
-		ScheduleID:     req.ScheduleID,
-		SOAPContent:    req.SOAPContent,
-		TemplateID:     req.TemplateID,
-		SourceRecordID: req.SourceRecordID,
-		SourceType:     req.SourceType,
-		AudioFileURL:   req.AudioFileURL,
-		Version:        1,
-		CreatedAt:      now,
-		CreatedBy:      createdBy,
-		UpdatedAt:      now,
-		Deleted:        false,
-	}
-
-	// Convert JSONB field to spanner.NullString
-	var soapContentStr spanner.NullString
-	if len(req.SOAPContent) > 0 {
-		soapContentStr = spanner.NullString{StringVal: string(req.SOAPContent), Valid: true}
+		RecordID:        recordID,
+		PatientID:       patientID,
+		VisitStartedAt:  req.VisitStartedAt,
+		VisitEndedAt:    req.VisitEndedAt,
+		VisitType:       req.VisitType,
+		PerformedBy:     req.PerformedBy,
+		Status:          req.Status,
+		ScheduleID:      req.ScheduleID,
+		SOAPContent:     req.SOAPContent,
+		TemplateID:      req.TemplateID,
+		SourceRecordID:  req.SourceRecordID,
+		SourceType:      req.SourceType,
+		AudioFileURL:    req.AudioFileURL,
+		HasAIAssistance: models.HasAIAssistance(req.SourceType, req.SOAPContent),
+		Version:         1,
+		CreatedAt:       now,
+		CreatedBy:       createdBy,
+		UpdatedAt:       now,
+		Deleted:         false,
 	}
 
-	// Convert optional fields to sql.Null types
-	var visitEndedAt spanner.NullTime
-	if req.VisitEndedAt != nil {
-		visitEndedAt = spanner.NullTime{Time: *req.VisitEndedAt, Valid: true}
-	}
-
-	var scheduleID, templateID, sourceRecordID, audioFileURL spanner.NullString
-	if req.ScheduleID != nil {
-		scheduleID = spanner.NullString{StringVal: *req.ScheduleID, Valid: true}
-	}
-	if req.TemplateID != nil {
-		templateID = spanner.NullString{StringVal: *req.TemplateID, Valid: true}
-	}
-	if req.SourceRecordID != nil {
-		sourceRecordID = spanner.NullString{StringVal: *req.SourceRecordID, Valid: true}
-	}
-	if req.AudioFileURL != nil {
-		audioFileURL = spanner.NullString{StringVal: *req.AudioFileURL, Valid: true}
-	}
+	mutation := medicalRecordInsert(record)
 
-	mutation := spanner.Insert("medical_records",
-		[]string{
-			"record_id", "patient_id",
-			"visit_started_at", "visit_ended_at", "visit_type", "performed_by", "status",
-			"schedule_id", "soap_content",
-			"template_id", "source_record_id", "source_type", "audio_file_url",
-			"version",
-			"created_at", "created_by", "updated_at", "deleted",
-		},
-		[]interface{}{
-			recordID, patientID,
-			req.VisitStartedAt, visitEndedAt, req.VisitType, req.PerformedBy, req.Status,
-			scheduleID, soapContentStr,
-			templateID, sourceRecordID, req.SourceType, audioFileURL,
-			1,
-			now, createdBy, now, false,
-		},
-	)
+	// Revision 1 is recorded in the same commit
+	revision := medicalRecordRevisionInsert(record, "create", nil, nil, createdBy, now)
 
-	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation, revision})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create medical record: %w", err)
 	}
@@ -114,7 +79,8 @@ func (r *MedicalRecordRepository) GetByID(ctx context.Context, patientID, record
 			COALESCE(soap_completed, false), COALESCE(has_ai_assistance, false),
 			version,
 			created_at, created_by, updated_at, COALESCE(updated_by, ''),
-			deleted, deleted_at, COALESCE(deleted_by, '')
+			deleted, deleted_at, COALESCE(deleted_by, ''),
+			signed_at, signed_by, signature_hash, amends_record_id, amendment_reason
 		FROM medical_records
 		WHERE patient_id = @patientID AND record_id = @recordID AND deleted = false`,
 		map[string]interface{}{
@@ -214,7 +180,8 @@ func (r *MedicalRecordRepository) List(ctx context.Context, filter *models.Medic
 			COALESCE(soap_completed, false), COALESCE(has_ai_assistance, false),
 			version,
 			created_at, created_by, updated_at, COALESCE(updated_by, ''),
-			deleted, deleted_at, COALESCE(deleted_by, '')
+			deleted, deleted_at, COALESCE(deleted_by, ''),
+			signed_at, signed_by, signature_hash, amends_record_id, amendment_reason
 		FROM medical_records
 		%s
 		ORDER BY visit_started_at DESC, created_at DESC
@@ -244,178 +211,370 @@ func (r *MedicalRecordRepository) List(ctx context.Context, filter *models.Medic
 	return records, nil
 }
 
-// Update updates a medical record
+// Update updates a medical record and appends the new revision
 func (r *MedicalRecordRepository) Update(ctx context.Context, patientID, recordID string, req *models.MedicalRecordUpdateRequest, updatedBy string) (*models.MedicalRecord, error) {
-	// First, get the existing record
-	existing, err := r.GetByID(ctx, patientID, recordID)
-	if err != nil {
-		return nil, err
-	}
+	return r.update(ctx, patientID, recordID, nil, req, updatedBy)
+}
 
-	// Build update map
-	updates := make(map[string]interface{})
+// UpdateWithVersion updates a medical record with optimistic locking and appends the new revision
+func (r *MedicalRecordRepository) UpdateWithVersion(ctx context.Context, patientID, recordID string, expectedVersion int64, req *models.MedicalRecordUpdateRequest, updatedBy string) (*models.MedicalRecord, error) {
+	return r.update(ctx, patientID, recordID, &expectedVersion, req, updatedBy)
+}
 
-	if req.VisitEndedAt != nil {
-		updates["visit_ended_at"] = spanner.NullTime{Time: *req.VisitEndedAt, Valid: true}
-		existing.VisitEndedAt = req.VisitEndedAt
-	}
+// update applies req to the record and writes the row and its revision in one transaction
+func (r *MedicalRecordRepository) update(ctx context.Context, patientID, recordID string, expectedVersion *int64, req *models.MedicalRecordUpdateRequest, updatedBy string) (*models.MedicalRecord, error) {
+	var updated *models.MedicalRecord
 
-	if req.VisitType != nil {
-		updates["visit_type"] = *req.VisitType
-		existing.VisitType = *req.VisitType
-	}
+	_, err := r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		existing, mutations, err := readMedicalRecordForRevision(ctx, txn, patientID, recordID, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if existing.SignedAt != nil {
+			return ErrMedicalRecordLocked
+		}
 
-	if req.Status != nil {
-		updates["status"] = *req.Status
-		existing.Status = *req.Status
-	}
+		// Build update map
+		updates := make(map[string]interface{})
 
-	if len(req.SOAPContent) > 0 {
-		updates["soap_content"] = spanner.NullString{StringVal: string(req.SOAPContent), Valid: true}
-		existing.SOAPContent = req.SOAPContent
-	}
+		if req.VisitEndedAt != nil {
+			updates["visit_ended_at"] = spanner.NullTime{Time: *req.VisitEndedAt, Valid: true}
+			existing.VisitEndedAt = req.VisitEndedAt
+		}
 
-	if req.ScheduleID != nil {
-		updates["schedule_id"] = spanner.NullString{StringVal: *req.ScheduleID, Valid: true}
-		existing.ScheduleID = req.ScheduleID
-	}
+		if req.VisitType != nil {
+			updates["visit_type"] = *req.VisitType
+			existing.VisitType = *req.VisitType
+		}
 
-	if req.TemplateID != nil {
-		updates["template_id"] = spanner.NullString{StringVal: *req.TemplateID, Valid: true}
-		existing.TemplateID = req.TemplateID
-	}
+		if req.Status != nil {
+			updates["status"] = *req.Status
+			existing.Status = *req.Status
+		}
 
-	if req.AudioFileURL != nil {
-		updates["audio_file_url"] = spanner.NullString{StringVal: *req.AudioFileURL, Valid: true}
-		existing.AudioFileURL = req.AudioFileURL
-	}
+		if len(req.SOAPContent) > 0 {
+			updates["soap_content"] = spanner.NullString{StringVal: string(req.SOAPContent), Valid: true}
+			existing.SOAPContent = req.SOAPContent
+			if !existing.HasAIAssistance && models.HasAIAssistance(existing.SourceType, req.SOAPContent) {
+				updates["has_ai_assistance"] = true
+				existing.HasAIAssistance = true
+			}
+		}
 
-	if len(updates) == 0 {
-		return existing, nil
-	}
+		if req.ScheduleID != nil {
+			updates["schedule_id"] = spanner.NullString{StringVal: *req.ScheduleID, Valid: true}
+			existing.ScheduleID = req.ScheduleID
+		}
 
-	// Update audit fields
-	now := time.Now()
-	updates["updated_at"] = now
-	updates["updated_by"] = spanner.NullString{StringVal: updatedBy, Valid: true}
-	existing.UpdatedAt = now
-	existing.UpdatedBy = &updatedBy
+		if req.TemplateID != nil {
+			updates["template_id"] = spanner.NullString{StringVal: *req.TemplateID, Valid: true}
+			existing.TemplateID = req.TemplateID
+		}
 
-	// Increment version
-	updates["version"] = existing.Version + 1
-	existing.Version++
+		if req.AudioFileURL != nil {
+			updates["audio_file_url"] = spanner.NullString{StringVal: *req.AudioFileURL, Valid: true}
+			existing.AudioFileURL = req.AudioFileURL
+		}
 
-	// Build column list and values
-	columns := []string{"record_id"}
-	values := []interface{}{recordID}
+		updated = existing
+		if len(updates) == 0 {
+			return txn.BufferWrite(mutations)
+		}
 
-	for col, val := range updates {
-		columns = append(columns, col)
-		values = append(values, val)
+		now := time.Now()
+		mutations = append(mutations, medicalRecordUpdate(existing, updates, updatedBy, now))
+		mutations = append(mutations, medicalRecordRevisionInsert(existing, "update", req.ChangeReason, nil, updatedBy, now))
+		return txn.BufferWrite(mutations)
+	})
+	if err != nil {
+		return nil, wrapMedicalRecordTxnError("failed to update medical record", err)
 	}
 
-	mutation := spanner.Update("medical_records", columns, values)
+	return updated, nil
+}
 
-	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+// RestoreRevision writes the content of an earlier revision back to the record as a new revision.
+// Visit details, SOAP content, schedule, template and audio are restored exactly, including
+// fields that were empty; status is left as it is.
+func (r *MedicalRecordRepository) RestoreRevision(ctx context.Context, patientID, recordID string, revision *models.MedicalRecordRevision, expectedVersion *int64, reason *string, restoredBy string) (*models.MedicalRecord, error) {
+	var restored *models.MedicalRecord
+
+	_, err := r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		existing, mutations, err := readMedicalRecordForRevision(ctx, txn, patientID, recordID, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if existing.SignedAt != nil {
+			return ErrMedicalRecordLocked
+		}
+
+		var visitEndedAt spanner.NullTime
+		if revision.VisitEndedAt != nil {
+			visitEndedAt = spanner.NullTime{Time: *revision.VisitEndedAt, Valid: true}
+		}
+		var soapContentStr spanner.NullString
+		if len(revision.SOAPContent) > 0 {
+			soapContentStr = spanner.NullString{StringVal: string(revision.SOAPContent), Valid: true}
+		}
+
+		updates := map[string]interface{}{
+			"visit_ended_at": visitEndedAt,
+			"visit_type":     revision.VisitType,
+			"soap_content":   soapContentStr,
+			"schedule_id":    nullString(revision.ScheduleID),
+			"template_id":    nullString(revision.TemplateID),
+			"audio_file_url": nullString(revision.AudioFileURL),
+		}
+		existing.VisitEndedAt = revision.VisitEndedAt
+		existing.VisitType = revision.VisitType
+		existing.SOAPContent = revision.SOAPContent
+		existing.ScheduleID = revision.ScheduleID
+		existing.TemplateID = revision.TemplateID
+		existing.AudioFileURL = revision.AudioFileURL
+
+		now := time.Now()
+		restoredFrom := revision.Revision
+		mutations = append(mutations, medicalRecordUpdate(existing, updates, restoredBy, now))
+		mutations = append(mutations, medicalRecordRevisionInsert(existing, "restore", reason, &restoredFrom, restoredBy, now))
+
+		restored = existing
+		return txn.BufferWrite(mutations)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update medical record: %w", err)
+		return nil, wrapMedicalRecordTxnError("failed to restore medical record revision", err)
 	}
 
-	return existing, nil
+	return restored, nil
 }
 
-// UpdateWithVersion updates a medical record with optimistic locking
-func (r *MedicalRecordRepository) UpdateWithVersion(ctx context.Context, patientID, recordID string, expectedVersion int64, req *models.MedicalRecordUpdateRequest, updatedBy string) (*models.MedicalRecord, error) {
-	// First, get the existing record
-	existing, err := r.GetByID(ctx, patientID, recordID)
-	if err != nil {
-		return nil, err
-	}
+// ListRevisions retrieves the revisions of a medical record, newest first.
+// Revisions are kept after the record is soft-deleted.
+func (r *MedicalRecordRepository) ListRevisions(ctx context.Context, patientID, recordID string) ([]*models.MedicalRecordRevision, error) {
+	stmt := NewStatement(`SELECT
+			record_id, revision, patient_id,
+			visit_ended_at, visit_type, status, schedule_id, soap_content::text,
+			template_id, audio_file_url,
+			change_type, change_reason, restored_from_revision, revised_by, revised_at
+		FROM medical_record_revisions
+		WHERE patient_id = @patientID AND record_id = @recordID
+		ORDER BY revision DESC`,
+		map[string]interface{}{
+			"patientID": patientID,
+			"recordID":  recordID,
+		})
 
-	// Check version for optimistic locking
-	if existing.Version != expectedVersion {
-		return nil, fmt.Errorf("CONFLICT: Record was modified by another user. Expected version %d but found %d", expectedVersion, existing.Version)
-	}
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
 
-	// Build update map
-	updates := make(map[string]interface{})
+	var revisions []*models.MedicalRecordRevision
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate medical record revisions: %w", err)
+		}
 
-	if req.VisitEndedAt != nil {
-		updates["visit_ended_at"] = spanner.NullTime{Time: *req.VisitEndedAt, Valid: true}
-		existing.VisitEndedAt = req.VisitEndedAt
+		revision, err := scanMedicalRecordRevision(row)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
 	}
 
-	if req.VisitType != nil {
-		updates["visit_type"] = *req.VisitType
-		existing.VisitType = *req.VisitType
-	}
+	return revisions, nil
+}
 
-	if req.Status != nil {
-		updates["status"] = *req.Status
-		existing.Status = *req.Status
-	}
+// GetRevision retrieves one revision of a medical record
+func (r *MedicalRecordRepository) GetRevision(ctx context.Context, patientID, recordID string, revision int64) (*models.MedicalRecordRevision, error) {
+	stmt := NewStatement(`SELECT
+			record_id, revision, patient_id,
+			visit_ended_at, visit_type, status, schedule_id, soap_content::text,
+			template_id, audio_file_url,
+			change_type, change_reason, restored_from_revision, revised_by, revised_at
+		FROM medical_record_revisions
+		WHERE patient_id = @patientID AND record_id = @recordID AND revision = @revision`,
+		map[string]interface{}{
+			"patientID": patientID,
+			"recordID":  recordID,
+			"revision":  revision,
+		})
 
-	if len(req.SOAPContent) > 0 {
-		updates["soap_content"] = spanner.NullString{StringVal: string(req.SOAPContent), Valid: true}
-		existing.SOAPContent = req.SOAPContent
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("medical record revision not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query medical record revision: %w", err)
 	}
 
-	if req.ScheduleID != nil {
-		updates["schedule_id"] = spanner.NullString{StringVal: *req.ScheduleID, Valid: true}
-		existing.ScheduleID = req.ScheduleID
+	return scanMedicalRecordRevision(row)
+}
+
+// Sign finalizes a record: sets it to completed, records the signer and the hash of its
+// canonical form, and locks it against further changes. Only the performing clinician
+// may sign, and only a record with SOAP content that is not cancelled.
+func (r *MedicalRecordRepository) Sign(ctx context.Context, patientID, recordID string, expectedVersion *int64, signedBy string) (*models.MedicalRecord, error) {
+	var signed *models.MedicalRecord
+
+	_, err := r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		record, mutations, err := readMedicalRecordForRevision(ctx, txn, patientID, recordID, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if record.SignedAt != nil {
+			return fmt.Errorf("medical record is already signed")
+		}
+		if record.PerformedBy != signedBy {
+			return fmt.Errorf("access denied: only the clinician who performed the visit can sign this record")
+		}
+		if record.Status == "cancelled" {
+			return fmt.Errorf("cannot sign a cancelled medical record")
+		}
+		if len(record.SOAPContent) == 0 {
+			return fmt.Errorf("cannot sign a medical record without SOAP content")
+		}
+
+		// Stored timestamps have microsecond precision; hash what will be read back
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		record.Status = "completed"
+		record.Version++
+		record.SignedAt = &now
+		record.SignedBy = &signedBy
+		record.UpdatedAt = now
+		record.UpdatedBy = &signedBy
+
+		hash, err := record.ComputeSignatureHash()
+		if err != nil {
+			return err
+		}
+		record.SignatureHash = &hash
+
+		mutations = append(mutations,
+			spanner.Update("medical_records",
+				[]string{"record_id", "status", "version", "signed_at", "signed_by", "signature_hash", "updated_at", "updated_by"},
+				[]interface{}{recordID, record.Status, record.Version, now, signedBy, hash, now, signedBy},
+			),
+			medicalRecordRevisionInsert(record, "sign", nil, nil, signedBy, now),
+		)
+
+		signed = record
+		return txn.BufferWrite(mutations)
+	})
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "access denied") || strings.HasPrefix(err.Error(), "cannot sign") || err.Error() == "medical record is already signed" {
+			return nil, err
+		}
+		return nil, wrapMedicalRecordTxnError("failed to sign medical record", err)
 	}
 
-	if req.TemplateID != nil {
-		updates["template_id"] = spanner.NullString{StringVal: *req.TemplateID, Valid: true}
-		existing.TemplateID = req.TemplateID
+	return signed, nil
+}
+
+// CreateAddendum adds a signed amendment to a signed record. The addendum is a record of its
+// own for the same visit, authored and signed by signedBy in the same commit.
+func (r *MedicalRecordRepository) CreateAddendum(ctx context.Context, original *models.MedicalRecord, req *models.MedicalRecordAddendumRequest, signedBy string) (*models.MedicalRecord, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	originalID := original.RecordID
+	reason := req.Reason
+
+	addendum := &models.MedicalRecord{
+		RecordID:        uuid.New().String(),
+		PatientID:       original.PatientID,
+		VisitStartedAt:  original.VisitStartedAt,
+		VisitEndedAt:    original.VisitEndedAt,
+		VisitType:       original.VisitType,
+		PerformedBy:     signedBy,
+		Status:          "completed",
+		ScheduleID:      original.ScheduleID,
+		SOAPContent:     req.SOAPContent,
+		SourceType:      "manual",
+		Version:         1,
+		CreatedAt:       now,
+		CreatedBy:       signedBy,
+		UpdatedAt:       now,
+		SignedAt:        &now,
+		SignedBy:        &signedBy,
+		AmendsRecordID:  &originalID,
+		AmendmentReason: &reason,
 	}
 
-	if req.AudioFileURL != nil {
-		updates["audio_file_url"] = spanner.NullString{StringVal: *req.AudioFileURL, Valid: true}
-		existing.AudioFileURL = req.AudioFileURL
+	hash, err := addendum.ComputeSignatureHash()
+	if err != nil {
+		return nil, err
 	}
+	addendum.SignatureHash = &hash
 
-	if len(updates) == 0 {
-		return existing, nil
+	mutations := []*spanner.Mutation{
+		medicalRecordInsert(addendum),
+		medicalRecordRevisionInsert(addendum, "create", &reason, nil, signedBy, now),
 	}
 
-	// Update audit fields
-	now := time.Now()
-	updates["updated_at"] = now
-	updates["updated_by"] = spanner.NullString{StringVal: updatedBy, Valid: true}
-	existing.UpdatedAt = now
-	existing.UpdatedBy = &updatedBy
+	_, err = r.spannerRepo.client.Apply(ctx, mutations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create addendum: %w", err)
+	}
 
-	// Increment version
-	updates["version"] = existing.Version + 1
-	existing.Version++
+	return addendum, nil
+}
 
-	// Build column list and values
-	columns := []string{"record_id"}
-	values := []interface{}{recordID}
+// ListAddenda retrieves the addenda of a record, oldest first
+func (r *MedicalRecordRepository) ListAddenda(ctx context.Context, patientID, recordID string) ([]*models.MedicalRecord, error) {
+	stmt := NewStatement(`SELECT
+			record_id, patient_id,
+			visit_started_at, visit_ended_at, visit_type, performed_by, status,
+			schedule_id, soap_content::text,
+			template_id, source_record_id, source_type, audio_file_url,
+			COALESCE(soap_completed, false), COALESCE(has_ai_assistance, false),
+			version,
+			created_at, created_by, updated_at, COALESCE(updated_by, ''),
+			deleted, deleted_at, COALESCE(deleted_by, ''),
+			signed_at, signed_by, signature_hash, amends_record_id, amendment_reason
+		FROM medical_records
+		WHERE patient_id = @patientID AND amends_record_id = @recordID AND deleted = false
+		ORDER BY created_at`,
+		map[string]interface{}{
+			"patientID": patientID,
+			"recordID":  recordID,
+		})
 
-	for col, val := range updates {
-		columns = append(columns, col)
-		values = append(values, val)
-	}
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
 
-	mutation := spanner.Update("medical_records", columns, values)
+	var records []*models.MedicalRecord
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate addenda: %w", err)
+		}
 
-	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
-	if err != nil {
-		return nil, fmt.Errorf("failed to update medical record: %w", err)
+		record, err := scanMedicalRecord(row)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
 	}
 
-	return existing, nil
+	return records, nil
 }
 
 // Delete soft-deletes a medical record
 func (r *MedicalRecordRepository) Delete(ctx context.Context, patientID, recordID string, deletedBy string) error {
 	// Check if record exists
-	_, err := r.GetByID(ctx, patientID, recordID)
+	existing, err := r.GetByID(ctx, patientID, recordID)
 	if err != nil {
 		return err
 	}
+	if existing.SignedAt != nil {
+		return ErrMedicalRecordLocked
+	}
 
 	now := time.Now()
 	mutation := spanner.Update("medical_records",
@@ -452,7 +611,8 @@ func (r *MedicalRecordRepository) GetLatestByPatient(ctx context.Context, patien
 			COALESCE(soap_completed, false), COALESCE(has_ai_assistance, false),
 			version,
 			created_at, created_by, updated_at, COALESCE(updated_by, ''),
-			deleted, deleted_at, COALESCE(deleted_by, '')
+			deleted, deleted_at, COALESCE(deleted_by, ''),
+			signed_at, signed_by, signature_hash, amends_record_id, amendment_reason
 		FROM medical_records
 		WHERE patient_id = @patientID AND deleted = false
 		ORDER BY visit_started_at DESC, created_at DESC
@@ -495,7 +655,8 @@ func (r *MedicalRecordRepository) GetByScheduleID(ctx context.Context, scheduleI
 			COALESCE(soap_completed, false), COALESCE(has_ai_assistance, false),
 			version,
 			created_at, created_by, updated_at, COALESCE(updated_by, ''),
-			deleted, deleted_at, COALESCE(deleted_by, '')
+			deleted, deleted_at, COALESCE(deleted_by, ''),
+			signed_at, signed_by, signature_hash, amends_record_id, amendment_reason
 		FROM medical_records
 		WHERE schedule_id = @scheduleID AND deleted = false
 		ORDER BY visit_started_at DESC`,
@@ -536,7 +697,8 @@ func (r *MedicalRecordRepository) GetDraftRecords(ctx context.Context, performed
 			COALESCE(soap_completed, false), COALESCE(has_ai_assistance, false),
 			version,
 			created_at, created_by, updated_at, COALESCE(updated_by, ''),
-			deleted, deleted_at, COALESCE(deleted_by, '')
+			deleted, deleted_at, COALESCE(deleted_by, ''),
+			signed_at, signed_by, signature_hash, amends_record_id, amendment_reason
 		FROM medical_records
 		WHERE performed_by = @performedBy AND status IN ('draft', 'in_progress') AND deleted = false
 		ORDER BY visit_started_at DESC`,
@@ -567,6 +729,268 @@ func (r *MedicalRecordRepository) GetDraftRecords(ctx context.Context, performed
 	return records, nil
 }
 
+// ListUpdatedSince returns records, deleted ones included, whose updated_at is at or after
+// since, in the order they were updated. Pass the updated_at and ID of the last record of
+// a page to read the next one; afterID is empty for the first page.
+func (r *MedicalRecordRepository) ListUpdatedSince(ctx context.Context, since time.Time, afterID string, limit int) ([]*models.MedicalRecord, error) {
+	stmt := NewStatement(`SELECT
+			record_id, patient_id,
+			visit_started_at, visit_ended_at, visit_type, performed_by, status,
+			schedule_id, soap_content::text,
+			template_id, source_record_id, source_type, audio_file_url,
+			COALESCE(soap_completed, false), COALESCE(has_ai_assistance, false),
+			version,
+			created_at, created_by, updated_at, COALESCE(updated_by, ''),
+			deleted, deleted_at, COALESCE(deleted_by, ''),
+			signed_at, signed_by, signature_hash, amends_record_id, amendment_reason
+		FROM medical_records
+		WHERE updated_at > @since OR (updated_at = @since AND record_id > @afterID)
+		ORDER BY updated_at, record_id
+		LIMIT @limit`,
+		map[string]interface{}{
+			"since":   since,
+			"afterID": afterID,
+			"limit":   int64(limit),
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var records []*models.MedicalRecord
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate updated medical records: %w", err)
+		}
+
+		record, err := scanMedicalRecord(row)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// readMedicalRecordForRevision reads a record inside a transaction and checks its version.
+// A record saved before revisions were kept gets its current state recorded as a revision
+// first (returned as a mutation), so the history has a baseline to diff and restore.
+func readMedicalRecordForRevision(ctx context.Context, txn *spanner.ReadWriteTransaction, patientID, recordID string, expectedVersion *int64) (*models.MedicalRecord, []*spanner.Mutation, error) {
+	stmt := NewStatement(`SELECT
+			record_id, patient_id,
+			visit_started_at, visit_ended_at, visit_type, performed_by, status,
+			schedule_id, soap_content::text,
+			template_id, source_record_id, source_type, audio_file_url,
+			COALESCE(soap_completed, false), COALESCE(has_ai_assistance, false),
+			version,
+			created_at, created_by, updated_at, COALESCE(updated_by, ''),
+			deleted, deleted_at, COALESCE(deleted_by, ''),
+			signed_at, signed_by, signature_hash, amends_record_id, amendment_reason
+		FROM medical_records
+		WHERE patient_id = @patientID AND record_id = @recordID AND deleted = false`,
+		map[string]interface{}{
+			"patientID": patientID,
+			"recordID":  recordID,
+		})
+
+	iter := txn.Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, nil, fmt.Errorf("medical record not found")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	record, err := scanMedicalRecord(row)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Check version for optimistic locking
+	if expectedVersion != nil && record.Version != *expectedVersion {
+		return nil, nil, fmt.Errorf("CONFLICT: Record was modified by another user. Expected version %d but found %d", *expectedVersion, record.Version)
+	}
+
+	revisionStmt := NewStatement(`SELECT revision FROM medical_record_revisions WHERE record_id = @recordID AND revision = @revision`,
+		map[string]interface{}{
+			"recordID": recordID,
+			"revision": record.Version,
+		})
+	revisionIter := txn.Query(ctx, revisionStmt)
+	defer revisionIter.Stop()
+
+	_, err = revisionIter.Next()
+	if err == nil {
+		return record, nil, nil
+	}
+	if err != iterator.Done {
+		return nil, nil, err
+	}
+
+	changeType, revisedBy := "create", record.CreatedBy
+	if record.Version > 1 {
+		changeType = "update"
+		if record.UpdatedBy != nil {
+			revisedBy = *record.UpdatedBy
+		}
+	}
+	baseline := medicalRecordRevisionInsert(record, changeType, nil, nil, revisedBy, record.UpdatedAt)
+	return record, []*spanner.Mutation{baseline}, nil
+}
+
+// medicalRecordInsert builds the insert of a new record row
+func medicalRecordInsert(record *models.MedicalRecord) *spanner.Mutation {
+	// Convert JSONB field to spanner.NullString
+	var soapContentStr spanner.NullString
+	if len(record.SOAPContent) > 0 {
+		soapContentStr = spanner.NullString{StringVal: string(record.SOAPContent), Valid: true}
+	}
+
+	var visitEndedAt, signedAt spanner.NullTime
+	if record.VisitEndedAt != nil {
+		visitEndedAt = spanner.NullTime{Time: *record.VisitEndedAt, Valid: true}
+	}
+	if record.SignedAt != nil {
+		signedAt = spanner.NullTime{Time: *record.SignedAt, Valid: true}
+	}
+
+	return spanner.Insert("medical_records",
+		[]string{
+			"record_id", "patient_id",
+			"visit_started_at", "visit_ended_at", "visit_type", "performed_by", "status",
+			"schedule_id", "soap_content",
+			"template_id", "source_record_id", "source_type", "audio_file_url", "has_ai_assistance",
+			"version",
+			"created_at", "created_by", "updated_at", "deleted",
+			"signed_at", "signed_by", "signature_hash", "amends_record_id", "amendment_reason",
+		},
+		[]interface{}{
+			record.RecordID, record.PatientID,
+			record.VisitStartedAt, visitEndedAt, record.VisitType, record.PerformedBy, record.Status,
+			nullString(record.ScheduleID), soapContentStr,
+			nullString(record.TemplateID), nullString(record.SourceRecordID), record.SourceType, nullString(record.AudioFileURL), record.HasAIAssistance,
+			record.Version,
+			record.CreatedAt, record.CreatedBy, record.UpdatedAt, false,
+			signedAt, nullString(record.SignedBy), nullString(record.SignatureHash), nullString(record.AmendsRecordID), nullString(record.AmendmentReason),
+		},
+	)
+}
+
+// medicalRecordUpdate builds the row update for a record change, stamping the audit
+// fields and bumping the version on the record
+func medicalRecordUpdate(record *models.MedicalRecord, updates map[string]interface{}, updatedBy string, now time.Time) *spanner.Mutation {
+	updates["updated_at"] = now
+	updates["updated_by"] = spanner.NullString{StringVal: updatedBy, Valid: true}
+	record.UpdatedAt = now
+	record.UpdatedBy = &updatedBy
+
+	// Increment version
+	updates["version"] = record.Version + 1
+	record.Version++
+
+	// Build column list and values
+	columns := []string{"record_id"}
+	values := []interface{}{record.RecordID}
+
+	for col, val := range updates {
+		columns = append(columns, col)
+		values = append(values, val)
+	}
+
+	return spanner.Update("medical_records", columns, values)
+}
+
+// medicalRecordRevisionInsert builds the revision row of a record at its current version
+func medicalRecordRevisionInsert(record *models.MedicalRecord, changeType string, reason *string, restoredFrom *int64, revisedBy string, revisedAt time.Time) *spanner.Mutation {
+	var visitEndedAt spanner.NullTime
+	if record.VisitEndedAt != nil {
+		visitEndedAt = spanner.NullTime{Time: *record.VisitEndedAt, Valid: true}
+	}
+	var soapContentStr spanner.NullString
+	if len(record.SOAPContent) > 0 {
+		soapContentStr = spanner.NullString{StringVal: string(record.SOAPContent), Valid: true}
+	}
+	var restoredFromRevision spanner.NullInt64
+	if restoredFrom != nil {
+		restoredFromRevision = spanner.NullInt64{Int64: *restoredFrom, Valid: true}
+	}
+
+	return spanner.Insert("medical_record_revisions",
+		[]string{
+			"record_id", "revision", "patient_id",
+			"visit_ended_at", "visit_type", "status", "schedule_id", "soap_content",
+			"template_id", "audio_file_url",
+			"change_type", "change_reason", "restored_from_revision", "revised_by", "revised_at",
+		},
+		[]interface{}{
+			record.RecordID, record.Version, record.PatientID,
+			visitEndedAt, record.VisitType, record.Status, nullString(record.ScheduleID), soapContentStr,
+			nullString(record.TemplateID), nullString(record.AudioFileURL),
+			changeType, nullString(reason), restoredFromRevision, revisedBy, revisedAt,
+		},
+	)
+}
+
+// wrapMedicalRecordTxnError passes not-found and version conflicts through unchanged so
+// callers can map them, and wraps anything else
+func wrapMedicalRecordTxnError(msg string, err error) error {
+	if err.Error() == "medical record not found" || strings.HasPrefix(err.Error(), "CONFLICT") || errors.Is(err, ErrMedicalRecordLocked) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// scanMedicalRecordRevision scans a Spanner row into a MedicalRecordRevision model
+func scanMedicalRecordRevision(row *spanner.Row) (*models.MedicalRecordRevision, error) {
+	var revision models.MedicalRecordRevision
+	var visitEndedAt spanner.NullTime
+	var scheduleID, soapContentStr, templateID, audioFileURL, changeReason spanner.NullString
+	var restoredFrom spanner.NullInt64
+
+	err := row.Columns(
+		&revision.RecordID,
+		&revision.Revision,
+		&revision.PatientID,
+		&visitEndedAt,
+		&revision.VisitType,
+		&revision.Status,
+		&scheduleID,
+		&soapContentStr,
+		&templateID,
+		&audioFileURL,
+		&revision.ChangeType,
+		&changeReason,
+		&restoredFrom,
+		&revision.RevisedBy,
+		&revision.RevisedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan medical record revision: %w", err)
+	}
+
+	if visitEndedAt.Valid {
+		revision.VisitEndedAt = &visitEndedAt.Time
+	}
+	if soapContentStr.Valid {
+		revision.SOAPContent = json.RawMessage(soapContentStr.StringVal)
+	}
+	revision.ScheduleID = stringPtrFromNull(scheduleID)
+	revision.TemplateID = stringPtrFromNull(templateID)
+	revision.AudioFileURL = stringPtrFromNull(audioFileURL)
+	revision.ChangeReason = stringPtrFromNull(changeReason)
+	if restoredFrom.Valid {
+		revision.RestoredFromRevision = &restoredFrom.Int64
+	}
+
+	return &revision, nil
+}
+
 // scanMedicalRecord scans a Spanner row into a MedicalRecord model
 func scanMedicalRecord(row *spanner.Row) (*models.MedicalRecord, error) {
 	var record models.MedicalRecord
@@ -576,7 +1000,8 @@ func scanMedicalRecord(row *spanner.Row) (*models.MedicalRecord, error) {
 	var scheduleID, soapContentStr spanner.NullString
 	var templateID, sourceRecordID, audioFileURL spanner.NullString
 	var updatedByStr, deletedByStr string
-	var deletedAt spanner.NullTime
+	var deletedAt, signedAt spanner.NullTime
+	var signedBy, signatureHash, amendsRecordID, amendmentReason spanner.NullString
 	var version int64
 
 	err := row.Columns(
@@ -603,6 +1028,11 @@ func scanMedicalRecord(row *spanner.Row) (*models.MedicalRecord, error) {
 		&record.Deleted,
 		&deletedAt,
 		&deletedByStr,
+		&signedAt,
+		&signedBy,
+		&signatureHash,
+		&amendsRecordID,
+		&amendmentReason,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan medical record: %w", err)
@@ -643,6 +1073,13 @@ func scanMedicalRecord(row *spanner.Row) (*models.MedicalRecord, error) {
 	if deletedByStr != "" {
 		record.DeletedBy = &deletedByStr
 	}
+	if signedAt.Valid {
+		record.SignedAt = &signedAt.Time
+	}
+	record.SignedBy = stringPtrFromNull(signedBy)
+	record.SignatureHash = stringPtrFromNull(signatureHash)
+	record.AmendsRecordID = stringPtrFromNull(amendsRecordID)
+	record.AmendmentReason = stringPtrFromNull(amendmentReason)
 
 	return &record, nil
 }
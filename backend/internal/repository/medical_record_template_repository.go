@@ -48,6 +48,7 @@ func (r *MedicalRecordTemplateRepository) Create(ctx context.Context, req *model
 		template.Specialty = req.Specialty
 	}
 	template.SOAPTemplate = req.SOAPTemplate
+	template.RequiredSections = req.RequiredSections
 
 	// Convert optional fields to spanner.Null types
 	var description, specialty spanner.NullString
@@ -60,21 +61,25 @@ func (r *MedicalRecordTemplateRepository) Create(ctx context.Context, req *model
 
 	// Convert JSONB to string
 	soapTemplateStr := spanner.NullString{StringVal: string(req.SOAPTemplate), Valid: true}
+	requiredSections, err := requiredSectionsValue(req.RequiredSections)
+	if err != nil {
+		return nil, err
+	}
 
 	mutation := spanner.Insert("medical_record_templates",
 		[]string{
 			"template_id", "template_name", "template_description", "specialty",
-			"soap_template", "is_system_template", "usage_count",
+			"soap_template", "required_sections", "is_system_template", "usage_count",
 			"created_at", "created_by", "updated_at", "deleted",
 		},
 		[]interface{}{
 			templateID, req.TemplateName, description, specialty,
-			soapTemplateStr, req.IsSystemTemplate, 0,
+			soapTemplateStr, requiredSections, req.IsSystemTemplate, 0,
 			now, createdBy, now, false,
 		},
 	)
 
-	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create template: %w", err)
 	}
@@ -86,7 +91,7 @@ func (r *MedicalRecordTemplateRepository) Create(ctx context.Context, req *model
 func (r *MedicalRecordTemplateRepository) GetByID(ctx context.Context, templateID string) (*models.MedicalRecordTemplate, error) {
 	stmt := NewStatement(`SELECT
 			template_id, template_name, template_description, specialty,
-			soap_template::text, is_system_template, usage_count,
+			soap_template::text, required_sections::text, is_system_template, usage_count,
 			created_at, created_by, updated_at, updated_by,
 			deleted, deleted_at
 		FROM medical_record_templates
@@ -146,7 +151,7 @@ func (r *MedicalRecordTemplateRepository) List(ctx context.Context, filter *mode
 
 	stmt := NewStatement(fmt.Sprintf(`SELECT
 			template_id, template_name, template_description, specialty,
-			soap_template::text, is_system_template, usage_count,
+			soap_template::text, required_sections::text, is_system_template, usage_count,
 			created_at, created_by, updated_at, updated_by,
 			deleted, deleted_at
 		FROM medical_record_templates
@@ -209,6 +214,18 @@ func (r *MedicalRecordTemplateRepository) Update(ctx context.Context, templateID
 		existing.SOAPTemplate = req.SOAPTemplate
 	}
 
+	if req.RequiredSections != nil {
+		requiredSections, err := requiredSectionsValue(req.RequiredSections)
+		if err != nil {
+			return nil, err
+		}
+		updates["required_sections"] = requiredSections
+		existing.RequiredSections = req.RequiredSections
+		if len(req.RequiredSections) == 0 {
+			existing.RequiredSections = nil
+		}
+	}
+
 	if len(updates) == 0 {
 		return existing, nil
 	}
@@ -311,7 +328,7 @@ func (r *MedicalRecordTemplateRepository) IncrementUsageCount(ctx context.Contex
 func (r *MedicalRecordTemplateRepository) GetSystemTemplates(ctx context.Context) ([]*models.MedicalRecordTemplate, error) {
 	stmt := NewStatement(`SELECT
 			template_id, template_name, template_description, specialty,
-			soap_template::text, is_system_template, usage_count,
+			soap_template::text, required_sections::text, is_system_template, usage_count,
 			created_at, created_by, updated_at, updated_by,
 			deleted, deleted_at
 		FROM medical_record_templates
@@ -346,7 +363,7 @@ func (r *MedicalRecordTemplateRepository) GetSystemTemplates(ctx context.Context
 func (r *MedicalRecordTemplateRepository) GetBySpecialty(ctx context.Context, specialty string) ([]*models.MedicalRecordTemplate, error) {
 	stmt := NewStatement(`SELECT
 			template_id, template_name, template_description, specialty,
-			soap_template::text, is_system_template, usage_count,
+			soap_template::text, required_sections::text, is_system_template, usage_count,
 			created_at, created_by, updated_at, updated_by,
 			deleted, deleted_at
 		FROM medical_record_templates
@@ -385,7 +402,7 @@ func scanTemplate(row *spanner.Row) (*models.MedicalRecordTemplate, error) {
 
 	// Nullable fields
 	var description, specialty spanner.NullString
-	var soapTemplateStr spanner.NullString
+	var soapTemplateStr, requiredSections spanner.NullString
 	var updatedBy spanner.NullString
 	var deletedAt spanner.NullTime
 
@@ -395,6 +412,7 @@ func scanTemplate(row *spanner.Row) (*models.MedicalRecordTemplate, error) {
 		&description,
 		&specialty,
 		&soapTemplateStr,
+		&requiredSections,
 		&template.IsSystemTemplate,
 		&template.UsageCount,
 		&template.CreatedAt,
@@ -418,6 +436,11 @@ func scanTemplate(row *spanner.Row) (*models.MedicalRecordTemplate, error) {
 	if soapTemplateStr.Valid {
 		template.SOAPTemplate = json.RawMessage(soapTemplateStr.StringVal)
 	}
+	if requiredSections.Valid {
+		if err := json.Unmarshal([]byte(requiredSections.StringVal), &template.RequiredSections); err != nil {
+			return nil, fmt.Errorf("failed to parse required sections: %w", err)
+		}
+	}
 	if updatedBy.Valid {
 		template.UpdatedBy = &updatedBy.StringVal
 	}
@@ -427,3 +450,16 @@ func scanTemplate(row *spanner.Row) (*models.MedicalRecordTemplate, error) {
 
 	return &template, nil
 }
+
+// requiredSectionsValue encodes a template's required sections as JSONB, storing NULL
+// when there are none
+func requiredSectionsValue(sections []string) (spanner.NullString, error) {
+	if len(sections) == 0 {
+		return spanner.NullString{}, nil
+	}
+	data, err := json.Marshal(sections)
+	if err != nil {
+		return spanner.NullString{}, fmt.Errorf("failed to encode required sections: %w", err)
+	}
+	return spanner.NullString{StringVal: string(data), Valid: true}, nil
+}
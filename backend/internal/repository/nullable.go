@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+)
+
+// nullString converts an optional string to spanner.NullString
+func nullString(s *string) spanner.NullString {
+	if s == nil {
+		return spanner.NullString{}
+	}
+	return spanner.NullString{StringVal: *s, Valid: true}
+}
+
+// nullDate converts an optional date to spanner.NullDate
+func nullDate(d *civil.Date) spanner.NullDate {
+	if d == nil {
+		return spanner.NullDate{}
+	}
+	return spanner.NullDate{Date: *d, Valid: true}
+}
+
+// nullTime converts an optional timestamp to spanner.NullTime
+func nullTime(t *time.Time) spanner.NullTime {
+	if t == nil {
+		return spanner.NullTime{}
+	}
+	return spanner.NullTime{Time: *t, Valid: true}
+}
+
+// nullFloat64 converts an optional float to spanner.NullFloat64
+func nullFloat64(f *float64) spanner.NullFloat64 {
+	if f == nil {
+		return spanner.NullFloat64{}
+	}
+	return spanner.NullFloat64{Float64: *f, Valid: true}
+}
+
+// nullInt64 converts an optional integer to spanner.NullInt64
+func nullInt64(i *int64) spanner.NullInt64 {
+	if i == nil {
+		return spanner.NullInt64{}
+	}
+	return spanner.NullInt64{Int64: *i, Valid: true}
+}
+
+// nullJSON converts a JSONB payload to the string form Spanner expects
+func nullJSON(raw json.RawMessage) spanner.NullString {
+	if len(raw) == 0 {
+		return spanner.NullString{}
+	}
+	return spanner.NullString{StringVal: string(raw), Valid: true}
+}
+
+// stringPtrFromNull converts spanner.NullString back to an optional string
+func stringPtrFromNull(s spanner.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	v := s.StringVal
+	return &v
+}
+
+// int64PtrFromNull converts spanner.NullInt64 back to an optional integer
+func int64PtrFromNull(i spanner.NullInt64) *int64 {
+	if !i.Valid {
+		return nil
+	}
+	v := i.Int64
+	return &v
+}
+
+// datePtrFromNull converts spanner.NullDate back to an optional date
+func datePtrFromNull(d spanner.NullDate) *civil.Date {
+	if !d.Valid {
+		return nil
+	}
+	v := d.Date
+	return &v
+}
+
+// float64PtrFromNull converts spanner.NullFloat64 back to an optional float
+func float64PtrFromNull(f spanner.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	v := f.Float64
+	return &v
+}
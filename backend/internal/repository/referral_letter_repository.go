@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// ReferralLetterRepository handles outbound referral letters
+type ReferralLetterRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewReferralLetterRepository creates a new referral letter repository
+func NewReferralLetterRepository(spannerRepo *SpannerRepository) *ReferralLetterRepository {
+	return &ReferralLetterRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const referralLetterColumns = `referral_id, patient_id, referral_status,
+			recipient_institution, recipient_department, recipient_physician, purpose, urgency, source_record_id,
+			summary::text, remarks,
+			sender_institution, sender_address, sender_phone,
+			pdf_sha256, pdf_size_bytes, pdf_blob_key, pdf_wrapped_key,
+			sent_at, sent_by, sent_method,
+			acknowledged_at, acknowledged_by, acknowledgement_note,
+			created_at, created_by, updated_at, updated_by`
+
+// referralLetterMutableColumns are written whenever a letter is saved
+var referralLetterMutableColumns = []string{
+	"referral_status",
+	"recipient_institution", "recipient_department", "recipient_physician", "purpose", "urgency",
+	"summary", "remarks",
+	"sender_institution", "sender_address", "sender_phone",
+	"pdf_sha256", "pdf_size_bytes", "pdf_blob_key", "pdf_wrapped_key",
+	"sent_at", "sent_by", "sent_method",
+	"acknowledged_at", "acknowledged_by", "acknowledgement_note",
+	"updated_at", "updated_by",
+}
+
+// Create inserts a draft referral letter
+func (r *ReferralLetterRepository) Create(ctx context.Context, letter *models.ReferralLetter) error {
+	values, err := referralLetterMutableValues(letter)
+	if err != nil {
+		return err
+	}
+	columns := append([]string{"referral_id", "patient_id", "source_record_id", "created_at", "created_by"}, referralLetterMutableColumns...)
+	values = append([]interface{}{
+		letter.ReferralID, letter.PatientID, nullString(letter.SourceRecordID), letter.CreatedAt, letter.CreatedBy,
+	}, values...)
+
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{spanner.Insert("referral_letters", columns, values)})
+	if err != nil {
+		return fmt.Errorf("failed to create referral letter: %w", err)
+	}
+	return nil
+}
+
+// Save writes a letter back if it still has the expected status, so concurrent edits
+// cannot change a letter that has meanwhile been sent or acknowledged
+func (r *ReferralLetterRepository) Save(ctx context.Context, letter *models.ReferralLetter, expectedStatus string) error {
+	values, err := referralLetterMutableValues(letter)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		status, err := readReferralLetterStatus(ctx, txn, letter.PatientID, letter.ReferralID)
+		if err != nil {
+			return err
+		}
+		if status != expectedStatus {
+			return fmt.Errorf("CONFLICT: referral letter is %s", status)
+		}
+		return txn.BufferWrite([]*spanner.Mutation{
+			spanner.Update("referral_letters",
+				append([]string{"referral_id"}, referralLetterMutableColumns...),
+				append([]interface{}{letter.ReferralID}, values...)),
+		})
+	})
+	if err != nil {
+		if err.Error() == "referral letter not found" || strings.HasPrefix(err.Error(), "CONFLICT") {
+			return err
+		}
+		return fmt.Errorf("failed to save referral letter: %w", err)
+	}
+	return nil
+}
+
+// DeleteDraft deletes a letter that has not been sent
+func (r *ReferralLetterRepository) DeleteDraft(ctx context.Context, patientID, referralID string) error {
+	_, err := r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		status, err := readReferralLetterStatus(ctx, txn, patientID, referralID)
+		if err != nil {
+			return err
+		}
+		if status != models.ReferralStatusDraft {
+			return fmt.Errorf("CONFLICT: referral letter is %s", status)
+		}
+		return txn.BufferWrite([]*spanner.Mutation{spanner.Delete("referral_letters", spanner.Key{referralID})})
+	})
+	if err != nil {
+		if err.Error() == "referral letter not found" || strings.HasPrefix(err.Error(), "CONFLICT") {
+			return err
+		}
+		return fmt.Errorf("failed to delete referral letter: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a patient's referral letter by ID
+func (r *ReferralLetterRepository) GetByID(ctx context.Context, patientID, referralID string) (*models.ReferralLetter, error) {
+	stmt := NewStatement(`SELECT `+referralLetterColumns+`
+		FROM referral_letters
+		WHERE referral_id = @referral_id AND patient_id = @patient_id`,
+		map[string]interface{}{
+			"referral_id": referralID,
+			"patient_id":  patientID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("referral letter not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referral letter: %w", err)
+	}
+
+	return scanReferralLetter(row)
+}
+
+// List lists a patient's referral letters, most recently created first
+func (r *ReferralLetterRepository) List(ctx context.Context, patientID string, filter *models.ReferralLetterFilter) ([]*models.ReferralLetter, error) {
+	conditions := []string{"patient_id = @patient_id"}
+	params := map[string]interface{}{
+		"patient_id": patientID,
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, "referral_status = @referral_status")
+		params["referral_status"] = *filter.Status
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	params["limit"] = int64(limit)
+
+	stmt := NewStatement(`SELECT `+referralLetterColumns+`
+		FROM referral_letters
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY created_at DESC
+		LIMIT @limit`, params)
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	letters := []*models.ReferralLetter{}
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate referral letters: %w", err)
+		}
+
+		letter, err := scanReferralLetter(row)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+
+	return letters, nil
+}
+
+func readReferralLetterStatus(ctx context.Context, txn *spanner.ReadWriteTransaction, patientID, referralID string) (string, error) {
+	stmt := NewStatement(`SELECT referral_status FROM referral_letters
+		WHERE referral_id = @referral_id AND patient_id = @patient_id`,
+		map[string]interface{}{
+			"referral_id": referralID,
+			"patient_id":  patientID,
+		})
+
+	iter := txn.Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return "", fmt.Errorf("referral letter not found")
+	}
+	if err != nil {
+		return "", err
+	}
+	var status string
+	if err := row.Columns(&status); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// referralLetterMutableValues returns the values of referralLetterMutableColumns
+func referralLetterMutableValues(letter *models.ReferralLetter) ([]interface{}, error) {
+	summary, err := json.Marshal(letter.Summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode referral summary: %w", err)
+	}
+	return []interface{}{
+		letter.Status,
+		letter.RecipientInstitution, nullString(letter.RecipientDepartment), nullString(letter.RecipientPhysician), letter.Purpose, letter.Urgency,
+		spanner.NullString{StringVal: string(summary), Valid: true}, nullString(letter.Remarks),
+		nullString(letter.SenderInstitution), nullString(letter.SenderAddress), nullString(letter.SenderPhone),
+		nullString(letter.PDFSHA256), nullInt64(letter.PDFSizeBytes), nullString(letter.PDFBlobKey), nullString(letter.PDFWrappedKey),
+		nullTime(letter.SentAt), nullString(letter.SentBy), nullString(letter.SentMethod),
+		nullTime(letter.AcknowledgedAt), nullString(letter.AcknowledgedBy), nullString(letter.AcknowledgementNote),
+		letter.UpdatedAt, letter.UpdatedBy,
+	}, nil
+}
+
+// scanReferralLetter scans a Spanner row into a ReferralLetter model
+func scanReferralLetter(row *spanner.Row) (*models.ReferralLetter, error) {
+	var letter models.ReferralLetter
+	var recipientDepartment, recipientPhysician, sourceRecordID, summary, remarks spanner.NullString
+	var senderInstitution, senderAddress, senderPhone spanner.NullString
+	var pdfSHA256, pdfBlobKey, pdfWrappedKey, sentBy, sentMethod spanner.NullString
+	var acknowledgedBy, acknowledgementNote spanner.NullString
+	var pdfSizeBytes spanner.NullInt64
+	var sentAt, acknowledgedAt spanner.NullTime
+
+	err := row.Columns(
+		&letter.ReferralID,
+		&letter.PatientID,
+		&letter.Status,
+		&letter.RecipientInstitution,
+		&recipientDepartment,
+		&recipientPhysician,
+		&letter.Purpose,
+		&letter.Urgency,
+		&sourceRecordID,
+		&summary,
+		&remarks,
+		&senderInstitution,
+		&senderAddress,
+		&senderPhone,
+		&pdfSHA256,
+		&pdfSizeBytes,
+		&pdfBlobKey,
+		&pdfWrappedKey,
+		&sentAt,
+		&sentBy,
+		&sentMethod,
+		&acknowledgedAt,
+		&acknowledgedBy,
+		&acknowledgementNote,
+		&letter.CreatedAt,
+		&letter.CreatedBy,
+		&letter.UpdatedAt,
+		&letter.UpdatedBy,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan referral letter: %w", err)
+	}
+
+	if summary.Valid {
+		if err := json.Unmarshal([]byte(summary.StringVal), &letter.Summary); err != nil {
+			return nil, fmt.Errorf("failed to parse referral summary: %w", err)
+		}
+	}
+	letter.RecipientDepartment = stringPtrFromNull(recipientDepartment)
+	letter.RecipientPhysician = stringPtrFromNull(recipientPhysician)
+	letter.SourceRecordID = stringPtrFromNull(sourceRecordID)
+	letter.Remarks = stringPtrFromNull(remarks)
+	letter.SenderInstitution = stringPtrFromNull(senderInstitution)
+	letter.SenderAddress = stringPtrFromNull(senderAddress)
+	letter.SenderPhone = stringPtrFromNull(senderPhone)
+	letter.PDFSHA256 = stringPtrFromNull(pdfSHA256)
+	letter.PDFSizeBytes = int64PtrFromNull(pdfSizeBytes)
+	letter.PDFBlobKey = stringPtrFromNull(pdfBlobKey)
+	letter.PDFWrappedKey = stringPtrFromNull(pdfWrappedKey)
+	letter.SentBy = stringPtrFromNull(sentBy)
+	letter.SentMethod = stringPtrFromNull(sentMethod)
+	letter.AcknowledgedBy = stringPtrFromNull(acknowledgedBy)
+	letter.AcknowledgementNote = stringPtrFromNull(acknowledgementNote)
+	if sentAt.Valid {
+		t := sentAt.Time
+		letter.SentAt = &t
+	}
+	if acknowledgedAt.Valid {
+		t := acknowledgedAt.Time
+		letter.AcknowledgedAt = &t
+	}
+
+	return &letter, nil
+}
@@ -0,0 +1,497 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// RouteOptimizationJobRepository handles route optimization job data operations
+type RouteOptimizationJobRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewRouteOptimizationJobRepository creates a new route optimization job repository
+func NewRouteOptimizationJobRepository(spannerRepo *SpannerRepository) *RouteOptimizationJobRepository {
+	return &RouteOptimizationJobRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const routeOptimizationJobColumns = `job_id, organization_id, job_type, job_status, optimizer_engine,
+			target_date, target_start_time, target_end_time,
+			staff_id, vehicle_id,
+			start_location_id, end_location_id, start_latitude, start_longitude, end_latitude, end_longitude,
+			optimization_params::text, included_schedule_ids::text, total_visits_count,
+			google_api_request_payload::text, google_api_request_time,
+			google_api_response_payload::text, google_api_response_time, google_api_computation_time_ms,
+			optimized_route::text, total_distance_meters, total_duration_seconds,
+			applied_to_schedules, applied_at, applied_by,
+			error_code, error_message, COALESCE(retry_count, 0),
+			started_at, completed_at,
+			created_at, created_by, updated_at, updated_by`
+
+// Create inserts a new job. JobID, CreatedAt and UpdatedAt are filled in.
+func (r *RouteOptimizationJobRepository) Create(ctx context.Context, job *models.RouteOptimizationJob) error {
+	mutation, err := routeOptimizationJobInsert(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to create route optimization job: %w", err)
+	}
+
+	return nil
+}
+
+// routeOptimizationJobInsert fills in the job's ID and timestamps and builds its insert mutation
+func routeOptimizationJobInsert(job *models.RouteOptimizationJob) (*spanner.Mutation, error) {
+	now := time.Now()
+	if job.JobID == "" {
+		job.JobID = uuid.New().String()
+	}
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	job.TotalVisitsCount = int64(len(job.IncludedScheduleIDs))
+
+	scheduleIDs, err := json.Marshal(job.IncludedScheduleIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal included_schedule_ids: %w", err)
+	}
+
+	return spanner.Insert("route_optimization_jobs",
+		[]string{
+			"job_id", "organization_id", "job_type", "job_status", "optimizer_engine",
+			"target_date", "target_start_time", "target_end_time",
+			"staff_id", "vehicle_id",
+			"start_location_id", "end_location_id", "start_latitude", "start_longitude", "end_latitude", "end_longitude",
+			"optimization_params", "included_schedule_ids", "total_visits_count",
+			"applied_to_schedules", "has_manual_overrides", "retry_count",
+			"created_at", "created_by", "updated_at", "deleted",
+		},
+		[]interface{}{
+			job.JobID, nullString(job.OrganizationID), job.JobType, job.JobStatus, job.OptimizerEngine,
+			job.TargetDate, nullTime(job.TargetStartTime), nullTime(job.TargetEndTime),
+			nullString(job.StaffID), nullString(job.VehicleID),
+			nullString(job.StartLocationID), nullString(job.EndLocationID),
+			nullFloat64(job.StartLatitude), nullFloat64(job.StartLongitude), nullFloat64(job.EndLatitude), nullFloat64(job.EndLongitude),
+			string(job.OptimizationParams), string(scheduleIDs), job.TotalVisitsCount,
+			false, false, int64(0),
+			now, nullString(job.CreatedBy), now, false,
+		},
+	), nil
+}
+
+// GetByID retrieves a route optimization job by ID
+func (r *RouteOptimizationJobRepository) GetByID(ctx context.Context, jobID string) (*models.RouteOptimizationJob, error) {
+	stmt := NewStatement(`SELECT `+routeOptimizationJobColumns+`
+		FROM route_optimization_jobs
+		WHERE job_id = @job_id AND deleted = false`,
+		map[string]interface{}{
+			"job_id": jobID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("route optimization job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query route optimization job: %w", err)
+	}
+
+	return scanRouteOptimizationJob(row)
+}
+
+// MarkProcessing records that the job has started running
+func (r *RouteOptimizationJobRepository) MarkProcessing(ctx context.Context, jobID string) error {
+	now := time.Now()
+	return r.transition(ctx, jobID, "started", jobStatusesOpen, map[string]interface{}{
+		"job_status": "processing",
+		"started_at": now,
+	})
+}
+
+// MarkCompleted stores the optimization result on the job
+func (r *RouteOptimizationJobRepository) MarkCompleted(ctx context.Context, jobID string, result *models.RouteOptimizationResult, requestTime, responseTime *time.Time) error {
+	updates, err := completedJobUpdates(result)
+	if err != nil {
+		return err
+	}
+
+	if len(result.RequestPayload) > 0 {
+		updates["google_api_request_payload"] = nullJSON(result.RequestPayload)
+		updates["google_api_request_time"] = nullTime(requestTime)
+	}
+	if len(result.ResponsePayload) > 0 {
+		updates["google_api_response_payload"] = nullJSON(result.ResponsePayload)
+		updates["google_api_response_time"] = nullTime(responseTime)
+		if requestTime != nil && responseTime != nil {
+			updates["google_api_computation_time_ms"] = responseTime.Sub(*requestTime).Milliseconds()
+		}
+	}
+
+	return r.transition(ctx, jobID, "completed", []string{"processing"}, updates)
+}
+
+// completedJobUpdates returns the column updates that store a result on a completed job
+func completedJobUpdates(result *models.RouteOptimizationResult) (map[string]interface{}, error) {
+	routeJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal optimized route: %w", err)
+	}
+
+	var distance, duration int64
+	for _, route := range result.Routes {
+		distance += route.TotalDistanceMeters
+		duration += route.TotalDurationSeconds
+	}
+
+	return map[string]interface{}{
+		"job_status":             "completed",
+		"optimized_route":        string(routeJSON),
+		"total_distance_meters":  distance,
+		"total_duration_seconds": duration,
+		"completed_at":           time.Now(),
+		"error_code":             spanner.NullString{},
+		"error_message":          spanner.NullString{},
+	}, nil
+}
+
+// GetLatestApplied retrieves the most recently applied completed route of a staff member on a date.
+// Returns nil when no route has been applied.
+func (r *RouteOptimizationJobRepository) GetLatestApplied(ctx context.Context, staffID string, targetDate civil.Date) (*models.RouteOptimizationJob, error) {
+	stmt := NewStatement(`SELECT `+routeOptimizationJobColumns+`
+		FROM route_optimization_jobs
+		WHERE staff_id = @staff_id
+		  AND target_date = @target_date
+		  AND job_status = 'completed'
+		  AND applied_to_schedules = true
+		  AND deleted = false
+		ORDER BY applied_at DESC
+		LIMIT 1`,
+		map[string]interface{}{
+			"staff_id":    staffID,
+			"target_date": targetDate,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query route optimization job: %w", err)
+	}
+
+	return scanRouteOptimizationJob(row)
+}
+
+// MarkFailed records a failed run
+func (r *RouteOptimizationJobRepository) MarkFailed(ctx context.Context, jobID, errorCode, errorMessage string) error {
+	return r.transition(ctx, jobID, "failed", jobStatusesOpen, map[string]interface{}{
+		"job_status":    "failed",
+		"error_code":    errorCode,
+		"error_message": errorMessage,
+		"completed_at":  time.Now(),
+	})
+}
+
+// MarkApplied records that the optimized route was written to visit_schedules
+func (r *RouteOptimizationJobRepository) MarkApplied(ctx context.Context, jobID, appliedBy string) error {
+	return r.transition(ctx, jobID, "applied", []string{"completed"}, map[string]interface{}{
+		"applied_to_schedules": true,
+		"applied_at":           time.Now(),
+		"applied_by":           appliedBy,
+		"error_code":           spanner.NullString{},
+		"error_message":        spanner.NullString{},
+	})
+}
+
+// MarkApplyFailed records why a completed route could not be written to visit_schedules.
+// The job stays completed; its result is still valid.
+func (r *RouteOptimizationJobRepository) MarkApplyFailed(ctx context.Context, jobID, errorMessage string) error {
+	return r.transition(ctx, jobID, "applied", []string{"completed"}, map[string]interface{}{
+		"error_code":    "apply_failed",
+		"error_message": errorMessage,
+	})
+}
+
+// MarkRetrying returns a job to pending after a failed attempt
+func (r *RouteOptimizationJobRepository) MarkRetrying(ctx context.Context, jobID string, retryCount int64, errorMessage string) error {
+	return r.transition(ctx, jobID, "retried", jobStatusesOpen, map[string]interface{}{
+		"job_status":    "pending",
+		"retry_count":   retryCount,
+		"error_code":    "retrying",
+		"error_message": errorMessage,
+	})
+}
+
+// MarkCancelled records that the job was cancelled before completing
+func (r *RouteOptimizationJobRepository) MarkCancelled(ctx context.Context, jobID, cancelledBy string) error {
+	return r.transition(ctx, jobID, "cancelled", jobStatusesOpen, map[string]interface{}{
+		"job_status":   "cancelled",
+		"completed_at": time.Now(),
+		"updated_by":   cancelledBy,
+	})
+}
+
+// ListStale retrieves pending or processing jobs not updated since the given time
+func (r *RouteOptimizationJobRepository) ListStale(ctx context.Context, updatedBefore time.Time) ([]*models.RouteOptimizationJob, error) {
+	stmt := NewStatement(`SELECT `+routeOptimizationJobColumns+`
+		FROM route_optimization_jobs
+		WHERE deleted = false
+		  AND job_status IN ('pending', 'processing')
+		  AND updated_at < @updated_before
+		ORDER BY created_at ASC`,
+		map[string]interface{}{
+			"updated_before": updatedBefore,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var jobs []*models.RouteOptimizationJob
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate route optimization jobs: %w", err)
+		}
+
+		job, err := scanRouteOptimizationJob(row)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// jobStatusesOpen are the statuses of a job that has not finished yet
+var jobStatusesOpen = []string{"pending", "processing"}
+
+// JobStatusError is returned when a job's stored status does not allow a status write,
+// e.g. cancelling a job that completed in the meantime
+type JobStatusError struct {
+	JobID  string
+	Action string
+	Status string
+}
+
+func (e *JobStatusError) Error() string {
+	return fmt.Sprintf("route optimization job cannot be %s in status %s", e.Action, e.Status)
+}
+
+// transition writes the given column updates to a job row in a read-write transaction,
+// after checking that the job's current status is one of from
+func (r *RouteOptimizationJobRepository) transition(ctx context.Context, jobID, action string, from []string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+
+	columns := []string{"job_id"}
+	values := []interface{}{jobID}
+	for col, val := range updates {
+		columns = append(columns, col)
+		values = append(values, val)
+	}
+
+	mutation := spanner.Update("route_optimization_jobs", columns, values)
+
+	_, err := r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		status, err := readJobStatus(ctx, txn, jobID)
+		if err != nil {
+			return err
+		}
+		allowed := false
+		for _, f := range from {
+			if status == f {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &JobStatusError{JobID: jobID, Action: action, Status: status}
+		}
+		return txn.BufferWrite([]*spanner.Mutation{mutation})
+	})
+	if err != nil {
+		var statusErr *JobStatusError
+		if errors.As(err, &statusErr) {
+			return statusErr
+		}
+		return fmt.Errorf("failed to update route optimization job: %w", err)
+	}
+
+	return nil
+}
+
+// readJobStatus reads a job's current status inside a transaction
+func readJobStatus(ctx context.Context, txn *spanner.ReadWriteTransaction, jobID string) (string, error) {
+	stmt := NewStatement(`SELECT job_status FROM route_optimization_jobs WHERE job_id = @job_id AND deleted = false`,
+		map[string]interface{}{
+			"job_id": jobID,
+		})
+
+	iter := txn.Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return "", fmt.Errorf("route optimization job not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read route optimization job status: %w", err)
+	}
+
+	var status string
+	if err := row.Columns(&status); err != nil {
+		return "", fmt.Errorf("failed to parse route optimization job status: %w", err)
+	}
+	return status, nil
+}
+
+// scanRouteOptimizationJob scans a Spanner row into a RouteOptimizationJob model
+func scanRouteOptimizationJob(row *spanner.Row) (*models.RouteOptimizationJob, error) {
+	var j models.RouteOptimizationJob
+	var organizationID, staffID, vehicleID, startLocationID, endLocationID spanner.NullString
+	var params, scheduleIDs, requestPayload, responsePayload, optimizedRoute spanner.NullString
+	var appliedBy, errorCode, errorMessage, createdBy, updatedBy spanner.NullString
+	var startLat, startLng, endLat, endLng spanner.NullFloat64
+	var totalVisits, computationMs, distance, duration spanner.NullInt64
+	var targetStart, targetEnd, requestTime, responseTime, appliedAt, startedAt, completedAt spanner.NullTime
+
+	err := row.Columns(
+		&j.JobID,
+		&organizationID,
+		&j.JobType,
+		&j.JobStatus,
+		&j.OptimizerEngine,
+		&j.TargetDate,
+		&targetStart,
+		&targetEnd,
+		&staffID,
+		&vehicleID,
+		&startLocationID,
+		&endLocationID,
+		&startLat,
+		&startLng,
+		&endLat,
+		&endLng,
+		&params,
+		&scheduleIDs,
+		&totalVisits,
+		&requestPayload,
+		&requestTime,
+		&responsePayload,
+		&responseTime,
+		&computationMs,
+		&optimizedRoute,
+		&distance,
+		&duration,
+		&j.AppliedToSchedules,
+		&appliedAt,
+		&appliedBy,
+		&errorCode,
+		&errorMessage,
+		&j.RetryCount,
+		&startedAt,
+		&completedAt,
+		&j.CreatedAt,
+		&createdBy,
+		&j.UpdatedAt,
+		&updatedBy,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan route optimization job: %w", err)
+	}
+
+	j.OrganizationID = stringPtrFromNull(organizationID)
+	j.StaffID = stringPtrFromNull(staffID)
+	j.VehicleID = stringPtrFromNull(vehicleID)
+	j.StartLocationID = stringPtrFromNull(startLocationID)
+	j.EndLocationID = stringPtrFromNull(endLocationID)
+	j.AppliedBy = stringPtrFromNull(appliedBy)
+	j.ErrorCode = stringPtrFromNull(errorCode)
+	j.ErrorMessage = stringPtrFromNull(errorMessage)
+	j.CreatedBy = stringPtrFromNull(createdBy)
+	j.UpdatedBy = stringPtrFromNull(updatedBy)
+
+	j.GoogleAPIComputationTimeMs = int64PtrFromNull(computationMs)
+	j.TotalDistanceMeters = int64PtrFromNull(distance)
+	j.TotalDurationSeconds = int64PtrFromNull(duration)
+	if totalVisits.Valid {
+		j.TotalVisitsCount = totalVisits.Int64
+	}
+
+	for _, f := range []struct {
+		src spanner.NullFloat64
+		dst **float64
+	}{
+		{startLat, &j.StartLatitude},
+		{startLng, &j.StartLongitude},
+		{endLat, &j.EndLatitude},
+		{endLng, &j.EndLongitude},
+	} {
+		if f.src.Valid {
+			v := f.src.Float64
+			*f.dst = &v
+		}
+	}
+
+	for _, t := range []struct {
+		src spanner.NullTime
+		dst **time.Time
+	}{
+		{targetStart, &j.TargetStartTime},
+		{targetEnd, &j.TargetEndTime},
+		{requestTime, &j.GoogleAPIRequestTime},
+		{responseTime, &j.GoogleAPIResponseTime},
+		{appliedAt, &j.AppliedAt},
+		{startedAt, &j.StartedAt},
+		{completedAt, &j.CompletedAt},
+	} {
+		if t.src.Valid {
+			v := t.src.Time
+			*t.dst = &v
+		}
+	}
+
+	if params.Valid {
+		j.OptimizationParams = json.RawMessage(params.StringVal)
+	}
+	if requestPayload.Valid {
+		j.GoogleAPIRequestPayload = json.RawMessage(requestPayload.StringVal)
+	}
+	if responsePayload.Valid {
+		j.GoogleAPIResponsePayload = json.RawMessage(responsePayload.StringVal)
+	}
+	if optimizedRoute.Valid {
+		j.OptimizedRoute = json.RawMessage(optimizedRoute.StringVal)
+	}
+	j.IncludedScheduleIDs = []string{}
+	if scheduleIDs.Valid && strings.TrimSpace(scheduleIDs.StringVal) != "" {
+		if err := json.Unmarshal([]byte(scheduleIDs.StringVal), &j.IncludedScheduleIDs); err != nil {
+			return nil, fmt.Errorf("failed to parse included_schedule_ids: %w", err)
+		}
+	}
+
+	return &j, nil
+}
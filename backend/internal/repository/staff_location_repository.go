@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+)
+
+// StaffLocationRepository handles staff location ping data operations
+type StaffLocationRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewStaffLocationRepository creates a new staff location repository
+func NewStaffLocationRepository(spannerRepo *SpannerRepository) *StaffLocationRepository {
+	return &StaffLocationRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+// InsertPings stores a batch of location pings. When current is set, it also becomes the
+// staff member's current position, and the vehicle's when the pings carry a vehicle ID,
+// in the same commit.
+func (r *StaffLocationRepository) InsertPings(ctx context.Context, pings []*models.StaffLocationPing, current *models.StaffLocationPing) error {
+	if len(pings) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	mutations := make([]*spanner.Mutation, 0, len(pings)+2)
+	for _, ping := range pings {
+		ping.PingID = uuid.New().String()
+		ping.ReceivedAt = now
+
+		mutations = append(mutations, spanner.Insert("staff_location_pings",
+			[]string{
+				"ping_id", "staff_id", "vehicle_id",
+				"latitude", "longitude", "accuracy_meters", "speed_kmh", "heading_degrees",
+				"recorded_at", "received_at",
+			},
+			[]interface{}{
+				ping.PingID, ping.StaffID, nullString(ping.VehicleID),
+				ping.Latitude, ping.Longitude, nullFloat64(ping.AccuracyMeters), nullFloat64(ping.SpeedKmh), nullFloat64(ping.HeadingDegrees),
+				ping.RecordedAt, now,
+			},
+		))
+	}
+
+	if current != nil {
+		mutations = append(mutations, spanner.Update("staff_members",
+			[]string{"staff_id", "current_latitude", "current_longitude", "last_location_update"},
+			[]interface{}{current.StaffID, current.Latitude, current.Longitude, current.RecordedAt},
+		))
+		if current.VehicleID != nil {
+			mutations = append(mutations, spanner.Update("vehicles",
+				[]string{"vehicle_id", "current_latitude", "current_longitude", "last_location_update"},
+				[]interface{}{*current.VehicleID, current.Latitude, current.Longitude, current.RecordedAt},
+			))
+		}
+	}
+
+	_, err := r.spannerRepo.client.Apply(ctx, mutations)
+	if err != nil {
+		return fmt.Errorf("failed to store location pings: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRecordedBefore removes pings recorded before the cutoff and returns how many were deleted
+func (r *StaffLocationRepository) DeleteRecordedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	stmt := NewStatement(`DELETE FROM staff_location_pings WHERE recorded_at < @cutoff`,
+		map[string]interface{}{
+			"cutoff": cutoff,
+		})
+
+	count, err := r.spannerRepo.client.PartitionedUpdate(ctx, stmt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired location pings: %w", err)
+	}
+
+	return count, nil
+}
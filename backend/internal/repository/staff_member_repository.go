@@ -0,0 +1,489 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// StaffMemberRepository handles staff directory data operations
+type StaffMemberRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewStaffMemberRepository creates a new staff member repository
+func NewStaffMemberRepository(spannerRepo *SpannerRepository) *StaffMemberRepository {
+	return &StaffMemberRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const staffMemberColumns = `staff_id, organization_id,
+			family_name, given_name, family_name_kana, given_name_kana,
+			email, phone, emergency_contact_phone,
+			role, qualification_type, license_number, license_issued_date, license_expiry_date,
+			specialties::text, certifications::text, work_schedule::text, availability_status,
+			base_location_id, current_latitude, current_longitude, last_location_update, assigned_vehicle_id,
+			can_prescribe, can_view_all_patients, access_level,
+			account_status, onboarding_completed, last_login_at,
+			bio, internal_notes,
+			created_at, created_by, updated_at, updated_by, deleted, deleted_at`
+
+// Create creates a new staff member
+func (r *StaffMemberRepository) Create(ctx context.Context, req *models.StaffMemberCreateRequest, createdBy string) (*models.StaffMember, error) {
+	staffID := uuid.New().String()
+	if req.StaffID != nil && *req.StaffID != "" {
+		staffID = *req.StaffID
+	}
+	now := time.Now()
+
+	staff := &models.StaffMember{
+		StaffID:               staffID,
+		OrganizationID:        req.OrganizationID,
+		FamilyName:            req.FamilyName,
+		GivenName:             req.GivenName,
+		FamilyNameKana:        req.FamilyNameKana,
+		GivenNameKana:         req.GivenNameKana,
+		Email:                 req.Email,
+		Phone:                 req.Phone,
+		EmergencyContactPhone: req.EmergencyContactPhone,
+		Role:                  req.Role,
+		QualificationType:     req.QualificationType,
+		LicenseNumber:         req.LicenseNumber,
+		LicenseIssuedDate:     req.LicenseIssuedDate,
+		LicenseExpiryDate:     req.LicenseExpiryDate,
+		Specialties:           req.Specialties,
+		Certifications:        req.Certifications,
+		WorkSchedule:          req.WorkSchedule,
+		AvailabilityStatus:    req.AvailabilityStatus,
+		BaseLocationID:        req.BaseLocationID,
+		AssignedVehicleID:     req.AssignedVehicleID,
+		CanPrescribe:          req.CanPrescribe,
+		CanViewAllPatients:    req.CanViewAllPatients,
+		AccessLevel:           req.AccessLevel,
+		AccountStatus:         req.AccountStatus,
+		Bio:                   req.Bio,
+		InternalNotes:         req.InternalNotes,
+		CreatedAt:             now,
+		CreatedBy:             &createdBy,
+		UpdatedAt:             now,
+	}
+
+	mutation := spanner.Insert("staff_members",
+		[]string{
+			"staff_id", "organization_id",
+			"family_name", "given_name", "family_name_kana", "given_name_kana",
+			"email", "phone", "emergency_contact_phone",
+			"role", "qualification_type", "license_number", "license_issued_date", "license_expiry_date",
+			"specialties", "certifications", "work_schedule", "availability_status",
+			"base_location_id", "assigned_vehicle_id",
+			"can_prescribe", "can_view_all_patients", "access_level",
+			"account_status", "onboarding_completed",
+			"bio", "internal_notes",
+			"created_at", "created_by", "updated_at", "deleted",
+		},
+		[]interface{}{
+			staffID, nullString(req.OrganizationID),
+			req.FamilyName, req.GivenName, nullString(req.FamilyNameKana), nullString(req.GivenNameKana),
+			req.Email, nullString(req.Phone), nullString(req.EmergencyContactPhone),
+			req.Role, nullString(req.QualificationType), nullString(req.LicenseNumber), nullDate(req.LicenseIssuedDate), nullDate(req.LicenseExpiryDate),
+			nullJSON(req.Specialties), nullJSON(req.Certifications), nullJSON(req.WorkSchedule), req.AvailabilityStatus,
+			nullString(req.BaseLocationID), nullString(req.AssignedVehicleID),
+			req.CanPrescribe, req.CanViewAllPatients, req.AccessLevel,
+			req.AccountStatus, false,
+			nullString(req.Bio), nullString(req.InternalNotes),
+			now, createdBy, now, false,
+		},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staff member: %w", err)
+	}
+
+	return staff, nil
+}
+
+// GetByID retrieves a staff member by ID
+func (r *StaffMemberRepository) GetByID(ctx context.Context, staffID string) (*models.StaffMember, error) {
+	stmt := NewStatement(`SELECT `+staffMemberColumns+`
+		FROM staff_members
+		WHERE staff_id = @staff_id AND deleted = false`,
+		map[string]interface{}{
+			"staff_id": staffID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("staff member not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query staff member: %w", err)
+	}
+
+	return scanStaffMember(row)
+}
+
+// List retrieves staff members with filters
+func (r *StaffMemberRepository) List(ctx context.Context, filter *models.StaffMemberFilter) ([]*models.StaffMember, error) {
+	conditions := []string{"deleted = false"}
+	params := make(map[string]interface{})
+
+	if filter.OrganizationID != nil {
+		conditions = append(conditions, "organization_id = @organization_id")
+		params["organization_id"] = *filter.OrganizationID
+	}
+
+	if filter.Role != nil {
+		conditions = append(conditions, "role = @role")
+		params["role"] = *filter.Role
+	}
+
+	if filter.Specialty != nil {
+		// specialties is a JSON array of strings; match the quoted element
+		conditions = append(conditions, "specialties::text LIKE @specialty_pattern")
+		params["specialty_pattern"] = `%"` + *filter.Specialty + `"%`
+	}
+
+	if filter.AvailabilityStatus != nil {
+		conditions = append(conditions, "availability_status = @availability_status")
+		params["availability_status"] = *filter.AvailabilityStatus
+	}
+
+	if filter.AccountStatus != nil {
+		conditions = append(conditions, "account_status = @account_status")
+		params["account_status"] = *filter.AccountStatus
+	}
+
+	limit := 100
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	offset := 0
+	if filter.Offset > 0 {
+		offset = filter.Offset
+	}
+
+	params["limit"] = limit
+	params["offset"] = offset
+
+	stmt := NewStatement(fmt.Sprintf(`SELECT `+staffMemberColumns+`
+		FROM staff_members
+		WHERE %s
+		ORDER BY family_name_kana, given_name_kana, family_name, given_name
+		LIMIT @limit OFFSET @offset`, strings.Join(conditions, " AND ")),
+		params)
+
+	return r.queryStaffMembers(ctx, stmt)
+}
+
+// GetExpiringLicenses retrieves active staff whose license expires on or before the given date
+func (r *StaffMemberRepository) GetExpiringLicenses(ctx context.Context, before civil.Date) ([]*models.StaffMember, error) {
+	stmt := NewStatement(`SELECT `+staffMemberColumns+`
+		FROM staff_members
+		WHERE deleted = false
+		  AND account_status = 'active'
+		  AND license_expiry_date IS NOT NULL
+		  AND license_expiry_date <= @before
+		ORDER BY license_expiry_date ASC`,
+		map[string]interface{}{
+			"before": before,
+		})
+
+	return r.queryStaffMembers(ctx, stmt)
+}
+
+// Update updates a staff member
+func (r *StaffMemberRepository) Update(ctx context.Context, staffID string, req *models.StaffMemberUpdateRequest, updatedBy string) (*models.StaffMember, error) {
+	existing, err := r.GetByID(ctx, staffID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.FamilyName != nil {
+		updates["family_name"] = *req.FamilyName
+		existing.FamilyName = *req.FamilyName
+	}
+	if req.GivenName != nil {
+		updates["given_name"] = *req.GivenName
+		existing.GivenName = *req.GivenName
+	}
+	if req.FamilyNameKana != nil {
+		updates["family_name_kana"] = nullString(req.FamilyNameKana)
+		existing.FamilyNameKana = req.FamilyNameKana
+	}
+	if req.GivenNameKana != nil {
+		updates["given_name_kana"] = nullString(req.GivenNameKana)
+		existing.GivenNameKana = req.GivenNameKana
+	}
+	if req.Email != nil {
+		updates["email"] = *req.Email
+		existing.Email = *req.Email
+	}
+	if req.Phone != nil {
+		updates["phone"] = nullString(req.Phone)
+		existing.Phone = req.Phone
+	}
+	if req.EmergencyContactPhone != nil {
+		updates["emergency_contact_phone"] = nullString(req.EmergencyContactPhone)
+		existing.EmergencyContactPhone = req.EmergencyContactPhone
+	}
+	if req.Role != nil {
+		updates["role"] = *req.Role
+		existing.Role = *req.Role
+	}
+	if req.QualificationType != nil {
+		updates["qualification_type"] = nullString(req.QualificationType)
+		existing.QualificationType = req.QualificationType
+	}
+	if req.LicenseNumber != nil {
+		updates["license_number"] = nullString(req.LicenseNumber)
+		existing.LicenseNumber = req.LicenseNumber
+	}
+	if req.LicenseIssuedDate != nil {
+		updates["license_issued_date"] = nullDate(req.LicenseIssuedDate)
+		existing.LicenseIssuedDate = req.LicenseIssuedDate
+	}
+	if req.LicenseExpiryDate != nil {
+		updates["license_expiry_date"] = nullDate(req.LicenseExpiryDate)
+		existing.LicenseExpiryDate = req.LicenseExpiryDate
+	}
+	if len(req.Specialties) > 0 {
+		updates["specialties"] = nullJSON(req.Specialties)
+		existing.Specialties = req.Specialties
+	}
+	if len(req.Certifications) > 0 {
+		updates["certifications"] = nullJSON(req.Certifications)
+		existing.Certifications = req.Certifications
+	}
+	if len(req.WorkSchedule) > 0 {
+		updates["work_schedule"] = nullJSON(req.WorkSchedule)
+		existing.WorkSchedule = req.WorkSchedule
+	}
+	if req.AvailabilityStatus != nil {
+		updates["availability_status"] = *req.AvailabilityStatus
+		existing.AvailabilityStatus = *req.AvailabilityStatus
+	}
+	if req.BaseLocationID != nil {
+		updates["base_location_id"] = nullString(req.BaseLocationID)
+		existing.BaseLocationID = req.BaseLocationID
+	}
+	if req.AssignedVehicleID != nil {
+		updates["assigned_vehicle_id"] = nullString(req.AssignedVehicleID)
+		existing.AssignedVehicleID = req.AssignedVehicleID
+	}
+	if req.CanPrescribe != nil {
+		updates["can_prescribe"] = *req.CanPrescribe
+		existing.CanPrescribe = *req.CanPrescribe
+	}
+	if req.CanViewAllPatients != nil {
+		updates["can_view_all_patients"] = *req.CanViewAllPatients
+		existing.CanViewAllPatients = *req.CanViewAllPatients
+	}
+	if req.AccessLevel != nil {
+		updates["access_level"] = *req.AccessLevel
+		existing.AccessLevel = *req.AccessLevel
+	}
+	if req.AccountStatus != nil {
+		updates["account_status"] = *req.AccountStatus
+		existing.AccountStatus = *req.AccountStatus
+	}
+	if req.OnboardingCompleted != nil {
+		updates["onboarding_completed"] = *req.OnboardingCompleted
+		existing.OnboardingCompleted = *req.OnboardingCompleted
+	}
+	if req.Bio != nil {
+		updates["bio"] = nullString(req.Bio)
+		existing.Bio = req.Bio
+	}
+	if req.InternalNotes != nil {
+		updates["internal_notes"] = nullString(req.InternalNotes)
+		existing.InternalNotes = req.InternalNotes
+	}
+
+	if len(updates) == 0 {
+		return existing, nil
+	}
+
+	now := time.Now()
+	updates["updated_at"] = now
+	updates["updated_by"] = spanner.NullString{StringVal: updatedBy, Valid: true}
+	existing.UpdatedAt = now
+	existing.UpdatedBy = &updatedBy
+
+	columns := []string{"staff_id"}
+	values := []interface{}{staffID}
+
+	for col, val := range updates {
+		columns = append(columns, col)
+		values = append(values, val)
+	}
+
+	mutation := spanner.Update("staff_members", columns, values)
+
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update staff member: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Delete soft-deletes a staff member
+func (r *StaffMemberRepository) Delete(ctx context.Context, staffID, deletedBy string) error {
+	if _, err := r.GetByID(ctx, staffID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	mutation := spanner.Update("staff_members",
+		[]string{"staff_id", "deleted", "deleted_at", "account_status", "updated_at", "updated_by"},
+		[]interface{}{staffID, true, now, "inactive", now, deletedBy},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to delete staff member: %w", err)
+	}
+
+	return nil
+}
+
+// queryStaffMembers runs a staff member query and scans all rows
+func (r *StaffMemberRepository) queryStaffMembers(ctx context.Context, stmt spanner.Statement) ([]*models.StaffMember, error) {
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var staff []*models.StaffMember
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate staff members: %w", err)
+		}
+
+		member, err := scanStaffMember(row)
+		if err != nil {
+			return nil, err
+		}
+		staff = append(staff, member)
+	}
+
+	return staff, nil
+}
+
+// scanStaffMember scans a Spanner row into a StaffMember model
+func scanStaffMember(row *spanner.Row) (*models.StaffMember, error) {
+	var staff models.StaffMember
+	var organizationID, familyNameKana, givenNameKana spanner.NullString
+	var phone, emergencyPhone, qualificationType, licenseNumber spanner.NullString
+	var licenseIssued, licenseExpiry spanner.NullDate
+	var specialties, certifications, workSchedule spanner.NullString
+	var baseLocationID, assignedVehicleID spanner.NullString
+	var latitude, longitude spanner.NullFloat64
+	var lastLocationUpdate, lastLoginAt, deletedAt spanner.NullTime
+	var bio, internalNotes, createdBy, updatedBy spanner.NullString
+
+	err := row.Columns(
+		&staff.StaffID,
+		&organizationID,
+		&staff.FamilyName,
+		&staff.GivenName,
+		&familyNameKana,
+		&givenNameKana,
+		&staff.Email,
+		&phone,
+		&emergencyPhone,
+		&staff.Role,
+		&qualificationType,
+		&licenseNumber,
+		&licenseIssued,
+		&licenseExpiry,
+		&specialties,
+		&certifications,
+		&workSchedule,
+		&staff.AvailabilityStatus,
+		&baseLocationID,
+		&latitude,
+		&longitude,
+		&lastLocationUpdate,
+		&assignedVehicleID,
+		&staff.CanPrescribe,
+		&staff.CanViewAllPatients,
+		&staff.AccessLevel,
+		&staff.AccountStatus,
+		&staff.OnboardingCompleted,
+		&lastLoginAt,
+		&bio,
+		&internalNotes,
+		&staff.CreatedAt,
+		&createdBy,
+		&staff.UpdatedAt,
+		&updatedBy,
+		&staff.Deleted,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan staff member: %w", err)
+	}
+
+	staff.OrganizationID = stringPtrFromNull(organizationID)
+	staff.FamilyNameKana = stringPtrFromNull(familyNameKana)
+	staff.GivenNameKana = stringPtrFromNull(givenNameKana)
+	staff.Phone = stringPtrFromNull(phone)
+	staff.EmergencyContactPhone = stringPtrFromNull(emergencyPhone)
+	staff.QualificationType = stringPtrFromNull(qualificationType)
+	staff.LicenseNumber = stringPtrFromNull(licenseNumber)
+	staff.BaseLocationID = stringPtrFromNull(baseLocationID)
+	staff.AssignedVehicleID = stringPtrFromNull(assignedVehicleID)
+	staff.Bio = stringPtrFromNull(bio)
+	staff.InternalNotes = stringPtrFromNull(internalNotes)
+	staff.CreatedBy = stringPtrFromNull(createdBy)
+	staff.UpdatedBy = stringPtrFromNull(updatedBy)
+
+	staff.LicenseIssuedDate = datePtrFromNull(licenseIssued)
+	staff.LicenseExpiryDate = datePtrFromNull(licenseExpiry)
+
+	if specialties.Valid {
+		staff.Specialties = json.RawMessage(specialties.StringVal)
+	}
+	if certifications.Valid {
+		staff.Certifications = json.RawMessage(certifications.StringVal)
+	}
+	if workSchedule.Valid {
+		staff.WorkSchedule = json.RawMessage(workSchedule.StringVal)
+	}
+	if latitude.Valid {
+		staff.CurrentLatitude = &latitude.Float64
+	}
+	if longitude.Valid {
+		staff.CurrentLongitude = &longitude.Float64
+	}
+	if lastLocationUpdate.Valid {
+		staff.LastLocationUpdate = &lastLocationUpdate.Time
+	}
+	if lastLoginAt.Valid {
+		staff.LastLoginAt = &lastLoginAt.Time
+	}
+	if deletedAt.Valid {
+		staff.DeletedAt = &deletedAt.Time
+	}
+
+	return &staff, nil
+}
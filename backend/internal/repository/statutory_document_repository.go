@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// StatutoryDocumentRepository handles issued statutory documents
+type StatutoryDocumentRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewStatutoryDocumentRepository creates a new statutory document repository
+func NewStatutoryDocumentRepository(spannerRepo *SpannerRepository) *StatutoryDocumentRepository {
+	return &StatutoryDocumentRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const statutoryDocumentColumns = `document_id, patient_id, document_type, document_status, valid_from, valid_to,
+			fields::text,
+			pdf_sha256, pdf_size_bytes, pdf_blob_key, pdf_wrapped_key,
+			issued_at, issued_by, superseded_by, superseded_at`
+
+// Issue inserts an issued document and, in the same transaction, supersedes the issued
+// documents of the same patient and type whose validity period overlaps it. It returns
+// the IDs of the superseded documents.
+func (r *StatutoryDocumentRepository) Issue(ctx context.Context, doc *models.StatutoryDocument) ([]string, error) {
+	doc.Status = "issued"
+
+	fields, err := json.Marshal(doc.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document fields: %w", err)
+	}
+
+	var superseded []string
+	_, err = r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		superseded = nil
+
+		stmt := NewStatement(`SELECT document_id
+			FROM statutory_documents
+			WHERE patient_id = @patient_id
+			  AND document_type = @document_type
+			  AND document_status = 'issued'
+			  AND valid_from <= @valid_to
+			  AND valid_to >= @valid_from`,
+			map[string]interface{}{
+				"patient_id":    doc.PatientID,
+				"document_type": doc.DocumentType,
+				"valid_from":    doc.ValidFrom,
+				"valid_to":      doc.ValidTo,
+			})
+
+		iter := txn.Query(ctx, stmt)
+		defer iter.Stop()
+		for {
+			row, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to query overlapping documents: %w", err)
+			}
+			var documentID string
+			if err := row.Columns(&documentID); err != nil {
+				return fmt.Errorf("failed to parse document ID: %w", err)
+			}
+			superseded = append(superseded, documentID)
+		}
+
+		mutations := []*spanner.Mutation{
+			spanner.Insert("statutory_documents",
+				[]string{
+					"document_id", "patient_id", "document_type", "document_status", "valid_from", "valid_to",
+					"fields",
+					"pdf_sha256", "pdf_size_bytes", "pdf_blob_key", "pdf_wrapped_key",
+					"issued_at", "issued_by",
+				},
+				[]interface{}{
+					doc.DocumentID, doc.PatientID, doc.DocumentType, doc.Status, doc.ValidFrom, doc.ValidTo,
+					spanner.NullString{StringVal: string(fields), Valid: true},
+					doc.PDFSHA256, doc.PDFSizeBytes, doc.PDFBlobKey, doc.PDFWrappedKey,
+					doc.IssuedAt, doc.IssuedBy,
+				},
+			),
+		}
+		for _, documentID := range superseded {
+			mutations = append(mutations, spanner.Update("statutory_documents",
+				[]string{"document_id", "document_status", "superseded_by", "superseded_at"},
+				[]interface{}{documentID, "superseded", doc.DocumentID, doc.IssuedAt},
+			))
+		}
+		return txn.BufferWrite(mutations)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue statutory document: %w", err)
+	}
+
+	return superseded, nil
+}
+
+// GetByID retrieves a patient's statutory document by ID
+func (r *StatutoryDocumentRepository) GetByID(ctx context.Context, patientID, documentID string) (*models.StatutoryDocument, error) {
+	stmt := NewStatement(`SELECT `+statutoryDocumentColumns+`
+		FROM statutory_documents
+		WHERE document_id = @document_id AND patient_id = @patient_id`,
+		map[string]interface{}{
+			"document_id": documentID,
+			"patient_id":  patientID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("statutory document not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statutory document: %w", err)
+	}
+
+	return scanStatutoryDocument(row)
+}
+
+// List lists a patient's statutory documents, most recently issued first
+func (r *StatutoryDocumentRepository) List(ctx context.Context, patientID string, filter *models.StatutoryDocumentFilter) ([]*models.StatutoryDocument, error) {
+	conditions := []string{"patient_id = @patient_id"}
+	params := map[string]interface{}{
+		"patient_id": patientID,
+	}
+
+	if filter.DocumentType != nil {
+		conditions = append(conditions, "document_type = @document_type")
+		params["document_type"] = *filter.DocumentType
+	}
+	if filter.Status != nil {
+		conditions = append(conditions, "document_status = @document_status")
+		params["document_status"] = *filter.Status
+	}
+	if filter.ValidOn != nil {
+		conditions = append(conditions, "valid_from <= @valid_on AND valid_to >= @valid_on")
+		params["valid_on"] = *filter.ValidOn
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	params["limit"] = int64(limit)
+
+	stmt := NewStatement(`SELECT `+statutoryDocumentColumns+`
+		FROM statutory_documents
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY issued_at DESC
+		LIMIT @limit`, params)
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	documents := []*models.StatutoryDocument{}
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate statutory documents: %w", err)
+		}
+
+		doc, err := scanStatutoryDocument(row)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, doc)
+	}
+
+	return documents, nil
+}
+
+// scanStatutoryDocument scans a Spanner row into a StatutoryDocument model
+func scanStatutoryDocument(row *spanner.Row) (*models.StatutoryDocument, error) {
+	var doc models.StatutoryDocument
+	var fields, supersededBy spanner.NullString
+	var supersededAt spanner.NullTime
+
+	err := row.Columns(
+		&doc.DocumentID,
+		&doc.PatientID,
+		&doc.DocumentType,
+		&doc.Status,
+		&doc.ValidFrom,
+		&doc.ValidTo,
+		&fields,
+		&doc.PDFSHA256,
+		&doc.PDFSizeBytes,
+		&doc.PDFBlobKey,
+		&doc.PDFWrappedKey,
+		&doc.IssuedAt,
+		&doc.IssuedBy,
+		&supersededBy,
+		&supersededAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan statutory document: %w", err)
+	}
+
+	if fields.Valid {
+		if err := json.Unmarshal([]byte(fields.StringVal), &doc.Fields); err != nil {
+			return nil, fmt.Errorf("failed to parse document fields: %w", err)
+		}
+	}
+	doc.SupersededBy = stringPtrFromNull(supersededBy)
+	if supersededAt.Valid {
+		t := supersededAt.Time
+		doc.SupersededAt = &t
+	}
+
+	return &doc, nil
+}
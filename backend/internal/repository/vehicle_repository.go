@@ -0,0 +1,464 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// VehicleRepository handles vehicle fleet data operations
+type VehicleRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewVehicleRepository creates a new vehicle repository
+func NewVehicleRepository(spannerRepo *SpannerRepository) *VehicleRepository {
+	return &VehicleRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const vehicleColumns = `vehicle_id, organization_id,
+			vehicle_type, make, model, year, color,
+			license_plate, registration_number, registration_expiry_date,
+			insurance_company, insurance_policy_number, insurance_expiry_date,
+			last_maintenance_date, next_maintenance_date, odometer_reading, fuel_type,
+			medical_equipment::text, passenger_capacity, cargo_capacity_kg,
+			gps_device_id, current_latitude, current_longitude, last_location_update,
+			vehicle_status, currently_assigned_to, assignment_start_date,
+			notes,
+			created_at, created_by, updated_at, updated_by, deleted, deleted_at`
+
+// Create creates a new vehicle
+func (r *VehicleRepository) Create(ctx context.Context, req *models.VehicleCreateRequest, createdBy string) (*models.Vehicle, error) {
+	vehicleID := uuid.New().String()
+	now := time.Now()
+
+	vehicle := &models.Vehicle{
+		VehicleID:              vehicleID,
+		OrganizationID:         req.OrganizationID,
+		VehicleType:            req.VehicleType,
+		Make:                   req.Make,
+		Model:                  req.Model,
+		Year:                   req.Year,
+		Color:                  req.Color,
+		LicensePlate:           req.LicensePlate,
+		RegistrationNumber:     req.RegistrationNumber,
+		RegistrationExpiryDate: req.RegistrationExpiryDate,
+		InsuranceCompany:       req.InsuranceCompany,
+		InsurancePolicyNumber:  req.InsurancePolicyNumber,
+		InsuranceExpiryDate:    req.InsuranceExpiryDate,
+		LastMaintenanceDate:    req.LastMaintenanceDate,
+		NextMaintenanceDate:    req.NextMaintenanceDate,
+		OdometerReading:        req.OdometerReading,
+		FuelType:               req.FuelType,
+		MedicalEquipment:       req.MedicalEquipment,
+		PassengerCapacity:      req.PassengerCapacity,
+		CargoCapacityKg:        req.CargoCapacityKg,
+		GPSDeviceID:            req.GPSDeviceID,
+		VehicleStatus:          req.VehicleStatus,
+		Notes:                  req.Notes,
+		CreatedAt:              now,
+		CreatedBy:              &createdBy,
+		UpdatedAt:              now,
+	}
+
+	mutation := spanner.Insert("vehicles",
+		[]string{
+			"vehicle_id", "organization_id",
+			"vehicle_type", "make", "model", "year", "color",
+			"license_plate", "registration_number", "registration_expiry_date",
+			"insurance_company", "insurance_policy_number", "insurance_expiry_date",
+			"last_maintenance_date", "next_maintenance_date", "odometer_reading", "fuel_type",
+			"medical_equipment", "passenger_capacity", "cargo_capacity_kg",
+			"gps_device_id", "vehicle_status", "notes",
+			"created_at", "created_by", "updated_at", "deleted",
+		},
+		[]interface{}{
+			vehicleID, nullString(req.OrganizationID),
+			req.VehicleType, nullString(req.Make), nullString(req.Model), nullInt64(req.Year), nullString(req.Color),
+			req.LicensePlate, nullString(req.RegistrationNumber), nullDate(req.RegistrationExpiryDate),
+			nullString(req.InsuranceCompany), nullString(req.InsurancePolicyNumber), nullDate(req.InsuranceExpiryDate),
+			nullDate(req.LastMaintenanceDate), nullDate(req.NextMaintenanceDate), nullInt64(req.OdometerReading), nullString(req.FuelType),
+			nullJSON(req.MedicalEquipment), nullInt64(req.PassengerCapacity), nullFloat64(req.CargoCapacityKg),
+			nullString(req.GPSDeviceID), req.VehicleStatus, nullString(req.Notes),
+			now, createdBy, now, false,
+		},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vehicle: %w", err)
+	}
+
+	return vehicle, nil
+}
+
+// GetByID retrieves a vehicle by ID
+func (r *VehicleRepository) GetByID(ctx context.Context, vehicleID string) (*models.Vehicle, error) {
+	stmt := NewStatement(`SELECT `+vehicleColumns+`
+		FROM vehicles
+		WHERE vehicle_id = @vehicle_id AND deleted = false`,
+		map[string]interface{}{
+			"vehicle_id": vehicleID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("vehicle not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vehicle: %w", err)
+	}
+
+	return scanVehicle(row)
+}
+
+// List retrieves vehicles with filters
+func (r *VehicleRepository) List(ctx context.Context, filter *models.VehicleFilter) ([]*models.Vehicle, error) {
+	conditions := []string{"deleted = false"}
+	params := make(map[string]interface{})
+
+	if filter.OrganizationID != nil {
+		conditions = append(conditions, "organization_id = @organization_id")
+		params["organization_id"] = *filter.OrganizationID
+	}
+
+	if filter.VehicleType != nil {
+		conditions = append(conditions, "vehicle_type = @vehicle_type")
+		params["vehicle_type"] = *filter.VehicleType
+	}
+
+	if filter.VehicleStatus != nil {
+		conditions = append(conditions, "vehicle_status = @vehicle_status")
+		params["vehicle_status"] = *filter.VehicleStatus
+	}
+
+	if filter.CurrentlyAssignedTo != nil {
+		conditions = append(conditions, "currently_assigned_to = @currently_assigned_to")
+		params["currently_assigned_to"] = *filter.CurrentlyAssignedTo
+	}
+
+	limit := 100
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	offset := 0
+	if filter.Offset > 0 {
+		offset = filter.Offset
+	}
+
+	params["limit"] = limit
+	params["offset"] = offset
+
+	stmt := NewStatement(fmt.Sprintf(`SELECT `+vehicleColumns+`
+		FROM vehicles
+		WHERE %s
+		ORDER BY license_plate ASC
+		LIMIT @limit OFFSET @offset`, strings.Join(conditions, " AND ")),
+		params)
+
+	return r.queryVehicles(ctx, stmt)
+}
+
+// GetWithUpcomingDeadlines retrieves vehicles whose registration, insurance or
+// maintenance date falls on or before the given date
+func (r *VehicleRepository) GetWithUpcomingDeadlines(ctx context.Context, before civil.Date) ([]*models.Vehicle, error) {
+	stmt := NewStatement(`SELECT `+vehicleColumns+`
+		FROM vehicles
+		WHERE deleted = false
+		  AND vehicle_status != 'retired'
+		  AND (registration_expiry_date <= @before
+		    OR insurance_expiry_date <= @before
+		    OR next_maintenance_date <= @before)
+		ORDER BY license_plate ASC`,
+		map[string]interface{}{
+			"before": before,
+		})
+
+	return r.queryVehicles(ctx, stmt)
+}
+
+// Update updates a vehicle
+func (r *VehicleRepository) Update(ctx context.Context, vehicleID string, req *models.VehicleUpdateRequest, updatedBy string) (*models.Vehicle, error) {
+	existing, err := r.GetByID(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.VehicleType != nil {
+		updates["vehicle_type"] = *req.VehicleType
+		existing.VehicleType = *req.VehicleType
+	}
+	if req.Make != nil {
+		updates["make"] = nullString(req.Make)
+		existing.Make = req.Make
+	}
+	if req.Model != nil {
+		updates["model"] = nullString(req.Model)
+		existing.Model = req.Model
+	}
+	if req.Year != nil {
+		updates["year"] = nullInt64(req.Year)
+		existing.Year = req.Year
+	}
+	if req.Color != nil {
+		updates["color"] = nullString(req.Color)
+		existing.Color = req.Color
+	}
+	if req.LicensePlate != nil {
+		updates["license_plate"] = *req.LicensePlate
+		existing.LicensePlate = *req.LicensePlate
+	}
+	if req.RegistrationNumber != nil {
+		updates["registration_number"] = nullString(req.RegistrationNumber)
+		existing.RegistrationNumber = req.RegistrationNumber
+	}
+	if req.RegistrationExpiryDate != nil {
+		updates["registration_expiry_date"] = nullDate(req.RegistrationExpiryDate)
+		existing.RegistrationExpiryDate = req.RegistrationExpiryDate
+	}
+	if req.InsuranceCompany != nil {
+		updates["insurance_company"] = nullString(req.InsuranceCompany)
+		existing.InsuranceCompany = req.InsuranceCompany
+	}
+	if req.InsurancePolicyNumber != nil {
+		updates["insurance_policy_number"] = nullString(req.InsurancePolicyNumber)
+		existing.InsurancePolicyNumber = req.InsurancePolicyNumber
+	}
+	if req.InsuranceExpiryDate != nil {
+		updates["insurance_expiry_date"] = nullDate(req.InsuranceExpiryDate)
+		existing.InsuranceExpiryDate = req.InsuranceExpiryDate
+	}
+	if req.LastMaintenanceDate != nil {
+		updates["last_maintenance_date"] = nullDate(req.LastMaintenanceDate)
+		existing.LastMaintenanceDate = req.LastMaintenanceDate
+	}
+	if req.NextMaintenanceDate != nil {
+		updates["next_maintenance_date"] = nullDate(req.NextMaintenanceDate)
+		existing.NextMaintenanceDate = req.NextMaintenanceDate
+	}
+	if req.OdometerReading != nil {
+		updates["odometer_reading"] = nullInt64(req.OdometerReading)
+		existing.OdometerReading = req.OdometerReading
+	}
+	if req.FuelType != nil {
+		updates["fuel_type"] = nullString(req.FuelType)
+		existing.FuelType = req.FuelType
+	}
+	if len(req.MedicalEquipment) > 0 {
+		updates["medical_equipment"] = nullJSON(req.MedicalEquipment)
+		existing.MedicalEquipment = req.MedicalEquipment
+	}
+	if req.PassengerCapacity != nil {
+		updates["passenger_capacity"] = nullInt64(req.PassengerCapacity)
+		existing.PassengerCapacity = req.PassengerCapacity
+	}
+	if req.CargoCapacityKg != nil {
+		updates["cargo_capacity_kg"] = nullFloat64(req.CargoCapacityKg)
+		existing.CargoCapacityKg = req.CargoCapacityKg
+	}
+	if req.GPSDeviceID != nil {
+		updates["gps_device_id"] = nullString(req.GPSDeviceID)
+		existing.GPSDeviceID = req.GPSDeviceID
+	}
+	if req.VehicleStatus != nil {
+		updates["vehicle_status"] = *req.VehicleStatus
+		existing.VehicleStatus = *req.VehicleStatus
+	}
+	if req.CurrentlyAssignedTo != nil {
+		updates["currently_assigned_to"] = nullString(req.CurrentlyAssignedTo)
+		existing.CurrentlyAssignedTo = req.CurrentlyAssignedTo
+	}
+	if req.AssignmentStartDate != nil {
+		updates["assignment_start_date"] = nullDate(req.AssignmentStartDate)
+		existing.AssignmentStartDate = req.AssignmentStartDate
+	}
+	if req.Notes != nil {
+		updates["notes"] = nullString(req.Notes)
+		existing.Notes = req.Notes
+	}
+
+	if len(updates) == 0 {
+		return existing, nil
+	}
+
+	now := time.Now()
+	updates["updated_at"] = now
+	updates["updated_by"] = spanner.NullString{StringVal: updatedBy, Valid: true}
+	existing.UpdatedAt = now
+	existing.UpdatedBy = &updatedBy
+
+	columns := []string{"vehicle_id"}
+	values := []interface{}{vehicleID}
+
+	for col, val := range updates {
+		columns = append(columns, col)
+		values = append(values, val)
+	}
+
+	mutation := spanner.Update("vehicles", columns, values)
+
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update vehicle: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Delete soft-deletes a vehicle
+func (r *VehicleRepository) Delete(ctx context.Context, vehicleID, deletedBy string) error {
+	if _, err := r.GetByID(ctx, vehicleID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	mutation := spanner.Update("vehicles",
+		[]string{"vehicle_id", "deleted", "deleted_at", "updated_at", "updated_by"},
+		[]interface{}{vehicleID, true, now, now, deletedBy},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to delete vehicle: %w", err)
+	}
+
+	return nil
+}
+
+// queryVehicles runs a vehicle query and scans all rows
+func (r *VehicleRepository) queryVehicles(ctx context.Context, stmt spanner.Statement) ([]*models.Vehicle, error) {
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var vehicles []*models.Vehicle
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate vehicles: %w", err)
+		}
+
+		vehicle, err := scanVehicle(row)
+		if err != nil {
+			return nil, err
+		}
+		vehicles = append(vehicles, vehicle)
+	}
+
+	return vehicles, nil
+}
+
+// scanVehicle scans a Spanner row into a Vehicle model
+func scanVehicle(row *spanner.Row) (*models.Vehicle, error) {
+	var v models.Vehicle
+	var organizationID, makeStr, model, color spanner.NullString
+	var registrationNumber, insuranceCompany, insurancePolicy, fuelType spanner.NullString
+	var medicalEquipment, gpsDeviceID, assignedTo, notes, createdBy, updatedBy spanner.NullString
+	var year, odometer, passengerCapacity spanner.NullInt64
+	var cargoCapacity, latitude, longitude spanner.NullFloat64
+	var registrationExpiry, insuranceExpiry, lastMaintenance, nextMaintenance, assignmentStart spanner.NullDate
+	var lastLocationUpdate, deletedAt spanner.NullTime
+
+	err := row.Columns(
+		&v.VehicleID,
+		&organizationID,
+		&v.VehicleType,
+		&makeStr,
+		&model,
+		&year,
+		&color,
+		&v.LicensePlate,
+		&registrationNumber,
+		&registrationExpiry,
+		&insuranceCompany,
+		&insurancePolicy,
+		&insuranceExpiry,
+		&lastMaintenance,
+		&nextMaintenance,
+		&odometer,
+		&fuelType,
+		&medicalEquipment,
+		&passengerCapacity,
+		&cargoCapacity,
+		&gpsDeviceID,
+		&latitude,
+		&longitude,
+		&lastLocationUpdate,
+		&v.VehicleStatus,
+		&assignedTo,
+		&assignmentStart,
+		&notes,
+		&v.CreatedAt,
+		&createdBy,
+		&v.UpdatedAt,
+		&updatedBy,
+		&v.Deleted,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan vehicle: %w", err)
+	}
+
+	v.OrganizationID = stringPtrFromNull(organizationID)
+	v.Make = stringPtrFromNull(makeStr)
+	v.Model = stringPtrFromNull(model)
+	v.Color = stringPtrFromNull(color)
+	v.RegistrationNumber = stringPtrFromNull(registrationNumber)
+	v.InsuranceCompany = stringPtrFromNull(insuranceCompany)
+	v.InsurancePolicyNumber = stringPtrFromNull(insurancePolicy)
+	v.FuelType = stringPtrFromNull(fuelType)
+	v.GPSDeviceID = stringPtrFromNull(gpsDeviceID)
+	v.CurrentlyAssignedTo = stringPtrFromNull(assignedTo)
+	v.Notes = stringPtrFromNull(notes)
+	v.CreatedBy = stringPtrFromNull(createdBy)
+	v.UpdatedBy = stringPtrFromNull(updatedBy)
+
+	v.Year = int64PtrFromNull(year)
+	v.OdometerReading = int64PtrFromNull(odometer)
+	v.PassengerCapacity = int64PtrFromNull(passengerCapacity)
+
+	v.RegistrationExpiryDate = datePtrFromNull(registrationExpiry)
+	v.InsuranceExpiryDate = datePtrFromNull(insuranceExpiry)
+	v.LastMaintenanceDate = datePtrFromNull(lastMaintenance)
+	v.NextMaintenanceDate = datePtrFromNull(nextMaintenance)
+	v.AssignmentStartDate = datePtrFromNull(assignmentStart)
+
+	if medicalEquipment.Valid {
+		v.MedicalEquipment = json.RawMessage(medicalEquipment.StringVal)
+	}
+	if cargoCapacity.Valid {
+		v.CargoCapacityKg = &cargoCapacity.Float64
+	}
+	if latitude.Valid {
+		v.CurrentLatitude = &latitude.Float64
+	}
+	if longitude.Valid {
+		v.CurrentLongitude = &longitude.Float64
+	}
+	if lastLocationUpdate.Valid {
+		v.LastLocationUpdate = &lastLocationUpdate.Time
+	}
+	if deletedAt.Valid {
+		v.DeletedAt = &deletedAt.Time
+	}
+
+	return &v, nil
+}
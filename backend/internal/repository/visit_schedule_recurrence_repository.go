@@ -0,0 +1,451 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// VisitScheduleRecurrenceRepository handles recurring visit rule data operations
+type VisitScheduleRecurrenceRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewVisitScheduleRecurrenceRepository creates a new visit schedule recurrence repository
+func NewVisitScheduleRecurrenceRepository(spannerRepo *SpannerRepository) *VisitScheduleRecurrenceRepository {
+	return &VisitScheduleRecurrenceRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const visitScheduleRecurrenceColumns = `recurrence_id, patient_id,
+			frequency, by_weekday::text, by_week_of_month::text,
+			start_date, end_date, exception_dates::text,
+			visit_type, start_time, end_time, estimated_duration_minutes,
+			assigned_staff_id, assigned_vehicle_id, priority_score, constraints::text,
+			care_plan_ref, activity_ref,
+			materialized_until, status,
+			created_at, created_by, updated_at, updated_by, deleted, deleted_at`
+
+// Insert writes a fully built recurrence rule
+func (r *VisitScheduleRecurrenceRepository) Insert(ctx context.Context, recurrence *models.VisitScheduleRecurrence) error {
+	mutation, err := recurrenceInsert(recurrence)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to create visit schedule recurrence: %w", err)
+	}
+
+	return nil
+}
+
+// RecurrenceSplit is an edit of an occurrence and every later one: the original rule
+// stops before the occurrence and a new rule takes over from it
+type RecurrenceSplit struct {
+	Original *models.VisitScheduleRecurrence
+	// LastDate ends the original rule; nil deletes it because no occurrence is left before the split
+	LastDate *civil.Date
+	// Split is the new rule, with MaterializedUntil set to the horizon of Created
+	Split *models.VisitScheduleRecurrence
+	// Keep are the original's occurrences moved to the new rule unchanged
+	Keep []string
+	// Replace are the original's occurrences deleted and re-generated as Created
+	Replace []*models.VisitSchedule
+	Created []*models.VisitSchedule
+}
+
+// Split commits a recurrence split in one read-write transaction, so a failure leaves the
+// original rule and its occurrences as they were. Occurrences to replace are re-read and
+// the split fails if one has changed status (e.g. started) in the meantime.
+func (r *VisitScheduleRecurrenceRepository) Split(ctx context.Context, split *RecurrenceSplit, updatedBy string) error {
+	now := time.Now()
+
+	insert, err := recurrenceInsert(split.Split)
+	if err != nil {
+		return err
+	}
+	mutations := []*spanner.Mutation{insert}
+	mutations = append(mutations, occurrenceReassigns(split.Keep, split.Split.RecurrenceID, now)...)
+	replaceIDs := make([]string, len(split.Replace))
+	for i, o := range split.Replace {
+		replaceIDs[i] = o.ScheduleID
+	}
+	mutations = append(mutations, occurrenceDeletes(replaceIDs)...)
+	mutations = append(mutations, occurrenceInserts(split.Created, now)...)
+	if split.LastDate != nil {
+		mutations = append(mutations, recurrenceEnd(split.Original.RecurrenceID, split.LastDate, updatedBy, now))
+	} else {
+		mutations = append(mutations, recurrenceDelete(split.Original.RecurrenceID, updatedBy, now))
+	}
+
+	_, err = r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		for _, o := range split.Replace {
+			current, err := readScheduleStatus(ctx, txn, o.ScheduleID)
+			if err != nil {
+				return err
+			}
+			if current != o.Status {
+				return &StatusChangedError{ScheduleID: o.ScheduleID, Expected: o.Status, Actual: current}
+			}
+		}
+		return txn.BufferWrite(mutations)
+	})
+	if err != nil {
+		var changed *StatusChangedError
+		if errors.As(err, &changed) {
+			return changed
+		}
+		return fmt.Errorf("failed to split visit schedule recurrence: %w", err)
+	}
+
+	return nil
+}
+
+// recurrenceInsert builds the insert mutation for a fully built rule
+func recurrenceInsert(recurrence *models.VisitScheduleRecurrence) (*spanner.Mutation, error) {
+	byWeekday, err := json.Marshal(recurrence.ByWeekday)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode by_weekday: %w", err)
+	}
+	byWeekOfMonth, err := marshalOptionalJSON(recurrence.ByWeekOfMonth, len(recurrence.ByWeekOfMonth) > 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode by_week_of_month: %w", err)
+	}
+	exceptionDates, err := marshalOptionalJSON(recurrence.ExceptionDates, len(recurrence.ExceptionDates) > 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode exception_dates: %w", err)
+	}
+
+	return spanner.Insert("visit_schedule_recurrences",
+		[]string{
+			"recurrence_id", "patient_id",
+			"frequency", "by_weekday", "by_week_of_month",
+			"start_date", "end_date", "exception_dates",
+			"visit_type", "start_time", "end_time", "estimated_duration_minutes",
+			"assigned_staff_id", "assigned_vehicle_id", "priority_score", "constraints",
+			"care_plan_ref", "activity_ref",
+			"materialized_until", "status",
+			"created_at", "created_by", "updated_at", "deleted",
+		},
+		[]interface{}{
+			recurrence.RecurrenceID, recurrence.PatientID,
+			recurrence.Frequency, string(byWeekday), byWeekOfMonth,
+			recurrence.StartDate, nullDate(recurrence.EndDate), exceptionDates,
+			recurrence.VisitType, nullString(recurrence.StartTime), nullString(recurrence.EndTime), recurrence.EstimatedDurationMinutes,
+			nullString(recurrence.AssignedStaffID), nullString(recurrence.AssignedVehicleID), recurrence.PriorityScore, nullJSON(recurrence.Constraints),
+			nullString(recurrence.CarePlanRef), nullString(recurrence.ActivityRef),
+			nullDate(recurrence.MaterializedUntil), recurrence.Status,
+			recurrence.CreatedAt, nullString(recurrence.CreatedBy), recurrence.UpdatedAt, false,
+		},
+	), nil
+}
+
+// GetByID retrieves a recurrence rule by ID
+func (r *VisitScheduleRecurrenceRepository) GetByID(ctx context.Context, recurrenceID string) (*models.VisitScheduleRecurrence, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleRecurrenceColumns+`
+		FROM visit_schedule_recurrences
+		WHERE recurrence_id = @recurrence_id AND deleted = false`,
+		map[string]interface{}{
+			"recurrence_id": recurrenceID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("visit schedule recurrence not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visit schedule recurrence: %w", err)
+	}
+
+	return scanVisitScheduleRecurrence(row)
+}
+
+// ListByPatient retrieves a patient's recurrence rules
+func (r *VisitScheduleRecurrenceRepository) ListByPatient(ctx context.Context, patientID string) ([]*models.VisitScheduleRecurrence, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleRecurrenceColumns+`
+		FROM visit_schedule_recurrences
+		WHERE patient_id = @patient_id AND deleted = false
+		ORDER BY start_date DESC`,
+		map[string]interface{}{
+			"patient_id": patientID,
+		})
+
+	return r.queryVisitScheduleRecurrences(ctx, stmt)
+}
+
+// ListByCarePlan retrieves the active rules generated from a care plan's activities
+func (r *VisitScheduleRecurrenceRepository) ListByCarePlan(ctx context.Context, planID string) ([]*models.VisitScheduleRecurrence, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleRecurrenceColumns+`
+		FROM visit_schedule_recurrences
+		WHERE care_plan_ref = @plan_id AND status = 'active' AND deleted = false
+		ORDER BY start_date ASC`,
+		map[string]interface{}{
+			"plan_id": planID,
+		})
+
+	return r.queryVisitScheduleRecurrences(ctx, stmt)
+}
+
+// ListStoppedCarePlanRefs retrieves the care plans that still have active rules although the
+// plan has been revoked, completed or deleted
+func (r *VisitScheduleRecurrenceRepository) ListStoppedCarePlanRefs(ctx context.Context) ([]string, error) {
+	stmt := NewStatement(`SELECT DISTINCT r.care_plan_ref
+		FROM visit_schedule_recurrences r
+		LEFT JOIN care_plans p ON p.plan_id = r.care_plan_ref
+		WHERE r.care_plan_ref IS NOT NULL AND r.status = 'active' AND r.deleted = false
+		  AND (p.plan_id IS NULL OR p.status IN ('revoked', 'completed'))`,
+		map[string]interface{}{})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var planIDs []string
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate stopped care plans: %w", err)
+		}
+
+		var planID string
+		if err := row.Columns(&planID); err != nil {
+			return nil, fmt.Errorf("failed to parse care plan ref: %w", err)
+		}
+		planIDs = append(planIDs, planID)
+	}
+
+	return planIDs, nil
+}
+
+// ListDue retrieves active rules that have not been materialized up to the given date
+func (r *VisitScheduleRecurrenceRepository) ListDue(ctx context.Context, until civil.Date) ([]*models.VisitScheduleRecurrence, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleRecurrenceColumns+`
+		FROM visit_schedule_recurrences
+		WHERE status = 'active' AND deleted = false
+		  AND (materialized_until IS NULL OR materialized_until < @until)
+		  AND (end_date IS NULL OR materialized_until IS NULL OR materialized_until < end_date)`,
+		map[string]interface{}{
+			"until": until,
+		})
+
+	return r.queryVisitScheduleRecurrences(ctx, stmt)
+}
+
+// SetMaterializedUntil records how far a rule's occurrences have been generated
+func (r *VisitScheduleRecurrenceRepository) SetMaterializedUntil(ctx context.Context, recurrenceID string, until civil.Date) error {
+	mutation := spanner.Update("visit_schedule_recurrences",
+		[]string{"recurrence_id", "materialized_until", "updated_at"},
+		[]interface{}{recurrenceID, until, time.Now()},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to update visit schedule recurrence: %w", err)
+	}
+
+	return nil
+}
+
+// AddExceptionDate excludes a single date from a rule
+func (r *VisitScheduleRecurrenceRepository) AddExceptionDate(ctx context.Context, recurrenceID string, date civil.Date, updatedBy string) error {
+	recurrence, err := r.GetByID(ctx, recurrenceID)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range recurrence.ExceptionDates {
+		if d == date {
+			return nil
+		}
+	}
+
+	exceptionDates, err := json.Marshal(append(recurrence.ExceptionDates, date))
+	if err != nil {
+		return fmt.Errorf("failed to encode exception_dates: %w", err)
+	}
+
+	mutation := spanner.Update("visit_schedule_recurrences",
+		[]string{"recurrence_id", "exception_dates", "updated_at", "updated_by"},
+		[]interface{}{recurrenceID, string(exceptionDates), time.Now(), updatedBy},
+	)
+
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to update visit schedule recurrence: %w", err)
+	}
+
+	return nil
+}
+
+// End stops a rule after the given date; a nil date ends the rule outright
+func (r *VisitScheduleRecurrenceRepository) End(ctx context.Context, recurrenceID string, lastDate *civil.Date, updatedBy string) error {
+	mutation := recurrenceEnd(recurrenceID, lastDate, updatedBy, time.Now())
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to end visit schedule recurrence: %w", err)
+	}
+
+	return nil
+}
+
+// recurrenceEnd builds the update that ends a rule
+func recurrenceEnd(recurrenceID string, lastDate *civil.Date, updatedBy string, now time.Time) *spanner.Mutation {
+	columns := []string{"recurrence_id", "status", "updated_at", "updated_by"}
+	values := []interface{}{recurrenceID, "ended", now, updatedBy}
+
+	if lastDate != nil {
+		columns = append(columns, "end_date")
+		values = append(values, *lastDate)
+	}
+
+	return spanner.Update("visit_schedule_recurrences", columns, values)
+}
+
+// Delete soft-deletes a recurrence rule
+func (r *VisitScheduleRecurrenceRepository) Delete(ctx context.Context, recurrenceID, deletedBy string) error {
+	mutation := recurrenceDelete(recurrenceID, deletedBy, time.Now())
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to delete visit schedule recurrence: %w", err)
+	}
+
+	return nil
+}
+
+// recurrenceDelete builds the update that soft-deletes a rule
+func recurrenceDelete(recurrenceID, deletedBy string, now time.Time) *spanner.Mutation {
+	return spanner.Update("visit_schedule_recurrences",
+		[]string{"recurrence_id", "status", "deleted", "deleted_at", "updated_at", "updated_by"},
+		[]interface{}{recurrenceID, "ended", true, now, now, deletedBy},
+	)
+}
+
+// queryVisitScheduleRecurrences runs a recurrence query and scans all rows
+func (r *VisitScheduleRecurrenceRepository) queryVisitScheduleRecurrences(ctx context.Context, stmt spanner.Statement) ([]*models.VisitScheduleRecurrence, error) {
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var recurrences []*models.VisitScheduleRecurrence
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate visit schedule recurrences: %w", err)
+		}
+
+		recurrence, err := scanVisitScheduleRecurrence(row)
+		if err != nil {
+			return nil, err
+		}
+		recurrences = append(recurrences, recurrence)
+	}
+
+	return recurrences, nil
+}
+
+// marshalOptionalJSON encodes v for a nullable JSONB column
+func marshalOptionalJSON(v interface{}, present bool) (spanner.NullString, error) {
+	if !present {
+		return spanner.NullString{}, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return spanner.NullString{}, err
+	}
+	return spanner.NullString{StringVal: string(raw), Valid: true}, nil
+}
+
+// scanVisitScheduleRecurrence scans a Spanner row into a VisitScheduleRecurrence model
+func scanVisitScheduleRecurrence(row *spanner.Row) (*models.VisitScheduleRecurrence, error) {
+	var rec models.VisitScheduleRecurrence
+	var byWeekday string
+	var byWeekOfMonth, exceptionDates, constraints spanner.NullString
+	var startTime, endTime, staffID, vehicleID, carePlanRef, activityRef spanner.NullString
+	var createdBy, updatedBy spanner.NullString
+	var endDate, materializedUntil spanner.NullDate
+	var deletedAt spanner.NullTime
+
+	err := row.Columns(
+		&rec.RecurrenceID,
+		&rec.PatientID,
+		&rec.Frequency,
+		&byWeekday,
+		&byWeekOfMonth,
+		&rec.StartDate,
+		&endDate,
+		&exceptionDates,
+		&rec.VisitType,
+		&startTime,
+		&endTime,
+		&rec.EstimatedDurationMinutes,
+		&staffID,
+		&vehicleID,
+		&rec.PriorityScore,
+		&constraints,
+		&carePlanRef,
+		&activityRef,
+		&materializedUntil,
+		&rec.Status,
+		&rec.CreatedAt,
+		&createdBy,
+		&rec.UpdatedAt,
+		&updatedBy,
+		&rec.Deleted,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan visit schedule recurrence: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(byWeekday), &rec.ByWeekday); err != nil {
+		return nil, fmt.Errorf("failed to decode by_weekday: %w", err)
+	}
+	if byWeekOfMonth.Valid {
+		if err := json.Unmarshal([]byte(byWeekOfMonth.StringVal), &rec.ByWeekOfMonth); err != nil {
+			return nil, fmt.Errorf("failed to decode by_week_of_month: %w", err)
+		}
+	}
+	if exceptionDates.Valid {
+		if err := json.Unmarshal([]byte(exceptionDates.StringVal), &rec.ExceptionDates); err != nil {
+			return nil, fmt.Errorf("failed to decode exception_dates: %w", err)
+		}
+	}
+	if constraints.Valid {
+		rec.Constraints = json.RawMessage(constraints.StringVal)
+	}
+
+	rec.EndDate = datePtrFromNull(endDate)
+	rec.MaterializedUntil = datePtrFromNull(materializedUntil)
+	rec.StartTime = stringPtrFromNull(startTime)
+	rec.EndTime = stringPtrFromNull(endTime)
+	rec.AssignedStaffID = stringPtrFromNull(staffID)
+	rec.AssignedVehicleID = stringPtrFromNull(vehicleID)
+	rec.CarePlanRef = stringPtrFromNull(carePlanRef)
+	rec.ActivityRef = stringPtrFromNull(activityRef)
+	rec.CreatedBy = stringPtrFromNull(createdBy)
+	rec.UpdatedBy = stringPtrFromNull(updatedBy)
+	if deletedAt.Valid {
+		rec.DeletedAt = &deletedAt.Time
+	}
+
+	return &rec, nil
+}
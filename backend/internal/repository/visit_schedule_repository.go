@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -26,6 +27,17 @@ func NewVisitScheduleRepository(spannerRepo *SpannerRepository) *VisitScheduleRe
 	}
 }
 
+const visitScheduleColumns = `schedule_id, patient_id, visit_date, visit_type,
+			time_window_start, time_window_end, estimated_duration_minutes,
+			assigned_staff_id, assigned_vehicle_id,
+			status, priority_score, constraints::text, optimization_result::text,
+			care_plan_ref, activity_ref,
+			recurrence_id, occurrence_date, is_recurrence_exception,
+			actual_arrival_at, actual_departure_at, cancelled_at, cancelled_by, cancellation_reason,
+			estimated_arrival_at, running_late, eta_updated_at,
+			check_in_verified, check_out_verified,
+			created_at, updated_at`
+
 // Create creates a new visit schedule
 func (r *VisitScheduleRepository) Create(ctx context.Context, patientID string, req *models.VisitScheduleCreateRequest) (*models.VisitSchedule, error) {
 	scheduleID := uuid.New().String()
@@ -79,6 +91,7 @@ func (r *VisitScheduleRepository) Create(ctx context.Context, patientID string,
 			"assigned_staff_id", "assigned_vehicle_id",
 			"status", "priority_score", "constraints",
 			"care_plan_ref", "activity_ref",
+			"is_recurrence_exception",
 			"created_at", "updated_at",
 		},
 		[]interface{}{
@@ -87,6 +100,7 @@ func (r *VisitScheduleRepository) Create(ctx context.Context, patientID string,
 			schedule.AssignedStaffID, schedule.AssignedVehicleID,
 			req.Status, req.PriorityScore, constraintsStr,
 			schedule.CarePlanRef, schedule.ActivityRef,
+			false,
 			now, now,
 		},
 	)
@@ -101,13 +115,7 @@ func (r *VisitScheduleRepository) Create(ctx context.Context, patientID string,
 
 // GetByID retrieves a visit schedule by ID
 func (r *VisitScheduleRepository) GetByID(ctx context.Context, patientID, scheduleID string) (*models.VisitSchedule, error) {
-	stmt := NewStatement(`SELECT
-			schedule_id, patient_id, visit_date, visit_type,
-			time_window_start, time_window_end, estimated_duration_minutes,
-			assigned_staff_id, assigned_vehicle_id,
-			status, priority_score, constraints::text, optimization_result::text,
-			care_plan_ref, activity_ref,
-			created_at, updated_at
+	stmt := NewStatement(`SELECT `+visitScheduleColumns+`
 		FROM visit_schedules
 		WHERE patient_id = @patient_id AND schedule_id = @schedule_id`,
 		map[string]interface{}{
@@ -129,6 +137,101 @@ func (r *VisitScheduleRepository) GetByID(ctx context.Context, patientID, schedu
 	return scanVisitSchedule(row)
 }
 
+// GetByScheduleID retrieves a visit schedule by ID without knowing its patient
+func (r *VisitScheduleRepository) GetByScheduleID(ctx context.Context, scheduleID string) (*models.VisitSchedule, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleColumns+`
+		FROM visit_schedules
+		WHERE schedule_id = @schedule_id`,
+		map[string]interface{}{
+			"schedule_id": scheduleID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("visit schedule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visit schedule: %w", err)
+	}
+
+	return scanVisitSchedule(row)
+}
+
+// ListByStaffAndDate retrieves a staff member's open (not cancelled or completed) schedules on a date
+func (r *VisitScheduleRepository) ListByStaffAndDate(ctx context.Context, staffID string, visitDate civil.Date) ([]*models.VisitSchedule, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleColumns+`
+		FROM visit_schedules
+		WHERE assigned_staff_id = @staff_id
+		  AND visit_date = @visit_date
+		  AND status NOT IN ('cancelled', 'completed')
+		ORDER BY time_window_start ASC`,
+		map[string]interface{}{
+			"staff_id":   staffID,
+			"visit_date": visitDate,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var schedules []*models.VisitSchedule
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate visit schedules: %w", err)
+		}
+
+		schedule, err := scanVisitSchedule(row)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+// ListStaffDay retrieves all of a staff member's schedules on a date except cancelled ones
+func (r *VisitScheduleRepository) ListStaffDay(ctx context.Context, staffID string, visitDate civil.Date) ([]*models.VisitSchedule, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleColumns+`
+		FROM visit_schedules
+		WHERE assigned_staff_id = @staff_id
+		  AND visit_date = @visit_date
+		  AND status != 'cancelled'
+		ORDER BY time_window_start ASC, created_at ASC`,
+		map[string]interface{}{
+			"staff_id":   staffID,
+			"visit_date": visitDate,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var schedules []*models.VisitSchedule
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate visit schedules: %w", err)
+		}
+
+		schedule, err := scanVisitSchedule(row)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
 // List retrieves visit schedules with filters
 func (r *VisitScheduleRepository) List(ctx context.Context, filter *models.VisitScheduleFilter) ([]*models.VisitSchedule, error) {
 	var conditions []string
@@ -192,13 +295,7 @@ func (r *VisitScheduleRepository) List(ctx context.Context, filter *models.Visit
 	params["limit"] = limit
 	params["offset"] = offset
 
-	stmt := NewStatement(fmt.Sprintf(`SELECT
-			schedule_id, patient_id, visit_date, visit_type,
-			time_window_start, time_window_end, estimated_duration_minutes,
-			assigned_staff_id, assigned_vehicle_id,
-			status, priority_score, constraints::text, optimization_result::text,
-			care_plan_ref, activity_ref,
-			created_at, updated_at
+	stmt := NewStatement(fmt.Sprintf(`SELECT `+visitScheduleColumns+`
 		FROM visit_schedules
 		%s
 		ORDER BY visit_date DESC, created_at DESC
@@ -236,6 +333,22 @@ func (r *VisitScheduleRepository) Update(ctx context.Context, patientID, schedul
 		return nil, err
 	}
 
+	mutation := scheduleUpdateMutation(existing, req, time.Now())
+	if mutation == nil {
+		return existing, nil
+	}
+
+	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update visit schedule: %w", err)
+	}
+
+	return existing, nil
+}
+
+// scheduleUpdateMutation applies req to the schedule and builds the matching update mutation.
+// It returns nil when req changes nothing.
+func scheduleUpdateMutation(existing *models.VisitSchedule, req *models.VisitScheduleUpdateRequest, now time.Time) *spanner.Mutation {
 	// Build update map
 	updates := make(map[string]interface{})
 
@@ -306,29 +419,36 @@ func (r *VisitScheduleRepository) Update(ctx context.Context, patientID, schedul
 	}
 
 	if len(updates) == 0 {
-		return existing, nil
+		return nil
 	}
 
-	updates["updated_at"] = time.Now()
-	existing.UpdatedAt = updates["updated_at"].(time.Time)
+	// Editing an occurrence's details (anything beyond its status) detaches it from
+	// its recurrence rule so series edits leave it alone
+	if existing.RecurrenceID.Valid && !existing.IsRecurrenceException {
+		for col := range updates {
+			if col != "status" && col != "optimization_result" {
+				existing.IsRecurrenceException = true
+				break
+			}
+		}
+		if existing.IsRecurrenceException {
+			updates["is_recurrence_exception"] = true
+		}
+	}
+
+	updates["updated_at"] = now
+	existing.UpdatedAt = now
 
 	// Build column list and values
 	columns := []string{"patient_id", "schedule_id"}
-	values := []interface{}{patientID, scheduleID}
+	values := []interface{}{existing.PatientID, existing.ScheduleID}
 
 	for col, val := range updates {
 		columns = append(columns, col)
 		values = append(values, val)
 	}
 
-	mutation := spanner.Update("visit_schedules", columns, values)
-
-	_, err = r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
-	if err != nil {
-		return nil, fmt.Errorf("failed to update visit schedule: %w", err)
-	}
-
-	return existing, nil
+	return spanner.Update("visit_schedules", columns, values)
 }
 
 // Delete soft deletes a visit schedule (if soft delete is implemented, otherwise hard delete)
@@ -349,13 +469,7 @@ func (r *VisitScheduleRepository) GetUpcomingSchedules(ctx context.Context, pati
 	nowDate := civil.DateOf(now)
 	endDate := civil.DateOf(now.AddDate(0, 0, days))
 
-	stmt := NewStatement(`SELECT
-			schedule_id, patient_id, visit_date, visit_type,
-			time_window_start, time_window_end, estimated_duration_minutes,
-			assigned_staff_id, assigned_vehicle_id,
-			status, priority_score, constraints::text, optimization_result::text,
-			care_plan_ref, activity_ref,
-			created_at, updated_at
+	stmt := NewStatement(`SELECT `+visitScheduleColumns+`
 		FROM visit_schedules
 		WHERE patient_id = @patient_id
 		  AND visit_date >= @now_date
@@ -391,6 +505,632 @@ func (r *VisitScheduleRepository) GetUpcomingSchedules(ctx context.Context, pati
 	return schedules, nil
 }
 
+// CreateOccurrences inserts visits materialized from a recurrence rule
+func (r *VisitScheduleRepository) CreateOccurrences(ctx context.Context, schedules []*models.VisitSchedule) error {
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	_, err := r.spannerRepo.client.Apply(ctx, occurrenceInserts(schedules, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to create recurring visit schedules: %w", err)
+	}
+
+	return nil
+}
+
+// occurrenceInserts assigns IDs and timestamps to materialized visits and builds their inserts
+func occurrenceInserts(schedules []*models.VisitSchedule, now time.Time) []*spanner.Mutation {
+	mutations := make([]*spanner.Mutation, 0, len(schedules))
+	for _, schedule := range schedules {
+		schedule.ScheduleID = uuid.New().String()
+		schedule.CreatedAt = now
+		schedule.UpdatedAt = now
+
+		mutations = append(mutations, visitScheduleInsert(schedule))
+	}
+	return mutations
+}
+
+// visitScheduleInsert builds the insert mutation for a fully populated schedule
+func visitScheduleInsert(schedule *models.VisitSchedule) *spanner.Mutation {
+	return spanner.Insert("visit_schedules",
+		[]string{
+			"schedule_id", "patient_id", "visit_date", "visit_type",
+			"time_window_start", "time_window_end", "estimated_duration_minutes",
+			"assigned_staff_id", "assigned_vehicle_id",
+			"status", "priority_score", "constraints", "optimization_result",
+			"care_plan_ref", "activity_ref",
+			"recurrence_id", "occurrence_date", "is_recurrence_exception",
+			"created_at", "updated_at",
+		},
+		[]interface{}{
+			schedule.ScheduleID, schedule.PatientID, schedule.VisitDate, schedule.VisitType,
+			schedule.TimeWindowStart, schedule.TimeWindowEnd, schedule.EstimatedDurationMinutes,
+			schedule.AssignedStaffID, schedule.AssignedVehicleID,
+			schedule.Status, schedule.PriorityScore, nullJSON(schedule.Constraints), nullJSON(schedule.OptimizationResult),
+			schedule.CarePlanRef, schedule.ActivityRef,
+			schedule.RecurrenceID, schedule.OccurrenceDate, false,
+			schedule.CreatedAt, schedule.UpdatedAt,
+		},
+	)
+}
+
+// InsertEmergencyVisit creates an emergency visit with its status history row, writes the
+// resequenced route to the affected schedules' optimization_result and records the route as
+// a completed, applied job, all in a single commit. The caller assigns ScheduleID and JobID
+// so the route and the resequenced results can reference them.
+func (r *VisitScheduleRepository) InsertEmergencyVisit(
+	ctx context.Context,
+	schedule *models.VisitSchedule,
+	event *models.VisitScheduleStatusEvent,
+	resequenced map[string]json.RawMessage,
+	job *models.RouteOptimizationJob,
+	result *models.RouteOptimizationResult,
+	appliedBy string,
+) error {
+	now := time.Now()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	event.EventID = uuid.New().String()
+	event.CreatedAt = now
+
+	jobInsert, err := routeOptimizationJobInsert(job)
+	if err != nil {
+		return err
+	}
+	jobUpdates, err := completedJobUpdates(result)
+	if err != nil {
+		return err
+	}
+	jobUpdates["applied_to_schedules"] = true
+	jobUpdates["applied_at"] = now
+	jobUpdates["applied_by"] = appliedBy
+	jobUpdates["updated_at"] = now
+
+	jobColumns := []string{"job_id"}
+	jobValues := []interface{}{job.JobID}
+	for col, val := range jobUpdates {
+		jobColumns = append(jobColumns, col)
+		jobValues = append(jobValues, val)
+	}
+
+	mutations := []*spanner.Mutation{
+		visitScheduleInsert(schedule),
+		statusEventInsert(event, now),
+		jobInsert,
+		spanner.Update("route_optimization_jobs", jobColumns, jobValues),
+	}
+	for scheduleID, optimizationResult := range resequenced {
+		mutations = append(mutations, spanner.Update("visit_schedules",
+			[]string{"schedule_id", "optimization_result", "updated_at"},
+			[]interface{}{scheduleID, string(optimizationResult), now},
+		))
+	}
+
+	_, err = r.spannerRepo.client.Apply(ctx, mutations)
+	if err != nil {
+		return fmt.Errorf("failed to insert emergency visit: %w", err)
+	}
+
+	return nil
+}
+
+// ListUpcomingByCarePlan retrieves a care plan's visits on or after a date that have not started
+func (r *VisitScheduleRepository) ListUpcomingByCarePlan(ctx context.Context, planID string, from civil.Date) ([]*models.VisitSchedule, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleColumns+`
+		FROM visit_schedules
+		WHERE care_plan_ref = @plan_id
+		  AND visit_date >= @from_date
+		  AND status IN ('draft', 'optimized', 'assigned')
+		ORDER BY visit_date ASC, time_window_start ASC`,
+		map[string]interface{}{
+			"plan_id":   planID,
+			"from_date": from,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var schedules []*models.VisitSchedule
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate care plan visit schedules: %w", err)
+		}
+
+		schedule, err := scanVisitSchedule(row)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+// ListOccurrences retrieves the visits materialized from a recurrence rule on or after a date
+func (r *VisitScheduleRepository) ListOccurrences(ctx context.Context, recurrenceID string, from civil.Date) ([]*models.VisitSchedule, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleColumns+`
+		FROM visit_schedules
+		WHERE recurrence_id = @recurrence_id
+		  AND occurrence_date >= @from_date
+		ORDER BY occurrence_date ASC`,
+		map[string]interface{}{
+			"recurrence_id": recurrenceID,
+			"from_date":     from,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var schedules []*models.VisitSchedule
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate recurring visit schedules: %w", err)
+		}
+
+		schedule, err := scanVisitSchedule(row)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+// DeleteOccurrences hard deletes materialized visits by ID
+func (r *VisitScheduleRepository) DeleteOccurrences(ctx context.Context, scheduleIDs []string) error {
+	if len(scheduleIDs) == 0 {
+		return nil
+	}
+
+	_, err := r.spannerRepo.client.Apply(ctx, occurrenceDeletes(scheduleIDs))
+	if err != nil {
+		return fmt.Errorf("failed to delete recurring visit schedules: %w", err)
+	}
+
+	return nil
+}
+
+// occurrenceDeletes builds the deletes of materialized visits
+func occurrenceDeletes(scheduleIDs []string) []*spanner.Mutation {
+	mutations := make([]*spanner.Mutation, 0, len(scheduleIDs))
+	for _, id := range scheduleIDs {
+		mutations = append(mutations, spanner.Delete("visit_schedules", spanner.Key{id}))
+	}
+	return mutations
+}
+
+// occurrenceReassigns builds the updates that move materialized visits to another recurrence rule
+func occurrenceReassigns(scheduleIDs []string, recurrenceID string, now time.Time) []*spanner.Mutation {
+	mutations := make([]*spanner.Mutation, 0, len(scheduleIDs))
+	for _, id := range scheduleIDs {
+		mutations = append(mutations, spanner.Update("visit_schedules",
+			[]string{"schedule_id", "recurrence_id", "updated_at"},
+			[]interface{}{id, recurrenceID, now},
+		))
+	}
+	return mutations
+}
+
+// StatusChangedError is returned when a visit's stored status no longer matches the status a
+// transition was validated against, because another request changed it in the meantime
+type StatusChangedError struct {
+	ScheduleID string
+	Expected   string
+	Actual     string
+}
+
+func (e *StatusChangedError) Error() string {
+	return fmt.Sprintf("invalid status transition: visit schedule %s is now %s, not %s", e.ScheduleID, e.Actual, e.Expected)
+}
+
+// ScheduleChange is one visit's update committed by CommitChanges. The caller applies the
+// new status and transition timestamps to Schedule beforehand.
+type ScheduleChange struct {
+	Schedule *models.VisitSchedule
+	// Event records a status transition; nil when the status does not change
+	Event *models.VisitScheduleStatusEvent
+	// Update holds other field changes; its Status must be nil (status moves through Event)
+	Update *models.VisitScheduleUpdateRequest
+	// ExpectedStatus, when set on a change without an Event, is the status the visit must
+	// still have for Update to be written
+	ExpectedStatus string
+}
+
+// TransitionStatus writes a status change together with its history event.
+// The caller sets the schedule's new status and transition timestamps beforehand.
+func (r *VisitScheduleRepository) TransitionStatus(ctx context.Context, schedule *models.VisitSchedule, event *models.VisitScheduleStatusEvent) error {
+	return r.commitChanges(ctx, []ScheduleChange{{Schedule: schedule, Event: event}}, nil, "failed to update visit schedule status")
+}
+
+// CommitChanges writes field updates and status transitions of several visits in one
+// read-write transaction, so they are applied together or not at all. A transition fails
+// with StatusChangedError when its visit's stored status is no longer the event's FromStatus,
+// and an update likewise when it is no longer the change's ExpectedStatus.
+func (r *VisitScheduleRepository) CommitChanges(ctx context.Context, changes []ScheduleChange) error {
+	return r.commitChanges(ctx, changes, nil, "failed to update visit schedules")
+}
+
+// commitChanges commits changes plus any extra mutations, checking each transition's
+// starting status inside the transaction
+func (r *VisitScheduleRepository) commitChanges(ctx context.Context, changes []ScheduleChange, extra []*spanner.Mutation, failure string) error {
+	now := time.Now()
+
+	var mutations []*spanner.Mutation
+	expected := make(map[string]string) // schedule ID -> status it must still have
+	for _, change := range changes {
+		if change.Update != nil {
+			if mutation := scheduleUpdateMutation(change.Schedule, change.Update, now); mutation != nil {
+				mutations = append(mutations, mutation)
+			}
+		}
+		if change.Event != nil {
+			mutations = append(mutations, statusTransitionMutations(change.Schedule, change.Event, now)...)
+			expected[change.Schedule.ScheduleID] = change.Event.FromStatus
+		} else if change.ExpectedStatus != "" {
+			expected[change.Schedule.ScheduleID] = change.ExpectedStatus
+		}
+	}
+	mutations = append(mutations, extra...)
+	if len(mutations) == 0 {
+		return nil
+	}
+
+	_, err := r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		for scheduleID, status := range expected {
+			current, err := readScheduleStatus(ctx, txn, scheduleID)
+			if err != nil {
+				return err
+			}
+			if current != status {
+				return &StatusChangedError{
+					ScheduleID: scheduleID,
+					Expected:   status,
+					Actual:     current,
+				}
+			}
+		}
+		return txn.BufferWrite(mutations)
+	})
+	if err != nil {
+		var changed *StatusChangedError
+		if errors.As(err, &changed) {
+			return changed
+		}
+		return fmt.Errorf("%s: %w", failure, err)
+	}
+
+	return nil
+}
+
+// readScheduleStatus reads a visit's current status inside a transaction
+func readScheduleStatus(ctx context.Context, txn *spanner.ReadWriteTransaction, scheduleID string) (string, error) {
+	stmt := NewStatement(`SELECT status FROM visit_schedules WHERE schedule_id = @schedule_id`,
+		map[string]interface{}{
+			"schedule_id": scheduleID,
+		})
+
+	iter := txn.Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return "", fmt.Errorf("visit schedule not found")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var status string
+	if err := row.Columns(&status); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// RecordCheck writes a check-in or check-out with the status change it causes, in one commit.
+// The caller sets the schedule's status, transition timestamps and verification flag beforehand.
+func (r *VisitScheduleRepository) RecordCheck(ctx context.Context, schedule *models.VisitSchedule, event *models.VisitScheduleStatusEvent, check *models.VisitCheck) error {
+	now := time.Now()
+	check.CheckID = uuid.New().String()
+	check.CreatedAt = now
+
+	extra := []*spanner.Mutation{
+		spanner.Update("visit_schedules",
+			[]string{"schedule_id", "check_in_verified", "check_out_verified"},
+			[]interface{}{schedule.ScheduleID, schedule.CheckInVerified, schedule.CheckOutVerified},
+		),
+		spanner.Insert("visit_checks",
+			[]string{
+				"check_id", "schedule_id", "patient_id", "staff_id", "check_type",
+				"latitude", "longitude", "accuracy_meters", "recorded_at",
+				"patient_latitude", "patient_longitude", "distance_meters", "radius_meters",
+				"verified", "failure_reason", "created_at",
+			},
+			[]interface{}{
+				check.CheckID, check.ScheduleID, check.PatientID, check.StaffID, check.CheckType,
+				check.Latitude, check.Longitude, nullFloat64(check.AccuracyMeters), check.RecordedAt,
+				nullFloat64(check.PatientLatitude), nullFloat64(check.PatientLongitude), nullFloat64(check.DistanceMeters), check.RadiusMeters,
+				check.Verified, nullString(check.FailureReason), now,
+			},
+		),
+	}
+
+	return r.commitChanges(ctx, []ScheduleChange{{Schedule: schedule, Event: event}}, extra, "failed to record visit "+check.CheckType)
+}
+
+// statusTransitionMutations builds the schedule update and history insert for a status change
+func statusTransitionMutations(schedule *models.VisitSchedule, event *models.VisitScheduleStatusEvent, now time.Time) []*spanner.Mutation {
+	event.EventID = uuid.New().String()
+	event.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	update := spanner.Update("visit_schedules",
+		[]string{
+			"schedule_id", "status",
+			"actual_arrival_at", "actual_departure_at",
+			"cancelled_at", "cancelled_by", "cancellation_reason",
+			"updated_at",
+		},
+		[]interface{}{
+			schedule.ScheduleID, schedule.Status,
+			schedule.ActualArrivalAt, schedule.ActualDepartureAt,
+			schedule.CancelledAt, schedule.CancelledBy, schedule.CancellationReason,
+			now,
+		},
+	)
+
+	return []*spanner.Mutation{update, statusEventInsert(event, now)}
+}
+
+// statusEventInsert builds the history insert for a status change
+func statusEventInsert(event *models.VisitScheduleStatusEvent, now time.Time) *spanner.Mutation {
+	return spanner.Insert("visit_schedule_status_events",
+		[]string{
+			"event_id", "schedule_id", "patient_id",
+			"from_status", "to_status", "reason",
+			"occurred_at", "changed_by", "created_at",
+		},
+		[]interface{}{
+			event.EventID, event.ScheduleID, event.PatientID,
+			event.FromStatus, event.ToStatus, nullString(event.Reason),
+			event.OccurredAt, event.ChangedBy, now,
+		},
+	)
+}
+
+// ListChecks retrieves a visit's check-ins and check-outs, oldest first
+func (r *VisitScheduleRepository) ListChecks(ctx context.Context, scheduleID string) ([]*models.VisitCheck, error) {
+	stmt := NewStatement(`SELECT `+visitCheckColumns+`
+		FROM visit_checks
+		WHERE schedule_id = @schedule_id
+		ORDER BY recorded_at ASC, created_at ASC`,
+		map[string]interface{}{
+			"schedule_id": scheduleID,
+		})
+
+	return r.queryVisitChecks(ctx, stmt)
+}
+
+// ListChecksForSchedules retrieves the checks of several visits, oldest first
+func (r *VisitScheduleRepository) ListChecksForSchedules(ctx context.Context, scheduleIDs []string) ([]*models.VisitCheck, error) {
+	if len(scheduleIDs) == 0 {
+		return nil, nil
+	}
+
+	stmt := NewStatement(`SELECT `+visitCheckColumns+`
+		FROM visit_checks
+		WHERE schedule_id = ANY(@schedule_ids)
+		ORDER BY recorded_at ASC, created_at ASC`,
+		map[string]interface{}{
+			"schedule_ids": scheduleIDs,
+		})
+
+	return r.queryVisitChecks(ctx, stmt)
+}
+
+// ListUnverified retrieves completed visits in a date range whose check-in or check-out
+// is missing or failed geofence verification
+func (r *VisitScheduleRepository) ListUnverified(ctx context.Context, from, to civil.Date) ([]*models.VisitSchedule, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleColumns+`
+		FROM visit_schedules
+		WHERE status = 'completed'
+		  AND visit_date >= @from_date
+		  AND visit_date <= @to_date
+		  AND (check_in_verified IS NULL OR check_in_verified = false
+		       OR check_out_verified IS NULL OR check_out_verified = false)
+		ORDER BY visit_date ASC, actual_arrival_at ASC`,
+		map[string]interface{}{
+			"from_date": from,
+			"to_date":   to,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var schedules []*models.VisitSchedule
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate visit schedules: %w", err)
+		}
+
+		schedule, err := scanVisitSchedule(row)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+const visitCheckColumns = `check_id, schedule_id, patient_id, staff_id, check_type,
+			latitude, longitude, accuracy_meters, recorded_at,
+			patient_latitude, patient_longitude, distance_meters, radius_meters,
+			verified, failure_reason, created_at`
+
+// queryVisitChecks runs a visit check query and scans all rows
+func (r *VisitScheduleRepository) queryVisitChecks(ctx context.Context, stmt spanner.Statement) ([]*models.VisitCheck, error) {
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var checks []*models.VisitCheck
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate visit checks: %w", err)
+		}
+
+		var check models.VisitCheck
+		var accuracy, patientLat, patientLng, distance spanner.NullFloat64
+		var failureReason spanner.NullString
+		if err := row.Columns(
+			&check.CheckID,
+			&check.ScheduleID,
+			&check.PatientID,
+			&check.StaffID,
+			&check.CheckType,
+			&check.Latitude,
+			&check.Longitude,
+			&accuracy,
+			&check.RecordedAt,
+			&patientLat,
+			&patientLng,
+			&distance,
+			&check.RadiusMeters,
+			&check.Verified,
+			&failureReason,
+			&check.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan visit check: %w", err)
+		}
+		check.AccuracyMeters = float64PtrFromNull(accuracy)
+		check.PatientLatitude = float64PtrFromNull(patientLat)
+		check.PatientLongitude = float64PtrFromNull(patientLng)
+		check.DistanceMeters = float64PtrFromNull(distance)
+		check.FailureReason = stringPtrFromNull(failureReason)
+		checks = append(checks, &check)
+	}
+
+	return checks, nil
+}
+
+// UpdateETA writes the live arrival estimate and running-late flag of a visit
+func (r *VisitScheduleRepository) UpdateETA(ctx context.Context, scheduleID string, estimatedArrival time.Time, runningLate bool) error {
+	now := time.Now()
+	mutation := spanner.Update("visit_schedules",
+		[]string{"schedule_id", "estimated_arrival_at", "running_late", "eta_updated_at"},
+		[]interface{}{scheduleID, estimatedArrival, runningLate, now},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to update visit ETA: %w", err)
+	}
+
+	return nil
+}
+
+// ListRunningLate retrieves the visits on a date that have not started and are flagged as running late
+func (r *VisitScheduleRepository) ListRunningLate(ctx context.Context, visitDate civil.Date) ([]*models.VisitSchedule, error) {
+	stmt := NewStatement(`SELECT `+visitScheduleColumns+`
+		FROM visit_schedules
+		WHERE visit_date = @visit_date
+		  AND running_late = true
+		  AND status IN ('draft', 'optimized', 'assigned')
+		ORDER BY estimated_arrival_at ASC`,
+		map[string]interface{}{
+			"visit_date": visitDate,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var schedules []*models.VisitSchedule
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate visit schedules: %w", err)
+		}
+
+		schedule, err := scanVisitSchedule(row)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, nil
+}
+
+// ListStatusEvents retrieves a visit schedule's status history, oldest first
+func (r *VisitScheduleRepository) ListStatusEvents(ctx context.Context, scheduleID string) ([]*models.VisitScheduleStatusEvent, error) {
+	stmt := NewStatement(`SELECT
+			event_id, schedule_id, patient_id,
+			from_status, to_status, reason,
+			occurred_at, changed_by, created_at
+		FROM visit_schedule_status_events
+		WHERE schedule_id = @schedule_id
+		ORDER BY occurred_at ASC, created_at ASC`,
+		map[string]interface{}{
+			"schedule_id": scheduleID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var events []*models.VisitScheduleStatusEvent
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate visit schedule status events: %w", err)
+		}
+
+		var event models.VisitScheduleStatusEvent
+		var reason spanner.NullString
+		if err := row.Columns(
+			&event.EventID,
+			&event.ScheduleID,
+			&event.PatientID,
+			&event.FromStatus,
+			&event.ToStatus,
+			&reason,
+			&event.OccurredAt,
+			&event.ChangedBy,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan visit schedule status event: %w", err)
+		}
+		event.Reason = stringPtrFromNull(reason)
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
 // scanVisitSchedule scans a Spanner row into a VisitSchedule model
 func scanVisitSchedule(row *spanner.Row) (*models.VisitSchedule, error) {
 	var schedule models.VisitSchedule
@@ -412,6 +1152,19 @@ func scanVisitSchedule(row *spanner.Row) (*models.VisitSchedule, error) {
 		&optimizationResultStr,
 		&schedule.CarePlanRef,
 		&schedule.ActivityRef,
+		&schedule.RecurrenceID,
+		&schedule.OccurrenceDate,
+		&schedule.IsRecurrenceException,
+		&schedule.ActualArrivalAt,
+		&schedule.ActualDepartureAt,
+		&schedule.CancelledAt,
+		&schedule.CancelledBy,
+		&schedule.CancellationReason,
+		&schedule.EstimatedArrivalAt,
+		&schedule.RunningLate,
+		&schedule.ETAUpdatedAt,
+		&schedule.CheckInVerified,
+		&schedule.CheckOutVerified,
 		&schedule.CreatedAt,
 		&schedule.UpdatedAt,
 	)
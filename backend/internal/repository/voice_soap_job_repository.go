@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/visitas/backend/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// ErrVoiceSOAPJobClosed is returned when a status write targets a job that has already
+// completed or failed
+var ErrVoiceSOAPJobClosed = errors.New("voice SOAP job has already finished")
+
+// VoiceSOAPJobRepository handles voice-to-SOAP draft jobs
+type VoiceSOAPJobRepository struct {
+	spannerRepo *SpannerRepository
+}
+
+// NewVoiceSOAPJobRepository creates a new voice SOAP job repository
+func NewVoiceSOAPJobRepository(spannerRepo *SpannerRepository) *VoiceSOAPJobRepository {
+	return &VoiceSOAPJobRepository{
+		spannerRepo: spannerRepo,
+	}
+}
+
+const voiceSOAPJobColumns = `job_id, patient_id, visit_started_at, visit_type, schedule_id, language,
+			job_status, attempts,
+			audio_content_type, audio_size_bytes, audio_blob_key, audio_wrapped_key,
+			transcript, transcriber_model, structurer_model, record_id, error_message,
+			created_at, created_by, updated_at, started_at, completed_at`
+
+// Create inserts a pending job. The caller assigns the job ID, since it is part of the
+// audio blob key and its encryption AAD; CreatedAt and UpdatedAt are filled in.
+func (r *VoiceSOAPJobRepository) Create(ctx context.Context, job *models.VoiceSOAPJob) error {
+	now := time.Now()
+	job.JobStatus = "pending"
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	mutation := spanner.Insert("voice_soap_jobs",
+		[]string{
+			"job_id", "patient_id", "visit_started_at", "visit_type", "schedule_id", "language",
+			"job_status", "attempts",
+			"audio_content_type", "audio_size_bytes", "audio_blob_key", "audio_wrapped_key",
+			"created_at", "created_by", "updated_at",
+		},
+		[]interface{}{
+			job.JobID, job.PatientID, job.VisitStartedAt, job.VisitType, nullString(job.ScheduleID), job.Language,
+			job.JobStatus, int64(0),
+			job.AudioContentType, job.AudioSizeBytes, job.AudioBlobKey, job.AudioWrappedKey,
+			now, job.CreatedBy, now,
+		},
+	)
+
+	_, err := r.spannerRepo.client.Apply(ctx, []*spanner.Mutation{mutation})
+	if err != nil {
+		return fmt.Errorf("failed to create voice SOAP job: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a voice SOAP job by ID
+func (r *VoiceSOAPJobRepository) GetByID(ctx context.Context, jobID string) (*models.VoiceSOAPJob, error) {
+	stmt := NewStatement(`SELECT `+voiceSOAPJobColumns+`
+		FROM voice_soap_jobs
+		WHERE job_id = @job_id`,
+		map[string]interface{}{
+			"job_id": jobID,
+		})
+
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	row, err := iter.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("voice SOAP job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query voice SOAP job: %w", err)
+	}
+
+	return scanVoiceSOAPJob(row)
+}
+
+// ListByPatient lists a patient's voice SOAP jobs, newest first
+func (r *VoiceSOAPJobRepository) ListByPatient(ctx context.Context, patientID string, limit int) ([]*models.VoiceSOAPJob, error) {
+	stmt := NewStatement(`SELECT `+voiceSOAPJobColumns+`
+		FROM voice_soap_jobs
+		WHERE patient_id = @patient_id
+		ORDER BY created_at DESC
+		LIMIT @limit`,
+		map[string]interface{}{
+			"patient_id": patientID,
+			"limit":      int64(limit),
+		})
+
+	return r.query(ctx, stmt)
+}
+
+// ListStale retrieves pending or processing jobs not updated since the given time
+func (r *VoiceSOAPJobRepository) ListStale(ctx context.Context, updatedBefore time.Time) ([]*models.VoiceSOAPJob, error) {
+	stmt := NewStatement(`SELECT `+voiceSOAPJobColumns+`
+		FROM voice_soap_jobs
+		WHERE job_status IN ('pending', 'processing')
+		  AND updated_at < @updated_before
+		ORDER BY created_at ASC`,
+		map[string]interface{}{
+			"updated_before": updatedBefore,
+		})
+
+	return r.query(ctx, stmt)
+}
+
+// MarkProcessing records the start of an attempt
+func (r *VoiceSOAPJobRepository) MarkProcessing(ctx context.Context, jobID string, attempt int64) error {
+	return r.transition(ctx, jobID, map[string]interface{}{
+		"job_status": "processing",
+		"attempts":   attempt,
+		"started_at": time.Now(),
+	})
+}
+
+// MarkCompleted stores the transcript and the draft record created from it
+func (r *VoiceSOAPJobRepository) MarkCompleted(ctx context.Context, jobID, recordID, transcript, transcriberModel, structurerModel string) error {
+	return r.transition(ctx, jobID, map[string]interface{}{
+		"job_status":        "completed",
+		"record_id":         recordID,
+		"transcript":        transcript,
+		"transcriber_model": transcriberModel,
+		"structurer_model":  structurerModel,
+		"error_message":     spanner.NullString{},
+		"completed_at":      time.Now(),
+	})
+}
+
+// MarkFailed records why the job failed
+func (r *VoiceSOAPJobRepository) MarkFailed(ctx context.Context, jobID, errorMessage string) error {
+	return r.transition(ctx, jobID, map[string]interface{}{
+		"job_status":    "failed",
+		"error_message": errorMessage,
+		"completed_at":  time.Now(),
+	})
+}
+
+// transition writes the given column updates to a job that has not finished yet
+func (r *VoiceSOAPJobRepository) transition(ctx context.Context, jobID string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+
+	columns := []string{"job_id"}
+	values := []interface{}{jobID}
+	for col, val := range updates {
+		columns = append(columns, col)
+		values = append(values, val)
+	}
+
+	mutation := spanner.Update("voice_soap_jobs", columns, values)
+
+	_, err := r.spannerRepo.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		stmt := NewStatement(`SELECT job_status FROM voice_soap_jobs WHERE job_id = @job_id`,
+			map[string]interface{}{
+				"job_id": jobID,
+			})
+
+		iter := txn.Query(ctx, stmt)
+		defer iter.Stop()
+
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return fmt.Errorf("voice SOAP job not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read voice SOAP job status: %w", err)
+		}
+
+		var status string
+		if err := row.Columns(&status); err != nil {
+			return fmt.Errorf("failed to parse voice SOAP job status: %w", err)
+		}
+		if status != "pending" && status != "processing" {
+			return ErrVoiceSOAPJobClosed
+		}
+		return txn.BufferWrite([]*spanner.Mutation{mutation})
+	})
+	if err != nil {
+		if errors.Is(err, ErrVoiceSOAPJobClosed) {
+			return ErrVoiceSOAPJobClosed
+		}
+		return fmt.Errorf("failed to update voice SOAP job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *VoiceSOAPJobRepository) query(ctx context.Context, stmt spanner.Statement) ([]*models.VoiceSOAPJob, error) {
+	iter := r.spannerRepo.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	jobs := []*models.VoiceSOAPJob{}
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate voice SOAP jobs: %w", err)
+		}
+
+		job, err := scanVoiceSOAPJob(row)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// scanVoiceSOAPJob scans a Spanner row into a VoiceSOAPJob model
+func scanVoiceSOAPJob(row *spanner.Row) (*models.VoiceSOAPJob, error) {
+	var job models.VoiceSOAPJob
+	var scheduleID, transcript, transcriberModel, structurerModel, recordID, errorMessage spanner.NullString
+	var startedAt, completedAt spanner.NullTime
+
+	err := row.Columns(
+		&job.JobID,
+		&job.PatientID,
+		&job.VisitStartedAt,
+		&job.VisitType,
+		&scheduleID,
+		&job.Language,
+		&job.JobStatus,
+		&job.Attempts,
+		&job.AudioContentType,
+		&job.AudioSizeBytes,
+		&job.AudioBlobKey,
+		&job.AudioWrappedKey,
+		&transcript,
+		&transcriberModel,
+		&structurerModel,
+		&recordID,
+		&errorMessage,
+		&job.CreatedAt,
+		&job.CreatedBy,
+		&job.UpdatedAt,
+		&startedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan voice SOAP job: %w", err)
+	}
+
+	job.ScheduleID = stringPtrFromNull(scheduleID)
+	job.Transcript = stringPtrFromNull(transcript)
+	job.TranscriberModel = stringPtrFromNull(transcriberModel)
+	job.StructurerModel = stringPtrFromNull(structurerModel)
+	job.RecordID = stringPtrFromNull(recordID)
+	job.ErrorMessage = stringPtrFromNull(errorMessage)
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+
+	return &job, nil
+}
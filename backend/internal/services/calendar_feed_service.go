@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/ical"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// CalendarFeedPathPrefix is where signed feed URLs are served (outside the authenticated API)
+const CalendarFeedPathPrefix = "/calendar/"
+
+// Feeds cover recent history plus the upcoming quarter
+const (
+	calendarFeedPastDays   = 30
+	calendarFeedFutureDays = 90
+	calendarFeedMaxVisits  = 1000
+)
+
+var validCalendarFeedTypes = map[string]bool{
+	"staff":   true,
+	"patient": true,
+}
+
+// CalendarFeedService issues, revokes and renders iCalendar feeds of visit schedules.
+// Feed URLs carry the token ID and an HMAC signature over the token row, so a URL
+// cannot be forged or retargeted, and revoking the row disables it.
+type CalendarFeedService struct {
+	feedTokenRepo     *repository.CalendarFeedTokenRepository
+	visitScheduleRepo *repository.VisitScheduleRepository
+	patientRepo       *repository.PatientRepository
+	signingKey        []byte
+}
+
+// NewCalendarFeedService creates a new calendar feed service.
+// Feeds are reported as not available when signingKey is empty.
+func NewCalendarFeedService(
+	feedTokenRepo *repository.CalendarFeedTokenRepository,
+	visitScheduleRepo *repository.VisitScheduleRepository,
+	patientRepo *repository.PatientRepository,
+	signingKey string,
+) *CalendarFeedService {
+	return &CalendarFeedService{
+		feedTokenRepo:     feedTokenRepo,
+		visitScheduleRepo: visitScheduleRepo,
+		patientRepo:       patientRepo,
+		signingKey:        []byte(signingKey),
+	}
+}
+
+// CreateFeed issues a feed URL for the caller's own visits or for a patient's visits
+func (s *CalendarFeedService) CreateFeed(ctx context.Context, req *models.CalendarFeedTokenCreateRequest, createdBy string) (*models.CalendarFeedToken, error) {
+	if len(s.signingKey) == 0 {
+		return nil, fmt.Errorf("calendar feeds not available: no signing key configured")
+	}
+	if !validCalendarFeedTypes[req.FeedType] {
+		return nil, fmt.Errorf("invalid feed_type: %s", req.FeedType)
+	}
+
+	subjectID := createdBy
+	if req.FeedType == "patient" {
+		if req.PatientID == nil || *req.PatientID == "" {
+			return nil, fmt.Errorf("patient_id is required for patient feeds")
+		}
+		hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, createdBy, *req.PatientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check access: %w", err)
+		}
+		if !hasAccess {
+			logger.WarnContext(ctx, "Unauthorized calendar feed creation attempt", map[string]interface{}{
+				"patient_id": *req.PatientID,
+				"created_by": createdBy,
+			})
+			return nil, fmt.Errorf("access denied: you do not have permission to view this patient's schedules")
+		}
+		subjectID = *req.PatientID
+	}
+
+	token, err := s.feedTokenRepo.Create(ctx, req.FeedType, subjectID, req.Label, createdBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create calendar feed token", err, map[string]interface{}{
+			"feed_type":  req.FeedType,
+			"created_by": createdBy,
+		})
+		return nil, err
+	}
+	token.FeedPath = s.feedPath(token)
+
+	logger.InfoContext(ctx, "Calendar feed created", map[string]interface{}{
+		"token_id":   token.TokenID,
+		"feed_type":  token.FeedType,
+		"created_by": createdBy,
+	})
+
+	return token, nil
+}
+
+// ListFeeds returns the caller's active feed URLs
+func (s *CalendarFeedService) ListFeeds(ctx context.Context, userID string) ([]*models.CalendarFeedToken, error) {
+	tokens, err := s.feedTokenRepo.ListByCreator(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		token.FeedPath = s.feedPath(token)
+	}
+	return tokens, nil
+}
+
+// RevokeFeed disables a feed URL. Only its creator, or the staff member a staff feed belongs to, may revoke it.
+func (s *CalendarFeedService) RevokeFeed(ctx context.Context, tokenID, userID string) error {
+	token, err := s.feedTokenRepo.GetByID(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+
+	if token.CreatedBy != userID && !(token.FeedType == "staff" && token.SubjectID == userID) {
+		return fmt.Errorf("access denied: you cannot revoke this calendar feed")
+	}
+	if token.Revoked {
+		return nil
+	}
+
+	if err := s.feedTokenRepo.Revoke(ctx, tokenID, userID); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Calendar feed revoked", map[string]interface{}{
+		"token_id":   tokenID,
+		"revoked_by": userID,
+	})
+
+	return nil
+}
+
+// RenderFeed verifies a signed feed token and builds its calendar. Invalid, revoked
+// and no-longer-authorized tokens are all reported as not found.
+func (s *CalendarFeedService) RenderFeed(ctx context.Context, signedToken string) (*ical.Calendar, error) {
+	if len(s.signingKey) == 0 {
+		return nil, fmt.Errorf("calendar feeds not available: no signing key configured")
+	}
+
+	tokenID, signature, ok := strings.Cut(signedToken, ".")
+	if !ok {
+		return nil, fmt.Errorf("calendar feed not found")
+	}
+
+	token, err := s.feedTokenRepo.GetByID(ctx, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("calendar feed not found")
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(token))) || token.Revoked {
+		logger.WarnContext(ctx, "Rejected calendar feed request", map[string]interface{}{
+			"token_id": tokenID,
+			"revoked":  token.Revoked,
+		})
+		return nil, fmt.Errorf("calendar feed not found")
+	}
+
+	today := time.Now().In(clinicTimeZone)
+	from := today.AddDate(0, 0, -calendarFeedPastDays)
+	to := today.AddDate(0, 0, calendarFeedFutureDays)
+	filter := &models.VisitScheduleFilter{
+		VisitDateFrom: &from,
+		VisitDateTo:   &to,
+		Limit:         calendarFeedMaxVisits,
+	}
+
+	calendar := &ical.Calendar{ProductID: "-//Visitas//Visit Schedule//JA"}
+	switch token.FeedType {
+	case "staff":
+		filter.AssignedStaffID = &token.SubjectID
+		calendar.Name = "Visitas: my visits"
+	case "patient":
+		// The creator must still have access to the patient for the feed to keep working
+		hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, token.CreatedBy, token.SubjectID)
+		if err != nil || !hasAccess {
+			return nil, fmt.Errorf("calendar feed not found")
+		}
+		filter.PatientID = &token.SubjectID
+		calendar.Name = "Visitas: patient visits"
+	}
+
+	schedules, err := s.visitScheduleRepo.List(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list visits for calendar feed", err, map[string]interface{}{
+			"token_id": tokenID,
+		})
+		return nil, fmt.Errorf("failed to build calendar feed: %w", err)
+	}
+
+	for _, schedule := range schedules {
+		calendar.Events = append(calendar.Events, visitEvent(schedule))
+	}
+
+	if err := s.feedTokenRepo.TouchAccessed(ctx, tokenID); err != nil {
+		logger.WarnContext(ctx, "Failed to record calendar feed access", map[string]interface{}{
+			"token_id": tokenID,
+			"error":    err.Error(),
+		})
+	}
+
+	return calendar, nil
+}
+
+func (s *CalendarFeedService) feedPath(token *models.CalendarFeedToken) string {
+	return CalendarFeedPathPrefix + token.TokenID + "." + s.sign(token) + ".ics"
+}
+
+// sign binds the signature to the feed's target so a token row cannot be pointed elsewhere
+func (s *CalendarFeedService) sign(token *models.CalendarFeedToken) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(token.TokenID + "|" + token.FeedType + "|" + token.SubjectID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// visitEvent maps a visit to a calendar event. Titles carry no patient details;
+// the UID is the schedule ID and SEQUENCE grows with updated_at, so subscribed
+// calendars update the event in place when the visit changes.
+func visitEvent(schedule *models.VisitSchedule) ical.Event {
+	event := ical.Event{
+		UID:          schedule.ScheduleID + "@visitas",
+		Sequence:     int64(schedule.UpdatedAt.Sub(schedule.CreatedAt) / time.Second),
+		Stamp:        schedule.UpdatedAt,
+		LastModified: schedule.UpdatedAt,
+		Summary:      fmt.Sprintf("Home visit (%s)", schedule.VisitType),
+		Description:  "Open Visitas for patient details. Schedule ID: " + schedule.ScheduleID,
+		Status:       "CONFIRMED",
+	}
+
+	switch schedule.Status {
+	case "cancelled":
+		event.Status = "CANCELLED"
+	case "draft", "optimized":
+		event.Status = "TENTATIVE"
+	}
+
+	var optimized models.ScheduleOptimizationResult
+	switch {
+	case len(schedule.OptimizationResult) > 0 && json.Unmarshal(schedule.OptimizationResult, &optimized) == nil && !optimized.StartTime.IsZero():
+		event.Start = optimized.StartTime
+		event.End = optimized.DepartureTime
+	case schedule.TimeWindowStart.Valid:
+		event.Start = schedule.TimeWindowStart.Time
+		event.End = event.Start.Add(time.Duration(schedule.EstimatedDurationMinutes) * time.Minute)
+	default:
+		date := schedule.VisitDate
+		event.Date = &date
+	}
+
+	return event
+}
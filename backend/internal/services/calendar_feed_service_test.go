@@ -0,0 +1,71 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/visitas/backend/internal/models"
+)
+
+func TestVisitEvent(t *testing.T) {
+	created := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 4, 10, 9, 30, 0, 0, clinicTimeZone)
+
+	schedule := &models.VisitSchedule{
+		ScheduleID:               "s-1",
+		VisitDate:                civil.Date{Year: 2025, Month: 4, Day: 10},
+		VisitType:                "regular",
+		TimeWindowStart:          spanner.NullTime{Time: start, Valid: true},
+		EstimatedDurationMinutes: 45,
+		Status:                   "assigned",
+		CreatedAt:                created,
+		UpdatedAt:                created,
+	}
+
+	event := visitEvent(schedule)
+	assert.Equal(t, "s-1@visitas", event.UID)
+	assert.Equal(t, int64(0), event.Sequence)
+	assert.Equal(t, start, event.Start)
+	assert.Equal(t, start.Add(45*time.Minute), event.End)
+	assert.Equal(t, "CONFIRMED", event.Status)
+	assert.Equal(t, "Home visit (regular)", event.Summary)
+
+	// Updating the visit keeps the UID and raises the sequence
+	schedule.UpdatedAt = created.Add(time.Hour)
+	schedule.Status = "cancelled"
+	updated := visitEvent(schedule)
+	assert.Equal(t, event.UID, updated.UID)
+	assert.Greater(t, updated.Sequence, event.Sequence)
+	assert.Equal(t, "CANCELLED", updated.Status)
+
+	// Optimized routes take precedence over the requested window
+	schedule.OptimizationResult = []byte(`{"job_id":"j","sequence":1,"start_time":"2025-04-10T01:00:00Z","departure_time":"2025-04-10T01:40:00Z"}`)
+	assert.Equal(t, time.Date(2025, 4, 10, 1, 0, 0, 0, time.UTC), visitEvent(schedule).Start.UTC())
+
+	// Visits without a time are all-day events
+	unscheduled := &models.VisitSchedule{ScheduleID: "s-2", VisitDate: civil.Date{Year: 2025, Month: 4, Day: 11}, Status: "draft"}
+	allDay := visitEvent(unscheduled)
+	if assert.NotNil(t, allDay.Date) {
+		assert.Equal(t, unscheduled.VisitDate, *allDay.Date)
+	}
+	assert.Equal(t, "TENTATIVE", allDay.Status)
+}
+
+func TestCalendarFeedSignature(t *testing.T) {
+	s := &CalendarFeedService{signingKey: []byte("test-key")}
+	token := &models.CalendarFeedToken{TokenID: "t-1", FeedType: "staff", SubjectID: "staff-1"}
+
+	path := s.feedPath(token)
+	assert.True(t, strings.HasPrefix(path, CalendarFeedPathPrefix+"t-1."))
+	assert.True(t, strings.HasSuffix(path, ".ics"))
+
+	// The signature is bound to the feed's target and the key
+	retargeted := *token
+	retargeted.SubjectID = "staff-2"
+	assert.NotEqual(t, s.sign(token), s.sign(&retargeted))
+	assert.NotEqual(t, s.sign(token), (&CalendarFeedService{signingKey: []byte("other")}).sign(token))
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// carePlanVisitPriority is the priority score of visits generated from care plan activities
+const carePlanVisitPriority = 5
+
+// carePlanResyncActor is recorded as the author of changes made by the periodic resync
+const carePlanResyncActor = "system"
+
+// CarePlanScheduleService keeps the visits generated from care plan activities in step with
+// the plan: one recurrence rule per scheduled activity while the plan is active, and no
+// upcoming visits once the plan is revoked or completed
+type CarePlanScheduleService struct {
+	recurrenceService *VisitScheduleRecurrenceService
+	recurrenceRepo    *repository.VisitScheduleRecurrenceRepository
+	visitScheduleRepo *repository.VisitScheduleRepository
+}
+
+// NewCarePlanScheduleService creates a new care plan schedule service
+func NewCarePlanScheduleService(
+	recurrenceService *VisitScheduleRecurrenceService,
+	recurrenceRepo *repository.VisitScheduleRecurrenceRepository,
+	visitScheduleRepo *repository.VisitScheduleRepository,
+) *CarePlanScheduleService {
+	return &CarePlanScheduleService{
+		recurrenceService: recurrenceService,
+		recurrenceRepo:    recurrenceRepo,
+		visitScheduleRepo: visitScheduleRepo,
+	}
+}
+
+// Sync brings a plan's visit schedules in line with its status and activities. An active
+// plan gets a rule for every activity with a frequency; rules of changed activities are
+// replaced and rules of removed activities end with their upcoming visits cancelled. A
+// revoked or completed plan has its rules ended and its upcoming visits cancelled. Draft
+// and on-hold plans are left as they are. Sync is idempotent.
+func (s *CarePlanScheduleService) Sync(ctx context.Context, plan *models.CarePlan, updatedBy string) error {
+	switch plan.Status {
+	case "active":
+		return s.scheduleActivities(ctx, plan, updatedBy)
+	case "revoked", "completed":
+		return s.stopPlan(ctx, plan, "care plan "+plan.Status, updatedBy)
+	default:
+		return nil
+	}
+}
+
+// scheduleActivities creates, replaces and ends the rules of an active plan's activities
+func (s *CarePlanScheduleService) scheduleActivities(ctx context.Context, plan *models.CarePlan, updatedBy string) error {
+	activities, err := models.ParseCarePlanActivities(plan.Activities)
+	if err != nil {
+		return err
+	}
+
+	rules, err := s.recurrenceRepo.ListByCarePlan(ctx, plan.PlanID)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]*models.VisitScheduleRecurrence, len(rules))
+	for _, rule := range rules {
+		if rule.ActivityRef != nil {
+			current[*rule.ActivityRef] = rule
+		}
+	}
+
+	today := clinicToday()
+	for i := range activities {
+		activity := &activities[i]
+		if activity.Frequency == nil {
+			continue
+		}
+
+		desired := carePlanRecurrence(plan, activity, today, updatedBy)
+		if rule, ok := current[activity.ActivityID]; ok {
+			delete(current, activity.ActivityID)
+			if sameActivitySchedule(rule, desired, today) {
+				continue
+			}
+			// Upcoming visits of the old pattern are regenerated from the new rule
+			if _, err := s.recurrenceService.removeOccurrences(ctx, rule.RecurrenceID, today); err != nil {
+				return err
+			}
+			if err := s.recurrenceService.endRecurrenceBefore(ctx, rule, today, updatedBy); err != nil {
+				return err
+			}
+		}
+
+		if desired.EndDate != nil && desired.EndDate.Before(desired.StartDate) {
+			continue
+		}
+		if err := s.startRule(ctx, desired); err != nil {
+			return err
+		}
+	}
+
+	// Rules left over belong to activities that were removed from the plan
+	for activityID, rule := range current {
+		if err := s.recurrenceService.endRecurrenceBefore(ctx, rule, today, updatedBy); err != nil {
+			return err
+		}
+		if err := s.cancelUpcoming(ctx, plan, &activityID, "care plan activity removed", updatedBy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startRule saves a new activity rule and materializes its first horizon of visits. The
+// plan was accepted when it was saved, so staff clashes are logged rather than enforced.
+func (s *CarePlanScheduleService) startRule(ctx context.Context, rule *models.VisitScheduleRecurrence) error {
+	until := clinicToday().AddDays(RecurrenceHorizonDays)
+	schedules, err := s.recurrenceService.planOccurrences(ctx, rule, rule.StartDate, until)
+	if err != nil {
+		return err
+	}
+	if err := s.recurrenceService.validateAssignment(ctx, rule, schedules); err != nil {
+		return err
+	}
+	s.recurrenceService.warnConflicts(ctx, rule, schedules)
+
+	if err := s.recurrenceRepo.Insert(ctx, rule); err != nil {
+		logger.ErrorContext(ctx, "Failed to create care plan activity recurrence", err, map[string]interface{}{
+			"plan_id":     *rule.CarePlanRef,
+			"activity_id": *rule.ActivityRef,
+		})
+		return fmt.Errorf("failed to create visit schedule recurrence: %w", err)
+	}
+	if err := s.recurrenceService.saveOccurrences(ctx, rule, schedules, rule.StartDate, until); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Care plan activity scheduled", map[string]interface{}{
+		"plan_id":       *rule.CarePlanRef,
+		"activity_id":   *rule.ActivityRef,
+		"recurrence_id": rule.RecurrenceID,
+		"rrule":         rule.RRule(),
+		"visits":        len(schedules),
+	})
+	return nil
+}
+
+// stopPlan ends every rule of the plan and cancels its upcoming visits
+func (s *CarePlanScheduleService) stopPlan(ctx context.Context, plan *models.CarePlan, reason, updatedBy string) error {
+	rules, err := s.recurrenceRepo.ListByCarePlan(ctx, plan.PlanID)
+	if err != nil {
+		return err
+	}
+
+	today := clinicToday()
+	for _, rule := range rules {
+		if err := s.recurrenceService.endRecurrenceBefore(ctx, rule, today, updatedBy); err != nil {
+			return err
+		}
+	}
+
+	return s.cancelUpcoming(ctx, plan, nil, reason, updatedBy)
+}
+
+// cancelUpcoming cancels the plan's visits from today on that have not started, limited to
+// one activity's visits when activityID is set. The cancellations are committed together.
+func (s *CarePlanScheduleService) cancelUpcoming(ctx context.Context, plan *models.CarePlan, activityID *string, reason, updatedBy string) error {
+	schedules, err := s.visitScheduleRepo.ListUpcomingByCarePlan(ctx, plan.PlanID, clinicToday())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var changes []repository.ScheduleChange
+	for _, schedule := range schedules {
+		if activityID != nil && (!schedule.ActivityRef.Valid || schedule.ActivityRef.StringVal != *activityID) {
+			continue
+		}
+		from := schedule.Status
+		if err := applyStatusTransition(schedule, "cancelled", &reason, now, updatedBy); err != nil {
+			return err
+		}
+		changes = append(changes, repository.ScheduleChange{
+			Schedule: schedule,
+			Event: &models.VisitScheduleStatusEvent{
+				ScheduleID: schedule.ScheduleID,
+				PatientID:  schedule.PatientID,
+				FromStatus: from,
+				ToStatus:   "cancelled",
+				Reason:     &reason,
+				OccurredAt: now,
+				ChangedBy:  updatedBy,
+			},
+		})
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err := s.visitScheduleRepo.CommitChanges(ctx, changes); err != nil {
+		logger.ErrorContext(ctx, "Failed to cancel care plan visits", err, map[string]interface{}{
+			"plan_id": plan.PlanID,
+			"count":   len(changes),
+		})
+		return err
+	}
+
+	logger.InfoContext(ctx, "Care plan visits cancelled", map[string]interface{}{
+		"plan_id":    plan.PlanID,
+		"count":      len(changes),
+		"reason":     reason,
+		"updated_by": updatedBy,
+	})
+	return nil
+}
+
+// normalizeCarePlanActivities validates a plan's activities, including the visit pattern of
+// each scheduled one, and assigns IDs to new activities so their visits can reference them
+func normalizeCarePlanActivities(raw json.RawMessage) (json.RawMessage, error) {
+	activities, err := models.ParseCarePlanActivities(raw)
+	if err != nil || activities == nil {
+		return raw, err
+	}
+
+	seen := make(map[string]bool, len(activities))
+	for i := range activities {
+		activity := &activities[i]
+		if err := activity.Validate(); err != nil {
+			return nil, fmt.Errorf("activity %d: %w", i+1, err)
+		}
+		if activity.ActivityID == "" {
+			activity.ActivityID = uuid.New().String()
+		}
+		if seen[activity.ActivityID] {
+			return nil, fmt.Errorf("activity %d: duplicate activity_id %s", i+1, activity.ActivityID)
+		}
+		seen[activity.ActivityID] = true
+
+		if activity.Frequency != nil {
+			// The pattern is checked against today's date; the plan's period is applied on sync
+			rule := carePlanRecurrence(&models.CarePlan{}, activity, clinicToday(), "")
+			if err := validateRecurrence(rule); err != nil {
+				return nil, fmt.Errorf("activity %d: %w", i+1, err)
+			}
+		}
+	}
+
+	normalized, err := json.Marshal(activities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal activities: %w", err)
+	}
+	return normalized, nil
+}
+
+// carePlanRecurrence builds the rule for a scheduled activity. It runs from the later of
+// the plan's start and today until the plan's end; the activity's role is carried in the
+// visit constraints for the route optimizer and coordinators.
+func carePlanRecurrence(plan *models.CarePlan, activity *models.CarePlanActivity, today civil.Date, createdBy string) *models.VisitScheduleRecurrence {
+	start := today
+	if !plan.PeriodStart.IsZero() {
+		if planStart := civil.DateOf(plan.PeriodStart.In(clinicTimeZone)); planStart.After(today) {
+			start = planStart
+		}
+	}
+	var end *civil.Date
+	if plan.PeriodEnd.Valid {
+		planEnd := civil.DateOf(plan.PeriodEnd.Time.In(clinicTimeZone))
+		end = &planEnd
+	}
+
+	constraints, _ := json.Marshal(map[string]string{"required_role": activity.AssignedRole})
+	planID := plan.PlanID
+	activityID := activity.ActivityID
+	now := time.Now()
+
+	return &models.VisitScheduleRecurrence{
+		RecurrenceID:             uuid.New().String(),
+		PatientID:                plan.PatientID,
+		Frequency:                activity.Frequency.Pattern,
+		ByWeekday:                activity.Frequency.ByWeekday,
+		ByWeekOfMonth:            activity.Frequency.ByWeekOfMonth,
+		StartDate:                start,
+		EndDate:                  end,
+		VisitType:                "regular",
+		StartTime:                activity.Frequency.StartTime,
+		EndTime:                  activity.Frequency.EndTime,
+		EstimatedDurationMinutes: activity.DurationMinutes,
+		AssignedStaffID:          activity.AssignedStaffID,
+		PriorityScore:            carePlanVisitPriority,
+		Constraints:              constraints,
+		CarePlanRef:              &planID,
+		ActivityRef:              &activityID,
+		Status:                   "active",
+		CreatedAt:                now,
+		CreatedBy:                &createdBy,
+		UpdatedAt:                now,
+	}
+}
+
+// sameActivitySchedule reports whether a running rule already produces the desired visits.
+// A rule that started in the past and one starting today are the same from today on.
+func sameActivitySchedule(current, desired *models.VisitScheduleRecurrence, today civil.Date) bool {
+	currentStart := current.StartDate
+	if currentStart.Before(today) {
+		currentStart = today
+	}
+
+	return current.Frequency == desired.Frequency &&
+		equalStrings(current.ByWeekday, desired.ByWeekday) &&
+		equalInts(current.ByWeekOfMonth, desired.ByWeekOfMonth) &&
+		currentStart == desired.StartDate &&
+		equalDatePtr(current.EndDate, desired.EndDate) &&
+		equalStringPtr(current.StartTime, desired.StartTime) &&
+		equalStringPtr(current.EndTime, desired.EndTime) &&
+		current.EstimatedDurationMinutes == desired.EstimatedDurationMinutes &&
+		equalStringPtr(current.AssignedStaffID, desired.AssignedStaffID) &&
+		requiredRole(current.Constraints) == requiredRole(desired.Constraints)
+}
+
+// requiredRole reads the role from visit constraints; stored JSONB is compared by value,
+// not by its text, which the database may reformat
+func requiredRole(constraints json.RawMessage) string {
+	var c struct {
+		RequiredRole string `json:"required_role"`
+	}
+	if len(constraints) > 0 {
+		_ = json.Unmarshal(constraints, &c)
+	}
+	return c.RequiredRole
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalInts(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func equalDatePtr(a, b *civil.Date) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func TestNormalizeCarePlanActivities(t *testing.T) {
+	t.Run("Assigns IDs to new activities", func(t *testing.T) {
+		raw := json.RawMessage(`[
+			{"kind": "home_nursing_visit", "assigned_role": "nurse", "duration_minutes": 60,
+			 "frequency": {"pattern": "weekly", "by_weekday": ["MO", "TH"], "start_time": "10:00"}},
+			{"activity_id": "act-2", "kind": "other", "assigned_role": "care_manager", "duration_minutes": 30}
+		]`)
+
+		normalized, err := normalizeCarePlanActivities(raw)
+		require.NoError(t, err)
+
+		activities, err := models.ParseCarePlanActivities(normalized)
+		require.NoError(t, err)
+		require.Len(t, activities, 2)
+		assert.NotEmpty(t, activities[0].ActivityID)
+		assert.Equal(t, "act-2", activities[1].ActivityID)
+	})
+
+	t.Run("Empty activities are left as they are", func(t *testing.T) {
+		normalized, err := normalizeCarePlanActivities(nil)
+		require.NoError(t, err)
+		assert.Nil(t, normalized)
+	})
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"Not an array", `{"kind": "other"}`},
+		{"Unknown kind", `[{"kind": "massage", "assigned_role": "nurse", "duration_minutes": 30}]`},
+		{"Unknown role", `[{"kind": "other", "assigned_role": "driver", "duration_minutes": 30}]`},
+		{"Duration out of range", `[{"kind": "other", "assigned_role": "nurse", "duration_minutes": 600}]`},
+		{"Duplicate IDs", `[{"activity_id": "a", "kind": "other", "assigned_role": "nurse", "duration_minutes": 30},
+			{"activity_id": "a", "kind": "other", "assigned_role": "nurse", "duration_minutes": 30}]`},
+		{"Invalid pattern", `[{"kind": "home_medical_visit", "assigned_role": "doctor", "duration_minutes": 30,
+			"frequency": {"pattern": "daily", "by_weekday": ["MO"]}}]`},
+		{"Missing weekdays", `[{"kind": "home_medical_visit", "assigned_role": "doctor", "duration_minutes": 30,
+			"frequency": {"pattern": "weekly"}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := normalizeCarePlanActivities(json.RawMessage(tt.raw))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCarePlanRecurrence(t *testing.T) {
+	today := civil.Date{Year: 2025, Month: 4, Day: 10}
+	staffID := "staff-1"
+	activity := &models.CarePlanActivity{
+		ActivityID:      "act-1",
+		Kind:            "home_medical_visit",
+		AssignedRole:    "doctor",
+		AssignedStaffID: &staffID,
+		DurationMinutes: 45,
+		Frequency: &models.CarePlanActivityFrequency{
+			Pattern:       "monthly_nth_weekday",
+			ByWeekday:     []string{"TU"},
+			ByWeekOfMonth: []int64{2, 4},
+		},
+	}
+
+	t.Run("Plan already running starts today and ends with the plan", func(t *testing.T) {
+		plan := &models.CarePlan{
+			PlanID:      "plan-1",
+			PatientID:   "patient-1",
+			PeriodStart: time.Date(2025, 1, 1, 0, 0, 0, 0, clinicTimeZone),
+			PeriodEnd:   spanner.NullTime{Time: time.Date(2025, 9, 30, 0, 0, 0, 0, clinicTimeZone), Valid: true},
+		}
+
+		rule := carePlanRecurrence(plan, activity, today, "user-1")
+
+		assert.Equal(t, today, rule.StartDate)
+		require.NotNil(t, rule.EndDate)
+		assert.Equal(t, civil.Date{Year: 2025, Month: 9, Day: 30}, *rule.EndDate)
+		assert.Equal(t, "plan-1", *rule.CarePlanRef)
+		assert.Equal(t, "act-1", *rule.ActivityRef)
+		assert.Equal(t, "staff-1", *rule.AssignedStaffID)
+		assert.Equal(t, int64(45), rule.EstimatedDurationMinutes)
+		assert.Equal(t, "doctor", requiredRole(rule.Constraints))
+		assert.NoError(t, validateRecurrence(rule))
+	})
+
+	t.Run("Future plan starts on its period start", func(t *testing.T) {
+		plan := &models.CarePlan{PeriodStart: time.Date(2025, 5, 1, 0, 0, 0, 0, clinicTimeZone)}
+
+		rule := carePlanRecurrence(plan, activity, today, "user-1")
+
+		assert.Equal(t, civil.Date{Year: 2025, Month: 5, Day: 1}, rule.StartDate)
+		assert.Nil(t, rule.EndDate)
+	})
+}
+
+func TestSameActivitySchedule(t *testing.T) {
+	today := civil.Date{Year: 2025, Month: 4, Day: 10}
+	activity := &models.CarePlanActivity{
+		ActivityID:      "act-1",
+		Kind:            "home_nursing_visit",
+		AssignedRole:    "nurse",
+		DurationMinutes: 60,
+		Frequency:       &models.CarePlanActivityFrequency{Pattern: "weekly", ByWeekday: []string{"MO"}},
+	}
+	plan := &models.CarePlan{PlanID: "plan-1", PeriodStart: time.Date(2025, 1, 1, 0, 0, 0, 0, clinicTimeZone)}
+
+	running := carePlanRecurrence(plan, activity, today.AddDays(-30), "user-1")
+	// Stored JSONB comes back reformatted
+	running.Constraints = json.RawMessage(`{"required_role": "nurse"}`)
+
+	assert.True(t, sameActivitySchedule(running, carePlanRecurrence(plan, activity, today, "user-1"), today))
+
+	changed := *activity
+	changed.DurationMinutes = 90
+	assert.False(t, sameActivitySchedule(running, carePlanRecurrence(plan, &changed, today, "user-1"), today))
+
+	changed = *activity
+	changed.AssignedRole = "therapist"
+	assert.False(t, sameActivitySchedule(running, carePlanRecurrence(plan, &changed, today, "user-1"), today))
+
+	ended := *plan
+	ended.PeriodEnd = spanner.NullTime{Time: time.Date(2025, 6, 30, 0, 0, 0, 0, clinicTimeZone), Valid: true}
+	assert.False(t, sameActivitySchedule(running, carePlanRecurrence(&ended, activity, today, "user-1"), today))
+}
@@ -2,8 +2,16 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/google/uuid"
 	"github.com/visitas/backend/internal/models"
 	"github.com/visitas/backend/internal/repository"
 	"github.com/visitas/backend/pkg/logger"
@@ -11,18 +19,24 @@ import (
 
 // CarePlanService handles business logic for care plans
 type CarePlanService struct {
-	carePlanRepo *repository.CarePlanRepository
-	patientRepo  *repository.PatientRepository
+	carePlanRepo    *repository.CarePlanRepository
+	patientRepo     *repository.PatientRepository
+	observationRepo *repository.ClinicalObservationRepository
+	scheduleService *CarePlanScheduleService
 }
 
 // NewCarePlanService creates a new care plan service
 func NewCarePlanService(
 	carePlanRepo *repository.CarePlanRepository,
 	patientRepo *repository.PatientRepository,
+	observationRepo *repository.ClinicalObservationRepository,
+	scheduleService *CarePlanScheduleService,
 ) *CarePlanService {
 	return &CarePlanService{
-		carePlanRepo: carePlanRepo,
-		patientRepo:  patientRepo,
+		carePlanRepo:    carePlanRepo,
+		patientRepo:     patientRepo,
+		observationRepo: observationRepo,
+		scheduleService: scheduleService,
 	}
 }
 
@@ -95,6 +109,29 @@ func (s *CarePlanService) CreateCarePlan(ctx context.Context, patientID string,
 		return nil, fmt.Errorf("period_start is required")
 	}
 
+	// Validate activities and assign IDs to them
+	activities, err := normalizeCarePlanActivities(req.Activities)
+	if err != nil {
+		logger.WarnContext(ctx, "Invalid activities", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+	req.Activities = activities
+
+	// Validate goals and assign IDs to them
+	goals, err := normalizeCarePlanGoals(req.Goals)
+	if err != nil {
+		logger.WarnContext(ctx, "Invalid goals", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+	req.Goals = goals
+
+	// The authenticated user is recorded as author of the plan and its first version
+	req.CreatedBy = createdBy
+
 	plan, err := s.carePlanRepo.Create(ctx, patientID, req)
 	if err != nil {
 		logger.ErrorContext(ctx, "Failed to create care plan", err, map[string]interface{}{
@@ -111,6 +148,8 @@ func (s *CarePlanService) CreateCarePlan(ctx context.Context, patientID string,
 		"created_by": createdBy,
 	})
 
+	s.syncSchedule(ctx, plan, createdBy)
+
 	return plan, nil
 }
 
@@ -233,6 +272,30 @@ func (s *CarePlanService) UpdateCarePlan(ctx context.Context, patientID, planID
 		return nil, fmt.Errorf("title cannot be empty")
 	}
 
+	// Validate activities and assign IDs to new ones if provided
+	if len(req.Activities) > 0 {
+		activities, err := normalizeCarePlanActivities(req.Activities)
+		if err != nil {
+			logger.WarnContext(ctx, "Invalid activities", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil, err
+		}
+		req.Activities = activities
+	}
+
+	// Validate goals and assign IDs to new ones if provided
+	if len(req.Goals) > 0 {
+		goals, err := normalizeCarePlanGoals(req.Goals)
+		if err != nil {
+			logger.WarnContext(ctx, "Invalid goals", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil, err
+		}
+		req.Goals = goals
+	}
+
 	// Get existing care plan for optimistic locking
 	existing, err := s.carePlanRepo.GetByID(ctx, patientID, planID)
 	if err != nil {
@@ -255,9 +318,9 @@ func (s *CarePlanService) UpdateCarePlan(ctx context.Context, patientID, planID
 
 	var plan *models.CarePlan
 	if req.ExpectedVersion != nil {
-		plan, err = s.carePlanRepo.UpdateWithVersion(ctx, patientID, planID, *req.ExpectedVersion, req)
+		plan, err = s.carePlanRepo.UpdateWithVersion(ctx, patientID, planID, *req.ExpectedVersion, req, updatedBy)
 	} else {
-		plan, err = s.carePlanRepo.Update(ctx, patientID, planID, req)
+		plan, err = s.carePlanRepo.Update(ctx, patientID, planID, req, updatedBy)
 	}
 	if err != nil {
 		logger.ErrorContext(ctx, "Failed to update care plan", err, map[string]interface{}{
@@ -274,6 +337,11 @@ func (s *CarePlanService) UpdateCarePlan(ctx context.Context, patientID, planID
 		"updated_by": updatedBy,
 	})
 
+	// An active plan is re-synced on every edit; a plan that has just ended stops its visits
+	if plan.Status == "active" || plan.Status != existing.Status {
+		s.syncSchedule(ctx, plan, updatedBy)
+	}
+
 	return plan, nil
 }
 
@@ -300,7 +368,7 @@ func (s *CarePlanService) DeleteCarePlan(ctx context.Context, patientID, planID,
 	}
 
 	// Verify the care plan exists before deletion
-	_, err = s.carePlanRepo.GetByID(ctx, patientID, planID)
+	plan, err := s.carePlanRepo.GetByID(ctx, patientID, planID)
 	if err != nil {
 		logger.ErrorContext(ctx, "Failed to get care plan for deletion", err, map[string]interface{}{
 			"patient_id": patientID,
@@ -309,6 +377,15 @@ func (s *CarePlanService) DeleteCarePlan(ctx context.Context, patientID, planID,
 		return fmt.Errorf("care plan not found: %w", err)
 	}
 
+	// The plan's rules and upcoming visits must not outlive it
+	if err := s.scheduleService.stopPlan(ctx, plan, "care plan deleted", deletedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to stop care plan visit schedules", err, map[string]interface{}{
+			"patient_id": patientID,
+			"plan_id":    planID,
+		})
+		return fmt.Errorf("failed to cancel care plan visits: %w", err)
+	}
+
 	err = s.carePlanRepo.Delete(ctx, patientID, planID)
 	if err != nil {
 		logger.ErrorContext(ctx, "Failed to delete care plan", err, map[string]interface{}{
@@ -350,3 +427,424 @@ func (s *CarePlanService) GetActiveCarePlans(ctx context.Context, patientID, req
 
 	return s.carePlanRepo.GetActiveCarePlans(ctx, patientID)
 }
+
+// GetGoalStatus computes the progress of each of a plan's goals from the patient's clinical
+// observations over the plan period
+func (s *CarePlanService) GetGoalStatus(ctx context.Context, patientID, planID, requestorID string) (*models.CarePlanGoalStatus, error) {
+	plan, err := s.GetCarePlan(ctx, patientID, planID, requestorID)
+	if err != nil {
+		return nil, err
+	}
+
+	goals, err := models.ParseCarePlanGoals(plan.Goals)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	to := now
+	if plan.PeriodEnd.Valid && plan.PeriodEnd.Time.Before(now) {
+		to = plan.PeriodEnd.Time
+	}
+
+	// One time series per observation category, shared by the goals that measure it
+	series := make(map[string][]*models.ClinicalObservation)
+	for _, goal := range goals {
+		if goal.Target == nil {
+			continue
+		}
+		if _, ok := series[goal.Target.Category]; ok {
+			continue
+		}
+		observations, err := s.observationRepo.GetTimeSeriesData(ctx, patientID, goal.Target.Category, plan.PeriodStart, to)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to load observations for care plan goals", err, map[string]interface{}{
+				"plan_id":  planID,
+				"category": goal.Target.Category,
+			})
+			return nil, fmt.Errorf("failed to load observations: %w", err)
+		}
+		series[goal.Target.Category] = observations
+	}
+
+	today := civil.DateOf(now.In(clinicTimeZone))
+	status := &models.CarePlanGoalStatus{
+		PlanID:      plan.PlanID,
+		PatientID:   plan.PatientID,
+		EvaluatedAt: now,
+		Goals:       []models.CarePlanGoalProgress{},
+	}
+	for i := range goals {
+		goal := &goals[i]
+		if goal.DueDate == nil && plan.PeriodEnd.Valid {
+			due := civil.DateOf(plan.PeriodEnd.Time.In(clinicTimeZone))
+			goal.DueDate = &due
+		}
+		var observations []*models.ClinicalObservation
+		if goal.Target != nil {
+			observations = series[goal.Target.Category]
+		}
+		status.Goals = append(status.Goals, evaluateGoal(goal, observations, today))
+	}
+
+	return status, nil
+}
+
+// ListVersions lists the saved versions of a care plan, newest first, with access control
+func (s *CarePlanService) ListVersions(ctx context.Context, patientID, planID, requestorID string) ([]*models.CarePlanVersion, error) {
+	if err := s.checkVersionAccess(ctx, patientID, planID, requestorID); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.carePlanRepo.ListVersions(ctx, patientID, planID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list care plan versions", err, map[string]interface{}{
+			"patient_id": patientID,
+			"plan_id":    planID,
+		})
+		return nil, fmt.Errorf("failed to list care plan versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("care plan not found")
+	}
+
+	return versions, nil
+}
+
+// GetVersion retrieves one saved version of a care plan with access control
+func (s *CarePlanService) GetVersion(ctx context.Context, patientID, planID string, version int64, requestorID string) (*models.CarePlanVersion, error) {
+	if err := s.checkVersionAccess(ctx, patientID, planID, requestorID); err != nil {
+		return nil, err
+	}
+
+	return s.carePlanRepo.GetVersion(ctx, patientID, planID, version)
+}
+
+// CompareVersions returns what changed in a care plan between two of its saved versions
+func (s *CarePlanService) CompareVersions(ctx context.Context, patientID, planID string, fromVersion, toVersion int64, requestorID string) (*models.CarePlanDiff, error) {
+	if err := s.checkVersionAccess(ctx, patientID, planID, requestorID); err != nil {
+		return nil, err
+	}
+
+	from, err := s.carePlanRepo.GetVersion(ctx, patientID, planID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.carePlanRepo.GetVersion(ctx, patientID, planID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffCarePlanVersions(from, to)
+}
+
+// checkVersionAccess checks the requestor may read a plan's history
+func (s *CarePlanService) checkVersionAccess(ctx context.Context, patientID, planID, requestorID string) error {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":   patientID,
+			"plan_id":      planID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized care plan history access attempt", map[string]interface{}{
+			"patient_id":   patientID,
+			"plan_id":      planID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("access denied: you do not have permission to view this care plan")
+	}
+
+	return nil
+}
+
+// syncSchedule generates, updates or cancels the visit schedules of a saved plan's
+// activities. The plan is saved either way: a failed sync is logged and retried by
+// ResyncSchedules, so a client never retries a save that already went through.
+func (s *CarePlanService) syncSchedule(ctx context.Context, plan *models.CarePlan, updatedBy string) {
+	if err := s.scheduleService.Sync(ctx, plan, updatedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to sync care plan visit schedules", err, map[string]interface{}{
+			"plan_id":    plan.PlanID,
+			"patient_id": plan.PatientID,
+			"status":     plan.Status,
+		})
+	}
+}
+
+// ResyncSchedules re-syncs the visit schedules of every active plan and stops the rules
+// left running by plans that have ended or been deleted. It is run periodically to finish
+// syncs that failed when a plan was saved.
+func (s *CarePlanService) ResyncSchedules(ctx context.Context) error {
+	plans, err := s.carePlanRepo.ListAllActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list active care plans: %w", err)
+	}
+	for _, plan := range plans {
+		if err := s.scheduleService.Sync(ctx, plan, carePlanResyncActor); err != nil {
+			// Keep going; the plan is picked up again on the next run
+			logger.WarnContext(ctx, "Failed to resync care plan visit schedules", map[string]interface{}{
+				"plan_id": plan.PlanID,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	planIDs, err := s.scheduleService.recurrenceRepo.ListStoppedCarePlanRefs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list stopped care plans: %w", err)
+	}
+	for _, planID := range planIDs {
+		plan := &models.CarePlan{PlanID: planID}
+		if err := s.scheduleService.stopPlan(ctx, plan, "care plan ended", carePlanResyncActor); err != nil {
+			logger.WarnContext(ctx, "Failed to stop care plan visit schedules", map[string]interface{}{
+				"plan_id": planID,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	return nil
+}
+
+// normalizeCarePlanGoals validates a plan's goals and assigns IDs to new goals
+func normalizeCarePlanGoals(raw json.RawMessage) (json.RawMessage, error) {
+	goals, err := models.ParseCarePlanGoals(raw)
+	if err != nil || goals == nil {
+		return raw, err
+	}
+
+	seen := make(map[string]bool, len(goals))
+	for i := range goals {
+		goal := &goals[i]
+		if err := goal.Validate(); err != nil {
+			return nil, fmt.Errorf("goal %d: %w", i+1, err)
+		}
+		if goal.GoalID == "" {
+			goal.GoalID = uuid.New().String()
+		}
+		if seen[goal.GoalID] {
+			return nil, fmt.Errorf("goal %d: duplicate goal_id %s", i+1, goal.GoalID)
+		}
+		seen[goal.GoalID] = true
+	}
+
+	normalized, err := json.Marshal(goals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal goals: %w", err)
+	}
+	return normalized, nil
+}
+
+// evaluateGoal computes a goal's progress from observations in time order. A goal is
+// achieved when its latest measurement meets the target. Otherwise it is in progress while
+// the measurements move towards the target, and off track when they do not or when it is
+// past its due date.
+func evaluateGoal(goal *models.CarePlanGoal, observations []*models.ClinicalObservation, today civil.Date) models.CarePlanGoalProgress {
+	progress := models.CarePlanGoalProgress{
+		GoalID:      goal.GoalID,
+		Description: goal.Description,
+		Target:      goal.Target,
+		DueDate:     goal.DueDate,
+	}
+	if goal.Target == nil {
+		progress.Status = "not_measurable"
+		return progress
+	}
+
+	for _, observation := range observations {
+		if !goal.Target.Matches(observation) {
+			continue
+		}
+		value, ok := observation.NumericValue(goal.Target.Component)
+		if !ok {
+			continue
+		}
+		progress.History = append(progress.History, models.GoalMeasurement{
+			ObservationID:     observation.ObservationID,
+			EffectiveDatetime: observation.EffectiveDatetime,
+			Value:             value,
+		})
+	}
+	progress.Measurements = len(progress.History)
+	if progress.Measurements == 0 {
+		progress.Status = "no_data"
+		return progress
+	}
+
+	baseline := progress.History[0]
+	latest := progress.History[len(progress.History)-1]
+	progress.Baseline = &baseline
+	progress.Latest = &latest
+
+	if goal.Target.Met(latest.Value) {
+		full := 100.0
+		progress.ProgressPercent = &full
+		progress.Status = "achieved"
+		return progress
+	}
+
+	if gap := goal.Target.Value - baseline.Value; gap != 0 {
+		percent := math.Round(math.Max(0, math.Min(100, (latest.Value-baseline.Value)/gap*100))*10) / 10
+		progress.ProgressPercent = &percent
+	}
+
+	improving := math.Abs(goal.Target.Value-latest.Value) < math.Abs(goal.Target.Value-baseline.Value)
+	switch {
+	case goal.DueDate != nil && goal.DueDate.Before(today):
+		progress.Status = "off_track"
+	case progress.Measurements == 1 || improving:
+		progress.Status = "in_progress"
+	default:
+		progress.Status = "off_track"
+	}
+	return progress
+}
+
+// diffCarePlanVersions compares two snapshots of the same plan field by field.
+// Goals and activities are matched by their IDs; items saved without one are matched by position.
+func diffCarePlanVersions(from, to *models.CarePlanVersion) (*models.CarePlanDiff, error) {
+	if from.PlanID != to.PlanID {
+		return nil, fmt.Errorf("versions belong to different care plans")
+	}
+
+	diff := &models.CarePlanDiff{
+		PlanID:      to.PlanID,
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Fields:      []models.CarePlanFieldChange{},
+	}
+
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"title", from.Title, to.Title},
+		{"description", nullStringValue(from.Description), nullStringValue(to.Description)},
+		{"status", from.Status, to.Status},
+		{"intent", from.Intent, to.Intent},
+		{"period_start", from.PeriodStart.Format("2006-01-02"), to.PeriodStart.Format("2006-01-02")},
+		{"period_end", nullDateValue(from.PeriodEnd), nullDateValue(to.PeriodEnd)},
+	}
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.from, f.to) {
+			diff.Fields = append(diff.Fields, models.CarePlanFieldChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+
+	var err error
+	if diff.Goals, err = diffCarePlanItems(from.Goals, to.Goals, "goal_id"); err != nil {
+		return nil, fmt.Errorf("invalid goals: %w", err)
+	}
+	if diff.Activities, err = diffCarePlanItems(from.Activities, to.Activities, "activity_id"); err != nil {
+		return nil, fmt.Errorf("invalid activities: %w", err)
+	}
+
+	return diff, nil
+}
+
+// diffCarePlanItems compares two JSON arrays of goals or activities keyed by idField
+func diffCarePlanItems(fromRaw, toRaw json.RawMessage, idField string) (models.CarePlanItemsDiff, error) {
+	diff := models.CarePlanItemsDiff{
+		Added:   []map[string]interface{}{},
+		Removed: []map[string]interface{}{},
+		Changed: []models.CarePlanItemChange{},
+	}
+
+	fromItems, err := parseCarePlanItems(fromRaw)
+	if err != nil {
+		return diff, err
+	}
+	toItems, err := parseCarePlanItems(toRaw)
+	if err != nil {
+		return diff, err
+	}
+
+	key := func(item map[string]interface{}, index int) string {
+		if id, ok := item[idField].(string); ok && id != "" {
+			return id
+		}
+		return fmt.Sprintf("#%d", index+1)
+	}
+
+	fromByKey := make(map[string]map[string]interface{}, len(fromItems))
+	for i, item := range fromItems {
+		fromByKey[key(item, i)] = item
+	}
+
+	seen := make(map[string]bool, len(toItems))
+	for i, item := range toItems {
+		k := key(item, i)
+		seen[k] = true
+		previous, ok := fromByKey[k]
+		if !ok {
+			diff.Added = append(diff.Added, item)
+			continue
+		}
+		if changes := diffCarePlanItemFields(previous, item); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, models.CarePlanItemChange{ID: k, Fields: changes})
+		}
+	}
+	for i, item := range fromItems {
+		if !seen[key(item, i)] {
+			diff.Removed = append(diff.Removed, item)
+		}
+	}
+
+	return diff, nil
+}
+
+// diffCarePlanItemFields lists the top-level fields that differ between two versions of an item
+func diffCarePlanItemFields(from, to map[string]interface{}) []models.CarePlanFieldChange {
+	names := make(map[string]bool, len(from)+len(to))
+	for name := range from {
+		names[name] = true
+	}
+	for name := range to {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []models.CarePlanFieldChange
+	for _, name := range sorted {
+		if !reflect.DeepEqual(from[name], to[name]) {
+			changes = append(changes, models.CarePlanFieldChange{Field: name, From: from[name], To: to[name]})
+		}
+	}
+	return changes
+}
+
+// parseCarePlanItems decodes a goals or activities array; empty means no items
+func parseCarePlanItems(raw json.RawMessage) ([]map[string]interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var items []map[string]interface{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("must be an array of objects: %w", err)
+	}
+	return items, nil
+}
+
+// nullStringValue is the JSON value of a nullable string
+func nullStringValue(s spanner.NullString) interface{} {
+	if !s.Valid {
+		return nil
+	}
+	return s.StringVal
+}
+
+// nullDateValue is the JSON value of a nullable date
+func nullDateValue(t spanner.NullTime) interface{} {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time.Format("2006-01-02")
+}
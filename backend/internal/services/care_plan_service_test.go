@@ -0,0 +1,220 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func systolicObservations(values ...float64) []*models.ClinicalObservation {
+	observations := make([]*models.ClinicalObservation, len(values))
+	for i, v := range values {
+		observations[i] = &models.ClinicalObservation{
+			ObservationID:     fmt.Sprintf("obs-%d", i+1),
+			Category:          "vital_signs",
+			Code:              json.RawMessage(`{"system": "LOINC", "code": "85354-9", "display": "Blood pressure panel"}`),
+			EffectiveDatetime: time.Date(2025, 4, 1+i, 10, 0, 0, 0, clinicTimeZone),
+			Value:             json.RawMessage(fmt.Sprintf(`{"systolic": {"value": %g, "unit": "mmHg"}, "diastolic": {"value": 80, "unit": "mmHg"}}`, v)),
+		}
+	}
+	return observations
+}
+
+func TestEvaluateGoal(t *testing.T) {
+	today := civil.Date{Year: 2025, Month: 4, Day: 20}
+	due := civil.Date{Year: 2025, Month: 6, Day: 30}
+	overdue := civil.Date{Year: 2025, Month: 4, Day: 15}
+	bloodPressure := &models.CarePlanGoalTarget{Category: "vital_signs", Code: "85354-9", Component: "systolic", Comparator: "<", Value: 140}
+
+	tests := []struct {
+		name            string
+		target          *models.CarePlanGoalTarget
+		dueDate         *civil.Date
+		observations    []*models.ClinicalObservation
+		expectedStatus  string
+		expectedPercent *float64
+	}{
+		{
+			name:           "Goal without a target",
+			expectedStatus: "not_measurable",
+		},
+		{
+			name:           "No matching observations",
+			target:         &models.CarePlanGoalTarget{Category: "vital_signs", Code: "8867-4", Comparator: "<", Value: 100},
+			observations:   systolicObservations(150),
+			expectedStatus: "no_data",
+		},
+		{
+			name:            "Latest measurement meets the target",
+			target:          bloodPressure,
+			dueDate:         &due,
+			observations:    systolicObservations(160, 150, 135),
+			expectedStatus:  "achieved",
+			expectedPercent: floatPtr(100),
+		},
+		{
+			name:            "Moving towards the target",
+			target:          bloodPressure,
+			dueDate:         &due,
+			observations:    systolicObservations(160, 155, 150),
+			expectedStatus:  "in_progress",
+			expectedPercent: floatPtr(50),
+		},
+		{
+			name:            "Moving away from the target",
+			target:          bloodPressure,
+			dueDate:         &due,
+			observations:    systolicObservations(150, 155, 165),
+			expectedStatus:  "off_track",
+			expectedPercent: floatPtr(0),
+		},
+		{
+			name:            "Past the due date without meeting the target",
+			target:          bloodPressure,
+			dueDate:         &overdue,
+			observations:    systolicObservations(160, 150),
+			expectedStatus:  "off_track",
+			expectedPercent: floatPtr(50),
+		},
+		{
+			name:            "Single measurement short of the target",
+			target:          bloodPressure,
+			observations:    systolicObservations(150),
+			expectedStatus:  "in_progress",
+			expectedPercent: floatPtr(0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := &models.CarePlanGoal{GoalID: "goal-1", Description: "Blood pressure control", Target: tt.target, DueDate: tt.dueDate}
+
+			progress := evaluateGoal(goal, tt.observations, today)
+
+			assert.Equal(t, tt.expectedStatus, progress.Status)
+			assert.Equal(t, tt.expectedPercent, progress.ProgressPercent)
+		})
+	}
+
+	t.Run("Baseline and latest come from the series ends", func(t *testing.T) {
+		goal := &models.CarePlanGoal{GoalID: "goal-1", Description: "Blood pressure control", Target: bloodPressure}
+
+		progress := evaluateGoal(goal, systolicObservations(160, 150, 145), today)
+
+		require.NotNil(t, progress.Baseline)
+		require.NotNil(t, progress.Latest)
+		assert.Equal(t, 160.0, progress.Baseline.Value)
+		assert.Equal(t, 145.0, progress.Latest.Value)
+		assert.Equal(t, "obs-3", progress.Latest.ObservationID)
+		assert.Equal(t, 3, progress.Measurements)
+	})
+}
+
+func TestNormalizeCarePlanGoals(t *testing.T) {
+	normalized, err := normalizeCarePlanGoals(json.RawMessage(`[
+		{"description": "Barthel index of 60 or more",
+		 "target": {"category": "adl_assessment", "component": "total_score", "comparator": ">=", "value": 60}},
+		{"goal_id": "goal-2", "description": "Family can manage tube feeding"}
+	]`))
+	require.NoError(t, err)
+
+	goals, err := models.ParseCarePlanGoals(normalized)
+	require.NoError(t, err)
+	require.Len(t, goals, 2)
+	assert.NotEmpty(t, goals[0].GoalID)
+	assert.Equal(t, "goal-2", goals[1].GoalID)
+
+	for _, raw := range []string{
+		`"Improve mobility"`,
+		`[{"target": {"category": "vital_signs", "comparator": "<", "value": 140}}]`,
+		`[{"description": "x", "target": {"category": "labs", "comparator": "<", "value": 1}}]`,
+		`[{"description": "x", "target": {"category": "vital_signs", "comparator": "~", "value": 1}}]`,
+		`[{"goal_id": "g", "description": "x"}, {"goal_id": "g", "description": "y"}]`,
+	} {
+		_, err := normalizeCarePlanGoals(json.RawMessage(raw))
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestDiffCarePlanVersions(t *testing.T) {
+	from := &models.CarePlanVersion{
+		PlanID:      "plan-1",
+		Version:     1,
+		Status:      "active",
+		Intent:      "plan",
+		Title:       "在宅療養計画",
+		PeriodStart: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		Goals: json.RawMessage(`[
+			{"goal_id": "g1", "description": "血圧管理", "target": {"category": "vital_signs", "code": "8480-6", "comparator": "<=", "value": 140}},
+			{"goal_id": "g2", "description": "転倒予防"}
+		]`),
+		Activities: json.RawMessage(`[{"activity_id": "a1", "kind": "home_nursing_visit", "assigned_role": "nurse", "duration_minutes": 60}]`),
+	}
+
+	t.Run("Reports field, goal and activity changes", func(t *testing.T) {
+		to := *from
+		to.Version = 3
+		to.Title = "在宅療養計画 (改訂)"
+		to.PeriodEnd = spanner.NullTime{Time: time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC), Valid: true}
+		to.Goals = json.RawMessage(`[
+			{"goal_id": "g1", "description": "血圧管理", "target": {"category": "vital_signs", "code": "8480-6", "comparator": "<=", "value": 130}},
+			{"goal_id": "g3", "description": "服薬自己管理"}
+		]`)
+		to.Activities = json.RawMessage(`[{"activity_id": "a1", "kind": "home_nursing_visit", "assigned_role": "nurse", "duration_minutes": 60}]`)
+
+		diff, err := diffCarePlanVersions(from, &to)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), diff.FromVersion)
+		assert.Equal(t, int64(3), diff.ToVersion)
+		assert.Equal(t, []models.CarePlanFieldChange{
+			{Field: "title", From: "在宅療養計画", To: "在宅療養計画 (改訂)"},
+			{Field: "period_end", From: nil, To: "2025-09-30"},
+		}, diff.Fields)
+
+		require.Len(t, diff.Goals.Added, 1)
+		assert.Equal(t, "g3", diff.Goals.Added[0]["goal_id"])
+		require.Len(t, diff.Goals.Removed, 1)
+		assert.Equal(t, "g2", diff.Goals.Removed[0]["goal_id"])
+		require.Len(t, diff.Goals.Changed, 1)
+		assert.Equal(t, "g1", diff.Goals.Changed[0].ID)
+		require.Len(t, diff.Goals.Changed[0].Fields, 1)
+		assert.Equal(t, "target", diff.Goals.Changed[0].Fields[0].Field)
+
+		assert.Empty(t, diff.Activities.Added)
+		assert.Empty(t, diff.Activities.Removed)
+		assert.Empty(t, diff.Activities.Changed)
+	})
+
+	t.Run("Items without IDs are matched by position", func(t *testing.T) {
+		legacy := *from
+		legacy.Goals = json.RawMessage(`[{"description": "血圧管理"}]`)
+		to := legacy
+		to.Version = 2
+		to.Goals = json.RawMessage(`[{"description": "血圧を130以下に"}]`)
+
+		diff, err := diffCarePlanVersions(&legacy, &to)
+		require.NoError(t, err)
+		assert.Empty(t, diff.Fields)
+		require.Len(t, diff.Goals.Changed, 1)
+		assert.Equal(t, "#1", diff.Goals.Changed[0].ID)
+	})
+
+	t.Run("Versions of different plans", func(t *testing.T) {
+		other := *from
+		other.PlanID = "plan-2"
+		_, err := diffCarePlanVersions(from, &other)
+		assert.Error(t, err)
+	})
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
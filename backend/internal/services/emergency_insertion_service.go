@@ -0,0 +1,649 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/geo"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// emergencyUrgency sets how soon an emergency visit must start and how heavily the
+// emergency patient's wait counts against delays to other patients
+type emergencyUrgency struct {
+	responseWindow time.Duration // zero means by the end of the clinic day
+	priority       int64
+	waitWeight     float64
+}
+
+var emergencyUrgencies = map[string]emergencyUrgency{
+	"critical": {responseWindow: time.Hour, priority: 10, waitWeight: 5},
+	"urgent":   {responseWindow: 3 * time.Hour, priority: 9, waitWeight: 2},
+	"same_day": {priority: 8, waitWeight: 0.5},
+}
+
+// onDutyAvailability lists the availability statuses of staff who can take an emergency visit
+var onDutyAvailability = []string{"available", "on_visit"}
+
+// clinicalRoles are the roles evaluated when an emergency request names no role
+var clinicalRoles = []string{"doctor", "nurse", "therapist", "pharmacist"}
+
+// locationStaleAfter is how old a reported position may be before it is no longer trusted
+// as the staff member's starting point
+const locationStaleAfter = 30 * time.Minute
+
+// remainingRoute is what is left of a staff member's day: where they set off from next
+// and the visits still to be made, in their applied order
+type remainingRoute struct {
+	staff     *models.StaffMember
+	job       *models.RouteOptimizationJob
+	origin    string
+	start     geo.Point
+	end       geo.Point
+	departure time.Time
+	stops     []models.RouteStop
+}
+
+// EmergencyInsertionService fits urgent visits into the day's already-planned routes
+type EmergencyInsertionService struct {
+	jobRepo           *repository.RouteOptimizationJobRepository
+	visitScheduleRepo *repository.VisitScheduleRepository
+	patientRepo       *repository.PatientRepository
+	staffMemberRepo   *repository.StaffMemberRepository
+	conflictChecker   *ScheduleConflictChecker
+	travel            *LocalRouteOptimizer
+}
+
+// NewEmergencyInsertionService creates a new emergency insertion service
+func NewEmergencyInsertionService(
+	jobRepo *repository.RouteOptimizationJobRepository,
+	visitScheduleRepo *repository.VisitScheduleRepository,
+	patientRepo *repository.PatientRepository,
+	staffMemberRepo *repository.StaffMemberRepository,
+) *EmergencyInsertionService {
+	return &EmergencyInsertionService{
+		jobRepo:           jobRepo,
+		visitScheduleRepo: visitScheduleRepo,
+		patientRepo:       patientRepo,
+		staffMemberRepo:   staffMemberRepo,
+		conflictChecker:   NewScheduleConflictChecker(visitScheduleRepo, patientRepo),
+		travel:            NewLocalRouteOptimizer(),
+	}
+}
+
+// InsertEmergencyVisit evaluates the cheapest insertion of an emergency visit into each
+// on-duty staff member's remaining route for today. When requested, the chosen proposal is
+// applied: the visit is created, the route's other visits are resequenced and the new route
+// is recorded as an applied emergency_insertion job, in one commit.
+func (s *EmergencyInsertionService) InsertEmergencyVisit(ctx context.Context, req *models.EmergencyInsertionRequest, requestedBy string) (*models.EmergencyInsertionResult, error) {
+	urgency, ok := emergencyUrgencies[req.Urgency]
+	if !ok {
+		return nil, fmt.Errorf("invalid urgency: %s", req.Urgency)
+	}
+	if req.EstimatedDurationMinutes == 0 {
+		req.EstimatedDurationMinutes = 30
+	}
+	if req.EstimatedDurationMinutes < 5 || req.EstimatedDurationMinutes > 480 {
+		return nil, fmt.Errorf("estimated_duration_minutes must be between 5 and 480")
+	}
+
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestedBy, req.PatientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized emergency insertion attempt", map[string]interface{}{
+			"patient_id":   req.PatientID,
+			"requested_by": requestedBy,
+		})
+		return nil, fmt.Errorf("access denied: you do not have permission to schedule visits for this patient")
+	}
+
+	patient, err := s.patientRepo.GetPatientByID(ctx, req.PatientID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := patient.VisitGeolocation()
+	if err != nil || location == nil {
+		return nil, fmt.Errorf("patient has no geolocated address to route to")
+	}
+
+	now := time.Now().In(clinicTimeZone)
+	today := civil.DateOf(now)
+	deadline := emergencyDeadline(now, urgency)
+
+	emergency := models.RouteStop{
+		ScheduleID:      uuid.New().String(),
+		PatientID:       req.PatientID,
+		Location:        geo.Point{Latitude: location.Latitude, Longitude: location.Longitude},
+		WindowStart:     &now,
+		WindowEnd:       &deadline,
+		DurationMinutes: req.EstimatedDurationMinutes,
+		Priority:        urgency.priority,
+	}
+
+	candidates, err := s.candidateStaff(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.EmergencyInsertionResult{
+		PatientID: req.PatientID,
+		Urgency:   req.Urgency,
+		Deadline:  deadline,
+		Proposals: []*models.EmergencyInsertionProposal{},
+	}
+	for _, staff := range candidates {
+		route, err := s.remainingRoute(ctx, staff, today, now)
+		if err != nil {
+			return nil, err
+		}
+		if route == nil {
+			result.SkippedStaffIDs = append(result.SkippedStaffIDs, staff.StaffID)
+			continue
+		}
+
+		proposal := s.travel.cheapestInsertion(route, emergency, now, deadline, urgency.waitWeight)
+		proposal.StaffID = staff.StaffID
+		proposal.StaffName = staff.FamilyName + " " + staff.GivenName
+		if route.job != nil {
+			jobID := route.job.JobID
+			proposal.RouteJobID = &jobID
+		}
+		result.Proposals = append(result.Proposals, proposal)
+	}
+	sort.SliceStable(result.Proposals, func(i, j int) bool {
+		return betterInsertion(result.Proposals[i], result.Proposals[j])
+	})
+
+	if !req.Apply {
+		return result, nil
+	}
+
+	chosen, err := chooseProposal(result.Proposals, req.StaffID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, result, chosen, emergency, today, req.OverrideConflicts, requestedBy); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// candidateStaff resolves the requested staff, or every active on-duty staff member of the
+// requested role (clinical staff when no role is given)
+func (s *EmergencyInsertionService) candidateStaff(ctx context.Context, req *models.EmergencyInsertionRequest) ([]*models.StaffMember, error) {
+	var candidates []*models.StaffMember
+
+	if len(req.StaffIDs) > 0 {
+		for _, staffID := range req.StaffIDs {
+			staff, err := s.staffMemberRepo.GetByID(ctx, staffID)
+			if err != nil {
+				return nil, err
+			}
+			if staff.IsActive() {
+				candidates = append(candidates, staff)
+			}
+		}
+		return candidates, nil
+	}
+
+	roles := clinicalRoles
+	if req.Role != nil {
+		roles = []string{*req.Role}
+	}
+
+	active := "active"
+	for _, role := range roles {
+		role := role
+		for _, availability := range onDutyAvailability {
+			availability := availability
+			staff, err := s.staffMemberRepo.List(ctx, &models.StaffMemberFilter{
+				Role:               &role,
+				AvailabilityStatus: &availability,
+				AccountStatus:      &active,
+				Limit:              500,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list on-duty staff: %w", err)
+			}
+			candidates = append(candidates, staff...)
+		}
+	}
+
+	return candidates, nil
+}
+
+// remainingRoute rebuilds a staff member's outstanding visits from the latest applied
+// route, followed by any open visits assigned since. Returns nil when there is no known
+// position to route the staff member from.
+func (s *EmergencyInsertionService) remainingRoute(ctx context.Context, staff *models.StaffMember, today civil.Date, now time.Time) (*remainingRoute, error) {
+	job, err := s.jobRepo.GetLatestApplied(ctx, staff.StaffID, today)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.visitScheduleRepo.ListStaffDay(ctx, staff.StaffID, today)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.VisitSchedule, len(schedules))
+	for _, schedule := range schedules {
+		byID[schedule.ScheduleID] = schedule
+	}
+
+	// Applied order first, then visits outside the applied route in planned start order
+	var ordered []*models.VisitSchedule
+	seen := make(map[string]bool)
+	if job != nil && len(job.OptimizedRoute) > 0 {
+		var applied models.RouteOptimizationResult
+		if err := json.Unmarshal(job.OptimizedRoute, &applied); err == nil {
+			for _, r := range applied.Routes {
+				for _, visit := range r.Visits {
+					if schedule, ok := byID[visit.ScheduleID]; ok && !seen[visit.ScheduleID] {
+						ordered = append(ordered, schedule)
+						seen[visit.ScheduleID] = true
+					}
+				}
+			}
+		}
+	}
+	for _, schedule := range schedules {
+		if !seen[schedule.ScheduleID] {
+			ordered = append(ordered, schedule)
+		}
+	}
+
+	route := &remainingRoute{staff: staff, job: job, departure: now}
+	locations := make(map[string]*geo.Point)
+	originSet := false
+
+	for _, schedule := range ordered {
+		loc := s.patientLocation(ctx, schedule.PatientID, locations)
+
+		switch schedule.Status {
+		case "completed":
+			continue
+		case "in_progress":
+			// The staff member sets off from this patient once the visit ends
+			if loc != nil {
+				route.origin = "in_progress_visit"
+				route.start = *loc
+				if schedule.ActualArrivalAt.Valid {
+					free := schedule.ActualArrivalAt.Time.Add(time.Duration(schedule.EstimatedDurationMinutes) * time.Minute)
+					if free.After(now) {
+						route.departure = free
+					}
+				}
+				originSet = true
+			}
+			continue
+		}
+
+		if loc == nil {
+			continue
+		}
+		stop := models.RouteStop{
+			ScheduleID:      schedule.ScheduleID,
+			PatientID:       schedule.PatientID,
+			Location:        *loc,
+			DurationMinutes: schedule.EstimatedDurationMinutes,
+			Priority:        schedule.PriorityScore,
+		}
+		if schedule.TimeWindowStart.Valid {
+			start := schedule.TimeWindowStart.Time
+			stop.WindowStart = &start
+		}
+		if schedule.TimeWindowEnd.Valid {
+			end := schedule.TimeWindowEnd.Time
+			stop.WindowEnd = &end
+		}
+		route.stops = append(route.stops, stop)
+	}
+
+	if !originSet {
+		current := currentLocation(staff, now)
+		switch {
+		case current != nil:
+			route.origin = "current_location"
+			route.start = *current
+		case job != nil && job.StartLatitude != nil && job.StartLongitude != nil:
+			route.origin = "route_start"
+			route.start = geo.Point{Latitude: *job.StartLatitude, Longitude: *job.StartLongitude}
+			if job.TargetStartTime != nil && job.TargetStartTime.After(now) {
+				route.departure = *job.TargetStartTime
+			}
+		default:
+			return nil, nil
+		}
+	}
+
+	// Return to the applied route's end point; without one, the day ends where it started
+	route.end = route.start
+	if job != nil && job.EndLatitude != nil && job.EndLongitude != nil {
+		route.end = geo.Point{Latitude: *job.EndLatitude, Longitude: *job.EndLongitude}
+	}
+
+	return route, nil
+}
+
+// currentLocation is the staff member's last reported position, or nil when none was
+// reported or it is too old to route from
+func currentLocation(staff *models.StaffMember, now time.Time) *geo.Point {
+	if staff.CurrentLatitude == nil || staff.CurrentLongitude == nil || staff.LastLocationUpdate == nil {
+		return nil
+	}
+	if now.Sub(*staff.LastLocationUpdate) > locationStaleAfter {
+		return nil
+	}
+	return &geo.Point{Latitude: *staff.CurrentLatitude, Longitude: *staff.CurrentLongitude}
+}
+
+// patientLocation resolves (and caches) a patient's visit coordinates
+func (s *EmergencyInsertionService) patientLocation(ctx context.Context, patientID string, cache map[string]*geo.Point) *geo.Point {
+	if loc, ok := cache[patientID]; ok {
+		return loc
+	}
+
+	var loc *geo.Point
+	if patient, err := s.patientRepo.GetPatientByID(ctx, patientID); err == nil {
+		if g, err := patient.VisitGeolocation(); err == nil && g != nil {
+			loc = &geo.Point{Latitude: g.Latitude, Longitude: g.Longitude}
+		}
+	} else {
+		logger.WarnContext(ctx, "Failed to load patient for emergency insertion", map[string]interface{}{
+			"patient_id": patientID,
+			"error":      err.Error(),
+		})
+	}
+
+	cache[patientID] = loc
+	return loc
+}
+
+// apply creates the emergency visit and writes the chosen proposal's route, unless the new
+// route clashes with the staff member's other visits and the clash is not overridden
+func (s *EmergencyInsertionService) apply(
+	ctx context.Context,
+	result *models.EmergencyInsertionResult,
+	chosen *models.EmergencyInsertionProposal,
+	emergency models.RouteStop,
+	today civil.Date,
+	override bool,
+	requestedBy string,
+) error {
+	params, err := json.Marshal(map[string]interface{}{
+		"urgency":             result.Urgency,
+		"deadline":            result.Deadline,
+		"emergency_schedule":  emergency.ScheduleID,
+		"based_on_route_job":  chosen.RouteJobID,
+		"total_delay_seconds": chosen.TotalDelaySeconds,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal optimization params: %w", err)
+	}
+
+	scheduleIDs := make([]string, len(chosen.Route.Visits))
+	for i, visit := range chosen.Route.Visits {
+		scheduleIDs[i] = visit.ScheduleID
+	}
+
+	departure := time.Now()
+	if len(chosen.Route.Visits) > 0 {
+		first := chosen.Route.Visits[0]
+		departure = first.ArrivalTime.Add(-time.Duration(first.TravelDurationSeconds) * time.Second)
+	}
+
+	staffID := chosen.StaffID
+	job := &models.RouteOptimizationJob{
+		JobType:             "emergency_insertion",
+		JobStatus:           "completed",
+		OptimizerEngine:     OptimizerEngineLocal,
+		TargetDate:          today,
+		TargetStartTime:     &departure,
+		StaffID:             &staffID,
+		OptimizationParams:  params,
+		IncludedScheduleIDs: scheduleIDs,
+		CreatedBy:           &requestedBy,
+		StartedAt:           &departure,
+	}
+	// Keep the route's end point so later insertions return to the same base
+	if chosen.RouteJobID != nil {
+		basedOn, err := s.jobRepo.GetByID(ctx, *chosen.RouteJobID)
+		if err != nil {
+			return err
+		}
+		job.VehicleID = basedOn.VehicleID
+		job.EndLocationID = basedOn.EndLocationID
+		job.EndLatitude = basedOn.EndLatitude
+		job.EndLongitude = basedOn.EndLongitude
+	}
+
+	jobResult := &models.RouteOptimizationResult{
+		Engine: OptimizerEngineLocal,
+		Routes: []models.OptimizedRoute{chosen.Route},
+	}
+
+	schedule := &models.VisitSchedule{
+		ScheduleID:               emergency.ScheduleID,
+		PatientID:                emergency.PatientID,
+		VisitDate:                today,
+		VisitType:                "emergency",
+		TimeWindowStart:          spanner.NullTime{Time: *emergency.WindowStart, Valid: true},
+		TimeWindowEnd:            spanner.NullTime{Time: *emergency.WindowEnd, Valid: true},
+		EstimatedDurationMinutes: emergency.DurationMinutes,
+		AssignedStaffID:          spanner.NullString{StringVal: chosen.StaffID, Valid: true},
+		Status:                   "assigned",
+		PriorityScore:            emergency.Priority,
+	}
+	if job.VehicleID != nil {
+		schedule.AssignedVehicleID = spanner.NullString{StringVal: *job.VehicleID, Valid: true}
+	}
+
+	// Every visit on the new route points at the new job, including the emergency visit itself
+	job.JobID = uuid.New().String()
+	resequenced := make(map[string]json.RawMessage, len(chosen.Route.Visits))
+	var candidates []*models.VisitSchedule
+	for _, visit := range chosen.Route.Visits {
+		candidate := *schedule
+		if visit.ScheduleID != schedule.ScheduleID {
+			existing, err := s.visitScheduleRepo.GetByScheduleID(ctx, visit.ScheduleID)
+			if err != nil {
+				return err
+			}
+			candidate = *existing
+		}
+		candidate.TimeWindowStart = spanner.NullTime{Time: visit.StartTime, Valid: true}
+		candidates = append(candidates, &candidate)
+
+		raw, err := json.Marshal(models.ScheduleOptimizationResult{
+			JobID:         job.JobID,
+			Engine:        OptimizerEngineLocal,
+			Sequence:      visit.Sequence,
+			ArrivalTime:   visit.ArrivalTime,
+			StartTime:     visit.StartTime,
+			DepartureTime: visit.DepartureTime,
+			Late:          visit.Late,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal optimization result: %w", err)
+		}
+		if visit.ScheduleID == schedule.ScheduleID {
+			schedule.OptimizationResult = raw
+			continue
+		}
+		resequenced[visit.ScheduleID] = raw
+	}
+
+	// The route's visits are checked at their new start times against the rest of the day
+	if err := s.conflictChecker.Enforce(ctx, candidates, chosen.StaffID, override, requestedBy); err != nil {
+		return err
+	}
+
+	reason := "emergency insertion"
+	event := &models.VisitScheduleStatusEvent{
+		ScheduleID: schedule.ScheduleID,
+		PatientID:  schedule.PatientID,
+		FromStatus: "draft",
+		ToStatus:   schedule.Status,
+		Reason:     &reason,
+		OccurredAt: time.Now(),
+		ChangedBy:  requestedBy,
+	}
+	if err := s.visitScheduleRepo.InsertEmergencyVisit(ctx, schedule, event, resequenced, job, jobResult, requestedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to apply emergency insertion", err, map[string]interface{}{
+			"patient_id": emergency.PatientID,
+			"staff_id":   chosen.StaffID,
+		})
+		return err
+	}
+
+	logger.InfoContext(ctx, "Emergency visit inserted", map[string]interface{}{
+		"schedule_id":         schedule.ScheduleID,
+		"staff_id":            chosen.StaffID,
+		"position":            chosen.Position,
+		"total_delay_seconds": chosen.TotalDelaySeconds,
+		"requested_by":        requestedBy,
+	})
+
+	jobID := job.JobID
+	result.Applied = chosen
+	result.Schedule = schedule
+	result.JobID = &jobID
+	return nil
+}
+
+// emergencyDeadline is the latest acceptable start of an emergency visit
+func emergencyDeadline(now time.Time, urgency emergencyUrgency) time.Time {
+	if urgency.responseWindow > 0 {
+		return now.Add(urgency.responseWindow)
+	}
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 23, 59, 0, 0, now.Location())
+}
+
+// chooseProposal picks the requested staff member's proposal, or the cheapest one
+func chooseProposal(proposals []*models.EmergencyInsertionProposal, staffID *string) (*models.EmergencyInsertionProposal, error) {
+	if len(proposals) == 0 {
+		return nil, fmt.Errorf("no on-duty staff with a known position to insert the visit for")
+	}
+	if staffID == nil {
+		return proposals[0], nil
+	}
+	for _, p := range proposals {
+		if p.StaffID == *staffID {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no proposal for staff %s", *staffID)
+}
+
+// betterInsertion prefers proposals that meet the deadline, then the lower cost
+func betterInsertion(a, b *models.EmergencyInsertionProposal) bool {
+	if a.MeetsDeadline != b.MeetsDeadline {
+		return a.MeetsDeadline
+	}
+	return a.Cost < b.Cost
+}
+
+// cheapestInsertion tries the emergency stop at every position of the remaining route,
+// keeping the other visits in their current order. The cost of a position is the added
+// travel time, plus the knock-on delay to other visits (counted twice for visits it makes
+// late), plus the emergency patient's wait weighted by urgency.
+func (o *LocalRouteOptimizer) cheapestInsertion(route *remainingRoute, emergency models.RouteStop, now, deadline time.Time, waitWeight float64) *models.EmergencyInsertionProposal {
+	problem := &models.RouteOptimizationProblem{
+		Start:         route.start,
+		End:           route.end,
+		DepartureTime: route.departure,
+		Objective:     objectiveMinimizeTravelTime,
+		Stops:         append(append([]models.RouteStop{}, route.stops...), emergency),
+	}
+	n := len(route.stops)
+
+	baseOrder := make([]int, n)
+	for i := range baseOrder {
+		baseOrder[i] = i
+	}
+	baseline, _ := o.simulate(problem, baseOrder)
+	baseTravel := routeTravelSeconds(baseline, problem.DepartureTime)
+	baseVisits := make(map[string]models.OptimizedVisit, n)
+	for _, visit := range baseline.Visits {
+		baseVisits[visit.ScheduleID] = visit
+	}
+
+	var best *models.EmergencyInsertionProposal
+	for pos := 0; pos <= n; pos++ {
+		order := make([]int, 0, n+1)
+		order = append(order, baseOrder[:pos]...)
+		order = append(order, n)
+		order = append(order, baseOrder[pos:]...)
+
+		candidate, _ := o.simulate(problem, order)
+		inserted := candidate.Visits[pos]
+
+		proposal := &models.EmergencyInsertionProposal{
+			Origin:             route.origin,
+			Position:           pos + 1,
+			Arrival:            inserted.ArrivalTime,
+			StartTime:          inserted.StartTime,
+			MeetsDeadline:      !inserted.StartTime.After(deadline),
+			AddedTravelSeconds: routeTravelSeconds(candidate, problem.DepartureTime) - baseTravel,
+			Delays:             []models.EmergencyVisitDelay{},
+			Route:              candidate,
+		}
+
+		var penalty float64
+		for _, visit := range candidate.Visits {
+			original, ok := baseVisits[visit.ScheduleID]
+			if !ok {
+				continue
+			}
+			delay := int64(visit.StartTime.Sub(original.StartTime).Seconds())
+			if delay <= 0 {
+				continue
+			}
+			proposal.TotalDelaySeconds += delay
+			newlyLate := visit.Late && !original.Late
+			if newlyLate {
+				penalty += float64(delay)
+			}
+			proposal.Delays = append(proposal.Delays, models.EmergencyVisitDelay{
+				ScheduleID:    visit.ScheduleID,
+				PatientID:     problem.Stops[order[visit.Sequence-1]].PatientID,
+				OriginalStart: original.StartTime,
+				NewStart:      visit.StartTime,
+				DelaySeconds:  delay,
+				Late:          visit.Late,
+			})
+		}
+
+		proposal.Cost = float64(proposal.AddedTravelSeconds) + float64(proposal.TotalDelaySeconds) + penalty +
+			waitWeight*inserted.StartTime.Sub(now).Seconds()
+
+		if best == nil || betterInsertion(proposal, best) {
+			best = proposal
+		}
+	}
+
+	return best
+}
+
+// routeTravelSeconds is the time spent driving on a route, including the return leg
+func routeTravelSeconds(route models.OptimizedRoute, departure time.Time) int64 {
+	var seconds int64
+	last := departure
+	for _, visit := range route.Visits {
+		seconds += visit.TravelDurationSeconds
+		last = visit.DepartureTime
+	}
+	return seconds + int64(route.ReturnTime.Sub(last).Seconds())
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/pkg/geo"
+)
+
+func TestCheapestInsertion(t *testing.T) {
+	base := geo.Point{Latitude: 35.6812, Longitude: 139.7671}
+	now := *routeTestTime(9, 0)
+
+	route := &remainingRoute{
+		origin:    "current_location",
+		start:     base,
+		end:       base,
+		departure: now,
+		stops: []models.RouteStop{
+			{ScheduleID: "near", PatientID: "p-near", Location: geo.Point{Latitude: 35.6912, Longitude: 139.7671}, DurationMinutes: 30, Priority: 5},
+			{ScheduleID: "far", PatientID: "p-far", Location: geo.Point{Latitude: 35.7112, Longitude: 139.7671}, DurationMinutes: 30, Priority: 5},
+		},
+	}
+
+	t.Run("Low urgency waits until the route passes by without delaying anyone", func(t *testing.T) {
+		deadline := now.Add(8 * time.Hour)
+		emergency := models.RouteStop{
+			ScheduleID: "emergency", PatientID: "p-emergency", Location: geo.Point{Latitude: 35.7012, Longitude: 139.7671},
+			DurationMinutes: 30, Priority: 8, WindowStart: &now, WindowEnd: &deadline,
+		}
+
+		proposal := NewLocalRouteOptimizer().cheapestInsertion(route, emergency, now, deadline, 0.5)
+		require.NotNil(t, proposal)
+
+		assert.Equal(t, 3, proposal.Position)
+		assert.True(t, proposal.MeetsDeadline)
+		assert.Equal(t, []string{"near", "far", "emergency"}, visitOrder(proposal.Route))
+		assert.Empty(t, proposal.Delays)
+	})
+
+	t.Run("Balanced weight inserts on the way, delaying later visits", func(t *testing.T) {
+		deadline := now.Add(3 * time.Hour)
+		emergency := models.RouteStop{
+			ScheduleID: "emergency", PatientID: "p-emergency", Location: geo.Point{Latitude: 35.7012, Longitude: 139.7671},
+			DurationMinutes: 30, Priority: 9, WindowStart: &now, WindowEnd: &deadline,
+		}
+
+		proposal := NewLocalRouteOptimizer().cheapestInsertion(route, emergency, now, deadline, 1)
+		require.NotNil(t, proposal)
+
+		assert.Equal(t, 2, proposal.Position)
+		assert.True(t, proposal.MeetsDeadline)
+		assert.Equal(t, []string{"near", "emergency", "far"}, visitOrder(proposal.Route))
+		require.Len(t, proposal.Delays, 1)
+		assert.Equal(t, "far", proposal.Delays[0].ScheduleID)
+		assert.Equal(t, "p-far", proposal.Delays[0].PatientID)
+		assert.Equal(t, proposal.Delays[0].DelaySeconds, proposal.TotalDelaySeconds)
+	})
+
+	t.Run("Critical urgency goes first even at the cost of delays", func(t *testing.T) {
+		deadline := now.Add(time.Hour)
+		emergency := models.RouteStop{
+			ScheduleID: "emergency", PatientID: "p-emergency", Location: geo.Point{Latitude: 35.7012, Longitude: 139.7671},
+			DurationMinutes: 30, Priority: 10, WindowStart: &now, WindowEnd: &deadline,
+		}
+
+		proposal := NewLocalRouteOptimizer().cheapestInsertion(route, emergency, now, deadline, 5)
+		require.NotNil(t, proposal)
+
+		assert.Equal(t, 1, proposal.Position)
+		assert.True(t, proposal.MeetsDeadline)
+		assert.Len(t, proposal.Delays, 2)
+	})
+
+	t.Run("Empty route visits the emergency patient directly", func(t *testing.T) {
+		deadline := now.Add(time.Hour)
+		emergency := models.RouteStop{
+			ScheduleID: "emergency", Location: geo.Point{Latitude: 35.7012, Longitude: 139.7671},
+			DurationMinutes: 30, Priority: 10, WindowStart: &now, WindowEnd: &deadline,
+		}
+
+		proposal := NewLocalRouteOptimizer().cheapestInsertion(&remainingRoute{start: base, end: base, departure: now}, emergency, now, deadline, 5)
+		require.NotNil(t, proposal)
+
+		assert.Equal(t, 1, proposal.Position)
+		assert.Empty(t, proposal.Delays)
+		assert.Equal(t, int64(0), proposal.TotalDelaySeconds)
+	})
+}
+
+func TestBetterInsertion(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        *models.EmergencyInsertionProposal
+		b        *models.EmergencyInsertionProposal
+		expected bool
+	}{
+		{"Meeting the deadline beats cost", &models.EmergencyInsertionProposal{MeetsDeadline: true, Cost: 900}, &models.EmergencyInsertionProposal{Cost: 100}, true},
+		{"Missing the deadline loses", &models.EmergencyInsertionProposal{Cost: 100}, &models.EmergencyInsertionProposal{MeetsDeadline: true, Cost: 900}, false},
+		{"Lower cost wins otherwise", &models.EmergencyInsertionProposal{MeetsDeadline: true, Cost: 100}, &models.EmergencyInsertionProposal{MeetsDeadline: true, Cost: 200}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, betterInsertion(tt.a, tt.b))
+		})
+	}
+}
+
+func TestEmergencyDeadline(t *testing.T) {
+	now := *routeTestTime(10, 15)
+
+	assert.Equal(t, now.Add(time.Hour), emergencyDeadline(now, emergencyUrgencies["critical"]))
+	assert.Equal(t, *routeTestTime(23, 59), emergencyDeadline(now, emergencyUrgencies["same_day"]))
+}
+
+func TestChooseProposal(t *testing.T) {
+	proposals := []*models.EmergencyInsertionProposal{{StaffID: "staff-a"}, {StaffID: "staff-b"}}
+
+	chosen, err := chooseProposal(proposals, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "staff-a", chosen.StaffID)
+
+	staffID := "staff-b"
+	chosen, err = chooseProposal(proposals, &staffID)
+	require.NoError(t, err)
+	assert.Equal(t, "staff-b", chosen.StaffID)
+
+	missing := "staff-c"
+	_, err = chooseProposal(proposals, &missing)
+	assert.Error(t, err)
+
+	_, err = chooseProposal(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestCurrentLocation(t *testing.T) {
+	now := *routeTestTime(10, 15)
+	lat, lng := 35.6812, 139.7671
+	staffAt := func(reported *time.Time) *models.StaffMember {
+		return &models.StaffMember{CurrentLatitude: &lat, CurrentLongitude: &lng, LastLocationUpdate: reported}
+	}
+
+	recent := now.Add(-10 * time.Minute)
+	stale := now.Add(-2 * time.Hour)
+
+	assert.Equal(t, &geo.Point{Latitude: lat, Longitude: lng}, currentLocation(staffAt(&recent), now))
+	assert.Nil(t, currentLocation(staffAt(&stale), now))
+	assert.Nil(t, currentLocation(staffAt(nil), now))
+	assert.Nil(t, currentLocation(&models.StaffMember{LastLocationUpdate: &recent}, now))
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/blobstore"
+	"github.com/visitas/backend/pkg/imaging"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// ErrInboundReferralsUnavailable is returned when no blob store or key wrapper is configured
+var ErrInboundReferralsUnavailable = errors.New("inbound referrals are not available: blob storage or encryption key is not configured")
+
+// referralItemKinds lists the kinds of items that can be extracted from an inbound referral
+var referralItemKinds = map[string]bool{
+	models.ReferralItemKindCondition:  true,
+	models.ReferralItemKindAllergy:    true,
+	models.ReferralItemKindMedication: true,
+}
+
+// InboundReferralService takes in referral letters received from other providers. The
+// scanned or electronic letter is stored encrypted like record attachments. Problems,
+// allergies and medications read from it are kept as pending items until staff review
+// them; an accepted item is created as a condition, allergy or medication order.
+type InboundReferralService struct {
+	intakeRepo             *repository.InboundReferralRepository
+	patientRepo            *repository.PatientRepository
+	auditRepo              *repository.AuditRepository
+	conditionService       *MedicalConditionService
+	allergyService         *AllergyIntoleranceService
+	medicationOrderService *MedicationOrderService
+	blobs                  *blobstore.SealedStore
+}
+
+// NewInboundReferralService creates a new inbound referral service. Receiving referrals
+// and downloading their documents is reported as not available when blobs is nil.
+func NewInboundReferralService(
+	intakeRepo *repository.InboundReferralRepository,
+	patientRepo *repository.PatientRepository,
+	auditRepo *repository.AuditRepository,
+	conditionService *MedicalConditionService,
+	allergyService *AllergyIntoleranceService,
+	medicationOrderService *MedicationOrderService,
+	blobs *blobstore.SealedStore,
+) *InboundReferralService {
+	return &InboundReferralService{
+		intakeRepo:             intakeRepo,
+		patientRepo:            patientRepo,
+		auditRepo:              auditRepo,
+		conditionService:       conditionService,
+		allergyService:         allergyService,
+		medicationOrderService: medicationOrderService,
+		blobs:                  blobs,
+	}
+}
+
+// ReceiveReferral stores a received referral document with the items read from it,
+// pending review
+func (s *InboundReferralService) ReceiveReferral(ctx context.Context, patientID string, upload *models.InboundReferralUpload, receivedBy string) (*models.InboundReferral, error) {
+	if s.blobs == nil {
+		return nil, ErrInboundReferralsUnavailable
+	}
+	if err := s.checkAccess(ctx, patientID, receivedBy); err != nil {
+		return nil, err
+	}
+
+	intake, data, err := newInboundReferral(patientID, upload, receivedBy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storeDocument(ctx, intake, data); err != nil {
+		return nil, err
+	}
+
+	if err := s.intakeRepo.Create(ctx, intake); err != nil {
+		logger.ErrorContext(ctx, "Failed to record inbound referral", err, map[string]interface{}{
+			"patient_id": patientID,
+		})
+		if delErr := s.blobs.Delete(ctx, intake.BlobKey); delErr != nil {
+			logger.WarnContext(ctx, "Failed to remove orphaned referral document blob", map[string]interface{}{
+				"blob_key": intake.BlobKey,
+				"error":    delErr.Error(),
+			})
+		}
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Inbound referral received", map[string]interface{}{
+		"intake_id":    intake.IntakeID,
+		"patient_id":   patientID,
+		"content_type": intake.ContentType,
+		"items":        len(intake.Items),
+		"received_by":  receivedBy,
+	})
+
+	return intake, nil
+}
+
+// ListReferrals lists a patient's inbound referrals with access control
+func (s *InboundReferralService) ListReferrals(ctx context.Context, patientID string, filter *models.InboundReferralFilter, requestorID string) ([]*models.InboundReferral, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+	return s.intakeRepo.List(ctx, patientID, filter)
+}
+
+// GetReferral retrieves an inbound referral with its items with access control
+func (s *InboundReferralService) GetReferral(ctx context.Context, patientID, intakeID, requestorID string) (*models.InboundReferral, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+	return s.intakeRepo.GetByID(ctx, patientID, intakeID)
+}
+
+// DownloadDocument decrypts a received referral document and checks it against its
+// recorded hash. Every attempt that passes the access check is written to the patient
+// access audit log.
+func (s *InboundReferralService) DownloadDocument(ctx context.Context, patientID, intakeID, requestorID, ipAddress, userAgent string) (*models.AttachmentContent, error) {
+	if s.blobs == nil {
+		return nil, ErrInboundReferralsUnavailable
+	}
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+
+	content, err := s.readDocument(ctx, patientID, intakeID)
+
+	fields, _ := json.Marshal([]string{"inbound_referral:document"})
+	auditLog := &repository.AuditLog{
+		EventTime:      time.Now(),
+		ActorID:        requestorID,
+		Action:         repository.AuditActionDownload,
+		ResourceID:     intakeID,
+		PatientID:      patientID,
+		AccessedFields: fields,
+		Success:        err == nil,
+		IPAddress:      ipAddress,
+		UserAgent:      userAgent,
+	}
+	if err != nil {
+		auditLog.ErrorMessage = err.Error()
+	}
+	if logErr := s.auditRepo.LogAccess(ctx, auditLog); logErr != nil {
+		logger.ErrorContext(ctx, "Failed to write audit log", logErr, map[string]interface{}{
+			"patient_id": patientID,
+			"intake_id":  intakeID,
+			"user_id":    requestorID,
+		})
+	}
+
+	return content, err
+}
+
+// AddItems adds items read from a referral after it was received, for review
+func (s *InboundReferralService) AddItems(ctx context.Context, patientID, intakeID string, inputs []models.ReferralIntakeItemInput, addedBy string) (*models.InboundReferral, error) {
+	if err := s.checkAccess(ctx, patientID, addedBy); err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("items are required")
+	}
+	items, err := newIntakeItems(inputs)
+	if err != nil {
+		return nil, err
+	}
+	return s.intakeRepo.AddItems(ctx, patientID, intakeID, items, addedBy)
+}
+
+// AcceptItem creates the condition, allergy or medication order a reviewer mapped a
+// pending item to and records it on the item. If the item was resolved concurrently,
+// the created record is removed again.
+func (s *InboundReferralService) AcceptItem(ctx context.Context, patientID, intakeID, itemID string, req *models.ReferralItemAcceptRequest, reviewedBy string) (*models.InboundReferral, error) {
+	if err := s.checkAccess(ctx, patientID, reviewedBy); err != nil {
+		return nil, err
+	}
+
+	intake, err := s.intakeRepo.GetByID(ctx, patientID, intakeID)
+	if err != nil {
+		return nil, err
+	}
+	item, err := intake.PendingItem(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	provenance := fmt.Sprintf("%s からの紹介状より: %s", intake.ReferringInstitution, item.Text)
+	var resourceID string
+	var undo func() error
+	switch item.Kind {
+	case models.ReferralItemKindCondition:
+		if req.Condition == nil || req.Allergy != nil || req.MedicationOrder != nil {
+			return nil, fmt.Errorf("a condition is required to accept a condition item")
+		}
+		req.Condition.PatientID = patientID
+		if req.Condition.ClinicalNotes == "" {
+			req.Condition.ClinicalNotes = provenance
+		}
+		condition, err := s.conditionService.CreateCondition(ctx, req.Condition, reviewedBy)
+		if err != nil {
+			return nil, err
+		}
+		resourceID = condition.ConditionID
+		undo = func() error { return s.conditionService.DeleteCondition(ctx, resourceID, reviewedBy) }
+	case models.ReferralItemKindAllergy:
+		if req.Allergy == nil || req.Condition != nil || req.MedicationOrder != nil {
+			return nil, fmt.Errorf("an allergy is required to accept an allergy item")
+		}
+		req.Allergy.PatientID = patientID
+		if req.Allergy.ClinicalNotes == "" {
+			req.Allergy.ClinicalNotes = provenance
+		}
+		allergy, err := s.allergyService.CreateAllergy(ctx, req.Allergy, reviewedBy)
+		if err != nil {
+			return nil, err
+		}
+		resourceID = allergy.AllergyID
+		undo = func() error { return s.allergyService.DeleteAllergy(ctx, resourceID, reviewedBy) }
+	case models.ReferralItemKindMedication:
+		if req.MedicationOrder == nil || req.Condition != nil || req.Allergy != nil {
+			return nil, fmt.Errorf("a medication_order is required to accept a medication item")
+		}
+		order, err := s.medicationOrderService.CreateMedicationOrder(ctx, patientID, req.MedicationOrder, reviewedBy)
+		if err != nil {
+			return nil, err
+		}
+		resourceID = order.OrderID
+		undo = func() error {
+			return s.medicationOrderService.DeleteMedicationOrder(ctx, patientID, resourceID, reviewedBy)
+		}
+	default:
+		return nil, fmt.Errorf("invalid item kind: %s", item.Kind)
+	}
+
+	updated, err := s.intakeRepo.ResolveItem(ctx, patientID, intakeID, itemID, models.ReferralItemStatusAccepted, &resourceID, nil, reviewedBy)
+	if err != nil {
+		if undoErr := undo(); undoErr != nil {
+			logger.WarnContext(ctx, "Failed to remove record of unresolved referral item", map[string]interface{}{
+				"intake_id":   intakeID,
+				"item_id":     itemID,
+				"resource_id": resourceID,
+				"error":       undoErr.Error(),
+			})
+		}
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Referral item accepted", map[string]interface{}{
+		"intake_id":   intakeID,
+		"item_id":     itemID,
+		"kind":        item.Kind,
+		"resource_id": resourceID,
+		"patient_id":  patientID,
+		"reviewed_by": reviewedBy,
+	})
+
+	return updated, nil
+}
+
+// RejectItem marks a pending item as not taken over into the patient's records
+func (s *InboundReferralService) RejectItem(ctx context.Context, patientID, intakeID, itemID string, req *models.ReferralItemRejectRequest, reviewedBy string) (*models.InboundReferral, error) {
+	if err := s.checkAccess(ctx, patientID, reviewedBy); err != nil {
+		return nil, err
+	}
+	return s.intakeRepo.ResolveItem(ctx, patientID, intakeID, itemID, models.ReferralItemStatusRejected, nil, req.Note, reviewedBy)
+}
+
+func (s *InboundReferralService) readDocument(ctx context.Context, patientID, intakeID string) (*models.AttachmentContent, error) {
+	intake, err := s.intakeRepo.GetByID(ctx, patientID, intakeID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.openDocument(ctx, intake)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AttachmentContent{FileName: intake.FileName, ContentType: intake.ContentType, Data: data}, nil
+}
+
+// storeDocument seals a received document and records its key, hash and size on the referral
+func (s *InboundReferralService) storeDocument(ctx context.Context, intake *models.InboundReferral, data []byte) error {
+	sealed, err := s.blobs.Put(ctx, intake.BlobKey, data, inboundReferralAAD(intake))
+	if err != nil {
+		return fmt.Errorf("failed to store referral document: %w", err)
+	}
+	intake.WrappedKey = sealed.WrappedKey
+	intake.SHA256 = sealed.SHA256
+	intake.SizeBytes = sealed.SizeBytes
+	return nil
+}
+
+// openDocument decrypts a referral's document and checks it against its recorded hash
+func (s *InboundReferralService) openDocument(ctx context.Context, intake *models.InboundReferral) ([]byte, error) {
+	data, err := s.blobs.Get(ctx, blobstore.Sealed{Key: intake.BlobKey, WrappedKey: intake.WrappedKey, SHA256: intake.SHA256}, inboundReferralAAD(intake))
+	switch {
+	case errors.Is(err, blobstore.ErrNotFound):
+		return nil, fmt.Errorf("referral document content not found")
+	case errors.Is(err, blobstore.ErrDigestMismatch):
+		return nil, fmt.Errorf("referral document content does not match its recorded hash")
+	case err != nil:
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *InboundReferralService) checkAccess(ctx context.Context, patientID, requestorID string) error {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":   patientID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized inbound referral access attempt", map[string]interface{}{
+			"patient_id":   patientID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("access denied: you do not have permission to access referrals of this patient")
+	}
+
+	return nil
+}
+
+// newInboundReferral validates an upload and sets up the referral it is received as,
+// waiting for review if any items were read from it. The document's metadata is
+// stripped; the returned data is what gets stored.
+func newInboundReferral(patientID string, upload *models.InboundReferralUpload, receivedBy string, now time.Time) (*models.InboundReferral, []byte, error) {
+	institution := strings.TrimSpace(upload.ReferringInstitution)
+	if institution == "" {
+		return nil, nil, fmt.Errorf("referring_institution is required")
+	}
+	if len(upload.Data) == 0 {
+		return nil, nil, fmt.Errorf("file is required")
+	}
+
+	// Trust the content, not the client's Content-Type
+	contentType := http.DetectContentType(upload.Data)
+	if !models.AttachmentContentTypes[contentType] {
+		return nil, nil, fmt.Errorf("unsupported file type: %s", contentType)
+	}
+	data, err := imaging.StripMetadata(upload.Data, contentType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	items, err := newIntakeItems(upload.Items)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	intakeID := uuid.New().String()
+	intake := &models.InboundReferral{
+		IntakeID:             intakeID,
+		PatientID:            patientID,
+		ReferringInstitution: institution,
+		ReferringPhysician:   upload.ReferringPhysician,
+		ReferralDate:         upload.ReferralDate,
+		Purpose:              upload.Purpose,
+		FileName:             attachmentFileName(upload.FileName),
+		ContentType:          contentType,
+		BlobKey:              fmt.Sprintf("inbound-referrals/%s/%s", patientID, intakeID),
+		Items:                items,
+		ReceivedAt:           now,
+		ReceivedBy:           receivedBy,
+		UpdatedAt:            now,
+	}
+	intake.UpdateReviewStatus(receivedBy, now)
+	return intake, data, nil
+}
+
+// newIntakeItems validates items read from a referral and sets them up for review
+func newIntakeItems(inputs []models.ReferralIntakeItemInput) ([]models.ReferralIntakeItem, error) {
+	items := make([]models.ReferralIntakeItem, 0, len(inputs))
+	for i, input := range inputs {
+		if !referralItemKinds[input.Kind] {
+			return nil, fmt.Errorf("items[%d]: invalid kind: %s", i, input.Kind)
+		}
+		text := strings.TrimSpace(input.Text)
+		if text == "" {
+			return nil, fmt.Errorf("items[%d]: text is required", i)
+		}
+		items = append(items, models.ReferralIntakeItem{
+			ItemID: uuid.New().String(),
+			Kind:   input.Kind,
+			Text:   text,
+			Status: models.ReferralItemStatusPending,
+		})
+	}
+	return items, nil
+}
+
+// inboundReferralAAD names a referral's document for sealing
+func inboundReferralAAD(intake *models.InboundReferral) []byte {
+	return blobstore.AAD(intake.PatientID, "inbound-referrals", intake.IntakeID)
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func testReferralUpload() *models.InboundReferralUpload {
+	return &models.InboundReferralUpload{
+		ReferringInstitution: " 中央病院 ",
+		FileName:             "referral.pdf",
+		Data:                 []byte("%PDF-1.4 紹介状"),
+		Items: []models.ReferralIntakeItemInput{
+			{Kind: models.ReferralItemKindCondition, Text: "2型糖尿病"},
+			{Kind: models.ReferralItemKindAllergy, Text: "ペニシリン"},
+		},
+	}
+}
+
+func TestInboundReferralReviewGate(t *testing.T) {
+	receivedAt := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("A referral without items needs no review", func(t *testing.T) {
+		upload := testReferralUpload()
+		upload.Items = nil
+		intake, _, err := newInboundReferral("p-1", upload, "s-1", receivedAt)
+		require.NoError(t, err)
+		assert.Equal(t, models.InboundReferralStatusReviewed, intake.Status)
+		assert.Equal(t, receivedAt, *intake.ReviewedAt)
+		assert.Equal(t, "s-1", *intake.ReviewedBy)
+	})
+
+	intake, _, err := newInboundReferral("p-1", testReferralUpload(), "s-1", receivedAt)
+	require.NoError(t, err)
+	assert.Equal(t, "中央病院", intake.ReferringInstitution)
+	assert.Equal(t, models.InboundReferralStatusPendingReview, intake.Status)
+	assert.Nil(t, intake.ReviewedAt)
+	assert.Nil(t, intake.ReviewedBy)
+
+	// Resolving one of two items leaves the referral waiting for review
+	condition, allergy := intake.Items[0].ItemID, intake.Items[1].ItemID
+	resourceID := "c-1"
+	acceptedAt := receivedAt.Add(time.Hour)
+	require.NoError(t, intake.ResolveItem(condition, models.ReferralItemStatusAccepted, &resourceID, nil, "s-2", acceptedAt))
+	intake.UpdateReviewStatus("s-2", acceptedAt)
+	assert.Equal(t, models.InboundReferralStatusPendingReview, intake.Status)
+	assert.Equal(t, 1, intake.PendingItems())
+
+	t.Run("Resolved items are not resolved again", func(t *testing.T) {
+		_, err := intake.PendingItem(condition)
+		assert.EqualError(t, err, "CONFLICT: referral item has already been accepted")
+		err = intake.ResolveItem(condition, models.ReferralItemStatusRejected, nil, nil, "s-2", acceptedAt)
+		assert.EqualError(t, err, "CONFLICT: referral item has already been accepted")
+	})
+
+	t.Run("Unknown item", func(t *testing.T) {
+		_, err := intake.PendingItem("missing")
+		assert.EqualError(t, err, "referral item not found")
+	})
+
+	// The reviewer of the last item is recorded as the referral's reviewer
+	rejectedAt := acceptedAt.Add(time.Hour)
+	require.NoError(t, intake.ResolveItem(allergy, models.ReferralItemStatusRejected, nil, nil, "s-3", rejectedAt))
+	intake.UpdateReviewStatus("s-3", rejectedAt)
+	assert.Equal(t, models.InboundReferralStatusReviewed, intake.Status)
+	assert.Equal(t, rejectedAt, *intake.ReviewedAt)
+	assert.Equal(t, "s-3", *intake.ReviewedBy)
+	assert.Equal(t, &resourceID, intake.Item(condition).ResourceID)
+
+	// Items added later put the referral back up for review
+	items, err := newIntakeItems([]models.ReferralIntakeItemInput{{Kind: models.ReferralItemKindMedication, Text: "メトホルミン"}})
+	require.NoError(t, err)
+	intake.Items = append(intake.Items, items...)
+	intake.UpdateReviewStatus("s-2", rejectedAt.Add(time.Hour))
+	assert.Equal(t, models.InboundReferralStatusPendingReview, intake.Status)
+	assert.Nil(t, intake.ReviewedAt)
+	assert.Nil(t, intake.ReviewedBy)
+}
+
+func TestNewInboundReferralValidation(t *testing.T) {
+	now := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	upload := testReferralUpload()
+	upload.ReferringInstitution = " "
+	_, _, err := newInboundReferral("p-1", upload, "s-1", now)
+	assert.EqualError(t, err, "referring_institution is required")
+
+	upload = testReferralUpload()
+	upload.Data = []byte("plain text")
+	_, _, err = newInboundReferral("p-1", upload, "s-1", now)
+	assert.ErrorContains(t, err, "unsupported file type")
+}
+
+func TestInboundReferralDocumentRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	service := NewInboundReferralService(nil, nil, nil, nil, nil, nil, newTestSealedStore(t))
+	intake, data, err := newInboundReferral("p-1", testReferralUpload(), "s-1", time.Now())
+	require.NoError(t, err)
+
+	require.NoError(t, service.storeDocument(ctx, intake, data))
+	assert.Equal(t, "inbound-referrals/p-1/"+intake.IntakeID, intake.BlobKey)
+	assert.Equal(t, int64(len(data)), intake.SizeBytes)
+	assert.Len(t, intake.SHA256, 64)
+
+	opened, err := service.openDocument(ctx, intake)
+	require.NoError(t, err)
+	assert.Equal(t, data, opened)
+
+	t.Run("A document does not open as another patient's referral", func(t *testing.T) {
+		other := *intake
+		other.PatientID = "p-2"
+		_, err := service.openDocument(ctx, &other)
+		assert.Error(t, err)
+	})
+
+	t.Run("Missing content", func(t *testing.T) {
+		missing := *intake
+		missing.BlobKey = "inbound-referrals/p-1/missing"
+		_, err := service.openDocument(ctx, &missing)
+		assert.EqualError(t, err, "referral document content not found")
+	})
+}
+
+func TestNewIntakeItems(t *testing.T) {
+	items, err := newIntakeItems([]models.ReferralIntakeItemInput{
+		{Kind: models.ReferralItemKindCondition, Text: " 2型糖尿病 "},
+		{Kind: models.ReferralItemKindMedication, Text: "メトホルミン 500mg 1日2回"},
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "2型糖尿病", items[0].Text)
+	assert.Equal(t, models.ReferralItemStatusPending, items[0].Status)
+	assert.NotEmpty(t, items[0].ItemID)
+	assert.NotEqual(t, items[0].ItemID, items[1].ItemID)
+
+	_, err = newIntakeItems([]models.ReferralIntakeItemInput{{Kind: "procedure", Text: "PEG"}})
+	assert.EqualError(t, err, "items[0]: invalid kind: procedure")
+
+	_, err = newIntakeItems([]models.ReferralIntakeItemInput{{Kind: models.ReferralItemKindAllergy, Text: " "}})
+	assert.EqualError(t, err, "items[0]: text is required")
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/geo"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+var (
+	validLocationTypes = map[string]bool{
+		"clinic":        true,
+		"office":        true,
+		"pharmacy":      true,
+		"hospital":      true,
+		"care_facility": true,
+		"warehouse":     true,
+	}
+	validLocationStatuses = map[string]bool{
+		"active":           true,
+		"inactive":         true,
+		"temporary_closed": true,
+		"relocated":        true,
+	}
+)
+
+// LogisticsLocationService handles business logic for logistics locations
+type LogisticsLocationService struct {
+	locationRepo *repository.LogisticsLocationRepository
+}
+
+// NewLogisticsLocationService creates a new logistics location service
+func NewLogisticsLocationService(locationRepo *repository.LogisticsLocationRepository) *LogisticsLocationService {
+	return &LogisticsLocationService{
+		locationRepo: locationRepo,
+	}
+}
+
+// CreateLocation creates a new logistics location
+func (s *LogisticsLocationService) CreateLocation(ctx context.Context, req *models.LogisticsLocationCreateRequest, createdBy string) (*models.LogisticsLocation, error) {
+	if req.LocationName == "" {
+		return nil, fmt.Errorf("location_name is required")
+	}
+	if req.LocationStatus == "" {
+		req.LocationStatus = "active"
+	}
+
+	if err := validateLocationEnums(ctx, &req.LocationType, &req.LocationStatus); err != nil {
+		return nil, err
+	}
+	if !(geo.Point{Latitude: req.Latitude, Longitude: req.Longitude}).Valid() {
+		return nil, fmt.Errorf("invalid coordinates: latitude must be within ±90 and longitude within ±180")
+	}
+	if err := validateLocationTimes(req.DefaultDepartureTime, req.DefaultReturnTime); err != nil {
+		return nil, err
+	}
+
+	candidate := &models.LogisticsLocation{ServiceArea: req.ServiceArea, MaxServiceRadiusKm: req.MaxServiceRadiusKm}
+	if _, err := candidate.ParseServiceArea(); err != nil {
+		return nil, err
+	}
+
+	location, err := s.locationRepo.Create(ctx, req, createdBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create logistics location", err, map[string]interface{}{
+			"location_name": req.LocationName,
+			"created_by":    createdBy,
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Logistics location created successfully", map[string]interface{}{
+		"location_id": location.LocationID,
+		"created_by":  createdBy,
+	})
+
+	return location, nil
+}
+
+// GetLocation retrieves a logistics location by ID
+func (s *LogisticsLocationService) GetLocation(ctx context.Context, locationID string) (*models.LogisticsLocation, error) {
+	location, err := s.locationRepo.GetByID(ctx, locationID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get logistics location", err, map[string]interface{}{
+			"location_id": locationID,
+		})
+		return nil, err
+	}
+
+	return location, nil
+}
+
+// ListLocations retrieves logistics locations with filters
+func (s *LogisticsLocationService) ListLocations(ctx context.Context, filter *models.LogisticsLocationFilter) ([]*models.LogisticsLocation, error) {
+	locations, err := s.locationRepo.List(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list logistics locations", err, map[string]interface{}{})
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+// UpdateLocation updates a logistics location
+func (s *LogisticsLocationService) UpdateLocation(ctx context.Context, locationID string, req *models.LogisticsLocationUpdateRequest, updatedBy string) (*models.LogisticsLocation, error) {
+	if err := validateLocationEnums(ctx, req.LocationType, req.LocationStatus); err != nil {
+		return nil, err
+	}
+	if err := validateLocationTimes(req.DefaultDepartureTime, req.DefaultReturnTime); err != nil {
+		return nil, err
+	}
+
+	if req.Latitude != nil || req.Longitude != nil || len(req.ServiceArea) > 0 || req.MaxServiceRadiusKm != nil {
+		existing, err := s.locationRepo.GetByID(ctx, locationID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Validate the merged state so a partial update cannot leave invalid coordinates or area
+		if req.Latitude != nil {
+			existing.Latitude = *req.Latitude
+		}
+		if req.Longitude != nil {
+			existing.Longitude = *req.Longitude
+		}
+		if len(req.ServiceArea) > 0 {
+			existing.ServiceArea = req.ServiceArea
+		}
+		if req.MaxServiceRadiusKm != nil {
+			existing.MaxServiceRadiusKm = req.MaxServiceRadiusKm
+		}
+		if !existing.Point().Valid() {
+			return nil, fmt.Errorf("invalid coordinates: latitude must be within ±90 and longitude within ±180")
+		}
+		if _, err := existing.ParseServiceArea(); err != nil {
+			return nil, err
+		}
+	}
+
+	location, err := s.locationRepo.Update(ctx, locationID, req, updatedBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to update logistics location", err, map[string]interface{}{
+			"location_id": locationID,
+			"updated_by":  updatedBy,
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Logistics location updated successfully", map[string]interface{}{
+		"location_id": locationID,
+		"updated_by":  updatedBy,
+	})
+
+	return location, nil
+}
+
+// DeleteLocation soft-deletes a logistics location
+func (s *LogisticsLocationService) DeleteLocation(ctx context.Context, locationID, deletedBy string) error {
+	if err := s.locationRepo.Delete(ctx, locationID, deletedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete logistics location", err, map[string]interface{}{
+			"location_id": locationID,
+			"deleted_by":  deletedBy,
+		})
+		return err
+	}
+
+	logger.InfoContext(ctx, "Logistics location deleted successfully", map[string]interface{}{
+		"location_id": locationID,
+		"deleted_by":  deletedBy,
+	})
+
+	return nil
+}
+
+// FindNearest returns the active location of the given type closest to the point
+func (s *LogisticsLocationService) FindNearest(ctx context.Context, locationType string, point geo.Point, organizationID *string) (*models.NearestLocationResult, error) {
+	if err := validateLocationEnums(ctx, &locationType, nil); err != nil {
+		return nil, err
+	}
+	if !point.Valid() {
+		return nil, fmt.Errorf("invalid coordinates: latitude must be within ±90 and longitude within ±180")
+	}
+
+	locations, err := s.locationRepo.ListActive(ctx, &locationType, organizationID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list active logistics locations", err, map[string]interface{}{
+			"location_type": locationType,
+		})
+		return nil, err
+	}
+
+	var nearest *models.NearestLocationResult
+	for _, l := range locations {
+		distance := geo.DistanceKm(point, l.Point())
+		if nearest == nil || distance < nearest.DistanceKm {
+			nearest = &models.NearestLocationResult{Location: l, DistanceKm: distance}
+		}
+	}
+
+	if nearest == nil {
+		return nil, fmt.Errorf("no active %s location found", locationType)
+	}
+
+	return nearest, nil
+}
+
+// CheckServiceArea reports which active locations cover the requested point,
+// nearest first. The point may come from explicit coordinates or from an
+// address geolocation (e.g. when registering a patient residence).
+func (s *LogisticsLocationService) CheckServiceArea(ctx context.Context, req *models.ServiceAreaCheckRequest) (*models.ServiceAreaCheckResult, error) {
+	var point geo.Point
+	if req.Latitude != nil && req.Longitude != nil {
+		point = geo.Point{Latitude: *req.Latitude, Longitude: *req.Longitude}
+	} else if req.Address != nil && req.Address.Geolocation != nil {
+		point = geo.Point{Latitude: req.Address.Geolocation.Latitude, Longitude: req.Address.Geolocation.Longitude}
+	} else {
+		return nil, fmt.Errorf("latitude and longitude or address.geolocation is required")
+	}
+
+	if !point.Valid() {
+		return nil, fmt.Errorf("invalid coordinates: latitude must be within ±90 and longitude within ±180")
+	}
+	if err := validateLocationEnums(ctx, req.LocationType, nil); err != nil {
+		return nil, err
+	}
+
+	locations, err := s.locationRepo.ListActive(ctx, req.LocationType, req.OrganizationID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list active logistics locations", err, map[string]interface{}{})
+		return nil, err
+	}
+
+	result := &models.ServiceAreaCheckResult{
+		Latitude:          point.Latitude,
+		Longitude:         point.Longitude,
+		CoveringLocations: []*models.NearestLocationResult{},
+	}
+
+	for _, l := range locations {
+		covered, err := l.CoversPoint(point)
+		if err != nil {
+			// A malformed area on one location should not block the whole check
+			logger.WarnContext(ctx, "Skipping location with invalid service area", map[string]interface{}{
+				"location_id": l.LocationID,
+				"error":       err.Error(),
+			})
+			continue
+		}
+		if covered {
+			result.CoveringLocations = append(result.CoveringLocations, &models.NearestLocationResult{
+				Location:   l,
+				DistanceKm: geo.DistanceKm(point, l.Point()),
+			})
+		}
+	}
+
+	sort.Slice(result.CoveringLocations, func(i, j int) bool {
+		return result.CoveringLocations[i].DistanceKm < result.CoveringLocations[j].DistanceKm
+	})
+	result.Covered = len(result.CoveringLocations) > 0
+
+	return result, nil
+}
+
+// validateLocationEnums validates enumerated location fields; nil values are skipped
+func validateLocationEnums(ctx context.Context, locationType, locationStatus *string) error {
+	if locationType != nil && !validLocationTypes[*locationType] {
+		logger.WarnContext(ctx, "Invalid location type", map[string]interface{}{
+			"location_type": *locationType,
+		})
+		return fmt.Errorf("invalid location_type: %s", *locationType)
+	}
+	if locationStatus != nil && !validLocationStatuses[*locationStatus] {
+		logger.WarnContext(ctx, "Invalid location status", map[string]interface{}{
+			"location_status": *locationStatus,
+		})
+		return fmt.Errorf("invalid location_status: %s", *locationStatus)
+	}
+	return nil
+}
+
+// validateLocationTimes checks that departure/return times are HH:MM
+func validateLocationTimes(departure, ret *string) error {
+	if departure != nil {
+		if _, err := time.Parse("15:04", *departure); err != nil {
+			return fmt.Errorf("invalid default_departure_time format, expected HH:MM")
+		}
+	}
+	if ret != nil {
+		if _, err := time.Parse("15:04", *ret); err != nil {
+			return fmt.Errorf("invalid default_return_time format, expected HH:MM")
+		}
+	}
+	return nil
+}
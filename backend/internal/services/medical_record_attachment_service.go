@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/blobstore"
+	"github.com/visitas/backend/pkg/imaging"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// ErrAttachmentsUnavailable is returned when no blob store or key wrapper is configured
+var ErrAttachmentsUnavailable = errors.New("attachments are not available: blob storage or encryption key is not configured")
+
+// thumbnailMaxEdge is the longest side of attachment thumbnails, in pixels
+const thumbnailMaxEdge = 320
+
+// MedicalRecordAttachmentService handles photos and scanned documents attached to
+// medical records. Content is stripped of metadata, encrypted per object and stored in
+// a blob store; downloads are audit-logged.
+type MedicalRecordAttachmentService struct {
+	attachmentRepo    *repository.MedicalRecordAttachmentRepository
+	medicalRecordRepo *repository.MedicalRecordRepository
+	patientRepo       *repository.PatientRepository
+	auditRepo         *repository.AuditRepository
+	blobs             *blobstore.SealedStore
+}
+
+// NewMedicalRecordAttachmentService creates a new attachment service. Attachments are
+// reported as not available when blobs is nil.
+func NewMedicalRecordAttachmentService(
+	attachmentRepo *repository.MedicalRecordAttachmentRepository,
+	medicalRecordRepo *repository.MedicalRecordRepository,
+	patientRepo *repository.PatientRepository,
+	auditRepo *repository.AuditRepository,
+	blobs *blobstore.SealedStore,
+) *MedicalRecordAttachmentService {
+	return &MedicalRecordAttachmentService{
+		attachmentRepo:    attachmentRepo,
+		medicalRecordRepo: medicalRecordRepo,
+		patientRepo:       patientRepo,
+		auditRepo:         auditRepo,
+		blobs:             blobs,
+	}
+}
+
+// UploadAttachment strips, encrypts and stores a file and attaches it to a record.
+// Attachments are append-only evidence, so signed records accept them too.
+func (s *MedicalRecordAttachmentService) UploadAttachment(ctx context.Context, patientID, recordID string, upload *models.MedicalRecordAttachmentUpload, uploadedBy string) (*models.MedicalRecordAttachment, error) {
+	if s.blobs == nil {
+		return nil, ErrAttachmentsUnavailable
+	}
+	if err := s.checkAccess(ctx, patientID, recordID, uploadedBy); err != nil {
+		return nil, err
+	}
+	if _, err := s.medicalRecordRepo.GetByID(ctx, patientID, recordID); err != nil {
+		return nil, err
+	}
+
+	if !models.AttachmentCategories[upload.Category] {
+		return nil, fmt.Errorf("invalid category: %s", upload.Category)
+	}
+	if len(upload.Data) == 0 {
+		return nil, fmt.Errorf("file is required")
+	}
+
+	// Trust the content, not the client's Content-Type
+	contentType := http.DetectContentType(upload.Data)
+	if !models.AttachmentContentTypes[contentType] {
+		return nil, fmt.Errorf("unsupported file type: %s", contentType)
+	}
+
+	data, err := imaging.StripMetadata(upload.Data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	attachmentID := uuid.New().String()
+	attachment := &models.MedicalRecordAttachment{
+		AttachmentID: attachmentID,
+		RecordID:     recordID,
+		PatientID:    patientID,
+		Category:     upload.Category,
+		FileName:     attachmentFileName(upload.FileName),
+		ContentType:  contentType,
+		Caption:      upload.Caption,
+		BlobKey:      fmt.Sprintf("medical-records/%s/%s", recordID, attachmentID),
+		UploadedAt:   time.Now(),
+		UploadedBy:   uploadedBy,
+	}
+
+	var thumbnail []byte
+	if strings.HasPrefix(contentType, "image/") {
+		width, height, err := imaging.Dimensions(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image: %w", err)
+		}
+		w, h := int64(width), int64(height)
+		attachment.Width, attachment.Height = &w, &h
+
+		thumbnail, err = imaging.Thumbnail(data, thumbnailMaxEdge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image: %w", err)
+		}
+	}
+
+	sealed, err := s.blobs.Put(ctx, attachment.BlobKey, data, attachmentAAD(attachment, false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	attachment.WrappedKey = sealed.WrappedKey
+	attachment.SHA256 = sealed.SHA256
+	attachment.SizeBytes = sealed.SizeBytes
+	stored := []string{attachment.BlobKey}
+
+	if thumbnail != nil {
+		thumbnailKey := attachment.BlobKey + ".thumbnail"
+		sealedThumbnail, err := s.blobs.Put(ctx, thumbnailKey, thumbnail, attachmentAAD(attachment, true))
+		if err != nil {
+			s.removeBlobs(ctx, stored)
+			return nil, fmt.Errorf("failed to store attachment thumbnail: %w", err)
+		}
+		attachment.ThumbnailBlobKey = &thumbnailKey
+		attachment.ThumbnailWrappedKey = &sealedThumbnail.WrappedKey
+		attachment.HasThumbnail = true
+		stored = append(stored, thumbnailKey)
+	}
+
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		logger.ErrorContext(ctx, "Failed to record attachment", err, map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+		})
+		s.removeBlobs(ctx, stored)
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Medical record attachment uploaded", map[string]interface{}{
+		"attachment_id": attachmentID,
+		"record_id":     recordID,
+		"patient_id":    patientID,
+		"content_type":  contentType,
+		"size_bytes":    attachment.SizeBytes,
+		"uploaded_by":   uploadedBy,
+	})
+
+	return attachment, nil
+}
+
+// ListAttachments lists the attachments of a record with access control
+func (s *MedicalRecordAttachmentService) ListAttachments(ctx context.Context, patientID, recordID, requestorID string) ([]*models.MedicalRecordAttachment, error) {
+	if err := s.checkAccess(ctx, patientID, recordID, requestorID); err != nil {
+		return nil, err
+	}
+	return s.attachmentRepo.ListByRecord(ctx, patientID, recordID)
+}
+
+// GetAttachment retrieves the metadata of an attachment with access control
+func (s *MedicalRecordAttachmentService) GetAttachment(ctx context.Context, patientID, recordID, attachmentID, requestorID string) (*models.MedicalRecordAttachment, error) {
+	if err := s.checkAccess(ctx, patientID, recordID, requestorID); err != nil {
+		return nil, err
+	}
+	return s.attachmentRepo.GetByID(ctx, patientID, recordID, attachmentID)
+}
+
+// DownloadAttachment decrypts an attachment, or its thumbnail, for the requestor. Every
+// attempt that passes the access check is written to the patient access audit log.
+func (s *MedicalRecordAttachmentService) DownloadAttachment(ctx context.Context, patientID, recordID, attachmentID string, thumbnail bool, requestorID, ipAddress, userAgent string) (*models.AttachmentContent, error) {
+	if s.blobs == nil {
+		return nil, ErrAttachmentsUnavailable
+	}
+	if err := s.checkAccess(ctx, patientID, recordID, requestorID); err != nil {
+		return nil, err
+	}
+
+	content, err := s.readAttachment(ctx, patientID, recordID, attachmentID, thumbnail)
+
+	part := "content"
+	if thumbnail {
+		part = "thumbnail"
+	}
+	fields, _ := json.Marshal([]string{"attachment:" + part})
+	auditLog := &repository.AuditLog{
+		EventTime:      time.Now(),
+		ActorID:        requestorID,
+		Action:         repository.AuditActionDownload,
+		ResourceID:     attachmentID,
+		PatientID:      patientID,
+		AccessedFields: fields,
+		Success:        err == nil,
+		IPAddress:      ipAddress,
+		UserAgent:      userAgent,
+	}
+	if err != nil {
+		auditLog.ErrorMessage = err.Error()
+	}
+	if logErr := s.auditRepo.LogAccess(ctx, auditLog); logErr != nil {
+		logger.ErrorContext(ctx, "Failed to write audit log", logErr, map[string]interface{}{
+			"patient_id":    patientID,
+			"attachment_id": attachmentID,
+			"user_id":       requestorID,
+		})
+	}
+
+	return content, err
+}
+
+// DeleteAttachment soft-deletes an attachment of an unsigned record. The encrypted blobs
+// are kept for the medical record retention period.
+func (s *MedicalRecordAttachmentService) DeleteAttachment(ctx context.Context, patientID, recordID, attachmentID, deletedBy string) error {
+	if err := s.checkAccess(ctx, patientID, recordID, deletedBy); err != nil {
+		return err
+	}
+
+	record, err := s.medicalRecordRepo.GetByID(ctx, patientID, recordID)
+	if err != nil {
+		return err
+	}
+	if record.SignedAt != nil {
+		return repository.ErrMedicalRecordLocked
+	}
+
+	if _, err := s.attachmentRepo.GetByID(ctx, patientID, recordID, attachmentID); err != nil {
+		return err
+	}
+	if err := s.attachmentRepo.Delete(ctx, attachmentID, deletedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete attachment", err, map[string]interface{}{
+			"attachment_id": attachmentID,
+			"record_id":     recordID,
+		})
+		return err
+	}
+
+	logger.InfoContext(ctx, "Medical record attachment deleted", map[string]interface{}{
+		"attachment_id": attachmentID,
+		"record_id":     recordID,
+		"patient_id":    patientID,
+		"deleted_by":    deletedBy,
+	})
+
+	return nil
+}
+
+func (s *MedicalRecordAttachmentService) readAttachment(ctx context.Context, patientID, recordID, attachmentID string, thumbnail bool) (*models.AttachmentContent, error) {
+	attachment, err := s.attachmentRepo.GetByID(ctx, patientID, recordID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := blobstore.Sealed{Key: attachment.BlobKey, WrappedKey: attachment.WrappedKey, SHA256: attachment.SHA256}
+	content := &models.AttachmentContent{FileName: attachment.FileName, ContentType: attachment.ContentType}
+	if thumbnail {
+		if attachment.ThumbnailBlobKey == nil || attachment.ThumbnailWrappedKey == nil {
+			return nil, fmt.Errorf("thumbnail not found")
+		}
+		sealed = blobstore.Sealed{Key: *attachment.ThumbnailBlobKey, WrappedKey: *attachment.ThumbnailWrappedKey}
+		content.FileName = strings.TrimSuffix(attachment.FileName, filepath.Ext(attachment.FileName)) + "_thumbnail.jpg"
+		content.ContentType = "image/jpeg"
+	}
+
+	content.Data, err = s.blobs.Get(ctx, sealed, attachmentAAD(attachment, thumbnail))
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil, fmt.Errorf("attachment content not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// removeBlobs cleans up blobs of an upload that could not be recorded
+func (s *MedicalRecordAttachmentService) removeBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			logger.WarnContext(ctx, "Failed to remove orphaned attachment blob", map[string]interface{}{
+				"blob_key": key,
+				"error":    err.Error(),
+			})
+		}
+	}
+}
+
+func (s *MedicalRecordAttachmentService) checkAccess(ctx context.Context, patientID, recordID, requestorID string) error {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":   patientID,
+			"record_id":    recordID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized medical record attachment access attempt", map[string]interface{}{
+			"patient_id":   patientID,
+			"record_id":    recordID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("access denied: you do not have permission to access attachments of this medical record")
+	}
+
+	return nil
+}
+
+// attachmentAAD names an attachment's content, or its thumbnail, for sealing
+func attachmentAAD(attachment *models.MedicalRecordAttachment, thumbnail bool) []byte {
+	if thumbnail {
+		return blobstore.AAD(attachment.PatientID, attachment.RecordID, attachment.AttachmentID, "thumbnail")
+	}
+	return blobstore.AAD(attachment.PatientID, attachment.RecordID, attachment.AttachmentID)
+}
+
+// attachmentFileName keeps the base name of an uploaded file, without any client path
+func attachmentFileName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
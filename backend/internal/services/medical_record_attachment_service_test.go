@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func TestAttachmentFileName(t *testing.T) {
+	assert.Equal(t, "wound.jpg", attachmentFileName("wound.jpg"))
+	assert.Equal(t, "wound.jpg", attachmentFileName("../../etc/wound.jpg"))
+	assert.Equal(t, "scan.pdf", attachmentFileName(`C:\Users\nurse\scan.pdf`))
+	assert.Equal(t, "attachment", attachmentFileName(""))
+	assert.Equal(t, "attachment", attachmentFileName("  "))
+	assert.Len(t, attachmentFileName(strings.Repeat("a", 300)+".jpg"), 255)
+}
+
+func TestAttachmentAAD(t *testing.T) {
+	attachment := &models.MedicalRecordAttachment{AttachmentID: "a-1", RecordID: "r-1", PatientID: "p-1"}
+	assert.Equal(t, "p-1/r-1/a-1", string(attachmentAAD(attachment, false)))
+	assert.Equal(t, "p-1/r-1/a-1/thumbnail", string(attachmentAAD(attachment, true)))
+}
+
+func TestAttachmentsUnavailable(t *testing.T) {
+	service := NewMedicalRecordAttachmentService(nil, nil, nil, nil, nil)
+
+	_, err := service.UploadAttachment(context.Background(), "p-1", "r-1", &models.MedicalRecordAttachmentUpload{}, "staff-1")
+	require.ErrorIs(t, err, ErrAttachmentsUnavailable)
+
+	_, err = service.DownloadAttachment(context.Background(), "p-1", "r-1", "a-1", false, "staff-1", "", "")
+	require.ErrorIs(t, err, ErrAttachmentsUnavailable)
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/logger"
+	"github.com/visitas/backend/pkg/search"
+)
+
+const (
+	// Records read from the database per page when refreshing the search index
+	searchRefreshBatch = 500
+	// How far back each refresh re-reads, so that a record whose write committed after
+	// a later-stamped one was read is still picked up
+	searchRefreshOverlap = time.Minute
+)
+
+// Index fields and terms of a medical record document
+const (
+	searchFieldMedication   = "medication"
+	searchTermDiagnosisCode = "diagnosis_code"
+	searchTermStatus        = "status"
+	searchTermVisitType     = "visit_type"
+)
+
+// soapSearchSections are the SOAP sections indexed as text, each as a field of its name
+var soapSearchSections = []string{"subjective", "objective", "assessment", "plan"}
+
+// soapSearchSkippedKeys name string values that hold codes and references rather than
+// text a clinician would search for
+var soapSearchSkippedKeys = map[string]bool{
+	"code":            true,
+	"system":          true,
+	"linkedOrder":     true,
+	"linkedCondition": true,
+}
+
+// MedicalRecordSearchService searches the SOAP content of medical records. Records are
+// kept in a search index that is brought up to date from the records changed since its
+// last refresh before every search, so every way a record is written is covered.
+type MedicalRecordSearchService struct {
+	medicalRecordRepo *repository.MedicalRecordRepository
+	patientRepo       *repository.PatientRepository
+	assignmentRepo    *repository.AssignmentRepository
+	auditRepo         *repository.AuditRepository
+	index             search.Index
+
+	refreshMu   sync.Mutex
+	refreshedTo time.Time // updated_at of the last record indexed
+}
+
+// NewMedicalRecordSearchService creates a new medical record search service
+func NewMedicalRecordSearchService(
+	medicalRecordRepo *repository.MedicalRecordRepository,
+	patientRepo *repository.PatientRepository,
+	assignmentRepo *repository.AssignmentRepository,
+	auditRepo *repository.AuditRepository,
+	index search.Index,
+) *MedicalRecordSearchService {
+	return &MedicalRecordSearchService{
+		medicalRecordRepo: medicalRecordRepo,
+		patientRepo:       patientRepo,
+		assignmentRepo:    assignmentRepo,
+		auditRepo:         auditRepo,
+		index:             index,
+	}
+}
+
+// Refresh indexes the records written since the last refresh and removes deleted ones.
+// The first refresh indexes every record.
+func (s *MedicalRecordSearchService) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	since, afterID := s.refreshedTo, ""
+	if !since.IsZero() {
+		since = since.Add(-searchRefreshOverlap)
+	}
+
+	for {
+		records, err := s.medicalRecordRepo.ListUpdatedSince(ctx, since, afterID, searchRefreshBatch)
+		if err != nil {
+			return fmt.Errorf("failed to refresh search index: %w", err)
+		}
+
+		for _, record := range records {
+			if err := s.indexRecord(ctx, record); err != nil {
+				return fmt.Errorf("failed to refresh search index: %w", err)
+			}
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		last := records[len(records)-1]
+		since, afterID = last.UpdatedAt, last.RecordID
+		if last.UpdatedAt.After(s.refreshedTo) {
+			s.refreshedTo = last.UpdatedAt
+		}
+		if len(records) < searchRefreshBatch {
+			return nil
+		}
+	}
+}
+
+// indexRecord puts a record into the index, or removes it if it has been deleted
+func (s *MedicalRecordSearchService) indexRecord(ctx context.Context, record *models.MedicalRecord) error {
+	if record.Deleted {
+		return s.index.Delete(ctx, record.RecordID)
+	}
+
+	doc, err := medicalRecordSearchDocument(record)
+	if err != nil {
+		// Leave the record out rather than stop indexing every record after it
+		logger.WarnContext(ctx, "Medical record not indexed for search", map[string]interface{}{
+			"record_id": record.RecordID,
+			"error":     err.Error(),
+		})
+		return s.index.Delete(ctx, record.RecordID)
+	}
+	return s.index.Put(ctx, doc)
+}
+
+// Search returns the records of the patients the requestor is assigned to that match
+// the request. Each record returned is audit-logged as viewed.
+func (s *MedicalRecordSearchService) Search(ctx context.Context, req *models.MedicalRecordSearchRequest, requestorID, ipAddress, userAgent string) (*models.MedicalRecordSearchResult, error) {
+	query, err := medicalRecordSearchQuery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query.Scopes, err = s.searchablePatients(ctx, req.PatientID, requestorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(query.Scopes) == 0 {
+		return &models.MedicalRecordSearchResult{Hits: []models.MedicalRecordSearchHit{}}, nil
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	found, err := s.index.Search(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search medical records: %w", err)
+	}
+
+	result := &models.MedicalRecordSearchResult{
+		Hits:  make([]models.MedicalRecordSearchHit, 0, len(found.Hits)),
+		Total: found.Total,
+	}
+	for _, hit := range found.Hits {
+		result.Hits = append(result.Hits, medicalRecordSearchHit(hit))
+		s.logSearchHit(ctx, hit, requestorID, ipAddress, userAgent)
+	}
+	return result, nil
+}
+
+// searchablePatients returns the patients a search covers: the one asked for, if the
+// requestor is assigned to them, or every patient the requestor is assigned to
+func (s *MedicalRecordSearchService) searchablePatients(ctx context.Context, patientID *string, requestorID string) ([]string, error) {
+	if patientID != nil {
+		hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, *patientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check access: %w", err)
+		}
+		if !hasAccess {
+			return nil, fmt.Errorf("access denied: staff %s does not have access to patient %s", requestorID, *patientID)
+		}
+		return []string{*patientID}, nil
+	}
+
+	assignments, err := s.assignmentRepo.GetAssignmentsByStaffID(ctx, requestorID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assigned patients: %w", err)
+	}
+	patientIDs := make([]string, 0, len(assignments))
+	for _, assignment := range assignments {
+		patientIDs = append(patientIDs, assignment.PatientID)
+	}
+	return patientIDs, nil
+}
+
+// logSearchHit audit-logs a record returned by a search and the sections shown of it
+func (s *MedicalRecordSearchService) logSearchHit(ctx context.Context, hit search.Hit, requestorID, ipAddress, userAgent string) {
+	accessed := []string{"search"}
+	for _, snippet := range hit.Snippets {
+		accessed = append(accessed, "soap_content."+snippet.Field)
+	}
+	fields, _ := json.Marshal(accessed)
+
+	auditLog := &repository.AuditLog{
+		EventTime:      time.Now(),
+		ActorID:        requestorID,
+		Action:         repository.AuditActionView,
+		ResourceID:     hit.ID,
+		PatientID:      hit.Scope,
+		AccessedFields: fields,
+		Success:        true,
+		IPAddress:      ipAddress,
+		UserAgent:      userAgent,
+	}
+	if err := s.auditRepo.LogAccess(ctx, auditLog); err != nil {
+		logger.ErrorContext(ctx, "Failed to write audit log", err, map[string]interface{}{
+			"patient_id": hit.Scope,
+			"record_id":  hit.ID,
+			"user_id":    requestorID,
+		})
+	}
+}
+
+// medicalRecordSearchQuery validates a search request and translates it to an index
+// query, leaving out the patients it covers
+func medicalRecordSearchQuery(req *models.MedicalRecordSearchRequest) (*search.Query, error) {
+	query := &search.Query{
+		Text:   strings.TrimSpace(req.Query),
+		Fields: make(map[string]string),
+		Terms:  make(map[string][]string),
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+
+	for _, code := range req.DiagnosisCodes {
+		if code = strings.TrimSpace(code); code != "" {
+			query.Terms[searchTermDiagnosisCode] = append(query.Terms[searchTermDiagnosisCode], code)
+		}
+	}
+	if req.Medication != nil && strings.TrimSpace(*req.Medication) != "" {
+		query.Fields[searchFieldMedication] = strings.TrimSpace(*req.Medication)
+	}
+	if query.Text == "" && len(query.Terms) == 0 && len(query.Fields) == 0 {
+		return nil, fmt.Errorf("a search query, diagnosis code or medication is required")
+	}
+	if req.Status != nil {
+		query.Terms[searchTermStatus] = []string{*req.Status}
+	}
+
+	if req.VisitDateFrom != nil {
+		query.From = *req.VisitDateFrom
+	}
+	if req.VisitDateTo != nil {
+		query.To = req.VisitDateTo.AddDate(0, 0, 1)
+	}
+
+	switch req.Sort {
+	case "", models.MedicalRecordSearchSortRecent:
+		query.Sort = search.SortByTime
+	case models.MedicalRecordSearchSortRelevance:
+		query.Sort = search.SortByRelevance
+	default:
+		return nil, fmt.Errorf("invalid sort: %s", req.Sort)
+	}
+
+	return query, nil
+}
+
+// medicalRecordSearchDocument builds the index document of a record: the text of each
+// SOAP section, the drug names of the plan's medications, and the assessment's
+// diagnosis codes
+func medicalRecordSearchDocument(record *models.MedicalRecord) (*search.Document, error) {
+	doc := &search.Document{
+		ID:     record.RecordID,
+		Scope:  record.PatientID,
+		Time:   record.VisitStartedAt,
+		Fields: make(map[string]string),
+		Terms: map[string][]string{
+			searchTermStatus:    {record.Status},
+			searchTermVisitType: {record.VisitType},
+		},
+	}
+	if len(record.SOAPContent) == 0 {
+		return doc, nil
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(record.SOAPContent, &sections); err != nil {
+		return nil, fmt.Errorf("invalid soap_content: %w", err)
+	}
+	for _, section := range soapSearchSections {
+		if raw, ok := sections[section]; ok {
+			var content interface{}
+			if err := json.Unmarshal(raw, &content); err != nil {
+				return nil, fmt.Errorf("invalid soap_content.%s: %w", section, err)
+			}
+			if text := strings.Join(soapText(content, nil), "\n"); text != "" {
+				doc.Fields[section] = text
+			}
+		}
+	}
+
+	var soap models.SOAPContent
+	if err := json.Unmarshal(record.SOAPContent, &soap); err != nil {
+		return nil, fmt.Errorf("invalid soap_content: %w", err)
+	}
+	if soap.Assessment != nil {
+		for _, diagnosis := range soap.Assessment.Diagnoses {
+			if diagnosis.Code != nil && diagnosis.Code.Code != "" {
+				doc.Terms[searchTermDiagnosisCode] = append(doc.Terms[searchTermDiagnosisCode], diagnosis.Code.Code)
+			}
+		}
+	}
+	if soap.Plan != nil {
+		var drugs []string
+		for _, medication := range soap.Plan.Medications {
+			if medication.DrugName != "" {
+				drugs = append(drugs, medication.DrugName)
+			}
+		}
+		if len(drugs) > 0 {
+			doc.Fields[searchFieldMedication] = strings.Join(drugs, "\n")
+		}
+	}
+
+	return doc, nil
+}
+
+// soapText collects the strings of a decoded SOAP section in a stable order: object
+// keys sorted, array elements in order
+func soapText(value interface{}, text []string) []string {
+	switch v := value.(type) {
+	case string:
+		if s := strings.TrimSpace(v); s != "" {
+			text = append(text, s)
+		}
+	case []interface{}:
+		for _, item := range v {
+			text = soapText(item, text)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key, item := range v {
+			if _, isString := item.(string); isString && soapSearchSkippedKeys[key] {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			text = soapText(v[key], text)
+		}
+	}
+	return text
+}
+
+// medicalRecordSearchHit converts an index hit to the API representation
+func medicalRecordSearchHit(hit search.Hit) models.MedicalRecordSearchHit {
+	result := models.MedicalRecordSearchHit{
+		RecordID:       hit.ID,
+		PatientID:      hit.Scope,
+		VisitStartedAt: hit.Time,
+		DiagnosisCodes: hit.Terms[searchTermDiagnosisCode],
+		Score:          hit.Score,
+		Snippets:       make([]models.MedicalRecordSearchSnippet, 0, len(hit.Snippets)),
+	}
+	if status := hit.Terms[searchTermStatus]; len(status) > 0 {
+		result.Status = status[0]
+	}
+	if visitType := hit.Terms[searchTermVisitType]; len(visitType) > 0 {
+		result.VisitType = visitType[0]
+	}
+	for _, snippet := range hit.Snippets {
+		highlights := make([]models.TextRange, 0, len(snippet.Highlights))
+		for _, r := range snippet.Highlights {
+			highlights = append(highlights, models.TextRange{Start: r.Start, End: r.End})
+		}
+		result.Snippets = append(result.Snippets, models.MedicalRecordSearchSnippet{
+			Section:    snippet.Field,
+			Text:       snippet.Text,
+			Highlights: highlights,
+		})
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/pkg/search"
+)
+
+func TestMedicalRecordSearchDocument(t *testing.T) {
+	visit := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	record := &models.MedicalRecord{
+		RecordID:       "r-1",
+		PatientID:      "p-1",
+		VisitStartedAt: visit,
+		VisitType:      "routine",
+		Status:         "completed",
+		SOAPContent: json.RawMessage(`{
+			"subjective": {"chiefComplaint": "臀部の痛み", "symptoms": [{"code": "22253000", "display": "疼痛"}]},
+			"objective": {"physicalExam": {"skin": "仙骨部に褥瘡 (d2)"}, "vitalSigns": {"temperature": {"value": 36.8, "unit": "℃"}}},
+			"assessment": {"diagnoses": [{"code": {"system": "ICD-10", "code": "L89.1", "display": "褥瘡"}, "linkedCondition": "c-1"}]},
+			"plan": {"medications": [{"action": "prescribe", "drugName": "ゲーベンクリーム", "linkedOrder": "o-1"}]},
+			"_metadata": {"aiAssistance": {"model": "x"}}
+		}`),
+	}
+
+	doc, err := medicalRecordSearchDocument(record)
+	require.NoError(t, err)
+	assert.Equal(t, "p-1", doc.Scope)
+	assert.Equal(t, visit, doc.Time)
+	assert.Equal(t, map[string]string{
+		"subjective": "臀部の痛み\n疼痛",
+		"objective":  "仙骨部に褥瘡 (d2)\n℃",
+		"assessment": "褥瘡",
+		"plan":       "prescribe\nゲーベンクリーム",
+		"medication": "ゲーベンクリーム",
+	}, doc.Fields)
+	assert.Equal(t, []string{"L89.1"}, doc.Terms["diagnosis_code"])
+	assert.Equal(t, []string{"completed"}, doc.Terms["status"])
+
+	_, err = medicalRecordSearchDocument(&models.MedicalRecord{RecordID: "r-2", SOAPContent: json.RawMessage(`[]`)})
+	assert.Error(t, err)
+}
+
+func TestMedicalRecordSearchQuery(t *testing.T) {
+	_, err := medicalRecordSearchQuery(&models.MedicalRecordSearchRequest{Query: "  "})
+	assert.EqualError(t, err, "a search query, diagnosis code or medication is required")
+
+	_, err = medicalRecordSearchQuery(&models.MedicalRecordSearchRequest{Query: "褥瘡", Sort: "oldest"})
+	assert.EqualError(t, err, "invalid sort: oldest")
+
+	medication := " ゲーベン "
+	to := time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)
+	query, err := medicalRecordSearchQuery(&models.MedicalRecordSearchRequest{
+		DiagnosisCodes: []string{"L89", ""},
+		Medication:     &medication,
+		VisitDateTo:    &to,
+		Sort:           models.MedicalRecordSearchSortRelevance,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"L89"}, query.Terms["diagnosis_code"])
+	assert.Equal(t, "ゲーベン", query.Fields["medication"])
+	assert.Equal(t, time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), query.To, "the last day is included")
+	assert.Equal(t, search.SortByRelevance, query.Sort)
+	assert.Empty(t, query.Scopes)
+}
+
+func TestMedicalRecordSearchHit(t *testing.T) {
+	ctx := context.Background()
+	index := search.NewMemoryIndex()
+	for _, record := range []*models.MedicalRecord{
+		{RecordID: "r-1", PatientID: "p-1", Status: "completed", VisitType: "routine",
+			SOAPContent: json.RawMessage(`{"assessment":{"diagnoses":[{"code":{"code":"L89.1"}}],"clinicalImpression":"褥瘡は改善傾向"}}`)},
+		{RecordID: "r-2", PatientID: "p-2", Status: "completed", VisitType: "routine",
+			SOAPContent: json.RawMessage(`{"assessment":{"clinicalImpression":"褥瘡なし"}}`)},
+	} {
+		doc, err := medicalRecordSearchDocument(record)
+		require.NoError(t, err)
+		require.NoError(t, index.Put(ctx, doc))
+	}
+
+	query, err := medicalRecordSearchQuery(&models.MedicalRecordSearchRequest{Query: "褥瘡", DiagnosisCodes: []string{"l89"}})
+	require.NoError(t, err)
+	query.Scopes = []string{"p-1", "p-2"}
+	found, err := index.Search(ctx, query)
+	require.NoError(t, err)
+	require.Equal(t, 1, found.Total)
+
+	hit := medicalRecordSearchHit(found.Hits[0])
+	assert.Equal(t, "r-1", hit.RecordID)
+	assert.Equal(t, "completed", hit.Status)
+	assert.Equal(t, "routine", hit.VisitType)
+	assert.Equal(t, []string{"L89.1"}, hit.DiagnosisCodes)
+	require.Len(t, hit.Snippets, 1)
+	assert.Equal(t, models.MedicalRecordSearchSnippet{
+		Section:    "assessment",
+		Text:       "褥瘡は改善傾向",
+		Highlights: []models.TextRange{{Start: 0, End: 2}},
+	}, hit.Snippets[0])
+}
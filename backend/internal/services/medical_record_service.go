@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/visitas/backend/internal/models"
 	"github.com/visitas/backend/internal/repository"
@@ -15,6 +19,7 @@ type MedicalRecordService struct {
 	medicalRecordRepo *repository.MedicalRecordRepository
 	patientRepo       *repository.PatientRepository
 	templateRepo      *repository.MedicalRecordTemplateRepository
+	observationRepo   *repository.ClinicalObservationRepository
 }
 
 // NewMedicalRecordService creates a new medical record service
@@ -22,11 +27,13 @@ func NewMedicalRecordService(
 	medicalRecordRepo *repository.MedicalRecordRepository,
 	patientRepo *repository.PatientRepository,
 	templateRepo *repository.MedicalRecordTemplateRepository,
+	observationRepo *repository.ClinicalObservationRepository,
 ) *MedicalRecordService {
 	return &MedicalRecordService{
 		medicalRecordRepo: medicalRecordRepo,
 		patientRepo:       patientRepo,
 		templateRepo:      templateRepo,
+		observationRepo:   observationRepo,
 	}
 }
 
@@ -105,6 +112,22 @@ func (s *MedicalRecordService) CreateRecord(ctx context.Context, patientID strin
 		return nil, fmt.Errorf("performed_by is required")
 	}
 
+	// Validate SOAP content against the typed schema
+	if err := models.ValidateSOAPContent(req.SOAPContent); err != nil {
+		logger.WarnContext(ctx, "Invalid SOAP content", map[string]interface{}{
+			"patient_id": patientID,
+			"error":      err.Error(),
+		})
+		return nil, err
+	}
+
+	// A completed record must fill the sections its template requires
+	if req.Status == "completed" {
+		if err := s.checkRequiredSections(ctx, req.TemplateID, req.SOAPContent); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create record
 	record, err := s.medicalRecordRepo.Create(ctx, patientID, req, createdBy)
 	if err != nil {
@@ -115,6 +138,8 @@ func (s *MedicalRecordService) CreateRecord(ctx context.Context, patientID strin
 		return nil, err
 	}
 
+	s.syncVitalSigns(ctx, record, nil, createdBy)
+
 	// If using a template, increment usage count
 	if req.TemplateID != nil {
 		go func() {
@@ -163,6 +188,8 @@ func (s *MedicalRecordService) GetRecord(ctx context.Context, patientID, recordI
 		return nil, err
 	}
 
+	s.hydrateVitalSigns(ctx, record)
+
 	logger.InfoContext(ctx, "Medical record retrieved successfully", map[string]interface{}{
 		"record_id":    recordID,
 		"patient_id":   patientID,
@@ -245,6 +272,15 @@ func (s *MedicalRecordService) UpdateRecord(ctx context.Context, patientID, reco
 		return nil, err
 	}
 
+	// Signed records are final
+	if existing.SignedAt != nil {
+		logger.WarnContext(ctx, "Attempt to update a signed medical record", map[string]interface{}{
+			"record_id":  recordID,
+			"updated_by": updatedBy,
+		})
+		return nil, repository.ErrMedicalRecordLocked
+	}
+
 	// Check version for optimistic locking if provided
 	if req.ExpectedVersion != nil && existing.Version != *req.ExpectedVersion {
 		logger.WarnContext(ctx, "Concurrent edit conflict detected", map[string]interface{}{
@@ -282,6 +318,26 @@ func (s *MedicalRecordService) UpdateRecord(ctx context.Context, patientID, reco
 		}
 	}
 
+	// Validate the submitted SOAP content against the typed schema
+	if err := models.ValidateSOAPContent(req.SOAPContent); err != nil {
+		logger.WarnContext(ctx, "Invalid SOAP content", map[string]interface{}{
+			"record_id": recordID,
+			"error":     err.Error(),
+		})
+		return nil, err
+	}
+
+	// AI provenance is only changed by the pipeline and by ReviewAISuggestions
+	if err := models.ValidateAIAssistancePatch(existing.SOAPContent, req.SOAPContent); err != nil {
+		return nil, err
+	}
+
+	// Vital signs written by this update, synced to observations after saving
+	touchedVitals, err := models.TouchedVitalSigns(req.SOAPContent)
+	if err != nil {
+		return nil, fmt.Errorf("invalid soap_content: %w", err)
+	}
+
 	// Merge SOAP content if provided (intelligent merge)
 	if len(req.SOAPContent) > 0 && len(existing.SOAPContent) > 0 {
 		mergedSOAP, err := s.mergeSOAPContent(existing.SOAPContent, req.SOAPContent)
@@ -295,6 +351,23 @@ func (s *MedicalRecordService) UpdateRecord(ctx context.Context, patientID, reco
 		}
 	}
 
+	// A record ending up completed must fill the sections its template requires
+	status, templateID, soapContent := existing.Status, existing.TemplateID, existing.SOAPContent
+	if req.Status != nil {
+		status = *req.Status
+	}
+	if req.TemplateID != nil {
+		templateID = req.TemplateID
+	}
+	if len(req.SOAPContent) > 0 {
+		soapContent = req.SOAPContent
+	}
+	if status == "completed" {
+		if err := s.checkRequiredSections(ctx, templateID, soapContent); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update record with version check
 	var record *models.MedicalRecord
 	if req.ExpectedVersion != nil {
@@ -312,6 +385,10 @@ func (s *MedicalRecordService) UpdateRecord(ctx context.Context, patientID, reco
 		return nil, err
 	}
 
+	if len(touchedVitals) > 0 {
+		s.syncVitalSigns(ctx, record, touchedVitals, updatedBy)
+	}
+
 	logger.InfoContext(ctx, "Medical record updated successfully", map[string]interface{}{
 		"record_id":  recordID,
 		"patient_id": patientID,
@@ -392,6 +469,10 @@ func (s *MedicalRecordService) CopyRecord(ctx context.Context, sourcePatientID,
 		}
 	}
 
+	if err := models.ValidateSOAPContent(req.ModifySOAP); err != nil {
+		return nil, err
+	}
+
 	// Prepare SOAP content (merge with modifications if provided)
 	soapContent := sourceRecord.SOAPContent
 	if len(req.ModifySOAP) > 0 {
@@ -422,6 +503,8 @@ func (s *MedicalRecordService) CopyRecord(ctx context.Context, sourcePatientID,
 		return nil, err
 	}
 
+	s.syncVitalSigns(ctx, record, nil, createdBy)
+
 	logger.InfoContext(ctx, "Medical record copied successfully", map[string]interface{}{
 		"source_record_id": sourceRecordID,
 		"new_record_id":    record.RecordID,
@@ -448,6 +531,10 @@ func (s *MedicalRecordService) CreateFromTemplate(ctx context.Context, patientID
 		return nil, fmt.Errorf("failed to get template: %w", err)
 	}
 
+	if err := models.ValidateSOAPContent(req.InitialSOAP); err != nil {
+		return nil, err
+	}
+
 	// Initialize SOAP content from template
 	soapContent := template.SOAPTemplate
 	if len(req.InitialSOAP) > 0 {
@@ -478,6 +565,8 @@ func (s *MedicalRecordService) CreateFromTemplate(ctx context.Context, patientID
 		return nil, err
 	}
 
+	s.syncVitalSigns(ctx, record, nil, createdBy)
+
 	// Increment template usage count
 	go func() {
 		_ = s.templateRepo.IncrementUsageCount(context.Background(), req.TemplateID)
@@ -533,6 +622,490 @@ func (s *MedicalRecordService) GetDraftRecords(ctx context.Context, staffID stri
 	return records, nil
 }
 
+// ListRevisions lists the revisions of a medical record, newest first, with access control
+func (s *MedicalRecordService) ListRevisions(ctx context.Context, patientID, recordID, requestorID string) ([]*models.MedicalRecordRevision, error) {
+	if err := s.checkRevisionAccess(ctx, patientID, recordID, requestorID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.medicalRecordRepo.ListRevisions(ctx, patientID, recordID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list medical record revisions", err, map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+		})
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("medical record not found")
+	}
+
+	return revisions, nil
+}
+
+// GetRevision retrieves one revision of a medical record with access control
+func (s *MedicalRecordService) GetRevision(ctx context.Context, patientID, recordID string, revision int64, requestorID string) (*models.MedicalRecordRevision, error) {
+	if err := s.checkRevisionAccess(ctx, patientID, recordID, requestorID); err != nil {
+		return nil, err
+	}
+
+	return s.medicalRecordRepo.GetRevision(ctx, patientID, recordID, revision)
+}
+
+// CompareRevisions returns the field and SOAP content changes between two revisions
+func (s *MedicalRecordService) CompareRevisions(ctx context.Context, patientID, recordID string, fromRevision, toRevision int64, requestorID string) (*models.MedicalRecordRevisionDiff, error) {
+	if err := s.checkRevisionAccess(ctx, patientID, recordID, requestorID); err != nil {
+		return nil, err
+	}
+
+	from, err := s.medicalRecordRepo.GetRevision(ctx, patientID, recordID, fromRevision)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.medicalRecordRepo.GetRevision(ctx, patientID, recordID, toRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffMedicalRecordRevisions(from, to)
+}
+
+// RestoreRevision writes an earlier revision's content back to the record as a new revision.
+// Nothing is overwritten: the revisions in between stay in the history.
+func (s *MedicalRecordService) RestoreRevision(ctx context.Context, patientID, recordID string, revision int64, req *models.RestoreRevisionRequest, restoredBy string) (*models.MedicalRecord, error) {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, restoredBy, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":  patientID,
+			"record_id":   recordID,
+			"restored_by": restoredBy,
+		})
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized medical record restore attempt", map[string]interface{}{
+			"patient_id":  patientID,
+			"record_id":   recordID,
+			"restored_by": restoredBy,
+		})
+		return nil, fmt.Errorf("access denied: you do not have permission to update this medical record")
+	}
+
+	target, err := s.medicalRecordRepo.GetRevision(ctx, patientID, recordID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("restored from revision %d", revision)
+	if req.Reason != nil && *req.Reason != "" {
+		reason = *req.Reason
+	}
+
+	record, err := s.medicalRecordRepo.RestoreRevision(ctx, patientID, recordID, target, req.ExpectedVersion, &reason, restoredBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to restore medical record revision", err, map[string]interface{}{
+			"patient_id":  patientID,
+			"record_id":   recordID,
+			"revision":    revision,
+			"restored_by": restoredBy,
+		})
+		return nil, err
+	}
+
+	s.syncVitalSigns(ctx, record, nil, restoredBy)
+
+	logger.InfoContext(ctx, "Medical record revision restored", map[string]interface{}{
+		"record_id":     recordID,
+		"patient_id":    patientID,
+		"restored_from": revision,
+		"version":       record.Version,
+		"restored_by":   restoredBy,
+	})
+
+	return record, nil
+}
+
+// SignRecord finalizes a medical record with the performing clinician's signature
+func (s *MedicalRecordService) SignRecord(ctx context.Context, patientID, recordID string, req *models.MedicalRecordSignRequest, signedBy string) (*models.MedicalRecord, error) {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, signedBy, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+			"signed_by":  signedBy,
+		})
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized medical record signing attempt", map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+			"signed_by":  signedBy,
+		})
+		return nil, fmt.Errorf("access denied: you do not have permission to sign this medical record")
+	}
+
+	// Signing completes the record, so the template's required sections must be filled
+	existing, err := s.medicalRecordRepo.GetByID(ctx, patientID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.SignedAt == nil {
+		if err := s.checkRequiredSections(ctx, existing.TemplateID, existing.SOAPContent); err != nil {
+			return nil, err
+		}
+
+		// AI-suggested text must have been reviewed by a physician
+		pending, err := models.PendingAIReview(existing.SOAPContent)
+		if err != nil {
+			return nil, fmt.Errorf("invalid soap_content: %w", err)
+		}
+		if len(pending) > 0 {
+			logger.WarnContext(ctx, "Attempt to sign a record with unreviewed AI suggestions", map[string]interface{}{
+				"record_id": recordID,
+				"sections":  pending,
+				"signed_by": signedBy,
+			})
+			return nil, models.AIReviewPendingError(pending)
+		}
+	}
+
+	record, err := s.medicalRecordRepo.Sign(ctx, patientID, recordID, req.ExpectedVersion, signedBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to sign medical record", err, map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+			"signed_by":  signedBy,
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Medical record signed", map[string]interface{}{
+		"record_id":      recordID,
+		"patient_id":     patientID,
+		"signed_by":      signedBy,
+		"version":        record.Version,
+		"signature_hash": *record.SignatureHash,
+	})
+
+	return record, nil
+}
+
+// ReviewAISuggestions records that a physician has reviewed the AI-suggested sections of a
+// record. The review is saved as a new revision; once no section is pending the record can
+// be signed.
+func (s *MedicalRecordService) ReviewAISuggestions(ctx context.Context, patientID, recordID string, req *models.MedicalRecordAIReviewRequest, reviewedBy string) (*models.MedicalRecord, error) {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, reviewedBy, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":  patientID,
+			"record_id":   recordID,
+			"reviewed_by": reviewedBy,
+		})
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized AI suggestion review attempt", map[string]interface{}{
+			"patient_id":  patientID,
+			"record_id":   recordID,
+			"reviewed_by": reviewedBy,
+		})
+		return nil, fmt.Errorf("access denied: you do not have permission to review this medical record")
+	}
+
+	existing, err := s.medicalRecordRepo.GetByID(ctx, patientID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.SignedAt != nil {
+		return nil, repository.ErrMedicalRecordLocked
+	}
+
+	content, reviewed, err := models.ReviewAISections(existing.SOAPContent, req.Sections, reviewedBy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(reviewed) == 0 {
+		return existing, nil
+	}
+
+	reason := "Reviewed AI-suggested sections: " + strings.Join(reviewed, ", ")
+	record, err := s.medicalRecordRepo.UpdateWithVersion(ctx, patientID, recordID, existing.Version, &models.MedicalRecordUpdateRequest{
+		SOAPContent:  content,
+		ChangeReason: &reason,
+	}, reviewedBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to record AI suggestion review", err, map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "AI-suggested sections reviewed", map[string]interface{}{
+		"record_id":   recordID,
+		"patient_id":  patientID,
+		"sections":    reviewed,
+		"reviewed_by": reviewedBy,
+		"version":     record.Version,
+	})
+
+	return record, nil
+}
+
+// AddAddendum amends a signed record with a new signed addendum linked to it
+func (s *MedicalRecordService) AddAddendum(ctx context.Context, patientID, recordID string, req *models.MedicalRecordAddendumRequest, signedBy string) (*models.MedicalRecord, error) {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, signedBy, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+			"signed_by":  signedBy,
+		})
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized addendum attempt", map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+			"signed_by":  signedBy,
+		})
+		return nil, fmt.Errorf("access denied: you do not have permission to amend this medical record")
+	}
+
+	if req.Reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	if len(req.SOAPContent) == 0 {
+		return nil, fmt.Errorf("soap_content is required")
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(req.SOAPContent, &content); err != nil {
+		return nil, fmt.Errorf("invalid soap_content: %w", err)
+	}
+	if err := models.ValidateSOAPContent(req.SOAPContent); err != nil {
+		return nil, err
+	}
+
+	original, err := s.medicalRecordRepo.GetByID(ctx, patientID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if original.SignedAt == nil {
+		return nil, fmt.Errorf("only signed medical records take addenda; edit the record instead")
+	}
+	if original.AmendsRecordID != nil {
+		return nil, fmt.Errorf("addenda are added to the original medical record, not to another addendum")
+	}
+
+	addendum, err := s.medicalRecordRepo.CreateAddendum(ctx, original, req, signedBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create addendum", err, map[string]interface{}{
+			"patient_id": patientID,
+			"record_id":  recordID,
+			"signed_by":  signedBy,
+		})
+		return nil, err
+	}
+
+	s.syncVitalSigns(ctx, addendum, nil, signedBy)
+
+	logger.InfoContext(ctx, "Signed addendum added", map[string]interface{}{
+		"record_id":   recordID,
+		"addendum_id": addendum.RecordID,
+		"patient_id":  patientID,
+		"signed_by":   signedBy,
+	})
+
+	return addendum, nil
+}
+
+// ListAddenda lists the addenda of a record, oldest first, with access control
+func (s *MedicalRecordService) ListAddenda(ctx context.Context, patientID, recordID, requestorID string) ([]*models.MedicalRecord, error) {
+	if err := s.checkRevisionAccess(ctx, patientID, recordID, requestorID); err != nil {
+		return nil, err
+	}
+
+	return s.medicalRecordRepo.ListAddenda(ctx, patientID, recordID)
+}
+
+// VerifySignature recomputes a record's canonical hash and compares it with the stored one
+func (s *MedicalRecordService) VerifySignature(ctx context.Context, patientID, recordID, requestorID string) (*models.SignatureVerification, error) {
+	record, err := s.GetRecord(ctx, patientID, recordID, requestorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifySignature(record)
+}
+
+// checkRevisionAccess checks the requestor may read a record's history
+func (s *MedicalRecordService) checkRevisionAccess(ctx context.Context, patientID, recordID, requestorID string) error {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":   patientID,
+			"record_id":    recordID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized medical record history access attempt", map[string]interface{}{
+			"patient_id":   patientID,
+			"record_id":    recordID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("access denied: you do not have permission to view this medical record")
+	}
+
+	return nil
+}
+
+// syncVitalSigns mirrors the record's SOAP vital signs into LOINC-coded vital_signs
+// observations linked to it by visit_record_id: a vital sign in the content creates or
+// updates its observation, and one cleared from the content deletes it. Only the vital
+// signs in touched are synced (nil means all), so an observation corrected after the
+// visit is not overwritten by an unrelated edit of the record. The record is already
+// saved, so failures are logged rather than returned.
+func (s *MedicalRecordService) syncVitalSigns(ctx context.Context, record *models.MedicalRecord, touched map[string]bool, actor string) {
+	logFields := map[string]interface{}{
+		"patient_id": record.PatientID,
+		"record_id":  record.RecordID,
+	}
+
+	vitals, err := models.VitalSignObservations(record.SOAPContent)
+	if err != nil {
+		logger.WarnContext(ctx, "Skipping vital sign sync for unreadable SOAP content", logFields)
+		return
+	}
+
+	category := "vital_signs"
+	existing, err := s.observationRepo.List(ctx, &models.ClinicalObservationFilter{
+		PatientID:     &record.PatientID,
+		Category:      &category,
+		VisitRecordID: &record.RecordID,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list observations for vital sign sync", err, logFields)
+		return
+	}
+	linked := make(map[string]*models.ClinicalObservation)
+	for _, o := range existing {
+		if _, ok := linked[o.CodeValue()]; !ok {
+			linked[o.CodeValue()] = o // latest first
+		}
+	}
+
+	wanted := make(map[string]bool)
+	for _, v := range vitals {
+		wanted[v.Code.Code] = true
+		vs, _ := models.VitalSignCodeFor(v.Code.Code)
+		if touched != nil && !touched[vs.Key] {
+			continue
+		}
+
+		if o, ok := linked[v.Code.Code]; ok {
+			current, _ := decodeSOAPContent(o.Value)
+			synced, _ := decodeSOAPContent(v.Value)
+			if reflect.DeepEqual(current, synced) {
+				continue
+			}
+			if _, err := s.observationRepo.Update(ctx, record.PatientID, o.ObservationID, &models.ClinicalObservationUpdateRequest{Value: v.Value}, actor); err != nil {
+				logger.ErrorContext(ctx, "Failed to update vital sign observation", err, logFields)
+			}
+			continue
+		}
+
+		code, err := json.Marshal(v.Code)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to encode vital sign code", err, logFields)
+			continue
+		}
+		performedBy := record.PerformedBy
+		if _, err := s.observationRepo.Create(ctx, record.PatientID, &models.ClinicalObservationCreateRequest{
+			Category:          category,
+			Code:              code,
+			EffectiveDatetime: record.VisitStartedAt,
+			Value:             v.Value,
+			PerformerID:       &performedBy,
+			VisitRecordID:     &record.RecordID,
+		}, actor); err != nil {
+			logger.ErrorContext(ctx, "Failed to create vital sign observation", err, logFields)
+		}
+	}
+
+	for code, o := range linked {
+		vs, ok := models.VitalSignCodeFor(code)
+		if !ok || wanted[code] || (touched != nil && !touched[vs.Key]) {
+			continue
+		}
+		if err := s.observationRepo.Delete(ctx, record.PatientID, o.ObservationID); err != nil {
+			logger.ErrorContext(ctx, "Failed to delete vital sign observation", err, logFields)
+		}
+	}
+}
+
+// hydrateVitalSigns shows the vital signs of the observations linked to a record in its
+// SOAP content, since observations may be corrected after the visit. Signed records keep
+// the content that was signed.
+func (s *MedicalRecordService) hydrateVitalSigns(ctx context.Context, record *models.MedicalRecord) {
+	if record.SignedAt != nil {
+		return
+	}
+
+	observations, err := s.observationRepo.List(ctx, &models.ClinicalObservationFilter{
+		PatientID:     &record.PatientID,
+		VisitRecordID: &record.RecordID,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list linked observations", err, map[string]interface{}{
+			"record_id": record.RecordID,
+		})
+		return
+	}
+
+	hydrated, err := models.HydrateVitalSigns(record.SOAPContent, observations)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to hydrate vital signs", map[string]interface{}{
+			"record_id": record.RecordID,
+			"error":     err.Error(),
+		})
+		return
+	}
+	record.SOAPContent = hydrated
+}
+
+// checkRequiredSections rejects completing a record while sections required by its
+// template are empty. A template that has since been deleted no longer imposes any.
+func (s *MedicalRecordService) checkRequiredSections(ctx context.Context, templateID *string, soapContent json.RawMessage) error {
+	if templateID == nil {
+		return nil
+	}
+
+	template, err := s.templateRepo.GetByID(ctx, *templateID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return fmt.Errorf("failed to get template: %w", err)
+	}
+
+	if err := models.CheckRequiredSections(soapContent, template.RequiredSections); err != nil {
+		logger.WarnContext(ctx, "Required SOAP sections missing", map[string]interface{}{
+			"template_id": *templateID,
+			"error":       err.Error(),
+		})
+		return err
+	}
+	return nil
+}
+
 // mergeSOAPContent performs a deep merge of SOAP content
 func (s *MedicalRecordService) mergeSOAPContent(existing, updates json.RawMessage) (json.RawMessage, error) {
 	var existingMap, updatesMap map[string]interface{}
@@ -586,3 +1159,155 @@ func deepMerge(existing, updates map[string]interface{}) map[string]interface{}
 
 	return result
 }
+
+// diffMedicalRecordRevisions lists the changes between two revisions of the same record:
+// visit fields by name and SOAP content leaf by leaf under "soap_content"
+func diffMedicalRecordRevisions(from, to *models.MedicalRecordRevision) (*models.MedicalRecordRevisionDiff, error) {
+	if from.RecordID != to.RecordID {
+		return nil, fmt.Errorf("revisions belong to different medical records")
+	}
+
+	var changes []models.JSONChange
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"visit_type", from.VisitType, to.VisitType},
+		{"status", from.Status, to.Status},
+		{"visit_ended_at", timeValue(from.VisitEndedAt), timeValue(to.VisitEndedAt)},
+		{"schedule_id", stringValue(from.ScheduleID), stringValue(to.ScheduleID)},
+		{"template_id", stringValue(from.TemplateID), stringValue(to.TemplateID)},
+		{"audio_file_url", stringValue(from.AudioFileURL), stringValue(to.AudioFileURL)},
+	}
+	for _, f := range fields {
+		diffJSON(f.name, f.from, f.to, &changes)
+	}
+
+	fromSOAP, err := decodeSOAPContent(from.SOAPContent)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOAP content in revision %d: %w", from.Revision, err)
+	}
+	toSOAP, err := decodeSOAPContent(to.SOAPContent)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOAP content in revision %d: %w", to.Revision, err)
+	}
+	diffJSON("soap_content", fromSOAP, toSOAP, &changes)
+
+	if changes == nil {
+		changes = []models.JSONChange{}
+	}
+	return &models.MedicalRecordRevisionDiff{
+		RecordID:     to.RecordID,
+		FromRevision: from.Revision,
+		ToRevision:   to.Revision,
+		Changes:      changes,
+	}, nil
+}
+
+// diffJSON appends the changes between two decoded JSON values at path. Objects are compared
+// key by key in sorted order and arrays element by element, so a change deep in the SOAP
+// content is reported at its own path rather than as a replaced section.
+func diffJSON(path string, from, to interface{}, changes *[]models.JSONChange) {
+	switch {
+	case from == nil && to == nil:
+		return
+	case from == nil:
+		*changes = append(*changes, models.JSONChange{Path: path, Op: "added", To: to})
+		return
+	case to == nil:
+		*changes = append(*changes, models.JSONChange{Path: path, Op: "removed", From: from})
+		return
+	}
+
+	fromObject, fromIsObject := from.(map[string]interface{})
+	toObject, toIsObject := to.(map[string]interface{})
+	if fromIsObject && toIsObject {
+		keys := make(map[string]bool, len(fromObject)+len(toObject))
+		for key := range fromObject {
+			keys[key] = true
+		}
+		for key := range toObject {
+			keys[key] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+		for _, key := range sorted {
+			diffJSON(path+"."+key, fromObject[key], toObject[key], changes)
+		}
+		return
+	}
+
+	fromArray, fromIsArray := from.([]interface{})
+	toArray, toIsArray := to.([]interface{})
+	if fromIsArray && toIsArray {
+		for i := 0; i < len(fromArray) || i < len(toArray); i++ {
+			var fromItem, toItem interface{}
+			if i < len(fromArray) {
+				fromItem = fromArray[i]
+			}
+			if i < len(toArray) {
+				toItem = toArray[i]
+			}
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), fromItem, toItem, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, models.JSONChange{Path: path, Op: "changed", From: from, To: to})
+	}
+}
+
+// decodeSOAPContent decodes stored SOAP content for diffing; empty content is nil
+func decodeSOAPContent(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var content interface{}
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// stringValue is the JSON value of an optional string
+func stringValue(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+// timeValue is the JSON value of an optional timestamp
+func timeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// verifySignature checks that a signed record still hashes to its stored signature
+func verifySignature(record *models.MedicalRecord) (*models.SignatureVerification, error) {
+	verification := &models.SignatureVerification{
+		RecordID:   record.RecordID,
+		Signed:     record.SignedAt != nil,
+		SignedBy:   record.SignedBy,
+		SignedAt:   record.SignedAt,
+		StoredHash: record.SignatureHash,
+	}
+	if !verification.Signed {
+		return verification, nil
+	}
+
+	computed, err := record.ComputeSignatureHash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute signature hash: %w", err)
+	}
+	verification.ComputedHash = computed
+	verification.Valid = record.SignatureHash != nil && *record.SignatureHash == computed
+
+	return verification, nil
+}
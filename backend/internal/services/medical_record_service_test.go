@@ -0,0 +1,130 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func TestDiffMedicalRecordRevisions(t *testing.T) {
+	from := &models.MedicalRecordRevision{
+		RecordID:  "record-1",
+		Revision:  1,
+		VisitType: "regular",
+		Status:    "draft",
+		SOAPContent: json.RawMessage(`{
+			"subjective": {"chiefComplaint": "倦怠感"},
+			"objective": {"vitalSigns": {"heartRate": {"value": 72, "unit": "bpm"}}},
+			"assessment": {"diagnoses": [{"code": {"system": "ICD-10", "code": "I10"}}]}
+		}`),
+	}
+
+	t.Run("Reports field and SOAP leaf changes by path", func(t *testing.T) {
+		to := *from
+		to.Revision = 2
+		to.Status = "completed"
+		to.SOAPContent = json.RawMessage(`{
+			"subjective": {"chiefComplaint": "倦怠感"},
+			"objective": {"vitalSigns": {"heartRate": {"value": 88, "unit": "bpm"}, "spo2": {"value": 96}}},
+			"assessment": {"diagnoses": [{"code": {"system": "ICD-10", "code": "I10"}}, {"code": {"system": "ICD-10", "code": "E11"}}]},
+			"plan": {"nextVisit": {"purpose": "血圧再測定"}}
+		}`)
+
+		diff, err := diffMedicalRecordRevisions(from, &to)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), diff.FromRevision)
+		assert.Equal(t, int64(2), diff.ToRevision)
+		assert.Equal(t, []models.JSONChange{
+			{Path: "status", Op: "changed", From: "draft", To: "completed"},
+			{Path: "soap_content.assessment.diagnoses[1]", Op: "added", To: map[string]interface{}{
+				"code": map[string]interface{}{"system": "ICD-10", "code": "E11"},
+			}},
+			{Path: "soap_content.objective.vitalSigns.heartRate.value", Op: "changed", From: float64(72), To: float64(88)},
+			{Path: "soap_content.objective.vitalSigns.spo2", Op: "added", To: map[string]interface{}{"value": float64(96)}},
+			{Path: "soap_content.plan", Op: "added", To: map[string]interface{}{
+				"nextVisit": map[string]interface{}{"purpose": "血圧再測定"},
+			}},
+		}, diff.Changes)
+	})
+
+	t.Run("Identical revisions have no changes", func(t *testing.T) {
+		to := *from
+		to.Revision = 2
+
+		diff, err := diffMedicalRecordRevisions(from, &to)
+		require.NoError(t, err)
+		assert.NotNil(t, diff.Changes)
+		assert.Empty(t, diff.Changes)
+	})
+
+	t.Run("Cleared SOAP content is reported as removed", func(t *testing.T) {
+		to := *from
+		to.Revision = 2
+		to.SOAPContent = nil
+
+		diff, err := diffMedicalRecordRevisions(from, &to)
+		require.NoError(t, err)
+		require.Len(t, diff.Changes, 1)
+		assert.Equal(t, "soap_content", diff.Changes[0].Path)
+		assert.Equal(t, "removed", diff.Changes[0].Op)
+	})
+
+	t.Run("Revisions of different records", func(t *testing.T) {
+		other := *from
+		other.RecordID = "record-2"
+		_, err := diffMedicalRecordRevisions(from, &other)
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifySignature(t *testing.T) {
+	signedAt := time.Date(2025, 4, 10, 1, 30, 0, 0, time.UTC)
+	signedBy := "doctor-1"
+	record := &models.MedicalRecord{
+		RecordID:       "record-1",
+		PatientID:      "patient-1",
+		VisitStartedAt: time.Date(2025, 4, 10, 1, 0, 0, 0, time.UTC),
+		VisitType:      "regular",
+		PerformedBy:    signedBy,
+		Status:         "completed",
+		SOAPContent:    json.RawMessage(`{"assessment": {"clinicalImpression": "安定"}}`),
+		SourceType:     "manual",
+		Version:        2,
+		SignedAt:       &signedAt,
+		SignedBy:       &signedBy,
+	}
+	hash, err := record.ComputeSignatureHash()
+	require.NoError(t, err)
+	record.SignatureHash = &hash
+
+	t.Run("Untouched record verifies", func(t *testing.T) {
+		verification, err := verifySignature(record)
+		require.NoError(t, err)
+		assert.True(t, verification.Signed)
+		assert.True(t, verification.Valid)
+		assert.Equal(t, hash, verification.ComputedHash)
+	})
+
+	t.Run("Altered content fails", func(t *testing.T) {
+		tampered := *record
+		tampered.SOAPContent = json.RawMessage(`{"assessment": {"clinicalImpression": "増悪"}}`)
+
+		verification, err := verifySignature(&tampered)
+		require.NoError(t, err)
+		assert.False(t, verification.Valid)
+		assert.NotEqual(t, hash, verification.ComputedHash)
+	})
+
+	t.Run("Unsigned record", func(t *testing.T) {
+		verification, err := verifySignature(&models.MedicalRecord{RecordID: "record-2"})
+		require.NoError(t, err)
+		assert.False(t, verification.Signed)
+		assert.False(t, verification.Valid)
+		assert.Empty(t, verification.ComputedHash)
+	})
+}
@@ -49,6 +49,13 @@ func (s *MedicalRecordTemplateService) CreateTemplate(ctx context.Context, req *
 	if len(req.SOAPTemplate) == 0 {
 		return nil, fmt.Errorf("soap_template is required")
 	}
+	if err := models.ValidateSOAPContent(req.SOAPTemplate); err != nil {
+		return nil, err
+	}
+
+	if err := models.ValidateRequiredSections(req.RequiredSections); err != nil {
+		return nil, err
+	}
 
 	// Create template
 	template, err := s.templateRepo.Create(ctx, req, createdBy)
@@ -112,6 +119,14 @@ func (s *MedicalRecordTemplateService) UpdateTemplate(ctx context.Context, templ
 		}
 	}
 
+	if err := models.ValidateSOAPContent(req.SOAPTemplate); err != nil {
+		return nil, err
+	}
+
+	if err := models.ValidateRequiredSections(req.RequiredSections); err != nil {
+		return nil, err
+	}
+
 	// Update template
 	template, err := s.templateRepo.Update(ctx, templateID, req, updatedBy)
 	if err != nil {
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/visitas/backend/internal/models"
 	"github.com/visitas/backend/internal/repository"
@@ -14,6 +15,7 @@ type PatientService struct {
 	patientRepo    *repository.PatientRepository
 	assignmentRepo *repository.AssignmentRepository
 	auditRepo      *repository.AuditRepository
+	staffRepo      *repository.StaffMemberRepository
 }
 
 // NewPatientService creates a new patient service
@@ -21,11 +23,13 @@ func NewPatientService(
 	patientRepo *repository.PatientRepository,
 	assignmentRepo *repository.AssignmentRepository,
 	auditRepo *repository.AuditRepository,
+	staffRepo *repository.StaffMemberRepository,
 ) *PatientService {
 	return &PatientService{
 		patientRepo:    patientRepo,
 		assignmentRepo: assignmentRepo,
 		auditRepo:      auditRepo,
+		staffRepo:      staffRepo,
 	}
 }
 
@@ -214,6 +218,28 @@ func (s *PatientService) AssignPatientToStaff(ctx context.Context, patientID, st
 		return fmt.Errorf("patient not found: %w", err)
 	}
 
+	// Verify staff member exists and is active
+	staff, err := s.staffRepo.GetByID(ctx, staffID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			logger.WarnContext(ctx, "Staff member not found for assignment", map[string]interface{}{
+				"staff_id": staffID,
+			})
+			return fmt.Errorf("staff member not found: %w", err)
+		}
+		logger.ErrorContext(ctx, "Failed to get staff member for assignment", err, map[string]interface{}{
+			"staff_id": staffID,
+		})
+		return fmt.Errorf("failed to get staff member: %w", err)
+	}
+	if !staff.IsActive() {
+		logger.WarnContext(ctx, "Attempt to assign patient to inactive staff", map[string]interface{}{
+			"staff_id":       staffID,
+			"account_status": staff.AccountStatus,
+		})
+		return fmt.Errorf("staff member is not active")
+	}
+
 	// Create assignment
 	_, err = s.assignmentRepo.CreateAssignment(ctx, staffID, patientID, role, assignmentType, assignedBy)
 	if err != nil {
@@ -0,0 +1,569 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/documents"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/blobstore"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// ErrReferralsUnavailable is returned when no blob store or key wrapper is configured
+var ErrReferralsUnavailable = errors.New("referral documents are not available: blob storage or encryption key is not configured")
+
+// referralUrgencies lists the valid urgencies of a referral, as in the SOAP plan
+var referralUrgencies = map[string]bool{
+	"routine":  true,
+	"urgent":   true,
+	"emergent": true,
+}
+
+// ReferralLetterService drafts, sends and tracks outbound referral letters. A draft holds
+// a snapshot of the patient summary that staff can edit; sending renders the letter,
+// stores the PDF encrypted like record attachments and locks it.
+type ReferralLetterService struct {
+	letterRepo          *repository.ReferralLetterRepository
+	patientRepo         *repository.PatientRepository
+	medicalRecordRepo   *repository.MedicalRecordRepository
+	conditionRepo       *repository.MedicalConditionRepository
+	medicationOrderRepo *repository.MedicationOrderRepository
+	allergyRepo         *repository.AllergyIntoleranceRepository
+	staffMemberRepo     *repository.StaffMemberRepository
+	auditRepo           *repository.AuditRepository
+	blobs               *blobstore.SealedStore
+}
+
+// NewReferralLetterService creates a new referral letter service. Sending letters and
+// downloading sent ones is reported as not available when blobs is nil.
+func NewReferralLetterService(
+	letterRepo *repository.ReferralLetterRepository,
+	patientRepo *repository.PatientRepository,
+	medicalRecordRepo *repository.MedicalRecordRepository,
+	conditionRepo *repository.MedicalConditionRepository,
+	medicationOrderRepo *repository.MedicationOrderRepository,
+	allergyRepo *repository.AllergyIntoleranceRepository,
+	staffMemberRepo *repository.StaffMemberRepository,
+	auditRepo *repository.AuditRepository,
+	blobs *blobstore.SealedStore,
+) *ReferralLetterService {
+	return &ReferralLetterService{
+		letterRepo:          letterRepo,
+		patientRepo:         patientRepo,
+		medicalRecordRepo:   medicalRecordRepo,
+		conditionRepo:       conditionRepo,
+		medicationOrderRepo: medicationOrderRepo,
+		allergyRepo:         allergyRepo,
+		staffMemberRepo:     staffMemberRepo,
+		auditRepo:           auditRepo,
+		blobs:               blobs,
+	}
+}
+
+// CreateLetter drafts a referral letter with a summary of the patient's current problems,
+// medications and allergies and the SOAP note of the source record. Details missing from
+// the request are taken from the referral planned in that note, if one is selected.
+func (s *ReferralLetterService) CreateLetter(ctx context.Context, patientID string, req *models.ReferralLetterCreateRequest, createdBy string) (*models.ReferralLetter, error) {
+	if err := s.checkAccess(ctx, patientID, createdBy); err != nil {
+		return nil, err
+	}
+
+	record, err := s.sourceRecord(ctx, patientID, req.SourceRecordID)
+	if err != nil {
+		return nil, err
+	}
+
+	letter := &models.ReferralLetter{
+		ReferralID:           uuid.New().String(),
+		PatientID:            patientID,
+		Status:               models.ReferralStatusDraft,
+		RecipientInstitution: strings.TrimSpace(req.RecipientInstitution),
+		RecipientDepartment:  req.RecipientDepartment,
+		RecipientPhysician:   req.RecipientPhysician,
+		Purpose:              strings.TrimSpace(req.Purpose),
+		Urgency:              req.Urgency,
+		Remarks:              req.Remarks,
+		SenderInstitution:    req.SenderInstitution,
+		SenderAddress:        req.SenderAddress,
+		SenderPhone:          req.SenderPhone,
+		CreatedBy:            createdBy,
+		UpdatedBy:            createdBy,
+	}
+	if record != nil {
+		letter.SourceRecordID = &record.RecordID
+	}
+
+	if req.PlanReferralIndex != nil {
+		if record == nil {
+			return nil, fmt.Errorf("plan_referral_index requires a source record")
+		}
+		planned, err := plannedReferral(record, *req.PlanReferralIndex)
+		if err != nil {
+			return nil, err
+		}
+		if letter.RecipientInstitution == "" {
+			letter.RecipientInstitution = planned.Facility
+		}
+		if letter.RecipientDepartment == nil && planned.Specialty != "" {
+			letter.RecipientDepartment = &planned.Specialty
+		}
+		if letter.Purpose == "" {
+			letter.Purpose = planned.Reason
+		}
+		if letter.Urgency == "" {
+			letter.Urgency = planned.Urgency
+		}
+	}
+	if letter.Urgency == "" {
+		letter.Urgency = "routine"
+	}
+	if err := validateReferralLetter(letter); err != nil {
+		return nil, err
+	}
+
+	src, err := s.loadSources(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	letter.Summary, err = documents.SummarizeForReferral(src, record)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	letter.CreatedAt, letter.UpdatedAt = now, now
+	if err := s.letterRepo.Create(ctx, letter); err != nil {
+		logger.ErrorContext(ctx, "Failed to create referral letter", err, map[string]interface{}{
+			"patient_id": patientID,
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Referral letter drafted", map[string]interface{}{
+		"referral_id": letter.ReferralID,
+		"patient_id":  patientID,
+		"created_by":  createdBy,
+	})
+
+	return letter, nil
+}
+
+// ListLetters lists a patient's referral letters with access control
+func (s *ReferralLetterService) ListLetters(ctx context.Context, patientID string, filter *models.ReferralLetterFilter, requestorID string) ([]*models.ReferralLetter, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+	return s.letterRepo.List(ctx, patientID, filter)
+}
+
+// GetLetter retrieves a referral letter with access control
+func (s *ReferralLetterService) GetLetter(ctx context.Context, patientID, referralID, requestorID string) (*models.ReferralLetter, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+	return s.letterRepo.GetByID(ctx, patientID, referralID)
+}
+
+// UpdateDraft edits a letter that has not been sent, including its patient summary
+func (s *ReferralLetterService) UpdateDraft(ctx context.Context, patientID, referralID string, req *models.ReferralLetterUpdateRequest, updatedBy string) (*models.ReferralLetter, error) {
+	if err := s.checkAccess(ctx, patientID, updatedBy); err != nil {
+		return nil, err
+	}
+
+	letter, err := s.letterRepo.GetByID(ctx, patientID, referralID)
+	if err != nil {
+		return nil, err
+	}
+	if letter.Status != models.ReferralStatusDraft {
+		return nil, fmt.Errorf("CONFLICT: referral letter is %s", letter.Status)
+	}
+
+	if req.RecipientInstitution != nil {
+		letter.RecipientInstitution = strings.TrimSpace(*req.RecipientInstitution)
+	}
+	if req.RecipientDepartment != nil {
+		letter.RecipientDepartment = req.RecipientDepartment
+	}
+	if req.RecipientPhysician != nil {
+		letter.RecipientPhysician = req.RecipientPhysician
+	}
+	if req.Purpose != nil {
+		letter.Purpose = strings.TrimSpace(*req.Purpose)
+	}
+	if req.Urgency != nil {
+		letter.Urgency = *req.Urgency
+	}
+	if req.Summary != nil {
+		letter.Summary = *req.Summary
+	}
+	if req.Remarks != nil {
+		letter.Remarks = req.Remarks
+	}
+	if req.SenderInstitution != nil {
+		letter.SenderInstitution = req.SenderInstitution
+	}
+	if req.SenderAddress != nil {
+		letter.SenderAddress = req.SenderAddress
+	}
+	if req.SenderPhone != nil {
+		letter.SenderPhone = req.SenderPhone
+	}
+	if err := validateReferralLetter(letter); err != nil {
+		return nil, err
+	}
+
+	letter.UpdatedAt = time.Now()
+	letter.UpdatedBy = updatedBy
+	if err := s.letterRepo.Save(ctx, letter, models.ReferralStatusDraft); err != nil {
+		return nil, err
+	}
+
+	return letter, nil
+}
+
+// DeleteDraft deletes a letter that has not been sent; sent letters are kept as records
+func (s *ReferralLetterService) DeleteDraft(ctx context.Context, patientID, referralID, deletedBy string) error {
+	if err := s.checkAccess(ctx, patientID, deletedBy); err != nil {
+		return err
+	}
+	if err := s.letterRepo.DeleteDraft(ctx, patientID, referralID); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Referral letter draft deleted", map[string]interface{}{
+		"referral_id": referralID,
+		"patient_id":  patientID,
+		"deleted_by":  deletedBy,
+	})
+	return nil
+}
+
+// SendLetter renders a draft under the sending physician's name, stores the PDF and
+// records how the letter was sent. Only physicians can send referral letters.
+func (s *ReferralLetterService) SendLetter(ctx context.Context, patientID, referralID string, req *models.ReferralSendRequest, sentBy string) (*models.ReferralLetter, error) {
+	if s.blobs == nil {
+		return nil, ErrReferralsUnavailable
+	}
+	if err := s.checkAccess(ctx, patientID, sentBy); err != nil {
+		return nil, err
+	}
+	if !models.ReferralSendMethods[req.Method] {
+		return nil, fmt.Errorf("invalid send method: %s", req.Method)
+	}
+
+	letter, err := s.letterRepo.GetByID(ctx, patientID, referralID)
+	if err != nil {
+		return nil, err
+	}
+	physician, err := s.staffMemberRepo.GetByID(ctx, sentBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sendDraft(ctx, letter, physician, req.Method, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.letterRepo.Save(ctx, letter, models.ReferralStatusDraft); err != nil {
+		logger.ErrorContext(ctx, "Failed to record sent referral letter", err, map[string]interface{}{
+			"referral_id": referralID,
+			"patient_id":  patientID,
+		})
+		if delErr := s.blobs.Delete(ctx, *letter.PDFBlobKey); delErr != nil {
+			logger.WarnContext(ctx, "Failed to remove orphaned referral letter blob", map[string]interface{}{
+				"blob_key": *letter.PDFBlobKey,
+				"error":    delErr.Error(),
+			})
+		}
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Referral letter sent", map[string]interface{}{
+		"referral_id": referralID,
+		"patient_id":  patientID,
+		"method":      req.Method,
+		"sent_by":     sentBy,
+	})
+
+	return letter, nil
+}
+
+// AcknowledgeLetter records the recipient's reply to a sent letter
+func (s *ReferralLetterService) AcknowledgeLetter(ctx context.Context, patientID, referralID string, req *models.ReferralAcknowledgeRequest, recordedBy string) (*models.ReferralLetter, error) {
+	if err := s.checkAccess(ctx, patientID, recordedBy); err != nil {
+		return nil, err
+	}
+
+	letter, err := s.letterRepo.GetByID(ctx, patientID, referralID)
+	if err != nil {
+		return nil, err
+	}
+	if err := acknowledgeReferral(letter, req, recordedBy, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.letterRepo.Save(ctx, letter, models.ReferralStatusSent); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Referral letter acknowledged", map[string]interface{}{
+		"referral_id": referralID,
+		"patient_id":  patientID,
+		"recorded_by": recordedBy,
+	})
+
+	return letter, nil
+}
+
+// DownloadPDF returns the PDF of a letter: the stored one, checked against its recorded
+// hash, once sent, and a preview marked as a draft before that. Every attempt that passes
+// the access check is written to the patient access audit log.
+func (s *ReferralLetterService) DownloadPDF(ctx context.Context, patientID, referralID, requestorID, ipAddress, userAgent string) (*models.ReferralLetter, []byte, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, nil, err
+	}
+
+	letter, pdf, err := s.readPDF(ctx, patientID, referralID, requestorID)
+
+	fields, _ := json.Marshal([]string{"referral_letter:pdf"})
+	auditLog := &repository.AuditLog{
+		EventTime:      time.Now(),
+		ActorID:        requestorID,
+		Action:         repository.AuditActionDownload,
+		ResourceID:     referralID,
+		PatientID:      patientID,
+		AccessedFields: fields,
+		Success:        err == nil,
+		IPAddress:      ipAddress,
+		UserAgent:      userAgent,
+	}
+	if err != nil {
+		auditLog.ErrorMessage = err.Error()
+	}
+	if logErr := s.auditRepo.LogAccess(ctx, auditLog); logErr != nil {
+		logger.ErrorContext(ctx, "Failed to write audit log", logErr, map[string]interface{}{
+			"patient_id":  patientID,
+			"referral_id": referralID,
+			"user_id":     requestorID,
+		})
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return letter, pdf, nil
+}
+
+func (s *ReferralLetterService) readPDF(ctx context.Context, patientID, referralID, requestorID string) (*models.ReferralLetter, []byte, error) {
+	letter, err := s.letterRepo.GetByID(ctx, patientID, referralID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if letter.Status == models.ReferralStatusDraft {
+		requestor, err := s.staffMemberRepo.GetByID(ctx, requestorID)
+		if err != nil {
+			return nil, nil, err
+		}
+		pdf, err := documents.RenderReferralLetter(letter, staffName(requestor), time.Now(), clinicTimeZone, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to render referral letter: %w", err)
+		}
+		return letter, pdf, nil
+	}
+
+	if s.blobs == nil {
+		return nil, nil, ErrReferralsUnavailable
+	}
+	pdf, err := s.openPDF(ctx, letter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return letter, pdf, nil
+}
+
+// sendDraft renders a draft under the physician's name, seals the PDF and marks the
+// letter sent. The letter is not saved.
+func (s *ReferralLetterService) sendDraft(ctx context.Context, letter *models.ReferralLetter, physician *models.StaffMember, method string, now time.Time) error {
+	if letter.Status != models.ReferralStatusDraft {
+		return fmt.Errorf("CONFLICT: referral letter is %s", letter.Status)
+	}
+	if letter.SenderInstitution == nil || strings.TrimSpace(*letter.SenderInstitution) == "" {
+		return fmt.Errorf("sender_institution is required to send a referral letter")
+	}
+	if physician.Role != "doctor" {
+		return fmt.Errorf("access denied: a %s can only be sent by a physician", documents.ReferralTitle)
+	}
+
+	pdf, err := documents.RenderReferralLetter(letter, staffName(physician), now, clinicTimeZone, false)
+	if err != nil {
+		return fmt.Errorf("failed to render referral letter: %w", err)
+	}
+	blobKey := fmt.Sprintf("referral-letters/%s/%s.pdf", letter.PatientID, letter.ReferralID)
+	sealed, err := s.blobs.Put(ctx, blobKey, pdf, referralLetterAAD(letter))
+	if err != nil {
+		return fmt.Errorf("failed to store referral letter: %w", err)
+	}
+
+	letter.Status = models.ReferralStatusSent
+	letter.PDFSHA256, letter.PDFSizeBytes = &sealed.SHA256, &sealed.SizeBytes
+	letter.PDFBlobKey, letter.PDFWrappedKey = &sealed.Key, &sealed.WrappedKey
+	letter.SentAt, letter.SentBy, letter.SentMethod = &now, &physician.StaffID, &method
+	letter.UpdatedAt, letter.UpdatedBy = now, physician.StaffID
+	return nil
+}
+
+// openPDF decrypts a sent letter's PDF and checks it against its recorded hash
+func (s *ReferralLetterService) openPDF(ctx context.Context, letter *models.ReferralLetter) ([]byte, error) {
+	if letter.PDFBlobKey == nil || letter.PDFWrappedKey == nil || letter.PDFSHA256 == nil {
+		return nil, fmt.Errorf("referral letter content not found")
+	}
+	sealed := blobstore.Sealed{Key: *letter.PDFBlobKey, WrappedKey: *letter.PDFWrappedKey, SHA256: *letter.PDFSHA256}
+	pdf, err := s.blobs.Get(ctx, sealed, referralLetterAAD(letter))
+	switch {
+	case errors.Is(err, blobstore.ErrNotFound):
+		return nil, fmt.Errorf("referral letter content not found")
+	case errors.Is(err, blobstore.ErrDigestMismatch):
+		return nil, fmt.Errorf("referral letter content does not match its recorded hash")
+	case err != nil:
+		return nil, err
+	}
+	return pdf, nil
+}
+
+// acknowledgeReferral records the recipient's reply on a sent letter. The reply cannot
+// be dated in the future or before the letter was sent.
+func acknowledgeReferral(letter *models.ReferralLetter, req *models.ReferralAcknowledgeRequest, recordedBy string, now time.Time) error {
+	if letter.Status != models.ReferralStatusSent {
+		return fmt.Errorf("CONFLICT: referral letter is %s", letter.Status)
+	}
+
+	acknowledgedAt := now
+	if req.AcknowledgedAt != nil {
+		acknowledgedAt = *req.AcknowledgedAt
+	}
+	if acknowledgedAt.After(now) {
+		return fmt.Errorf("acknowledged_at cannot be in the future")
+	}
+	if letter.SentAt != nil && acknowledgedAt.Before(*letter.SentAt) {
+		return fmt.Errorf("acknowledged_at cannot be before the letter was sent")
+	}
+
+	letter.Status = models.ReferralStatusAcknowledged
+	letter.AcknowledgedAt, letter.AcknowledgedBy = &acknowledgedAt, &recordedBy
+	letter.AcknowledgementNote = req.Note
+	letter.UpdatedAt, letter.UpdatedBy = now, recordedBy
+	return nil
+}
+
+// sourceRecord returns the record whose SOAP note is summarized: the given one, or else
+// the patient's latest completed record, if any
+func (s *ReferralLetterService) sourceRecord(ctx context.Context, patientID string, recordID *string) (*models.MedicalRecord, error) {
+	if recordID != nil {
+		return s.medicalRecordRepo.GetByID(ctx, patientID, *recordID)
+	}
+
+	records, err := s.medicalRecordRepo.GetLatestByPatient(ctx, patientID, 20)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Status == "completed" || record.SignedAt != nil {
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+// loadSources reads the records a referral summary is taken from
+func (s *ReferralLetterService) loadSources(ctx context.Context, patientID string) (*documents.Sources, error) {
+	patient, err := s.patientRepo.GetPatientByID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	conditions, err := s.conditionRepo.GetActiveConditions(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	medications, err := s.medicationOrderRepo.GetActiveOrders(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	allergies, err := s.allergyRepo.GetActiveAllergies(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &documents.Sources{
+		Patient:     patient,
+		Conditions:  conditions,
+		Medications: medications,
+		Allergies:   allergies,
+		IssuedOn:    civil.DateOf(time.Now().In(clinicTimeZone)),
+		Location:    clinicTimeZone,
+	}, nil
+}
+
+func (s *ReferralLetterService) checkAccess(ctx context.Context, patientID, requestorID string) error {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":   patientID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized referral letter access attempt", map[string]interface{}{
+			"patient_id":   patientID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("access denied: you do not have permission to access referrals of this patient")
+	}
+
+	return nil
+}
+
+// plannedReferral returns a referral planned in a record's SOAP plan
+func plannedReferral(record *models.MedicalRecord, index int) (*models.ReferralPlan, error) {
+	var soap models.SOAPContent
+	if len(record.SOAPContent) > 0 {
+		if err := json.Unmarshal(record.SOAPContent, &soap); err != nil {
+			return nil, fmt.Errorf("invalid SOAP content in record %s: %w", record.RecordID, err)
+		}
+	}
+	if soap.Plan == nil || index < 0 || index >= len(soap.Plan.Referrals) {
+		return nil, fmt.Errorf("plan_referral_index %d: record %s has no such planned referral", index, record.RecordID)
+	}
+	return &soap.Plan.Referrals[index], nil
+}
+
+func validateReferralLetter(letter *models.ReferralLetter) error {
+	if letter.RecipientInstitution == "" {
+		return fmt.Errorf("recipient_institution is required")
+	}
+	if letter.Purpose == "" {
+		return fmt.Errorf("purpose is required")
+	}
+	if !referralUrgencies[letter.Urgency] {
+		return fmt.Errorf("invalid urgency: %s", letter.Urgency)
+	}
+	return nil
+}
+
+func staffName(staff *models.StaffMember) string {
+	return strings.TrimSpace(staff.FamilyName + " " + staff.GivenName)
+}
+
+// referralLetterAAD names a letter's PDF for sealing
+func referralLetterAAD(letter *models.ReferralLetter) []byte {
+	return blobstore.AAD(letter.PatientID, "referral-letters", letter.ReferralID)
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func testReferralDraft() *models.ReferralLetter {
+	sender := "さくら在宅クリニック"
+	return &models.ReferralLetter{
+		ReferralID:           "ref-1",
+		PatientID:            "p-1",
+		Status:               models.ReferralStatusDraft,
+		RecipientInstitution: "中央病院",
+		Purpose:              "精査",
+		Urgency:              "routine",
+		SenderInstitution:    &sender,
+	}
+}
+
+func TestReferralLetterSendAndAcknowledge(t *testing.T) {
+	ctx := context.Background()
+	service := NewReferralLetterService(nil, nil, nil, nil, nil, nil, nil, nil, newTestSealedStore(t))
+	physician := &models.StaffMember{StaffID: "s-1", FamilyName: "山田", GivenName: "太郎", Role: "doctor"}
+	sentAt := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("Only physicians send letters", func(t *testing.T) {
+		nurse := &models.StaffMember{StaffID: "s-2", Role: "nurse"}
+		err := service.sendDraft(ctx, testReferralDraft(), nurse, "fax", sentAt)
+		assert.ErrorContains(t, err, "access denied")
+	})
+
+	t.Run("Sender institution is required", func(t *testing.T) {
+		letter := testReferralDraft()
+		letter.SenderInstitution = nil
+		assert.Error(t, service.sendDraft(ctx, letter, physician, "fax", sentAt))
+	})
+
+	letter := testReferralDraft()
+	require.NoError(t, service.sendDraft(ctx, letter, physician, "fax", sentAt))
+	assert.Equal(t, models.ReferralStatusSent, letter.Status)
+	assert.Equal(t, "s-1", *letter.SentBy)
+	assert.Equal(t, "fax", *letter.SentMethod)
+	assert.Equal(t, sentAt, *letter.SentAt)
+	assert.Equal(t, "referral-letters/p-1/ref-1.pdf", *letter.PDFBlobKey)
+
+	pdf, err := service.openPDF(ctx, letter)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+	assert.Equal(t, *letter.PDFSizeBytes, int64(len(pdf)))
+
+	t.Run("A sent letter is not sent again", func(t *testing.T) {
+		err := service.sendDraft(ctx, letter, physician, "mail", sentAt)
+		assert.EqualError(t, err, "CONFLICT: referral letter is sent")
+	})
+
+	t.Run("Content must match the hash recorded when sent", func(t *testing.T) {
+		tampered := *letter
+		hash := "00" + (*letter.PDFSHA256)[2:]
+		tampered.PDFSHA256 = &hash
+		_, err := service.openPDF(ctx, &tampered)
+		assert.ErrorContains(t, err, "does not match its recorded hash")
+	})
+
+	t.Run("A PDF does not open as another patient's letter", func(t *testing.T) {
+		other := *letter
+		other.PatientID = "p-2"
+		_, err := service.openPDF(ctx, &other)
+		assert.Error(t, err)
+	})
+
+	t.Run("Drafts are not acknowledged", func(t *testing.T) {
+		err := acknowledgeReferral(testReferralDraft(), &models.ReferralAcknowledgeRequest{}, "s-3", sentAt)
+		assert.EqualError(t, err, "CONFLICT: referral letter is draft")
+	})
+
+	t.Run("A reply is not dated before the letter was sent", func(t *testing.T) {
+		sent := *letter
+		before := sentAt.Add(-time.Hour)
+		err := acknowledgeReferral(&sent, &models.ReferralAcknowledgeRequest{AcknowledgedAt: &before}, "s-3", sentAt.Add(time.Hour))
+		assert.Error(t, err)
+	})
+
+	t.Run("A reply is not dated in the future", func(t *testing.T) {
+		sent := *letter
+		later := sentAt.Add(2 * time.Hour)
+		err := acknowledgeReferral(&sent, &models.ReferralAcknowledgeRequest{AcknowledgedAt: &later}, "s-3", sentAt.Add(time.Hour))
+		assert.Error(t, err)
+	})
+
+	now := sentAt.Add(24 * time.Hour)
+	note := "受診予約済み"
+	require.NoError(t, acknowledgeReferral(letter, &models.ReferralAcknowledgeRequest{Note: &note}, "s-3", now))
+	assert.Equal(t, models.ReferralStatusAcknowledged, letter.Status)
+	assert.Equal(t, now, *letter.AcknowledgedAt)
+	assert.Equal(t, "s-3", *letter.AcknowledgedBy)
+	assert.Equal(t, &note, letter.AcknowledgementNote)
+
+	err = acknowledgeReferral(letter, &models.ReferralAcknowledgeRequest{}, "s-3", now)
+	assert.EqualError(t, err, "CONFLICT: referral letter is acknowledged")
+}
+
+func TestPlannedReferral(t *testing.T) {
+	record := &models.MedicalRecord{
+		RecordID:    "r-1",
+		SOAPContent: json.RawMessage(`{"plan":{"referrals":[{"specialty":"整形外科","facility":"中央病院","reason":"精査","urgency":"urgent"}]}}`),
+	}
+
+	planned, err := plannedReferral(record, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "中央病院", planned.Facility)
+	assert.Equal(t, "urgent", planned.Urgency)
+
+	_, err = plannedReferral(record, 1)
+	assert.Error(t, err)
+	_, err = plannedReferral(&models.MedicalRecord{RecordID: "r-2"}, 0)
+	assert.Error(t, err)
+}
+
+func TestValidateReferralLetter(t *testing.T) {
+	letter := &models.ReferralLetter{RecipientInstitution: "中央病院", Purpose: "精査", Urgency: "routine"}
+	assert.NoError(t, validateReferralLetter(letter))
+
+	letter.Urgency = "asap"
+	assert.EqualError(t, validateReferralLetter(letter), "invalid urgency: asap")
+
+	letter.Urgency, letter.Purpose = "routine", ""
+	assert.EqualError(t, validateReferralLetter(letter), "purpose is required")
+
+	letter.RecipientInstitution = ""
+	assert.EqualError(t, validateReferralLetter(letter), "recipient_institution is required")
+}
@@ -0,0 +1,716 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/geo"
+	"github.com/visitas/backend/pkg/jobqueue"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+var (
+	validRouteJobTypes = map[string]bool{
+		"daily_route":         true,
+		"weekly_route":        true,
+		"emergency_insertion": true,
+		"manual_optimization": true,
+	}
+	validRouteObjectives = map[string]bool{
+		objectiveMinimizeTravelTime: true,
+		objectiveMinimizeDistance:   true,
+	}
+)
+
+// clinicTimeZone is used to interpret wall-clock times such as "09:00" on a visit date
+var clinicTimeZone = loadClinicTimeZone()
+
+func loadClinicTimeZone() *time.Location {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		return time.FixedZone("JST", 9*60*60)
+	}
+	return loc
+}
+
+// jobStatusTimeout bounds the status writes made from queue callbacks,
+// which run outside any request context
+const jobStatusTimeout = 10 * time.Second
+
+// RouteOptimizationService plans visit routes and records each run as a job.
+// Optimizations run in the background on the job queue; callers poll the job.
+type RouteOptimizationService struct {
+	jobRepo           *repository.RouteOptimizationJobRepository
+	visitScheduleRepo *repository.VisitScheduleRepository
+	patientRepo       *repository.PatientRepository
+	locationRepo      *repository.LogisticsLocationRepository
+	queue             *jobqueue.Queue
+	optimizers        map[string]RouteOptimizer
+	conflictChecker   *ScheduleConflictChecker
+}
+
+// NewRouteOptimizationService creates a new route optimization service.
+// Optimizers are keyed by Engine(); the local engine should always be supplied.
+func NewRouteOptimizationService(
+	jobRepo *repository.RouteOptimizationJobRepository,
+	visitScheduleRepo *repository.VisitScheduleRepository,
+	patientRepo *repository.PatientRepository,
+	locationRepo *repository.LogisticsLocationRepository,
+	queue *jobqueue.Queue,
+	optimizers ...RouteOptimizer,
+) *RouteOptimizationService {
+	byEngine := make(map[string]RouteOptimizer, len(optimizers))
+	for _, o := range optimizers {
+		byEngine[o.Engine()] = o
+	}
+
+	return &RouteOptimizationService{
+		jobRepo:           jobRepo,
+		visitScheduleRepo: visitScheduleRepo,
+		patientRepo:       patientRepo,
+		locationRepo:      locationRepo,
+		queue:             queue,
+		optimizers:        byEngine,
+		conflictChecker:   NewScheduleConflictChecker(visitScheduleRepo, patientRepo),
+	}
+}
+
+// OptimizeRoute validates the request, records a pending route_optimization_jobs
+// row and queues the optimization. The returned job is polled via GetJob; when
+// requested, the result is applied to the schedules once the job completes.
+func (s *RouteOptimizationService) OptimizeRoute(ctx context.Context, req *models.RouteOptimizeRequest, requestedBy string) (*models.RouteOptimizationJob, error) {
+	if err := s.normalizeRequest(req); err != nil {
+		return nil, err
+	}
+
+	optimizer, ok := s.optimizers[req.Engine]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrOptimizerUnavailable, req.Engine)
+	}
+
+	targetDate, err := civil.ParseDate(req.TargetDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target_date format, expected YYYY-MM-DD")
+	}
+
+	schedules, err := s.loadSchedules(ctx, req, targetDate, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, fmt.Errorf("no visit schedules to optimize")
+	}
+
+	problem, skipped, err := s.buildProblem(ctx, req, targetDate, schedules)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := json.Marshal(map[string]interface{}{
+		"objective":      problem.Objective,
+		"departure_time": problem.DepartureTime,
+		"apply":          req.Apply,
+		"override":       req.OverrideConflicts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal optimization params: %w", err)
+	}
+
+	scheduleIDs := make([]string, len(schedules))
+	for i, schedule := range schedules {
+		scheduleIDs[i] = schedule.ScheduleID
+	}
+
+	departure := problem.DepartureTime
+	job := &models.RouteOptimizationJob{
+		OrganizationID:      req.OrganizationID,
+		JobType:             req.JobType,
+		JobStatus:           "pending",
+		OptimizerEngine:     optimizer.Engine(),
+		TargetDate:          targetDate,
+		TargetStartTime:     &departure,
+		StaffID:             req.StaffID,
+		VehicleID:           req.VehicleID,
+		StartLocationID:     req.StartLocationID,
+		EndLocationID:       req.EndLocationID,
+		StartLatitude:       &problem.Start.Latitude,
+		StartLongitude:      &problem.Start.Longitude,
+		EndLatitude:         &problem.End.Latitude,
+		EndLongitude:        &problem.End.Longitude,
+		OptimizationParams:  params,
+		IncludedScheduleIDs: scheduleIDs,
+		CreatedBy:           &requestedBy,
+	}
+
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		logger.ErrorContext(ctx, "Failed to create route optimization job", err, map[string]interface{}{
+			"target_date":  req.TargetDate,
+			"requested_by": requestedBy,
+		})
+		return nil, err
+	}
+
+	jobID := job.JobID
+	err = s.queue.Submit(&jobqueue.Job{
+		ID: jobID,
+		Run: func(ctx context.Context, attempt int) error {
+			return s.executeJob(ctx, jobID, optimizer, problem, skipped, schedules, req, requestedBy)
+		},
+		OnRetry: func(attempt int, err error) {
+			s.recordJobStatus(jobID, "Route optimization attempt failed, retrying", err, func(ctx context.Context) error {
+				return s.jobRepo.MarkRetrying(ctx, jobID, int64(attempt), err.Error())
+			})
+		},
+		OnDone: func(err error) {
+			if err == nil {
+				return
+			}
+			if errors.Is(err, context.Canceled) {
+				// CancelJob records a user's cancellation before stopping the job, so a job
+				// still open here was stopped by the server shutting down
+				s.recordJobStatus(jobID, "Route optimization interrupted", nil, func(ctx context.Context) error {
+					return ignoreJobStatusError(s.jobRepo.MarkFailed(ctx, jobID, "interrupted", "server stopped before the job finished"))
+				})
+				return
+			}
+			s.recordJobStatus(jobID, "Route optimization failed", err, func(ctx context.Context) error {
+				markErr := s.jobRepo.MarkFailed(ctx, jobID, "optimizer_error", err.Error())
+				var statusErr *repository.JobStatusError
+				if errors.As(markErr, &statusErr) && statusErr.Status == "completed" {
+					// The route was optimized but could not be applied
+					return s.jobRepo.MarkApplyFailed(ctx, jobID, err.Error())
+				}
+				return ignoreJobStatusError(markErr)
+			})
+		},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to queue route optimization job", err, map[string]interface{}{
+			"job_id": jobID,
+		})
+		if markErr := s.jobRepo.MarkFailed(ctx, jobID, "queue_unavailable", err.Error()); markErr != nil {
+			logger.ErrorContext(ctx, "Failed to mark route optimization job as failed", markErr, map[string]interface{}{
+				"job_id": jobID,
+			})
+		}
+		return nil, fmt.Errorf("route optimization is not available: %w", err)
+	}
+
+	logger.InfoContext(ctx, "Route optimization job queued", map[string]interface{}{
+		"job_id":       jobID,
+		"engine":       optimizer.Engine(),
+		"visits":       len(problem.Stops),
+		"requested_by": requestedBy,
+	})
+
+	return job, nil
+}
+
+// GetJob retrieves a route optimization job for its requester or staff with access to its patients
+func (s *RouteOptimizationService) GetJob(ctx context.Context, jobID, requestorID string) (*models.RouteOptimizationJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get route optimization job", err, map[string]interface{}{
+			"job_id": jobID,
+		})
+		return nil, err
+	}
+
+	if err := s.checkJobAccess(ctx, job, requestorID); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// CancelJob cancels a pending or processing job
+func (s *RouteOptimizationService) CancelJob(ctx context.Context, jobID, cancelledBy string) (*models.RouteOptimizationJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkJobAccess(ctx, job, cancelledBy); err != nil {
+		return nil, err
+	}
+
+	// Record the cancellation first: it fails if the job finished in the meantime, and a
+	// running attempt then cannot store its result over it. A job unknown to the queue
+	// was orphaned (e.g. by a restart) and only needs the status change.
+	if err := s.jobRepo.MarkCancelled(ctx, jobID, cancelledBy); err != nil {
+		return nil, err
+	}
+	s.queue.Cancel(jobID)
+
+	logger.InfoContext(ctx, "Route optimization job cancelled", map[string]interface{}{
+		"job_id":       jobID,
+		"cancelled_by": cancelledBy,
+	})
+
+	return s.jobRepo.GetByID(ctx, jobID)
+}
+
+// FailStaleJobs marks jobs that have been pending or processing without an
+// update for longer than staleAfter as failed. Queued jobs live in memory only,
+// so a restart leaves their rows behind; the age check keeps jobs running on
+// other instances untouched.
+func (s *RouteOptimizationService) FailStaleJobs(ctx context.Context, staleAfter time.Duration) error {
+	jobs, err := s.jobRepo.ListStale(ctx, time.Now().Add(-staleAfter))
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		// A job that finished since it was listed keeps its outcome
+		if err := ignoreJobStatusError(s.jobRepo.MarkFailed(ctx, job.JobID, "interrupted", "server stopped before the job finished")); err != nil {
+			return err
+		}
+	}
+
+	if len(jobs) > 0 {
+		logger.WarnContext(ctx, "Marked stale route optimization jobs as failed", map[string]interface{}{
+			"count": len(jobs),
+		})
+	}
+
+	return nil
+}
+
+// executeJob is one attempt of a queued job: run the optimizer, store the
+// result and optionally apply it. Errors are retried by the queue; an attempt
+// after the result was stored only retries applying it.
+func (s *RouteOptimizationService) executeJob(
+	ctx context.Context,
+	jobID string,
+	optimizer RouteOptimizer,
+	problem *models.RouteOptimizationProblem,
+	skipped []string,
+	schedules []*models.VisitSchedule,
+	req *models.RouteOptimizeRequest,
+	requestedBy string,
+) error {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if job.JobStatus == "completed" {
+		if !req.Apply || job.AppliedToSchedules {
+			return nil
+		}
+		var stored models.RouteOptimizationResult
+		if err := json.Unmarshal(job.OptimizedRoute, &stored); err != nil {
+			return jobqueue.Permanent(fmt.Errorf("failed to decode optimized route: %w", err))
+		}
+		return s.applyAndRecord(ctx, jobID, &stored, schedules, req, requestedBy)
+	}
+
+	if err := s.jobRepo.MarkProcessing(ctx, jobID); err != nil {
+		return permanentIfJobStatus(err)
+	}
+
+	requestTime := time.Now()
+	result, err := optimizer.Optimize(ctx, problem)
+	responseTime := time.Now()
+	if err != nil {
+		if errors.Is(err, ErrOptimizerUnavailable) {
+			return jobqueue.Permanent(err)
+		}
+		return err
+	}
+	result.SkippedScheduleIDs = append(result.SkippedScheduleIDs, skipped...)
+
+	// Do not overwrite a cancellation that arrived while the optimizer ran
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.jobRepo.MarkCompleted(ctx, jobID, result, &requestTime, &responseTime); err != nil {
+		return permanentIfJobStatus(err)
+	}
+
+	logger.InfoContext(ctx, "Route optimization completed", map[string]interface{}{
+		"job_id":  jobID,
+		"engine":  optimizer.Engine(),
+		"visits":  len(problem.Stops),
+		"skipped": len(result.SkippedScheduleIDs),
+	})
+
+	if !req.Apply {
+		return nil
+	}
+
+	return s.applyAndRecord(ctx, jobID, result, schedules, req, requestedBy)
+}
+
+// applyAndRecord applies a completed job's result and marks the job applied
+func (s *RouteOptimizationService) applyAndRecord(
+	ctx context.Context,
+	jobID string,
+	result *models.RouteOptimizationResult,
+	schedules []*models.VisitSchedule,
+	req *models.RouteOptimizeRequest,
+	requestedBy string,
+) error {
+	if err := s.applyResult(ctx, jobID, result, schedules, req, requestedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to apply optimized route", err, map[string]interface{}{
+			"job_id": jobID,
+		})
+		return err
+	}
+
+	return permanentIfJobStatus(s.jobRepo.MarkApplied(ctx, jobID, requestedBy))
+}
+
+// checkJobAccess allows the staff member who requested a job, the staff member whose
+// route it plans, and staff with access to every patient it routes
+func (s *RouteOptimizationService) checkJobAccess(ctx context.Context, job *models.RouteOptimizationJob, requestorID string) error {
+	if (job.CreatedBy != nil && *job.CreatedBy == requestorID) || (job.StaffID != nil && *job.StaffID == requestorID) {
+		return nil
+	}
+
+	// Every routed patient must be accessible; a job with no remaining visits is not
+	granted := false
+	checked := make(map[string]bool)
+	for _, scheduleID := range job.IncludedScheduleIDs {
+		schedule, err := s.visitScheduleRepo.GetByScheduleID(ctx, scheduleID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				continue
+			}
+			return fmt.Errorf("failed to verify access: %w", err)
+		}
+		if checked[schedule.PatientID] {
+			continue
+		}
+
+		hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, schedule.PatientID)
+		if err != nil {
+			return fmt.Errorf("failed to verify access: %w", err)
+		}
+		if !hasAccess {
+			granted = false
+			break
+		}
+		checked[schedule.PatientID] = true
+		granted = true
+	}
+
+	if !granted {
+		logger.WarnContext(ctx, "Access denied to route optimization job", map[string]interface{}{
+			"job_id":       job.JobID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("access denied: you do not have permission to access this route optimization job")
+	}
+	return nil
+}
+
+// permanentIfJobStatus stops retrying when the job's status no longer allows the
+// attempt's write, e.g. because the job was cancelled while it ran
+func permanentIfJobStatus(err error) error {
+	var statusErr *repository.JobStatusError
+	if errors.As(err, &statusErr) {
+		return jobqueue.Permanent(err)
+	}
+	return err
+}
+
+// ignoreJobStatusError treats a job that already reached another final status as recorded
+func ignoreJobStatusError(err error) error {
+	var statusErr *repository.JobStatusError
+	if errors.As(err, &statusErr) {
+		return nil
+	}
+	return err
+}
+
+// recordJobStatus performs a status write from a queue callback
+func (s *RouteOptimizationService) recordJobStatus(jobID, message string, cause error, write func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobStatusTimeout)
+	defer cancel()
+
+	if cause != nil {
+		logger.WarnContext(ctx, message, map[string]interface{}{
+			"job_id": jobID,
+			"error":  cause.Error(),
+		})
+	}
+
+	if err := write(ctx); err != nil {
+		logger.ErrorContext(ctx, "Failed to update route optimization job status", err, map[string]interface{}{
+			"job_id": jobID,
+		})
+	}
+}
+
+// routeAssignableStatuses are the statuses of visits an optimized route is written to
+var routeAssignableStatuses = map[string]bool{
+	"draft":     true,
+	"optimized": true,
+	"assigned":  true,
+}
+
+// applyResult writes each visit's sequence and times to its schedule. Draft
+// schedules move to "optimized"; the requested staff/vehicle are assigned.
+// Visits no longer in an assignable status are skipped.
+// All visits are written in one transaction, after the staff member's day has
+// been checked for conflicts with the optimized times.
+func (s *RouteOptimizationService) applyResult(
+	ctx context.Context,
+	jobID string,
+	result *models.RouteOptimizationResult,
+	schedules []*models.VisitSchedule,
+	req *models.RouteOptimizeRequest,
+	updatedBy string,
+) error {
+	routed := make(map[string]bool, len(schedules))
+	for _, schedule := range schedules {
+		routed[schedule.ScheduleID] = true
+	}
+
+	now := time.Now()
+	var changes []repository.ScheduleChange
+	var candidates []*models.VisitSchedule
+	for _, route := range result.Routes {
+		for _, visit := range route.Visits {
+			if !routed[visit.ScheduleID] {
+				continue
+			}
+
+			// The job ran in the background; work from the visit as it is now, and leave
+			// alone one that has since started, finished or been cancelled
+			schedule, err := s.visitScheduleRepo.GetByScheduleID(ctx, visit.ScheduleID)
+			if err != nil {
+				return err
+			}
+			if !routeAssignableStatuses[schedule.Status] {
+				logger.InfoContext(ctx, "Optimized visit no longer assignable; left unchanged", map[string]interface{}{
+					"job_id":      jobID,
+					"schedule_id": schedule.ScheduleID,
+					"status":      schedule.Status,
+				})
+				continue
+			}
+
+			resultJSON, err := json.Marshal(models.ScheduleOptimizationResult{
+				JobID:         jobID,
+				Engine:        result.Engine,
+				Sequence:      visit.Sequence,
+				ArrivalTime:   visit.ArrivalTime,
+				StartTime:     visit.StartTime,
+				DepartureTime: visit.DepartureTime,
+				Late:          visit.Late,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to marshal optimization result: %w", err)
+			}
+
+			change := repository.ScheduleChange{
+				Schedule: schedule,
+				Update: &models.VisitScheduleUpdateRequest{
+					OptimizationResult: resultJSON,
+					AssignedStaffID:    req.StaffID,
+					AssignedVehicleID:  req.VehicleID,
+				},
+				// A visit that moves on before the commit fails the attempt, and the retry skips it
+				ExpectedStatus: schedule.Status,
+			}
+			if schedule.Status == "draft" {
+				if err := applyStatusTransition(schedule, "optimized", nil, now, updatedBy); err != nil {
+					return jobqueue.Permanent(err)
+				}
+				change.Event = &models.VisitScheduleStatusEvent{
+					ScheduleID: schedule.ScheduleID,
+					PatientID:  schedule.PatientID,
+					FromStatus: "draft",
+					ToStatus:   "optimized",
+					OccurredAt: now,
+					ChangedBy:  updatedBy,
+				}
+			}
+			changes = append(changes, change)
+
+			candidate := *schedule
+			candidate.TimeWindowStart = spanner.NullTime{Time: visit.StartTime, Valid: true}
+			candidates = append(candidates, &candidate)
+		}
+	}
+
+	if req.StaffID != nil {
+		if err := s.conflictChecker.Enforce(ctx, candidates, *req.StaffID, req.OverrideConflicts, updatedBy); err != nil {
+			var conflictErr *ScheduleConflictError
+			if errors.As(err, &conflictErr) {
+				// Retrying cannot resolve a clash; the job fails and the route is left unapplied
+				return jobqueue.Permanent(err)
+			}
+			return err
+		}
+	}
+
+	return s.visitScheduleRepo.CommitChanges(ctx, changes)
+}
+
+// normalizeRequest applies defaults and validates enumerated fields
+func (s *RouteOptimizationService) normalizeRequest(req *models.RouteOptimizeRequest) error {
+	if req.Engine == "" {
+		req.Engine = OptimizerEngineLocal
+	}
+	if req.Objective == "" {
+		req.Objective = objectiveMinimizeTravelTime
+	}
+	if req.JobType == "" {
+		req.JobType = "manual_optimization"
+	}
+
+	if !validRouteObjectives[req.Objective] {
+		return fmt.Errorf("invalid objective: %s", req.Objective)
+	}
+	if !validRouteJobTypes[req.JobType] {
+		return fmt.Errorf("invalid job_type: %s", req.JobType)
+	}
+	if len(req.ScheduleIDs) == 0 && req.StaffID == nil {
+		return fmt.Errorf("schedule_ids or staff_id is required")
+	}
+	if req.DepartureTime != nil {
+		if _, err := time.Parse("15:04", *req.DepartureTime); err != nil {
+			return fmt.Errorf("invalid departure_time format, expected HH:MM")
+		}
+	}
+
+	return nil
+}
+
+// loadSchedules resolves the schedules to route and checks patient access for each
+func (s *RouteOptimizationService) loadSchedules(ctx context.Context, req *models.RouteOptimizeRequest, targetDate civil.Date, requestorID string) ([]*models.VisitSchedule, error) {
+	var schedules []*models.VisitSchedule
+
+	if len(req.ScheduleIDs) > 0 {
+		for _, scheduleID := range req.ScheduleIDs {
+			schedule, err := s.visitScheduleRepo.GetByScheduleID(ctx, scheduleID)
+			if err != nil {
+				return nil, err
+			}
+			if schedule.VisitDate != targetDate {
+				return nil, fmt.Errorf("visit schedule %s is not on target_date %s", scheduleID, targetDate)
+			}
+			schedules = append(schedules, schedule)
+		}
+	} else {
+		var err error
+		schedules, err = s.visitScheduleRepo.ListByStaffAndDate(ctx, *req.StaffID, targetDate)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to list schedules for route optimization", err, map[string]interface{}{
+				"staff_id":    *req.StaffID,
+				"target_date": req.TargetDate,
+			})
+			return nil, err
+		}
+	}
+
+	for _, schedule := range schedules {
+		hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, schedule.PatientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify access: %w", err)
+		}
+		if !hasAccess {
+			logger.WarnContext(ctx, "Access denied to route optimization", map[string]interface{}{
+				"patient_id":   schedule.PatientID,
+				"requestor_id": requestorID,
+			})
+			return nil, fmt.Errorf("access denied: you do not have permission to access this patient")
+		}
+	}
+
+	return schedules, nil
+}
+
+// buildProblem resolves start/end points, departure time and patient coordinates.
+// Schedules whose patient has no geolocated address are returned as skipped.
+func (s *RouteOptimizationService) buildProblem(ctx context.Context, req *models.RouteOptimizeRequest, targetDate civil.Date, schedules []*models.VisitSchedule) (*models.RouteOptimizationProblem, []string, error) {
+	problem := &models.RouteOptimizationProblem{Objective: req.Objective}
+	departure := "09:00"
+
+	if req.StartLocationID != nil {
+		location, err := s.locationRepo.GetByID(ctx, *req.StartLocationID)
+		if err != nil {
+			return nil, nil, err
+		}
+		problem.Start = location.Point()
+		if location.DefaultDepartureTime != nil {
+			departure = *location.DefaultDepartureTime
+		}
+	} else if req.StartLatitude != nil && req.StartLongitude != nil {
+		problem.Start = geo.Point{Latitude: *req.StartLatitude, Longitude: *req.StartLongitude}
+	} else {
+		return nil, nil, fmt.Errorf("start_location_id or start_latitude/start_longitude is required")
+	}
+
+	problem.End = problem.Start
+	if req.EndLocationID != nil {
+		location, err := s.locationRepo.GetByID(ctx, *req.EndLocationID)
+		if err != nil {
+			return nil, nil, err
+		}
+		problem.End = location.Point()
+	} else if req.EndLatitude != nil && req.EndLongitude != nil {
+		problem.End = geo.Point{Latitude: *req.EndLatitude, Longitude: *req.EndLongitude}
+	}
+
+	if !problem.Start.Valid() || !problem.End.Valid() {
+		return nil, nil, fmt.Errorf("invalid coordinates: latitude must be within ±90 and longitude within ±180")
+	}
+
+	if req.DepartureTime != nil {
+		departure = *req.DepartureTime
+	}
+	clock, err := time.Parse("15:04", departure)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid departure time: %s", departure)
+	}
+	problem.DepartureTime = time.Date(targetDate.Year, targetDate.Month, targetDate.Day,
+		clock.Hour(), clock.Minute(), 0, 0, clinicTimeZone)
+
+	var skipped []string
+	for _, schedule := range schedules {
+		patient, err := s.patientRepo.GetPatientByID(ctx, schedule.PatientID)
+		if err != nil {
+			return nil, nil, err
+		}
+		location, err := patient.VisitGeolocation()
+		if err != nil || location == nil {
+			skipped = append(skipped, schedule.ScheduleID)
+			continue
+		}
+
+		stop := models.RouteStop{
+			ScheduleID:      schedule.ScheduleID,
+			PatientID:       schedule.PatientID,
+			Location:        geo.Point{Latitude: location.Latitude, Longitude: location.Longitude},
+			DurationMinutes: schedule.EstimatedDurationMinutes,
+			Priority:        schedule.PriorityScore,
+		}
+		if schedule.TimeWindowStart.Valid {
+			start := schedule.TimeWindowStart.Time
+			stop.WindowStart = &start
+		}
+		if schedule.TimeWindowEnd.Valid {
+			end := schedule.TimeWindowEnd.Time
+			stop.WindowEnd = &end
+		}
+		problem.Stops = append(problem.Stops, stop)
+	}
+
+	if len(problem.Stops) == 0 {
+		return nil, nil, fmt.Errorf("no visit schedules with a geolocated patient address")
+	}
+
+	return problem, skipped, nil
+}
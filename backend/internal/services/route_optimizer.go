@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/pkg/geo"
+)
+
+const (
+	// OptimizerEngineLocal is the built-in heuristic solver; it needs no network access
+	OptimizerEngineLocal = "local"
+	// OptimizerEngineGoogle delegates to Google Route Optimization
+	OptimizerEngineGoogle = "google"
+
+	objectiveMinimizeTravelTime = "minimize_travel_time"
+	objectiveMinimizeDistance   = "minimize_distance"
+)
+
+// ErrOptimizerUnavailable is returned for an engine that cannot run at all, e.g. one with
+// no client configured; retrying does not help
+var ErrOptimizerUnavailable = errors.New("route optimizer is not available")
+
+// RouteOptimizer orders the stops of a route. Implementations must return every
+// input stop either in a route or in SkippedScheduleIDs.
+type RouteOptimizer interface {
+	Engine() string
+	Optimize(ctx context.Context, problem *models.RouteOptimizationProblem) (*models.RouteOptimizationResult, error)
+}
+
+// LocalRouteOptimizer is a single-vehicle heuristic solver: a time-window aware
+// greedy construction followed by 2-opt and relocate improvement. Travel times
+// are estimated from haversine distances, so it works fully offline.
+type LocalRouteOptimizer struct {
+	// AverageSpeedKmh is the assumed door-to-door travel speed
+	AverageSpeedKmh float64
+	// RoadFactor inflates straight-line distance to approximate road distance
+	RoadFactor float64
+	// MaxIterations caps the improvement phase
+	MaxIterations int
+}
+
+// NewLocalRouteOptimizer creates a local solver tuned for urban home visits
+func NewLocalRouteOptimizer() *LocalRouteOptimizer {
+	return &LocalRouteOptimizer{
+		AverageSpeedKmh: 25,
+		RoadFactor:      1.3,
+		MaxIterations:   100,
+	}
+}
+
+// Engine returns the engine name recorded on the job
+func (o *LocalRouteOptimizer) Engine() string {
+	return OptimizerEngineLocal
+}
+
+// Optimize computes a visit order. A visit's time window bounds when service
+// may start; arriving early means waiting, starting after the window closes
+// marks the visit late and is penalised by its priority.
+func (o *LocalRouteOptimizer) Optimize(ctx context.Context, problem *models.RouteOptimizationProblem) (*models.RouteOptimizationResult, error) {
+	if problem == nil {
+		return nil, fmt.Errorf("optimization problem is required")
+	}
+
+	order := o.construct(problem)
+
+	for iter := 0; iter < o.MaxIterations; iter++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !o.improve(problem, order) {
+			break
+		}
+	}
+
+	route, _ := o.simulate(problem, order)
+	return &models.RouteOptimizationResult{
+		Engine: OptimizerEngineLocal,
+		Routes: []models.OptimizedRoute{route},
+	}, nil
+}
+
+// construct builds an initial order: from the current position, serve the stop
+// that can start earliest without being late; higher priority breaks ties.
+// When no remaining stop can be served on time, the highest-priority one goes next.
+func (o *LocalRouteOptimizer) construct(problem *models.RouteOptimizationProblem) []int {
+	remaining := make([]int, len(problem.Stops))
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	order := make([]int, 0, len(problem.Stops))
+	pos := problem.Start
+	now := problem.DepartureTime
+
+	for len(remaining) > 0 {
+		best := -1
+		var bestStart time.Time
+		for k, idx := range remaining {
+			stop := problem.Stops[idx]
+			start := now.Add(o.travelDuration(pos, stop.Location))
+			if stop.WindowStart != nil && start.Before(*stop.WindowStart) {
+				start = *stop.WindowStart
+			}
+			if stop.WindowEnd != nil && start.After(*stop.WindowEnd) {
+				continue
+			}
+			if best < 0 || start.Before(bestStart) ||
+				(start.Equal(bestStart) && stop.Priority > problem.Stops[remaining[best]].Priority) {
+				best = k
+				bestStart = start
+			}
+		}
+
+		if best < 0 {
+			best = 0
+			for k, idx := range remaining {
+				if problem.Stops[idx].Priority > problem.Stops[remaining[best]].Priority {
+					best = k
+				}
+			}
+			stop := problem.Stops[remaining[best]]
+			bestStart = now.Add(o.travelDuration(pos, stop.Location))
+		}
+
+		idx := remaining[best]
+		order = append(order, idx)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		pos = problem.Stops[idx].Location
+		now = bestStart.Add(time.Duration(problem.Stops[idx].DurationMinutes) * time.Minute)
+	}
+
+	return order
+}
+
+// improve applies the first improving 2-opt or relocate move; reports whether one was found
+func (o *LocalRouteOptimizer) improve(problem *models.RouteOptimizationProblem, order []int) bool {
+	_, bestCost := o.simulate(problem, order)
+	n := len(order)
+
+	candidate := make([]int, n)
+	for i := 0; i < n-1; i++ {
+		for j := i + 1; j < n; j++ {
+			// 2-opt: reverse order[i..j]
+			copy(candidate, order)
+			for a, b := i, j; a < b; a, b = a+1, b-1 {
+				candidate[a], candidate[b] = candidate[b], candidate[a]
+			}
+			if _, cost := o.simulate(problem, candidate); cost < bestCost-1e-9 {
+				copy(order, candidate)
+				return true
+			}
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+			// relocate: move order[i] to position j
+			moved := make([]int, 0, n)
+			for k, idx := range order {
+				if k != i {
+					moved = append(moved, idx)
+				}
+			}
+			moved = append(moved[:j], append([]int{order[i]}, moved[j:]...)...)
+			if _, cost := o.simulate(problem, moved); cost < bestCost-1e-9 {
+				copy(order, moved)
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// simulate walks the route in the given order and returns it with its cost
+func (o *LocalRouteOptimizer) simulate(problem *models.RouteOptimizationProblem, order []int) (models.OptimizedRoute, float64) {
+	route := models.OptimizedRoute{Visits: make([]models.OptimizedVisit, 0, len(order))}
+	pos := problem.Start
+	now := problem.DepartureTime
+	var cost float64
+
+	for seq, idx := range order {
+		stop := problem.Stops[idx]
+		distance := o.roadDistanceMeters(pos, stop.Location)
+		travel := o.travelDuration(pos, stop.Location)
+		arrival := now.Add(travel)
+
+		start := arrival
+		if stop.WindowStart != nil && start.Before(*stop.WindowStart) {
+			start = *stop.WindowStart
+		}
+		wait := start.Sub(arrival)
+
+		late := false
+		if stop.WindowEnd != nil && start.After(*stop.WindowEnd) {
+			late = true
+			cost += start.Sub(*stop.WindowEnd).Seconds() * float64(1+stop.Priority) * 10
+		}
+
+		departure := start.Add(time.Duration(stop.DurationMinutes) * time.Minute)
+		route.Visits = append(route.Visits, models.OptimizedVisit{
+			ScheduleID:            stop.ScheduleID,
+			Sequence:              seq + 1,
+			ArrivalTime:           arrival,
+			StartTime:             start,
+			DepartureTime:         departure,
+			TravelDistanceMeters:  int64(math.Round(distance)),
+			TravelDurationSeconds: int64(travel.Seconds()),
+			WaitSeconds:           int64(wait.Seconds()),
+			Late:                  late,
+		})
+
+		route.TotalDistanceMeters += int64(math.Round(distance))
+		if problem.Objective == objectiveMinimizeDistance {
+			cost += distance
+		} else {
+			cost += travel.Seconds() + wait.Seconds()
+		}
+
+		pos = stop.Location
+		now = departure
+	}
+
+	distance := o.roadDistanceMeters(pos, problem.End)
+	travel := o.travelDuration(pos, problem.End)
+	route.TotalDistanceMeters += int64(math.Round(distance))
+	route.ReturnTime = now.Add(travel)
+	route.TotalDurationSeconds = int64(route.ReturnTime.Sub(problem.DepartureTime).Seconds())
+	if problem.Objective == objectiveMinimizeDistance {
+		cost += distance
+	} else {
+		cost += travel.Seconds()
+	}
+
+	return route, cost
+}
+
+func (o *LocalRouteOptimizer) roadDistanceMeters(a, b geo.Point) float64 {
+	return geo.DistanceMeters(a, b) * o.RoadFactor
+}
+
+func (o *LocalRouteOptimizer) travelDuration(a, b geo.Point) time.Duration {
+	hours := o.roadDistanceMeters(a, b) / 1000 / o.AverageSpeedKmh
+	return time.Duration(hours * float64(time.Hour)).Round(time.Second)
+}
+
+// GoogleRouteOptimizationClient sends an OptimizeTours request to Google Route
+// Optimization and returns the raw JSON response. It is injected so the adapter
+// can be wired to the official client or an HTTP transport without a hard dependency.
+type GoogleRouteOptimizationClient interface {
+	OptimizeTours(ctx context.Context, request json.RawMessage) (json.RawMessage, error)
+}
+
+// GoogleRouteOptimizer adapts RouteOptimizationProblem to Google Route Optimization
+type GoogleRouteOptimizer struct {
+	client GoogleRouteOptimizationClient
+}
+
+// NewGoogleRouteOptimizer creates a Google adapter; a nil client leaves the engine unavailable
+func NewGoogleRouteOptimizer(client GoogleRouteOptimizationClient) *GoogleRouteOptimizer {
+	return &GoogleRouteOptimizer{
+		client: client,
+	}
+}
+
+// Engine returns the engine name recorded on the job
+func (o *GoogleRouteOptimizer) Engine() string {
+	return OptimizerEngineGoogle
+}
+
+type googleLatLng struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type googleTimeWindow struct {
+	StartTime *time.Time `json:"startTime,omitempty"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+}
+
+type googleVisitRequest struct {
+	ArrivalLocation googleLatLng       `json:"arrivalLocation"`
+	Duration        string             `json:"duration"`
+	TimeWindows     []googleTimeWindow `json:"timeWindows,omitempty"`
+}
+
+type googleShipment struct {
+	Label       string               `json:"label"`
+	Deliveries  []googleVisitRequest `json:"deliveries"`
+	PenaltyCost float64              `json:"penaltyCost"`
+}
+
+type googleVehicle struct {
+	StartLocation    googleLatLng `json:"startLocation"`
+	EndLocation      googleLatLng `json:"endLocation"`
+	CostPerHour      float64      `json:"costPerHour,omitempty"`
+	CostPerKilometer float64      `json:"costPerKilometer,omitempty"`
+}
+
+type googleOptimizeToursRequest struct {
+	Model struct {
+		GlobalStartTime time.Time        `json:"globalStartTime"`
+		GlobalEndTime   time.Time        `json:"globalEndTime"`
+		Shipments       []googleShipment `json:"shipments"`
+		Vehicles        []googleVehicle  `json:"vehicles"`
+	} `json:"model"`
+}
+
+type googleOptimizeToursResponse struct {
+	Routes []struct {
+		VehicleIndex int `json:"vehicleIndex"`
+		Visits       []struct {
+			ShipmentIndex int       `json:"shipmentIndex"`
+			StartTime     time.Time `json:"startTime"`
+		} `json:"visits"`
+		Transitions []struct {
+			TravelDuration       string `json:"travelDuration"`
+			TravelDistanceMeters int64  `json:"travelDistanceMeters"`
+			WaitDuration         string `json:"waitDuration"`
+		} `json:"transitions"`
+		VehicleStartTime time.Time `json:"vehicleStartTime"`
+		VehicleEndTime   time.Time `json:"vehicleEndTime"`
+		Metrics          struct {
+			TravelDistanceMeters int64 `json:"travelDistanceMeters"`
+		} `json:"metrics"`
+	} `json:"routes"`
+	SkippedShipments []struct {
+		Index int `json:"index"`
+	} `json:"skippedShipments"`
+}
+
+// Optimize builds an OptimizeTours request, sends it and maps the response back
+func (o *GoogleRouteOptimizer) Optimize(ctx context.Context, problem *models.RouteOptimizationProblem) (*models.RouteOptimizationResult, error) {
+	if o.client == nil {
+		return nil, fmt.Errorf("google: %w: no client configured", ErrOptimizerUnavailable)
+	}
+	if problem == nil {
+		return nil, fmt.Errorf("optimization problem is required")
+	}
+
+	payload, err := json.Marshal(buildGoogleRequest(problem))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build google optimization request: %w", err)
+	}
+
+	response, err := o.client.OptimizeTours(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("google route optimization failed: %w", err)
+	}
+
+	result, err := parseGoogleResponse(problem, response)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestPayload = payload
+	result.ResponsePayload = response
+
+	return result, nil
+}
+
+func buildGoogleRequest(problem *models.RouteOptimizationProblem) *googleOptimizeToursRequest {
+	req := &googleOptimizeToursRequest{}
+	req.Model.GlobalStartTime = problem.DepartureTime
+	req.Model.GlobalEndTime = problem.DepartureTime.Add(24 * time.Hour)
+
+	for _, stop := range problem.Stops {
+		visit := googleVisitRequest{
+			ArrivalLocation: googleLatLng{Latitude: stop.Location.Latitude, Longitude: stop.Location.Longitude},
+			Duration:        fmt.Sprintf("%ds", stop.DurationMinutes*60),
+		}
+		if stop.WindowStart != nil || stop.WindowEnd != nil {
+			visit.TimeWindows = []googleTimeWindow{{StartTime: stop.WindowStart, EndTime: stop.WindowEnd}}
+		}
+		req.Model.Shipments = append(req.Model.Shipments, googleShipment{
+			Label:       stop.ScheduleID,
+			Deliveries:  []googleVisitRequest{visit},
+			PenaltyCost: float64(stop.Priority) * 1000,
+		})
+	}
+
+	vehicle := googleVehicle{
+		StartLocation: googleLatLng{Latitude: problem.Start.Latitude, Longitude: problem.Start.Longitude},
+		EndLocation:   googleLatLng{Latitude: problem.End.Latitude, Longitude: problem.End.Longitude},
+	}
+	if problem.Objective == objectiveMinimizeDistance {
+		vehicle.CostPerKilometer = 1
+	} else {
+		vehicle.CostPerHour = 1
+	}
+	req.Model.Vehicles = []googleVehicle{vehicle}
+
+	return req
+}
+
+func parseGoogleResponse(problem *models.RouteOptimizationProblem, raw json.RawMessage) (*models.RouteOptimizationResult, error) {
+	var resp googleOptimizeToursResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("invalid google optimization response: %w", err)
+	}
+
+	result := &models.RouteOptimizationResult{Engine: OptimizerEngineGoogle}
+
+	for _, r := range resp.Routes {
+		route := models.OptimizedRoute{
+			VehicleIndex:         r.VehicleIndex,
+			ReturnTime:           r.VehicleEndTime,
+			TotalDistanceMeters:  r.Metrics.TravelDistanceMeters,
+			TotalDurationSeconds: int64(r.VehicleEndTime.Sub(r.VehicleStartTime).Seconds()),
+		}
+
+		for i, v := range r.Visits {
+			if v.ShipmentIndex < 0 || v.ShipmentIndex >= len(problem.Stops) {
+				return nil, fmt.Errorf("invalid google optimization response: shipment index %d out of range", v.ShipmentIndex)
+			}
+			stop := problem.Stops[v.ShipmentIndex]
+
+			visit := models.OptimizedVisit{
+				ScheduleID:    stop.ScheduleID,
+				Sequence:      i + 1,
+				ArrivalTime:   v.StartTime,
+				StartTime:     v.StartTime,
+				DepartureTime: v.StartTime.Add(time.Duration(stop.DurationMinutes) * time.Minute),
+			}
+			// transitions[i] is the leg leading into visits[i]
+			if i < len(r.Transitions) {
+				t := r.Transitions[i]
+				visit.TravelDistanceMeters = t.TravelDistanceMeters
+				visit.TravelDurationSeconds = parseGoogleDurationSeconds(t.TravelDuration)
+				visit.WaitSeconds = parseGoogleDurationSeconds(t.WaitDuration)
+				visit.ArrivalTime = v.StartTime.Add(-time.Duration(visit.WaitSeconds) * time.Second)
+			}
+			if stop.WindowEnd != nil && visit.StartTime.After(*stop.WindowEnd) {
+				visit.Late = true
+			}
+			route.Visits = append(route.Visits, visit)
+		}
+
+		result.Routes = append(result.Routes, route)
+	}
+
+	skipped := make([]int, 0, len(resp.SkippedShipments))
+	for _, s := range resp.SkippedShipments {
+		if s.Index >= 0 && s.Index < len(problem.Stops) {
+			skipped = append(skipped, s.Index)
+		}
+	}
+	sort.Ints(skipped)
+	for _, idx := range skipped {
+		result.SkippedScheduleIDs = append(result.SkippedScheduleIDs, problem.Stops[idx].ScheduleID)
+	}
+
+	return result, nil
+}
+
+// parseGoogleDurationSeconds parses protobuf JSON durations such as "125s" or "1.5s"
+func parseGoogleDurationSeconds(d string) int64 {
+	if d == "" {
+		return 0
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSuffix(d, "s"), 64)
+	if err != nil {
+		return 0
+	}
+	return int64(seconds)
+}
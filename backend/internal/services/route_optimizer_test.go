@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/pkg/geo"
+)
+
+func routeTestTime(hour, minute int) *time.Time {
+	t := time.Date(2025, 4, 10, hour, minute, 0, 0, clinicTimeZone)
+	return &t
+}
+
+func visitOrder(route models.OptimizedRoute) []string {
+	ids := make([]string, len(route.Visits))
+	for i, v := range route.Visits {
+		ids[i] = v.ScheduleID
+	}
+	return ids
+}
+
+func TestLocalRouteOptimizer_Optimize(t *testing.T) {
+	base := geo.Point{Latitude: 35.6812, Longitude: 139.7671}
+
+	t.Run("Orders visits along a line without backtracking", func(t *testing.T) {
+		problem := &models.RouteOptimizationProblem{
+			Start:         base,
+			End:           base,
+			DepartureTime: *routeTestTime(9, 0),
+			Objective:     objectiveMinimizeTravelTime,
+			Stops: []models.RouteStop{
+				{ScheduleID: "far", Location: geo.Point{Latitude: 35.7112, Longitude: 139.7671}, DurationMinutes: 30, Priority: 5},
+				{ScheduleID: "near", Location: geo.Point{Latitude: 35.6912, Longitude: 139.7671}, DurationMinutes: 30, Priority: 5},
+				{ScheduleID: "middle", Location: geo.Point{Latitude: 35.7012, Longitude: 139.7671}, DurationMinutes: 30, Priority: 5},
+			},
+		}
+
+		result, err := NewLocalRouteOptimizer().Optimize(context.Background(), problem)
+		require.NoError(t, err)
+		require.Len(t, result.Routes, 1)
+
+		assert.Equal(t, OptimizerEngineLocal, result.Engine)
+		assert.Equal(t, []string{"near", "middle", "far"}, visitOrder(result.Routes[0]))
+		assert.Greater(t, result.Routes[0].TotalDistanceMeters, int64(0))
+		assert.True(t, result.Routes[0].ReturnTime.After(problem.DepartureTime))
+	})
+
+	t.Run("Respects time windows over proximity", func(t *testing.T) {
+		problem := &models.RouteOptimizationProblem{
+			Start:         base,
+			End:           base,
+			DepartureTime: *routeTestTime(9, 0),
+			Stops: []models.RouteStop{
+				{ScheduleID: "near-afternoon", Location: geo.Point{Latitude: 35.6862, Longitude: 139.7671}, DurationMinutes: 30, Priority: 5,
+					WindowStart: routeTestTime(13, 0), WindowEnd: routeTestTime(14, 0)},
+				{ScheduleID: "far-morning", Location: geo.Point{Latitude: 35.7112, Longitude: 139.7671}, DurationMinutes: 30, Priority: 5,
+					WindowStart: routeTestTime(9, 0), WindowEnd: routeTestTime(10, 0)},
+			},
+		}
+
+		result, err := NewLocalRouteOptimizer().Optimize(context.Background(), problem)
+		require.NoError(t, err)
+
+		route := result.Routes[0]
+		assert.Equal(t, []string{"far-morning", "near-afternoon"}, visitOrder(route))
+		assert.False(t, route.Visits[0].Late)
+		assert.False(t, route.Visits[1].Late)
+		assert.Equal(t, *routeTestTime(13, 0), route.Visits[1].StartTime)
+		assert.Greater(t, route.Visits[1].WaitSeconds, int64(0))
+	})
+
+	t.Run("Marks visits that cannot meet their window as late", func(t *testing.T) {
+		problem := &models.RouteOptimizationProblem{
+			Start:         base,
+			End:           base,
+			DepartureTime: *routeTestTime(11, 0),
+			Stops: []models.RouteStop{
+				{ScheduleID: "missed", Location: geo.Point{Latitude: 35.6912, Longitude: 139.7671}, DurationMinutes: 30, Priority: 8,
+					WindowStart: routeTestTime(9, 0), WindowEnd: routeTestTime(10, 0)},
+			},
+		}
+
+		result, err := NewLocalRouteOptimizer().Optimize(context.Background(), problem)
+		require.NoError(t, err)
+		assert.True(t, result.Routes[0].Visits[0].Late)
+	})
+
+	t.Run("Empty problem returns an empty route", func(t *testing.T) {
+		problem := &models.RouteOptimizationProblem{Start: base, End: base, DepartureTime: *routeTestTime(9, 0)}
+
+		result, err := NewLocalRouteOptimizer().Optimize(context.Background(), problem)
+		require.NoError(t, err)
+		assert.Empty(t, result.Routes[0].Visits)
+		assert.Equal(t, int64(0), result.Routes[0].TotalDistanceMeters)
+	})
+}
+
+type fakeGoogleClient struct {
+	request  json.RawMessage
+	response string
+}
+
+func (c *fakeGoogleClient) OptimizeTours(ctx context.Context, request json.RawMessage) (json.RawMessage, error) {
+	c.request = request
+	return json.RawMessage(c.response), nil
+}
+
+func TestGoogleRouteOptimizer_Optimize(t *testing.T) {
+	base := geo.Point{Latitude: 35.6812, Longitude: 139.7671}
+	problem := &models.RouteOptimizationProblem{
+		Start:         base,
+		End:           base,
+		DepartureTime: *routeTestTime(9, 0),
+		Stops: []models.RouteStop{
+			{ScheduleID: "a", Location: geo.Point{Latitude: 35.69, Longitude: 139.77}, DurationMinutes: 30, Priority: 5},
+			{ScheduleID: "b", Location: geo.Point{Latitude: 35.70, Longitude: 139.78}, DurationMinutes: 45, Priority: 3},
+			{ScheduleID: "c", Location: geo.Point{Latitude: 35.71, Longitude: 139.79}, DurationMinutes: 30, Priority: 1},
+		},
+	}
+
+	t.Run("Unavailable without a client", func(t *testing.T) {
+		_, err := NewGoogleRouteOptimizer(nil).Optimize(context.Background(), problem)
+		assert.ErrorIs(t, err, ErrOptimizerUnavailable)
+	})
+
+	t.Run("Maps visits, transitions and skipped shipments", func(t *testing.T) {
+		client := &fakeGoogleClient{response: `{
+			"routes": [{
+				"visits": [
+					{"shipmentIndex": 1, "startTime": "2025-04-10T00:20:00Z"},
+					{"startTime": "2025-04-10T01:30:00Z"}
+				],
+				"transitions": [
+					{"travelDuration": "900s", "travelDistanceMeters": 3000, "waitDuration": "300s"},
+					{"travelDuration": "600s", "travelDistanceMeters": 2000}
+				],
+				"vehicleStartTime": "2025-04-10T00:00:00Z",
+				"vehicleEndTime": "2025-04-10T02:30:00Z",
+				"metrics": {"travelDistanceMeters": 7000}
+			}],
+			"skippedShipments": [{"index": 2, "label": "c"}]
+		}`}
+
+		result, err := NewGoogleRouteOptimizer(client).Optimize(context.Background(), problem)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(client.request), `"label":"a"`)
+		assert.NotEmpty(t, result.RequestPayload)
+		assert.NotEmpty(t, result.ResponsePayload)
+
+		require.Len(t, result.Routes, 1)
+		route := result.Routes[0]
+		assert.Equal(t, []string{"b", "a"}, visitOrder(route))
+		assert.Equal(t, int64(300), route.Visits[0].WaitSeconds)
+		assert.Equal(t, int64(900), route.Visits[0].TravelDurationSeconds)
+		assert.Equal(t, route.Visits[0].StartTime.Add(-5*time.Minute), route.Visits[0].ArrivalTime)
+		assert.Equal(t, int64(7000), route.TotalDistanceMeters)
+		assert.Equal(t, int64(9000), route.TotalDurationSeconds)
+		assert.Equal(t, []string{"c"}, result.SkippedScheduleIDs)
+	})
+}
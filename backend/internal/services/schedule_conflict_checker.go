@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/geo"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// ScheduleConflictError is returned when a staff assignment double-books the staff
+// member or leaves too little time to travel between patients
+type ScheduleConflictError struct {
+	StaffID   string
+	Conflicts []models.ScheduleConflict
+}
+
+func (e *ScheduleConflictError) Error() string {
+	return fmt.Sprintf("schedule conflict: staff %s has %d conflicting visit(s)", e.StaffID, len(e.Conflicts))
+}
+
+// ScheduleIDs returns the IDs of the conflicting visits
+func (e *ScheduleConflictError) ScheduleIDs() []string {
+	ids := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		ids[i] = c.ScheduleID
+	}
+	return ids
+}
+
+// ScheduleConflictChecker compares a visit against the other visits of its assigned
+// staff member on the same day. Travel time between patient homes is estimated the
+// same way as the local route optimizer.
+type ScheduleConflictChecker struct {
+	visitScheduleRepo *repository.VisitScheduleRepository
+	patientRepo       *repository.PatientRepository
+	travel            *LocalRouteOptimizer
+}
+
+// NewScheduleConflictChecker creates a new schedule conflict checker
+func NewScheduleConflictChecker(
+	visitScheduleRepo *repository.VisitScheduleRepository,
+	patientRepo *repository.PatientRepository,
+) *ScheduleConflictChecker {
+	return &ScheduleConflictChecker{
+		visitScheduleRepo: visitScheduleRepo,
+		patientRepo:       patientRepo,
+		travel:            NewLocalRouteOptimizer(),
+	}
+}
+
+// workingDayMinutes is the visiting time one staff member can cover in a day. Visits without a
+// planned start cannot be placed on the timeline, so they are checked against this instead.
+const workingDayMinutes = 8 * 60
+
+// visitSlot is the time a visit occupies a staff member and where it takes place
+type visitSlot struct {
+	schedule *models.VisitSchedule
+	start    time.Time
+	end      time.Time
+	location *geo.Point
+}
+
+// Check returns the conflicts of giving the candidate visit to staffID
+func (c *ScheduleConflictChecker) Check(ctx context.Context, candidate *models.VisitSchedule, staffID string) ([]models.ScheduleConflict, error) {
+	return c.CheckAll(ctx, []*models.VisitSchedule{candidate}, staffID)
+}
+
+// CheckAll returns the conflicts of giving several visits to staffID at once, such as the
+// occurrences of a recurring series or the visits of an optimized route. The candidates are
+// compared with the staff member's other visits (completed ones included), not with each other.
+// Visits listed in replaced are about to be removed and are left out of the comparison.
+func (c *ScheduleConflictChecker) CheckAll(ctx context.Context, candidates []*models.VisitSchedule, staffID string, replaced ...string) ([]models.ScheduleConflict, error) {
+	candidateIDs := make(map[string]bool)
+	for _, id := range replaced {
+		candidateIDs[id] = true
+	}
+	byDate := make(map[civil.Date][]*models.VisitSchedule)
+	var dates []civil.Date
+	for _, candidate := range candidates {
+		if candidate.ScheduleID != "" {
+			candidateIDs[candidate.ScheduleID] = true
+		}
+		date := conflictDate(candidate)
+		if _, ok := byDate[date]; !ok {
+			dates = append(dates, date)
+		}
+		byDate[date] = append(byDate[date], candidate)
+	}
+
+	locations := make(map[string]*geo.Point)
+	seen := make(map[string]bool)
+	var conflicts []models.ScheduleConflict
+	for _, date := range dates {
+		day, err := c.visitScheduleRepo.ListStaffDay(ctx, staffID, date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load staff schedules: %w", err)
+		}
+
+		var others []*models.VisitSchedule
+		for _, other := range day {
+			if !candidateIDs[other.ScheduleID] {
+				others = append(others, other)
+			}
+		}
+
+		var slots []visitSlot
+		for _, other := range others {
+			if other.TimeWindowStart.Valid || other.ActualArrivalAt.Valid {
+				slots = append(slots, slotOf(other, c.patientLocation(ctx, other.PatientID, locations)))
+			}
+		}
+
+		var dayConflicts []models.ScheduleConflict
+		for _, candidate := range byDate[date] {
+			if candidate.TimeWindowStart.Valid {
+				target := slotOf(candidate, c.patientLocation(ctx, candidate.PatientID, locations))
+				dayConflicts = append(dayConflicts, findScheduleConflicts(target, append([]visitSlot(nil), slots...), c.travelMinutes)...)
+			}
+		}
+		dayConflicts = append(dayConflicts, findCapacityConflicts(byDate[date], others, date)...)
+
+		// A visit can clash with several candidates; report it once per conflict type
+		for _, conflict := range dayConflicts {
+			key := conflict.ScheduleID + "/" + conflict.ConflictType
+			if !seen[key] {
+				seen[key] = true
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// Enforce rejects visits that double-book staffID or leave too little travel time around them
+// with a ScheduleConflictError. With override set the conflicts are logged and allowed.
+func (c *ScheduleConflictChecker) Enforce(ctx context.Context, candidates []*models.VisitSchedule, staffID string, override bool, userID string, replaced ...string) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	conflicts, err := c.CheckAll(ctx, candidates, staffID, replaced...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check schedule conflicts", err, map[string]interface{}{
+			"schedule_id": candidates[0].ScheduleID,
+			"staff_id":    staffID,
+		})
+		return fmt.Errorf("failed to check schedule conflicts: %w", err)
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	conflictErr := &ScheduleConflictError{StaffID: staffID, Conflicts: conflicts}
+	fields := map[string]interface{}{
+		"schedule_id":              candidates[0].ScheduleID,
+		"patient_id":               candidates[0].PatientID,
+		"staff_id":                 staffID,
+		"candidates":               len(candidates),
+		"conflicting_schedule_ids": conflictErr.ScheduleIDs(),
+	}
+	if override {
+		fields["overridden_by"] = userID
+		logger.WarnContext(ctx, "Schedule conflicts overridden", fields)
+		return nil
+	}
+
+	logger.WarnContext(ctx, "Schedule conflicts detected", fields)
+	return conflictErr
+}
+
+// conflictDate is the clinic calendar day a visit falls on: its planned start when it has one
+// (time windows are stored as instants), otherwise its visit date
+func conflictDate(schedule *models.VisitSchedule) civil.Date {
+	if schedule.TimeWindowStart.Valid {
+		return civil.DateOf(schedule.TimeWindowStart.Time.In(clinicTimeZone))
+	}
+	return schedule.VisitDate
+}
+
+// findCapacityConflicts reports every other visit of the day when the day holds visits without
+// a planned start and all visits together need more than a working day
+func findCapacityConflicts(candidates, others []*models.VisitSchedule, date civil.Date) []models.ScheduleConflict {
+	unplaced := false
+	var total int64
+	for _, schedule := range append(append([]*models.VisitSchedule(nil), candidates...), others...) {
+		total += schedule.EstimatedDurationMinutes
+		if !schedule.TimeWindowStart.Valid && !schedule.ActualArrivalAt.Valid {
+			unplaced = true
+		}
+	}
+	if !unplaced || total <= workingDayMinutes || len(others) == 0 {
+		return nil
+	}
+
+	conflicts := make([]models.ScheduleConflict, 0, len(others))
+	for _, other := range others {
+		conflict := models.ScheduleConflict{
+			ScheduleID:   other.ScheduleID,
+			PatientID:    other.PatientID,
+			ConflictType: "day_capacity",
+			Message:      fmt.Sprintf("visits on %s need %d min, more than the %d min working day", date, total, workingDayMinutes),
+		}
+		if other.TimeWindowStart.Valid || other.ActualArrivalAt.Valid {
+			slot := slotOf(other, nil)
+			conflict.VisitStart = &slot.start
+			conflict.VisitEnd = &slot.end
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// patientLocation resolves (and caches) a patient's visit address coordinates; unknown locations skip travel checks
+func (c *ScheduleConflictChecker) patientLocation(ctx context.Context, patientID string, cache map[string]*geo.Point) *geo.Point {
+	if loc, ok := cache[patientID]; ok {
+		return loc
+	}
+
+	var loc *geo.Point
+	patient, err := c.patientRepo.GetPatientByID(ctx, patientID)
+	if err == nil {
+		if g, gerr := patient.VisitGeolocation(); gerr == nil && g != nil {
+			loc = &geo.Point{Latitude: g.Latitude, Longitude: g.Longitude}
+		}
+	} else {
+		logger.WarnContext(ctx, "Failed to load patient for conflict check", map[string]interface{}{
+			"patient_id": patientID,
+			"error":      err.Error(),
+		})
+	}
+
+	cache[patientID] = loc
+	return loc
+}
+
+func (c *ScheduleConflictChecker) travelMinutes(a, b geo.Point) int64 {
+	return int64(math.Ceil(c.travel.travelDuration(a, b).Minutes()))
+}
+
+// slotOf places a visit on the timeline: where it actually happened once started, otherwise
+// its planned start and estimated duration
+func slotOf(schedule *models.VisitSchedule, location *geo.Point) visitSlot {
+	start := schedule.TimeWindowStart.Time
+	if schedule.ActualArrivalAt.Valid {
+		start = schedule.ActualArrivalAt.Time
+	}
+	end := start.Add(time.Duration(schedule.EstimatedDurationMinutes) * time.Minute)
+	if schedule.ActualDepartureAt.Valid && schedule.ActualDepartureAt.Time.After(start) {
+		end = schedule.ActualDepartureAt.Time
+	}
+	return visitSlot{
+		schedule: schedule,
+		start:    start,
+		end:      end,
+		location: location,
+	}
+}
+
+// findScheduleConflicts reports visits overlapping the target, and the visits
+// immediately before and after it when the gap is shorter than the travel time
+func findScheduleConflicts(target visitSlot, others []visitSlot, travelMinutes func(a, b geo.Point) int64) []models.ScheduleConflict {
+	sort.Slice(others, func(i, j int) bool { return others[i].start.Before(others[j].start) })
+
+	var conflicts []models.ScheduleConflict
+	var previous, next *visitSlot
+	for i := range others {
+		other := &others[i]
+		switch {
+		case other.start.Before(target.end) && target.start.Before(other.end):
+			conflicts = append(conflicts, newScheduleConflict(other, "overlap",
+				fmt.Sprintf("overlaps visit %s–%s", clockOf(other.start), clockOf(other.end))))
+		case !other.end.After(target.start):
+			if previous == nil || other.end.After(previous.end) {
+				previous = other
+			}
+		case next == nil && !other.start.Before(target.end):
+			next = other
+		}
+	}
+
+	checkGap := func(from, to visitSlot, other *visitSlot) {
+		if from.location == nil || to.location == nil {
+			return
+		}
+		required := travelMinutes(*from.location, *to.location)
+		available := int64(to.start.Sub(from.end).Minutes())
+		if required > available {
+			conflict := newScheduleConflict(other, "travel_time",
+				fmt.Sprintf("needs about %d min of travel but only %d min are available", required, available))
+			conflict.RequiredTravelMinutes = required
+			conflict.AvailableTravelMinutes = available
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	if previous != nil {
+		checkGap(*previous, target, previous)
+	}
+	if next != nil {
+		checkGap(target, *next, next)
+	}
+
+	return conflicts
+}
+
+func newScheduleConflict(slot *visitSlot, conflictType, message string) models.ScheduleConflict {
+	start, end := slot.start, slot.end
+	return models.ScheduleConflict{
+		ScheduleID:   slot.schedule.ScheduleID,
+		PatientID:    slot.schedule.PatientID,
+		ConflictType: conflictType,
+		VisitStart:   &start,
+		VisitEnd:     &end,
+		Message:      message,
+	}
+}
+
+func clockOf(t time.Time) string {
+	return t.In(clinicTimeZone).Format("15:04")
+}
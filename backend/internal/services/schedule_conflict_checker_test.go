@@ -0,0 +1,151 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/pkg/geo"
+)
+
+func conflictSlot(id string, hour, minute int, durationMinutes int64, location *geo.Point) visitSlot {
+	return slotOf(&models.VisitSchedule{
+		ScheduleID:               id,
+		PatientID:                "patient-" + id,
+		TimeWindowStart:          spanner.NullTime{Time: time.Date(2025, 4, 10, hour, minute, 0, 0, clinicTimeZone), Valid: true},
+		EstimatedDurationMinutes: durationMinutes,
+	}, location)
+}
+
+func TestFindScheduleConflicts(t *testing.T) {
+	home := &geo.Point{Latitude: 35.6812, Longitude: 139.7671}   // Tokyo Station
+	nearby := &geo.Point{Latitude: 35.6852, Longitude: 139.7528} // ~1.4km
+	far := &geo.Point{Latitude: 35.6896, Longitude: 139.7006}    // Shinjuku, ~6km
+	travelMinutes := (&ScheduleConflictChecker{travel: NewLocalRouteOptimizer()}).travelMinutes
+
+	tests := []struct {
+		name          string
+		target        visitSlot
+		others        []visitSlot
+		expectedIDs   []string
+		expectedTypes []string
+	}{
+		{
+			name:   "No other visits",
+			target: conflictSlot("new", 10, 0, 30, home),
+		},
+		{
+			name:          "Overlapping visit",
+			target:        conflictSlot("new", 10, 0, 60, home),
+			others:        []visitSlot{conflictSlot("a", 10, 30, 30, home)},
+			expectedIDs:   []string{"a"},
+			expectedTypes: []string{"overlap"},
+		},
+		{
+			name:   "Back-to-back at the same address is fine",
+			target: conflictSlot("new", 10, 0, 30, home),
+			others: []visitSlot{conflictSlot("a", 9, 30, 30, home)},
+		},
+		{
+			name:   "Enough gap to reach a nearby patient",
+			target: conflictSlot("new", 10, 0, 30, home),
+			others: []visitSlot{conflictSlot("a", 9, 0, 45, nearby)},
+		},
+		{
+			name:          "Too little time to travel from the previous visit",
+			target:        conflictSlot("new", 10, 0, 30, home),
+			others:        []visitSlot{conflictSlot("a", 9, 0, 55, far)},
+			expectedIDs:   []string{"a"},
+			expectedTypes: []string{"travel_time"},
+		},
+		{
+			name:          "Too little time to reach the next visit",
+			target:        conflictSlot("new", 10, 0, 30, home),
+			others:        []visitSlot{conflictSlot("b", 10, 35, 30, far), conflictSlot("c", 13, 0, 30, far)},
+			expectedIDs:   []string{"b"},
+			expectedTypes: []string{"travel_time"},
+		},
+		{
+			name:   "Unknown locations skip the travel check",
+			target: conflictSlot("new", 10, 0, 30, nil),
+			others: []visitSlot{conflictSlot("a", 9, 0, 55, far)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := findScheduleConflicts(tt.target, tt.others, travelMinutes)
+
+			var ids, types []string
+			for _, c := range conflicts {
+				ids = append(ids, c.ScheduleID)
+				types = append(types, c.ConflictType)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, tt.expectedTypes, types)
+		})
+	}
+}
+
+func TestConflictDate(t *testing.T) {
+	visitDate := civil.Date{Year: 2025, Month: 4, Day: 10}
+
+	t.Run("Untimed visit uses its visit date", func(t *testing.T) {
+		assert.Equal(t, visitDate, conflictDate(&models.VisitSchedule{VisitDate: visitDate}))
+	})
+
+	t.Run("Planned start is read in clinic time", func(t *testing.T) {
+		// 2025-04-10 23:30 UTC is already the 11th in Tokyo
+		schedule := &models.VisitSchedule{
+			VisitDate:       visitDate,
+			TimeWindowStart: spanner.NullTime{Time: time.Date(2025, 4, 10, 23, 30, 0, 0, time.UTC), Valid: true},
+		}
+		assert.Equal(t, visitDate.AddDays(1), conflictDate(schedule))
+	})
+}
+
+func TestFindCapacityConflicts(t *testing.T) {
+	date := civil.Date{Year: 2025, Month: 4, Day: 10}
+	untimed := func(id string, minutes int64) *models.VisitSchedule {
+		return &models.VisitSchedule{ScheduleID: id, VisitDate: date, EstimatedDurationMinutes: minutes}
+	}
+	timed := func(id string, minutes int64) *models.VisitSchedule {
+		return conflictSlot(id, 9, 0, minutes, nil).schedule
+	}
+	completed := func(id string, minutes int64) *models.VisitSchedule {
+		schedule := untimed(id, minutes)
+		schedule.Status = "completed"
+		schedule.ActualArrivalAt = spanner.NullTime{Time: time.Date(2025, 4, 10, 13, 0, 0, 0, clinicTimeZone), Valid: true}
+		return schedule
+	}
+
+	t.Run("Untimed visit fits in the day", func(t *testing.T) {
+		conflicts := findCapacityConflicts([]*models.VisitSchedule{untimed("new", 60)}, []*models.VisitSchedule{timed("a", 120), untimed("b", 90)}, date)
+		assert.Empty(t, conflicts)
+	})
+
+	t.Run("Untimed visit overflows the day", func(t *testing.T) {
+		conflicts := findCapacityConflicts(
+			[]*models.VisitSchedule{untimed("new", 120)},
+			[]*models.VisitSchedule{timed("a", 180), untimed("b", 120), completed("c", 90)},
+			date)
+
+		var ids []string
+		for _, c := range conflicts {
+			ids = append(ids, c.ScheduleID)
+			assert.Equal(t, "day_capacity", c.ConflictType)
+		}
+		assert.Equal(t, []string{"a", "b", "c"}, ids)
+		assert.NotNil(t, conflicts[0].VisitStart)
+		assert.Nil(t, conflicts[1].VisitStart)
+		assert.Equal(t, time.Date(2025, 4, 10, 13, 0, 0, 0, clinicTimeZone), *conflicts[2].VisitStart)
+	})
+
+	t.Run("Fully timed days are left to the overlap check", func(t *testing.T) {
+		conflicts := findCapacityConflicts([]*models.VisitSchedule{timed("new", 300)}, []*models.VisitSchedule{timed("a", 300)}, date)
+		assert.Empty(t, conflicts)
+	})
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/geo"
+	"github.com/visitas/backend/pkg/logger"
+	"github.com/visitas/backend/pkg/ratelimit"
+)
+
+const (
+	// maxLocationPingsPerBatch bounds one location report; devices send what they buffered offline
+	maxLocationPingsPerBatch = 100
+	// locationReportInterval and locationReportBurst limit how often one staff member may report
+	locationReportInterval = 10 * time.Second
+	locationReportBurst    = 3
+	// runningLateGrace is how long after the planned start a visit without a window end may begin
+	runningLateGrace = 15 * time.Minute
+	// etaSubscriberBuffer is how many ETA updates a slow subscriber may fall behind before updates are dropped
+	etaSubscriberBuffer = 32
+)
+
+// LocationRateLimitError is returned when a staff member reports locations too often
+type LocationRateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LocationRateLimitError) Error() string {
+	return fmt.Sprintf("rate limited: retry location report in %s", e.RetryAfter.Round(time.Second))
+}
+
+// StaffLocationService ingests staff location pings, keeps the staff member's current
+// position and the live ETA of their next visit, and fans ETA updates out to subscribers
+type StaffLocationService struct {
+	locationRepo      *repository.StaffLocationRepository
+	staffMemberRepo   *repository.StaffMemberRepository
+	visitScheduleRepo *repository.VisitScheduleRepository
+	patientRepo       *repository.PatientRepository
+	retention         time.Duration
+	limiter           *ratelimit.Limiter
+	travel            *LocalRouteOptimizer
+
+	mu          sync.Mutex
+	subscribers map[chan *models.VisitETA]struct{}
+}
+
+// NewStaffLocationService creates a new staff location service. Pings older than
+// retention are rejected on ingestion and purged by PurgeExpiredPings.
+func NewStaffLocationService(
+	locationRepo *repository.StaffLocationRepository,
+	staffMemberRepo *repository.StaffMemberRepository,
+	visitScheduleRepo *repository.VisitScheduleRepository,
+	patientRepo *repository.PatientRepository,
+	retention time.Duration,
+) *StaffLocationService {
+	return &StaffLocationService{
+		locationRepo:      locationRepo,
+		staffMemberRepo:   staffMemberRepo,
+		visitScheduleRepo: visitScheduleRepo,
+		patientRepo:       patientRepo,
+		retention:         retention,
+		limiter:           ratelimit.New(locationReportInterval, locationReportBurst),
+		travel:            NewLocalRouteOptimizer(),
+		subscribers:       make(map[chan *models.VisitETA]struct{}),
+	}
+}
+
+// ReportLocation stores a batch of the staff member's location pings. The newest fix becomes
+// the staff member's (and vehicle's) current position, and the ETA of their next visit today
+// is recomputed from it. Fixes that cannot be valid are counted as rejected, not stored.
+func (s *StaffLocationService) ReportLocation(ctx context.Context, staffID string, req *models.StaffLocationBatchRequest) (*models.StaffLocationBatchResult, error) {
+	if len(req.Pings) == 0 {
+		return nil, fmt.Errorf("at least one ping is required")
+	}
+	if len(req.Pings) > maxLocationPingsPerBatch {
+		return nil, fmt.Errorf("a batch may contain at most %d pings", maxLocationPingsPerBatch)
+	}
+
+	if ok, retryAfter := s.limiter.Allow(staffID); !ok {
+		logger.WarnContext(ctx, "Location report rate limited", map[string]interface{}{
+			"staff_id":    staffID,
+			"retry_after": retryAfter.String(),
+		})
+		return nil, &LocationRateLimitError{RetryAfter: retryAfter}
+	}
+
+	staff, err := s.staffMemberRepo.GetByID(ctx, staffID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	vehicleID := staff.AssignedVehicleID
+	if req.VehicleID != nil && *req.VehicleID != "" {
+		// Only the staff member's own vehicle or one their visits today are booked on
+		schedules, err := s.visitScheduleRepo.ListStaffDay(ctx, staffID, civil.DateOf(now.In(clinicTimeZone)))
+		if err != nil {
+			return nil, err
+		}
+		if !vehicleInUse(staff, schedules, *req.VehicleID) {
+			logger.WarnContext(ctx, "Location reported for a vehicle not in use by the staff member", map[string]interface{}{
+				"staff_id":   staffID,
+				"vehicle_id": *req.VehicleID,
+			})
+			return nil, fmt.Errorf("vehicle %s is not assigned to you or booked for your visits today", *req.VehicleID)
+		}
+		vehicleID = req.VehicleID
+	}
+
+	result := &models.StaffLocationBatchResult{}
+	var pings []*models.StaffLocationPing
+	var latest *models.StaffLocationPing
+	for _, input := range req.Pings {
+		if !s.acceptPing(input, now) {
+			result.Rejected++
+			continue
+		}
+		ping := &models.StaffLocationPing{
+			StaffID:        staffID,
+			VehicleID:      vehicleID,
+			Latitude:       input.Latitude,
+			Longitude:      input.Longitude,
+			AccuracyMeters: input.AccuracyMeters,
+			SpeedKmh:       input.SpeedKmh,
+			HeadingDegrees: input.HeadingDegrees,
+			RecordedAt:     input.RecordedAt,
+		}
+		pings = append(pings, ping)
+		if latest == nil || ping.RecordedAt.After(latest.RecordedAt) {
+			latest = ping
+		}
+	}
+	result.Accepted = len(pings)
+	if len(pings) == 0 {
+		return result, nil
+	}
+
+	// A delayed batch of old fixes must not move the staff member backwards
+	current := latest
+	if staff.LastLocationUpdate != nil && !latest.RecordedAt.After(*staff.LastLocationUpdate) {
+		current = nil
+	}
+
+	if err := s.locationRepo.InsertPings(ctx, pings, current); err != nil {
+		logger.ErrorContext(ctx, "Failed to store location pings", err, map[string]interface{}{
+			"staff_id": staffID,
+			"count":    len(pings),
+		})
+		return nil, err
+	}
+
+	if current != nil {
+		position := geo.Point{Latitude: current.Latitude, Longitude: current.Longitude}
+		result.NextVisit = s.updateNextVisitETA(ctx, staffID, position, now)
+	}
+
+	return result, nil
+}
+
+// vehicleInUse reports whether a vehicle is the staff member's assigned vehicle or is
+// booked on one of their visits for the day
+func vehicleInUse(staff *models.StaffMember, schedules []*models.VisitSchedule, vehicleID string) bool {
+	if staff.AssignedVehicleID != nil && *staff.AssignedVehicleID == vehicleID {
+		return true
+	}
+	for _, schedule := range schedules {
+		if schedule.Status != "cancelled" && schedule.AssignedVehicleID.Valid && schedule.AssignedVehicleID.StringVal == vehicleID {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptPing reports whether a fix has legal coordinates and a plausible time:
+// not ahead of the server clock and not already past retention
+func (s *StaffLocationService) acceptPing(p models.StaffLocationPingInput, now time.Time) bool {
+	point := geo.Point{Latitude: p.Latitude, Longitude: p.Longitude}
+	if !point.Valid() || (p.Latitude == 0 && p.Longitude == 0) {
+		return false
+	}
+	if p.RecordedAt.IsZero() || p.RecordedAt.After(now.Add(time.Minute)) {
+		return false
+	}
+	return s.retention <= 0 || p.RecordedAt.After(now.Add(-s.retention))
+}
+
+// updateNextVisitETA recomputes and stores the ETA of the staff member's next visit today.
+// Failures are logged and return nil; the location itself has already been stored.
+func (s *StaffLocationService) updateNextVisitETA(ctx context.Context, staffID string, position geo.Point, now time.Time) *models.VisitETA {
+	today := civil.DateOf(now.In(clinicTimeZone))
+	schedules, err := s.visitScheduleRepo.ListStaffDay(ctx, staffID, today)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to load visits for ETA", map[string]interface{}{
+			"staff_id": staffID,
+			"error":    err.Error(),
+		})
+		return nil
+	}
+
+	next, departure := nextVisit(schedules, now)
+	if next == nil {
+		return nil
+	}
+
+	patient, err := s.patientRepo.GetPatientByID(ctx, next.PatientID)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to load patient for ETA", map[string]interface{}{
+			"patient_id": next.PatientID,
+			"error":      err.Error(),
+		})
+		return nil
+	}
+	location, err := patient.VisitGeolocation()
+	if err != nil || location == nil {
+		return nil
+	}
+
+	eta := s.estimateArrival(next, position, geo.Point{Latitude: location.Latitude, Longitude: location.Longitude}, departure)
+	eta.StaffID = staffID
+	eta.UpdatedAt = now
+
+	if err := s.visitScheduleRepo.UpdateETA(ctx, next.ScheduleID, eta.EstimatedArrivalAt, eta.RunningLate); err != nil {
+		logger.WarnContext(ctx, "Failed to store visit ETA", map[string]interface{}{
+			"schedule_id": next.ScheduleID,
+			"error":       err.Error(),
+		})
+		return nil
+	}
+	if eta.RunningLate && !next.RunningLate {
+		logger.InfoContext(ctx, "Visit is running late", map[string]interface{}{
+			"schedule_id":     next.ScheduleID,
+			"staff_id":        staffID,
+			"late_by_minutes": eta.LateByMinutes,
+		})
+	}
+
+	s.publish(eta)
+	return eta
+}
+
+// estimateArrival drives from position to the patient after departure, using the local
+// optimizer's travel-time estimate
+func (s *StaffLocationService) estimateArrival(next *models.VisitSchedule, position, destination geo.Point, departure time.Time) *models.VisitETA {
+	arrival := departure.Add(s.travel.travelDuration(position, destination))
+	eta := &models.VisitETA{
+		ScheduleID:         next.ScheduleID,
+		PatientID:          next.PatientID,
+		EstimatedArrivalAt: arrival,
+		DistanceKm:         math.Round(s.travel.roadDistanceMeters(position, destination)/10) / 100,
+	}
+
+	if dueBy := visitDueBy(next); dueBy != nil {
+		eta.DueBy = dueBy
+		if arrival.After(*dueBy) {
+			eta.RunningLate = true
+			eta.LateByMinutes = int64(math.Ceil(arrival.Sub(*dueBy).Minutes()))
+		}
+	}
+
+	return eta
+}
+
+// nextVisit picks the first visit still to be made in route order, and when the staff
+// member can set off for it: now, or once the visit in progress is expected to end
+func nextVisit(schedules []*models.VisitSchedule, now time.Time) (*models.VisitSchedule, time.Time) {
+	ordered := append([]*models.VisitSchedule{}, schedules...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i].OptimizedSequence(), ordered[j].OptimizedSequence()
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+
+	departure := now
+	var next *models.VisitSchedule
+	for _, schedule := range ordered {
+		switch schedule.Status {
+		case "completed", "cancelled":
+			continue
+		case "in_progress":
+			if schedule.ActualArrivalAt.Valid {
+				free := schedule.ActualArrivalAt.Time.Add(time.Duration(schedule.EstimatedDurationMinutes) * time.Minute)
+				if free.After(departure) {
+					departure = free
+				}
+			}
+			continue
+		}
+		if next == nil {
+			next = schedule
+		}
+	}
+
+	return next, departure
+}
+
+// visitDueBy is the latest on-time arrival: the end of the visit's window, or a grace
+// period after its planned start. Returns nil for visits without any planned time.
+func visitDueBy(schedule *models.VisitSchedule) *time.Time {
+	if schedule.TimeWindowEnd.Valid {
+		end := schedule.TimeWindowEnd.Time
+		return &end
+	}
+	if schedule.TimeWindowStart.Valid {
+		due := schedule.TimeWindowStart.Time.Add(runningLateGrace)
+		return &due
+	}
+	return nil
+}
+
+// GetRunningLate returns the visits on a date (the clinic's today when nil) that are
+// running late, limited to patients the requestor has access to
+func (s *StaffLocationService) GetRunningLate(ctx context.Context, requestorID string, date *civil.Date) ([]*models.VisitSchedule, error) {
+	day := clinicToday()
+	if date != nil {
+		day = *date
+	}
+
+	schedules, err := s.visitScheduleRepo.ListRunningLate(ctx, day)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list running late visits", err, map[string]interface{}{
+			"date": day.String(),
+		})
+		return nil, fmt.Errorf("failed to list running late visits: %w", err)
+	}
+
+	access := make(map[string]bool)
+	visible := []*models.VisitSchedule{}
+	for _, schedule := range schedules {
+		if s.canView(ctx, requestorID, schedule.PatientID, access) {
+			visible = append(visible, schedule)
+		}
+	}
+
+	return visible, nil
+}
+
+// SubscribeETAs streams ETA updates for patients the requestor has access to until ctx is done
+func (s *StaffLocationService) SubscribeETAs(ctx context.Context, requestorID string) <-chan *models.VisitETA {
+	raw := make(chan *models.VisitETA, etaSubscriberBuffer)
+	s.mu.Lock()
+	s.subscribers[raw] = struct{}{}
+	s.mu.Unlock()
+
+	out := make(chan *models.VisitETA)
+	go func() {
+		defer close(out)
+		defer func() {
+			s.mu.Lock()
+			delete(s.subscribers, raw)
+			s.mu.Unlock()
+		}()
+
+		access := make(map[string]bool)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case eta := <-raw:
+				if !s.canView(ctx, requestorID, eta.PatientID, access) {
+					continue
+				}
+				select {
+				case out <- eta:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// publish hands an ETA update to every subscriber, dropping it for subscribers that are full
+func (s *StaffLocationService) publish(eta *models.VisitETA) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- eta:
+		default:
+		}
+	}
+}
+
+// canView checks (and caches) whether the requestor has access to a patient
+func (s *StaffLocationService) canView(ctx context.Context, requestorID, patientID string, cache map[string]bool) bool {
+	if allowed, ok := cache[patientID]; ok {
+		return allowed
+	}
+	allowed, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to check staff access for ETA", map[string]interface{}{
+			"patient_id":   patientID,
+			"requestor_id": requestorID,
+			"error":        err.Error(),
+		})
+		return false
+	}
+	cache[patientID] = allowed
+	return allowed
+}
+
+// PurgeExpiredPings deletes location pings older than the retention period
+func (s *StaffLocationService) PurgeExpiredPings(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	deleted, err := s.locationRepo.DeleteRecordedBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		logger.InfoContext(ctx, "Expired location pings deleted", map[string]interface{}{
+			"count":     deleted,
+			"retention": s.retention.String(),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/pkg/geo"
+)
+
+func TestNextVisit(t *testing.T) {
+	now := *routeTestTime(10, 0)
+	sequenced := func(id, status string, sequence int) *models.VisitSchedule {
+		result, _ := json.Marshal(models.ScheduleOptimizationResult{JobID: "job-1", Sequence: sequence})
+		return &models.VisitSchedule{ScheduleID: id, Status: status, OptimizationResult: result, EstimatedDurationMinutes: 30}
+	}
+
+	t.Run("Skips finished visits and follows route order", func(t *testing.T) {
+		schedules := []*models.VisitSchedule{
+			{ScheduleID: "unrouted", Status: "assigned"},
+			sequenced("third", "assigned", 3),
+			sequenced("first", "completed", 1),
+			sequenced("second", "assigned", 2),
+		}
+
+		next, departure := nextVisit(schedules, now)
+		require.NotNil(t, next)
+		assert.Equal(t, "second", next.ScheduleID)
+		assert.Equal(t, now, departure)
+	})
+
+	t.Run("Departs once the visit in progress is expected to end", func(t *testing.T) {
+		inProgress := sequenced("first", "in_progress", 1)
+		inProgress.ActualArrivalAt = spanner.NullTime{Time: now.Add(-10 * time.Minute), Valid: true}
+
+		next, departure := nextVisit([]*models.VisitSchedule{inProgress, sequenced("second", "assigned", 2)}, now)
+		require.NotNil(t, next)
+		assert.Equal(t, "second", next.ScheduleID)
+		assert.Equal(t, now.Add(20*time.Minute), departure)
+	})
+
+	t.Run("No open visits", func(t *testing.T) {
+		next, _ := nextVisit([]*models.VisitSchedule{sequenced("first", "completed", 1)}, now)
+		assert.Nil(t, next)
+	})
+}
+
+func TestEstimateArrival(t *testing.T) {
+	s := &StaffLocationService{travel: NewLocalRouteOptimizer()}
+	now := *routeTestTime(10, 0)
+	position := geo.Point{Latitude: 35.6812, Longitude: 139.7671}
+	patient := geo.Point{Latitude: 35.7812, Longitude: 139.7671} // ~35 minutes away at the local optimizer's speed
+
+	t.Run("Late against the window end", func(t *testing.T) {
+		visit := &models.VisitSchedule{
+			ScheduleID:      "s-1",
+			PatientID:       "p-1",
+			TimeWindowStart: spanner.NullTime{Time: now, Valid: true},
+			TimeWindowEnd:   spanner.NullTime{Time: now.Add(30 * time.Minute), Valid: true},
+		}
+
+		eta := s.estimateArrival(visit, position, patient, now)
+		assert.True(t, eta.RunningLate)
+		assert.Equal(t, int64(5), eta.LateByMinutes)
+		assert.Equal(t, now.Add(30*time.Minute), *eta.DueBy)
+		assert.InDelta(t, 14.46, eta.DistanceKm, 0.05)
+	})
+
+	t.Run("Window start only allows a grace period", func(t *testing.T) {
+		visit := &models.VisitSchedule{
+			ScheduleID:      "s-1",
+			TimeWindowStart: spanner.NullTime{Time: now.Add(30 * time.Minute), Valid: true},
+		}
+
+		eta := s.estimateArrival(visit, position, patient, now)
+		assert.False(t, eta.RunningLate)
+		assert.Equal(t, now.Add(45*time.Minute), *eta.DueBy)
+	})
+
+	t.Run("Unplanned visits are never late", func(t *testing.T) {
+		eta := s.estimateArrival(&models.VisitSchedule{ScheduleID: "s-1"}, position, patient, now)
+		assert.False(t, eta.RunningLate)
+		assert.Nil(t, eta.DueBy)
+	})
+}
+
+func TestAcceptPing(t *testing.T) {
+	s := &StaffLocationService{retention: 72 * time.Hour}
+	now := *routeTestTime(10, 0)
+	ping := func(lat, lng float64, at time.Time) models.StaffLocationPingInput {
+		return models.StaffLocationPingInput{Latitude: lat, Longitude: lng, RecordedAt: at}
+	}
+
+	assert.True(t, s.acceptPing(ping(35.68, 139.76, now.Add(-time.Minute)), now))
+	assert.False(t, s.acceptPing(ping(95, 139.76, now), now), "latitude out of range")
+	assert.False(t, s.acceptPing(ping(0, 0, now), now), "null island")
+	assert.False(t, s.acceptPing(ping(35.68, 139.76, now.Add(10*time.Minute)), now), "future fix")
+	assert.False(t, s.acceptPing(ping(35.68, 139.76, now.Add(-73*time.Hour)), now), "past retention")
+	assert.False(t, s.acceptPing(ping(35.68, 139.76, time.Time{}), now), "missing time")
+}
+
+func TestVehicleInUse(t *testing.T) {
+	assigned := "vehicle-own"
+	staff := &models.StaffMember{AssignedVehicleID: &assigned}
+	schedules := []*models.VisitSchedule{
+		{ScheduleID: "a", Status: "assigned", AssignedVehicleID: spanner.NullString{StringVal: "vehicle-booked", Valid: true}},
+		{ScheduleID: "b", Status: "cancelled", AssignedVehicleID: spanner.NullString{StringVal: "vehicle-cancelled", Valid: true}},
+	}
+
+	assert.True(t, vehicleInUse(staff, schedules, "vehicle-own"))
+	assert.True(t, vehicleInUse(staff, schedules, "vehicle-booked"))
+	assert.False(t, vehicleInUse(staff, schedules, "vehicle-cancelled"))
+	assert.False(t, vehicleInUse(staff, schedules, "vehicle-unknown"))
+	assert.False(t, vehicleInUse(&models.StaffMember{}, nil, "vehicle-own"))
+}
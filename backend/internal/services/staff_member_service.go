@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+var (
+	validStaffRoles = map[string]bool{
+		"doctor":       true,
+		"nurse":        true,
+		"care_manager": true,
+		"therapist":    true,
+		"pharmacist":   true,
+		"driver":       true,
+		"admin":        true,
+	}
+	validAvailabilityStatuses = map[string]bool{
+		"available": true,
+		"on_visit":  true,
+		"off_duty":  true,
+		"on_leave":  true,
+	}
+	validAccessLevels = map[string]bool{
+		"standard":   true,
+		"supervisor": true,
+		"admin":      true,
+	}
+	validAccountStatuses = map[string]bool{
+		"active":    true,
+		"suspended": true,
+		"inactive":  true,
+	}
+)
+
+// StaffMemberService handles business logic for the staff directory
+type StaffMemberService struct {
+	staffRepo *repository.StaffMemberRepository
+}
+
+// NewStaffMemberService creates a new staff member service
+func NewStaffMemberService(staffRepo *repository.StaffMemberRepository) *StaffMemberService {
+	return &StaffMemberService{
+		staffRepo: staffRepo,
+	}
+}
+
+// requireAdmin checks that userID is an active staff member with admin access.
+// Creating, updating and deleting staff controls who can see patient data, so it is admin only.
+func (s *StaffMemberService) requireAdmin(ctx context.Context, userID, action string) error {
+	caller, err := s.staffRepo.GetByID(ctx, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			logger.WarnContext(ctx, "Staff directory change by unknown user", map[string]interface{}{
+				"user_id": userID,
+				"action":  action,
+			})
+			return fmt.Errorf("access denied: only administrators can %s staff members", action)
+		}
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if caller.AccessLevel != "admin" || caller.AccountStatus != "active" {
+		logger.WarnContext(ctx, "Unauthorized staff directory change attempt", map[string]interface{}{
+			"user_id":      userID,
+			"access_level": caller.AccessLevel,
+			"action":       action,
+		})
+		return fmt.Errorf("access denied: only administrators can %s staff members", action)
+	}
+
+	return nil
+}
+
+// CreateStaffMember creates a new staff member (admin only)
+func (s *StaffMemberService) CreateStaffMember(ctx context.Context, req *models.StaffMemberCreateRequest, createdBy string) (*models.StaffMember, error) {
+	if err := s.requireAdmin(ctx, createdBy, "create"); err != nil {
+		return nil, err
+	}
+
+	if req.FamilyName == "" || req.GivenName == "" {
+		return nil, fmt.Errorf("family_name and given_name are required")
+	}
+	if req.Email == "" {
+		return nil, fmt.Errorf("email is required")
+	}
+
+	// Apply column defaults so the returned model matches the stored row
+	if req.AvailabilityStatus == "" {
+		req.AvailabilityStatus = "available"
+	}
+	if req.AccessLevel == "" {
+		req.AccessLevel = "standard"
+	}
+	if req.AccountStatus == "" {
+		req.AccountStatus = "active"
+	}
+
+	if err := validateStaffEnums(ctx, &req.Role, &req.AvailabilityStatus, &req.AccessLevel, &req.AccountStatus); err != nil {
+		return nil, err
+	}
+	if err := validateLicenseDates(req.LicenseIssuedDate, req.LicenseExpiryDate); err != nil {
+		return nil, err
+	}
+
+	staff, err := s.staffRepo.Create(ctx, req, createdBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create staff member", err, map[string]interface{}{
+			"created_by": createdBy,
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Staff member created successfully", map[string]interface{}{
+		"staff_id":   staff.StaffID,
+		"role":       staff.Role,
+		"created_by": createdBy,
+	})
+
+	return staff, nil
+}
+
+// GetStaffMember retrieves a staff member by ID
+func (s *StaffMemberService) GetStaffMember(ctx context.Context, staffID string) (*models.StaffMember, error) {
+	staff, err := s.staffRepo.GetByID(ctx, staffID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get staff member", err, map[string]interface{}{
+			"staff_id": staffID,
+		})
+		return nil, err
+	}
+
+	return staff, nil
+}
+
+// ListStaffMembers retrieves staff members with filters
+func (s *StaffMemberService) ListStaffMembers(ctx context.Context, filter *models.StaffMemberFilter) ([]*models.StaffMember, error) {
+	staff, err := s.staffRepo.List(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list staff members", err, map[string]interface{}{})
+		return nil, err
+	}
+
+	return staff, nil
+}
+
+// UpdateStaffMember updates a staff member (admin only)
+func (s *StaffMemberService) UpdateStaffMember(ctx context.Context, staffID string, req *models.StaffMemberUpdateRequest, updatedBy string) (*models.StaffMember, error) {
+	if err := s.requireAdmin(ctx, updatedBy, "update"); err != nil {
+		return nil, err
+	}
+
+	if err := validateStaffEnums(ctx, req.Role, req.AvailabilityStatus, req.AccessLevel, req.AccountStatus); err != nil {
+		return nil, err
+	}
+	if err := validateLicenseDates(req.LicenseIssuedDate, req.LicenseExpiryDate); err != nil {
+		return nil, err
+	}
+
+	staff, err := s.staffRepo.Update(ctx, staffID, req, updatedBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to update staff member", err, map[string]interface{}{
+			"staff_id":   staffID,
+			"updated_by": updatedBy,
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Staff member updated successfully", map[string]interface{}{
+		"staff_id":   staffID,
+		"updated_by": updatedBy,
+	})
+
+	return staff, nil
+}
+
+// DeleteStaffMember soft-deletes a staff member (admin only)
+func (s *StaffMemberService) DeleteStaffMember(ctx context.Context, staffID, deletedBy string) error {
+	if err := s.requireAdmin(ctx, deletedBy, "delete"); err != nil {
+		return err
+	}
+
+	if err := s.staffRepo.Delete(ctx, staffID, deletedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete staff member", err, map[string]interface{}{
+			"staff_id":   staffID,
+			"deleted_by": deletedBy,
+		})
+		return err
+	}
+
+	logger.InfoContext(ctx, "Staff member deleted successfully", map[string]interface{}{
+		"staff_id":   staffID,
+		"deleted_by": deletedBy,
+	})
+
+	return nil
+}
+
+// GetExpiringLicenses retrieves active staff whose license expires within the given number of days
+// Already-expired licenses are included so they are not missed.
+func (s *StaffMemberService) GetExpiringLicenses(ctx context.Context, days int) ([]*models.StaffMember, error) {
+	if days < 0 {
+		return nil, fmt.Errorf("days must be zero or greater")
+	}
+
+	before := civil.DateOf(time.Now()).AddDays(days)
+	staff, err := s.staffRepo.GetExpiringLicenses(ctx, before)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get expiring licenses", err, map[string]interface{}{
+			"days": days,
+		})
+		return nil, err
+	}
+
+	return staff, nil
+}
+
+// validateStaffEnums validates enumerated staff fields; nil values are skipped
+func validateStaffEnums(ctx context.Context, role, availability, accessLevel, accountStatus *string) error {
+	if role != nil && !validStaffRoles[*role] {
+		logger.WarnContext(ctx, "Invalid staff role", map[string]interface{}{
+			"role": *role,
+		})
+		return fmt.Errorf("invalid role: %s", *role)
+	}
+	if availability != nil && !validAvailabilityStatuses[*availability] {
+		logger.WarnContext(ctx, "Invalid availability status", map[string]interface{}{
+			"availability_status": *availability,
+		})
+		return fmt.Errorf("invalid availability_status: %s", *availability)
+	}
+	if accessLevel != nil && !validAccessLevels[*accessLevel] {
+		logger.WarnContext(ctx, "Invalid access level", map[string]interface{}{
+			"access_level": *accessLevel,
+		})
+		return fmt.Errorf("invalid access_level: %s", *accessLevel)
+	}
+	if accountStatus != nil && !validAccountStatuses[*accountStatus] {
+		logger.WarnContext(ctx, "Invalid account status", map[string]interface{}{
+			"account_status": *accountStatus,
+		})
+		return fmt.Errorf("invalid account_status: %s", *accountStatus)
+	}
+	return nil
+}
+
+// validateLicenseDates ensures the license expiry is not before its issue date
+func validateLicenseDates(issued, expiry *civil.Date) error {
+	if issued != nil && expiry != nil && expiry.Before(*issued) {
+		return fmt.Errorf("license_expiry_date must be on or after license_issued_date")
+	}
+	return nil
+}
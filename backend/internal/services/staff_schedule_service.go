@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// StaffScheduleService builds a staff member's daily visit timeline across patients
+type StaffScheduleService struct {
+	visitScheduleRepo *repository.VisitScheduleRepository
+	auditRepo         *repository.AuditRepository
+	source            timelineSource
+}
+
+// timelineSource loads the patient data embedded in a timeline
+type timelineSource interface {
+	CheckStaffAccess(ctx context.Context, staffID, patientID string) (bool, error)
+	GetPatientByID(ctx context.Context, patientID string) (*models.Patient, error)
+	GetActiveAllergies(ctx context.Context, patientID string) ([]*models.AllergyIntolerance, error)
+	GetLatestACP(ctx context.Context, patientID string) (*models.ACPRecord, error)
+}
+
+// repositoryTimelineSource reads timeline patient data from the repositories
+type repositoryTimelineSource struct {
+	*repository.PatientRepository
+	*repository.AllergyIntoleranceRepository
+	*repository.ACPRecordRepository
+}
+
+// timelineAccessedFields are recorded in the audit log for each patient shown on a timeline
+var timelineAccessedFields = []string{"name", "phone", "address", "access_instructions", "active_allergies", "dnar"}
+
+// NewStaffScheduleService creates a new staff schedule service
+func NewStaffScheduleService(
+	visitScheduleRepo *repository.VisitScheduleRepository,
+	patientRepo *repository.PatientRepository,
+	allergyRepo *repository.AllergyIntoleranceRepository,
+	acpRecordRepo *repository.ACPRecordRepository,
+	auditRepo *repository.AuditRepository,
+) *StaffScheduleService {
+	return &StaffScheduleService{
+		visitScheduleRepo: visitScheduleRepo,
+		auditRepo:         auditRepo,
+		source:            repositoryTimelineSource{patientRepo, allergyRepo, acpRecordRepo},
+	}
+}
+
+// GetDaySchedule returns the staff member's visits on a date (the clinic's today when nil),
+// in optimized route order, with each patient's address, active allergies and DNAR flag.
+// Patient details are only embedded for patients the staff member has access to, and each
+// embedding is recorded in the audit log.
+func (s *StaffScheduleService) GetDaySchedule(ctx context.Context, staffID string, date *civil.Date) (*models.StaffDaySchedule, error) {
+	day := clinicToday()
+	if date != nil {
+		day = *date
+	}
+
+	schedules, err := s.visitScheduleRepo.ListStaffDay(ctx, staffID, day)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list staff visits", err, map[string]interface{}{
+			"staff_id": staffID,
+			"date":     day.String(),
+		})
+		return nil, fmt.Errorf("failed to list visits: %w", err)
+	}
+
+	visits := make([]*models.StaffScheduleVisit, len(schedules))
+	details := make(map[string]*models.StaffScheduleVisit)
+	for i, schedule := range schedules {
+		detail, ok := details[schedule.PatientID]
+		if !ok {
+			detail = patientDetails(ctx, s.source, staffID, schedule.PatientID)
+			details[schedule.PatientID] = detail
+			s.logPatientAccess(ctx, staffID, detail)
+		}
+
+		visits[i] = &models.StaffScheduleVisit{
+			VisitSchedule:      schedule,
+			Sequence:           schedule.OptimizedSequence(),
+			Patient:            detail.Patient,
+			Allergies:          detail.Allergies,
+			DNAR:               detail.DNAR,
+			UnavailableDetails: detail.UnavailableDetails,
+		}
+	}
+	sortTimeline(visits)
+
+	return &models.StaffDaySchedule{
+		StaffID: staffID,
+		Date:    day,
+		Visits:  visits,
+	}, nil
+}
+
+// logPatientAccess records that a patient's details were shown on a staff timeline. The
+// route is not under /patients, so the audit middleware does not see these reads.
+func (s *StaffScheduleService) logPatientAccess(ctx context.Context, staffID string, detail *models.StaffScheduleVisit) {
+	if hasUnavailable(detail, "patient") && hasUnavailable(detail, "allergies") && hasUnavailable(detail, "dnar") {
+		return
+	}
+
+	fields, _ := json.Marshal(timelineAccessedFields)
+	auditLog := &repository.AuditLog{
+		EventTime:      time.Now(),
+		ActorID:        staffID,
+		Action:         repository.AuditActionView,
+		ResourceID:     detail.Patient.PatientID,
+		PatientID:      detail.Patient.PatientID,
+		AccessedFields: fields,
+		Success:        true,
+	}
+	if err := s.auditRepo.LogAccess(ctx, auditLog); err != nil {
+		logger.ErrorContext(ctx, "Failed to write audit log", err, map[string]interface{}{
+			"patient_id": detail.Patient.PatientID,
+			"user_id":    staffID,
+		})
+	}
+}
+
+// patientDetails loads the patient fields shown on the timeline. A failed lookup does not
+// hide the rest of the day: the detail is left null and listed in UnavailableDetails, so
+// an unknown allergy list or DNAR order is never shown as "none".
+func patientDetails(ctx context.Context, source timelineSource, staffID, patientID string) *models.StaffScheduleVisit {
+	detail := &models.StaffScheduleVisit{
+		Patient: models.StaffSchedulePatient{PatientID: patientID},
+	}
+
+	hasAccess, err := source.CheckStaffAccess(ctx, staffID, patientID)
+	if err != nil || !hasAccess {
+		fields := map[string]interface{}{
+			"staff_id":   staffID,
+			"patient_id": patientID,
+			"has_access": hasAccess,
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		logger.WarnContext(ctx, "Omitting patient details from staff timeline", fields)
+		detail.UnavailableDetails = []string{"patient", "allergies", "dnar"}
+		return detail
+	}
+
+	patient, err := source.GetPatientByID(ctx, patientID)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to load patient for staff timeline", map[string]interface{}{
+			"patient_id": patientID,
+			"error":      err.Error(),
+		})
+		detail.UnavailableDetails = append(detail.UnavailableDetails, "patient")
+	} else {
+		detail.Patient.FamilyName = patient.CurrentFamilyName
+		detail.Patient.GivenName = patient.CurrentGivenName
+		detail.Patient.PrimaryPhone = patient.PrimaryPhone
+		if addr, err := patient.VisitAddress(); err == nil && addr != nil {
+			detail.Patient.Address = addr
+			detail.Patient.AccessInstructions = addr.AccessInstructions
+		}
+		if g, err := patient.VisitGeolocation(); err == nil {
+			detail.Patient.Geolocation = g
+		}
+	}
+
+	allergies, err := source.GetActiveAllergies(ctx, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load allergies for staff timeline", err, map[string]interface{}{
+			"patient_id": patientID,
+		})
+		detail.UnavailableDetails = append(detail.UnavailableDetails, "allergies")
+	} else {
+		detail.Allergies = []models.StaffScheduleAllergy{}
+		for _, a := range allergies {
+			detail.Allergies = append(detail.Allergies, models.StaffScheduleAllergy{
+				AllergyID:   a.AllergyID,
+				DisplayName: a.DisplayName,
+				Category:    a.Category,
+				Criticality: a.Criticality,
+				MaxSeverity: a.MaxSeverity,
+			})
+		}
+	}
+
+	detail.DNAR, err = dnarStatus(ctx, source, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load DNAR status for staff timeline", err, map[string]interface{}{
+			"patient_id": patientID,
+		})
+		detail.UnavailableDetails = append(detail.UnavailableDetails, "dnar")
+	}
+
+	return detail
+}
+
+// dnarStatus reads the DNAR order from the patient's active ACP record. No active record
+// means no DNAR; any other failure leaves the status unknown.
+func dnarStatus(ctx context.Context, source timelineSource, patientID string) (*bool, error) {
+	acp, err := source.GetLatestACP(ctx, patientID)
+	if err != nil {
+		if strings.Contains(err.Error(), "no active ACP record") {
+			dnar := false
+			return &dnar, nil
+		}
+		return nil, err
+	}
+
+	dnar, err := acp.HasDNAR()
+	if err != nil {
+		return nil, err
+	}
+	return &dnar, nil
+}
+
+func hasUnavailable(detail *models.StaffScheduleVisit, name string) bool {
+	for _, n := range detail.UnavailableDetails {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sortTimeline orders visits by their optimized route sequence. Visits outside an
+// optimized route follow in planned start order, which is how they are loaded.
+func sortTimeline(visits []*models.StaffScheduleVisit) {
+	sort.SliceStable(visits, func(i, j int) bool {
+		a, b := visits[i].Sequence, visits[j].Sequence
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+}
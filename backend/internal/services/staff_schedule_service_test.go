@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func TestSortTimeline(t *testing.T) {
+	seq := func(n int) *int { return &n }
+	visit := func(id string, sequence *int) *models.StaffScheduleVisit {
+		return &models.StaffScheduleVisit{
+			VisitSchedule: &models.VisitSchedule{ScheduleID: id},
+			Sequence:      sequence,
+		}
+	}
+
+	// Loaded in planned start order; "late-added" was inserted after the route was optimized
+	visits := []*models.StaffScheduleVisit{
+		visit("early-unrouted", nil),
+		visit("third", seq(3)),
+		visit("first", seq(1)),
+		visit("late-added", nil),
+		visit("second", seq(2)),
+	}
+	sortTimeline(visits)
+
+	var ids []string
+	for _, v := range visits {
+		ids = append(ids, v.ScheduleID)
+	}
+	assert.Equal(t, []string{"first", "second", "third", "early-unrouted", "late-added"}, ids)
+}
+
+// fakeTimelineSource serves timeline lookups from fixed values
+type fakeTimelineSource struct {
+	hasAccess  bool
+	accessErr  error
+	patient    *models.Patient
+	patientErr error
+	allergies  []*models.AllergyIntolerance
+	allergyErr error
+	acp        *models.ACPRecord
+	acpErr     error
+}
+
+func (f *fakeTimelineSource) CheckStaffAccess(ctx context.Context, staffID, patientID string) (bool, error) {
+	return f.hasAccess, f.accessErr
+}
+
+func (f *fakeTimelineSource) GetPatientByID(ctx context.Context, patientID string) (*models.Patient, error) {
+	return f.patient, f.patientErr
+}
+
+func (f *fakeTimelineSource) GetActiveAllergies(ctx context.Context, patientID string) ([]*models.AllergyIntolerance, error) {
+	return f.allergies, f.allergyErr
+}
+
+func (f *fakeTimelineSource) GetLatestACP(ctx context.Context, patientID string) (*models.ACPRecord, error) {
+	return f.acp, f.acpErr
+}
+
+func TestPatientDetails(t *testing.T) {
+	ctx := context.Background()
+	noACP := errors.New("no active ACP record found for patient")
+	available := func() *fakeTimelineSource {
+		return &fakeTimelineSource{
+			hasAccess: true,
+			patient:   &models.Patient{CurrentFamilyName: "山田", CurrentGivenName: "太郎"},
+			allergies: []*models.AllergyIntolerance{{AllergyID: "a-1", DisplayName: "ペニシリン", Criticality: "high"}},
+			acp:       &models.ACPRecord{Directives: json.RawMessage(`{"dnar": true}`)},
+		}
+	}
+
+	t.Run("All details loaded", func(t *testing.T) {
+		detail := patientDetails(ctx, available(), "staff-1", "p-1")
+
+		assert.Equal(t, "山田", detail.Patient.FamilyName)
+		require.Len(t, detail.Allergies, 1)
+		assert.Equal(t, "ペニシリン", detail.Allergies[0].DisplayName)
+		require.NotNil(t, detail.DNAR)
+		assert.True(t, *detail.DNAR)
+		assert.Empty(t, detail.UnavailableDetails)
+	})
+
+	t.Run("No allergies and no ACP record are known values", func(t *testing.T) {
+		source := available()
+		source.allergies = nil
+		source.acp, source.acpErr = nil, noACP
+
+		detail := patientDetails(ctx, source, "staff-1", "p-1")
+		assert.NotNil(t, detail.Allergies)
+		assert.Empty(t, detail.Allergies)
+		require.NotNil(t, detail.DNAR)
+		assert.False(t, *detail.DNAR)
+		assert.Empty(t, detail.UnavailableDetails)
+	})
+
+	t.Run("Patient without access is omitted", func(t *testing.T) {
+		source := available()
+		source.hasAccess = false
+
+		detail := patientDetails(ctx, source, "staff-1", "p-1")
+		assert.Equal(t, "p-1", detail.Patient.PatientID)
+		assert.Empty(t, detail.Patient.FamilyName)
+		assert.Nil(t, detail.Allergies)
+		assert.Nil(t, detail.DNAR)
+		assert.Equal(t, []string{"patient", "allergies", "dnar"}, detail.UnavailableDetails)
+	})
+
+	t.Run("Access check failure is treated as no access", func(t *testing.T) {
+		source := available()
+		source.accessErr = errors.New("spanner unavailable")
+
+		detail := patientDetails(ctx, source, "staff-1", "p-1")
+		assert.Empty(t, detail.Patient.FamilyName)
+		assert.Equal(t, []string{"patient", "allergies", "dnar"}, detail.UnavailableDetails)
+	})
+
+	t.Run("Allergy lookup failure is unknown, not none", func(t *testing.T) {
+		source := available()
+		source.allergyErr = errors.New("spanner unavailable")
+
+		detail := patientDetails(ctx, source, "staff-1", "p-1")
+		assert.Nil(t, detail.Allergies)
+		assert.Equal(t, []string{"allergies"}, detail.UnavailableDetails)
+		require.NotNil(t, detail.DNAR)
+	})
+
+	t.Run("ACP lookup failure is unknown, not no DNAR", func(t *testing.T) {
+		source := available()
+		source.acp, source.acpErr = nil, errors.New("failed to query latest ACP record: deadline exceeded")
+
+		detail := patientDetails(ctx, source, "staff-1", "p-1")
+		assert.Nil(t, detail.DNAR)
+		assert.Equal(t, []string{"dnar"}, detail.UnavailableDetails)
+	})
+
+	t.Run("Unreadable ACP directives are unknown", func(t *testing.T) {
+		source := available()
+		source.acp = &models.ACPRecord{Directives: json.RawMessage(`{"dnar": "yes"}`)}
+
+		detail := patientDetails(ctx, source, "staff-1", "p-1")
+		assert.Nil(t, detail.DNAR)
+		assert.Equal(t, []string{"dnar"}, detail.UnavailableDetails)
+	})
+
+	t.Run("Patient lookup failure keeps clinical details", func(t *testing.T) {
+		source := available()
+		source.patient, source.patientErr = nil, errors.New("patient not found")
+
+		detail := patientDetails(ctx, source, "staff-1", "p-1")
+		assert.Equal(t, []string{"patient"}, detail.UnavailableDetails)
+		assert.Len(t, detail.Allergies, 1)
+		require.NotNil(t, detail.DNAR)
+		assert.True(t, *detail.DNAR)
+	})
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/documents"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/blobstore"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// ErrDocumentsUnavailable is returned when no blob store or key wrapper is configured
+var ErrDocumentsUnavailable = errors.New("statutory documents are not available: blob storage or encryption key is not configured")
+
+// documentObservationWindow is how far back vital signs are summarized on a document
+const documentObservationWindow = 30 * 24 * time.Hour
+
+// StatutoryDocumentService issues 訪問看護指示書 and 居宅療養管理指導書 from patient
+// records. Each document keeps a snapshot of its field values and their source records;
+// the rendered PDF is encrypted in blob storage like record attachments.
+type StatutoryDocumentService struct {
+	documentRepo        *repository.StatutoryDocumentRepository
+	patientRepo         *repository.PatientRepository
+	conditionRepo       *repository.MedicalConditionRepository
+	medicationOrderRepo *repository.MedicationOrderRepository
+	coverageRepo        *repository.CoverageRepository
+	observationRepo     *repository.ClinicalObservationRepository
+	staffMemberRepo     *repository.StaffMemberRepository
+	auditRepo           *repository.AuditRepository
+	blobs               *blobstore.SealedStore
+}
+
+// NewStatutoryDocumentService creates a new statutory document service. Issuing and
+// downloading documents is reported as not available when blobs is nil.
+func NewStatutoryDocumentService(
+	documentRepo *repository.StatutoryDocumentRepository,
+	patientRepo *repository.PatientRepository,
+	conditionRepo *repository.MedicalConditionRepository,
+	medicationOrderRepo *repository.MedicationOrderRepository,
+	coverageRepo *repository.CoverageRepository,
+	observationRepo *repository.ClinicalObservationRepository,
+	staffMemberRepo *repository.StaffMemberRepository,
+	auditRepo *repository.AuditRepository,
+	blobs *blobstore.SealedStore,
+) *StatutoryDocumentService {
+	return &StatutoryDocumentService{
+		documentRepo:        documentRepo,
+		patientRepo:         patientRepo,
+		conditionRepo:       conditionRepo,
+		medicationOrderRepo: medicationOrderRepo,
+		coverageRepo:        coverageRepo,
+		observationRepo:     observationRepo,
+		staffMemberRepo:     staffMemberRepo,
+		auditRepo:           auditRepo,
+		blobs:               blobs,
+	}
+}
+
+// IssueDocument assembles a document from the patient's current records and the values
+// entered by the issuing physician, renders and stores its PDF, and supersedes the issued
+// documents of the same type whose validity period overlaps the new one.
+func (s *StatutoryDocumentService) IssueDocument(ctx context.Context, patientID string, req *models.StatutoryDocumentCreateRequest, issuedBy string) (*models.StatutoryDocument, error) {
+	if s.blobs == nil {
+		return nil, ErrDocumentsUnavailable
+	}
+	if err := s.checkAccess(ctx, patientID, issuedBy); err != nil {
+		return nil, err
+	}
+
+	form, err := documents.FormFor(req.DocumentType)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := s.staffMemberRepo.GetByID(ctx, issuedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	src, err := s.loadSources(ctx, patientID, now)
+	if err != nil {
+		return nil, err
+	}
+	src.Issuer = issuer
+	src.IssuedOn = civil.DateOf(now.In(clinicTimeZone))
+
+	doc, err := newStatutoryDocument(form, src, req, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storePDF(ctx, doc); err != nil {
+		return nil, err
+	}
+
+	superseded, err := s.documentRepo.Issue(ctx, doc)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to record statutory document", err, map[string]interface{}{
+			"document_id": doc.DocumentID,
+			"patient_id":  patientID,
+		})
+		if delErr := s.blobs.Delete(ctx, doc.PDFBlobKey); delErr != nil {
+			logger.WarnContext(ctx, "Failed to remove orphaned document blob", map[string]interface{}{
+				"blob_key": doc.PDFBlobKey,
+				"error":    delErr.Error(),
+			})
+		}
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Statutory document issued", map[string]interface{}{
+		"document_id":   doc.DocumentID,
+		"document_type": doc.DocumentType,
+		"patient_id":    patientID,
+		"valid_from":    doc.ValidFrom.String(),
+		"valid_to":      doc.ValidTo.String(),
+		"superseded":    superseded,
+		"issued_by":     issuedBy,
+	})
+
+	return doc, nil
+}
+
+// ListDocuments lists a patient's statutory documents with access control
+func (s *StatutoryDocumentService) ListDocuments(ctx context.Context, patientID string, filter *models.StatutoryDocumentFilter, requestorID string) ([]*models.StatutoryDocument, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+	if filter.DocumentType != nil {
+		if _, err := documents.FormFor(*filter.DocumentType); err != nil {
+			return nil, err
+		}
+	}
+	return s.documentRepo.List(ctx, patientID, filter)
+}
+
+// GetDocument retrieves a statutory document, with its field sources, with access control
+func (s *StatutoryDocumentService) GetDocument(ctx context.Context, patientID, documentID, requestorID string) (*models.StatutoryDocument, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+	return s.documentRepo.GetByID(ctx, patientID, documentID)
+}
+
+// DownloadPDF decrypts the PDF of a document and checks it against the hash recorded at
+// issue. Every attempt that passes the access check is written to the patient access
+// audit log.
+func (s *StatutoryDocumentService) DownloadPDF(ctx context.Context, patientID, documentID, requestorID, ipAddress, userAgent string) (*models.StatutoryDocument, []byte, error) {
+	if s.blobs == nil {
+		return nil, nil, ErrDocumentsUnavailable
+	}
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, nil, err
+	}
+
+	doc, pdf, err := s.readPDF(ctx, patientID, documentID)
+
+	fields, _ := json.Marshal([]string{"statutory_document:pdf"})
+	auditLog := &repository.AuditLog{
+		EventTime:      time.Now(),
+		ActorID:        requestorID,
+		Action:         repository.AuditActionDownload,
+		ResourceID:     documentID,
+		PatientID:      patientID,
+		AccessedFields: fields,
+		Success:        err == nil,
+		IPAddress:      ipAddress,
+		UserAgent:      userAgent,
+	}
+	if err != nil {
+		auditLog.ErrorMessage = err.Error()
+	}
+	if logErr := s.auditRepo.LogAccess(ctx, auditLog); logErr != nil {
+		logger.ErrorContext(ctx, "Failed to write audit log", logErr, map[string]interface{}{
+			"patient_id":  patientID,
+			"document_id": documentID,
+			"user_id":     requestorID,
+		})
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, pdf, nil
+}
+
+func (s *StatutoryDocumentService) readPDF(ctx context.Context, patientID, documentID string) (*models.StatutoryDocument, []byte, error) {
+	doc, err := s.documentRepo.GetByID(ctx, patientID, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdf, err := s.openPDF(ctx, doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, pdf, nil
+}
+
+// storePDF renders a document and seals the PDF into blob storage, recording on the
+// document where it is stored and the hash it is checked against on download
+func (s *StatutoryDocumentService) storePDF(ctx context.Context, doc *models.StatutoryDocument) error {
+	pdf, err := documents.Render(doc, clinicTimeZone)
+	if err != nil {
+		return fmt.Errorf("failed to render document: %w", err)
+	}
+
+	key := fmt.Sprintf("statutory-documents/%s/%s.pdf", doc.PatientID, doc.DocumentID)
+	sealed, err := s.blobs.Put(ctx, key, pdf, documentAAD(doc))
+	if err != nil {
+		return fmt.Errorf("failed to store document: %w", err)
+	}
+	doc.PDFBlobKey = sealed.Key
+	doc.PDFWrappedKey = sealed.WrappedKey
+	doc.PDFSHA256 = sealed.SHA256
+	doc.PDFSizeBytes = sealed.SizeBytes
+	return nil
+}
+
+// openPDF decrypts a document's PDF and checks it against the hash recorded at issue
+func (s *StatutoryDocumentService) openPDF(ctx context.Context, doc *models.StatutoryDocument) ([]byte, error) {
+	sealed := blobstore.Sealed{Key: doc.PDFBlobKey, WrappedKey: doc.PDFWrappedKey, SHA256: doc.PDFSHA256}
+	pdf, err := s.blobs.Get(ctx, sealed, documentAAD(doc))
+	switch {
+	case errors.Is(err, blobstore.ErrNotFound):
+		return nil, fmt.Errorf("document content not found")
+	case errors.Is(err, blobstore.ErrDigestMismatch):
+		return nil, fmt.Errorf("document content does not match its recorded hash")
+	case err != nil:
+		return nil, err
+	}
+	return pdf, nil
+}
+
+// loadSources reads the records a document is assembled from: demographics, active
+// conditions and medication orders, active coverages and recent vital signs
+func (s *StatutoryDocumentService) loadSources(ctx context.Context, patientID string, now time.Time) (*documents.Sources, error) {
+	patient, err := s.patientRepo.GetPatientByID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	conditions, err := s.conditionRepo.GetActiveConditions(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	medications, err := s.medicationOrderRepo.GetActiveOrders(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	coverages, err := s.coverageRepo.GetActiveCoverages(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	category := "vital_signs"
+	from := now.Add(-documentObservationWindow)
+	observations, err := s.observationRepo.List(ctx, &models.ClinicalObservationFilter{
+		PatientID:             &patientID,
+		Category:              &category,
+		EffectiveDatetimeFrom: &from,
+		Limit:                 200,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &documents.Sources{
+		Patient:      patient,
+		Conditions:   conditions,
+		Medications:  medications,
+		Coverages:    coverages,
+		Observations: observations,
+		Location:     clinicTimeZone,
+	}, nil
+}
+
+func (s *StatutoryDocumentService) checkAccess(ctx context.Context, patientID, requestorID string) error {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":   patientID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized statutory document access attempt", map[string]interface{}{
+			"patient_id":   patientID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("access denied: you do not have permission to access documents of this patient")
+	}
+
+	return nil
+}
+
+// newStatutoryDocument assembles an unsaved document of the given form: its validity
+// period, checked against the form's limits, and its fields with their sources
+func newStatutoryDocument(form *documents.Form, src *documents.Sources, req *models.StatutoryDocumentCreateRequest, now time.Time) (*models.StatutoryDocument, error) {
+	validTo, err := form.ValidityPeriod(req.ValidFrom, req.ValidTo)
+	if err != nil {
+		return nil, err
+	}
+	if src.Issuer == nil || !form.IssuableBy(src.Issuer.Role) {
+		return nil, fmt.Errorf("access denied: a %s can only be issued by a physician", form.Title)
+	}
+
+	fields, err := documents.Assemble(form, src, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StatutoryDocument{
+		DocumentID:   uuid.New().String(),
+		PatientID:    src.Patient.PatientID,
+		DocumentType: form.Type,
+		ValidFrom:    req.ValidFrom,
+		ValidTo:      validTo,
+		Fields:       fields,
+		IssuedAt:     now,
+		IssuedBy:     src.Issuer.StaffID,
+	}, nil
+}
+
+// documentAAD names a document's PDF for sealing
+func documentAAD(doc *models.StatutoryDocument) []byte {
+	return blobstore.AAD(doc.PatientID, "statutory-documents", doc.DocumentID)
+}
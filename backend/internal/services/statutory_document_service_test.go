@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/documents"
+	"github.com/visitas/backend/internal/models"
+)
+
+var documentSourcesUpdatedAt = time.Date(2025, 3, 20, 1, 0, 0, 0, time.UTC)
+
+func testDocumentSources(role string) *documents.Sources {
+	return &documents.Sources{
+		Patient: &models.Patient{
+			PatientID:   "p-1",
+			NameHistory: json.RawMessage(`[{"family":"佐藤","given":"花子","kana":"サトウ ハナコ","valid_from":"1940-04-10T00:00:00Z"}]`),
+			UpdatedAt:   documentSourcesUpdatedAt,
+		},
+		Conditions: []*models.MedicalCondition{
+			{ConditionID: "c-1", ClinicalStatus: "active", VerificationStatus: "confirmed", DisplayName: "脳梗塞後遺症", UpdatedAt: documentSourcesUpdatedAt},
+		},
+		Issuer:   &models.StaffMember{StaffID: "s-1", FamilyName: "山田", GivenName: "太郎", Role: role, UpdatedAt: documentSourcesUpdatedAt},
+		IssuedOn: civil.Date{Year: 2025, Month: 4, Day: 1},
+		Location: clinicTimeZone,
+	}
+}
+
+func testDocumentRequest() *models.StatutoryDocumentCreateRequest {
+	return &models.StatutoryDocumentCreateRequest{
+		DocumentType: models.DocumentTypeHomeNursingInstruction,
+		ValidFrom:    civil.Date{Year: 2025, Month: 4, Day: 1},
+		Fields: map[string]string{
+			"care_instructions":            "服薬管理、清潔保持",
+			"emergency_contact":            "長男 090-0000-0000",
+			"diagnoses":                    "脳梗塞後遺症、高血圧症",
+			documents.FieldRecipient:       "みどり訪問看護ステーション",
+			documents.FieldInstitutionName: "さくら在宅クリニック",
+		},
+	}
+}
+
+func documentField(t *testing.T, doc *models.StatutoryDocument, key string) models.DocumentField {
+	t.Helper()
+	for _, field := range doc.Fields {
+		if field.Key == key {
+			return field
+		}
+	}
+	t.Fatalf("field %s not assembled", key)
+	return models.DocumentField{}
+}
+
+func TestNewStatutoryDocumentValidityPeriod(t *testing.T) {
+	form := documents.Forms[models.DocumentTypeHomeNursingInstruction]
+	now := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+	date := func(year int, month time.Month, day int) *civil.Date {
+		return &civil.Date{Year: year, Month: month, Day: day}
+	}
+
+	tests := []struct {
+		name    string
+		validTo *civil.Date
+		want    civil.Date
+		wantErr bool
+	}{
+		{"Defaults to one month", nil, civil.Date{Year: 2025, Month: 4, Day: 30}, false},
+		{"Requested end date", date(2025, 6, 15), civil.Date{Year: 2025, Month: 6, Day: 15}, false},
+		{"Six months at most", date(2025, 9, 30), civil.Date{Year: 2025, Month: 9, Day: 30}, false},
+		{"Longer than six months", date(2025, 10, 1), civil.Date{}, true},
+		{"Ends before it starts", date(2025, 3, 31), civil.Date{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testDocumentRequest()
+			req.ValidTo = tt.validTo
+
+			doc, err := newStatutoryDocument(form, testDocumentSources("doctor"), req, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, req.ValidFrom, doc.ValidFrom)
+			assert.Equal(t, tt.want, doc.ValidTo)
+		})
+	}
+
+	t.Run("Valid from is required", func(t *testing.T) {
+		req := testDocumentRequest()
+		req.ValidFrom = civil.Date{}
+		_, err := newStatutoryDocument(form, testDocumentSources("doctor"), req, now)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewStatutoryDocumentFieldSources(t *testing.T) {
+	form := documents.Forms[models.DocumentTypeHomeNursingInstruction]
+	now := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	doc, err := newStatutoryDocument(form, testDocumentSources("doctor"), testDocumentRequest(), now)
+	require.NoError(t, err)
+	assert.Equal(t, "p-1", doc.PatientID)
+	assert.Equal(t, "s-1", doc.IssuedBy)
+	assert.Equal(t, models.DocumentTypeHomeNursingInstruction, doc.DocumentType)
+
+	// Derived fields name the record and version they were read from
+	name := documentField(t, doc, "patient_name")
+	require.Len(t, name.Sources, 1)
+	assert.Equal(t, models.DocumentSourcePatient, name.Sources[0].Type)
+	assert.Equal(t, "p-1", name.Sources[0].ID)
+	require.NotNil(t, name.Sources[0].UpdatedAt)
+	assert.Equal(t, documentSourcesUpdatedAt, *name.Sources[0].UpdatedAt)
+
+	physician := documentField(t, doc, documents.FieldPhysicianName)
+	assert.Equal(t, "山田 太郎", physician.Value)
+	require.Len(t, physician.Sources, 1)
+	assert.Equal(t, models.DocumentSourceStaffMember, physician.Sources[0].Type)
+
+	// An entered value replaces the derived one and is attributed to the physician
+	diagnoses := documentField(t, doc, "diagnoses")
+	assert.Equal(t, "脳梗塞後遺症、高血圧症", diagnoses.Value)
+	assert.Equal(t, []models.DocumentSource{{Type: models.DocumentSourceManual}}, diagnoses.Sources)
+
+	// Fields with neither a record nor an entry have no sources
+	assert.Empty(t, documentField(t, doc, "pressure_ulcer").Sources)
+
+	t.Run("Only physicians issue documents", func(t *testing.T) {
+		_, err := newStatutoryDocument(form, testDocumentSources("nurse"), testDocumentRequest(), now)
+		assert.ErrorContains(t, err, "access denied")
+	})
+
+	t.Run("Required fields must be filled", func(t *testing.T) {
+		req := testDocumentRequest()
+		delete(req.Fields, "emergency_contact")
+		_, err := newStatutoryDocument(form, testDocumentSources("doctor"), req, now)
+		assert.ErrorContains(t, err, "emergency_contact")
+	})
+}
+
+func TestStatutoryDocumentPDFRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	form := documents.Forms[models.DocumentTypeHomeCareGuidance]
+	req := testDocumentRequest()
+	req.DocumentType = models.DocumentTypeHomeCareGuidance
+	req.Fields = map[string]string{
+		"care_notes":                   "転倒に注意",
+		documents.FieldRecipient:       "あおば居宅介護支援事業所",
+		documents.FieldInstitutionName: "さくら在宅クリニック",
+	}
+	doc, err := newStatutoryDocument(form, testDocumentSources("doctor"), req, time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	service := NewStatutoryDocumentService(nil, nil, nil, nil, nil, nil, nil, nil, newTestSealedStore(t))
+	require.NoError(t, service.storePDF(ctx, doc))
+	assert.Equal(t, "statutory-documents/p-1/"+doc.DocumentID+".pdf", doc.PDFBlobKey)
+	assert.NotEmpty(t, doc.PDFWrappedKey)
+	assert.Len(t, doc.PDFSHA256, 64)
+
+	pdf, err := service.openPDF(ctx, doc)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+	assert.Equal(t, doc.PDFSizeBytes, int64(len(pdf)))
+
+	t.Run("Content must match the hash recorded at issue", func(t *testing.T) {
+		tampered := *doc
+		tampered.PDFSHA256 = "00" + doc.PDFSHA256[2:]
+		_, err := service.openPDF(ctx, &tampered)
+		assert.ErrorContains(t, err, "does not match its recorded hash")
+	})
+
+	t.Run("A PDF does not open as another patient's document", func(t *testing.T) {
+		other := *doc
+		other.PatientID = "p-2"
+		_, err := service.openPDF(ctx, &other)
+		assert.Error(t, err)
+	})
+
+	t.Run("Missing content", func(t *testing.T) {
+		missing := *doc
+		missing.PDFBlobKey = "statutory-documents/p-1/missing.pdf"
+		_, err := service.openPDF(ctx, &missing)
+		assert.ErrorContains(t, err, "document content not found")
+	})
+}
+
+func TestDocumentsUnavailable(t *testing.T) {
+	service := NewStatutoryDocumentService(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, err := service.IssueDocument(context.Background(), "p-1", &models.StatutoryDocumentCreateRequest{}, "staff-1")
+	require.ErrorIs(t, err, ErrDocumentsUnavailable)
+
+	_, _, err = service.DownloadPDF(context.Background(), "p-1", "d-1", "staff-1", "", "")
+	require.ErrorIs(t, err, ErrDocumentsUnavailable)
+}
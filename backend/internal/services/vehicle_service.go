@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+var (
+	validVehicleTypes = map[string]bool{
+		"car":            true,
+		"kei_car":        true,
+		"van":            true,
+		"wheelchair_van": true,
+		"motorcycle":     true,
+		"bicycle":        true,
+	}
+	validVehicleStatuses = map[string]bool{
+		"available":      true,
+		"in_use":         true,
+		"maintenance":    true,
+		"out_of_service": true,
+		"retired":        true,
+	}
+)
+
+// VehicleService handles business logic for the vehicle fleet
+type VehicleService struct {
+	vehicleRepo *repository.VehicleRepository
+}
+
+// NewVehicleService creates a new vehicle service
+func NewVehicleService(vehicleRepo *repository.VehicleRepository) *VehicleService {
+	return &VehicleService{
+		vehicleRepo: vehicleRepo,
+	}
+}
+
+// CreateVehicle creates a new vehicle
+func (s *VehicleService) CreateVehicle(ctx context.Context, req *models.VehicleCreateRequest, createdBy string) (*models.Vehicle, error) {
+	if req.LicensePlate == "" {
+		return nil, fmt.Errorf("license_plate is required")
+	}
+	if req.VehicleStatus == "" {
+		req.VehicleStatus = "available"
+	}
+
+	if err := validateVehicleEnums(ctx, &req.VehicleType, &req.VehicleStatus); err != nil {
+		return nil, err
+	}
+
+	vehicle, err := s.vehicleRepo.Create(ctx, req, createdBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create vehicle", err, map[string]interface{}{
+			"license_plate": req.LicensePlate,
+			"created_by":    createdBy,
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Vehicle created successfully", map[string]interface{}{
+		"vehicle_id": vehicle.VehicleID,
+		"created_by": createdBy,
+	})
+
+	return vehicle, nil
+}
+
+// GetVehicle retrieves a vehicle by ID
+func (s *VehicleService) GetVehicle(ctx context.Context, vehicleID string) (*models.Vehicle, error) {
+	vehicle, err := s.vehicleRepo.GetByID(ctx, vehicleID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get vehicle", err, map[string]interface{}{
+			"vehicle_id": vehicleID,
+		})
+		return nil, err
+	}
+
+	return vehicle, nil
+}
+
+// ListVehicles retrieves vehicles with filters
+func (s *VehicleService) ListVehicles(ctx context.Context, filter *models.VehicleFilter) ([]*models.Vehicle, error) {
+	vehicles, err := s.vehicleRepo.List(ctx, filter)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list vehicles", err, map[string]interface{}{})
+		return nil, err
+	}
+
+	return vehicles, nil
+}
+
+// UpdateVehicle updates a vehicle
+func (s *VehicleService) UpdateVehicle(ctx context.Context, vehicleID string, req *models.VehicleUpdateRequest, updatedBy string) (*models.Vehicle, error) {
+	if err := validateVehicleEnums(ctx, req.VehicleType, req.VehicleStatus); err != nil {
+		return nil, err
+	}
+
+	vehicle, err := s.vehicleRepo.Update(ctx, vehicleID, req, updatedBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to update vehicle", err, map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"updated_by": updatedBy,
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Vehicle updated successfully", map[string]interface{}{
+		"vehicle_id": vehicleID,
+		"updated_by": updatedBy,
+	})
+
+	return vehicle, nil
+}
+
+// DeleteVehicle soft-deletes a vehicle
+func (s *VehicleService) DeleteVehicle(ctx context.Context, vehicleID, deletedBy string) error {
+	if err := s.vehicleRepo.Delete(ctx, vehicleID, deletedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete vehicle", err, map[string]interface{}{
+			"vehicle_id": vehicleID,
+			"deleted_by": deletedBy,
+		})
+		return err
+	}
+
+	logger.InfoContext(ctx, "Vehicle deleted successfully", map[string]interface{}{
+		"vehicle_id": vehicleID,
+		"deleted_by": deletedBy,
+	})
+
+	return nil
+}
+
+// GetExpiryReport lists vehicles whose 車検, insurance or scheduled maintenance
+// falls due within the given number of days (overdue items are included)
+func (s *VehicleService) GetExpiryReport(ctx context.Context, days int) ([]*models.VehicleExpiryReport, error) {
+	if days < 0 {
+		return nil, fmt.Errorf("days must be zero or greater")
+	}
+
+	today := civil.DateOf(time.Now())
+	vehicles, err := s.vehicleRepo.GetWithUpcomingDeadlines(ctx, today.AddDays(days))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get vehicles with upcoming deadlines", err, map[string]interface{}{
+			"days": days,
+		})
+		return nil, err
+	}
+
+	report := make([]*models.VehicleExpiryReport, 0, len(vehicles))
+	for _, v := range vehicles {
+		items := v.ExpiryItems(today, days)
+		if len(items) == 0 {
+			continue
+		}
+		report = append(report, &models.VehicleExpiryReport{
+			VehicleID:     v.VehicleID,
+			LicensePlate:  v.LicensePlate,
+			VehicleType:   v.VehicleType,
+			VehicleStatus: v.VehicleStatus,
+			Items:         items,
+		})
+	}
+
+	return report, nil
+}
+
+// validateVehicleEnums validates enumerated vehicle fields; nil values are skipped
+func validateVehicleEnums(ctx context.Context, vehicleType, vehicleStatus *string) error {
+	if vehicleType != nil && !validVehicleTypes[*vehicleType] {
+		logger.WarnContext(ctx, "Invalid vehicle type", map[string]interface{}{
+			"vehicle_type": *vehicleType,
+		})
+		return fmt.Errorf("invalid vehicle_type: %s", *vehicleType)
+	}
+	if vehicleStatus != nil && !validVehicleStatuses[*vehicleStatus] {
+		logger.WarnContext(ctx, "Invalid vehicle status", map[string]interface{}{
+			"vehicle_status": *vehicleStatus,
+		})
+		return fmt.Errorf("invalid vehicle_status: %s", *vehicleStatus)
+	}
+	return nil
+}
@@ -0,0 +1,697 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+var (
+	validRecurrenceFrequencies = map[string]bool{
+		"weekly":              true,
+		"biweekly":            true,
+		"monthly_nth_weekday": true,
+	}
+	validRecurrenceVisitTypes = map[string]bool{
+		"regular":            true,
+		"emergency":          true,
+		"initial_assessment": true,
+		"terminal_care":      true,
+	}
+)
+
+// RecurrenceHorizonDays is how far ahead recurring visits are materialized
+const RecurrenceHorizonDays = 56
+
+// VisitScheduleRecurrenceService manages recurring visit rules and the visit
+// schedules materialized from them
+type VisitScheduleRecurrenceService struct {
+	recurrenceRepo    *repository.VisitScheduleRecurrenceRepository
+	visitScheduleRepo *repository.VisitScheduleRepository
+	patientRepo       *repository.PatientRepository
+	staffRepo         *repository.StaffMemberRepository
+	vehicleRepo       *repository.VehicleRepository
+	conflictChecker   *ScheduleConflictChecker
+}
+
+// NewVisitScheduleRecurrenceService creates a new visit schedule recurrence service
+func NewVisitScheduleRecurrenceService(
+	recurrenceRepo *repository.VisitScheduleRecurrenceRepository,
+	visitScheduleRepo *repository.VisitScheduleRepository,
+	patientRepo *repository.PatientRepository,
+	staffRepo *repository.StaffMemberRepository,
+	vehicleRepo *repository.VehicleRepository,
+) *VisitScheduleRecurrenceService {
+	return &VisitScheduleRecurrenceService{
+		recurrenceRepo:    recurrenceRepo,
+		visitScheduleRepo: visitScheduleRepo,
+		patientRepo:       patientRepo,
+		staffRepo:         staffRepo,
+		vehicleRepo:       vehicleRepo,
+		conflictChecker:   NewScheduleConflictChecker(visitScheduleRepo, patientRepo),
+	}
+}
+
+// CreateRecurrence creates a recurrence rule and materializes its first horizon of visits
+func (s *VisitScheduleRecurrenceService) CreateRecurrence(ctx context.Context, patientID string, req *models.VisitScheduleRecurrenceCreateRequest, createdBy string) (*models.VisitScheduleRecurrence, error) {
+	if err := s.checkAccess(ctx, patientID, createdBy); err != nil {
+		return nil, err
+	}
+
+	if err := validateRecurrenceRequest(req); err != nil {
+		logger.WarnContext(ctx, "Invalid visit schedule recurrence", map[string]interface{}{
+			"patient_id": patientID,
+			"error":      err.Error(),
+		})
+		return nil, err
+	}
+
+	recurrence := newRecurrence(patientID, req, createdBy)
+	today := clinicToday()
+	until := today.AddDays(RecurrenceHorizonDays)
+
+	// Check the first horizon against the staff member's day before anything is saved
+	schedules, err := s.planOccurrences(ctx, recurrence, today, until)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateAssignment(ctx, recurrence, schedules); err != nil {
+		return nil, err
+	}
+	if recurrence.AssignedStaffID != nil {
+		if err := s.conflictChecker.Enforce(ctx, schedules, *recurrence.AssignedStaffID, req.OverrideConflicts, createdBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.recurrenceRepo.Insert(ctx, recurrence); err != nil {
+		logger.ErrorContext(ctx, "Failed to create visit schedule recurrence", err, map[string]interface{}{
+			"patient_id": patientID,
+			"created_by": createdBy,
+		})
+		return nil, fmt.Errorf("failed to create visit schedule recurrence: %w", err)
+	}
+
+	if err := s.saveOccurrences(ctx, recurrence, schedules, today, until); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Visit schedule recurrence created successfully", map[string]interface{}{
+		"recurrence_id": recurrence.RecurrenceID,
+		"patient_id":    patientID,
+		"rrule":         recurrence.RRule(),
+		"created_by":    createdBy,
+	})
+
+	return recurrence, nil
+}
+
+// GetRecurrence retrieves a recurrence rule with access control
+func (s *VisitScheduleRecurrenceService) GetRecurrence(ctx context.Context, patientID, recurrenceID, requestorID string) (*models.VisitScheduleRecurrence, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+
+	return s.getPatientRecurrence(ctx, patientID, recurrenceID)
+}
+
+// ListRecurrences lists a patient's recurrence rules with access control
+func (s *VisitScheduleRecurrenceService) ListRecurrences(ctx context.Context, patientID, requestorID string) ([]*models.VisitScheduleRecurrence, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+
+	return s.recurrenceRepo.ListByPatient(ctx, patientID)
+}
+
+// DeleteRecurrence deletes a rule together with its upcoming visits that have not started
+func (s *VisitScheduleRecurrenceService) DeleteRecurrence(ctx context.Context, patientID, recurrenceID, deletedBy string) error {
+	if err := s.checkAccess(ctx, patientID, deletedBy); err != nil {
+		return err
+	}
+
+	if _, err := s.getPatientRecurrence(ctx, patientID, recurrenceID); err != nil {
+		return err
+	}
+
+	removed, err := s.removeOccurrences(ctx, recurrenceID, clinicToday())
+	if err != nil {
+		return err
+	}
+
+	if err := s.recurrenceRepo.Delete(ctx, recurrenceID, deletedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to delete visit schedule recurrence", err, map[string]interface{}{
+			"recurrence_id": recurrenceID,
+			"deleted_by":    deletedBy,
+		})
+		return fmt.Errorf("failed to delete visit schedule recurrence: %w", err)
+	}
+
+	logger.InfoContext(ctx, "Visit schedule recurrence deleted successfully", map[string]interface{}{
+		"recurrence_id":     recurrenceID,
+		"patient_id":        patientID,
+		"removed_schedules": removed,
+		"deleted_by":        deletedBy,
+	})
+
+	return nil
+}
+
+// UpdateFollowing applies an edit to an occurrence and every later occurrence of its rule.
+// The rule is split: the original ends the day before the occurrence and a new rule with
+// the edited template takes over from the occurrence date. Occurrences that were edited
+// individually or have already started are moved to the new rule unchanged.
+func (s *VisitScheduleRecurrenceService) UpdateFollowing(ctx context.Context, patientID, scheduleID string, req *models.VisitScheduleUpdateRequest, updatedBy string) (*models.VisitSchedule, error) {
+	if err := s.checkAccess(ctx, patientID, updatedBy); err != nil {
+		return nil, err
+	}
+
+	if req.VisitDate != nil || req.Status != nil || len(req.OptimizationResult) > 0 {
+		return nil, fmt.Errorf("visit_date, status and optimization_result can only be changed for a single occurrence")
+	}
+
+	schedule, original, err := s.getOccurrence(ctx, patientID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status == "in_progress" || schedule.Status == "completed" {
+		return nil, fmt.Errorf("cannot edit following occurrences from a visit that is %s", schedule.Status)
+	}
+
+	from := schedule.OccurrenceDate.Date
+	split := splitRecurrence(original, from, req, updatedBy)
+	if err := validateRecurrence(split); err != nil {
+		return nil, err
+	}
+
+	// Re-generate untouched occurrences from the new rule; keep the rest
+	occurrences, err := s.visitScheduleRepo.ListOccurrences(ctx, original.RecurrenceID, from)
+	if err != nil {
+		return nil, err
+	}
+	var regenerate []*models.VisitSchedule
+	var regenerateIDs, keep []string
+	for _, o := range occurrences {
+		if o.ScheduleID == scheduleID || (!o.IsRecurrenceException && o.Status != "in_progress" && o.Status != "completed") {
+			regenerate = append(regenerate, o)
+			regenerateIDs = append(regenerateIDs, o.ScheduleID)
+		} else {
+			keep = append(keep, o.ScheduleID)
+		}
+	}
+
+	until := clinicToday().AddDays(RecurrenceHorizonDays)
+	if original.MaterializedUntil != nil && original.MaterializedUntil.After(until) {
+		until = *original.MaterializedUntil
+	}
+	created, err := s.planOccurrences(ctx, split, from, until)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateAssignment(ctx, split, created); err != nil {
+		return nil, err
+	}
+	if split.AssignedStaffID != nil {
+		// The regenerated occurrences replace the old ones, so those cannot conflict
+		if err := s.conflictChecker.Enforce(ctx, created, *split.AssignedStaffID, req.OverrideConflicts, updatedBy, regenerateIDs...); err != nil {
+			return nil, err
+		}
+	}
+	split.MaterializedUntil = &until
+
+	change := &repository.RecurrenceSplit{
+		Original: original,
+		Split:    split,
+		Keep:     keep,
+		Replace:  regenerate,
+		Created:  created,
+	}
+	// Nothing is left of the original rule when the split starts on its first date
+	if from.After(original.StartDate) {
+		lastDate := from.AddDays(-1)
+		change.LastDate = &lastDate
+	}
+	if err := s.recurrenceRepo.Split(ctx, change, updatedBy); err != nil {
+		logger.ErrorContext(ctx, "Failed to split visit schedule recurrence", err, map[string]interface{}{
+			"recurrence_id": original.RecurrenceID,
+			"from":          from.String(),
+		})
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Following visit schedule occurrences updated", map[string]interface{}{
+		"schedule_id":        scheduleID,
+		"old_recurrence_id":  original.RecurrenceID,
+		"new_recurrence_id":  split.RecurrenceID,
+		"from":               from.String(),
+		"kept_schedules":     len(keep),
+		"regenerated_visits": len(created),
+		"updated_by":         updatedBy,
+	})
+
+	for _, c := range created {
+		if c.OccurrenceDate.Date == from {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("visit schedule not found")
+}
+
+// DeleteFollowing deletes an occurrence and every later occurrence of its rule that has not started,
+// and ends the rule the day before
+func (s *VisitScheduleRecurrenceService) DeleteFollowing(ctx context.Context, patientID, scheduleID, deletedBy string) error {
+	if err := s.checkAccess(ctx, patientID, deletedBy); err != nil {
+		return err
+	}
+
+	schedule, original, err := s.getOccurrence(ctx, patientID, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	from := schedule.OccurrenceDate.Date
+	removed, err := s.removeOccurrences(ctx, original.RecurrenceID, from)
+	if err != nil {
+		return err
+	}
+
+	if err := s.endRecurrenceBefore(ctx, original, from, deletedBy); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Following visit schedule occurrences deleted", map[string]interface{}{
+		"schedule_id":       scheduleID,
+		"recurrence_id":     original.RecurrenceID,
+		"from":              from.String(),
+		"removed_schedules": removed,
+		"deleted_by":        deletedBy,
+	})
+
+	return nil
+}
+
+// ExtendHorizons materializes visits for every active rule up to the rolling horizon.
+// It is run periodically so long-running rules keep a fixed window of upcoming visits.
+func (s *VisitScheduleRecurrenceService) ExtendHorizons(ctx context.Context) error {
+	today := clinicToday()
+	until := today.AddDays(RecurrenceHorizonDays)
+
+	recurrences, err := s.recurrenceRepo.ListDue(ctx, until)
+	if err != nil {
+		return fmt.Errorf("failed to list due recurrences: %w", err)
+	}
+
+	total := 0
+	for _, recurrence := range recurrences {
+		from := today
+		if recurrence.MaterializedUntil != nil && !recurrence.MaterializedUntil.Before(today) {
+			from = recurrence.MaterializedUntil.AddDays(1)
+		}
+
+		created, err := s.planOccurrences(ctx, recurrence, from, until)
+		if err == nil {
+			s.warnConflicts(ctx, recurrence, created)
+			err = s.saveOccurrences(ctx, recurrence, created, from, until)
+		}
+		if err != nil {
+			// Keep going; the rule is picked up again on the next run
+			logger.WarnContext(ctx, "Failed to extend visit schedule recurrence", map[string]interface{}{
+				"recurrence_id": recurrence.RecurrenceID,
+				"error":         err.Error(),
+			})
+			continue
+		}
+		total += len(created)
+	}
+
+	if len(recurrences) > 0 {
+		logger.InfoContext(ctx, "Visit schedule recurrences extended", map[string]interface{}{
+			"recurrences": len(recurrences),
+			"created":     total,
+			"until":       until.String(),
+		})
+	}
+
+	return nil
+}
+
+// planOccurrences builds the rule's missing visits between from and until without saving them
+func (s *VisitScheduleRecurrenceService) planOccurrences(ctx context.Context, recurrence *models.VisitScheduleRecurrence, from, until civil.Date) ([]*models.VisitSchedule, error) {
+	existing, err := s.visitScheduleRepo.ListOccurrences(ctx, recurrence.RecurrenceID, from)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[civil.Date]bool, len(existing))
+	for _, e := range existing {
+		taken[e.OccurrenceDate.Date] = true
+	}
+
+	var schedules []*models.VisitSchedule
+	for _, date := range recurrence.Occurrences(from, until) {
+		if taken[date] {
+			continue
+		}
+		schedule, err := recurrence.NewOccurrence(date, clinicTimeZone)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// saveOccurrences creates planned visits and records the rule's new horizon
+func (s *VisitScheduleRecurrenceService) saveOccurrences(ctx context.Context, recurrence *models.VisitScheduleRecurrence, schedules []*models.VisitSchedule, from, until civil.Date) error {
+	if err := s.visitScheduleRepo.CreateOccurrences(ctx, schedules); err != nil {
+		logger.ErrorContext(ctx, "Failed to materialize recurring visit schedules", err, map[string]interface{}{
+			"recurrence_id": recurrence.RecurrenceID,
+			"from":          from.String(),
+			"until":         until.String(),
+		})
+		return err
+	}
+
+	if err := s.recurrenceRepo.SetMaterializedUntil(ctx, recurrence.RecurrenceID, until); err != nil {
+		return err
+	}
+	recurrence.MaterializedUntil = &until
+	return nil
+}
+
+// validateAssignment checks a rule's staff member and vehicle before its visits are saved:
+// the staff member must exist and be active, and the vehicle must be bookable on every visit date
+func (s *VisitScheduleRecurrenceService) validateAssignment(ctx context.Context, recurrence *models.VisitScheduleRecurrence, schedules []*models.VisitSchedule) error {
+	if recurrence.AssignedStaffID != nil {
+		staff, err := s.staffRepo.GetByID(ctx, *recurrence.AssignedStaffID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return fmt.Errorf("assigned staff member not found")
+			}
+			return fmt.Errorf("failed to get staff member: %w", err)
+		}
+		if !staff.IsActive() {
+			logger.WarnContext(ctx, "Recurring visits assigned to inactive staff", map[string]interface{}{
+				"staff_id":       staff.StaffID,
+				"account_status": staff.AccountStatus,
+			})
+			return fmt.Errorf("assigned staff member is not active")
+		}
+	}
+
+	if recurrence.AssignedVehicleID != nil {
+		vehicle, err := s.vehicleRepo.GetByID(ctx, *recurrence.AssignedVehicleID)
+		if err != nil {
+			return err
+		}
+		for _, schedule := range schedules {
+			if !vehicle.IsBookable(schedule.VisitDate) {
+				logger.WarnContext(ctx, "Vehicle not available for recurring visit", map[string]interface{}{
+					"vehicle_id":     vehicle.VehicleID,
+					"vehicle_status": vehicle.VehicleStatus,
+					"visit_date":     schedule.VisitDate.String(),
+				})
+				return fmt.Errorf("vehicle is not available on %s (status: %s, or registration/insurance expired)", schedule.VisitDate, vehicle.VehicleStatus)
+			}
+		}
+	}
+
+	return nil
+}
+
+// warnConflicts logs clashes of visits materialized in the background. The rule was accepted
+// when it was created, so its visits are still created and left for the coordinator to resolve.
+func (s *VisitScheduleRecurrenceService) warnConflicts(ctx context.Context, recurrence *models.VisitScheduleRecurrence, schedules []*models.VisitSchedule) {
+	if recurrence.AssignedStaffID == nil || len(schedules) == 0 {
+		return
+	}
+
+	conflicts, err := s.conflictChecker.CheckAll(ctx, schedules, *recurrence.AssignedStaffID)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to check recurring visit conflicts", map[string]interface{}{
+			"recurrence_id": recurrence.RecurrenceID,
+			"error":         err.Error(),
+		})
+		return
+	}
+	if len(conflicts) > 0 {
+		conflictErr := &ScheduleConflictError{StaffID: *recurrence.AssignedStaffID, Conflicts: conflicts}
+		logger.WarnContext(ctx, "Recurring visits conflict with the staff schedule", map[string]interface{}{
+			"recurrence_id":            recurrence.RecurrenceID,
+			"staff_id":                 conflictErr.StaffID,
+			"conflicting_schedule_ids": conflictErr.ScheduleIDs(),
+		})
+	}
+}
+
+// removeOccurrences deletes a rule's visits from a date on, except those already started
+func (s *VisitScheduleRecurrenceService) removeOccurrences(ctx context.Context, recurrenceID string, from civil.Date) (int, error) {
+	occurrences, err := s.visitScheduleRepo.ListOccurrences(ctx, recurrenceID, from)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []string
+	for _, o := range occurrences {
+		if o.Status != "in_progress" && o.Status != "completed" {
+			ids = append(ids, o.ScheduleID)
+		}
+	}
+
+	if err := s.visitScheduleRepo.DeleteOccurrences(ctx, ids); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// endRecurrenceBefore ends a rule the day before from, or deletes it when nothing is left
+func (s *VisitScheduleRecurrenceService) endRecurrenceBefore(ctx context.Context, recurrence *models.VisitScheduleRecurrence, from civil.Date, updatedBy string) error {
+	if !from.After(recurrence.StartDate) {
+		return s.recurrenceRepo.Delete(ctx, recurrence.RecurrenceID, updatedBy)
+	}
+	lastDate := from.AddDays(-1)
+	return s.recurrenceRepo.End(ctx, recurrence.RecurrenceID, &lastDate, updatedBy)
+}
+
+// getOccurrence loads a materialized visit and the rule it belongs to
+func (s *VisitScheduleRecurrenceService) getOccurrence(ctx context.Context, patientID, scheduleID string) (*models.VisitSchedule, *models.VisitScheduleRecurrence, error) {
+	schedule, err := s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !schedule.RecurrenceID.Valid || !schedule.OccurrenceDate.Valid {
+		return nil, nil, fmt.Errorf("visit schedule is not part of a recurrence")
+	}
+
+	recurrence, err := s.getPatientRecurrence(ctx, patientID, schedule.RecurrenceID.StringVal)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schedule, recurrence, nil
+}
+
+func (s *VisitScheduleRecurrenceService) getPatientRecurrence(ctx context.Context, patientID, recurrenceID string) (*models.VisitScheduleRecurrence, error) {
+	recurrence, err := s.recurrenceRepo.GetByID(ctx, recurrenceID)
+	if err != nil {
+		return nil, err
+	}
+	if recurrence.PatientID != patientID {
+		return nil, fmt.Errorf("visit schedule recurrence not found")
+	}
+	return recurrence, nil
+}
+
+func (s *VisitScheduleRecurrenceService) checkAccess(ctx context.Context, patientID, userID string) error {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, userID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id": patientID,
+			"user_id":    userID,
+		})
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized visit schedule recurrence access attempt", map[string]interface{}{
+			"patient_id": patientID,
+			"user_id":    userID,
+		})
+		return fmt.Errorf("access denied: you do not have permission to manage visit schedules for this patient")
+	}
+
+	return nil
+}
+
+// newRecurrence builds an active rule from a create request
+func newRecurrence(patientID string, req *models.VisitScheduleRecurrenceCreateRequest, createdBy string) *models.VisitScheduleRecurrence {
+	now := time.Now()
+	return &models.VisitScheduleRecurrence{
+		RecurrenceID:             uuid.New().String(),
+		PatientID:                patientID,
+		Frequency:                req.Frequency,
+		ByWeekday:                req.ByWeekday,
+		ByWeekOfMonth:            req.ByWeekOfMonth,
+		StartDate:                req.StartDate,
+		EndDate:                  req.EndDate,
+		ExceptionDates:           req.ExceptionDates,
+		VisitType:                req.VisitType,
+		StartTime:                req.StartTime,
+		EndTime:                  req.EndTime,
+		EstimatedDurationMinutes: req.EstimatedDurationMinutes,
+		AssignedStaffID:          req.AssignedStaffID,
+		AssignedVehicleID:        req.AssignedVehicleID,
+		PriorityScore:            req.PriorityScore,
+		Constraints:              req.Constraints,
+		CarePlanRef:              req.CarePlanRef,
+		ActivityRef:              req.ActivityRef,
+		Status:                   "active",
+		CreatedAt:                now,
+		CreatedBy:                &createdBy,
+		UpdatedAt:                now,
+	}
+}
+
+// splitRecurrence copies a rule from the given date on, with the edit applied to its template
+func splitRecurrence(original *models.VisitScheduleRecurrence, from civil.Date, req *models.VisitScheduleUpdateRequest, updatedBy string) *models.VisitScheduleRecurrence {
+	now := time.Now()
+	split := *original
+	split.RecurrenceID = uuid.New().String()
+	split.StartDate = from
+	split.MaterializedUntil = nil
+	split.Status = "active"
+	split.CreatedAt = now
+	split.CreatedBy = &updatedBy
+	split.UpdatedAt = now
+	split.UpdatedBy = nil
+
+	split.ExceptionDates = nil
+	for _, d := range original.ExceptionDates {
+		if !d.Before(from) {
+			split.ExceptionDates = append(split.ExceptionDates, d)
+		}
+	}
+
+	if req.VisitType != nil {
+		split.VisitType = *req.VisitType
+	}
+	if req.TimeWindowStart != nil {
+		split.StartTime = stringPtr(req.TimeWindowStart.In(clinicTimeZone).Format("15:04"))
+	}
+	if req.TimeWindowEnd != nil {
+		split.EndTime = stringPtr(req.TimeWindowEnd.In(clinicTimeZone).Format("15:04"))
+	}
+	if req.EstimatedDurationMinutes != nil {
+		split.EstimatedDurationMinutes = *req.EstimatedDurationMinutes
+	}
+	if req.AssignedStaffID != nil {
+		split.AssignedStaffID = req.AssignedStaffID
+	}
+	if req.AssignedVehicleID != nil {
+		split.AssignedVehicleID = req.AssignedVehicleID
+	}
+	if req.PriorityScore != nil {
+		split.PriorityScore = *req.PriorityScore
+	}
+	if len(req.Constraints) > 0 {
+		split.Constraints = req.Constraints
+	}
+	if req.CarePlanRef != nil {
+		split.CarePlanRef = req.CarePlanRef
+	}
+	if req.ActivityRef != nil {
+		split.ActivityRef = req.ActivityRef
+	}
+
+	return &split
+}
+
+// validateRecurrenceRequest validates a new rule before it is stored
+func validateRecurrenceRequest(req *models.VisitScheduleRecurrenceCreateRequest) error {
+	return validateRecurrence(&models.VisitScheduleRecurrence{
+		Frequency:                req.Frequency,
+		ByWeekday:                req.ByWeekday,
+		ByWeekOfMonth:            req.ByWeekOfMonth,
+		StartDate:                req.StartDate,
+		EndDate:                  req.EndDate,
+		VisitType:                req.VisitType,
+		StartTime:                req.StartTime,
+		EndTime:                  req.EndTime,
+		EstimatedDurationMinutes: req.EstimatedDurationMinutes,
+		PriorityScore:            req.PriorityScore,
+	})
+}
+
+// validateRecurrence checks a rule's pattern and occurrence template
+func validateRecurrence(r *models.VisitScheduleRecurrence) error {
+	if !validRecurrenceFrequencies[r.Frequency] {
+		return fmt.Errorf("invalid frequency: %s", r.Frequency)
+	}
+
+	if len(r.ByWeekday) == 0 {
+		return fmt.Errorf("by_weekday is required")
+	}
+	for _, code := range r.ByWeekday {
+		if _, ok := models.RecurrenceWeekdays[code]; !ok {
+			return fmt.Errorf("invalid weekday: %s (expected MO, TU, WE, TH, FR, SA or SU)", code)
+		}
+	}
+
+	if r.Frequency == "monthly_nth_weekday" {
+		if len(r.ByWeekOfMonth) == 0 {
+			return fmt.Errorf("by_week_of_month is required for monthly_nth_weekday")
+		}
+		for _, w := range r.ByWeekOfMonth {
+			if w != -1 && (w < 1 || w > 5) {
+				return fmt.Errorf("invalid week of month: %d (expected 1-5 or -1 for last)", w)
+			}
+		}
+	} else if len(r.ByWeekOfMonth) > 0 {
+		return fmt.Errorf("by_week_of_month is only valid for monthly_nth_weekday")
+	}
+
+	if !r.StartDate.IsValid() {
+		return fmt.Errorf("start_date is required")
+	}
+	if r.EndDate != nil && r.EndDate.Before(r.StartDate) {
+		return fmt.Errorf("end_date cannot be before start_date")
+	}
+
+	if !validRecurrenceVisitTypes[r.VisitType] {
+		return fmt.Errorf("invalid visit type: %s", r.VisitType)
+	}
+	if r.EstimatedDurationMinutes < 5 || r.EstimatedDurationMinutes > 480 {
+		return fmt.Errorf("invalid estimated duration: must be between 5 and 480 minutes")
+	}
+	if r.PriorityScore < 1 || r.PriorityScore > 10 {
+		return fmt.Errorf("invalid priority score: must be between 1 and 10")
+	}
+
+	var start, end time.Time
+	var err error
+	if r.StartTime != nil {
+		if start, err = time.Parse("15:04", *r.StartTime); err != nil {
+			return fmt.Errorf("invalid start_time format (expected HH:MM)")
+		}
+	}
+	if r.EndTime != nil {
+		if end, err = time.Parse("15:04", *r.EndTime); err != nil {
+			return fmt.Errorf("invalid end_time format (expected HH:MM)")
+		}
+	}
+	if r.StartTime != nil && r.EndTime != nil && end.Before(start) {
+		return fmt.Errorf("end_time cannot be before start_time")
+	}
+
+	return nil
+}
+
+// clinicToday returns the current date in the clinic's time zone
+func clinicToday() civil.Date {
+	return civil.DateOf(time.Now().In(clinicTimeZone))
+}
@@ -3,26 +3,66 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
 	"github.com/visitas/backend/internal/models"
 	"github.com/visitas/backend/internal/repository"
 	"github.com/visitas/backend/pkg/logger"
 )
 
+// visitScheduleTransitions lists the statuses each status may move to
+var visitScheduleTransitions = map[string]map[string]bool{
+	"draft":       {"optimized": true, "assigned": true, "cancelled": true},
+	"optimized":   {"draft": true, "assigned": true, "cancelled": true},
+	"assigned":    {"draft": true, "optimized": true, "in_progress": true, "cancelled": true},
+	"in_progress": {"completed": true, "cancelled": true},
+	"completed":   {},
+	"cancelled":   {"draft": true},
+}
+
+// statusesWithSideEffects can only be reached through UpdateStatus, which records
+// visit times, cancellation details and the status history
+var statusesWithSideEffects = map[string]bool{
+	"in_progress": true,
+	"completed":   true,
+	"cancelled":   true,
+}
+
+// terminalVisitStatuses are the statuses of visits whose details record what happened
+// and can no longer be edited
+var terminalVisitStatuses = map[string]bool{
+	"completed": true,
+	"cancelled": true,
+}
+
 // VisitScheduleService handles business logic for visit schedules
 type VisitScheduleService struct {
 	visitScheduleRepo *repository.VisitScheduleRepository
 	patientRepo       *repository.PatientRepository
+	vehicleRepo       *repository.VehicleRepository
+	recurrenceRepo    *repository.VisitScheduleRecurrenceRepository
+	medicalRecordRepo *repository.MedicalRecordRepository
+	conflictChecker   *ScheduleConflictChecker
 }
 
 // NewVisitScheduleService creates a new visit schedule service
 func NewVisitScheduleService(
 	visitScheduleRepo *repository.VisitScheduleRepository,
 	patientRepo *repository.PatientRepository,
+	vehicleRepo *repository.VehicleRepository,
+	recurrenceRepo *repository.VisitScheduleRecurrenceRepository,
+	medicalRecordRepo *repository.MedicalRecordRepository,
 ) *VisitScheduleService {
 	return &VisitScheduleService{
 		visitScheduleRepo: visitScheduleRepo,
 		patientRepo:       patientRepo,
+		vehicleRepo:       vehicleRepo,
+		recurrenceRepo:    recurrenceRepo,
+		medicalRecordRepo: medicalRecordRepo,
+		conflictChecker:   NewScheduleConflictChecker(visitScheduleRepo, patientRepo),
 	}
 }
 
@@ -60,20 +100,16 @@ func (s *VisitScheduleService) CreateVisitSchedule(ctx context.Context, patientI
 		return nil, fmt.Errorf("invalid visit type: %s", req.VisitType)
 	}
 
-	// Validate status
-	validStatuses := map[string]bool{
-		"draft":       true,
-		"optimized":   true,
-		"assigned":    true,
-		"in_progress": true,
-		"completed":   true,
-		"cancelled":   true,
-	}
-	if !validStatuses[req.Status] {
-		logger.WarnContext(ctx, "Invalid status", map[string]interface{}{
+	// Validate status: a visit starts as a draft, or as assigned when staff is given up front.
+	// Every later status is reached through the transition table so its history is recorded.
+	if req.Status != "draft" && req.Status != "assigned" {
+		logger.WarnContext(ctx, "Invalid initial status", map[string]interface{}{
 			"status": req.Status,
 		})
-		return nil, fmt.Errorf("invalid status: %s", req.Status)
+		return nil, fmt.Errorf("invalid status: %s (a new visit schedule must be draft or assigned)", req.Status)
+	}
+	if req.Status == "assigned" && req.AssignedStaffID == nil {
+		return nil, fmt.Errorf("assigned_staff_id is required for an assigned visit schedule")
 	}
 
 	// Validate visit_date is not zero
@@ -109,6 +145,28 @@ func (s *VisitScheduleService) CreateVisitSchedule(ctx context.Context, patientI
 		}
 	}
 
+	// Validate vehicle assignment
+	if req.AssignedVehicleID != nil {
+		if err := s.validateVehicleAssignment(ctx, *req.AssignedVehicleID, civil.DateOf(req.VisitDate)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check the assigned staff member is free and can get there in time
+	if req.AssignedStaffID != nil {
+		candidate := &models.VisitSchedule{
+			PatientID:                patientID,
+			VisitDate:                civil.DateOf(req.VisitDate),
+			EstimatedDurationMinutes: req.EstimatedDurationMinutes,
+		}
+		if req.TimeWindowStart != nil {
+			candidate.TimeWindowStart = spanner.NullTime{Time: *req.TimeWindowStart, Valid: true}
+		}
+		if err := s.checkStaffConflicts(ctx, candidate, *req.AssignedStaffID, req.OverrideConflicts, createdBy); err != nil {
+			return nil, err
+		}
+	}
+
 	schedule, err := s.visitScheduleRepo.Create(ctx, patientID, req)
 	if err != nil {
 		logger.ErrorContext(ctx, "Failed to create visit schedule", err, map[string]interface{}{
@@ -215,24 +273,6 @@ func (s *VisitScheduleService) UpdateVisitSchedule(ctx context.Context, patientI
 		}
 	}
 
-	// Validate status if provided
-	if req.Status != nil {
-		validStatuses := map[string]bool{
-			"draft":       true,
-			"optimized":   true,
-			"assigned":    true,
-			"in_progress": true,
-			"completed":   true,
-			"cancelled":   true,
-		}
-		if !validStatuses[*req.Status] {
-			logger.WarnContext(ctx, "Invalid status", map[string]interface{}{
-				"status": *req.Status,
-			})
-			return nil, fmt.Errorf("invalid status: %s", *req.Status)
-		}
-	}
-
 	// Validate priority score if provided
 	if req.PriorityScore != nil {
 		if *req.PriorityScore < 1 || *req.PriorityScore > 10 {
@@ -264,13 +304,73 @@ func (s *VisitScheduleService) UpdateVisitSchedule(ctx context.Context, patientI
 		}
 	}
 
-	schedule, err := s.visitScheduleRepo.Update(ctx, patientID, scheduleID, req)
+	existing, err := s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transitions with side effects go through UpdateStatus
+	newStatus, err := updateStatusChange(existing, req)
+	if err != nil {
+		logger.WarnContext(ctx, "Invalid visit schedule update", map[string]interface{}{
+			"schedule_id": scheduleID,
+			"status":      existing.Status,
+			"error":       err.Error(),
+		})
+		return nil, err
+	}
+
+	// Validate vehicle assignment against the (possibly updated) visit date
+	if req.AssignedVehicleID != nil {
+		visitDate := existing.VisitDate
+		if req.VisitDate != nil {
+			visitDate = civil.DateOf(*req.VisitDate)
+		}
+		if err := s.validateVehicleAssignment(ctx, *req.AssignedVehicleID, visitDate); err != nil {
+			return nil, err
+		}
+	}
+
+	// Re-check staff conflicts when the assignment or timing changes
+	if req.AssignedStaffID != nil || req.VisitDate != nil || req.TimeWindowStart != nil || req.EstimatedDurationMinutes != nil {
+		candidate := *existing
+		if req.VisitDate != nil {
+			candidate.VisitDate = civil.DateOf(*req.VisitDate)
+		}
+		if req.TimeWindowStart != nil {
+			candidate.TimeWindowStart = spanner.NullTime{Time: *req.TimeWindowStart, Valid: true}
+		}
+		if req.EstimatedDurationMinutes != nil {
+			candidate.EstimatedDurationMinutes = *req.EstimatedDurationMinutes
+		}
+		if req.AssignedStaffID != nil {
+			candidate.AssignedStaffID = spanner.NullString{StringVal: *req.AssignedStaffID, Valid: true}
+		}
+		if candidate.AssignedStaffID.Valid {
+			if err := s.checkStaffConflicts(ctx, &candidate, candidate.AssignedStaffID.StringVal, req.OverrideConflicts, updatedBy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var schedule *models.VisitSchedule
+	if newStatus == nil {
+		schedule, err = s.visitScheduleRepo.Update(ctx, patientID, scheduleID, req)
+	} else {
+		// A status change is written with the other fields and its history event in one commit
+		fields := *req
+		fields.Status = nil
+		schedule, err = s.commitWithTransition(ctx, existing, &fields, *newStatus, updatedBy)
+	}
 	if err != nil {
 		logger.ErrorContext(ctx, "Failed to update visit schedule", err, map[string]interface{}{
 			"patient_id":  patientID,
 			"schedule_id": scheduleID,
 			"updated_by":  updatedBy,
 		})
+		if newStatus != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to update visit schedule: %w", err)
 	}
 
@@ -306,7 +406,7 @@ func (s *VisitScheduleService) DeleteVisitSchedule(ctx context.Context, patientI
 	}
 
 	// Verify the schedule exists before deletion
-	_, err = s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID)
+	existing, err := s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID)
 	if err != nil {
 		logger.ErrorContext(ctx, "Failed to get visit schedule for deletion", err, map[string]interface{}{
 			"patient_id":  patientID,
@@ -315,6 +415,20 @@ func (s *VisitScheduleService) DeleteVisitSchedule(ctx context.Context, patientI
 		return fmt.Errorf("visit schedule not found: %w", err)
 	}
 
+	// Deleting a single occurrence excludes its date from the recurrence rule,
+	// so later series edits do not bring it back
+	if existing.RecurrenceID.Valid && existing.OccurrenceDate.Valid {
+		err = s.recurrenceRepo.AddExceptionDate(ctx, existing.RecurrenceID.StringVal, existing.OccurrenceDate.Date, deletedBy)
+		// A deleted rule no longer materializes visits, so its leftovers need no exception
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			logger.ErrorContext(ctx, "Failed to record recurrence exception date", err, map[string]interface{}{
+				"schedule_id":   scheduleID,
+				"recurrence_id": existing.RecurrenceID.StringVal,
+			})
+			return fmt.Errorf("failed to delete visit schedule: %w", err)
+		}
+	}
+
 	err = s.visitScheduleRepo.Delete(ctx, patientID, scheduleID)
 	if err != nil {
 		logger.ErrorContext(ctx, "Failed to delete visit schedule", err, map[string]interface{}{
@@ -361,8 +475,9 @@ func (s *VisitScheduleService) GetUpcomingSchedules(ctx context.Context, patient
 	return s.visitScheduleRepo.GetUpcomingSchedules(ctx, patientID, days)
 }
 
-// AssignStaff assigns a staff member to a visit schedule with access control
-func (s *VisitScheduleService) AssignStaff(ctx context.Context, patientID, scheduleID, staffID, assignedBy string) (*models.VisitSchedule, error) {
+// AssignStaff assigns a staff member to a visit schedule with access control.
+// Conflicting assignments are rejected unless overrideConflicts is set.
+func (s *VisitScheduleService) AssignStaff(ctx context.Context, patientID, scheduleID, staffID string, overrideConflicts bool, assignedBy string) (*models.VisitSchedule, error) {
 	// Check if requestor has access to this patient
 	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, assignedBy, patientID)
 	if err != nil {
@@ -383,12 +498,25 @@ func (s *VisitScheduleService) AssignStaff(ctx context.Context, patientID, sched
 		return nil, fmt.Errorf("access denied: you do not have permission to assign staff to this visit schedule")
 	}
 
+	existing, err := s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkStaffConflicts(ctx, existing, staffID, overrideConflicts, assignedBy); err != nil {
+		return nil, err
+	}
+
 	req := &models.VisitScheduleUpdateRequest{
 		AssignedStaffID: &staffID,
-		Status:          stringPtr("assigned"),
 	}
-
-	schedule, err := s.visitScheduleRepo.Update(ctx, patientID, scheduleID, req)
+	// Staff may be reassigned on an assigned visit; otherwise assigning moves it to "assigned"
+	var schedule *models.VisitSchedule
+	if existing.Status == "assigned" {
+		schedule, err = s.visitScheduleRepo.Update(ctx, patientID, scheduleID, req)
+	} else {
+		schedule, err = s.commitWithTransition(ctx, existing, req, "assigned", assignedBy)
+	}
 	if err != nil {
 		logger.ErrorContext(ctx, "Failed to assign staff", err, map[string]interface{}{
 			"patient_id":  patientID,
@@ -429,6 +557,15 @@ func (s *VisitScheduleService) AssignVehicle(ctx context.Context, patientID, sch
 		return nil, fmt.Errorf("access denied: you do not have permission to assign vehicles to this visit schedule")
 	}
 
+	existing, err := s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateVehicleAssignment(ctx, vehicleID, existing.VisitDate); err != nil {
+		return nil, err
+	}
+
 	req := &models.VisitScheduleUpdateRequest{
 		AssignedVehicleID: &vehicleID,
 	}
@@ -452,8 +589,10 @@ func (s *VisitScheduleService) AssignVehicle(ctx context.Context, patientID, sch
 	return schedule, nil
 }
 
-// UpdateStatus updates the status of a visit schedule with access control
-func (s *VisitScheduleService) UpdateStatus(ctx context.Context, patientID, scheduleID, status, updatedBy string) (*models.VisitSchedule, error) {
+// UpdateStatus moves a visit schedule through the status state machine with access control.
+// Each transition is recorded in the status history; starting a visit records the arrival
+// time and opens a draft medical record, completing it records the departure time.
+func (s *VisitScheduleService) UpdateStatus(ctx context.Context, patientID, scheduleID string, req *models.VisitScheduleStatusChangeRequest, updatedBy string) (*models.VisitSchedule, error) {
 	// Check if requestor has access to this patient
 	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, updatedBy, patientID)
 	if err != nil {
@@ -474,45 +613,287 @@ func (s *VisitScheduleService) UpdateStatus(ctx context.Context, patientID, sche
 		return nil, fmt.Errorf("access denied: you do not have permission to update this visit schedule")
 	}
 
-	validStatuses := map[string]bool{
-		"draft":       true,
-		"optimized":   true,
-		"assigned":    true,
-		"in_progress": true,
-		"completed":   true,
-		"cancelled":   true,
+	schedule, err := s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID)
+	if err != nil {
+		return nil, err
 	}
-	if !validStatuses[status] {
-		logger.WarnContext(ctx, "Invalid status", map[string]interface{}{
-			"status": status,
-		})
-		return nil, fmt.Errorf("invalid status: %s", status)
+
+	occurredAt := time.Now()
+	if req.OccurredAt != nil {
+		if req.OccurredAt.After(occurredAt.Add(5 * time.Minute)) {
+			return nil, fmt.Errorf("occurred_at cannot be in the future")
+		}
+		occurredAt = *req.OccurredAt
 	}
 
-	req := &models.VisitScheduleUpdateRequest{
-		Status: &status,
+	fromStatus := schedule.Status
+	if err := applyStatusTransition(schedule, req.Status, req.Reason, occurredAt, updatedBy); err != nil {
+		logger.WarnContext(ctx, "Invalid status transition", map[string]interface{}{
+			"schedule_id": scheduleID,
+			"from":        fromStatus,
+			"to":          req.Status,
+			"error":       err.Error(),
+		})
+		return nil, err
 	}
 
-	schedule, err := s.visitScheduleRepo.Update(ctx, patientID, scheduleID, req)
-	if err != nil {
+	event := &models.VisitScheduleStatusEvent{
+		ScheduleID: scheduleID,
+		PatientID:  patientID,
+		FromStatus: fromStatus,
+		ToStatus:   req.Status,
+		Reason:     req.Reason,
+		OccurredAt: occurredAt,
+		ChangedBy:  updatedBy,
+	}
+	if err := s.visitScheduleRepo.TransitionStatus(ctx, schedule, event); err != nil {
 		logger.ErrorContext(ctx, "Failed to update status", err, map[string]interface{}{
 			"patient_id":  patientID,
 			"schedule_id": scheduleID,
-			"status":      status,
+			"status":      req.Status,
 		})
 		return nil, err
 	}
 
 	logger.InfoContext(ctx, "Visit schedule status updated", map[string]interface{}{
 		"schedule_id": scheduleID,
-		"status":      status,
+		"from":        fromStatus,
+		"status":      req.Status,
 		"updated_by":  updatedBy,
 	})
 
+	if req.Status == "in_progress" {
+		openVisitRecord(ctx, s.medicalRecordRepo, schedule, updatedBy)
+	}
+
 	return schedule, nil
 }
 
+// GetStatusHistory retrieves a visit schedule's status transitions with access control
+func (s *VisitScheduleService) GetStatusHistory(ctx context.Context, patientID, scheduleID, requestorID string) ([]*models.VisitScheduleStatusEvent, error) {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":   patientID,
+			"schedule_id":  scheduleID,
+			"requestor_id": requestorID,
+		})
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		return nil, fmt.Errorf("access denied: you do not have permission to view this visit schedule")
+	}
+
+	if _, err := s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID); err != nil {
+		return nil, err
+	}
+
+	return s.visitScheduleRepo.ListStatusEvents(ctx, scheduleID)
+}
+
+// commitWithTransition applies field updates and moves the visit to status in one commit,
+// recording the transition in the status history. Only transitions without side effects
+// (those not in statusesWithSideEffects) are made this way. The stored status is checked
+// in the commit, so schedule may have been read earlier in the request.
+func (s *VisitScheduleService) commitWithTransition(ctx context.Context, schedule *models.VisitSchedule, fields *models.VisitScheduleUpdateRequest, status, changedBy string) (*models.VisitSchedule, error) {
+	scheduleID, patientID := schedule.ScheduleID, schedule.PatientID
+
+	// Apply the assignment first so the transition sees it (e.g. staff required for "assigned")
+	if fields.AssignedStaffID != nil {
+		schedule.AssignedStaffID = spanner.NullString{StringVal: *fields.AssignedStaffID, Valid: true}
+	}
+
+	now := time.Now()
+	fromStatus := schedule.Status
+	if err := applyStatusTransition(schedule, status, nil, now, changedBy); err != nil {
+		logger.WarnContext(ctx, "Invalid status transition", map[string]interface{}{
+			"schedule_id": scheduleID,
+			"from":        fromStatus,
+			"to":          status,
+		})
+		return nil, err
+	}
+
+	change := repository.ScheduleChange{
+		Schedule: schedule,
+		Event: &models.VisitScheduleStatusEvent{
+			ScheduleID: scheduleID,
+			PatientID:  patientID,
+			FromStatus: fromStatus,
+			ToStatus:   status,
+			OccurredAt: now,
+			ChangedBy:  changedBy,
+		},
+		Update: fields,
+	}
+	if err := s.visitScheduleRepo.CommitChanges(ctx, []repository.ScheduleChange{change}); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// openVisitRecord creates the draft medical record for a visit that has started,
+// unless one is already linked to the schedule. Failures are logged; the visit
+// itself has already started.
+func openVisitRecord(ctx context.Context, medicalRecordRepo *repository.MedicalRecordRepository, schedule *models.VisitSchedule, startedBy string) {
+	existing, err := medicalRecordRepo.GetByScheduleID(ctx, schedule.ScheduleID)
+	if err != nil {
+		// Without knowing whether a record exists, creating one could duplicate it
+		logger.ErrorContext(ctx, "Failed to look up medical record for visit", err, map[string]interface{}{
+			"schedule_id": schedule.ScheduleID,
+			"patient_id":  schedule.PatientID,
+		})
+		return
+	}
+	if len(existing) > 0 {
+		return
+	}
+
+	scheduleID := schedule.ScheduleID
+	record, err := medicalRecordRepo.Create(ctx, schedule.PatientID, &models.MedicalRecordCreateRequest{
+		VisitStartedAt: schedule.ActualArrivalAt.Time,
+		VisitType:      medicalRecordVisitType(schedule.VisitType),
+		PerformedBy:    schedule.AssignedStaffID.StringVal,
+		Status:         "draft",
+		ScheduleID:     &scheduleID,
+		SourceType:     "manual",
+	}, startedBy)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create draft medical record for visit", err, map[string]interface{}{
+			"schedule_id": schedule.ScheduleID,
+			"patient_id":  schedule.PatientID,
+		})
+		return
+	}
+
+	logger.InfoContext(ctx, "Draft medical record created for visit", map[string]interface{}{
+		"schedule_id": schedule.ScheduleID,
+		"record_id":   record.RecordID,
+	})
+}
+
+// updateStatusChange returns the status an edit moves a visit to, or nil when it stays.
+// A completed or cancelled visit's details cannot be edited; assigning staff to a draft
+// or optimized visit moves it to "assigned", as AssignStaff does.
+func updateStatusChange(existing *models.VisitSchedule, req *models.VisitScheduleUpdateRequest) (*string, error) {
+	if terminalVisitStatuses[existing.Status] && hasScheduleFieldEdits(req) {
+		return nil, fmt.Errorf("a %s visit cannot be edited", existing.Status)
+	}
+
+	if req.Status != nil {
+		if statusesWithSideEffects[*req.Status] {
+			return nil, fmt.Errorf("status %s must be set via POST /schedules/{id}/status", *req.Status)
+		}
+		if existing.Status == *req.Status {
+			return nil, nil
+		}
+		if statusesWithSideEffects[existing.Status] {
+			return nil, fmt.Errorf("a %s visit's status must be changed via POST /schedules/{id}/status", existing.Status)
+		}
+		return req.Status, nil
+	}
+
+	if req.AssignedStaffID != nil && (existing.Status == "draft" || existing.Status == "optimized") {
+		assigned := "assigned"
+		return &assigned, nil
+	}
+	return nil, nil
+}
+
+// hasScheduleFieldEdits reports whether an update changes anything besides the status
+func hasScheduleFieldEdits(req *models.VisitScheduleUpdateRequest) bool {
+	return req.VisitDate != nil || req.VisitType != nil ||
+		req.TimeWindowStart != nil || req.TimeWindowEnd != nil || req.EstimatedDurationMinutes != nil ||
+		req.AssignedStaffID != nil || req.AssignedVehicleID != nil || req.PriorityScore != nil ||
+		len(req.Constraints) > 0 || len(req.OptimizationResult) > 0 ||
+		req.CarePlanRef != nil || req.ActivityRef != nil
+}
+
+// validateStatusTransition checks a status change against the transition table and its preconditions
+func validateStatusTransition(schedule *models.VisitSchedule, to string) error {
+	if _, ok := visitScheduleTransitions[to]; !ok {
+		return fmt.Errorf("invalid status: %s", to)
+	}
+	if !visitScheduleTransitions[schedule.Status][to] {
+		return fmt.Errorf("invalid status transition: %s -> %s", schedule.Status, to)
+	}
+	if to == "in_progress" && !schedule.AssignedStaffID.Valid {
+		return fmt.Errorf("invalid status transition: a visit must have assigned staff before it can start")
+	}
+	return nil
+}
+
+// applyStatusTransition validates a transition and sets the schedule's status and transition timestamps
+func applyStatusTransition(schedule *models.VisitSchedule, to string, reason *string, occurredAt time.Time, changedBy string) error {
+	if err := validateStatusTransition(schedule, to); err != nil {
+		return err
+	}
+
+	switch to {
+	case "in_progress":
+		schedule.ActualArrivalAt = spanner.NullTime{Time: occurredAt, Valid: true}
+	case "completed":
+		if schedule.ActualArrivalAt.Valid && occurredAt.Before(schedule.ActualArrivalAt.Time) {
+			return fmt.Errorf("departure time cannot be before arrival time")
+		}
+		schedule.ActualDepartureAt = spanner.NullTime{Time: occurredAt, Valid: true}
+	case "cancelled":
+		if reason == nil || *reason == "" {
+			return fmt.Errorf("a cancellation reason is required")
+		}
+		schedule.CancelledAt = spanner.NullTime{Time: occurredAt, Valid: true}
+		schedule.CancelledBy = spanner.NullString{StringVal: changedBy, Valid: true}
+		schedule.CancellationReason = spanner.NullString{StringVal: *reason, Valid: true}
+	case "draft":
+		// Reinstating a cancelled visit clears its cancellation
+		schedule.CancelledAt = spanner.NullTime{}
+		schedule.CancelledBy = spanner.NullString{}
+		schedule.CancellationReason = spanner.NullString{}
+	}
+
+	schedule.Status = to
+	return nil
+}
+
+// medicalRecordVisitType maps a schedule visit type onto the medical record vocabulary
+func medicalRecordVisitType(visitType string) string {
+	if visitType == "initial_assessment" {
+		return "initial"
+	}
+	return visitType
+}
+
+// validateVehicleAssignment checks that a vehicle exists and can be booked on the visit date
+func (s *VisitScheduleService) validateVehicleAssignment(ctx context.Context, vehicleID string, visitDate civil.Date) error {
+	vehicle, err := s.vehicleRepo.GetByID(ctx, vehicleID)
+	if err != nil {
+		logger.WarnContext(ctx, "Vehicle not found for schedule assignment", map[string]interface{}{
+			"vehicle_id": vehicleID,
+		})
+		return err
+	}
+
+	if !vehicle.IsBookable(visitDate) {
+		logger.WarnContext(ctx, "Vehicle not available for visit date", map[string]interface{}{
+			"vehicle_id":     vehicleID,
+			"vehicle_status": vehicle.VehicleStatus,
+			"visit_date":     visitDate.String(),
+		})
+		return fmt.Errorf("vehicle is not available on %s (status: %s, or registration/insurance expired)", visitDate, vehicle.VehicleStatus)
+	}
+
+	return nil
+}
+
 // Helper function to create string pointer
 func stringPtr(s string) *string {
 	return &s
 }
+
+// checkStaffConflicts rejects a visit that double-books staffID or leaves too little travel time
+// around it. With override set the conflicts are logged and the change is allowed.
+func (s *VisitScheduleService) checkStaffConflicts(ctx context.Context, candidate *models.VisitSchedule, staffID string, override bool, userID string) error {
+	return s.conflictChecker.Enforce(ctx, []*models.VisitSchedule{candidate}, staffID, override, userID)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func TestValidateStatusTransition(t *testing.T) {
+	assigned := spanner.NullString{StringVal: "staff-1", Valid: true}
+
+	tests := []struct {
+		name        string
+		schedule    models.VisitSchedule
+		to          string
+		expectError bool
+	}{
+		{name: "Draft to assigned", schedule: models.VisitSchedule{Status: "draft"}, to: "assigned"},
+		{name: "Assigned visit starts", schedule: models.VisitSchedule{Status: "assigned", AssignedStaffID: assigned}, to: "in_progress"},
+		{name: "Cancelled visit reinstated", schedule: models.VisitSchedule{Status: "cancelled"}, to: "draft"},
+		{name: "Draft cannot complete", schedule: models.VisitSchedule{Status: "draft"}, to: "completed", expectError: true},
+		{name: "Completed is final", schedule: models.VisitSchedule{Status: "completed"}, to: "cancelled", expectError: true},
+		{name: "Same status is not a transition", schedule: models.VisitSchedule{Status: "assigned"}, to: "assigned", expectError: true},
+		{name: "Start requires assigned staff", schedule: models.VisitSchedule{Status: "assigned"}, to: "in_progress", expectError: true},
+		{name: "Unknown status", schedule: models.VisitSchedule{Status: "draft"}, to: "done", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStatusTransition(&tt.schedule, tt.to)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestApplyStatusTransition(t *testing.T) {
+	arrival := time.Date(2025, 4, 10, 10, 0, 0, 0, clinicTimeZone)
+	schedule := &models.VisitSchedule{
+		Status:          "assigned",
+		AssignedStaffID: spanner.NullString{StringVal: "staff-1", Valid: true},
+	}
+
+	require.NoError(t, applyStatusTransition(schedule, "in_progress", nil, arrival, "staff-1"))
+	assert.Equal(t, "in_progress", schedule.Status)
+	assert.Equal(t, arrival, schedule.ActualArrivalAt.Time)
+
+	// Departure before arrival is rejected and leaves the visit in progress
+	assert.Error(t, applyStatusTransition(schedule, "completed", nil, arrival.Add(-time.Minute), "staff-1"))
+	assert.Equal(t, "in_progress", schedule.Status)
+
+	require.NoError(t, applyStatusTransition(schedule, "completed", nil, arrival.Add(40*time.Minute), "staff-1"))
+	assert.Equal(t, arrival.Add(40*time.Minute), schedule.ActualDepartureAt.Time)
+
+	cancelled := &models.VisitSchedule{Status: "draft"}
+	assert.Error(t, applyStatusTransition(cancelled, "cancelled", nil, arrival, "staff-2"), "reason is required")
+
+	reason := "patient hospitalised"
+	require.NoError(t, applyStatusTransition(cancelled, "cancelled", &reason, arrival, "staff-2"))
+	assert.Equal(t, "staff-2", cancelled.CancelledBy.StringVal)
+	assert.Equal(t, reason, cancelled.CancellationReason.StringVal)
+
+	require.NoError(t, applyStatusTransition(cancelled, "draft", nil, arrival, "staff-2"))
+	assert.False(t, cancelled.CancelledAt.Valid)
+	assert.False(t, cancelled.CancellationReason.Valid)
+}
+
+func TestUpdateStatusChange(t *testing.T) {
+	staff := "staff-1"
+	date := time.Date(2025, 4, 11, 0, 0, 0, 0, clinicTimeZone)
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		status      string
+		req         models.VisitScheduleUpdateRequest
+		want        *string
+		expectError string
+	}{
+		{name: "Assigning staff to a draft assigns it", status: "draft", req: models.VisitScheduleUpdateRequest{AssignedStaffID: &staff}, want: str("assigned")},
+		{name: "Assigning staff to an optimized visit assigns it", status: "optimized", req: models.VisitScheduleUpdateRequest{AssignedStaffID: &staff}, want: str("assigned")},
+		{name: "Reassigning staff keeps the status", status: "assigned", req: models.VisitScheduleUpdateRequest{AssignedStaffID: &staff}},
+		{name: "An explicit status wins", status: "draft", req: models.VisitScheduleUpdateRequest{AssignedStaffID: &staff, Status: str("draft")}},
+		{name: "Status change", status: "assigned", req: models.VisitScheduleUpdateRequest{Status: str("optimized")}, want: str("optimized")},
+		{name: "Rescheduling a draft", status: "draft", req: models.VisitScheduleUpdateRequest{VisitDate: &date}},
+		{name: "Completed visit cannot be rescheduled", status: "completed", req: models.VisitScheduleUpdateRequest{VisitDate: &date}, expectError: "a completed visit cannot be edited"},
+		{name: "Cancelled visit cannot be reassigned", status: "cancelled", req: models.VisitScheduleUpdateRequest{AssignedStaffID: &staff}, expectError: "a cancelled visit cannot be edited"},
+		{name: "Cancelled visit is reinstated via the status endpoint", status: "cancelled", req: models.VisitScheduleUpdateRequest{Status: str("draft")}, expectError: "a cancelled visit's status must be changed via POST /schedules/{id}/status"},
+		{name: "Side-effect status", status: "assigned", req: models.VisitScheduleUpdateRequest{Status: str("in_progress")}, expectError: "status in_progress must be set via POST /schedules/{id}/status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := updateStatusChange(&models.VisitSchedule{Status: tt.status}, &tt.req)
+			if tt.expectError != "" {
+				assert.EqualError(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
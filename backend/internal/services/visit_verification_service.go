@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/geo"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+const (
+	checkTypeIn  = "check_in"
+	checkTypeOut = "check_out"
+
+	// maxUnverifiedReportDays bounds the date range of one unverified visit report
+	maxUnverifiedReportDays = 93
+)
+
+// VisitVerificationService records visit check-ins and check-outs with the device location
+// and verifies them against a geofence around the patient's home, as proof for 訪問診療 billing
+type VisitVerificationService struct {
+	visitScheduleRepo *repository.VisitScheduleRepository
+	patientRepo       *repository.PatientRepository
+	medicalRecordRepo *repository.MedicalRecordRepository
+	radiusMeters      float64
+}
+
+// NewVisitVerificationService creates a new visit verification service. A check is verified
+// when the device is within radiusMeters of the patient's home geolocation.
+func NewVisitVerificationService(
+	visitScheduleRepo *repository.VisitScheduleRepository,
+	patientRepo *repository.PatientRepository,
+	medicalRecordRepo *repository.MedicalRecordRepository,
+	radiusMeters float64,
+) *VisitVerificationService {
+	return &VisitVerificationService{
+		visitScheduleRepo: visitScheduleRepo,
+		patientRepo:       patientRepo,
+		medicalRecordRepo: medicalRecordRepo,
+		radiusMeters:      radiusMeters,
+	}
+}
+
+// CheckIn starts a visit (assigned -> in_progress) at the device's recorded time and location,
+// and opens the visit's draft medical record like any other start
+func (s *VisitVerificationService) CheckIn(ctx context.Context, patientID, scheduleID string, req *models.VisitCheckRequest, staffID string) (*models.VisitCheckResult, error) {
+	return s.check(ctx, patientID, scheduleID, req, staffID, checkTypeIn)
+}
+
+// CheckOut completes a visit (in_progress -> completed) at the device's recorded time and location
+func (s *VisitVerificationService) CheckOut(ctx context.Context, patientID, scheduleID string, req *models.VisitCheckRequest, staffID string) (*models.VisitCheckResult, error) {
+	return s.check(ctx, patientID, scheduleID, req, staffID, checkTypeOut)
+}
+
+// check verifies the device location and writes the check with its status transition.
+// A location outside the geofence does not block the visit; it is recorded as unverified.
+func (s *VisitVerificationService) check(ctx context.Context, patientID, scheduleID string, req *models.VisitCheckRequest, staffID, checkType string) (*models.VisitCheckResult, error) {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, staffID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":  patientID,
+			"schedule_id": scheduleID,
+			"staff_id":    staffID,
+		})
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized visit check attempt", map[string]interface{}{
+			"patient_id":  patientID,
+			"schedule_id": scheduleID,
+			"staff_id":    staffID,
+			"check_type":  checkType,
+		})
+		return nil, fmt.Errorf("access denied: you do not have permission to update this visit schedule")
+	}
+
+	schedule, err := s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if !schedule.AssignedStaffID.Valid || schedule.AssignedStaffID.StringVal != staffID {
+		return nil, fmt.Errorf("access denied: only the assigned staff member can check in to or out of this visit")
+	}
+
+	device := geo.Point{Latitude: req.Latitude, Longitude: req.Longitude}
+	if !device.Valid() || (req.Latitude == 0 && req.Longitude == 0) {
+		return nil, fmt.Errorf("invalid coordinates")
+	}
+	if req.AccuracyMeters != nil && *req.AccuracyMeters < 0 {
+		return nil, fmt.Errorf("accuracy_meters must not be negative")
+	}
+
+	recordedAt := time.Now()
+	if req.RecordedAt != nil {
+		if req.RecordedAt.After(recordedAt.Add(5 * time.Minute)) {
+			return nil, fmt.Errorf("recorded_at cannot be in the future")
+		}
+		recordedAt = *req.RecordedAt
+	}
+
+	check := &models.VisitCheck{
+		ScheduleID:     scheduleID,
+		PatientID:      patientID,
+		StaffID:        staffID,
+		CheckType:      checkType,
+		Latitude:       req.Latitude,
+		Longitude:      req.Longitude,
+		AccuracyMeters: req.AccuracyMeters,
+		RecordedAt:     recordedAt,
+	}
+	home, err := s.patientHome(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	verifyGeofence(check, home, s.radiusMeters)
+
+	to := "in_progress"
+	if checkType == checkTypeOut {
+		to = "completed"
+	}
+	fromStatus := schedule.Status
+	if err := applyStatusTransition(schedule, to, nil, recordedAt, staffID); err != nil {
+		logger.WarnContext(ctx, "Invalid status transition for visit check", map[string]interface{}{
+			"schedule_id": scheduleID,
+			"check_type":  checkType,
+			"from":        fromStatus,
+			"error":       err.Error(),
+		})
+		return nil, err
+	}
+
+	verified := spanner.NullBool{Bool: check.Verified, Valid: true}
+	if checkType == checkTypeIn {
+		schedule.CheckInVerified = verified
+	} else {
+		schedule.CheckOutVerified = verified
+	}
+
+	event := &models.VisitScheduleStatusEvent{
+		ScheduleID: scheduleID,
+		PatientID:  patientID,
+		FromStatus: fromStatus,
+		ToStatus:   to,
+		OccurredAt: recordedAt,
+		ChangedBy:  staffID,
+	}
+	if err := s.visitScheduleRepo.RecordCheck(ctx, schedule, event, check); err != nil {
+		logger.ErrorContext(ctx, "Failed to record visit check", err, map[string]interface{}{
+			"schedule_id": scheduleID,
+			"check_type":  checkType,
+		})
+		return nil, err
+	}
+
+	fields := map[string]interface{}{
+		"schedule_id": scheduleID,
+		"staff_id":    staffID,
+		"check_type":  checkType,
+		"verified":    check.Verified,
+	}
+	if check.DistanceMeters != nil {
+		fields["distance_meters"] = *check.DistanceMeters
+	}
+	if check.Verified {
+		logger.InfoContext(ctx, "Visit check recorded", fields)
+	} else {
+		fields["failure_reason"] = *check.FailureReason
+		logger.WarnContext(ctx, "Visit check failed geofence verification", fields)
+	}
+
+	if checkType == checkTypeIn {
+		openVisitRecord(ctx, s.medicalRecordRepo, schedule, staffID)
+	}
+
+	return &models.VisitCheckResult{Schedule: schedule, Check: check}, nil
+}
+
+// patientHome returns the patient's home geolocation, or nil when none is recorded
+func (s *VisitVerificationService) patientHome(ctx context.Context, patientID string) (*models.Geolocation, error) {
+	patient, err := s.patientRepo.GetPatientByID(ctx, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to load patient for geofence verification", err, map[string]interface{}{
+			"patient_id": patientID,
+		})
+		return nil, fmt.Errorf("failed to load patient: %w", err)
+	}
+	home, err := patient.VisitGeolocation()
+	if err != nil {
+		// Unreadable address data counts as no recorded geolocation
+		logger.WarnContext(ctx, "Failed to read patient addresses for geofence verification", map[string]interface{}{
+			"patient_id": patientID,
+			"error":      err.Error(),
+		})
+		return nil, nil
+	}
+	return home, nil
+}
+
+// verifyGeofence fills in the check's distance to the patient's home and its verification
+// result. A fix is verified when it lies within the radius and, when the device reports
+// its accuracy, the accuracy is no coarser than the radius itself.
+func verifyGeofence(check *models.VisitCheck, home *models.Geolocation, radiusMeters float64) {
+	check.RadiusMeters = radiusMeters
+	check.Verified = false
+
+	if home == nil {
+		check.FailureReason = stringPtr("no_patient_geolocation")
+		return
+	}
+
+	lat, lng := home.Latitude, home.Longitude
+	check.PatientLatitude = &lat
+	check.PatientLongitude = &lng
+
+	distance := math.Round(geo.DistanceMeters(
+		geo.Point{Latitude: check.Latitude, Longitude: check.Longitude},
+		geo.Point{Latitude: lat, Longitude: lng},
+	)*10) / 10
+	check.DistanceMeters = &distance
+
+	switch {
+	case distance > radiusMeters:
+		check.FailureReason = stringPtr("outside_geofence")
+	case check.AccuracyMeters != nil && *check.AccuracyMeters > radiusMeters:
+		check.FailureReason = stringPtr("low_accuracy")
+	default:
+		check.Verified = true
+		check.FailureReason = nil
+	}
+}
+
+// GetChecks retrieves a visit's check-ins and check-outs with access control
+func (s *VisitVerificationService) GetChecks(ctx context.Context, patientID, scheduleID, requestorID string) ([]*models.VisitCheck, error) {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+	if !hasAccess {
+		return nil, fmt.Errorf("access denied: you do not have permission to view this visit schedule")
+	}
+
+	if _, err := s.visitScheduleRepo.GetByID(ctx, patientID, scheduleID); err != nil {
+		return nil, err
+	}
+
+	checks, err := s.visitScheduleRepo.ListChecks(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if checks == nil {
+		checks = []*models.VisitCheck{}
+	}
+	return checks, nil
+}
+
+// GetUnverifiedReport lists completed visits between from and to (inclusive) whose check-in
+// or check-out is missing or unverified, limited to patients the requestor has access to.
+// from defaults to the first of the current month and to defaults to today.
+func (s *VisitVerificationService) GetUnverifiedReport(ctx context.Context, requestorID string, fromDate, toDate *civil.Date) (*models.UnverifiedVisitReport, error) {
+	to := clinicToday()
+	if toDate != nil {
+		to = *toDate
+	}
+	from := civil.Date{Year: to.Year, Month: to.Month, Day: 1}
+	if fromDate != nil {
+		from = *fromDate
+	}
+
+	if to.Before(from) {
+		return nil, fmt.Errorf("to cannot be before from")
+	}
+	if to.DaysSince(from) >= maxUnverifiedReportDays {
+		return nil, fmt.Errorf("date range cannot exceed %d days", maxUnverifiedReportDays)
+	}
+
+	schedules, err := s.visitScheduleRepo.ListUnverified(ctx, from, to)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list unverified visits", err, map[string]interface{}{
+			"from": from.String(),
+			"to":   to.String(),
+		})
+		return nil, fmt.Errorf("failed to list unverified visits: %w", err)
+	}
+
+	access := make(map[string]bool)
+	var visible []*models.VisitSchedule
+	var scheduleIDs []string
+	for _, schedule := range schedules {
+		allowed, ok := access[schedule.PatientID]
+		if !ok {
+			allowed, err = s.patientRepo.CheckStaffAccess(ctx, requestorID, schedule.PatientID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check access: %w", err)
+			}
+			access[schedule.PatientID] = allowed
+		}
+		if allowed {
+			visible = append(visible, schedule)
+			scheduleIDs = append(scheduleIDs, schedule.ScheduleID)
+		}
+	}
+
+	checks, err := s.visitScheduleRepo.ListChecksForSchedules(ctx, scheduleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.UnverifiedVisitReport{From: from, To: to, Visits: []*models.UnverifiedVisit{}}
+	for _, schedule := range visible {
+		report.Visits = append(report.Visits, unverifiedVisit(schedule, checks))
+	}
+
+	return report, nil
+}
+
+// unverifiedVisit pairs a visit with its latest check-in and check-out and explains why it is unverified
+func unverifiedVisit(schedule *models.VisitSchedule, checks []*models.VisitCheck) *models.UnverifiedVisit {
+	visit := &models.UnverifiedVisit{Schedule: schedule, Reasons: []string{}}
+
+	// Checks are ordered oldest first, so the last one of each type wins
+	for _, check := range checks {
+		if check.ScheduleID != schedule.ScheduleID {
+			continue
+		}
+		if check.CheckType == checkTypeIn {
+			visit.CheckIn = check
+		} else {
+			visit.CheckOut = check
+		}
+	}
+
+	for _, c := range []struct {
+		checkType string
+		check     *models.VisitCheck
+	}{{checkTypeIn, visit.CheckIn}, {checkTypeOut, visit.CheckOut}} {
+		switch {
+		case c.check == nil:
+			visit.Reasons = append(visit.Reasons, "no_"+c.checkType)
+		case !c.check.Verified && c.check.FailureReason != nil:
+			visit.Reasons = append(visit.Reasons, c.checkType+"_"+*c.check.FailureReason)
+		}
+	}
+
+	return visit
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/models"
+)
+
+func TestVerifyGeofence(t *testing.T) {
+	home := &models.Geolocation{Latitude: 35.6812, Longitude: 139.7671}
+	accuracy := func(m float64) *float64 { return &m }
+
+	t.Run("Within radius", func(t *testing.T) {
+		check := &models.VisitCheck{Latitude: 35.6813, Longitude: 139.7672, AccuracyMeters: accuracy(15)}
+		verifyGeofence(check, home, 200)
+
+		assert.True(t, check.Verified)
+		assert.Nil(t, check.FailureReason)
+		require.NotNil(t, check.DistanceMeters)
+		assert.InDelta(t, 14.5, *check.DistanceMeters, 1)
+		assert.Equal(t, 200.0, check.RadiusMeters)
+		assert.Equal(t, home.Latitude, *check.PatientLatitude)
+	})
+
+	t.Run("Outside radius", func(t *testing.T) {
+		check := &models.VisitCheck{Latitude: 35.6912, Longitude: 139.7671}
+		verifyGeofence(check, home, 200)
+
+		assert.False(t, check.Verified)
+		require.NotNil(t, check.FailureReason)
+		assert.Equal(t, "outside_geofence", *check.FailureReason)
+		assert.InDelta(t, 1112, *check.DistanceMeters, 2)
+	})
+
+	t.Run("Accuracy coarser than radius", func(t *testing.T) {
+		check := &models.VisitCheck{Latitude: 35.6812, Longitude: 139.7671, AccuracyMeters: accuracy(500)}
+		verifyGeofence(check, home, 200)
+
+		assert.False(t, check.Verified)
+		assert.Equal(t, "low_accuracy", *check.FailureReason)
+	})
+
+	t.Run("No patient geolocation", func(t *testing.T) {
+		check := &models.VisitCheck{Latitude: 35.6812, Longitude: 139.7671}
+		verifyGeofence(check, nil, 200)
+
+		assert.False(t, check.Verified)
+		assert.Equal(t, "no_patient_geolocation", *check.FailureReason)
+		assert.Nil(t, check.DistanceMeters)
+	})
+}
+
+func TestUnverifiedVisit(t *testing.T) {
+	schedule := &models.VisitSchedule{ScheduleID: "s-1"}
+	reason := "outside_geofence"
+
+	t.Run("Missing check-in and unverified check-out", func(t *testing.T) {
+		checks := []*models.VisitCheck{
+			{ScheduleID: "s-2", CheckType: checkTypeIn, Verified: true},
+			{ScheduleID: "s-1", CheckType: checkTypeOut, Verified: false, FailureReason: &reason},
+		}
+
+		visit := unverifiedVisit(schedule, checks)
+		assert.Nil(t, visit.CheckIn)
+		require.NotNil(t, visit.CheckOut)
+		assert.Equal(t, []string{"no_check_in", "check_out_outside_geofence"}, visit.Reasons)
+	})
+
+	t.Run("Latest check of each type wins", func(t *testing.T) {
+		checks := []*models.VisitCheck{
+			{CheckID: "old", ScheduleID: "s-1", CheckType: checkTypeIn, Verified: false, FailureReason: &reason},
+			{CheckID: "new", ScheduleID: "s-1", CheckType: checkTypeIn, Verified: true},
+		}
+
+		visit := unverifiedVisit(schedule, checks)
+		assert.Equal(t, "new", visit.CheckIn.CheckID)
+		assert.Equal(t, []string{"no_check_out"}, visit.Reasons)
+	})
+}
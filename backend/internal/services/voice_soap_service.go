@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/visitas/backend/internal/ai"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/internal/repository"
+	"github.com/visitas/backend/pkg/blobstore"
+	"github.com/visitas/backend/pkg/jobqueue"
+	"github.com/visitas/backend/pkg/logger"
+)
+
+// ErrVoiceSOAPUnavailable is returned when no AI provider, blob store or key wrapper is configured
+var ErrVoiceSOAPUnavailable = errors.New("voice-to-SOAP is not available: AI provider or audio storage is not configured")
+
+// VoiceSOAPService turns visit recordings into draft SOAP records. Recordings are stored
+// encrypted and processed on the job queue: transcribed, structured into SOAP sections and
+// saved as a voice_to_text draft whose sections carry their AI provenance until reviewed.
+type VoiceSOAPService struct {
+	jobRepo              *repository.VoiceSOAPJobRepository
+	patientRepo          *repository.PatientRepository
+	medicalRecordService *MedicalRecordService
+	blobs                *blobstore.SealedStore
+	queue                *jobqueue.Queue
+	transcriber          ai.Transcriber
+	structurer           ai.SOAPStructurer
+}
+
+// NewVoiceSOAPService creates a new voice-to-SOAP service. The pipeline reports itself as
+// not available when the transcriber, structurer or blobs is nil.
+func NewVoiceSOAPService(
+	jobRepo *repository.VoiceSOAPJobRepository,
+	patientRepo *repository.PatientRepository,
+	medicalRecordService *MedicalRecordService,
+	blobs *blobstore.SealedStore,
+	queue *jobqueue.Queue,
+	transcriber ai.Transcriber,
+	structurer ai.SOAPStructurer,
+) *VoiceSOAPService {
+	return &VoiceSOAPService{
+		jobRepo:              jobRepo,
+		patientRepo:          patientRepo,
+		medicalRecordService: medicalRecordService,
+		blobs:                blobs,
+		queue:                queue,
+		transcriber:          transcriber,
+		structurer:           structurer,
+	}
+}
+
+// SubmitRecording stores a visit recording and queues drafting a record from it. The
+// returned job is polled via GetJob; once completed it names the draft record.
+func (s *VoiceSOAPService) SubmitRecording(ctx context.Context, patientID string, req *models.VoiceSOAPRequest, requestedBy string) (*models.VoiceSOAPJob, error) {
+	if s.transcriber == nil || s.structurer == nil || s.blobs == nil || s.queue == nil {
+		return nil, ErrVoiceSOAPUnavailable
+	}
+	if err := s.checkAccess(ctx, patientID, requestedBy); err != nil {
+		return nil, err
+	}
+
+	validVisitTypes := map[string]bool{
+		"regular":       true,
+		"emergency":     true,
+		"initial":       true,
+		"follow_up":     true,
+		"terminal_care": true,
+	}
+	if !validVisitTypes[req.VisitType] {
+		return nil, fmt.Errorf("invalid visit type: %s", req.VisitType)
+	}
+	if req.VisitStartedAt.IsZero() {
+		return nil, fmt.Errorf("visit_started_at is required")
+	}
+	if len(req.Audio) == 0 {
+		return nil, fmt.Errorf("audio is required")
+	}
+	contentType := http.DetectContentType(req.Audio)
+	if !models.VoiceAudioContentTypes[contentType] {
+		return nil, fmt.Errorf("unsupported audio type: %s", contentType)
+	}
+	language := req.Language
+	if language == "" {
+		language = "ja"
+	}
+
+	job := &models.VoiceSOAPJob{
+		JobID:            uuid.New().String(),
+		PatientID:        patientID,
+		VisitStartedAt:   req.VisitStartedAt,
+		VisitType:        req.VisitType,
+		ScheduleID:       req.ScheduleID,
+		Language:         language,
+		AudioContentType: contentType,
+		AudioSizeBytes:   int64(len(req.Audio)),
+		CreatedBy:        requestedBy,
+	}
+	job.AudioBlobKey = fmt.Sprintf("voice-soap/%s/%s", patientID, job.JobID)
+
+	sealed, err := s.blobs.Put(ctx, job.AudioBlobKey, req.Audio, voiceAudioAAD(job))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store recording: %w", err)
+	}
+	job.AudioWrappedKey = sealed.WrappedKey
+
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		logger.ErrorContext(ctx, "Failed to create voice SOAP job", err, map[string]interface{}{
+			"patient_id":   patientID,
+			"requested_by": requestedBy,
+		})
+		if delErr := s.blobs.Delete(ctx, job.AudioBlobKey); delErr != nil {
+			logger.WarnContext(ctx, "Failed to remove orphaned recording", map[string]interface{}{
+				"blob_key": job.AudioBlobKey,
+				"error":    delErr.Error(),
+			})
+		}
+		return nil, err
+	}
+
+	jobID := job.JobID
+	err = s.queue.Submit(&jobqueue.Job{
+		ID: jobID,
+		Run: func(ctx context.Context, attempt int) error {
+			return s.executeJob(ctx, jobID, attempt)
+		},
+		OnRetry: func(attempt int, err error) {
+			logger.Warn("Voice SOAP attempt failed, retrying", map[string]interface{}{
+				"job_id":  jobID,
+				"attempt": attempt,
+				"error":   err.Error(),
+			})
+		},
+		OnDone: func(err error) {
+			if err == nil {
+				return
+			}
+			message := err.Error()
+			if errors.Is(err, context.Canceled) {
+				message = "server stopped before the job finished"
+			}
+			s.markFailed(jobID, message)
+		},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to queue voice SOAP job", err, map[string]interface{}{
+			"job_id": jobID,
+		})
+		s.markFailed(jobID, err.Error())
+		return nil, fmt.Errorf("voice-to-SOAP is not available: %w", err)
+	}
+
+	logger.InfoContext(ctx, "Voice SOAP job queued", map[string]interface{}{
+		"job_id":       jobID,
+		"patient_id":   patientID,
+		"content_type": contentType,
+		"size_bytes":   job.AudioSizeBytes,
+		"requested_by": requestedBy,
+	})
+
+	return job, nil
+}
+
+// GetJob retrieves a voice SOAP job of a patient with access control
+func (s *VoiceSOAPService) GetJob(ctx context.Context, patientID, jobID, requestorID string) (*models.VoiceSOAPJob, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.PatientID != patientID {
+		return nil, fmt.Errorf("voice SOAP job not found")
+	}
+	return job, nil
+}
+
+// ListJobs lists a patient's most recent voice SOAP jobs with access control
+func (s *VoiceSOAPService) ListJobs(ctx context.Context, patientID, requestorID string, limit int) ([]*models.VoiceSOAPJob, error) {
+	if err := s.checkAccess(ctx, patientID, requestorID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.jobRepo.ListByPatient(ctx, patientID, limit)
+}
+
+// FailStaleJobs marks jobs that have been pending or processing without an update for
+// longer than staleAfter as failed; queued jobs live in memory only and a restart
+// leaves their rows behind
+func (s *VoiceSOAPService) FailStaleJobs(ctx context.Context, staleAfter time.Duration) error {
+	jobs, err := s.jobRepo.ListStale(ctx, time.Now().Add(-staleAfter))
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		err := s.jobRepo.MarkFailed(ctx, job.JobID, "server stopped before the job finished")
+		if err != nil && !errors.Is(err, repository.ErrVoiceSOAPJobClosed) {
+			return err
+		}
+	}
+
+	if len(jobs) > 0 {
+		logger.WarnContext(ctx, "Marked stale voice SOAP jobs as failed", map[string]interface{}{
+			"count": len(jobs),
+		})
+	}
+
+	return nil
+}
+
+// executeJob is one attempt of a queued job: decrypt the recording, transcribe and
+// structure it, and save the draft record. Provider errors are retried by the queue;
+// an unusable recording or draft is not.
+func (s *VoiceSOAPService) executeJob(ctx context.Context, jobID string, attempt int) error {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if err := s.jobRepo.MarkProcessing(ctx, jobID, int64(attempt)); err != nil {
+		if errors.Is(err, repository.ErrVoiceSOAPJobClosed) {
+			return jobqueue.Permanent(err)
+		}
+		return err
+	}
+
+	soapContent, transcript, draft, err := s.draftFromRecording(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	record, err := s.medicalRecordService.CreateRecord(ctx, job.PatientID, &models.MedicalRecordCreateRequest{
+		VisitStartedAt: job.VisitStartedAt,
+		VisitType:      job.VisitType,
+		PerformedBy:    job.CreatedBy,
+		Status:         "draft",
+		ScheduleID:     job.ScheduleID,
+		SOAPContent:    soapContent,
+		SourceType:     "voice_to_text",
+	}, job.CreatedBy)
+	if err != nil {
+		var validationErr *models.SOAPValidationError
+		if errors.As(err, &validationErr) || strings.Contains(err.Error(), "access denied") {
+			return jobqueue.Permanent(err)
+		}
+		return err
+	}
+
+	// The draft exists now; a retry would create a second one
+	if err := s.jobRepo.MarkCompleted(ctx, jobID, record.RecordID, transcript.Text, transcript.Model, draft.Model); err != nil {
+		return jobqueue.Permanent(fmt.Errorf("draft record %s was created but the job could not be completed: %w", record.RecordID, err))
+	}
+
+	logger.InfoContext(ctx, "Voice SOAP draft created", map[string]interface{}{
+		"job_id":     jobID,
+		"record_id":  record.RecordID,
+		"patient_id": job.PatientID,
+		"sections":   len(draft.Sections),
+		"confidence": draft.Confidence,
+	})
+
+	return nil
+}
+
+// draftFromRecording decrypts a job's recording, transcribes and structures it, and builds
+// the draft's SOAP content. Errors a retry cannot fix are marked permanent.
+func (s *VoiceSOAPService) draftFromRecording(ctx context.Context, job *models.VoiceSOAPJob) (json.RawMessage, *ai.Transcript, *ai.SOAPDraft, error) {
+	audio, err := s.blobs.Get(ctx, blobstore.Sealed{Key: job.AudioBlobKey, WrappedKey: job.AudioWrappedKey}, voiceAudioAAD(job))
+	if err != nil {
+		return nil, nil, nil, jobqueue.Permanent(fmt.Errorf("failed to read recording: %w", err))
+	}
+
+	transcript, err := s.transcriber.Transcribe(ctx, audio, job.AudioContentType, job.Language)
+	if err != nil {
+		if errors.Is(err, ai.ErrEmptyTranscript) {
+			return nil, nil, nil, jobqueue.Permanent(err)
+		}
+		return nil, nil, nil, fmt.Errorf("transcription failed: %w", err)
+	}
+
+	draft, err := s.structurer.Structure(ctx, transcript)
+	if err != nil {
+		if errors.Is(err, ai.ErrEmptyTranscript) {
+			return nil, nil, nil, jobqueue.Permanent(err)
+		}
+		return nil, nil, nil, fmt.Errorf("SOAP structuring failed: %w", err)
+	}
+
+	soapContent, err := draftSOAPContent(job, transcript, draft)
+	if err != nil {
+		return nil, nil, nil, jobqueue.Permanent(err)
+	}
+	return soapContent, transcript, draft, nil
+}
+
+// markFailed records a job failure from a queue callback, outside any request context
+func (s *VoiceSOAPService) markFailed(jobID, message string) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobStatusTimeout)
+	defer cancel()
+
+	logger.WarnContext(ctx, "Voice SOAP job failed", map[string]interface{}{
+		"job_id": jobID,
+		"error":  message,
+	})
+	if err := s.jobRepo.MarkFailed(ctx, jobID, message); err != nil && !errors.Is(err, repository.ErrVoiceSOAPJobClosed) {
+		logger.ErrorContext(ctx, "Failed to update voice SOAP job status", err, map[string]interface{}{
+			"job_id": jobID,
+		})
+	}
+}
+
+func (s *VoiceSOAPService) checkAccess(ctx context.Context, patientID, requestorID string) error {
+	hasAccess, err := s.patientRepo.CheckStaffAccess(ctx, requestorID, patientID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to check staff access", err, map[string]interface{}{
+			"patient_id":   patientID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+
+	if !hasAccess {
+		logger.WarnContext(ctx, "Unauthorized voice SOAP access attempt", map[string]interface{}{
+			"patient_id":   patientID,
+			"requestor_id": requestorID,
+		})
+		return fmt.Errorf("access denied: you do not have permission to create medical records for this patient")
+	}
+
+	return nil
+}
+
+// draftSOAPContent combines the suggested sections with metadata recording, per section,
+// the model, confidence and transcript evidence the text came from. No section is
+// reviewed yet.
+func draftSOAPContent(job *models.VoiceSOAPJob, transcript *ai.Transcript, draft *ai.SOAPDraft) (json.RawMessage, error) {
+	content := draft.Content
+
+	provenance := make([]models.SectionProvenance, len(draft.Sections))
+	for i, section := range draft.Sections {
+		provenance[i] = models.SectionProvenance{
+			Section:    section.Section,
+			Source:     "ai_generated",
+			Model:      draft.Model,
+			Confidence: section.Confidence,
+			Evidence:   section.Evidence,
+		}
+	}
+
+	content.Metadata = &models.SOAPMetadata{
+		RecordedBy: job.CreatedBy,
+		RecordedAt: job.VisitStartedAt.Format(time.RFC3339),
+		SourceType: "voice_to_text",
+		DraftMode:  true,
+		AIAssistance: &models.AIAssistance{
+			Enabled:          true,
+			AIGenerated:      true,
+			Model:            draft.Model,
+			Confidence:       draft.Confidence,
+			JobID:            job.JobID,
+			TranscriberModel: transcript.Model,
+			Sections:         provenance,
+		},
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode draft SOAP content: %w", err)
+	}
+	return data, nil
+}
+
+// voiceAudioAAD names a job's recording for sealing
+func voiceAudioAAD(job *models.VoiceSOAPJob) []byte {
+	return blobstore.AAD(job.PatientID, "voice-soap", job.JobID)
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/ai"
+	"github.com/visitas/backend/internal/models"
+	"github.com/visitas/backend/pkg/blobstore"
+	"github.com/visitas/backend/pkg/encryption"
+)
+
+func TestDraftSOAPContent(t *testing.T) {
+	ctx := context.Background()
+	job := &models.VoiceSOAPJob{
+		JobID:          "job-1",
+		PatientID:      "p-1",
+		VisitStartedAt: time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC),
+		CreatedBy:      "doctor-1",
+	}
+
+	transcript, err := ai.NewStubTranscriber("").Transcribe(ctx, []byte("audio"), "audio/wave", "ja")
+	require.NoError(t, err)
+	draft, err := ai.NewStubStructurer().Structure(ctx, transcript)
+	require.NoError(t, err)
+
+	data, err := draftSOAPContent(job, transcript, draft)
+	require.NoError(t, err)
+	require.NoError(t, models.ValidateSOAPContent(data))
+
+	var content models.SOAPContent
+	require.NoError(t, json.Unmarshal(data, &content))
+	require.NotNil(t, content.Metadata)
+	assert.Equal(t, "voice_to_text", content.Metadata.SourceType)
+	assert.True(t, content.Metadata.DraftMode)
+
+	assistance := content.Metadata.AIAssistance
+	require.NotNil(t, assistance)
+	assert.Equal(t, "job-1", assistance.JobID)
+	assert.Equal(t, ai.StubTranscriberModel, assistance.TranscriberModel)
+	assert.Equal(t, ai.StubStructurerModel, assistance.Model)
+	require.Len(t, assistance.Sections, len(draft.Sections))
+	for _, section := range assistance.Sections {
+		assert.Equal(t, ai.StubStructurerModel, section.Model)
+		assert.NotEmpty(t, section.Evidence)
+		assert.Nil(t, section.ReviewedAt)
+	}
+
+	// Every suggested section needs review before the draft can be signed
+	pending, err := models.PendingAIReview(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"subjective", "objective", "assessment", "plan"}, pending)
+	assert.True(t, models.HasAIAssistance("voice_to_text", data))
+}
+
+// memoryBlobStore is an in-memory blobstore.BlobStore
+type memoryBlobStore struct {
+	objects map[string][]byte
+}
+
+func (m *memoryBlobStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	m.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *memoryBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, blobstore.ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *memoryBlobStore) Delete(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+// newTestSealedStore seals blobs into memory under a throwaway master key
+func newTestSealedStore(t *testing.T) *blobstore.SealedStore {
+	masterKey := make([]byte, 32)
+	_, err := rand.Read(masterKey)
+	require.NoError(t, err)
+	wrapper, err := encryption.NewLocalKeyWrapper(base64.StdEncoding.EncodeToString(masterKey))
+	require.NoError(t, err)
+	return blobstore.NewSealedStore(&memoryBlobStore{objects: map[string][]byte{}}, encryption.NewEnvelopeEncryptor(wrapper))
+}
+
+// fakeTranscriber returns a fixed transcript and records the audio it was given
+type fakeTranscriber struct {
+	transcript *ai.Transcript
+	err        error
+	audio      []byte
+}
+
+func (f *fakeTranscriber) Model() string { return "fake-transcriber" }
+
+func (f *fakeTranscriber) Transcribe(ctx context.Context, audio []byte, contentType, language string) (*ai.Transcript, error) {
+	f.audio = audio
+	return f.transcript, f.err
+}
+
+// fakeStructurer returns a fixed draft
+type fakeStructurer struct {
+	draft *ai.SOAPDraft
+	err   error
+}
+
+func (f *fakeStructurer) Model() string { return "fake-structurer" }
+
+func (f *fakeStructurer) Structure(ctx context.Context, transcript *ai.Transcript) (*ai.SOAPDraft, error) {
+	return f.draft, f.err
+}
+
+func newVoiceSOAPTest(t *testing.T, audio []byte) (*VoiceSOAPService, *fakeTranscriber, *fakeStructurer, *models.VoiceSOAPJob) {
+	transcriber := &fakeTranscriber{transcript: &ai.Transcript{
+		Text:     "腰の痛みが続いている。鎮痛薬は継続。",
+		Language: "ja",
+		Segments: []ai.Segment{{Text: "腰の痛みが続いている。", Confidence: 0.9}, {Text: "鎮痛薬は継続。", Confidence: 0.8}},
+		Model:    "fake-transcriber",
+	}}
+	structurer := &fakeStructurer{draft: &ai.SOAPDraft{
+		Content: models.SOAPContent{
+			Subjective: &models.SubjectiveSection{PatientNarrative: "腰の痛みが続いている"},
+			Plan:       &models.PlanSection{Medications: []models.MedicationPlan{{Action: "continue"}}},
+		},
+		Sections: []ai.SectionSuggestion{
+			{Section: "subjective", Evidence: []string{"腰の痛みが続いている。"}, Confidence: 0.9},
+			{Section: "plan", Evidence: []string{"鎮痛薬は継続。"}, Confidence: 0.6},
+		},
+		Confidence: 0.75,
+		Model:      "fake-structurer",
+	}}
+	blobs := newTestSealedStore(t)
+	service := NewVoiceSOAPService(nil, nil, nil, blobs, nil, transcriber, structurer)
+
+	job := &models.VoiceSOAPJob{
+		JobID:            "job-1",
+		PatientID:        "p-1",
+		VisitStartedAt:   time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC),
+		Language:         "ja",
+		AudioContentType: "audio/wave",
+		AudioBlobKey:     "voice-soap/p-1/job-1",
+		CreatedBy:        "doctor-1",
+	}
+	sealed, err := blobs.Put(context.Background(), job.AudioBlobKey, audio, voiceAudioAAD(job))
+	require.NoError(t, err)
+	job.AudioWrappedKey = sealed.WrappedKey
+	return service, transcriber, structurer, job
+}
+
+func TestVoiceSOAPDraftProvenanceReview(t *testing.T) {
+	ctx := context.Background()
+	audio := []byte("RIFF recording")
+	service, transcriber, _, job := newVoiceSOAPTest(t, audio)
+
+	data, transcript, draft, err := service.draftFromRecording(ctx, job)
+	require.NoError(t, err)
+	assert.Equal(t, audio, transcriber.audio, "the recording is decrypted before transcription")
+	assert.Equal(t, "fake-transcriber", transcript.Model)
+	assert.Equal(t, "fake-structurer", draft.Model)
+
+	var content models.SOAPContent
+	require.NoError(t, json.Unmarshal(data, &content))
+	require.NotNil(t, content.Metadata)
+	assistance := content.Metadata.AIAssistance
+	require.NotNil(t, assistance)
+	assert.Equal(t, "fake-transcriber", assistance.TranscriberModel)
+	require.Len(t, assistance.Sections, 2)
+	assert.Equal(t, models.SectionProvenance{
+		Section:    "plan",
+		Source:     "ai_generated",
+		Model:      "fake-structurer",
+		Confidence: 0.6,
+		Evidence:   []string{"鎮痛薬は継続。"},
+	}, assistance.Sections[1])
+
+	// Only sections that received suggested text wait for review
+	pending, err := models.PendingAIReview(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"subjective", "plan"}, pending)
+	assert.Error(t, models.AIReviewPendingError(pending))
+
+	reviewedAt := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+	t.Run("Sections are reviewed one at a time", func(t *testing.T) {
+		updated, reviewed, err := models.ReviewAISections(data, []string{"plan"}, "doctor-2", reviewedAt)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plan"}, reviewed)
+
+		pending, err := models.PendingAIReview(updated)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"subjective"}, pending)
+
+		var content models.SOAPContent
+		require.NoError(t, json.Unmarshal(updated, &content))
+		plan := content.Metadata.AIAssistance.Sections[1]
+		require.NotNil(t, plan.ReviewedBy)
+		assert.Equal(t, "doctor-2", *plan.ReviewedBy)
+		assert.Equal(t, reviewedAt, *plan.ReviewedAt)
+		assert.Equal(t, []string{"鎮痛薬は継続。"}, plan.Evidence, "provenance is kept after review")
+
+		// A reviewed section is not stamped again
+		again, reviewed, err := models.ReviewAISections(updated, []string{"plan"}, "doctor-3", reviewedAt.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, reviewed)
+		assert.Equal(t, updated, again)
+	})
+
+	t.Run("A section without suggested text cannot be reviewed", func(t *testing.T) {
+		_, _, err := models.ReviewAISections(data, []string{"objective"}, "doctor-2", reviewedAt)
+		assert.Error(t, err)
+	})
+
+	t.Run("Reviewing all pending sections clears the draft for signing", func(t *testing.T) {
+		updated, reviewed, err := models.ReviewAISections(data, nil, "doctor-2", reviewedAt)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"subjective", "plan"}, reviewed)
+
+		pending, err := models.PendingAIReview(updated)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+		assert.True(t, models.HasAIAssistance("voice_to_text", updated))
+	})
+}
+
+func TestVoiceSOAPDraftErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Provider errors are returned for retry", func(t *testing.T) {
+		service, transcriber, _, job := newVoiceSOAPTest(t, []byte("RIFF recording"))
+		transcriber.err = errors.New("provider unavailable")
+
+		_, _, _, err := service.draftFromRecording(ctx, job)
+		assert.ErrorContains(t, err, "transcription failed")
+	})
+
+	t.Run("An empty transcript is not drafted", func(t *testing.T) {
+		service, _, structurer, job := newVoiceSOAPTest(t, []byte("RIFF recording"))
+		structurer.err = ai.ErrEmptyTranscript
+
+		_, _, _, err := service.draftFromRecording(ctx, job)
+		assert.ErrorIs(t, err, ai.ErrEmptyTranscript)
+	})
+
+	t.Run("A recording sealed for another job does not open", func(t *testing.T) {
+		service, transcriber, _, job := newVoiceSOAPTest(t, []byte("RIFF recording"))
+		job.JobID = "job-2"
+
+		_, _, _, err := service.draftFromRecording(ctx, job)
+		assert.ErrorContains(t, err, "failed to read recording")
+		assert.Nil(t, transcriber.audio)
+	})
+}
@@ -0,0 +1,78 @@
+-- Migration: Create logistics_locations table (Emulator Compatible)
+-- Removed: GENERATED columns, FOREIGN KEY, WHERE clauses in indexes, COMMENT
+-- Changed: NUMERIC -> FLOAT8, TIME -> VARCHAR(5) ("HH:MM")
+
+CREATE TABLE logistics_locations (
+    location_id VARCHAR(36) NOT NULL,
+    organization_id VARCHAR(36),
+
+    location_type VARCHAR(50) NOT NULL,
+
+    location_name VARCHAR(300) NOT NULL,
+    location_code VARCHAR(50),
+
+    postal_code VARCHAR(10),
+    prefecture VARCHAR(50) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    address_line VARCHAR(500) NOT NULL,
+    building_name VARCHAR(200),
+
+    -- Removed GENERATED column: full_address
+
+    latitude FLOAT8 NOT NULL,
+    longitude FLOAT8 NOT NULL,
+    geolocation_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    geolocation_verified_at TIMESTAMPTZ,
+
+    google_place_id VARCHAR(200),
+    google_maps_url TEXT,
+
+    phone VARCHAR(50),
+    fax VARCHAR(50),
+    email VARCHAR(200),
+    website_url TEXT,
+
+    operating_hours JSONB,
+
+    staff_capacity INT,
+    vehicle_capacity INT,
+    parking_spots INT,
+    has_medical_equipment_storage BOOLEAN NOT NULL DEFAULT FALSE,
+
+    is_route_start_point BOOLEAN NOT NULL DEFAULT FALSE,
+    is_route_end_point BOOLEAN NOT NULL DEFAULT FALSE,
+    default_departure_time VARCHAR(5),
+    default_return_time VARCHAR(5),
+
+    service_area JSONB,
+    max_service_radius_km FLOAT8,
+
+    facilities JSONB,
+
+    parent_location_id VARCHAR(36),
+
+    location_status VARCHAR(30) NOT NULL DEFAULT 'active',
+
+    access_instructions TEXT,
+    special_notes TEXT,
+
+    is_billing_location BOOLEAN NOT NULL DEFAULT FALSE,
+    billing_code VARCHAR(50),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(100),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_by VARCHAR(100),
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    deleted_at TIMESTAMPTZ,
+
+    PRIMARY KEY (location_id)
+);
+
+CREATE INDEX idx_locations_organization ON logistics_locations(organization_id);
+CREATE INDEX idx_locations_type ON logistics_locations(location_type);
+CREATE INDEX idx_locations_status ON logistics_locations(location_status);
+CREATE INDEX idx_locations_active ON logistics_locations(organization_id, location_type, location_status);
+CREATE INDEX idx_locations_geolocation ON logistics_locations(latitude, longitude);
+CREATE INDEX idx_locations_parent ON logistics_locations(parent_location_id);
+CREATE INDEX idx_locations_prefecture_city ON logistics_locations(prefecture, city);
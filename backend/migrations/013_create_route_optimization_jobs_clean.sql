@@ -0,0 +1,87 @@
+-- Migration: Create route_optimization_jobs table (Emulator Compatible)
+-- Removed: GENERATED columns, FOREIGN KEY, WHERE clauses in indexes, COMMENT
+-- Changed: NUMERIC -> FLOAT8
+
+CREATE TABLE route_optimization_jobs (
+    job_id VARCHAR(36) NOT NULL,
+    organization_id VARCHAR(36),
+
+    job_type VARCHAR(50) NOT NULL,
+    job_status VARCHAR(30) NOT NULL DEFAULT 'pending',
+
+    target_date DATE NOT NULL,
+    target_start_time TIMESTAMPTZ,
+    target_end_time TIMESTAMPTZ,
+
+    staff_id VARCHAR(100),
+    vehicle_id VARCHAR(36),
+
+    start_location_id VARCHAR(36),
+    end_location_id VARCHAR(36),
+    start_latitude FLOAT8,
+    start_longitude FLOAT8,
+    end_latitude FLOAT8,
+    end_longitude FLOAT8,
+
+    optimization_params JSONB NOT NULL,
+
+    included_schedule_ids JSONB,
+    -- Removed GENERATED column: total_visits_count
+    total_visits_count INT,
+
+    google_api_request_payload JSONB,
+    google_api_request_time TIMESTAMPTZ,
+
+    google_api_response_payload JSONB,
+    google_api_response_time TIMESTAMPTZ,
+    google_api_computation_time_ms INT,
+
+    optimized_route JSONB,
+
+    total_distance_meters INT,
+    total_duration_seconds INT,
+    -- Removed GENERATED columns: total_distance_km, total_duration_hours
+
+    estimated_fuel_cost_jpy INT,
+    estimated_toll_cost_jpy INT,
+    estimated_total_cost_jpy INT,
+
+    baseline_job_id VARCHAR(36),
+    improvement_distance_percent FLOAT8,
+    improvement_duration_percent FLOAT8,
+
+    applied_to_schedules BOOLEAN NOT NULL DEFAULT FALSE,
+    applied_at TIMESTAMPTZ,
+    applied_by VARCHAR(100),
+
+    has_manual_overrides BOOLEAN NOT NULL DEFAULT FALSE,
+    manual_override_notes TEXT,
+
+    error_code VARCHAR(100),
+    error_message TEXT,
+    retry_count INT DEFAULT 0,
+
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    -- Removed GENERATED column: execution_duration_seconds
+
+    user_rating INT,
+    user_feedback TEXT,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(100),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_by VARCHAR(100),
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    deleted_at TIMESTAMPTZ,
+
+    PRIMARY KEY (job_id)
+);
+
+CREATE INDEX idx_route_jobs_organization ON route_optimization_jobs(organization_id);
+CREATE INDEX idx_route_jobs_status ON route_optimization_jobs(job_status);
+CREATE INDEX idx_route_jobs_staff ON route_optimization_jobs(staff_id);
+CREATE INDEX idx_route_jobs_vehicle ON route_optimization_jobs(vehicle_id);
+CREATE INDEX idx_route_jobs_recent ON route_optimization_jobs(staff_id, target_date, created_at);
+CREATE INDEX idx_route_jobs_target_date ON route_optimization_jobs(target_date, job_status);
+CREATE INDEX idx_route_jobs_baseline ON route_optimization_jobs(baseline_job_id);
@@ -0,0 +1,52 @@
+-- Migration: Create visit_schedule_recurrences table (Emulator Compatible)
+-- Recurring visit rules (weekly / biweekly / nth weekday of month) that
+-- materialize visit_schedules rows over a rolling horizon
+-- Changed: TIME -> VARCHAR(5)
+
+CREATE TABLE visit_schedule_recurrences (
+    recurrence_id VARCHAR(36) NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+
+    -- Pattern
+    frequency VARCHAR(30) NOT NULL,
+    by_weekday JSONB NOT NULL,
+    by_week_of_month JSONB,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    exception_dates JSONB,
+
+    -- Occurrence template
+    visit_type VARCHAR(50) NOT NULL,
+    start_time VARCHAR(5),
+    end_time VARCHAR(5),
+    estimated_duration_minutes INT NOT NULL DEFAULT 30,
+    assigned_staff_id VARCHAR(100),
+    assigned_vehicle_id VARCHAR(100),
+    priority_score INT NOT NULL DEFAULT 5,
+    constraints JSONB,
+    care_plan_ref VARCHAR(36),
+    activity_ref VARCHAR(36),
+
+    -- Rolling materialization
+    materialized_until DATE,
+    status VARCHAR(30) NOT NULL DEFAULT 'active',
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(100),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_by VARCHAR(100),
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    deleted_at TIMESTAMPTZ,
+
+    PRIMARY KEY (recurrence_id)
+);
+
+CREATE INDEX idx_schedule_recurrences_patient ON visit_schedule_recurrences(patient_id);
+CREATE INDEX idx_schedule_recurrences_status ON visit_schedule_recurrences(status, materialized_until);
+
+-- Link materialized visits back to their rule
+ALTER TABLE visit_schedules ADD COLUMN recurrence_id VARCHAR(36);
+ALTER TABLE visit_schedules ADD COLUMN occurrence_date DATE;
+ALTER TABLE visit_schedules ADD COLUMN is_recurrence_exception BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_schedules_recurrence ON visit_schedules(recurrence_id, occurrence_date);
@@ -0,0 +1,28 @@
+-- Migration: Visit schedule status tracking (Emulator Compatible)
+-- Actual visit times and cancellation details on visit_schedules, plus a
+-- history row for every status transition
+
+ALTER TABLE visit_schedules ADD COLUMN actual_arrival_at TIMESTAMPTZ;
+ALTER TABLE visit_schedules ADD COLUMN actual_departure_at TIMESTAMPTZ;
+ALTER TABLE visit_schedules ADD COLUMN cancelled_at TIMESTAMPTZ;
+ALTER TABLE visit_schedules ADD COLUMN cancelled_by VARCHAR(100);
+ALTER TABLE visit_schedules ADD COLUMN cancellation_reason TEXT;
+
+CREATE TABLE visit_schedule_status_events (
+    event_id VARCHAR(36) NOT NULL,
+    schedule_id VARCHAR(36) NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+
+    from_status VARCHAR(30) NOT NULL,
+    to_status VARCHAR(30) NOT NULL,
+    reason TEXT,
+
+    -- When the transition happened in the field (may be recorded after the fact)
+    occurred_at TIMESTAMPTZ NOT NULL,
+    changed_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (event_id)
+);
+
+CREATE INDEX idx_schedule_status_events_schedule ON visit_schedule_status_events(schedule_id, occurred_at);
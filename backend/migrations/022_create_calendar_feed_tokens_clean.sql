@@ -0,0 +1,26 @@
+-- Migration: Create calendar_feed_tokens table (Emulator Compatible)
+-- Revocable tokens behind the signed iCalendar (ICS) feed URLs for a staff
+-- member's or a patient's visit schedules. The URL signature is derived from
+-- the token row with a server-side key, so no secret is stored here.
+
+CREATE TABLE calendar_feed_tokens (
+    token_id VARCHAR(36) NOT NULL,
+
+    -- "staff" feeds list visits assigned to subject_id; "patient" feeds list a patient's visits
+    feed_type VARCHAR(20) NOT NULL,
+    subject_id VARCHAR(100) NOT NULL,
+    label VARCHAR(100),
+
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_accessed_at TIMESTAMPTZ,
+
+    revoked BOOLEAN NOT NULL DEFAULT false,
+    revoked_at TIMESTAMPTZ,
+    revoked_by VARCHAR(100),
+
+    PRIMARY KEY (token_id)
+);
+
+CREATE INDEX idx_calendar_feed_tokens_creator ON calendar_feed_tokens(created_by, revoked);
+CREATE INDEX idx_calendar_feed_tokens_subject ON calendar_feed_tokens(feed_type, subject_id);
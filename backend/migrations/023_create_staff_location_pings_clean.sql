@@ -0,0 +1,31 @@
+-- Migration: Staff location pings and live visit ETA (Emulator Compatible)
+-- Raw GPS pings from staff devices, kept only for the retention period, plus
+-- the live ETA and "running late" flag for the next visit on visit_schedules.
+-- The latest position is also written to staff_members/vehicles current_* columns.
+
+CREATE TABLE staff_location_pings (
+    ping_id VARCHAR(36) NOT NULL,
+    staff_id VARCHAR(100) NOT NULL,
+    vehicle_id VARCHAR(36),
+
+    latitude FLOAT8 NOT NULL,
+    longitude FLOAT8 NOT NULL,
+    accuracy_meters FLOAT8,
+    speed_kmh FLOAT8,
+    heading_degrees FLOAT8,
+
+    -- Device clock time of the fix; received_at is the server time of the batch
+    recorded_at TIMESTAMPTZ NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (ping_id)
+);
+
+CREATE INDEX idx_staff_location_pings_staff ON staff_location_pings(staff_id, recorded_at);
+CREATE INDEX idx_staff_location_pings_recorded ON staff_location_pings(recorded_at);
+
+ALTER TABLE visit_schedules ADD COLUMN estimated_arrival_at TIMESTAMPTZ;
+ALTER TABLE visit_schedules ADD COLUMN running_late BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE visit_schedules ADD COLUMN eta_updated_at TIMESTAMPTZ;
+
+CREATE INDEX idx_visit_schedules_running_late ON visit_schedules(visit_date, running_late);
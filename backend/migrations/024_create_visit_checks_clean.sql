@@ -0,0 +1,37 @@
+-- Migration: Visit check-in/check-out with geofence verification (Emulator Compatible)
+-- Device GPS recorded when staff check in to and out of a visit, compared with
+-- the patient's home geolocation. The per-visit outcome is kept on visit_schedules
+-- so unverified visits can be reported for 訪問診療 billing review.
+
+CREATE TABLE visit_checks (
+    check_id VARCHAR(36) NOT NULL,
+    schedule_id VARCHAR(36) NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+    staff_id VARCHAR(100) NOT NULL,
+
+    -- "check_in" | "check_out"
+    check_type VARCHAR(20) NOT NULL,
+
+    latitude FLOAT8 NOT NULL,
+    longitude FLOAT8 NOT NULL,
+    accuracy_meters FLOAT8,
+    recorded_at TIMESTAMPTZ NOT NULL,
+
+    -- Geofence result: distance to the patient's home and the radius in force at the time
+    patient_latitude FLOAT8,
+    patient_longitude FLOAT8,
+    distance_meters FLOAT8,
+    radius_meters FLOAT8 NOT NULL,
+    verified BOOLEAN NOT NULL,
+    -- "no_patient_geolocation" | "outside_geofence" | "low_accuracy"
+    failure_reason VARCHAR(50),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (check_id)
+);
+
+CREATE INDEX idx_visit_checks_schedule ON visit_checks(schedule_id, recorded_at);
+
+ALTER TABLE visit_schedules ADD COLUMN check_in_verified BOOLEAN;
+ALTER TABLE visit_schedules ADD COLUMN check_out_verified BOOLEAN;
@@ -0,0 +1,5 @@
+-- Migration: Route optimization engine selection (Emulator Compatible)
+-- Records which solver produced each route_optimization_jobs row; rows
+-- created before engines were selectable were all solved locally
+
+ALTER TABLE route_optimization_jobs ADD COLUMN optimizer_engine VARCHAR(30) NOT NULL DEFAULT 'local';
@@ -0,0 +1,5 @@
+-- Migration: Care plan activity scheduling (Emulator Compatible)
+-- Recurrence rules generated from care plan activities are looked up by plan
+-- whenever the plan is activated, edited, revoked or completed
+
+CREATE INDEX idx_recurrences_care_plan ON visit_schedule_recurrences(care_plan_ref, activity_ref);
@@ -0,0 +1,31 @@
+-- Migration: Care plan revision history (Emulator Compatible)
+-- Every saved version of a care plan is kept as an immutable snapshot so that
+-- what a plan said at any point can be shown for 居宅療養管理指導 audits.
+-- Snapshots are written in the same commit as the care_plans row and are never
+-- updated or deleted, including when the plan itself is deleted.
+
+CREATE TABLE care_plan_versions (
+    plan_id VARCHAR(36) NOT NULL,
+    version INT NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+
+    status VARCHAR(30) NOT NULL,
+    intent VARCHAR(30) NOT NULL,
+
+    title VARCHAR(300) NOT NULL,
+    description TEXT,
+
+    period_start DATE NOT NULL,
+    period_end DATE,
+
+    goals JSONB,
+    activities JSONB,
+
+    change_reason TEXT,
+    recorded_by VARCHAR(100),
+    recorded_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (plan_id, version)
+);
+
+CREATE INDEX idx_care_plan_versions_patient ON care_plan_versions(patient_id, plan_id);
@@ -0,0 +1,31 @@
+-- Migration: Medical record revision history (Emulator Compatible)
+-- 電子カルテの真正性: an edit never destroys what the record said before.
+-- Each saved version of a medical record is appended as a revision with who
+-- changed it, when and why. Revisions are never updated or deleted; a restore
+-- appends the old content again as a new revision.
+
+CREATE TABLE medical_record_revisions (
+    record_id VARCHAR(36) NOT NULL,
+    -- The record version this revision produced
+    revision INT NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+
+    visit_ended_at TIMESTAMPTZ,
+    visit_type VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    schedule_id VARCHAR(36),
+    soap_content JSONB,
+    template_id VARCHAR(36),
+    audio_file_url TEXT,
+
+    -- "create" | "update" | "restore"
+    change_type VARCHAR(20) NOT NULL,
+    change_reason TEXT,
+    restored_from_revision INT,
+    revised_by VARCHAR(36) NOT NULL,
+    revised_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (record_id, revision)
+);
+
+CREATE INDEX idx_medical_record_revisions_patient ON medical_record_revisions(patient_id, record_id);
@@ -0,0 +1,14 @@
+-- Migration: Medical record signing and addenda (Emulator Compatible)
+-- A signed record is final: the signature hash is SHA-256 over the canonical
+-- JSON of the record and its SOAP content, and no further edit, restore or
+-- delete is accepted. Corrections are made as signed addenda that reference
+-- the original through amends_record_id. Signing is also appended to
+-- medical_record_revisions with change_type 'sign'.
+
+ALTER TABLE medical_records ADD COLUMN signed_at TIMESTAMPTZ;
+ALTER TABLE medical_records ADD COLUMN signed_by VARCHAR(36);
+ALTER TABLE medical_records ADD COLUMN signature_hash VARCHAR(64);
+ALTER TABLE medical_records ADD COLUMN amends_record_id VARCHAR(36);
+ALTER TABLE medical_records ADD COLUMN amendment_reason TEXT;
+
+CREATE INDEX idx_medical_records_amends ON medical_records(amends_record_id);
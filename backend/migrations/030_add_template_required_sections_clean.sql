@@ -0,0 +1,6 @@
+-- Migration: Required SOAP sections on medical record templates (Emulator Compatible)
+-- A template may list dot-separated SOAP paths (e.g. "assessment.diagnoses",
+-- "plan.nextVisit") that must be filled before a record created from it can
+-- be set to completed or signed. NULL means no requirement.
+
+ALTER TABLE medical_record_templates ADD COLUMN required_sections JSONB;
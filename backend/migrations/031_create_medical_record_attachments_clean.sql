@@ -0,0 +1,40 @@
+-- Migration: Medical record attachments (Emulator Compatible)
+-- FR-RECORD-002: wound/skin photos and scanned documents attached to a record.
+-- File content lives in blob storage, never in the database. Each object is
+-- encrypted with its own AES-256-GCM data key; only the wrapped (KMS or
+-- master-key encrypted) data key is stored here. Photos have EXIF/GPS
+-- metadata stripped before storage and get an encrypted JPEG thumbnail.
+-- Downloads are written to audit_patient_access_logs.
+
+CREATE TABLE medical_record_attachments (
+    attachment_id VARCHAR(36) NOT NULL,
+    record_id VARCHAR(36) NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+
+    -- "wound_photo" | "skin_photo" | "scanned_document" | "other"
+    category VARCHAR(30) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    -- Detected from the content: image/jpeg, image/png or application/pdf
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    -- SHA-256 of the stored (metadata-stripped) plaintext
+    sha256 VARCHAR(64) NOT NULL,
+    width INT,
+    height INT,
+    caption TEXT,
+
+    blob_key TEXT NOT NULL,
+    wrapped_key TEXT NOT NULL,
+    thumbnail_blob_key TEXT,
+    thumbnail_wrapped_key TEXT,
+
+    uploaded_at TIMESTAMPTZ NOT NULL,
+    uploaded_by VARCHAR(36) NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    deleted_at TIMESTAMPTZ,
+    deleted_by VARCHAR(36),
+
+    PRIMARY KEY (attachment_id)
+);
+
+CREATE INDEX idx_medical_record_attachments_record ON medical_record_attachments(patient_id, record_id);
@@ -0,0 +1,45 @@
+-- Migration: Voice-to-SOAP draft jobs (Emulator Compatible)
+-- FR-AI-001: a recorded visit is transcribed and structured into a draft SOAP
+-- record in the background. The uploaded audio is encrypted like record
+-- attachments (per-object data key, only the wrapped key stored here). The
+-- resulting record is a draft with source_type 'voice_to_text'; the provenance
+-- of each AI-suggested section is kept in soap_content._metadata.aiAssistance
+-- and must be reviewed by a physician before the record can be signed.
+
+CREATE TABLE voice_soap_jobs (
+    job_id VARCHAR(36) NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+
+    -- Visit the draft record is created for
+    visit_started_at TIMESTAMPTZ NOT NULL,
+    visit_type VARCHAR(50) NOT NULL,
+    schedule_id VARCHAR(36),
+    language VARCHAR(10) NOT NULL DEFAULT 'ja',
+
+    -- "pending" | "processing" | "completed" | "failed"
+    job_status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+
+    audio_content_type VARCHAR(100) NOT NULL,
+    audio_size_bytes BIGINT NOT NULL,
+    audio_blob_key TEXT NOT NULL,
+    audio_wrapped_key TEXT NOT NULL,
+
+    -- Results
+    transcript TEXT,
+    transcriber_model VARCHAR(100),
+    structurer_model VARCHAR(100),
+    record_id VARCHAR(36),
+    error_message TEXT,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(36) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+
+    PRIMARY KEY (job_id)
+);
+
+CREATE INDEX idx_voice_soap_jobs_patient ON voice_soap_jobs(patient_id, created_at DESC);
+CREATE INDEX idx_voice_soap_jobs_status ON voice_soap_jobs(job_status, updated_at);
@@ -0,0 +1,38 @@
+-- Migration: Statutory documents (Emulator Compatible)
+-- FR-DOC-001: 訪問看護指示書 and 居宅療養管理指導書 issued from patient records.
+-- Each field value is a snapshot taken at issue time, stored with the records it
+-- was produced from (patient, medical_conditions, medication_orders,
+-- patient_coverages, clinical_observations, staff_members or manual entry) and
+-- the updated_at of the version used. The rendered PDF is encrypted in blob
+-- storage like record attachments; only the wrapped data key is stored here.
+-- Issuing a document supersedes the issued documents of the same type whose
+-- validity period (指示期間) overlaps it.
+
+CREATE TABLE statutory_documents (
+    document_id VARCHAR(36) NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+
+    -- "home_nursing_instruction" | "home_care_guidance"
+    document_type VARCHAR(50) NOT NULL,
+    -- "issued" | "superseded"
+    document_status VARCHAR(20) NOT NULL DEFAULT 'issued',
+    valid_from DATE NOT NULL,
+    valid_to DATE NOT NULL,
+
+    -- [{key, label, value, sources: [{type, id, updated_at}]}] in form order
+    fields JSONB NOT NULL,
+
+    pdf_sha256 VARCHAR(64) NOT NULL,
+    pdf_size_bytes BIGINT NOT NULL,
+    pdf_blob_key TEXT NOT NULL,
+    pdf_wrapped_key TEXT NOT NULL,
+
+    issued_at TIMESTAMPTZ NOT NULL,
+    issued_by VARCHAR(36) NOT NULL,
+    superseded_by VARCHAR(36),
+    superseded_at TIMESTAMPTZ,
+
+    PRIMARY KEY (document_id)
+);
+
+CREATE INDEX idx_statutory_documents_patient ON statutory_documents(patient_id, document_type, valid_from);
@@ -0,0 +1,88 @@
+-- Migration: Referral letters (Emulator Compatible)
+-- FR-DOC-002: Outbound 診療情報提供書 and inbound referral intake.
+-- An outbound letter is drafted from a snapshot of the patient's problems,
+-- medications, allergies and latest SOAP note. Sending renders the PDF, which
+-- is encrypted in blob storage like record attachments, and locks the letter.
+-- An inbound referral stores the received document the same way; the items
+-- extracted from it are mapped into medical_conditions, allergy_intolerances
+-- or medication_orders once staff have reviewed them.
+
+CREATE TABLE referral_letters (
+    referral_id VARCHAR(36) NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+
+    -- "draft" | "sent" | "acknowledged"
+    referral_status VARCHAR(20) NOT NULL DEFAULT 'draft',
+
+    recipient_institution TEXT NOT NULL,
+    recipient_department TEXT,
+    recipient_physician TEXT,
+    purpose TEXT NOT NULL,
+    -- "routine" | "urgent" | "emergent"
+    urgency VARCHAR(20) NOT NULL DEFAULT 'routine',
+    source_record_id VARCHAR(36),
+
+    -- {patient, problems, medications, allergies, latest_soap}
+    summary JSONB NOT NULL,
+    remarks TEXT,
+
+    sender_institution TEXT,
+    sender_address TEXT,
+    sender_phone VARCHAR(50),
+
+    -- Set when the letter is sent
+    pdf_sha256 VARCHAR(64),
+    pdf_size_bytes BIGINT,
+    pdf_blob_key TEXT,
+    pdf_wrapped_key TEXT,
+    sent_at TIMESTAMPTZ,
+    sent_by VARCHAR(36),
+    -- "fax" | "mail" | "hand" | "electronic"
+    sent_method VARCHAR(20),
+
+    acknowledged_at TIMESTAMPTZ,
+    acknowledged_by VARCHAR(36),
+    acknowledgement_note TEXT,
+
+    created_at TIMESTAMPTZ NOT NULL,
+    created_by VARCHAR(36) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    updated_by VARCHAR(36) NOT NULL,
+
+    PRIMARY KEY (referral_id)
+);
+
+CREATE INDEX idx_referral_letters_patient ON referral_letters(patient_id, created_at);
+
+CREATE TABLE inbound_referrals (
+    intake_id VARCHAR(36) NOT NULL,
+    patient_id VARCHAR(36) NOT NULL,
+
+    -- "pending_review" | "reviewed"
+    intake_status VARCHAR(20) NOT NULL DEFAULT 'pending_review',
+
+    referring_institution TEXT NOT NULL,
+    referring_physician TEXT,
+    referral_date DATE,
+    purpose TEXT,
+
+    file_name TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    blob_key TEXT NOT NULL,
+    wrapped_key TEXT NOT NULL,
+
+    -- [{item_id, kind, text, status, resource_id, reviewed_by, reviewed_at, note}]
+    items JSONB NOT NULL,
+
+    received_at TIMESTAMPTZ NOT NULL,
+    received_by VARCHAR(36) NOT NULL,
+    reviewed_at TIMESTAMPTZ,
+    reviewed_by VARCHAR(36),
+    updated_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (intake_id)
+);
+
+CREATE INDEX idx_inbound_referrals_patient ON inbound_referrals(patient_id, received_at);
@@ -0,0 +1,6 @@
+-- Migration: Medical record search (Emulator Compatible)
+-- The search index is kept in process and refreshed by reading the records
+-- changed since its last refresh, including deleted ones so they drop out
+-- of the index, in the order they changed.
+
+CREATE INDEX idx_medical_records_updated ON medical_records(updated_at, record_id);
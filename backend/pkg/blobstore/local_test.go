@@ -0,0 +1,42 @@
+package blobstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir)
+	require.NoError(t, err)
+
+	key := "medical-records/record-1/attachment-1"
+	require.NoError(t, store.Put(ctx, key, []byte("v1"), "application/octet-stream"))
+	require.NoError(t, store.Put(ctx, key, []byte("v2"), "application/octet-stream"))
+
+	data, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), data)
+
+	info, err := os.Stat(filepath.Join(dir, "medical-records", "record-1", "attachment-1"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, store.Delete(ctx, key))
+	_, err = store.Get(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, key), "deleting a missing blob")
+}
+
+func TestValidateKey(t *testing.T) {
+	assert.NoError(t, ValidateKey("medical-records/record-1/attachment-1"))
+	for _, key := range []string{"", "/etc/passwd", "a/../../b", "a//b", "./a", `a\b`} {
+		assert.Error(t, ValidateKey(key), key)
+	}
+}
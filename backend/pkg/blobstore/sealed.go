@@ -0,0 +1,98 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/visitas/backend/pkg/encryption"
+)
+
+// ErrDigestMismatch is returned when opened content does not match its recorded SHA-256
+var ErrDigestMismatch = errors.New("blob content does not match its recorded digest")
+
+// Sealed describes an encrypted object: where it is stored, its data key wrapped and
+// base64-encoded, and the hex SHA-256 and size of its plaintext
+type Sealed struct {
+	Key        string
+	WrappedKey string
+	SHA256     string
+	SizeBytes  int64
+}
+
+// SealedStore encrypts each object under its own data key before it reaches a BlobStore
+// and checks it on the way back. The caller's additional authenticated data (AAD) names
+// the record that owns an object, so a blob copied over another record's fails to open.
+type SealedStore struct {
+	blobs    BlobStore
+	envelope *encryption.EnvelopeEncryptor
+}
+
+// NewSealedStore creates a sealed store. It returns nil when either blobs or envelope is
+// nil, so callers can treat a nil store as storage not being configured.
+func NewSealedStore(blobs BlobStore, envelope *encryption.EnvelopeEncryptor) *SealedStore {
+	if blobs == nil || envelope == nil {
+		return nil
+	}
+	return &SealedStore{blobs: blobs, envelope: envelope}
+}
+
+// AAD joins the identifiers of an object's owner into additional authenticated data
+func AAD(parts ...string) []byte {
+	return []byte(strings.Join(parts, "/"))
+}
+
+// Put encrypts data and stores it under key
+func (s *SealedStore) Put(ctx context.Context, key string, data, aad []byte) (*Sealed, error) {
+	ciphertext, wrappedKey, err := s.envelope.Seal(ctx, data, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt blob: %w", err)
+	}
+	if err := s.blobs.Put(ctx, key, ciphertext, "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("failed to store blob: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return &Sealed{
+		Key:        key,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrappedKey),
+		SHA256:     hex.EncodeToString(sum[:]),
+		SizeBytes:  int64(len(data)),
+	}, nil
+}
+
+// Get reads and decrypts an object. Its plaintext is checked against sealed.SHA256 unless
+// that is empty. A missing object is reported as ErrNotFound.
+func (s *SealedStore) Get(ctx context.Context, sealed Sealed, aad []byte) ([]byte, error) {
+	ciphertext, err := s.blobs.Get(ctx, sealed.Key)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(sealed.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wrapped key: %w", err)
+	}
+	data, err := s.envelope.Open(ctx, ciphertext, wrappedKey, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt blob: %w", err)
+	}
+
+	if sealed.SHA256 != "" {
+		sum := sha256.Sum256(data)
+		want, err := hex.DecodeString(sealed.SHA256)
+		if err != nil || !bytes.Equal(sum[:], want) {
+			return nil, ErrDigestMismatch
+		}
+	}
+	return data, nil
+}
+
+// Delete removes an object; deleting a missing object is not an error
+func (s *SealedStore) Delete(ctx context.Context, key string) error {
+	return s.blobs.Delete(ctx, key)
+}
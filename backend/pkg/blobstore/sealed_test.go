@@ -0,0 +1,74 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/pkg/encryption"
+)
+
+func newTestSealedStore(t *testing.T) (*SealedStore, *LocalStore) {
+	masterKey := make([]byte, 32)
+	_, err := rand.Read(masterKey)
+	require.NoError(t, err)
+	wrapper, err := encryption.NewLocalKeyWrapper(base64.StdEncoding.EncodeToString(masterKey))
+	require.NoError(t, err)
+	blobs, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	return NewSealedStore(blobs, encryption.NewEnvelopeEncryptor(wrapper)), blobs
+}
+
+func TestSealedStore(t *testing.T) {
+	ctx := context.Background()
+	store, blobs := newTestSealedStore(t)
+	plaintext := []byte("褥瘡の写真")
+	aad := AAD("patient-1", "record-1", "attachment-1")
+
+	sealed, err := store.Put(ctx, "medical-records/record-1/attachment-1", plaintext, aad)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(plaintext)), sealed.SizeBytes)
+
+	stored, err := blobs.Get(ctx, sealed.Key)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stored), string(plaintext))
+
+	t.Run("Round trip", func(t *testing.T) {
+		data, err := store.Get(ctx, *sealed, aad)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, data)
+	})
+
+	t.Run("Another owner's AAD does not open it", func(t *testing.T) {
+		_, err := store.Get(ctx, *sealed, AAD("patient-2", "record-1", "attachment-1"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Content must match its digest", func(t *testing.T) {
+		other := *sealed
+		other.SHA256 = "00"
+		_, err := store.Get(ctx, other, aad)
+		assert.ErrorIs(t, err, ErrDigestMismatch)
+
+		other.SHA256 = ""
+		data, err := store.Get(ctx, other, aad)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, data)
+	})
+
+	t.Run("Missing object", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, sealed.Key))
+		_, err := store.Get(ctx, *sealed, aad)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestNewSealedStoreUnconfigured(t *testing.T) {
+	blobs, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, NewSealedStore(blobs, nil))
+	assert.Nil(t, NewSealedStore(nil, nil))
+}
@@ -0,0 +1,170 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+)
+
+// dataKeySize is the size of per-object AES-256 data encryption keys
+const dataKeySize = 32
+
+// KeyWrapper encrypts (wraps) and decrypts (unwraps) data encryption keys with a key
+// encryption key that never leaves the wrapper
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrappedKey []byte) ([]byte, error)
+}
+
+// dataKeyAAD binds wrapped data keys to their purpose
+var dataKeyAAD = []byte("data-encryption-key")
+
+// WrapKey wraps a data encryption key with the KMS key
+func (e *KMSEncryptor) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	result, err := e.client.Encrypt(ctx, &kmspb.EncryptRequest{
+		Name:                        e.keyName,
+		Plaintext:                   dataKey,
+		AdditionalAuthenticatedData: dataKeyAAD,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return result.Ciphertext, nil
+}
+
+// UnwrapKey unwraps a data encryption key with the KMS key
+func (e *KMSEncryptor) UnwrapKey(ctx context.Context, wrappedKey []byte) ([]byte, error) {
+	result, err := e.client.Decrypt(ctx, &kmspb.DecryptRequest{
+		Name:                        e.keyName,
+		Ciphertext:                  wrappedKey,
+		AdditionalAuthenticatedData: dataKeyAAD,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return result.Plaintext, nil
+}
+
+// LocalKeyWrapper wraps data keys with an AES-256 master key held in process memory.
+// It stands in for KMS in development and emulator setups.
+type LocalKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewLocalKeyWrapper creates a wrapper from a base64-encoded 32-byte master key
+func NewLocalKeyWrapper(masterKeyBase64 string) (*LocalKeyWrapper, error) {
+	masterKey, err := base64.StdEncoding.DecodeString(masterKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode master key: %w", err)
+	}
+	if len(masterKey) != dataKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", dataKeySize, len(masterKey))
+	}
+	aead, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKeyWrapper{aead: aead}, nil
+}
+
+// WrapKey wraps a data encryption key with the master key
+func (w *LocalKeyWrapper) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	return seal(w.aead, dataKey, dataKeyAAD)
+}
+
+// UnwrapKey unwraps a data encryption key with the master key
+func (w *LocalKeyWrapper) UnwrapKey(ctx context.Context, wrappedKey []byte) ([]byte, error) {
+	dataKey, err := open(w.aead, wrappedKey, dataKeyAAD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// EnvelopeEncryptor encrypts each object with its own random AES-256-GCM data key and
+// stores that key wrapped by a KeyWrapper. Rotating or revoking the wrapping key never
+// requires re-encrypting objects, and a leaked data key exposes a single object.
+type EnvelopeEncryptor struct {
+	wrapper KeyWrapper
+}
+
+// NewEnvelopeEncryptor creates an envelope encryptor over a key wrapper
+func NewEnvelopeEncryptor(wrapper KeyWrapper) *EnvelopeEncryptor {
+	return &EnvelopeEncryptor{wrapper: wrapper}
+}
+
+// Seal encrypts plaintext under a fresh data key. The same aad must be given to Open;
+// binding it to the object's identity stops ciphertexts being swapped between objects.
+func (e *EnvelopeEncryptor) Seal(ctx context.Context, plaintext, aad []byte) (ciphertext, wrappedKey []byte, err error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err = seal(aead, plaintext, aad)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrappedKey, err = e.wrapper.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext, wrappedKey, nil
+}
+
+// Open decrypts a ciphertext produced by Seal
+func (e *EnvelopeEncryptor) Open(ctx context.Context, ciphertext, wrappedKey, aad []byte) ([]byte, error) {
+	dataKey, err := e.wrapper.UnwrapKey(ctx, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(aead, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt object: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return aead, nil
+}
+
+// seal encrypts with a random nonce, which is prepended to the ciphertext
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, aad)
+}
@@ -0,0 +1,68 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEnvelope(t *testing.T) *EnvelopeEncryptor {
+	masterKey := make([]byte, 32)
+	_, err := rand.Read(masterKey)
+	require.NoError(t, err)
+	wrapper, err := NewLocalKeyWrapper(base64.StdEncoding.EncodeToString(masterKey))
+	require.NoError(t, err)
+	return NewEnvelopeEncryptor(wrapper)
+}
+
+func TestEnvelopeEncryptor(t *testing.T) {
+	ctx := context.Background()
+	envelope := newTestEnvelope(t)
+	plaintext := []byte("褥瘡の写真")
+	aad := []byte("attachment-1")
+
+	ciphertext, wrappedKey, err := envelope.Seal(ctx, plaintext, aad)
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), string(plaintext))
+
+	t.Run("Round trip", func(t *testing.T) {
+		decrypted, err := envelope.Open(ctx, ciphertext, wrappedKey, aad)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("Each object gets its own data key", func(t *testing.T) {
+		_, otherKey, err := envelope.Seal(ctx, plaintext, aad)
+		require.NoError(t, err)
+		assert.NotEqual(t, wrappedKey, otherKey)
+	})
+
+	t.Run("Wrong AAD", func(t *testing.T) {
+		_, err := envelope.Open(ctx, ciphertext, wrappedKey, []byte("attachment-2"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Tampered ciphertext", func(t *testing.T) {
+		tampered := append([]byte(nil), ciphertext...)
+		tampered[len(tampered)-1] ^= 0xff
+		_, err := envelope.Open(ctx, tampered, wrappedKey, aad)
+		assert.Error(t, err)
+	})
+
+	t.Run("Different master key", func(t *testing.T) {
+		_, err := newTestEnvelope(t).Open(ctx, ciphertext, wrappedKey, aad)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewLocalKeyWrapper(t *testing.T) {
+	_, err := NewLocalKeyWrapper(base64.StdEncoding.EncodeToString([]byte("too short")))
+	assert.Error(t, err)
+
+	_, err = NewLocalKeyWrapper("not base64!")
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+// Package geo provides small geographic helpers used for routing and
+// service-area checks. Distances use the haversine formula on a spherical
+// earth, which is accurate to well under 1% at the scale of a visit route.
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean earth radius used for distance calculations
+const EarthRadiusKm = 6371.0
+
+// Point is a latitude/longitude pair in decimal degrees
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Valid reports whether the point lies within the legal coordinate range
+func (p Point) Valid() bool {
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
+
+// DistanceKm returns the great-circle distance between two points in kilometres
+func DistanceKm(a, b Point) float64 {
+	lat1 := toRadians(a.Latitude)
+	lat2 := toRadians(b.Latitude)
+	dLat := lat2 - lat1
+	dLng := toRadians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// DistanceMeters returns the great-circle distance between two points in metres
+func DistanceMeters(a, b Point) float64 {
+	return DistanceKm(a, b) * 1000
+}
+
+// WithinRadius reports whether p lies within radiusKm of center
+func WithinRadius(center, p Point, radiusKm float64) bool {
+	return DistanceKm(center, p) <= radiusKm
+}
+
+// InPolygon reports whether p lies inside the polygon using ray casting.
+// The polygon does not need to be explicitly closed; points on an edge may
+// be reported either way.
+func InPolygon(p Point, polygon []Point) bool {
+	if len(polygon) < 3 {
+		return false
+	}
+
+	inside := false
+	j := len(polygon) - 1
+	for i := 0; i < len(polygon); i++ {
+		vi, vj := polygon[i], polygon[j]
+		if (vi.Latitude > p.Latitude) != (vj.Latitude > p.Latitude) {
+			crossLng := (vj.Longitude-vi.Longitude)*(p.Latitude-vi.Latitude)/(vj.Latitude-vi.Latitude) + vi.Longitude
+			if p.Longitude < crossLng {
+				inside = !inside
+			}
+		}
+		j = i
+	}
+
+	return inside
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,53 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tokyo := Point{Latitude: 35.681236, Longitude: 139.767125}    // 東京駅
+	shinjuku := Point{Latitude: 35.690921, Longitude: 139.700258} // 新宿駅
+	osaka := Point{Latitude: 34.702485, Longitude: 135.495951}    // 大阪駅
+
+	assert.InDelta(t, 0, DistanceKm(tokyo, tokyo), 1e-9)
+	assert.InDelta(t, 6.1, DistanceKm(tokyo, shinjuku), 0.2)
+	assert.InDelta(t, 403, DistanceKm(tokyo, osaka), 5)
+	assert.InDelta(t, DistanceKm(tokyo, osaka), DistanceKm(osaka, tokyo), 1e-9)
+}
+
+func TestWithinRadius(t *testing.T) {
+	center := Point{Latitude: 35.681236, Longitude: 139.767125}
+	near := Point{Latitude: 35.690921, Longitude: 139.700258}
+
+	assert.True(t, WithinRadius(center, near, 10))
+	assert.False(t, WithinRadius(center, near, 5))
+}
+
+func TestInPolygon(t *testing.T) {
+	square := []Point{
+		{Latitude: 35.0, Longitude: 139.0},
+		{Latitude: 35.0, Longitude: 140.0},
+		{Latitude: 36.0, Longitude: 140.0},
+		{Latitude: 36.0, Longitude: 139.0},
+	}
+
+	tests := []struct {
+		name     string
+		point    Point
+		polygon  []Point
+		expected bool
+	}{
+		{name: "Center of square", point: Point{Latitude: 35.5, Longitude: 139.5}, polygon: square, expected: true},
+		{name: "West of square", point: Point{Latitude: 35.5, Longitude: 138.5}, polygon: square, expected: false},
+		{name: "North of square", point: Point{Latitude: 36.5, Longitude: 139.5}, polygon: square, expected: false},
+		{name: "Degenerate polygon", point: Point{Latitude: 35.5, Longitude: 139.5}, polygon: square[:2], expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, InPolygon(tt.point, tt.polygon))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package ical writes RFC 5545 iCalendar feeds. It covers the subset needed to
+// publish read-only event feeds: VEVENTs with timed or all-day spans, status and
+// sequence numbers so that subscribed clients update events in place.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// maxLineOctets is the line length limit before folding (RFC 5545 §3.1)
+const maxLineOctets = 75
+
+// Calendar is a VCALENDAR with its events
+type Calendar struct {
+	ProductID string // PRODID, e.g. "-//Visitas//Visit Schedule//JA"
+	Name      string // X-WR-CALNAME shown by most clients
+	Events    []Event
+}
+
+// Event is a VEVENT. Set either Start/End for a timed event or Date for an all-day event.
+type Event struct {
+	UID          string // must stay the same across updates of the same event
+	Sequence     int64  // revision number; clients replace the event when it increases
+	Stamp        time.Time
+	LastModified time.Time
+
+	Start time.Time
+	End   time.Time
+	Date  *civil.Date
+
+	Summary     string
+	Description string
+	Status      string // TENTATIVE | CONFIRMED | CANCELLED
+}
+
+// Write serializes the calendar with CRLF line endings and folded long lines
+func (c *Calendar) Write(w io.Writer) error {
+	lw := &lineWriter{w: w}
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + escapeText(c.ProductID))
+	lw.line("CALSCALE:GREGORIAN")
+	lw.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+	for i := range c.Events {
+		c.Events[i].write(lw)
+	}
+	lw.line("END:VCALENDAR")
+	return lw.err
+}
+
+func (e *Event) write(lw *lineWriter) {
+	lw.line("BEGIN:VEVENT")
+	lw.line("UID:" + escapeText(e.UID))
+	lw.line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+	lw.line("DTSTAMP:" + formatUTC(e.Stamp))
+	if !e.LastModified.IsZero() {
+		lw.line("LAST-MODIFIED:" + formatUTC(e.LastModified))
+	}
+	if e.Date != nil {
+		lw.line("DTSTART;VALUE=DATE:" + formatDate(*e.Date))
+		lw.line("DTEND;VALUE=DATE:" + formatDate(e.Date.AddDays(1)))
+	} else {
+		lw.line("DTSTART:" + formatUTC(e.Start))
+		lw.line("DTEND:" + formatUTC(e.End))
+	}
+	lw.line("SUMMARY:" + escapeText(e.Summary))
+	if e.Description != "" {
+		lw.line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	if e.Status != "" {
+		lw.line("STATUS:" + e.Status)
+	}
+	lw.line("END:VEVENT")
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func formatDate(d civil.Date) string {
+	return fmt.Sprintf("%04d%02d%02d", d.Year, int(d.Month), d.Day)
+}
+
+// escapeText escapes a TEXT value (RFC 5545 §3.3.11)
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, ";", `\;`)
+	s = strings.ReplaceAll(s, ",", `\,`)
+	s = strings.ReplaceAll(s, "\r\n", `\n`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
+
+// fold splits a content line into 75-octet lines joined by CRLF + space,
+// never breaking inside a multi-byte UTF-8 character
+func fold(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var b strings.Builder
+	width, limit := 0, maxLineOctets
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			// Continuation lines start with a space, which counts towards the limit
+			width, limit = 0, maxLineOctets-1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = io.WriteString(lw.w, fold(s)+"\r\n")
+}
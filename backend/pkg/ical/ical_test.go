@@ -0,0 +1,71 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarWrite(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	updated := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	day := civil.Date{Year: 2025, Month: 4, Day: 30}
+
+	cal := &Calendar{
+		ProductID: "-//Visitas//Test//JA",
+		Name:      "Visits",
+		Events: []Event{
+			{
+				UID:      "s-1@visitas",
+				Sequence: 2,
+				Stamp:    updated,
+				Start:    time.Date(2025, 4, 10, 9, 30, 0, 0, jst),
+				End:      time.Date(2025, 4, 10, 10, 15, 0, 0, jst),
+				Summary:  "Home visit; regular, follow-up",
+				Status:   "CONFIRMED",
+			},
+			{
+				UID:     "s-2@visitas",
+				Stamp:   updated,
+				Date:    &day,
+				Summary: "Home visit",
+				Status:  "CANCELLED",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, cal.Write(&buf))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "DTSTART:20250410T003000Z\r\nDTEND:20250410T011500Z\r\n")
+	assert.Contains(t, out, `SUMMARY:Home visit\; regular\, follow-up`)
+	assert.Contains(t, out, "SEQUENCE:2\r\n")
+	// All-day events end on the following day (exclusive)
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20250430\r\nDTEND;VALUE=DATE:20250501\r\n")
+	assert.Contains(t, out, "STATUS:CANCELLED\r\n")
+}
+
+func TestFold(t *testing.T) {
+	assert.Equal(t, "SUMMARY:short", fold("SUMMARY:short"))
+
+	long := "DESCRIPTION:" + strings.Repeat("訪問", 30) // 3-octet runes
+	folded := fold(long)
+	lines := strings.Split(folded, "\r\n")
+	require.Greater(t, len(lines), 1)
+	for i, l := range lines {
+		assert.LessOrEqual(t, len(l), maxLineOctets, "line %d", i)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(l, " "))
+		}
+	}
+	// Unfolding restores the original line
+	assert.Equal(t, long, strings.ReplaceAll(folded, "\r\n ", ""))
+}
@@ -0,0 +1,152 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage is w x h with a red top-left pixel on a white background
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	img.Set(0, 0, color.RGBA{R: 0xFF, A: 0xFF})
+	return img
+}
+
+// exifSegment builds an APP1 segment with GPS-looking text and an orientation tag
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	ifd := make([]byte, 2+12+4)
+	binary.BigEndian.PutUint16(ifd[0:], 1)
+	binary.BigEndian.PutUint16(ifd[2:], 0x0112)
+	binary.BigEndian.PutUint16(ifd[4:], 3) // SHORT
+	binary.BigEndian.PutUint32(ifd[6:], 1)
+	binary.BigEndian.PutUint16(ifd[10:], orientation)
+	payload := append([]byte("Exif\x00\x00"), append(tiff, ifd...)...)
+	payload = append(payload, []byte("GPS 35.6812N 139.7671E")...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// jpegWithExif encodes img and returns it with and without EXIF and comment segments
+func jpegWithExif(t *testing.T, img image.Image, orientation uint16) (withExif, plain []byte) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}))
+	encoded := buf.Bytes()
+
+	comment := []byte{0xFF, 0xFE, 0x00, 0x0A}
+	comment = append(comment, []byte("Patient")...)
+	comment = append(comment, 0)
+
+	out := append([]byte{}, encoded[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	out = append(out, comment...)
+	return append(out, encoded[2:]...), encoded
+}
+
+func TestStripMetadataJPEG(t *testing.T) {
+	t.Run("Upright photo is filtered losslessly", func(t *testing.T) {
+		original, plain := jpegWithExif(t, testImage(8, 4), 1)
+
+		stripped, err := StripMetadata(original, "image/jpeg")
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(stripped, []byte("Exif")))
+		assert.False(t, bytes.Contains(stripped, []byte("GPS")))
+		assert.False(t, bytes.Contains(stripped, []byte("Patient")))
+		assert.Equal(t, plain, stripped)
+
+		w, h, err := Dimensions(stripped)
+		require.NoError(t, err)
+		assert.Equal(t, 8, w)
+		assert.Equal(t, 4, h)
+	})
+
+	t.Run("Rotated photo is turned upright", func(t *testing.T) {
+		original, _ := jpegWithExif(t, testImage(8, 4), 6)
+		stripped, err := StripMetadata(original, "image/jpeg")
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(stripped, []byte("GPS")))
+
+		img, err := jpeg.Decode(bytes.NewReader(stripped))
+		require.NoError(t, err)
+		assert.Equal(t, 4, img.Bounds().Dx())
+		assert.Equal(t, 8, img.Bounds().Dy())
+		// Rotating 90° clockwise moves the top-left pixel to the top-right
+		r, g, _, _ := img.At(3, 0).RGBA()
+		assert.Greater(t, r, g)
+	})
+
+	t.Run("Not a JPEG", func(t *testing.T) {
+		_, err := StripMetadata([]byte("%PDF-1.7"), "image/jpeg")
+		assert.Error(t, err)
+	})
+}
+
+func TestStripMetadataPNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(4, 4)))
+	encoded := buf.Bytes()
+
+	chunk := func(typ, data string) []byte {
+		c := make([]byte, 8, 12+len(data))
+		binary.BigEndian.PutUint32(c, uint32(len(data)))
+		copy(c[4:], typ)
+		c = append(c, data...)
+		return binary.BigEndian.AppendUint32(c, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+	}
+	// Insert metadata chunks after IHDR (signature 8 + IHDR 25 bytes)
+	original := append([]byte{}, encoded[:33]...)
+	original = append(original, chunk("tEXt", "Author\x00Nurse Sato")...)
+	original = append(original, chunk("eXIf", "MM\x00\x2aGPS")...)
+	original = append(original, encoded[33:]...)
+
+	stripped, err := StripMetadata(original, "image/png")
+	require.NoError(t, err)
+	assert.Equal(t, encoded, stripped)
+
+	_, err = png.Decode(bytes.NewReader(stripped))
+	assert.NoError(t, err)
+}
+
+func TestStripMetadataOtherTypes(t *testing.T) {
+	pdf := []byte("%PDF-1.7 scanned referral")
+	stripped, err := StripMetadata(pdf, "application/pdf")
+	require.NoError(t, err)
+	assert.Equal(t, pdf, stripped)
+}
+
+func TestThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(600, 300)))
+
+	thumb, err := Thumbnail(buf.Bytes(), 200)
+	require.NoError(t, err)
+	w, h, err := Dimensions(thumb)
+	require.NoError(t, err)
+	assert.Equal(t, 200, w)
+	assert.Equal(t, 100, h)
+
+	t.Run("Small images keep their size", func(t *testing.T) {
+		buf.Reset()
+		require.NoError(t, png.Encode(&buf, testImage(50, 80)))
+		thumb, err := Thumbnail(buf.Bytes(), 200)
+		require.NoError(t, err)
+		w, h, err := Dimensions(thumb)
+		require.NoError(t, err)
+		assert.Equal(t, 50, w)
+		assert.Equal(t, 80, h)
+	})
+}
@@ -0,0 +1,271 @@
+// Package jobqueue is a small in-process background job runner: a bounded
+// queue drained by a fixed worker pool, with per-job retry/backoff and
+// cancellation. Jobs live only in memory; callers persist their own status.
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when the queue has no free capacity
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrClosed is returned by Submit after Shutdown has started
+	ErrClosed = errors.New("job queue is closed")
+	// ErrDuplicateJob is returned by Submit when a job with the same ID is queued or running
+	ErrDuplicateJob = errors.New("job is already queued")
+)
+
+// Job is a unit of background work
+type Job struct {
+	ID string
+
+	// Run performs the work; attempt starts at 1. Returning a Permanent error stops retries.
+	Run func(ctx context.Context, attempt int) error
+
+	// OnRetry is called before a failed attempt is retried (optional)
+	OnRetry func(attempt int, err error)
+
+	// OnDone is called exactly once with the final error: nil on success,
+	// context.Canceled when the job was cancelled (optional)
+	OnDone func(err error)
+}
+
+// Options configures a Queue
+type Options struct {
+	Workers     int           // number of concurrent workers (default 2)
+	Capacity    int           // max queued jobs (default 100)
+	MaxAttempts int           // attempts per job including the first (default 3)
+	BaseBackoff time.Duration // delay before the first retry, doubled each retry (default 2s)
+	MaxBackoff  time.Duration // upper bound for the retry delay (default 1m)
+	JobTimeout  time.Duration // per-attempt timeout, 0 for none
+}
+
+// Queue runs submitted jobs on a worker pool
+type Queue struct {
+	opts  Options
+	jobs  chan *entry
+	ctx   context.Context
+	stop  context.CancelFunc
+	wg    sync.WaitGroup
+	once  sync.Once
+	mu    sync.Mutex
+	alive map[string]context.CancelFunc // queued or running jobs
+	done  bool
+}
+
+// entry is a queued job with its own cancellable context
+type entry struct {
+	job *Job
+	ctx context.Context
+}
+
+// New creates a queue and starts its workers
+func New(opts Options) *Queue {
+	if opts.Workers <= 0 {
+		opts.Workers = 2
+	}
+	if opts.Capacity <= 0 {
+		opts.Capacity = 100
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 2 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Minute
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	q := &Queue{
+		opts:  opts,
+		jobs:  make(chan *entry, opts.Capacity),
+		ctx:   ctx,
+		stop:  stop,
+		alive: make(map[string]context.CancelFunc),
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+
+	return q
+}
+
+// Submit enqueues a job without blocking
+func (q *Queue) Submit(job *Job) error {
+	if job == nil || job.ID == "" || job.Run == nil {
+		return fmt.Errorf("job requires an ID and a Run function")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.done {
+		return ErrClosed
+	}
+	if _, exists := q.alive[job.ID]; exists {
+		return ErrDuplicateJob
+	}
+
+	ctx, cancel := context.WithCancel(q.ctx)
+	select {
+	case q.jobs <- &entry{job: job, ctx: ctx}:
+		q.alive[job.ID] = cancel
+		return nil
+	default:
+		cancel()
+		return ErrQueueFull
+	}
+}
+
+// Cancel stops a queued or running job. It reports false when the job is unknown
+// to the queue (already finished, or never submitted).
+func (q *Queue) Cancel(id string) bool {
+	q.mu.Lock()
+	cancel, ok := q.alive[id]
+	q.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// Shutdown stops accepting jobs, cancels outstanding work and waits for the
+// workers to exit or ctx to expire
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.once.Do(func() {
+		q.mu.Lock()
+		q.done = true
+		q.mu.Unlock()
+		q.stop()
+		close(q.jobs)
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	for e := range q.jobs {
+		q.process(e)
+	}
+}
+
+// process runs a job through its attempts and reports the outcome
+func (q *Queue) process(e *entry) {
+	err := q.attempts(e.ctx, e.job)
+
+	q.mu.Lock()
+	cancel := q.alive[e.job.ID]
+	delete(q.alive, e.job.ID)
+	q.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+
+	if e.job.OnDone != nil {
+		e.job.OnDone(err)
+	}
+}
+
+func (q *Queue) attempts(ctx context.Context, job *Job) error {
+	var err error
+	for attempt := 1; attempt <= q.opts.MaxAttempts; attempt++ {
+		if ctx.Err() != nil {
+			return context.Canceled
+		}
+
+		err = q.runOnce(ctx, job, attempt)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return context.Canceled
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt == q.opts.MaxAttempts {
+			break
+		}
+
+		if job.OnRetry != nil {
+			job.OnRetry(attempt, err)
+		}
+
+		timer := time.NewTimer(q.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Canceled
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
+
+func (q *Queue) runOnce(ctx context.Context, job *Job, attempt int) (err error) {
+	if q.opts.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.opts.JobTimeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return job.Run(ctx, attempt)
+}
+
+// backoff returns the delay before retrying after the given attempt
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.opts.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= q.opts.MaxBackoff {
+			return q.opts.MaxBackoff
+		}
+	}
+	return delay
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error as not worth retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
@@ -0,0 +1,148 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fastOptions() Options {
+	return Options{Workers: 1, Capacity: 4, MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+}
+
+func waitDone(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not finish")
+		return nil
+	}
+}
+
+func TestQueue_RetriesUntilSuccess(t *testing.T) {
+	q := New(fastOptions())
+	defer q.Shutdown(context.Background())
+
+	var attempts, retries int32
+	done := make(chan error, 1)
+	err := q.Submit(&Job{
+		ID: "job-1",
+		Run: func(ctx context.Context, attempt int) error {
+			atomic.AddInt32(&attempts, 1)
+			if attempt < 3 {
+				return errors.New("transient")
+			}
+			return nil
+		},
+		OnRetry: func(attempt int, err error) { atomic.AddInt32(&retries, 1) },
+		OnDone:  func(err error) { done <- err },
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, waitDone(t, done))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&retries))
+}
+
+func TestQueue_GivesUpAfterMaxAttempts(t *testing.T) {
+	q := New(fastOptions())
+	defer q.Shutdown(context.Background())
+
+	var attempts int32
+	done := make(chan error, 1)
+	require.NoError(t, q.Submit(&Job{
+		ID: "job-1",
+		Run: func(ctx context.Context, attempt int) error {
+			atomic.AddInt32(&attempts, 1)
+			return errors.New("still failing")
+		},
+		OnDone: func(err error) { done <- err },
+	}))
+
+	assert.EqualError(t, waitDone(t, done), "still failing")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestQueue_PermanentErrorIsNotRetried(t *testing.T) {
+	q := New(fastOptions())
+	defer q.Shutdown(context.Background())
+
+	var attempts int32
+	done := make(chan error, 1)
+	require.NoError(t, q.Submit(&Job{
+		ID: "job-1",
+		Run: func(ctx context.Context, attempt int) error {
+			atomic.AddInt32(&attempts, 1)
+			return Permanent(errors.New("bad input"))
+		},
+		OnDone: func(err error) { done <- err },
+	}))
+
+	assert.EqualError(t, waitDone(t, done), "bad input")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestQueue_CancelRunningJob(t *testing.T) {
+	q := New(fastOptions())
+	defer q.Shutdown(context.Background())
+
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	require.NoError(t, q.Submit(&Job{
+		ID: "job-1",
+		Run: func(ctx context.Context, attempt int) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		OnDone: func(err error) { done <- err },
+	}))
+
+	<-started
+	assert.True(t, q.Cancel("job-1"))
+	assert.ErrorIs(t, waitDone(t, done), context.Canceled)
+	assert.False(t, q.Cancel("job-1"))
+}
+
+func TestQueue_SubmitValidation(t *testing.T) {
+	q := New(Options{Workers: 1, Capacity: 1})
+
+	block := make(chan struct{})
+	running := make(chan struct{})
+	run := func(ctx context.Context, attempt int) error {
+		select {
+		case running <- struct{}{}:
+		default:
+		}
+		<-block
+		return nil
+	}
+
+	require.NoError(t, q.Submit(&Job{ID: "running", Run: run}))
+	<-running
+	require.NoError(t, q.Submit(&Job{ID: "queued", Run: run}))
+
+	assert.ErrorIs(t, q.Submit(&Job{ID: "queued", Run: run}), ErrDuplicateJob)
+	assert.ErrorIs(t, q.Submit(&Job{ID: "overflow", Run: run}), ErrQueueFull)
+	assert.Error(t, q.Submit(&Job{ID: "no-run"}))
+
+	close(block)
+	require.NoError(t, q.Shutdown(context.Background()))
+	assert.ErrorIs(t, q.Submit(&Job{ID: "late", Run: run}), ErrClosed)
+}
+
+func TestQueue_Backoff(t *testing.T) {
+	q := &Queue{opts: Options{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second}}
+
+	assert.Equal(t, time.Second, q.backoff(1))
+	assert.Equal(t, 2*time.Second, q.backoff(2))
+	assert.Equal(t, 4*time.Second, q.backoff(3))
+	assert.Equal(t, 5*time.Second, q.backoff(4))
+}
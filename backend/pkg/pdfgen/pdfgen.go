@@ -0,0 +1,314 @@
+// Package pdfgen writes simple PDF 1.4 documents: A4 pages with text, lines and
+// rectangles, enough to lay out fixed forms. Text uses the standard Adobe-Japan1 CID
+// font HeiseiKakuGo-W5, which PDF viewers substitute with an installed Japanese font,
+// so no font file is embedded. Characters outside the Basic Multilingual Plane are
+// printed as 〓. Output is deterministic: the same pages and info give the same bytes.
+package pdfgen
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// A4 page size in millimetres
+const (
+	PageWidth  = 210.0
+	PageHeight = 297.0
+)
+
+const (
+	pointsPerMM = 72 / 25.4
+	fontName    = "HeiseiKakuGo-W5"
+	fontCMap    = "UniJIS-UCS2-HW-H"
+	// missingGlyph replaces characters the UCS-2 CMap cannot address
+	missingGlyph = '〓'
+)
+
+// Info is the document information dictionary
+type Info struct {
+	Title        string
+	Author       string
+	Subject      string
+	CreationDate time.Time
+}
+
+// Document is a PDF under construction
+type Document struct {
+	info  Info
+	pages []*Page
+}
+
+// New creates an empty document
+func New(info Info) *Document {
+	return &Document{info: info}
+}
+
+// AddPage appends a blank A4 portrait page
+func (d *Document) AddPage() *Page {
+	p := &Page{lineWidth: 0.2}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Page is one page. Coordinates are in millimetres from the top-left corner.
+type Page struct {
+	content   bytes.Buffer
+	lineWidth float64
+}
+
+// SetLineWidth sets the stroke width in millimetres for subsequent lines and rectangles
+func (p *Page) SetLineWidth(mm float64) {
+	p.lineWidth = mm
+}
+
+// Text draws s with its baseline at (x, y) in the given font size in points
+func (p *Page) Text(x, y, size float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td <%s> Tj ET\n",
+		num(size), num(x*pointsPerMM), num((PageHeight-y)*pointsPerMM), encodeText(s))
+}
+
+// TextCentered draws s centred horizontally on x
+func (p *Page) TextCentered(x, y, size float64, s string) {
+	p.Text(x-TextWidth(s, size)/2, y, size, s)
+}
+
+// TextBox draws s wrapped to width starting with the first baseline at y, and returns
+// the number of lines drawn. Line breaks in s are kept.
+func (p *Page) TextBox(x, y, width, size, lineHeight float64, s string) int {
+	lines := WrapText(s, width, size)
+	for i, line := range lines {
+		p.Text(x, y+float64(i)*lineHeight, size, line)
+	}
+	return len(lines)
+}
+
+// Line strokes a line from (x1, y1) to (x2, y2)
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(p.lineWidth*pointsPerMM),
+		num(x1*pointsPerMM), num((PageHeight-y1)*pointsPerMM),
+		num(x2*pointsPerMM), num((PageHeight-y2)*pointsPerMM))
+}
+
+// Rect strokes a rectangle whose top-left corner is (x, y)
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s %s %s re S\n", num(p.lineWidth*pointsPerMM),
+		num(x*pointsPerMM), num((PageHeight-y-h)*pointsPerMM), num(w*pointsPerMM), num(h*pointsPerMM))
+}
+
+// TextWidth returns the width of s in millimetres at the given font size in points.
+// Half-width characters are half an em wide and all others a full em.
+func TextWidth(s string, size float64) float64 {
+	var ems float64
+	for _, r := range s {
+		ems += runeWidth(r)
+	}
+	return ems * size / pointsPerMM
+}
+
+// WrapText splits s into lines no wider than width at the given font size. Japanese
+// text is broken between any two characters; runs of half-width characters are kept
+// together at spaces where possible.
+func WrapText(s string, width, size float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		lines = append(lines, wrapParagraph(paragraph, width, size)...)
+	}
+	return lines
+}
+
+func wrapParagraph(s string, width, size float64) []string {
+	limit := width * pointsPerMM / size // in ems
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	start := 0
+	var used float64
+	lastSpace := -1
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		w := runeWidth(r)
+		if used+w > limit && i > start {
+			end := i
+			next := i
+			if lastSpace > start && runeWidth(r) < 1 {
+				// Break at the last space of a half-width run rather than inside a word
+				end, next = lastSpace, lastSpace+1
+			}
+			lines = append(lines, strings.TrimRight(string(runes[start:end]), " "))
+			start, i, used, lastSpace = next, next-1, 0, -1
+			continue
+		}
+		if r == ' ' {
+			lastSpace = i
+		}
+		used += w
+	}
+	return append(lines, string(runes[start:]))
+}
+
+// runeWidth is the advance width of r in ems under the HW CMap
+func runeWidth(r rune) float64 {
+	if (r >= 0x20 && r <= 0x7e) || (r >= 0xff61 && r <= 0xff9f) {
+		return 0.5
+	}
+	return 1
+}
+
+// encodeText encodes s as hex UCS-2 for the UniJIS-UCS2-HW-H CMap
+func encodeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 0x20 {
+			continue
+		}
+		if r > 0xffff || utf16.IsSurrogate(r) {
+			r = missingGlyph
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
+
+// Write serializes the document
+func (d *Document) Write(w io.Writer) error {
+	pw := &pdfWriter{}
+	pw.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Fixed object numbers: 1 catalog, 2 page tree, 3 info, 4-6 font, then pages and contents
+	const catalog, pageTree, info, font, cidFont, descriptor = 1, 2, 3, 4, 5, 6
+	pageObjects := make([]int, len(d.pages))
+	for i := range d.pages {
+		pageObjects[i] = 7 + 2*i
+	}
+
+	pw.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pageTree))
+
+	kids := make([]string, len(pageObjects))
+	for i, n := range pageObjects {
+		kids[i] = fmt.Sprintf("%d 0 R", n)
+	}
+	pw.object(pageTree, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	infoDict := fmt.Sprintf("<< /Producer %s", textString("Visitas"))
+	if d.info.Title != "" {
+		infoDict += " /Title " + textString(d.info.Title)
+	}
+	if d.info.Author != "" {
+		infoDict += " /Author " + textString(d.info.Author)
+	}
+	if d.info.Subject != "" {
+		infoDict += " /Subject " + textString(d.info.Subject)
+	}
+	if !d.info.CreationDate.IsZero() {
+		infoDict += " /CreationDate " + pdfDate(d.info.CreationDate)
+	}
+	pw.object(info, infoDict+" >>")
+
+	pw.object(font, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s-%s /Encoding /%s /DescendantFonts [%d 0 R] >>",
+		fontName, fontCMap, fontCMap, cidFont))
+	// CIDs 231-389 are the half-width Latin and katakana glyphs the HW CMap maps to
+	pw.object(cidFont, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 2 >> "+
+		"/FontDescriptor %d 0 R /DW 1000 /W [231 389 500] >>", fontName, descriptor))
+	pw.object(descriptor, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 "+
+		"/FontBBox [-92 -250 1010 922] /ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 737 /StemV 114 >>", fontName))
+
+	for i, page := range d.pages {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(page.content.Bytes())
+		zw.Close()
+
+		pw.object(pageObjects[i], fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pageTree, num(PageWidth*pointsPerMM), num(PageHeight*pointsPerMM), font, pageObjects[i]+1))
+		pw.stream(pageObjects[i]+1, compressed.Bytes())
+	}
+
+	xref := pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for n := 1; n <= len(pw.offsets); n++ {
+		fmt.Fprintf(&pw.buf, "%010d 00000 n \n", pw.offsets[n])
+	}
+	fmt.Fprintf(&pw.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(pw.offsets)+1, catalog, info, xref)
+
+	_, err := w.Write(pw.buf.Bytes())
+	return err
+}
+
+// Bytes serializes the document into a byte slice
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (pw *pdfWriter) object(n int, body string) {
+	pw.begin(n)
+	fmt.Fprintf(&pw.buf, "%s\nendobj\n", body)
+}
+
+func (pw *pdfWriter) stream(n int, data []byte) {
+	pw.begin(n)
+	fmt.Fprintf(&pw.buf, "<< /Length %d /Filter /FlateDecode >>\nstream\n", len(data))
+	pw.buf.Write(data)
+	pw.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func (pw *pdfWriter) begin(n int) {
+	if pw.offsets == nil {
+		pw.offsets = map[int]int{}
+	}
+	pw.offsets[n] = pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "%d 0 obj\n", n)
+}
+
+// textString encodes s as a UTF-16BE text string with byte order mark
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfDate formats t as a PDF date string, e.g. (D:20250401093000+09'00')
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("(D:%s%c%02d'%02d')", t.Format("20060102150405"), sign, offset/3600, offset%3600/60)
+}
+
+// num formats a coordinate with two decimals and no trailing zeros
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
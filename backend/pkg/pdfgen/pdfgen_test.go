@@ -0,0 +1,75 @@
+package pdfgen
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentWrite(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	doc := New(Info{Title: "訪問看護指示書", CreationDate: time.Date(2025, 4, 1, 9, 30, 0, 0, jst)})
+	page := doc.AddPage()
+	page.Text(15, 20, 18, "訪問看護指示書")
+	page.Rect(15, 30, 180, 20)
+	page.Line(55, 30, 55, 50)
+	doc.AddPage().Text(15, 20, 10, "2/2")
+
+	data, err := doc.Bytes()
+	require.NoError(t, err)
+
+	out := string(data)
+	assert.True(t, strings.HasPrefix(out, "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(out, "%%EOF\n"))
+	assert.Contains(t, out, "/Count 2")
+	assert.Contains(t, out, "/BaseFont /HeiseiKakuGo-W5-UniJIS-UCS2-HW-H")
+	assert.Contains(t, out, "/CreationDate (D:20250401093000+09'00')")
+
+	// Every xref entry points at the start of its object
+	xrefAt := strings.LastIndex(out, "startxref\n")
+	xrefOffset, err := strconv.Atoi(strings.Fields(out[xrefAt+len("startxref\n"):])[0])
+	require.NoError(t, err)
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(out[xrefOffset:], -1)
+	require.Len(t, entries, 10)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		assert.True(t, strings.HasPrefix(out[offset:], strconv.Itoa(i+1)+" 0 obj\n"), "object %d", i+1)
+	}
+
+	// The first page's content stream draws the title as UCS-2
+	streamAt := strings.Index(out, "stream\n") + len("stream\n")
+	zr, err := zlib.NewReader(bytes.NewReader(data[streamAt:]))
+	require.NoError(t, err)
+	content, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "/F1 18 Tf 42.52 785.2 Td <8A2A554F770B8B776307793A66F8> Tj")
+	assert.Contains(t, string(content), "re S")
+
+	again, err := doc.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, data, again, "output must be deterministic")
+}
+
+func TestEncodeText(t *testing.T) {
+	assert.Equal(t, "0041304230A2", encodeText("Aあア"))
+	assert.Equal(t, "3013", encodeText("𠮷"), "characters outside the BMP print as the geta mark")
+	assert.Equal(t, "0041", encodeText("A\t"))
+}
+
+func TestWrapText(t *testing.T) {
+	// A box five ems wide fits five full-width or ten half-width characters
+	width := 5 * 10 / pointsPerMM
+	assert.Equal(t, []string{"あいうえお", "かきく"}, WrapText("あいうえおかきく", width, 10))
+	assert.Equal(t, []string{"一行目", "二行目"}, WrapText("一行目\n二行目", width, 10))
+	assert.Equal(t, []string{"abc", "defghij"}, WrapText("abc defghij", width, 10))
+	assert.Equal(t, []string{""}, WrapText("", width, 10))
+	assert.InDelta(t, 10/pointsPerMM*1.5, TextWidth("aあ", 10), 0.001)
+}
@@ -0,0 +1,89 @@
+// Package ratelimit provides an in-process token bucket limiter keyed by caller.
+// Buckets live only in memory, so limits are per instance rather than global.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows each key Burst requests at once, refilled at one token per Interval
+type Limiter struct {
+	interval time.Duration
+	burst    float64
+	now      func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweepAt time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New creates a limiter. A non-positive burst is treated as 1.
+func New(interval time.Duration, burst int) *Limiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &Limiter{
+		interval: interval,
+		burst:    float64(burst),
+		now:      time.Now,
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token for key. When none is available it returns false and how long
+// until the next token.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = l.refilled(b, now)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) * float64(l.interval))
+	return false, wait
+}
+
+// refilled is the bucket's token count at now, capped at the burst size
+func (l *Limiter) refilled(b *bucket, now time.Time) float64 {
+	if l.interval <= 0 {
+		return l.burst
+	}
+	tokens := b.tokens + float64(now.Sub(b.last))/float64(l.interval)
+	if tokens > l.burst {
+		return l.burst
+	}
+	return tokens
+}
+
+// sweep drops full buckets now and then so idle keys do not accumulate
+func (l *Limiter) sweep(now time.Time) {
+	if now.Before(l.sweepAt) {
+		return
+	}
+	for key, b := range l.buckets {
+		if l.refilled(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.sweepAt = now.Add(time.Minute)
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeClock(l *Limiter, start time.Time) *time.Time {
+	now := start
+	l.now = func() time.Time { return now }
+	return &now
+}
+
+func TestLimiter_BurstThenRefill(t *testing.T) {
+	l := New(10*time.Second, 2)
+	now := fakeClock(l, time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC))
+
+	ok, _ := l.Allow("staff-1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("staff-1")
+	assert.True(t, ok)
+
+	ok, wait := l.Allow("staff-1")
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Second, wait)
+
+	*now = now.Add(4 * time.Second)
+	ok, wait = l.Allow("staff-1")
+	assert.False(t, ok)
+	assert.Equal(t, 6*time.Second, wait)
+
+	*now = now.Add(6 * time.Second)
+	ok, _ = l.Allow("staff-1")
+	assert.True(t, ok)
+}
+
+func TestLimiter_KeysAreIndependent(t *testing.T) {
+	l := New(time.Minute, 1)
+	fakeClock(l, time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC))
+
+	ok, _ := l.Allow("staff-1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("staff-1")
+	assert.False(t, ok)
+
+	ok, _ = l.Allow("staff-2")
+	assert.True(t, ok)
+}
+
+func TestLimiter_SweepsIdleKeys(t *testing.T) {
+	l := New(time.Second, 1)
+	now := fakeClock(l, time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC))
+
+	l.Allow("staff-1")
+	l.Allow("staff-2")
+	assert.Len(t, l.buckets, 2)
+
+	*now = now.Add(2 * time.Minute)
+	l.Allow("staff-3")
+	assert.Len(t, l.buckets, 1)
+}
@@ -0,0 +1,331 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultLimit = 20
+
+	// Characters of context kept before the first match of a snippet, and the snippet's
+	// total length
+	snippetLead   = 30
+	snippetLength = 100
+)
+
+// MemoryIndex is an in-process Index. An inverted index from n-grams to documents
+// narrows the candidates of a query, which are then matched against their text.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*memoryDoc
+	postings map[string]map[string]struct{} // n-gram -> document IDs
+	scopes   map[string]map[string]struct{} // scope -> document IDs
+}
+
+type memoryDoc struct {
+	doc        Document
+	normalized map[string][]rune
+	grams      []string
+}
+
+// NewMemoryIndex creates an empty in-process index
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[string]*memoryDoc),
+		postings: make(map[string]map[string]struct{}),
+		scopes:   make(map[string]map[string]struct{}),
+	}
+}
+
+// Put adds a document or replaces the one with the same ID
+func (m *MemoryIndex) Put(ctx context.Context, doc *Document) error {
+	entry := &memoryDoc{
+		doc:        *doc,
+		normalized: make(map[string][]rune, len(doc.Fields)),
+	}
+	seen := make(map[string]bool)
+	for field, text := range doc.Fields {
+		runes := Normalize(text)
+		entry.normalized[field] = runes
+		for _, gram := range grams(runes) {
+			if !seen[gram] {
+				seen[gram] = true
+				entry.grams = append(entry.grams, gram)
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(doc.ID)
+	m.docs[doc.ID] = entry
+	addPosting(m.scopes, doc.Scope, doc.ID)
+	for _, gram := range entry.grams {
+		addPosting(m.postings, gram, doc.ID)
+	}
+	return nil
+}
+
+// Delete removes a document
+func (m *MemoryIndex) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(id)
+	return nil
+}
+
+// Len returns the number of documents in the index
+func (m *MemoryIndex) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.docs)
+}
+
+func (m *MemoryIndex) remove(id string) {
+	entry, ok := m.docs[id]
+	if !ok {
+		return
+	}
+	delete(m.docs, id)
+	removePosting(m.scopes, entry.doc.Scope, id)
+	for _, gram := range entry.grams {
+		removePosting(m.postings, gram, id)
+	}
+}
+
+func addPosting(postings map[string]map[string]struct{}, key, id string) {
+	ids, ok := postings[key]
+	if !ok {
+		ids = make(map[string]struct{})
+		postings[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func removePosting(postings map[string]map[string]struct{}, key, id string) {
+	ids := postings[key]
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(postings, key)
+	}
+}
+
+// Search returns the documents matching the query
+func (m *MemoryIndex) Search(ctx context.Context, q *Query) (*Result, error) {
+	terms := queryTerms(q.Text)
+	phrases := make(map[string][]rune, len(q.Fields))
+	for field, phrase := range q.Fields {
+		if normalized := strings.TrimSpace(string(Normalize(phrase))); normalized != "" {
+			phrases[field] = []rune(normalized)
+		}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	candidates := m.candidates(q.Scopes, terms, phrases)
+	var hits []Hit
+	for id := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if hit, ok := m.match(m.docs[id], q, terms, phrases); ok {
+			hits = append(hits, hit)
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if q.Sort == SortByRelevance && hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].Time.Equal(hits[j].Time) {
+			return hits[i].Time.After(hits[j].Time)
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	result := &Result{Total: len(hits)}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if q.Offset < len(hits) {
+		end := q.Offset + limit
+		if end > len(hits) {
+			end = len(hits)
+		}
+		result.Hits = hits[q.Offset:end]
+	}
+	return result, nil
+}
+
+// candidates returns the documents in the given scopes that contain every n-gram of the
+// query's terms and phrases. Terms too short to have a bigram don't narrow the set.
+func (m *MemoryIndex) candidates(scopes []string, terms [][]rune, phrases map[string][]rune) map[string]struct{} {
+	candidates := make(map[string]struct{})
+	for _, scope := range scopes {
+		for id := range m.scopes[scope] {
+			candidates[id] = struct{}{}
+		}
+	}
+
+	needles := append([][]rune{}, terms...)
+	for _, phrase := range phrases {
+		needles = append(needles, phrase)
+	}
+	for _, needle := range needles {
+		for _, gram := range grams(needle) {
+			if len([]rune(gram)) < 2 {
+				continue
+			}
+			ids := m.postings[gram]
+			for id := range candidates {
+				if _, ok := ids[id]; !ok {
+					delete(candidates, id)
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// match checks a candidate against the whole query and builds its hit
+func (m *MemoryIndex) match(entry *memoryDoc, q *Query, terms [][]rune, phrases map[string][]rune) (Hit, bool) {
+	doc := entry.doc
+	if !q.From.IsZero() && doc.Time.Before(q.From) {
+		return Hit{}, false
+	}
+	if !q.To.IsZero() && !doc.Time.Before(q.To) {
+		return Hit{}, false
+	}
+	for key, prefixes := range q.Terms {
+		if !hasTermPrefix(doc.Terms[key], prefixes) {
+			return Hit{}, false
+		}
+	}
+
+	matches := make(map[string][]Range)
+	for field, phrase := range phrases {
+		found := occurrences(entry.normalized[field], phrase)
+		if len(found) == 0 {
+			return Hit{}, false
+		}
+		matches[field] = append(matches[field], found...)
+	}
+
+	score := 0.0
+	for _, term := range terms {
+		count := 0
+		for field, text := range entry.normalized {
+			found := occurrences(text, term)
+			count += len(found)
+			matches[field] = append(matches[field], found...)
+		}
+		if count == 0 {
+			return Hit{}, false
+		}
+		score += 1 + math.Log(float64(count))
+	}
+
+	hit := Hit{
+		ID:    doc.ID,
+		Scope: doc.Scope,
+		Time:  doc.Time,
+		Terms: doc.Terms,
+		Score: score,
+	}
+	for field, ranges := range matches {
+		if len(ranges) > 0 {
+			hit.Snippets = append(hit.Snippets, snippet(field, []rune(doc.Fields[field]), ranges))
+		}
+	}
+	sort.Slice(hit.Snippets, func(i, j int) bool { return hit.Snippets[i].Field < hit.Snippets[j].Field })
+	return hit, true
+}
+
+func hasTermPrefix(values, prefixes []string) bool {
+	for _, value := range values {
+		for _, prefix := range prefixes {
+			if len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// occurrences returns the non-overlapping ranges at which needle occurs in text
+func occurrences(text, needle []rune) []Range {
+	var found []Range
+	if len(needle) == 0 {
+		return nil
+	}
+	for i := 0; i+len(needle) <= len(text); {
+		if equalRunes(text[i:i+len(needle)], needle) {
+			found = append(found, Range{Start: i, End: i + len(needle)})
+			i += len(needle)
+			continue
+		}
+		i++
+	}
+	return found
+}
+
+func equalRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// snippet excerpts text around its first match. Line breaks become spaces so the excerpt
+// reads as one line; "…" marks text cut at either end.
+func snippet(field string, text []rune, ranges []Range) Snippet {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	start := ranges[0].Start - snippetLead
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLength
+	if end < ranges[0].End {
+		end = ranges[0].End
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+
+	excerpt := make([]rune, 0, end-start+2)
+	offset := -start
+	if start > 0 {
+		excerpt = append(excerpt, '…')
+		offset++
+	}
+	for _, r := range text[start:end] {
+		if r == '\n' || r == '\r' || r == '\t' {
+			r = ' '
+		}
+		excerpt = append(excerpt, r)
+	}
+	if end < len(text) {
+		excerpt = append(excerpt, '…')
+	}
+
+	s := Snippet{Field: field}
+	last := -1
+	for _, rng := range ranges {
+		if rng.Start < start || rng.End > end || rng.Start < last {
+			continue
+		}
+		s.Highlights = append(s.Highlights, Range{Start: rng.Start + offset, End: rng.End + offset})
+		last = rng.End
+	}
+	s.Text = string(excerpt)
+	return s
+}
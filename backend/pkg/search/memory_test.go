@@ -0,0 +1,136 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIndexSearch(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	day := func(d int) time.Time { return time.Date(2026, 4, d, 10, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, index.Put(ctx, &Document{
+		ID: "r1", Scope: "p1", Time: day(1),
+		Fields: map[string]string{"objective": "仙骨部に褥瘡あり。発赤を認める", "plan": "アムロジピン継続"},
+		Terms:  map[string][]string{"diagnosis_code": {"L89.1"}},
+	}))
+	require.NoError(t, index.Put(ctx, &Document{
+		ID: "r2", Scope: "p1", Time: day(5),
+		Fields: map[string]string{"assessment": "褥瘡は改善傾向。褥瘡ケア継続"},
+		Terms:  map[string][]string{"diagnosis_code": {"L89.0", "I10"}},
+	}))
+	require.NoError(t, index.Put(ctx, &Document{
+		ID: "r3", Scope: "p2", Time: day(3),
+		Fields: map[string]string{"objective": "褥瘡なし"},
+	}))
+
+	t.Run("scoped and newest first", func(t *testing.T) {
+		result, err := index.Search(ctx, &Query{Text: "褥瘡", Scopes: []string{"p1"}})
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Total)
+		assert.Equal(t, "r2", result.Hits[0].ID)
+		assert.Equal(t, "r1", result.Hits[1].ID)
+	})
+
+	t.Run("no scopes matches nothing", func(t *testing.T) {
+		result, err := index.Search(ctx, &Query{Text: "褥瘡"})
+		require.NoError(t, err)
+		assert.Zero(t, result.Total)
+	})
+
+	t.Run("highlights", func(t *testing.T) {
+		result, err := index.Search(ctx, &Query{Text: "褥瘡", Scopes: []string{"p1"}})
+		require.NoError(t, err)
+		s := result.Hits[0].Snippets[0]
+		assert.Equal(t, "assessment", s.Field)
+		assert.Equal(t, "褥瘡は改善傾向。褥瘡ケア継続", s.Text)
+		assert.Equal(t, []Range{{0, 2}, {8, 10}}, s.Highlights)
+	})
+
+	t.Run("every term must match", func(t *testing.T) {
+		result, err := index.Search(ctx, &Query{Text: "褥瘡　発赤", Scopes: []string{"p1", "p2"}})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Total)
+		assert.Equal(t, "r1", result.Hits[0].ID)
+		assert.Len(t, result.Hits[0].Snippets, 1)
+	})
+
+	t.Run("single character term", func(t *testing.T) {
+		result, err := index.Search(ctx, &Query{Text: "赤", Scopes: []string{"p1"}})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Total)
+	})
+
+	t.Run("field phrase", func(t *testing.T) {
+		result, err := index.Search(ctx, &Query{Fields: map[string]string{"plan": "アムロ"}, Scopes: []string{"p1"}})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Total)
+		assert.Equal(t, "plan", result.Hits[0].Snippets[0].Field)
+
+		result, err = index.Search(ctx, &Query{Fields: map[string]string{"objective": "アムロ"}, Scopes: []string{"p1"}})
+		require.NoError(t, err)
+		assert.Zero(t, result.Total)
+	})
+
+	t.Run("term prefix", func(t *testing.T) {
+		result, err := index.Search(ctx, &Query{Terms: map[string][]string{"diagnosis_code": {"l89"}}, Scopes: []string{"p1"}})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Total)
+
+		result, err = index.Search(ctx, &Query{Terms: map[string][]string{"diagnosis_code": {"I10"}}, Scopes: []string{"p1"}})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Total)
+		assert.Equal(t, "r2", result.Hits[0].ID)
+	})
+
+	t.Run("date range", func(t *testing.T) {
+		result, err := index.Search(ctx, &Query{Text: "褥瘡", Scopes: []string{"p1"}, From: day(1), To: day(5)})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Total)
+		assert.Equal(t, "r1", result.Hits[0].ID)
+	})
+
+	t.Run("relevance", func(t *testing.T) {
+		require.NoError(t, index.Put(ctx, &Document{
+			ID: "r4", Scope: "p1", Time: day(9),
+			Fields: map[string]string{"plan": "体位変換"},
+		}))
+		result, err := index.Search(ctx, &Query{Text: "褥瘡", Scopes: []string{"p1"}, Sort: SortByRelevance, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Total)
+		require.Len(t, result.Hits, 1)
+		assert.Equal(t, "r2", result.Hits[0].ID, "two mentions outrank one")
+	})
+}
+
+func TestMemoryIndexReplaceAndDelete(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	query := &Query{Text: "褥瘡", Scopes: []string{"p1"}}
+
+	require.NoError(t, index.Put(ctx, &Document{ID: "r1", Scope: "p1", Fields: map[string]string{"plan": "褥瘡ケア"}}))
+	require.NoError(t, index.Put(ctx, &Document{ID: "r1", Scope: "p1", Fields: map[string]string{"plan": "体位変換"}}))
+	result, err := index.Search(ctx, query)
+	require.NoError(t, err)
+	assert.Zero(t, result.Total, "replaced text no longer matches")
+
+	require.NoError(t, index.Put(ctx, &Document{ID: "r1", Scope: "p1", Fields: map[string]string{"plan": "褥瘡ケア"}}))
+	require.NoError(t, index.Delete(ctx, "r1"))
+	require.NoError(t, index.Delete(ctx, "r1"))
+	result, err = index.Search(ctx, query)
+	require.NoError(t, err)
+	assert.Zero(t, result.Total)
+	assert.Zero(t, index.Len())
+}
+
+func TestSnippetWindow(t *testing.T) {
+	text := []rune("あいうえおかきくけこ" + "さしすせそたちつてと" + "なにぬねのはひふへほ" + "まみむめも褥瘡やゆよ")
+	s := snippet("plan", text, []Range{{35, 37}})
+	assert.Equal(t, '…', []rune(s.Text)[0])
+	assert.Equal(t, "褥瘡", string([]rune(s.Text)[s.Highlights[0].Start:s.Highlights[0].End]))
+}
@@ -0,0 +1,152 @@
+// Package search is a small full-text index over documents made of named text fields.
+// Japanese has no spaces between words, so text is indexed as overlapping character
+// bigrams rather than words: a query term matches wherever it occurs as a substring,
+// after folding full-width letters and digits to ASCII and case. Each document belongs
+// to a scope, and a query only ever matches documents in the scopes it names.
+package search
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Document is a unit of indexing
+type Document struct {
+	ID     string
+	Scope  string              // e.g. the patient a record belongs to
+	Time   time.Time           // for date filters and ordering by recency
+	Fields map[string]string   // searchable text by field name
+	Terms  map[string][]string // exact values, such as codes, that queries filter on
+}
+
+// Sort orders
+const (
+	SortByTime      = "time" // most recent first
+	SortByRelevance = "relevance"
+)
+
+// Query selects documents. Every term of Text must occur in some field; the phrase of
+// each Fields entry must occur in that field; for each Terms key, one of the document's
+// values must start with one of the given values, ignoring case.
+type Query struct {
+	Text   string
+	Scopes []string // documents outside these scopes never match; none matches nothing
+	Fields map[string]string
+	Terms  map[string][]string
+	From   time.Time // inclusive, zero for no bound
+	To     time.Time // exclusive, zero for no bound
+	Sort   string    // SortByTime (default) or SortByRelevance
+	Limit  int       // default 20
+	Offset int
+}
+
+// Result is a page of hits with the total number of matching documents
+type Result struct {
+	Hits  []Hit
+	Total int
+}
+
+// Hit is a matching document with excerpts of the fields that matched
+type Hit struct {
+	ID       string
+	Scope    string
+	Time     time.Time
+	Terms    map[string][]string
+	Score    float64
+	Snippets []Snippet
+}
+
+// Snippet is an excerpt of a field around its first match. Highlights are the matches
+// within Text, as character offsets.
+type Snippet struct {
+	Field      string
+	Text       string
+	Highlights []Range
+}
+
+// Range is a half-open range of character offsets
+type Range struct {
+	Start int
+	End   int
+}
+
+// Index stores documents for searching
+type Index interface {
+	// Put adds a document or replaces the one with the same ID
+	Put(ctx context.Context, doc *Document) error
+	// Delete removes a document; deleting an unknown ID is not an error
+	Delete(ctx context.Context, id string) error
+	Search(ctx context.Context, q *Query) (*Result, error)
+}
+
+// Normalize folds text for matching: full-width ASCII to half-width, the ideographic
+// space to a space, and letters to lower case. Each character maps to exactly one, so
+// offsets into normalized text are offsets into the original.
+func Normalize(text string) []rune {
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = normalizeRune(r)
+	}
+	return runes
+}
+
+func normalizeRune(r rune) rune {
+	switch {
+	case r >= '！' && r <= '～':
+		r -= '！' - '!'
+	case r == '　':
+		return ' '
+	}
+	return unicode.ToLower(r)
+}
+
+// isWordRune reports whether r is part of an indexable run: a letter, digit or the
+// katakana prolonged sound mark
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == 'ー'
+}
+
+// Tokenize returns the n-grams text is indexed under: the character bigrams of each run
+// of letters and digits, or the run itself if it is a single character
+func Tokenize(text string) []string {
+	return grams(Normalize(text))
+}
+
+func grams(runes []rune) []string {
+	var tokens []string
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		if end-start == 1 {
+			tokens = append(tokens, string(runes[start:end]))
+		}
+		for i := start; i+2 <= end; i++ {
+			tokens = append(tokens, string(runes[i:i+2]))
+		}
+		start = -1
+	}
+	for i, r := range runes {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(runes))
+	return tokens
+}
+
+// queryTerms splits query text into normalized terms at whitespace
+func queryTerms(text string) [][]rune {
+	var terms [][]rune
+	for _, term := range strings.Fields(string(Normalize(text))) {
+		terms = append(terms, []rune(term))
+	}
+	return terms
+}
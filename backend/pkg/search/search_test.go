@@ -0,0 +1,21 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"仙骨", "骨部", "部に", "に褥", "褥瘡"}, Tokenize("仙骨部に褥瘡"))
+	assert.Equal(t, []string{"アム", "ムロ", "ロジ", "ジピ", "ピン"}, Tokenize("アムロジピン"))
+	assert.Equal(t, []string{"コー", "ール"}, Tokenize("コール"), "the prolonged sound mark joins the run")
+	assert.Equal(t, []string{"bp", "12", "20"}, Tokenize("ＢＰ 120"), "full-width folded, runs split at spaces")
+	assert.Equal(t, []string{"熱"}, Tokenize("熱。"))
+	assert.Empty(t, Tokenize("、 。"))
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "crp 2.5mg/dl", string(Normalize("ＣＲＰ　２．５mg/dL")))
+	assert.Len(t, Normalize("褥瘡ＩＩ度"), 5, "one character per character")
+}
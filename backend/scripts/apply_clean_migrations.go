@@ -42,8 +42,26 @@ func main() {
 		"migrations/008_create_medication_orders_clean.sql",
 		"migrations/009_create_care_plans_clean.sql",
 		"migrations/011_create_acp_records_clean.sql",
+		"migrations/012_create_logistics_locations_clean.sql",
+		"migrations/013_create_route_optimization_jobs_clean.sql",
 		"migrations/014_create_audit_access_logs_clean.sql",
 		"migrations/016_create_medical_records_clean.sql",
+		"migrations/020_create_visit_schedule_recurrences_clean.sql",
+		"migrations/021_add_visit_schedule_status_tracking_clean.sql",
+		"migrations/022_create_calendar_feed_tokens_clean.sql",
+		"migrations/023_create_staff_location_pings_clean.sql",
+		"migrations/024_create_visit_checks_clean.sql",
+		"migrations/025_add_optimizer_engine_clean.sql",
+		"migrations/026_add_care_plan_schedule_index_clean.sql",
+		"migrations/027_create_care_plan_versions_clean.sql",
+		"migrations/028_create_medical_record_revisions_clean.sql",
+		"migrations/029_add_medical_record_signatures_clean.sql",
+		"migrations/030_add_template_required_sections_clean.sql",
+		"migrations/031_create_medical_record_attachments_clean.sql",
+		"migrations/032_create_voice_soap_jobs_clean.sql",
+		"migrations/033_create_statutory_documents_clean.sql",
+		"migrations/034_create_referrals_clean.sql",
+		"migrations/035_add_medical_record_search_index_clean.sql",
 	}
 
 	fmt.Printf("Applying %d clean migrations...\n\n", len(cleanMigrations))
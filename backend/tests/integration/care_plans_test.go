@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"cloud.google.com/go/civil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/visitas/backend/internal/models"
@@ -363,6 +364,69 @@ func TestCarePlan_Integration_Delete(t *testing.T) {
 	})
 }
 
+func TestCarePlan_Integration_DeleteActivePlan(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	// Setup test server
+	ts := SetupTestServer(t)
+	defer ts.Close()
+
+	// Create a test patient
+	patientID := ts.CreateTestPatient(t)
+
+	// Create an active care plan with a weekly nursing visit
+	periodStart := time.Now().Format(time.RFC3339)
+	periodEnd := time.Now().AddDate(0, 3, 0).Format(time.RFC3339)
+
+	carePlanJSON := fmt.Sprintf(`{
+		"title": "Home Nursing Plan",
+		"status": "active",
+		"intent": "plan",
+		"period_start": "%s",
+		"period_end": "%s",
+		"activities": [
+			{"kind": "home_nursing_visit", "assigned_role": "nurse", "duration_minutes": 60,
+			 "frequency": {"pattern": "weekly", "by_weekday": ["MO", "TH"], "start_time": "10:00"}}
+		]
+	}`, periodStart, periodEnd)
+
+	createResp := ts.MakeRequest(t, http.MethodPost, fmt.Sprintf("/api/v1/patients/%s/care-plans", patientID), strings.NewReader(carePlanJSON))
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+
+	var carePlan models.CarePlan
+	DecodeJSONResponse(t, createResp, &carePlan)
+
+	rules, err := ts.Config.RecurrenceRepo.ListByCarePlan(ts.Context, carePlan.PlanID)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	visits, err := ts.Config.VisitScheduleRepo.ListUpcomingByCarePlan(ts.Context, carePlan.PlanID, civil.DateOf(time.Now()))
+	require.NoError(t, err)
+	require.NotEmpty(t, visits)
+
+	// Test: Deleting the plan ends its rule and cancels its upcoming visits
+	t.Run("Delete active care plan", func(t *testing.T) {
+		deleteResp := ts.MakeRequest(t, http.MethodDelete, fmt.Sprintf("/api/v1/patients/%s/care-plans/%s", patientID, carePlan.PlanID), nil)
+		require.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+
+		rules, err := ts.Config.RecurrenceRepo.ListByCarePlan(ts.Context, carePlan.PlanID)
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+
+		upcoming, err := ts.Config.VisitScheduleRepo.ListUpcomingByCarePlan(ts.Context, carePlan.PlanID, civil.DateOf(time.Now()))
+		require.NoError(t, err)
+		assert.Empty(t, upcoming)
+
+		for _, visit := range visits {
+			schedule, err := ts.Config.VisitScheduleRepo.GetByID(ts.Context, patientID, visit.ScheduleID)
+			require.NoError(t, err)
+			assert.Equal(t, "cancelled", schedule.Status)
+		}
+	})
+}
+
 func TestCarePlan_Integration_ValidationErrors(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test")
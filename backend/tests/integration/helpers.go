@@ -2,6 +2,8 @@ package integration
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,15 +12,22 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/require"
+	"github.com/visitas/backend/internal/ai"
 	"github.com/visitas/backend/internal/config"
 	"github.com/visitas/backend/internal/handlers"
 	"github.com/visitas/backend/internal/middleware"
+	"github.com/visitas/backend/internal/models"
 	"github.com/visitas/backend/internal/repository"
 	"github.com/visitas/backend/internal/services"
+	"github.com/visitas/backend/pkg/blobstore"
+	"github.com/visitas/backend/pkg/encryption"
+	"github.com/visitas/backend/pkg/jobqueue"
+	"github.com/visitas/backend/pkg/search"
 )
 
 // TestConfig holds configuration for integration tests
@@ -26,6 +35,7 @@ type TestConfig struct {
 	SpannerRepo               *repository.SpannerRepository
 	PatientRepo               *repository.PatientRepository
 	VisitScheduleRepo         *repository.VisitScheduleRepository
+	RecurrenceRepo            *repository.VisitScheduleRecurrenceRepository
 	ClinicalObservationRepo   *repository.ClinicalObservationRepository
 	CarePlanRepo              *repository.CarePlanRepository
 	MedicationOrderRepo       *repository.MedicationOrderRepository
@@ -33,6 +43,7 @@ type TestConfig struct {
 	MedicalRecordRepo         *repository.MedicalRecordRepository
 	MedicalRecordTemplateRepo *repository.MedicalRecordTemplateRepository
 	AuditRepo                 *repository.AuditRepository
+	StaffMemberRepo           *repository.StaffMemberRepository
 }
 
 // TestServer wraps the test HTTP server and related resources
@@ -68,26 +79,79 @@ func SetupTestServer(t *testing.T) *TestServer {
 	acpRecordRepo := repository.NewACPRecordRepository(spannerRepo)
 	medicalRecordRepo := repository.NewMedicalRecordRepository(spannerRepo)
 	medicalRecordTemplateRepo := repository.NewMedicalRecordTemplateRepository(spannerRepo)
+	staffMemberRepo := repository.NewStaffMemberRepository(spannerRepo)
+	vehicleRepo := repository.NewVehicleRepository(spannerRepo)
+	visitScheduleRecurrenceRepo := repository.NewVisitScheduleRecurrenceRepository(spannerRepo)
+	medicalRecordAttachmentRepo := repository.NewMedicalRecordAttachmentRepository(spannerRepo)
+	voiceSOAPJobRepo := repository.NewVoiceSOAPJobRepository(spannerRepo)
+	medicalConditionRepo := repository.NewMedicalConditionRepository(spannerRepo)
+	coverageRepo := repository.NewCoverageRepository(spannerRepo)
+	statutoryDocumentRepo := repository.NewStatutoryDocumentRepository(spannerRepo)
+	allergyIntoleranceRepo := repository.NewAllergyIntoleranceRepository(spannerRepo)
+	referralLetterRepo := repository.NewReferralLetterRepository(spannerRepo)
+	inboundReferralRepo := repository.NewInboundReferralRepository(spannerRepo)
+
+	// Attachments are stored under a temporary directory with a throwaway master key
+	attachmentBlobs, err := blobstore.NewLocalStore(t.TempDir())
+	require.NoError(t, err, "Failed to initialize attachment storage")
+	masterKey := make([]byte, 32)
+	_, err = rand.Read(masterKey)
+	require.NoError(t, err)
+	keyWrapper, err := encryption.NewLocalKeyWrapper(base64.StdEncoding.EncodeToString(masterKey))
+	require.NoError(t, err)
+	sealedBlobs := blobstore.NewSealedStore(attachmentBlobs, encryption.NewEnvelopeEncryptor(keyWrapper))
+
+	// Patients can only be assigned to active staff, so make sure the test staff exists
+	ensureTestStaff(t, ctx, staffMemberRepo)
 
 	// Initialize services
-	patientService := services.NewPatientService(patientRepo, assignmentRepo, auditRepo)
-	visitScheduleService := services.NewVisitScheduleService(visitScheduleRepo, patientRepo)
+	patientService := services.NewPatientService(patientRepo, assignmentRepo, auditRepo, staffMemberRepo)
+	visitScheduleService := services.NewVisitScheduleService(visitScheduleRepo, patientRepo, vehicleRepo, visitScheduleRecurrenceRepo, medicalRecordRepo)
+	visitScheduleRecurrenceService := services.NewVisitScheduleRecurrenceService(visitScheduleRecurrenceRepo, visitScheduleRepo, patientRepo, staffMemberRepo, vehicleRepo)
 	clinicalObservationService := services.NewClinicalObservationService(clinicalObservationRepo, patientRepo)
-	carePlanService := services.NewCarePlanService(carePlanRepo, patientRepo)
+	carePlanScheduleService := services.NewCarePlanScheduleService(visitScheduleRecurrenceService, visitScheduleRecurrenceRepo, visitScheduleRepo)
+	carePlanService := services.NewCarePlanService(carePlanRepo, patientRepo, clinicalObservationRepo, carePlanScheduleService)
 	medicationOrderService := services.NewMedicationOrderService(medicationOrderRepo, patientRepo)
 	acpRecordService := services.NewACPRecordService(acpRecordRepo, patientRepo)
-	medicalRecordService := services.NewMedicalRecordService(medicalRecordRepo, patientRepo, medicalRecordTemplateRepo)
+	medicalRecordService := services.NewMedicalRecordService(medicalRecordRepo, patientRepo, medicalRecordTemplateRepo, clinicalObservationRepo)
 	medicalRecordTemplateService := services.NewMedicalRecordTemplateService(medicalRecordTemplateRepo)
+	medicalRecordAttachmentService := services.NewMedicalRecordAttachmentService(medicalRecordAttachmentRepo, medicalRecordRepo, patientRepo, auditRepo, sealedBlobs)
+
+	// Voice drafts run on the stub providers with a single worker and no retry backoff
+	voiceJobQueue := jobqueue.New(jobqueue.Options{
+		Workers:     1,
+		Capacity:    10,
+		MaxAttempts: 1,
+		JobTimeout:  time.Minute,
+	})
+	t.Cleanup(func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		voiceJobQueue.Shutdown(shutdownCtx)
+	})
+	voiceSOAPService := services.NewVoiceSOAPService(voiceSOAPJobRepo, patientRepo, medicalRecordService, sealedBlobs, voiceJobQueue, ai.NewStubTranscriber(""), ai.NewStubStructurer())
+	statutoryDocumentService := services.NewStatutoryDocumentService(statutoryDocumentRepo, patientRepo, medicalConditionRepo, medicationOrderRepo, coverageRepo, clinicalObservationRepo, staffMemberRepo, auditRepo, sealedBlobs)
+	medicalConditionService := services.NewMedicalConditionService(medicalConditionRepo, patientRepo)
+	allergyIntoleranceService := services.NewAllergyIntoleranceService(allergyIntoleranceRepo, patientRepo)
+	referralLetterService := services.NewReferralLetterService(referralLetterRepo, patientRepo, medicalRecordRepo, medicalConditionRepo, medicationOrderRepo, allergyIntoleranceRepo, staffMemberRepo, auditRepo, sealedBlobs)
+	inboundReferralService := services.NewInboundReferralService(inboundReferralRepo, patientRepo, auditRepo, medicalConditionService, allergyIntoleranceService, medicationOrderService, sealedBlobs)
+	medicalRecordSearchService := services.NewMedicalRecordSearchService(medicalRecordRepo, patientRepo, assignmentRepo, auditRepo, search.NewMemoryIndex())
 
 	// Initialize handlers
 	patientHandler := handlers.NewPatientHandler(patientService)
-	visitScheduleHandler := handlers.NewVisitScheduleHandler(visitScheduleService)
+	visitScheduleHandler := handlers.NewVisitScheduleHandler(visitScheduleService, visitScheduleRecurrenceService)
 	clinicalObservationHandler := handlers.NewClinicalObservationHandler(clinicalObservationService)
 	carePlanHandler := handlers.NewCarePlanHandler(carePlanService)
 	medicationOrderHandler := handlers.NewMedicationOrderHandler(medicationOrderService)
 	acpRecordHandler := handlers.NewACPRecordHandler(acpRecordService)
 	medicalRecordHandler := handlers.NewMedicalRecordHandler(medicalRecordService)
 	medicalRecordTemplateHandler := handlers.NewMedicalRecordTemplateHandler(medicalRecordTemplateService)
+	medicalRecordAttachmentHandler := handlers.NewMedicalRecordAttachmentHandler(medicalRecordAttachmentService, 20<<20)
+	voiceSOAPHandler := handlers.NewVoiceSOAPHandler(voiceSOAPService, 20<<20)
+	statutoryDocumentHandler := handlers.NewStatutoryDocumentHandler(statutoryDocumentService)
+	referralLetterHandler := handlers.NewReferralLetterHandler(referralLetterService)
+	inboundReferralHandler := handlers.NewInboundReferralHandler(inboundReferralService, 20<<20)
+	medicalRecordSearchHandler := handlers.NewMedicalRecordSearchHandler(medicalRecordSearchService)
 
 	// Initialize middleware
 	auditMiddleware := middleware.NewAuditLoggerMiddleware(auditRepo)
@@ -129,7 +193,9 @@ func SetupTestServer(t *testing.T) *TestServer {
 			r.Put("/{id}", visitScheduleHandler.UpdateVisitSchedule)
 			r.Delete("/{id}", visitScheduleHandler.DeleteVisitSchedule)
 			r.Post("/{id}/assign-staff", visitScheduleHandler.AssignStaff)
+			r.Post("/{id}/assign-vehicle", visitScheduleHandler.AssignVehicle)
 			r.Post("/{id}/status", visitScheduleHandler.UpdateStatus)
+			r.Get("/{id}/status-history", visitScheduleHandler.GetStatusHistory)
 		})
 
 		// Clinical observation routes
@@ -149,6 +215,10 @@ func SetupTestServer(t *testing.T) *TestServer {
 			r.Post("/", carePlanHandler.CreateCarePlan)
 			r.Get("/active", carePlanHandler.GetActiveCarePlans)
 			r.Get("/{id}", carePlanHandler.GetCarePlan)
+			r.Get("/{id}/goals", carePlanHandler.GetGoalStatus)
+			r.Get("/{id}/versions", carePlanHandler.GetVersions)
+			r.Get("/{id}/versions/diff", carePlanHandler.CompareVersions)
+			r.Get("/{id}/versions/{version}", carePlanHandler.GetVersion)
 			r.Put("/{id}", carePlanHandler.UpdateCarePlan)
 			r.Delete("/{id}", carePlanHandler.DeleteCarePlan)
 		})
@@ -182,9 +252,61 @@ func SetupTestServer(t *testing.T) *TestServer {
 			r.Get("/latest", medicalRecordHandler.GetLatestRecords)
 			r.Get("/{id}", medicalRecordHandler.GetMedicalRecord)
 			r.Put("/{id}", medicalRecordHandler.UpdateMedicalRecord)
+			r.Get("/{id}/revisions", medicalRecordHandler.GetRevisions)
+			r.Get("/{id}/revisions/diff", medicalRecordHandler.CompareRevisions)
+			r.Get("/{id}/revisions/{revision}", medicalRecordHandler.GetRevision)
+			r.Post("/{id}/revisions/{revision}/restore", medicalRecordHandler.RestoreRevision)
+			r.Post("/{id}/sign", medicalRecordHandler.SignMedicalRecord)
+			r.Get("/{id}/verify", medicalRecordHandler.VerifySignature)
+			r.Get("/{id}/addenda", medicalRecordHandler.GetAddenda)
+			r.Post("/{id}/addenda", medicalRecordHandler.CreateAddendum)
+			r.Post("/{id}/ai-review", medicalRecordHandler.ReviewAISuggestions)
+			r.Get("/{id}/attachments", medicalRecordAttachmentHandler.ListAttachments)
+			r.Post("/{id}/attachments", medicalRecordAttachmentHandler.UploadAttachment)
+			r.Get("/{id}/attachments/{attachment_id}/content", medicalRecordAttachmentHandler.GetAttachmentContent)
+			r.Get("/{id}/attachments/{attachment_id}/thumbnail", medicalRecordAttachmentHandler.GetAttachmentThumbnail)
+			r.Delete("/{id}/attachments/{attachment_id}", medicalRecordAttachmentHandler.DeleteAttachment)
 			r.Delete("/{id}", medicalRecordHandler.DeleteMedicalRecord)
 		})
 
+		// Voice-to-SOAP draft routes
+		r.Route("/patients/{patient_id}/voice-drafts", func(r chi.Router) {
+			r.Get("/", voiceSOAPHandler.ListJobs)
+			r.Post("/", voiceSOAPHandler.SubmitRecording)
+			r.Get("/{id}", voiceSOAPHandler.GetJob)
+		})
+
+		// Statutory document routes
+		r.Route("/patients/{patient_id}/documents", func(r chi.Router) {
+			r.Get("/", statutoryDocumentHandler.ListDocuments)
+			r.Post("/", statutoryDocumentHandler.IssueDocument)
+			r.Get("/{id}", statutoryDocumentHandler.GetDocument)
+			r.Get("/{id}/pdf", statutoryDocumentHandler.GetDocumentPDF)
+		})
+
+		// Referral letter routes
+		r.Route("/patients/{patient_id}/referrals", func(r chi.Router) {
+			r.Get("/", referralLetterHandler.ListLetters)
+			r.Post("/", referralLetterHandler.CreateLetter)
+			r.Get("/{id}", referralLetterHandler.GetLetter)
+			r.Put("/{id}", referralLetterHandler.UpdateLetter)
+			r.Delete("/{id}", referralLetterHandler.DeleteLetter)
+			r.Post("/{id}/send", referralLetterHandler.SendLetter)
+			r.Post("/{id}/acknowledge", referralLetterHandler.AcknowledgeLetter)
+			r.Get("/{id}/pdf", referralLetterHandler.GetLetterPDF)
+		})
+
+		// Inbound referral routes
+		r.Route("/patients/{patient_id}/inbound-referrals", func(r chi.Router) {
+			r.Get("/", inboundReferralHandler.ListReferrals)
+			r.Post("/", inboundReferralHandler.ReceiveReferral)
+			r.Get("/{id}", inboundReferralHandler.GetReferral)
+			r.Get("/{id}/document", inboundReferralHandler.GetReferralDocument)
+			r.Post("/{id}/items", inboundReferralHandler.AddItems)
+			r.Post("/{id}/items/{item_id}/accept", inboundReferralHandler.AcceptItem)
+			r.Post("/{id}/items/{item_id}/reject", inboundReferralHandler.RejectItem)
+		})
+
 		// Medical record copy route
 		r.Route("/medical-records/{record_id}", func(r chi.Router) {
 			r.Post("/copy", medicalRecordHandler.CopyMedicalRecord)
@@ -193,6 +315,9 @@ func SetupTestServer(t *testing.T) *TestServer {
 		// Medical record drafts route
 		r.Get("/medical-records/drafts", medicalRecordHandler.GetDraftRecords)
 
+		// Medical record search route
+		r.Get("/medical-records/search", medicalRecordSearchHandler.SearchMedicalRecords)
+
 		// Medical record template routes
 		r.Route("/medical-record-templates", func(r chi.Router) {
 			r.Get("/", medicalRecordTemplateHandler.ListTemplates)
@@ -212,6 +337,7 @@ func SetupTestServer(t *testing.T) *TestServer {
 		SpannerRepo:               spannerRepo,
 		PatientRepo:               patientRepo,
 		VisitScheduleRepo:         visitScheduleRepo,
+		RecurrenceRepo:            visitScheduleRecurrenceRepo,
 		ClinicalObservationRepo:   clinicalObservationRepo,
 		CarePlanRepo:              carePlanRepo,
 		MedicationOrderRepo:       medicationOrderRepo,
@@ -219,6 +345,7 @@ func SetupTestServer(t *testing.T) *TestServer {
 		MedicalRecordRepo:         medicalRecordRepo,
 		MedicalRecordTemplateRepo: medicalRecordTemplateRepo,
 		AuditRepo:                 auditRepo,
+		StaffMemberRepo:           staffMemberRepo,
 	}
 
 	return &TestServer{
@@ -237,6 +364,28 @@ func (ts *TestServer) Close() {
 	}
 }
 
+// ensureTestStaff creates the "test-staff-id" staff member if it does not exist yet
+func ensureTestStaff(t *testing.T, ctx context.Context, staffRepo *repository.StaffMemberRepository) {
+	t.Helper()
+
+	if _, err := staffRepo.GetByID(ctx, "test-staff-id"); err == nil {
+		return
+	}
+
+	staffID := "test-staff-id"
+	_, err := staffRepo.Create(ctx, &models.StaffMemberCreateRequest{
+		StaffID:            &staffID,
+		FamilyName:         "テスト",
+		GivenName:          "医師",
+		Email:              "test-staff@example.com",
+		Role:               "doctor",
+		AvailabilityStatus: "available",
+		AccessLevel:        "standard",
+		AccountStatus:      "active",
+	}, "test-setup")
+	require.NoError(t, err, "Failed to create test staff member")
+}
+
 // testAuthMiddleware adds a test user ID to the context (bypassing real authentication)
 func testAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {